@@ -22,6 +22,11 @@ func main() {
 		logger.L().Fatalf("Failed to load config: %v", err)
 	}
 
+	// 校验配置项之间的依赖关系，列出全部问题后退出
+	if err := cfg.Validate(); err != nil {
+		logger.L().Fatalf("%v", err)
+	}
+
 	// 初始化应用（包含所有服务）
 	application, err := app.New(cfg)
 	if err != nil {
@@ -38,13 +43,17 @@ func main() {
 	db := application.MongoDB.Database()
 	logger.L().Infof("Using database: %s", db.Name())
 
-	// 启动 Telegram Bot（在 goroutine 中运行，因为 Start 是阻塞式的）
-	go func() {
-		logger.L().Info("Starting Telegram bot...")
-		if err := application.TelegramBot.Start(ctx); err != nil {
-			logger.L().Errorf("Telegram bot error: %v", err)
-		}
-	}()
+	// 启动全部 Telegram Bot（每个 Bot 在独立 goroutine 中运行，因为 Start 是阻塞式的）
+	application.StartBots(ctx)
+
+	// 启动管理 API（可选）
+	if application.AdminAPI != nil {
+		go func() {
+			if err := application.AdminAPI.Start(); err != nil {
+				logger.L().Errorf("Admin API error: %v", err)
+			}
+		}()
+	}
 
 	// 等待中断信号（优雅关闭）
 	sigChan := make(chan os.Signal, 1)
@@ -53,13 +62,10 @@ func main() {
 	<-sigChan // 阻塞等待信号
 	logger.L().Info("Received shutdown signal, gracefully shutting down...")
 
-	// 取消 context，通知 bot 停止
+	// 取消 context，通知所有 bot 停止
 	cancel()
 
-	// 等待 bot 停止（给一些时间让 bot 完成当前处理）
-	time.Sleep(2 * time.Second)
-
-	// 关闭所有服务
+	// 关闭所有服务（会等待全部 bot 退出）
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
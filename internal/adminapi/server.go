@@ -0,0 +1,294 @@
+package adminapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/config"
+	"go_bot/internal/logger"
+	"go_bot/internal/metrics"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+const (
+	defaultLogLimit = 20
+	maxLogLimit     = 200
+
+	idempotencyHeader = "Idempotency-Key"
+	maxRequestBody    = 1 << 16
+)
+
+// Server 上游余额管理 HTTP API
+type Server struct {
+	balanceService service.UpstreamBalanceService
+	token          string
+	httpServer     *http.Server
+}
+
+// New 创建管理 API 服务
+func New(cfg config.AdminAPIConfig, balanceService service.UpstreamBalanceService) (*Server, error) {
+	if balanceService == nil {
+		return nil, errors.New("balance service is required")
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, errors.New("admin api token is required")
+	}
+
+	s := &Server{
+		balanceService: balanceService,
+		token:          token,
+	}
+	s.httpServer = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s, nil
+}
+
+// Handler 返回带鉴权的路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /groups/{id}/balance", s.handleGetBalance)
+	mux.HandleFunc("POST /groups/{id}/adjust", s.handleAdjust)
+	mux.HandleFunc("GET /groups/{id}/logs", s.handleListLogs)
+	mux.Handle("GET /metrics", metrics.Handler())
+	return s.requireToken(mux)
+}
+
+// Start 启动 HTTP 服务（阻塞式，应在 goroutine 中运行）
+func (s *Server) Start() error {
+	logger.L().Infof("Admin API listening on %s", s.httpServer.Addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop 优雅关闭 HTTP 服务
+func (s *Server) Stop(ctx context.Context) error {
+	logger.L().Info("Stopping admin API...")
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		provided, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type balanceResponse struct {
+	GroupID           int64     `json:"group_id"`
+	Balance           float64   `json:"balance"`
+	MinBalance        float64   `json:"min_balance"`
+	AlertLimitPerHour int       `json:"alert_limit_per_hour"`
+	BelowMin          bool      `json:"below_min"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type adjustRequest struct {
+	Delta      float64 `json:"delta"`
+	Remark     string  `json:"remark"`
+	OperatorID int64   `json:"operator_id"`
+}
+
+type logResponse struct {
+	Delta       float64           `json:"delta"`
+	Balance     float64           `json:"balance"`
+	Type        string            `json:"type"`
+	Remark      string            `json:"remark,omitempty"`
+	OperatorID  int64             `json:"operator_id"`
+	OperationID string            `json:"operation_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.balanceService.Get(r.Context(), groupID)
+	if err != nil {
+		logger.L().Warnf("Admin API get balance failed: group_id=%d err=%v", groupID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toBalanceResponse(result))
+}
+
+func (s *Server) handleAdjust(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	key := strings.TrimSpace(r.Header.Get(idempotencyHeader))
+	if key == "" {
+		writeError(w, http.StatusBadRequest, "missing Idempotency-Key header")
+		return
+	}
+
+	var req adjustRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Delta == 0 {
+		writeError(w, http.StatusBadRequest, "delta must not be zero")
+		return
+	}
+
+	operationID := fmt.Sprintf("api:%d:%s", groupID, key)
+	result, _, err := s.balanceService.Adjust(r.Context(), groupID, req.Delta, req.OperatorID, strings.TrimSpace(req.Remark), operationID)
+	if err != nil {
+		logger.L().Warnf("Admin API adjust failed: group_id=%d key=%s err=%v", groupID, key, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	logger.L().Infof("Admin API adjusted balance: group_id=%d delta=%.2f key=%s balance=%.2f", groupID, req.Delta, key, result.Balance)
+	writeJSON(w, http.StatusOK, toBalanceResponse(result))
+}
+
+func (s *Server) handleListLogs(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := int64(defaultLogLimit)
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 || parsed > maxLogLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLogLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	var offset int64
+	if raw := strings.TrimSpace(r.URL.Query().Get("offset")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = parsed
+	}
+
+	from, ok := parseTimeParam(w, r, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeParam(w, r, "to")
+	if !ok {
+		return
+	}
+
+	logs, err := s.balanceService.ListLogs(r.Context(), groupID, from, to, offset, limit)
+	if err != nil {
+		logger.L().Warnf("Admin API list logs failed: group_id=%d err=%v", groupID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	items := make([]logResponse, 0, len(logs))
+	for _, log := range logs {
+		items = append(items, toLogResponse(log))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"group_id": groupID,
+		"logs":     items,
+	})
+}
+
+func parseGroupID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || groupID == 0 {
+		writeError(w, http.StatusBadRequest, "invalid group id")
+		return 0, false
+	}
+	return groupID, true
+}
+
+func parseTimeParam(w http.ResponseWriter, r *http.Request, name string) (time.Time, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get(name))
+	if raw == "" {
+		return time.Time{}, true
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be RFC3339 time", name))
+		return time.Time{}, false
+	}
+	return value, true
+}
+
+func toBalanceResponse(result *service.UpstreamBalanceResult) balanceResponse {
+	return balanceResponse{
+		GroupID:           result.GroupID,
+		Balance:           result.Balance,
+		MinBalance:        result.MinBalance,
+		AlertLimitPerHour: result.AlertLimitPerHour,
+		BelowMin:          result.Balance < result.MinBalance,
+		UpdatedAt:         result.UpdatedAt,
+	}
+}
+
+func toLogResponse(log *models.UpstreamBalanceLog) logResponse {
+	return logResponse{
+		Delta:       log.Delta,
+		Balance:     log.Balance,
+		Type:        string(log.Type),
+		Remark:      log.Remark,
+		OperatorID:  log.OperatorID,
+		OperationID: log.OperationID,
+		Metadata:    log.Metadata,
+		CreatedAt:   log.CreatedAt,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		logger.L().Warnf("Admin API encode response failed: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeServiceError 按错误类型返回状态码：校验失败 400、群组不存在 404，其余（数据库等）统一 500 且不暴露底层错误
+func writeServiceError(w http.ResponseWriter, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound):
+		writeError(w, http.StatusNotFound, "group not found")
+	case errors.As(err, &validationErr):
+		writeError(w, http.StatusBadRequest, validationErr.Message)
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,261 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/config"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+type fakeBalanceService struct {
+	balance     float64
+	adjustCalls int
+	operations  map[string]bool
+	logs        []*models.UpstreamBalanceLog
+	err         error
+}
+
+func newFakeBalanceService() *fakeBalanceService {
+	return &fakeBalanceService{operations: make(map[string]bool)}
+}
+
+func (f *fakeBalanceService) Adjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, operationID string) (*service.UpstreamBalanceResult, bool, error) {
+	f.adjustCalls++
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	if !f.operations[operationID] {
+		f.operations[operationID] = true
+		f.balance += delta
+	}
+	return &service.UpstreamBalanceResult{GroupID: groupID, Balance: f.balance}, false, nil
+}
+
+func (f *fakeBalanceService) SetMinBalance(ctx context.Context, groupID int64, threshold float64, operatorID int64) (*service.UpstreamBalanceResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) SetAlertLimit(ctx context.Context, groupID int64, limit int, operatorID int64) (*service.UpstreamBalanceResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) Get(ctx context.Context, groupID int64) (*service.UpstreamBalanceResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &service.UpstreamBalanceResult{GroupID: groupID, Balance: f.balance}, nil
+}
+
+func (f *fakeBalanceService) ListAll(ctx context.Context) ([]*service.UpstreamBalanceResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) ListLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, skip, limit int64) ([]*models.UpstreamBalanceLog, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.logs, nil
+}
+
+func (f *fakeBalanceService) ExportLogs(ctx context.Context, groupID int64, start, end time.Time, w io.Writer) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeBalanceService) Transfer(ctx context.Context, fromGroupID, toGroupID int64, amount float64, operatorID int64, allowNegative bool, operationID string) (*service.UpstreamTransferResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*service.SettlementResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) SettleRange(ctx context.Context, groupID int64, start, end time.Time, operatorID int64, dryRun bool) (*service.SettlementRangeResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) CorrectSettlement(ctx context.Context, groupID int64, settlementDate time.Time, delta float64, operatorID int64, note string, operationID string) (*service.UpstreamBalanceResult, bool, error) {
+	return nil, false, nil
+}
+
+func (f *fakeBalanceService) PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*service.SettlementResult, error) {
+	return nil, nil
+}
+
+func (f *fakeBalanceService) PruneLogs(ctx context.Context, groupID int64, before time.Time, keepLatest int) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeBalanceService) SubscribeEvents() <-chan *models.UpstreamBalanceEvent {
+	return nil
+}
+
+func (f *fakeBalanceService) EventStats() service.UpstreamBalanceEventStats {
+	return service.UpstreamBalanceEventStats{}
+}
+
+func newTestServer(t *testing.T, svc service.UpstreamBalanceService) http.Handler {
+	t.Helper()
+	srv, err := New(config.AdminAPIConfig{Token: "secret"}, svc)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return srv.Handler()
+}
+
+func doRequest(handler http.Handler, method, path, token, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminAPIRejectsUnauthorized(t *testing.T) {
+	handler := newTestServer(t, newFakeBalanceService())
+
+	for _, token := range []string{"", "wrong"} {
+		rec := doRequest(handler, http.MethodGet, "/groups/-1001/balance", token, "", "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+}
+
+func TestAdminAPIMetrics(t *testing.T) {
+	handler := newTestServer(t, newFakeBalanceService())
+
+	if rec := doRequest(handler, http.MethodGet, "/metrics", "", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+	rec := doRequest(handler, http.MethodGet, "/metrics", "secret", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestAdminAPIAdjustSuccess(t *testing.T) {
+	svc := newFakeBalanceService()
+	handler := newTestServer(t, svc)
+
+	rec := doRequest(handler, http.MethodPost, "/groups/-1001/adjust", "secret", "k1", `{"delta":100.5,"remark":"充值"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp balanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.GroupID != -1001 || resp.Balance != 100.5 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if !svc.operations["api:-1001:k1"] {
+		t.Fatalf("expected operation id to be derived from idempotency key, got %v", svc.operations)
+	}
+}
+
+func TestAdminAPIAdjustIdempotentReplay(t *testing.T) {
+	svc := newFakeBalanceService()
+	handler := newTestServer(t, svc)
+
+	for i := 0; i < 2; i++ {
+		rec := doRequest(handler, http.MethodPost, "/groups/-1001/adjust", "secret", "same-key", `{"delta":50}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("attempt %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+
+	if svc.adjustCalls != 2 {
+		t.Fatalf("expected 2 adjust calls, got %d", svc.adjustCalls)
+	}
+	if svc.balance != 50 {
+		t.Fatalf("expected replay to keep balance at 50, got %.2f", svc.balance)
+	}
+}
+
+func TestAdminAPIServiceErrorStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{name: "validation", err: &service.ValidationError{Message: "仅上游群可使用余额功能"}, wantCode: http.StatusBadRequest, wantBody: "仅上游群可使用余额功能"},
+		{name: "unknown group", err: service.ErrGroupNotFound, wantCode: http.StatusNotFound, wantBody: "group not found"},
+		{name: "storage", err: errors.New("connection(mongo:27017) incomplete read of message header"), wantCode: http.StatusInternalServerError, wantBody: "internal error"},
+	}
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/groups/-1001/balance"},
+		{method: http.MethodPost, path: "/groups/-1001/adjust", body: `{"delta":1}`},
+		{method: http.MethodGet, path: "/groups/-1001/logs"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeBalanceService()
+			svc.err = tc.err
+			handler := newTestServer(t, svc)
+
+			for _, req := range requests {
+				rec := doRequest(handler, req.method, req.path, "secret", "k", req.body)
+				if rec.Code != tc.wantCode {
+					t.Fatalf("%s %s: expected %d, got %d", req.method, req.path, tc.wantCode, rec.Code)
+				}
+				var resp map[string]string
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+				if resp["error"] != tc.wantBody {
+					t.Fatalf("%s %s: expected error %q, got %q", req.method, req.path, tc.wantBody, resp["error"])
+				}
+			}
+		})
+	}
+}
+
+func TestAdminAPIAdjustValidation(t *testing.T) {
+	handler := newTestServer(t, newFakeBalanceService())
+
+	cases := []struct {
+		name string
+		path string
+		key  string
+		body string
+	}{
+		{name: "missing key", path: "/groups/-1001/adjust", body: `{"delta":1}`},
+		{name: "zero delta", path: "/groups/-1001/adjust", key: "k", body: `{"delta":0}`},
+		{name: "bad json", path: "/groups/-1001/adjust", key: "k", body: `{`},
+		{name: "bad group", path: "/groups/abc/adjust", key: "k", body: `{"delta":1}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := doRequest(handler, http.MethodPost, tc.path, "secret", tc.key, tc.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -3,23 +3,42 @@ package app
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"go_bot/internal/adminapi"
 	"go_bot/internal/config"
 	"go_bot/internal/logger"
 	"go_bot/internal/mongo"
 	paymentservice "go_bot/internal/payment/service"
 	"go_bot/internal/payment/sifang"
 	"go_bot/internal/telegram"
+	"go_bot/internal/telegram/models"
 )
 
+// Runner 可独立启动/停止的 Bot 实例
+type Runner interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// BotInstance 同进程中运行的单个 Bot
+type BotInstance struct {
+	Name   string
+	Runner Runner
+}
+
 // App 应用服务容器
 // 负责管理所有服务的生命周期（初始化、运行、关闭）
 type App struct {
 	MongoDB        *mongo.Client
-	TelegramBot    *telegram.Bot
+	TelegramBot    *telegram.Bot  // 主 Bot（第一个配置），管理 API 基于其数据
+	Bots           []*BotInstance // 全部 Bot 实例，包含主 Bot
 	PaymentService paymentservice.Service
+	AdminAPI       *adminapi.Server
 	// 未来扩展其他服务：
 	// RedisClient *redis.Client
+
+	botsWG sync.WaitGroup
 }
 
 // New 初始化应用及其所有服务
@@ -33,8 +52,26 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("init MongoDB failed: %w", err)
 	}
 	app.MongoDB = mongoClient
+
+	// 等待 MongoDB 就绪后再初始化依赖数据库的服务，避免启动早期的读写与建索引失败
+	if err := waitForReady(context.Background(), "MongoDB", mongoClient.Ping, defaultReadinessPolicy(cfg.MongoReadyTimeout)); err != nil {
+		app.Close(context.Background())
+		return nil, err
+	}
 	logger.L().Info("MongoDB initialized successfully")
 
+	// 金额展示精度（全局生效，未配置的币种保留 2 位小数）
+	if len(cfg.MoneyPrecision) > 0 {
+		models.SetMoneyPrecision(cfg.MoneyPrecision)
+		logger.L().Infof("Money precision configured: %v", cfg.MoneyPrecision)
+	}
+
+	// 订单链接域名白名单（全局生效，未配置时订单链接仅以纯文本展示）
+	if len(cfg.Payment.Sifang.OrderLinkHosts) > 0 {
+		models.SetOrderLinkHosts(cfg.Payment.Sifang.OrderLinkHosts)
+		logger.L().Infof("Order link hosts configured: %v", cfg.Payment.Sifang.OrderLinkHosts)
+	}
+
 	// 初始化四方支付服务（可选）
 	if cfg.Payment.Sifang.BaseURL != "" {
 		sifangClient, err := sifang.NewClient(cfg.Payment.Sifang)
@@ -42,33 +79,85 @@ func New(cfg *config.Config) (*App, error) {
 			app.Close(context.Background())
 			return nil, fmt.Errorf("init Sifang client failed: %w", err)
 		}
-		app.PaymentService = paymentservice.NewSifangService(sifangClient)
+		app.PaymentService = paymentservice.NewSifangService(sifangClient,
+			paymentservice.WithMaxHistoryDays(cfg.Payment.Sifang.MaxHistoryDays),
+			paymentservice.WithWithdrawPageSize(cfg.Payment.Sifang.WithdrawPageSize, cfg.Payment.Sifang.WithdrawPageMax),
+		)
 		logger.L().Info("Sifang payment service initialized successfully")
 	} else {
 		logger.L().Warn("Sifang payment service not initialized: SIFANG_BASE_URL is empty")
 	}
 
-	// 初始化 Telegram Bot
-	app.TelegramBot, err = telegram.InitFromConfig(cfg, app.MongoDB.Database(), app.PaymentService)
-	if err != nil {
-		app.Close(context.Background()) // 清理已初始化的服务
-		return nil, fmt.Errorf("init Telegram bot failed: %w", err)
+	// 初始化 Telegram Bot（每个实例使用独立数据库，共享 MongoDB 连接）
+	bots := cfg.Bots
+	if len(bots) == 0 {
+		bots = []config.BotConfig{{
+			Name:          "default",
+			TelegramToken: cfg.TelegramToken,
+			OwnerIDs:      cfg.BotOwnerIDs,
+			MongoDBName:   cfg.MongoDBName,
+			ChannelID:     cfg.ChannelID,
+		}}
+	}
+	// InitFromBotConfig 会同步建好索引；Bot 只在 StartBots 时开始接收更新，handler 运行时索引已就绪
+	for _, botCfg := range bots {
+		telegramBot, err := telegram.InitFromBotConfig(cfg, botCfg, app.MongoDB.DatabaseNamed(botCfg.MongoDBName), app.PaymentService)
+		if err != nil {
+			app.Close(context.Background()) // 清理已初始化的服务
+			return nil, fmt.Errorf("init Telegram bot %q failed: %w", botCfg.Name, err)
+		}
+		if app.TelegramBot == nil {
+			app.TelegramBot = telegramBot
+		}
+		app.Bots = append(app.Bots, &BotInstance{Name: botCfg.Name, Runner: telegramBot})
+		logger.L().Infof("Telegram bot %q initialized successfully (database: %s)", botCfg.Name, botCfg.MongoDBName)
+	}
+
+	// 初始化管理 API（可选）
+	if cfg.AdminAPI.Addr != "" {
+		app.AdminAPI, err = adminapi.New(cfg.AdminAPI, app.TelegramBot.BalanceService())
+		if err != nil {
+			app.Close(context.Background())
+			return nil, fmt.Errorf("init admin API failed: %w", err)
+		}
+		logger.L().Info("Admin API initialized successfully")
 	}
-	logger.L().Info("Telegram bot initialized successfully")
 
 	return app, nil
 }
 
+// StartBots 在各自的 goroutine 中启动全部 Bot，ctx 取消后 Bot 退出
+func (a *App) StartBots(ctx context.Context) {
+	for _, instance := range a.Bots {
+		a.botsWG.Add(1)
+		go func(instance *BotInstance) {
+			defer a.botsWG.Done()
+			logger.L().Infof("Starting Telegram bot %q...", instance.Name)
+			if err := instance.Runner.Start(ctx); err != nil {
+				logger.L().Errorf("Telegram bot %q error: %v", instance.Name, err)
+			}
+		}(instance)
+	}
+}
+
 // Close 优雅关闭所有服务
 // 应该在应用退出时调用，确保资源正确释放
 func (a *App) Close(ctx context.Context) error {
-	// 关闭 Telegram Bot
-	if a.TelegramBot != nil {
-		if err := a.TelegramBot.Stop(ctx); err != nil {
-			logger.L().Warnf("Failed to stop Telegram bot: %v", err)
+	// 关闭管理 API
+	if a.AdminAPI != nil {
+		if err := a.AdminAPI.Stop(ctx); err != nil {
+			logger.L().Warnf("Failed to stop admin API: %v", err)
 		}
 	}
 
+	// 关闭全部 Telegram Bot
+	for _, instance := range a.Bots {
+		if err := instance.Runner.Stop(ctx); err != nil {
+			logger.L().Warnf("Failed to stop Telegram bot %q: %v", instance.Name, err)
+		}
+	}
+	a.waitBots(ctx)
+
 	// 关闭 MongoDB
 	if a.MongoDB != nil {
 		if err := a.MongoDB.Close(ctx); err != nil {
@@ -78,3 +167,18 @@ func (a *App) Close(ctx context.Context) error {
 
 	return nil
 }
+
+// waitBots 等待 StartBots 启动的 goroutine 退出，超过 ctx 期限则放弃等待
+func (a *App) waitBots(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		a.botsWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.L().Warn("Timed out waiting for Telegram bots to stop")
+	}
+}
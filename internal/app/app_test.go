@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeRunner struct {
+	updates  chan string
+	received chan string
+	stopped  atomic.Bool
+	exited   atomic.Bool
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{
+		updates:  make(chan string, 1),
+		received: make(chan string, 1),
+	}
+}
+
+func (r *fakeRunner) Start(ctx context.Context) error {
+	defer r.exited.Store(true)
+	for {
+		select {
+		case update := <-r.updates:
+			r.received <- update
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *fakeRunner) Stop(ctx context.Context) error {
+	r.stopped.Store(true)
+	return nil
+}
+
+func TestAppRunsMultipleBotsIndependently(t *testing.T) {
+	first := newFakeRunner()
+	second := newFakeRunner()
+	application := &App{Bots: []*BotInstance{
+		{Name: "default", Runner: first},
+		{Name: "second", Runner: second},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	application.StartBots(ctx)
+
+	first.updates <- "update-for-first"
+	second.updates <- "update-for-second"
+
+	for name, runner := range map[string]*fakeRunner{"update-for-first": first, "update-for-second": second} {
+		select {
+		case got := <-runner.received:
+			if got != name {
+				t.Fatalf("bot received foreign update: got %q want %q", got, name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("bot did not receive %q", name)
+		}
+	}
+
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	if err := application.Close(shutdownCtx); err != nil {
+		t.Fatalf("close returned error: %v", err)
+	}
+
+	for _, runner := range []*fakeRunner{first, second} {
+		if !runner.stopped.Load() {
+			t.Fatalf("expected every bot to be stopped")
+		}
+		if !runner.exited.Load() {
+			t.Fatalf("expected Close to wait for every bot to exit")
+		}
+	}
+	if shutdownCtx.Err() != nil {
+		t.Fatalf("expected bots to exit before shutdown deadline")
+	}
+}
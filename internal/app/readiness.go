@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_bot/internal/logger"
+)
+
+// readinessPolicy 依赖就绪检查的重试策略
+type readinessPolicy struct {
+	Timeout        time.Duration // 等待就绪的总时长，超时后启动失败
+	AttemptTimeout time.Duration // 单次检查的超时时间
+	InitialBackoff time.Duration // 首次失败后的等待间隔，之后逐次翻倍
+	MaxBackoff     time.Duration // 等待间隔上限
+}
+
+// defaultReadinessPolicy 返回默认重试策略，timeout <= 0 时使用 30 秒
+func defaultReadinessPolicy(timeout time.Duration) readinessPolicy {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return readinessPolicy{
+		Timeout:        timeout,
+		AttemptTimeout: 5 * time.Second,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// waitForReady 按退避策略反复执行 check，直到成功或超过总时长
+func waitForReady(ctx context.Context, name string, check func(context.Context) error, policy readinessPolicy) error {
+	deadline := time.Now().Add(policy.Timeout)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancelAttempt := context.WithTimeout(ctx, policy.AttemptTimeout)
+		err := check(attemptCtx)
+		cancelAttempt()
+		if err == nil {
+			if attempt > 1 {
+				logger.L().Infof("%s ready after %d attempts", name, attempt)
+			}
+			return nil
+		}
+
+		if time.Until(deadline) < backoff {
+			return fmt.Errorf("%s not ready after %d attempts within %s: %w", name, attempt, policy.Timeout, err)
+		}
+		logger.L().Warnf("%s not ready (attempt %d), retrying in %s: %v", name, attempt, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s not ready after %d attempts within %s: %w", name, attempt, policy.Timeout, err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReadinessPolicy(timeout time.Duration) readinessPolicy {
+	return readinessPolicy{
+		Timeout:        timeout,
+		AttemptTimeout: 50 * time.Millisecond,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+func TestWaitForReadySucceedsAfterRetries(t *testing.T) {
+	attempts := 0
+	check := func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	if err := waitForReady(context.Background(), "MongoDB", check, testReadinessPolicy(time.Second)); err != nil {
+		t.Fatalf("expected ready, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWaitForReadyFailsAfterTimeout(t *testing.T) {
+	attempts := 0
+	check := func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	}
+
+	started := time.Now()
+	err := waitForReady(context.Background(), "MongoDB", check, testReadinessPolicy(50*time.Millisecond))
+	if err == nil {
+		t.Fatal("expected readiness to fail")
+	}
+	if !strings.Contains(err.Error(), "MongoDB not ready") || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected clear readiness error, got %v", err)
+	}
+	if attempts < 2 {
+		t.Fatalf("expected retries before giving up, got %d attempts", attempts)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected to give up near the timeout, took %s", elapsed)
+	}
+}
+
+func TestWaitForReadyBoundsEachAttempt(t *testing.T) {
+	check := func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("expected attempt context to have a deadline")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	policy := testReadinessPolicy(30 * time.Millisecond)
+	policy.AttemptTimeout = 10 * time.Millisecond
+	if err := waitForReady(context.Background(), "MongoDB", check, policy); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -8,16 +8,57 @@ import (
 	"time"
 )
 
+// 配置菜单关闭方式
+const (
+	ConfigMenuCloseDelete = "delete" // 直接删除菜单消息
+	ConfigMenuCloseEdit   = "edit"   // 编辑为"已关闭"提示，数秒后自动删除
+)
+
+// 日结扣减金额取整方式（保留 2 位小数）
+const (
+	SettlementRoundHalfUp = "round" // 四舍五入
+	SettlementRoundCeil   = "ceil"  // 向上取整
+	SettlementRoundFloor  = "floor" // 向下取整
+)
+
 // Config 应用程序配置
 type Config struct {
-	TelegramToken        string  // Telegram Bot API Token
-	BotOwnerIDs          []int64 // Bot管理员ID列表
-	MongoURI             string  // MongoDB连接URI
-	MongoDBName          string  // MongoDB数据库名称
-	MessageRetentionDays int     // 消息保留天数（过期自动删除）
-	ChannelID            int64   // 源频道 ID（用于转发功能）
-	DailyBillPushEnabled bool    // 是否启用每日账单推送
-	Payment              PaymentConfig
+	TelegramToken               string         // Telegram Bot API Token
+	BotOwnerIDs                 []int64        // Bot管理员ID列表
+	MongoURI                    string         // MongoDB连接URI
+	MongoDBName                 string         // MongoDB数据库名称
+	MongoReadyTimeout           time.Duration  // 启动时等待 MongoDB 可用的最长时间
+	MessageRetentionDays        int            // 消息保留天数（过期自动删除）
+	ChannelID                   int64          // 源频道 ID（用于转发功能）
+	DailyBillPushEnabled        bool           // 是否启用每日账单推送
+	BalanceReminderInterval     time.Duration  // 低余额定时提醒的扫描间隔
+	MoneyPrecision              map[string]int // 各币种金额展示的小数位数（币种 → 位数），未配置的币种保留 2 位
+	BalanceWriteConflictRetries int            // 余额事务遇到写冲突时的最大重试次数
+	ConfigMenuCloseMode         string         // 配置菜单关闭方式：delete（直接删除）或 edit（改为"已关闭"后自动删除）
+	SettlementRounding          string         // 日结扣减金额取整方式：round / ceil / floor（保留 2 位小数）
+	SettlementConcurrency       int            // 自动日结时同时处理的上游群数量上限
+	BalanceLogRetention         time.Duration  // 上游余额日志保留时长，0 表示不清理
+	BalanceLogKeepLatest        int            // 清理余额日志时每个群至少保留的最近条数
+	AutoGrantInviterAdmin       bool           // Bot 被拉入群组时自动将邀请人设为管理员（全局管理员，默认关闭）
+	Payment                     PaymentConfig
+	AdminAPI                    AdminAPIConfig
+	Bots                        []BotConfig // 同进程运行的全部 Bot，第一个为主 Bot
+}
+
+// BotConfig 单个 Bot 实例配置
+// 每个 Bot 使用独立的数据库，共享同一个 MongoDB 连接
+type BotConfig struct {
+	Name          string  // 实例名称，用于日志区分
+	TelegramToken string  // Telegram Bot API Token
+	OwnerIDs      []int64 // Bot管理员ID列表
+	MongoDBName   string  // 数据库名称
+	ChannelID     int64   // 源频道 ID（用于转发功能）
+}
+
+// AdminAPIConfig 管理 HTTP API 配置
+type AdminAPIConfig struct {
+	Addr  string // 监听地址，为空时不启用
+	Token string // Bearer 鉴权令牌
 }
 
 // PaymentConfig 支付相关配置
@@ -27,12 +68,19 @@ type PaymentConfig struct {
 
 // SifangConfig 四方支付配置
 type SifangConfig struct {
-	BaseURL            string
-	AccessKey          string
-	MasterKey          string
-	DefaultMerchantKey string
-	MerchantKeys       map[int64]string
-	Timeout            time.Duration
+	BaseURL             string
+	AccessKey           string
+	MasterKey           string
+	DefaultMerchantKey  string
+	MerchantKeys        map[int64]string
+	Timeout             time.Duration
+	MaxHistoryDays      int           // 历史余额最多可查询的天数
+	DebugLog            bool          // 是否在 Debug 日志中记录脱敏后的请求参数与响应体
+	OrderLinkHosts      []string      // 允许在消息中渲染为可点击链接的订单链接域名（含子域名）
+	WithdrawPageSize    int           // 提款查询默认单页条数，0 表示使用默认 20
+	WithdrawPageMax     int           // 提款查询单页条数上限，0 表示使用上游上限 100
+	RawCaptureEnabled   bool          // 是否留存解码失败/结果为空时的上游原始响应（脱敏，仅内存）
+	RawCaptureRetention time.Duration // 原始响应留存时长，0 表示使用默认 1 小时
 }
 
 // Load 从环境变量加载配置
@@ -49,6 +97,101 @@ func Load() (*Config, error) {
 		DailyBillPushEnabled: true,
 	}
 
+	// 解析MONGO_READY_TIMEOUT_SECONDS（默认30秒）
+	if secondsStr := strings.TrimSpace(os.Getenv("MONGO_READY_TIMEOUT_SECONDS")); secondsStr != "" {
+		seconds, err := strconv.Atoi(secondsStr)
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid MONGO_READY_TIMEOUT_SECONDS: %s", secondsStr)
+		}
+		cfg.MongoReadyTimeout = time.Duration(seconds) * time.Second
+	} else {
+		cfg.MongoReadyTimeout = 30 * time.Second
+	}
+
+	// 解析BALANCE_REMINDER_INTERVAL_MINUTES（默认10分钟）
+	if minutesStr := strings.TrimSpace(os.Getenv("BALANCE_REMINDER_INTERVAL_MINUTES")); minutesStr != "" {
+		minutes, err := strconv.Atoi(minutesStr)
+		if err != nil || minutes < 1 {
+			return nil, fmt.Errorf("invalid BALANCE_REMINDER_INTERVAL_MINUTES: %s", minutesStr)
+		}
+		cfg.BalanceReminderInterval = time.Duration(minutes) * time.Minute
+	} else {
+		cfg.BalanceReminderInterval = 10 * time.Minute
+	}
+
+	// 解析BALANCE_WRITE_CONFLICT_RETRIES（默认3次，0表示不重试）
+	if retriesStr := strings.TrimSpace(os.Getenv("BALANCE_WRITE_CONFLICT_RETRIES")); retriesStr != "" {
+		retries, err := strconv.Atoi(retriesStr)
+		if err != nil || retries < 0 {
+			return nil, fmt.Errorf("invalid BALANCE_WRITE_CONFLICT_RETRIES: %s", retriesStr)
+		}
+		cfg.BalanceWriteConflictRetries = retries
+	} else {
+		cfg.BalanceWriteConflictRetries = 3
+	}
+
+	// 解析SETTLEMENT_CONCURRENCY（默认8，最小1）
+	if concurrencyStr := strings.TrimSpace(os.Getenv("SETTLEMENT_CONCURRENCY")); concurrencyStr != "" {
+		concurrency, err := strconv.Atoi(concurrencyStr)
+		if err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("invalid SETTLEMENT_CONCURRENCY: %s", concurrencyStr)
+		}
+		cfg.SettlementConcurrency = concurrency
+	} else {
+		cfg.SettlementConcurrency = 8
+	}
+
+	// 解析BALANCE_LOG_RETENTION_DAYS（默认365天，0表示不清理）
+	if daysStr := strings.TrimSpace(os.Getenv("BALANCE_LOG_RETENTION_DAYS")); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid BALANCE_LOG_RETENTION_DAYS: %s", daysStr)
+		}
+		cfg.BalanceLogRetention = time.Duration(days) * 24 * time.Hour
+	} else {
+		cfg.BalanceLogRetention = 365 * 24 * time.Hour
+	}
+
+	// 解析BALANCE_LOG_KEEP_LATEST（默认100条）
+	if keepStr := strings.TrimSpace(os.Getenv("BALANCE_LOG_KEEP_LATEST")); keepStr != "" {
+		keep, err := strconv.Atoi(keepStr)
+		if err != nil || keep < 0 {
+			return nil, fmt.Errorf("invalid BALANCE_LOG_KEEP_LATEST: %s", keepStr)
+		}
+		cfg.BalanceLogKeepLatest = keep
+	} else {
+		cfg.BalanceLogKeepLatest = 100
+	}
+
+	// 解析CONFIG_MENU_CLOSE_MODE（默认delete）
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("CONFIG_MENU_CLOSE_MODE"))); mode {
+	case "", ConfigMenuCloseDelete:
+		cfg.ConfigMenuCloseMode = ConfigMenuCloseDelete
+	case ConfigMenuCloseEdit:
+		cfg.ConfigMenuCloseMode = ConfigMenuCloseEdit
+	default:
+		return nil, fmt.Errorf("invalid CONFIG_MENU_CLOSE_MODE: %s", mode)
+	}
+
+	// 解析SETTLEMENT_ROUNDING（默认round）
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("SETTLEMENT_ROUNDING"))); mode {
+	case "", SettlementRoundHalfUp:
+		cfg.SettlementRounding = SettlementRoundHalfUp
+	case SettlementRoundCeil, SettlementRoundFloor:
+		cfg.SettlementRounding = mode
+	default:
+		return nil, fmt.Errorf("invalid SETTLEMENT_ROUNDING: %s", mode)
+	}
+
+	// 解析MONEY_PRECISION（可选，例如 USDT:4,CNY:2）
+	if precisionStr := strings.TrimSpace(os.Getenv("MONEY_PRECISION")); precisionStr != "" {
+		precisions, err := parseMoneyPrecision(precisionStr)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MoneyPrecision = precisions
+	}
+
 	if enabled := strings.TrimSpace(os.Getenv("DAILY_BILL_PUSH_ENABLED")); enabled != "" {
 		value, err := strconv.ParseBool(enabled)
 		if err != nil {
@@ -57,6 +200,14 @@ func Load() (*Config, error) {
 		cfg.DailyBillPushEnabled = value
 	}
 
+	if enabled := strings.TrimSpace(os.Getenv("AUTO_GRANT_INVITER_ADMIN")); enabled != "" {
+		value, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AUTO_GRANT_INVITER_ADMIN: %w", err)
+		}
+		cfg.AutoGrantInviterAdmin = value
+	}
+
 	// 解析BOT_OWNER_IDS
 	ownerIDsStr := os.Getenv("BOT_OWNER_IDS")
 	if ownerIDsStr != "" {
@@ -99,9 +250,101 @@ func Load() (*Config, error) {
 	}
 	cfg.Payment.Sifang = sifangCfg
 
+	// 加载 Bot 实例配置（主 Bot + TELEGRAM_BOTS 中的额外 Bot）
+	bots, err := loadBotConfigs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Bots = bots
+
+	// 加载管理 API 配置（可选）
+	cfg.AdminAPI.Addr = strings.TrimSpace(os.Getenv("ADMIN_API_ADDR"))
+	cfg.AdminAPI.Token = strings.TrimSpace(os.Getenv("ADMIN_API_TOKEN"))
+
 	return cfg, nil
 }
 
+// loadBotConfigs 构建 Bot 实例列表
+// 主 Bot 沿用 TELEGRAM_TOKEN 等全局配置；TELEGRAM_BOTS 为逗号分隔的额外实例名，
+// 每个实例从 BOT_<NAME>_TOKEN、BOT_<NAME>_OWNER_IDS、BOT_<NAME>_MONGO_DB_NAME、BOT_<NAME>_CHANNEL_ID 读取配置
+func loadBotConfigs(cfg *Config) ([]BotConfig, error) {
+	bots := []BotConfig{{
+		Name:          "default",
+		TelegramToken: cfg.TelegramToken,
+		OwnerIDs:      cfg.BotOwnerIDs,
+		MongoDBName:   cfg.MongoDBName,
+		ChannelID:     cfg.ChannelID,
+	}}
+
+	names := strings.TrimSpace(os.Getenv("TELEGRAM_BOTS"))
+	if names == "" {
+		return bots, nil
+	}
+
+	for _, raw := range strings.Split(names, ",") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		prefix := "BOT_" + strings.ToUpper(name) + "_"
+
+		bot := BotConfig{
+			Name:          name,
+			TelegramToken: strings.TrimSpace(os.Getenv(prefix + "TOKEN")),
+			OwnerIDs:      cfg.BotOwnerIDs,
+			MongoDBName:   strings.TrimSpace(os.Getenv(prefix + "MONGO_DB_NAME")),
+		}
+		if bot.TelegramToken == "" {
+			return nil, fmt.Errorf("%sTOKEN is required for bot %q", prefix, name)
+		}
+		if bot.MongoDBName == "" {
+			bot.MongoDBName = cfg.MongoDBName + "_" + name
+		}
+		if ownerIDs := strings.TrimSpace(os.Getenv(prefix + "OWNER_IDS")); ownerIDs != "" {
+			ids, err := parseOwnerIDs(ownerIDs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %sOWNER_IDS: %w", prefix, err)
+			}
+			bot.OwnerIDs = ids
+		}
+		if channelID := strings.TrimSpace(os.Getenv(prefix + "CHANNEL_ID")); channelID != "" {
+			id, err := strconv.ParseInt(channelID, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %sCHANNEL_ID: %w", prefix, err)
+			}
+			bot.ChannelID = id
+		}
+		bots = append(bots, bot)
+	}
+
+	if err := validateBotConfigs(bots); err != nil {
+		return nil, err
+	}
+	return bots, nil
+}
+
+// validateBotConfigs 确保各实例的名称、Token、数据库互不冲突
+func validateBotConfigs(bots []BotConfig) error {
+	names := make(map[string]bool, len(bots))
+	tokens := make(map[string]bool, len(bots))
+	databases := make(map[string]bool, len(bots))
+	for _, bot := range bots {
+		if names[bot.Name] {
+			return fmt.Errorf("duplicate bot name %q", bot.Name)
+		}
+		if bot.TelegramToken != "" && tokens[bot.TelegramToken] {
+			return fmt.Errorf("bot %q reuses another bot's token", bot.Name)
+		}
+		if databases[bot.MongoDBName] {
+			return fmt.Errorf("bot %q reuses database %q", bot.Name, bot.MongoDBName)
+		}
+		names[bot.Name] = true
+		tokens[bot.TelegramToken] = true
+		databases[bot.MongoDBName] = true
+	}
+	return nil
+}
+
 // parseOwnerIDs 解析逗号分隔的用户ID字符串
 // 支持格式: "123456789" 或 "123456789,987654321"
 func parseOwnerIDs(s string) ([]int64, error) {
@@ -142,6 +385,62 @@ func loadSifangConfig() (SifangConfig, error) {
 		cfg.Timeout = 10 * time.Second
 	}
 
+	if daysStr := strings.TrimSpace(os.Getenv("SIFANG_MAX_HISTORY_DAYS")); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_MAX_HISTORY_DAYS: %s", daysStr)
+		}
+		cfg.MaxHistoryDays = days
+	} else {
+		cfg.MaxHistoryDays = 365
+	}
+
+	if debugStr := strings.TrimSpace(os.Getenv("SIFANG_DEBUG_LOG")); debugStr != "" {
+		debug, err := strconv.ParseBool(debugStr)
+		if err != nil {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_DEBUG_LOG: %s", debugStr)
+		}
+		cfg.DebugLog = debug
+	}
+
+	if captureStr := strings.TrimSpace(os.Getenv("SIFANG_RAW_CAPTURE")); captureStr != "" {
+		capture, err := strconv.ParseBool(captureStr)
+		if err != nil {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_RAW_CAPTURE: %s", captureStr)
+		}
+		cfg.RawCaptureEnabled = capture
+	}
+	if minutesStr := strings.TrimSpace(os.Getenv("SIFANG_RAW_CAPTURE_RETENTION_MINUTES")); minutesStr != "" {
+		minutes, err := strconv.Atoi(minutesStr)
+		if err != nil || minutes < 1 {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_RAW_CAPTURE_RETENTION_MINUTES: %s", minutesStr)
+		}
+		cfg.RawCaptureRetention = time.Duration(minutes) * time.Minute
+	}
+
+	// 提款查询分页条数（可选，1-100）
+	if sizeStr := strings.TrimSpace(os.Getenv("SIFANG_WITHDRAW_PAGE_SIZE")); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size <= 0 || size > 100 {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_WITHDRAW_PAGE_SIZE: %s", sizeStr)
+		}
+		cfg.WithdrawPageSize = size
+	}
+	if maxStr := strings.TrimSpace(os.Getenv("SIFANG_WITHDRAW_PAGE_MAX")); maxStr != "" {
+		size, err := strconv.Atoi(maxStr)
+		if err != nil || size <= 0 || size > 100 {
+			return SifangConfig{}, fmt.Errorf("invalid SIFANG_WITHDRAW_PAGE_MAX: %s", maxStr)
+		}
+		cfg.WithdrawPageMax = size
+	}
+
+	// 解析SIFANG_ORDER_LINK_HOSTS（可选，逗号分隔，例如 pay.example.com,admin.example.com）
+	for _, host := range strings.Split(os.Getenv("SIFANG_ORDER_LINK_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			cfg.OrderLinkHosts = append(cfg.OrderLinkHosts, host)
+		}
+	}
+
 	merchantKeyStr := strings.TrimSpace(os.Getenv("SIFANG_MERCHANT_KEYS"))
 	if merchantKeyStr != "" {
 		parsed, err := parseMerchantKeys(merchantKeyStr)
@@ -156,6 +455,34 @@ func loadSifangConfig() (SifangConfig, error) {
 	return cfg, nil
 }
 
+// parseMoneyPrecision 解析格式为 "USDT:4,CNY:2" 的字符串，位数范围 0-8
+func parseMoneyPrecision(input string) (map[string]int, error) {
+	pairs := strings.Split(input, ",")
+	result := make(map[string]int, len(pairs))
+
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid MONEY_PRECISION entry: %s", pair)
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(parts[0]))
+		digits, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if currency == "" || err != nil || digits < 0 || digits > 8 {
+			return nil, fmt.Errorf("invalid MONEY_PRECISION entry: %s", pair)
+		}
+
+		result[currency] = digits
+	}
+
+	return result, nil
+}
+
 // parseMerchantKeys 解析格式为 "1001:secret,1002:secret2" 的字符串
 func parseMerchantKeys(input string) (map[int64]string, error) {
 	pairs := strings.Split(input, ",")
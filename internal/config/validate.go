@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidationError 汇总配置校验发现的全部问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate 检查配置项之间的依赖关系，一次性返回全部问题而不是遇到第一个就退出
+func (c *Config) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(c.TelegramToken) == "" {
+		problems = append(problems, "TELEGRAM_TOKEN is required")
+	}
+	if strings.TrimSpace(c.MongoURI) == "" {
+		problems = append(problems, "MONGO_URI is required")
+	}
+	if c.AdminAPI.Addr != "" && c.AdminAPI.Token == "" {
+		problems = append(problems, "ADMIN_API_TOKEN is required when ADMIN_API_ADDR is set")
+	}
+	problems = append(problems, c.Payment.Sifang.validate()...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// validate 检查四方支付配置：设置了密钥等参数却缺少 SIFANG_BASE_URL 时四方功能会被静默禁用，
+// 设置了 SIFANG_BASE_URL 却没有可用的签名密钥时每次请求都会失败
+func (c SifangConfig) validate() []string {
+	var problems []string
+
+	if c.BaseURL == "" {
+		if configured := c.configuredKeyVars(); len(configured) > 0 {
+			problems = append(problems, fmt.Sprintf("SIFANG_BASE_URL is required when %s is set (sifang features are disabled without it)", strings.Join(configured, ", ")))
+		}
+		return problems
+	}
+
+	if parsed, err := url.Parse(c.BaseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		problems = append(problems, fmt.Sprintf("SIFANG_BASE_URL must be an absolute http(s) URL, got %q", c.BaseURL))
+	}
+
+	if (c.AccessKey == "") != (c.MasterKey == "") {
+		problems = append(problems, "SIFANG_ACCESS_KEY and SIFANG_MASTER_KEY must be set together")
+	}
+	if c.MasterKey == "" && c.DefaultMerchantKey == "" && len(c.MerchantKeys) == 0 {
+		problems = append(problems, "SIFANG_BASE_URL is set but no signing key is configured (set SIFANG_ACCESS_KEY with SIFANG_MASTER_KEY, SIFANG_DEFAULT_MERCHANT_KEY or SIFANG_MERCHANT_KEYS)")
+	}
+
+	if c.WithdrawPageSize > 0 && c.WithdrawPageMax > 0 && c.WithdrawPageSize > c.WithdrawPageMax {
+		problems = append(problems, fmt.Sprintf("SIFANG_WITHDRAW_PAGE_SIZE (%d) must not exceed SIFANG_WITHDRAW_PAGE_MAX (%d)", c.WithdrawPageSize, c.WithdrawPageMax))
+	}
+	return problems
+}
+
+// configuredKeyVars 返回已设置的四方签名相关环境变量名
+func (c SifangConfig) configuredKeyVars() []string {
+	var vars []string
+	if c.AccessKey != "" {
+		vars = append(vars, "SIFANG_ACCESS_KEY")
+	}
+	if c.MasterKey != "" {
+		vars = append(vars, "SIFANG_MASTER_KEY")
+	}
+	if c.DefaultMerchantKey != "" {
+		vars = append(vars, "SIFANG_DEFAULT_MERCHANT_KEY")
+	}
+	if len(c.MerchantKeys) > 0 {
+		vars = append(vars, "SIFANG_MERCHANT_KEYS")
+	}
+	return vars
+}
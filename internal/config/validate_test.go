@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validTestConfig() *Config {
+	return &Config{
+		TelegramToken: "token",
+		MongoURI:      "mongodb://localhost:27017",
+		Payment: PaymentConfig{Sifang: SifangConfig{
+			BaseURL:            "https://pay.example.com/api",
+			DefaultMerchantKey: "merchant-key",
+		}},
+	}
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	if err := validTestConfig().Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	cfg := validTestConfig()
+	cfg.Payment.Sifang = SifangConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected config without sifang to be valid, got %v", err)
+	}
+}
+
+func TestValidateReportsInvalidCombinations(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(cfg *Config)
+		want   []string
+	}{
+		{
+			name: "missing required values",
+			mutate: func(cfg *Config) {
+				cfg.TelegramToken = ""
+				cfg.MongoURI = " "
+			},
+			want: []string{"TELEGRAM_TOKEN is required", "MONGO_URI is required"},
+		},
+		{
+			name:   "admin api without token",
+			mutate: func(cfg *Config) { cfg.AdminAPI.Addr = ":8080" },
+			want:   []string{"ADMIN_API_TOKEN is required when ADMIN_API_ADDR is set"},
+		},
+		{
+			name: "sifang keys without base url",
+			mutate: func(cfg *Config) {
+				cfg.Payment.Sifang.BaseURL = ""
+				cfg.Payment.Sifang.MerchantKeys = map[int64]string{1001: "key"}
+			},
+			want: []string{"SIFANG_BASE_URL is required when SIFANG_DEFAULT_MERCHANT_KEY, SIFANG_MERCHANT_KEYS is set"},
+		},
+		{
+			name: "sifang base url without signing key",
+			mutate: func(cfg *Config) {
+				cfg.Payment.Sifang.BaseURL = "pay.example.com"
+				cfg.Payment.Sifang.DefaultMerchantKey = ""
+				cfg.Payment.Sifang.AccessKey = "access"
+			},
+			want: []string{
+				`SIFANG_BASE_URL must be an absolute http(s) URL, got "pay.example.com"`,
+				"SIFANG_ACCESS_KEY and SIFANG_MASTER_KEY must be set together",
+				"no signing key is configured",
+			},
+		},
+		{
+			name: "withdraw page size above max",
+			mutate: func(cfg *Config) {
+				cfg.Payment.Sifang.WithdrawPageSize = 50
+				cfg.Payment.Sifang.WithdrawPageMax = 20
+			},
+			want: []string{"SIFANG_WITHDRAW_PAGE_SIZE (50) must not exceed SIFANG_WITHDRAW_PAGE_MAX (20)"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			tc.mutate(cfg)
+
+			err := cfg.Validate()
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != len(tc.want) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tc.want), len(validationErr.Problems), validationErr.Problems)
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(validationErr.Problems[i], want) {
+					t.Fatalf("problem %d = %q, want it to contain %q", i, validationErr.Problems[i], want)
+				}
+				if !strings.Contains(err.Error(), validationErr.Problems[i]) {
+					t.Fatalf("expected aggregated error to list %q, got %q", validationErr.Problems[i], err.Error())
+				}
+			}
+		})
+	}
+}
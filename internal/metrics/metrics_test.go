@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHistogramVecWriteText(t *testing.T) {
+	h := &HistogramVec{name: "test_seconds", help: "test", labels: []string{"feature"}, buckets: []float64{0.1, 1}, series: make(map[string]*histogram)}
+	h.Observe(0.05, "bill")
+	h.Observe(0.5, "bill")
+	h.Observe(3, "bill")
+
+	var buf strings.Builder
+	h.write(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE test_seconds histogram",
+		`test_seconds_bucket{feature="bill",le="0.1"} 1`,
+		`test_seconds_bucket{feature="bill",le="1"} 2`,
+		`test_seconds_bucket{feature="bill",le="+Inf"} 3`,
+		`test_seconds_sum{feature="bill"} 3.55`,
+		`test_seconds_count{feature="bill"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestCounterVecInc(t *testing.T) {
+	c := &CounterVec{name: "test_total", help: "test", labels: []string{"feature", "result"}, series: make(map[string]*counter)}
+	c.Inc("bill", "handled")
+	c.Inc("bill", "handled")
+	c.Inc("bill", "error")
+
+	if got := c.Value("bill", "handled"); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+	if got := c.Value("bill", "unhandled"); got != 0 {
+		t.Fatalf("expected 0, got %d", got)
+	}
+
+	var buf strings.Builder
+	c.write(&buf)
+	if !strings.Contains(buf.String(), `test_total{feature="bill",result="error"} 1`) {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -23,6 +23,7 @@ type Config struct {
 	URI      string        // MongoDB 连接 URI，例如 "mongodb://localhost:27017"
 	Database string        // 数据库名称
 	Timeout  time.Duration // 连接超时时间
+	SkipPing bool          // 创建时不验证连接，由调用方自行等待就绪（见 Ping）
 }
 
 // NewClient 初始化 MongoDB 客户端
@@ -54,9 +55,12 @@ func NewClient(cfg Config) (*Client, error) {
 	}
 
 	// 验证连接
-	err = client.Ping(ctx, readpref.Primary())
-	if err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	if !cfg.SkipPing {
+		err = client.Ping(ctx, readpref.Primary())
+		if err != nil {
+			_ = client.Disconnect(context.Background())
+			return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		}
 	}
 
 	return &Client{
@@ -81,6 +85,14 @@ func (c *Client) Database() *mongo.Database {
 	return c.Client.Database(c.dbName)
 }
 
+// DatabaseNamed 返回指定名称的数据库句柄（多 Bot 实例共享连接、隔离数据）
+func (c *Client) DatabaseNamed(name string) *mongo.Database {
+	if c.Client == nil {
+		return nil
+	}
+	return c.Client.Database(name)
+}
+
 // Ping 验证与 MongoDB 的连接
 func (c *Client) Ping(ctx context.Context) error {
 	if c.Client == nil {
@@ -90,12 +102,13 @@ func (c *Client) Ping(ctx context.Context) error {
 }
 
 // InitFromConfig 从应用配置初始化 MongoDB 客户端
-// 这是一个便捷函数，封装了配置转换和客户端创建逻辑
+// 这是一个便捷函数，封装了配置转换和客户端创建逻辑；不验证连接，启动时由 app 按重试策略等待就绪
 func InitFromConfig(cfg *config.Config) (*Client, error) {
 	mongoCfg := Config{
 		URI:      cfg.MongoURI,
 		Database: cfg.MongoDBName,
 		Timeout:  5 * time.Second,
+		SkipPing: true,
 	}
 	return NewClient(mongoCfg)
 }
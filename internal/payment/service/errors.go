@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"strings"
 
 	"go_bot/internal/payment/sifang"
@@ -39,3 +42,71 @@ func IsOrderNotFoundError(err error) bool {
 
 	return false
 }
+
+// IsTransientError reports whether err is a timeout or transport-level failure that may
+// succeed on a later retry, as opposed to a business error returned by Sifang.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr *sifang.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+
+	// 非业务错误（HTTP 状态码异常、响应解析失败等）均视为暂时性故障
+	return true
+}
+
+// authErrorKeywords 四方返回签名或鉴权失败时错误信息中常见的关键词
+var authErrorKeywords = []string{"sign", "签名", "验签", "密钥", "unauthorized", "forbidden", "access_key", "鉴权", "认证"}
+
+// IsAuthError reports whether err means Sifang rejected the request signature or credentials.
+func IsAuthError(err error) bool {
+	var apiErr *sifang.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.Code == 401 || apiErr.Code == 403 {
+		return true
+	}
+
+	message := strings.ToLower(strings.TrimSpace(apiErr.Message))
+	for _, keyword := range authErrorKeywords {
+		if strings.Contains(message, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMaxHistoryDays 历史余额默认最多可查询的天数
+const DefaultMaxHistoryDays = 365
+
+// HistoryWindowError 表示请求的历史余额日期超出可查询范围
+type HistoryWindowError struct {
+	MaxDays int
+}
+
+func (e *HistoryWindowError) Error() string {
+	return fmt.Sprintf("仅支持查询最近 %d 天的历史余额", e.MaxDays)
+}
+
+// ErrNotConfigured 表示当前进程未配置支付服务
+var ErrNotConfigured = errors.New("未配置支付服务")
+
+// Available 判断支付服务是否可用，所有依赖支付服务的入口都应先调用
+func Available(svc Service) bool {
+	return svc != nil
+}
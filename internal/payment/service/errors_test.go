@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -53,3 +54,49 @@ func TestIsOrderNotFoundError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), want: true},
+		{name: "http error", err: fmt.Errorf("sifang http error: status=502, body=bad gateway"), want: true},
+		{name: "api not found", err: fmt.Errorf("wrapped: %w", &sifang.APIError{Code: 404, Message: "not found"}), want: false},
+		{name: "api business error", err: fmt.Errorf("wrapped: %w", &sifang.APIError{Code: 1, Message: "参数错误"}), want: false},
+		{name: "api server error", err: fmt.Errorf("wrapped: %w", &sifang.APIError{Code: 500, Message: "server error"}), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Fatalf("IsTransientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "api unauthorized code", err: fmt.Errorf("wrapped: %w", &sifang.APIError{Code: 401, Message: "denied"}), want: true},
+		{name: "api sign message", err: &sifang.APIError{Code: 1, Message: "签名错误"}, want: true},
+		{name: "api english sign message", err: &sifang.APIError{Code: 1, Message: "Invalid Sign"}, want: true},
+		{name: "api business error", err: &sifang.APIError{Code: 1, Message: "参数错误"}, want: false},
+		{name: "network error", err: fmt.Errorf("request sifang api failed: %w", context.DeadlineExceeded), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.want {
+				t.Fatalf("IsAuthError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,7 +16,7 @@ import (
 type Service interface {
 	GetBalance(ctx context.Context, merchantID int64, historyDays int) (*Balance, error)
 	GetSummaryByDay(ctx context.Context, merchantID int64, date time.Time) (*SummaryByDay, error)
-	GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time) ([]*SummaryByDayChannel, error)
+	GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time, channelCode string) ([]*SummaryByDayChannel, error)
 	GetSummaryByDayByPZID(ctx context.Context, pzid string, start, end time.Time) (*SummaryByPZID, error)
 	GetChannelStatus(ctx context.Context, merchantID int64) ([]*ChannelStatus, error)
 	GetWithdrawList(ctx context.Context, merchantID int64, start, end time.Time, page, pageSize int) (*WithdrawList, error)
@@ -26,14 +26,49 @@ type Service interface {
 	FindOrderChannelBinding(ctx context.Context, merchantID int64, orderNo string, numberType OrderNumberType) (*OrderChannelBinding, error)
 }
 
+// RawResponseSource 可选能力：查询商户最近一次解码失败或结果为空时留存的上游原始响应
+type RawResponseSource interface {
+	RawCaptureEnabled() bool
+	LastRawResponse(merchantID int64) (*sifang.RawResponse, bool)
+}
+
 type sifangService struct {
-	client *sifang.Client
+	client              *sifang.Client
+	maxHistoryDays      int
+	withdrawPageSize    int // 提款查询未指定条数时的默认单页条数
+	withdrawPageSizeMax int // 提款查询单页条数上限（不超过 MaxWithdrawPageSize）
+}
+
+// ServiceOption 自定义四方支付服务
+type ServiceOption func(*sifangService)
+
+// WithMaxHistoryDays 设置历史余额最多可查询的天数（<=0 时使用默认值）
+func WithMaxHistoryDays(days int) ServiceOption {
+	return func(s *sifangService) {
+		if days > 0 {
+			s.maxHistoryDays = days
+		}
+	}
+}
+
+// WithWithdrawPageSize 设置提款查询的默认单页条数与单页上限（<=0 时使用默认值，上限不超过 MaxWithdrawPageSize）
+func WithWithdrawPageSize(defaultSize, maxSize int) ServiceOption {
+	return func(s *sifangService) {
+		if maxSize > 0 {
+			s.withdrawPageSizeMax = min(maxSize, MaxWithdrawPageSize)
+		}
+		if defaultSize > 0 {
+			s.withdrawPageSize = defaultSize
+		}
+		s.withdrawPageSize = min(s.withdrawPageSize, s.withdrawPageSizeMax)
+	}
 }
 
 // SendMoneyOptions 下发请求的可选参数
 type SendMoneyOptions struct {
 	BankID     string
 	GoogleCode string
+	Remark     string // 下发备注，便于后续对账
 }
 
 // SendMoneyResult 表示下发接口的返回结果
@@ -44,6 +79,7 @@ type SendMoneyResult struct {
 	PendingWithdraw string
 	FrozenToday     string
 	Fee             string
+	Remark          string
 }
 
 // CreateOrderRequest 模拟下单请求参数
@@ -83,10 +119,13 @@ type CreateOrderResult struct {
 }
 
 // OrderDetail 订单详情结构
+//
+// 查询结果结构体（OrderDetail、Balance、SummaryByDay、SummaryByDayChannel、WithdrawList 及其子结构）
+// 带有 snake_case 的 JSON 标签，可直接由 HTTP API / CLI 输出；字段名视为对外契约，不要随意修改。
 type OrderDetail struct {
-	Order      *Order
-	Extended   *OrderExtended
-	NotifyLogs []*NotifyLog
+	Order      *Order         `json:"order"`
+	Extended   *OrderExtended `json:"extended"`
+	NotifyLogs []*NotifyLog   `json:"notify_logs"`
 }
 
 // OrderChannelBinding 描述订单所属渠道/接口信息
@@ -103,72 +142,75 @@ type OrderChannelBinding struct {
 	StatusCode          string
 	Status              string
 	StatusText          string
+	PaymentURL          string // 支付链接
+	ManageURL           string // 上游后台订单管理链接
 }
 
 // Order 订单基础信息
 type Order struct {
-	MerchantOrderNo  string
-	PlatformOrderNo  string
-	Amount           string
-	RealAmount       string
-	Status           string
-	StatusText       string
-	NotifyStatus     string
-	NotifyStatusText string
-	NotifyTimes      string
-	NotifyLastError  string
-	ChannelCode      string
-	ChannelName      string
-	CreatedAt        string
-	PaidAt           string
-	CompletedAt      string
-	ExpiredAt        string
-	NotifyURL        string
-	ReturnURL        string
-	Description      string
-	Attach           string
-	ClientIP         string
-	Currency         string
-	UserID           string
-	PaymentURL       string
-	BankCode         string
-	BankAccount      string
-	BankAccountName  string
-	BankBranch       string
-	BuyerName        string
-	BuyerID          string
-	Extra            map[string]string
+	MerchantOrderNo  string            `json:"merchant_order_no"`
+	PlatformOrderNo  string            `json:"platform_order_no"`
+	Amount           string            `json:"amount"`
+	RealAmount       string            `json:"real_amount"`
+	Status           string            `json:"status"`
+	StatusText       string            `json:"status_text"`
+	NotifyStatus     string            `json:"notify_status"`
+	NotifyStatusText string            `json:"notify_status_text"`
+	NotifyTimes      string            `json:"notify_times"`
+	NotifyLastError  string            `json:"notify_last_error"`
+	ChannelCode      string            `json:"channel_code"`
+	ChannelName      string            `json:"channel_name"`
+	CreatedAt        string            `json:"created_at"`
+	PaidAt           string            `json:"paid_at"`
+	CompletedAt      string            `json:"completed_at"`
+	ExpiredAt        string            `json:"expired_at"`
+	NotifyURL        string            `json:"notify_url"`
+	ReturnURL        string            `json:"return_url"`
+	Description      string            `json:"description"`
+	Attach           string            `json:"attach"`
+	ClientIP         string            `json:"client_ip"`
+	Currency         string            `json:"currency"`
+	UserID           string            `json:"user_id"`
+	PaymentURL       string            `json:"payment_url"`
+	ManageURL        string            `json:"manage_url"`
+	BankCode         string            `json:"bank_code"`
+	BankAccount      string            `json:"bank_account"`
+	BankAccountName  string            `json:"bank_account_name"`
+	BankBranch       string            `json:"bank_branch"`
+	BuyerName        string            `json:"buyer_name"`
+	BuyerID          string            `json:"buyer_id"`
+	Extra            map[string]string `json:"extra,omitempty"`
 }
 
 // OrderExtended 订单扩展信息
 type OrderExtended struct {
-	OrderID          string
-	MerchantID       string
-	ChannelID        string
-	ChannelFee       string
-	ChannelFeeRate   string
-	ChannelCost      string
-	DeductStatus     string
-	DeductStatusText string
-	DeductAmount     string
-	DeductReason     string
-	RiskFlag         bool
-	Manual           bool
-	Remark           string
-	CreatedAt        string
-	UpdatedAt        string
+	OrderID          string `json:"order_id"`
+	MerchantID       string `json:"merchant_id"`
+	ChannelID        string `json:"channel_id"`
+	ChannelFee       string `json:"channel_fee"`
+	ChannelFeeRate   string `json:"channel_fee_rate"`
+	ChannelCost      string `json:"channel_cost"`
+	DeductStatus     string `json:"deduct_status"`
+	DeductStatusText string `json:"deduct_status_text"`
+	DeductAmount     string `json:"deduct_amount"`
+	DeductReason     string `json:"deduct_reason"`
+	RiskFlag         bool   `json:"risk_flag"`
+	Manual           bool   `json:"manual"`
+	Remark           string `json:"remark"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
 }
 
 // NotifyLog 订单回调日志
 type NotifyLog struct {
-	Status      string
-	StatusText  string
-	Request     string
-	Response    string
-	URL         string
-	AttemptedAt string
-	Duration    string
-	Retry       string
+	Status      string `json:"status"`
+	StatusText  string `json:"status_text"`
+	Request     string `json:"request"`
+	Response    string `json:"response"`
+	URL         string `json:"url"`
+	AttemptedAt string `json:"attempted_at"`
+	Duration    string `json:"duration"`
+	Retry       string `json:"retry"`
 }
 
 // OrderNumberType 标识订单号类型
@@ -187,8 +229,39 @@ const orderDetailTimeout = 8 * time.Second
 const orderChannelLookupTimeout = 6 * time.Second
 
 // NewSifangService 创建基于四方支付的服务实现
-func NewSifangService(client *sifang.Client) Service {
-	return &sifangService{client: client}
+func NewSifangService(client *sifang.Client, opts ...ServiceOption) Service {
+	svc := &sifangService{
+		client:              client,
+		maxHistoryDays:      DefaultMaxHistoryDays,
+		withdrawPageSize:    DefaultWithdrawPageSize,
+		withdrawPageSizeMax: MaxWithdrawPageSize,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+// LastRawResponse 返回商户最近一次解码失败或结果为空时留存的上游原始响应
+func (s *sifangService) LastRawResponse(merchantID int64) (*sifang.RawResponse, bool) {
+	return s.client.LastRawResponse(merchantID)
+}
+
+// RawCaptureEnabled 是否开启了原始响应留存
+func (s *sifangService) RawCaptureEnabled() bool {
+	return s.client.RawCaptureEnabled()
+}
+
+// captureRaw 将已解析为 map 的 data 重新序列化后留存，未开启留存时不做序列化
+func (s *sifangService) captureRaw(action string, merchantID int64, reason string, data map[string]interface{}) {
+	if !s.client.RawCaptureEnabled() {
+		return
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	s.client.RecordRawResponse(action, merchantID, reason, body)
 }
 
 func (s *sifangService) GetBalance(ctx context.Context, merchantID int64, historyDays int) (*Balance, error) {
@@ -199,8 +272,8 @@ func (s *sifangService) GetBalance(ctx context.Context, merchantID int64, histor
 	if historyDays < 0 {
 		historyDays = 0
 	}
-	if historyDays > 365 {
-		historyDays = 365
+	if historyDays > s.maxHistoryDays {
+		return nil, &HistoryWindowError{MaxDays: s.maxHistoryDays}
 	}
 
 	business := map[string]string{
@@ -240,6 +313,7 @@ func (s *sifangService) GetSummaryByDay(ctx context.Context, merchantID int64, d
 
 	summary, err := decodeSummaryByDay(raw)
 	if err != nil {
+		s.client.RecordRawResponse("summarybyday", merchantID, err.Error(), raw)
 		return nil, err
 	}
 
@@ -260,7 +334,8 @@ func (s *sifangService) GetSummaryByDay(ctx context.Context, merchantID int64, d
 	return summary, nil
 }
 
-func (s *sifangService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time) ([]*SummaryByDayChannel, error) {
+// GetSummaryByDayByChannel 按通道查询日账单，channelCode 为空时查询全部通道
+func (s *sifangService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time, channelCode string) ([]*SummaryByDayChannel, error) {
 	if merchantID == 0 {
 		return nil, fmt.Errorf("merchant id is required")
 	}
@@ -271,7 +346,7 @@ func (s *sifangService) GetSummaryByDayByChannel(ctx context.Context, merchantID
 	business := map[string]string{
 		"start_time":    start.Format("2006-01-02 15:04:05"),
 		"end_time":      end.Format("2006-01-02 15:04:05"),
-		"channel_codes": "",
+		"channel_codes": strings.TrimSpace(channelCode),
 	}
 
 	var raw json.RawMessage
@@ -281,6 +356,7 @@ func (s *sifangService) GetSummaryByDayByChannel(ctx context.Context, merchantID
 
 	summaries, err := decodeSummaryByDayChannel(raw)
 	if err != nil {
+		s.client.RecordRawResponse("summarybydaychannel", merchantID, err.Error(), raw)
 		return nil, err
 	}
 
@@ -320,7 +396,12 @@ func (s *sifangService) GetSummaryByDayByPZID(ctx context.Context, pzid string,
 		return nil, err
 	}
 
-	return decodeSummaryByPZID(raw)
+	summary, err := decodeSummaryByPZID(raw)
+	if err != nil {
+		s.client.RecordRawResponse("summarybydaypzid", 0, err.Error(), raw)
+		return nil, err
+	}
+	return summary, nil
 }
 
 func (s *sifangService) GetChannelStatus(ctx context.Context, merchantID int64) ([]*ChannelStatus, error) {
@@ -335,6 +416,7 @@ func (s *sifangService) GetChannelStatus(ctx context.Context, merchantID int64)
 
 	statuses, err := decodeChannelStatus(raw)
 	if err != nil {
+		s.client.RecordRawResponse("channelstatus", merchantID, err.Error(), raw)
 		return nil, err
 	}
 
@@ -350,10 +432,10 @@ func (s *sifangService) GetWithdrawList(ctx context.Context, merchantID int64, s
 		page = 1
 	}
 	if pageSize <= 0 {
-		pageSize = 20
+		pageSize = s.withdrawPageSize
 	}
-	if pageSize > 100 {
-		pageSize = 100
+	if pageSize > s.withdrawPageSizeMax {
+		pageSize = s.withdrawPageSizeMax
 	}
 
 	business := map[string]string{
@@ -368,7 +450,12 @@ func (s *sifangService) GetWithdrawList(ctx context.Context, merchantID int64, s
 		return nil, err
 	}
 
-	return decodeWithdrawList(raw)
+	list, err := decodeWithdrawList(raw)
+	if err != nil {
+		s.client.RecordRawResponse("withdrawlist", merchantID, err.Error(), raw)
+		return nil, err
+	}
+	return list, nil
 }
 
 func (s *sifangService) SendMoney(ctx context.Context, merchantID int64, amount float64, opts SendMoneyOptions) (*SendMoneyResult, error) {
@@ -392,6 +479,10 @@ func (s *sifangService) SendMoney(ctx context.Context, merchantID int64, amount
 	if strings.TrimSpace(opts.GoogleCode) != "" {
 		business["google_code"] = strings.TrimSpace(opts.GoogleCode)
 	}
+	remark := strings.TrimSpace(opts.Remark)
+	if remark != "" {
+		business["remark"] = remark
+	}
 
 	raw := make(map[string]interface{})
 	if err := s.client.Post(ctx, "sendmoney", merchantID, business, &raw); err != nil {
@@ -402,6 +493,9 @@ func (s *sifangService) SendMoney(ctx context.Context, merchantID int64, amount
 	if result != nil && strings.TrimSpace(result.MerchantID) == "" {
 		result.MerchantID = strconv.FormatInt(merchantID, 10)
 	}
+	if result != nil && result.Remark == "" {
+		result.Remark = remark
+	}
 
 	return result, nil
 }
@@ -517,16 +611,15 @@ func (s *sifangService) GetOrderDetail(ctx context.Context, merchantID int64, or
 		cancel()
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				return nil, fmt.Errorf("get order detail timed out (%s number)", describeOrderNumberType(kind))
+				return nil, fmt.Errorf("get order detail timed out (%s number): %w", describeOrderNumberType(kind), err)
 			}
 
-			var apiErr *sifang.APIError
-			if errors.As(err, &apiErr) {
-				lastErr = fmt.Errorf("get order detail failed with sifang error (%s number): %w", describeOrderNumberType(kind), err)
-			} else {
-				lastErr = fmt.Errorf("get order detail failed (%s number): %w", describeOrderNumberType(kind), err)
+			// 超时/网络等暂时性故障换用另一种单号重试同样会失败，直接返回，避免耗尽超时预算并掩盖真实原因
+			if IsTransientError(err) {
+				return nil, fmt.Errorf("get order detail failed with transient error (%s number): %w", describeOrderNumberType(kind), err)
 			}
 
+			lastErr = fmt.Errorf("get order detail failed with sifang error (%s number): %w", describeOrderNumberType(kind), err)
 			if idx < len(lookupOrder)-1 {
 				continue
 			}
@@ -537,6 +630,7 @@ func (s *sifangService) GetOrderDetail(ctx context.Context, merchantID int64, or
 		detail := decodeOrderDetail(raw)
 		if detail == nil || detail.Order == nil {
 			lastErr = fmt.Errorf("order detail is empty (%s number)", describeOrderNumberType(kind))
+			s.captureRaw("orderdetail", merchantID, lastErr.Error(), raw)
 			if idx < len(lookupOrder)-1 {
 				continue
 			}
@@ -608,6 +702,7 @@ func (s *sifangService) FindOrderChannelBinding(ctx context.Context, merchantID
 		binding := decodeOrderChannelBinding(raw)
 		if binding == nil {
 			lastErr = fmt.Errorf("order channel binding is empty (%s number)", describeOrderNumberType(kind))
+			s.captureRaw("findpzidbyorder", merchantID, lastErr.Error(), raw)
 			if idx < len(lookupOrder)-1 {
 				continue
 			}
@@ -650,35 +745,35 @@ func describeOrderNumberType(numberType OrderNumberType) string {
 
 // Balance 表示账户余额信息
 type Balance struct {
-	MerchantID      string
-	Balance         string
-	PendingWithdraw string
-	Currency        string
-	UpdatedAt       string
-	HistoryDays     int
-	HistoryBalance  string
+	MerchantID      string `json:"merchant_id"`
+	Balance         string `json:"balance"`
+	PendingWithdraw string `json:"pending_withdraw"`
+	Currency        string `json:"currency"`
+	UpdatedAt       string `json:"updated_at"`
+	HistoryDays     int    `json:"history_days"`
+	HistoryBalance  string `json:"history_balance"`
 }
 
 // SummaryByDay 表示按日汇总数据
 type SummaryByDay struct {
-	Date           string
-	OrderCount     string
-	SuccessCount   string
-	TotalAmount    string
-	MerchantIncome string
-	AgentIncome    string
+	Date           string `json:"date"`
+	OrderCount     string `json:"order_count"`
+	SuccessCount   string `json:"success_count"`
+	TotalAmount    string `json:"total_amount"`
+	MerchantIncome string `json:"merchant_income"`
+	AgentIncome    string `json:"agent_income"`
 }
 
 // SummaryByDayChannel 表示按日按通道汇总数据
 type SummaryByDayChannel struct {
-	Date           string
-	ChannelCode    string
-	ChannelName    string
-	OrderCount     string
-	SuccessCount   string
-	TotalAmount    string
-	MerchantIncome string
-	AgentIncome    string
+	Date           string `json:"date"`
+	ChannelCode    string `json:"channel_code"`
+	ChannelName    string `json:"channel_name"`
+	OrderCount     string `json:"order_count"`
+	SuccessCount   string `json:"success_count"`
+	TotalAmount    string `json:"total_amount"`
+	MerchantIncome string `json:"merchant_income"`
+	AgentIncome    string `json:"agent_income"`
 }
 
 // SummaryByPZID 表示按日按上游配置 ID 汇总数据
@@ -717,23 +812,23 @@ type ChannelStatus struct {
 
 // Withdraw 表示提现记录
 type Withdraw struct {
-	WithdrawNo string
-	OrderNo    string
-	Amount     string
-	Fee        string
-	Status     string
-	CreatedAt  string
-	PaidAt     string
-	Channel    string
+	WithdrawNo string `json:"withdraw_no"`
+	OrderNo    string `json:"order_no"`
+	Amount     string `json:"amount"`
+	Fee        string `json:"fee"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	PaidAt     string `json:"paid_at"`
+	Channel    string `json:"channel"`
 }
 
 // WithdrawList 表示提现列表及分页信息
 type WithdrawList struct {
-	Page       int
-	PageSize   int
-	Total      int
-	TotalPages int
-	Items      []*Withdraw
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	Total      int         `json:"total"`
+	TotalPages int         `json:"total_pages"`
+	Items      []*Withdraw `json:"items"`
 }
 
 func decodeBalance(raw map[string]interface{}) *Balance {
@@ -766,6 +861,8 @@ func decodeOrderChannelBinding(raw map[string]interface{}) *OrderChannelBinding
 		StatusCode:          pickString(raw, "status_code", "status_num"),
 		Status:              pickString(raw, "status"),
 		StatusText:          pickString(raw, "status_text", "status_desc"),
+		PaymentURL:          pickString(raw, "payment_url", "pay_url", "cashier_url"),
+		ManageURL:           pickString(raw, "manage_url", "admin_url", "order_url"),
 	}
 
 	if binding.PZID == "" && binding.ChannelCode == "" &&
@@ -840,6 +937,7 @@ func buildOrder(value interface{}) *Order {
 		Currency:         pickString(m, "currency", "money_type"),
 		UserID:           pickString(m, "user_id", "uid", "member_id"),
 		PaymentURL:       pickString(m, "payment_url", "pay_url", "url", "cashier_url"),
+		ManageURL:        pickString(m, "manage_url", "admin_url", "order_url"),
 		BankCode:         pickString(m, "bank_code", "bank"),
 		BankAccount:      pickString(m, "bank_account", "account", "card_number"),
 		BankAccountName:  pickString(m, "bank_account_name", "account_name", "card_name"),
@@ -938,6 +1036,9 @@ func buildOrder(value interface{}) *Order {
 		"pay_url":            {},
 		"url":                {},
 		"cashier_url":        {},
+		"manage_url":         {},
+		"admin_url":          {},
+		"order_url":          {},
 		"bank_code":          {},
 		"bank":               {},
 		"bank_account":       {},
@@ -1394,6 +1495,7 @@ func decodeSendMoney(raw map[string]interface{}) *SendMoneyResult {
 		PendingWithdraw: stringify(raw["pending_withdraw"]),
 		FrozenToday:     stringify(raw["frozen_today"]),
 		Fee:             stringify(raw["fee"]),
+		Remark:          stringify(raw["remark"]),
 	}
 
 	if withdrawRaw, ok := raw["withdraw"]; ok && withdrawRaw != nil {
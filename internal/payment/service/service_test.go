@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -48,6 +49,8 @@ func TestDecodeOrderChannelBinding(t *testing.T) {
 		"status_code":            1,
 		"status":                 "success",
 		"status_text":            "已支付",
+		"pay_url":                "https://pay.example.com/M-1",
+		"manage_url":             "https://admin.example.com/order/M-1",
 	}
 
 	binding := decodeOrderChannelBinding(raw)
@@ -60,6 +63,9 @@ func TestDecodeOrderChannelBinding(t *testing.T) {
 	if binding.StatusText != "已支付" || binding.Status != "success" {
 		t.Fatalf("unexpected status: %#v", binding)
 	}
+	if binding.PaymentURL != "https://pay.example.com/M-1" || binding.ManageURL != "https://admin.example.com/order/M-1" {
+		t.Fatalf("unexpected order links: %#v", binding)
+	}
 }
 
 func TestDecodeOrderChannelBinding_Empty(t *testing.T) {
@@ -524,6 +530,71 @@ func TestSifangService_GetOrderDetail_Fallback(t *testing.T) {
 	}
 }
 
+func TestSifangService_GetOrderDetail_TimeoutFailsFast(t *testing.T) {
+	requestCount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	cfg := config.SifangConfig{
+		BaseURL:            ts.URL,
+		DefaultMerchantKey: "secret",
+		Timeout:            2 * time.Second,
+	}
+	client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	svc := NewSifangService(client)
+	_, err = svc.GetOrderDetail(ctx, 1001, "MER-1", OrderNumberTypeAuto)
+	if err == nil || !strings.Contains(err.Error(), "timed out (merchant number)") {
+		t.Fatalf("expected merchant number timeout error, got %v", err)
+	}
+	if requestCount != 1 {
+		t.Fatalf("expected no platform number fallback after timeout, got %d requests", requestCount)
+	}
+}
+
+func TestSifangService_GetOrderDetail_TransientErrorSkipsFallback(t *testing.T) {
+	requestCount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	cfg := config.SifangConfig{
+		BaseURL:            ts.URL,
+		DefaultMerchantKey: "secret",
+		Timeout:            2 * time.Second,
+	}
+	client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	svc := NewSifangService(client)
+	_, err = svc.GetOrderDetail(context.Background(), 1001, "MER-1", OrderNumberTypeAuto)
+	if err == nil || !strings.Contains(err.Error(), "transient error (merchant number)") {
+		t.Fatalf("expected transient merchant number error, got %v", err)
+	}
+	if IsOrderNotFoundError(err) {
+		t.Fatalf("transient error must not be reported as not found: %v", err)
+	}
+	if requestCount != 1 {
+		t.Fatalf("expected no platform number fallback after transient error, got %d requests", requestCount)
+	}
+}
+
 func TestSifangService_GetOrderDetail_NoData(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{}}`)
@@ -546,6 +617,61 @@ func TestSifangService_GetOrderDetail_NoData(t *testing.T) {
 	}
 }
 
+func TestSifangService_GetOrderDetail_EmptyRecordsRawResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"unexpected":{"bank_account":"6222000011112222"},"status":"pending"}}`)
+	}))
+	defer ts.Close()
+
+	newService := func(capture bool) Service {
+		cfg := config.SifangConfig{
+			BaseURL:            ts.URL,
+			DefaultMerchantKey: "secret",
+			Timeout:            2 * time.Second,
+			RawCaptureEnabled:  capture,
+		}
+		client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		return NewSifangService(client)
+	}
+
+	svc := newService(true)
+	if _, err := svc.GetOrderDetail(context.Background(), 1001, "MER-1", OrderNumberTypeMerchant); err == nil {
+		t.Fatalf("expected error for empty detail")
+	}
+
+	source, ok := svc.(RawResponseSource)
+	if !ok {
+		t.Fatalf("expected sifang service to expose raw responses")
+	}
+	entry, ok := source.LastRawResponse(1001)
+	if !ok {
+		t.Fatalf("expected raw response to be recorded")
+	}
+	if entry.Action != "orderdetail" || !strings.Contains(entry.Reason, "order detail is empty") {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if !strings.Contains(entry.Body, `"status":"pending"`) {
+		t.Fatalf("expected raw payload in body, got %s", entry.Body)
+	}
+	if strings.Contains(entry.Body, "6222000011112222") {
+		t.Fatalf("expected sensitive fields to be redacted, got %s", entry.Body)
+	}
+	if _, ok := source.LastRawResponse(2002); ok {
+		t.Fatalf("expected no raw response for other merchants")
+	}
+
+	disabled := newService(false)
+	_, _ = disabled.GetOrderDetail(context.Background(), 1001, "MER-1", OrderNumberTypeMerchant)
+	if source := disabled.(RawResponseSource); source.RawCaptureEnabled() {
+		t.Fatalf("expected capture disabled by default")
+	} else if _, ok := source.LastRawResponse(1001); ok {
+		t.Fatalf("expected nothing recorded when capture is disabled")
+	}
+}
+
 func TestSifangService_GetOrderDetail_APIError(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, `{"code":500,"message":"server error","data":null}`)
@@ -822,6 +948,62 @@ func TestDecodeSendMoney(t *testing.T) {
 	}
 }
 
+func TestSifangService_SendMoneyRemark(t *testing.T) {
+	cases := []struct {
+		name       string
+		remark     string
+		wantRemark string
+		wantSet    bool
+	}{
+		{name: "with remark", remark: "  对账-1026 ", wantRemark: "对账-1026", wantSet: true},
+		{name: "empty remark", remark: "   ", wantSet: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				gotSet    bool
+				gotRemark string
+			)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("parse form: %v", err)
+				}
+				_, gotSet = r.Form["remark"]
+				gotRemark = r.Form.Get("remark")
+				fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"merchant_id":"1001","balance_after":"900.00"}}`)
+			}))
+			defer ts.Close()
+
+			cfg := config.SifangConfig{
+				BaseURL:            ts.URL,
+				DefaultMerchantKey: "secret",
+				Timeout:            2 * time.Second,
+			}
+			client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+			if err != nil {
+				t.Fatalf("new client: %v", err)
+			}
+
+			svc := NewSifangService(client)
+			result, err := svc.SendMoney(context.Background(), 1001, 100, SendMoneyOptions{Remark: tc.remark})
+			if err != nil {
+				t.Fatalf("SendMoney returned error: %v", err)
+			}
+
+			if gotSet != tc.wantSet {
+				t.Fatalf("remark presence mismatch: got %v want %v", gotSet, tc.wantSet)
+			}
+			if gotRemark != tc.wantRemark {
+				t.Fatalf("unexpected remark in form: got %q want %q", gotRemark, tc.wantRemark)
+			}
+			if result.Remark != tc.wantRemark {
+				t.Fatalf("unexpected remark in result: got %q want %q", result.Remark, tc.wantRemark)
+			}
+		})
+	}
+}
+
 func TestDecodeSendMoney_Empty(t *testing.T) {
 	if decodeSendMoney(map[string]interface{}{}) != nil {
 		t.Fatalf("expected nil for empty map")
@@ -925,3 +1107,155 @@ func TestSifangService_CreateOrder(t *testing.T) {
 		t.Fatalf("unexpected payment url: %#v", result)
 	}
 }
+
+func TestOrderDetailMarshalJSON(t *testing.T) {
+	detail := &OrderDetail{
+		Order: &Order{
+			MerchantOrderNo: "M-1",
+			PlatformOrderNo: "P-1",
+			Amount:          "100.00",
+			Status:          "1",
+			NotifyURL:       "https://example.com/notify",
+			ClientIP:        "127.0.0.1",
+			Extra:           map[string]string{"memo": "vip"},
+		},
+		Extended: &OrderExtended{OrderID: "9", ChannelFeeRate: "0.05", RiskFlag: true},
+		NotifyLogs: []*NotifyLog{
+			{Status: "success", URL: "https://example.com/notify", Retry: "1"},
+		},
+	}
+
+	data, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("marshal order detail: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal order detail: %v", err)
+	}
+
+	order, ok := got["order"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected order object, got %s", data)
+	}
+	if order["merchant_order_no"] != "M-1" || order["platform_order_no"] != "P-1" || order["amount"] != "100.00" {
+		t.Fatalf("unexpected order fields: %s", data)
+	}
+	if order["notify_url"] != "https://example.com/notify" || order["client_ip"] != "127.0.0.1" {
+		t.Fatalf("unexpected acronym field names: %s", data)
+	}
+	extra, ok := order["extra"].(map[string]interface{})
+	if !ok || extra["memo"] != "vip" {
+		t.Fatalf("expected extra map, got %s", data)
+	}
+
+	extended, ok := got["extended"].(map[string]interface{})
+	if !ok || extended["order_id"] != "9" || extended["channel_fee_rate"] != "0.05" || extended["risk_flag"] != true {
+		t.Fatalf("unexpected extended fields: %s", data)
+	}
+
+	logs, ok := got["notify_logs"].([]interface{})
+	if !ok || len(logs) != 1 {
+		t.Fatalf("expected one notify log, got %s", data)
+	}
+	if log := logs[0].(map[string]interface{}); log["status"] != "success" || log["url"] != "https://example.com/notify" {
+		t.Fatalf("unexpected notify log fields: %s", data)
+	}
+
+	// 未设置 Extra 时省略该字段
+	data, err = json.Marshal(&Order{MerchantOrderNo: "M-2"})
+	if err != nil {
+		t.Fatalf("marshal order: %v", err)
+	}
+	if strings.Contains(string(data), `"extra"`) {
+		t.Fatalf("expected extra to be omitted, got %s", data)
+	}
+}
+
+func TestSifangService_GetBalance_HistoryWindow(t *testing.T) {
+	requestCount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"merchant_id":"1001","balance":"10.00","history_balance":"8.00"}}`)
+	}))
+	defer ts.Close()
+
+	cfg := config.SifangConfig{
+		BaseURL:            ts.URL,
+		DefaultMerchantKey: "secret",
+		Timeout:            2 * time.Second,
+	}
+	client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	svc := NewSifangService(client, WithMaxHistoryDays(30))
+	_, err = svc.GetBalance(context.Background(), 1001, 31)
+	var windowErr *HistoryWindowError
+	if !errors.As(err, &windowErr) || windowErr.MaxDays != 30 {
+		t.Fatalf("expected history window error, got %v", err)
+	}
+	if requestCount != 0 {
+		t.Fatalf("expected no upstream request for out-of-window date, got %d", requestCount)
+	}
+
+	balance, err := svc.GetBalance(context.Background(), 1001, 30)
+	if err != nil {
+		t.Fatalf("GetBalance returned error: %v", err)
+	}
+	if balance.HistoryBalance != "8.00" || balance.HistoryDays != 30 {
+		t.Fatalf("unexpected balance: %#v", balance)
+	}
+}
+
+func TestSifangService_GetWithdrawList_PageSize(t *testing.T) {
+	var pageSizes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		pageSizes = append(pageSizes, r.Form.Get("page_size"))
+		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"items":[],"page":1}}`)
+	}))
+	defer ts.Close()
+
+	cfg := config.SifangConfig{
+		BaseURL:            ts.URL,
+		DefaultMerchantKey: "secret",
+		Timeout:            2 * time.Second,
+	}
+	client, err := sifang.NewClient(cfg, sifang.WithHTTPClient(ts.Client()), sifang.WithNowFunc(func() time.Time { return time.Unix(1700000000, 0) }))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		opts     []ServiceOption
+		pageSize int
+		want     string
+	}{
+		{name: "builtin default", pageSize: 0, want: "20"},
+		{name: "builtin ceiling", pageSize: 500, want: "100"},
+		{name: "configured default", opts: []ServiceOption{WithWithdrawPageSize(50, 80)}, pageSize: 0, want: "50"},
+		{name: "configured max clamps", opts: []ServiceOption{WithWithdrawPageSize(50, 80)}, pageSize: 90, want: "80"},
+		{name: "explicit size kept", opts: []ServiceOption{WithWithdrawPageSize(50, 80)}, pageSize: 10, want: "10"},
+		{name: "max above hard cap", opts: []ServiceOption{WithWithdrawPageSize(0, 300)}, pageSize: 200, want: "100"},
+		{name: "default above max", opts: []ServiceOption{WithWithdrawPageSize(60, 30)}, pageSize: 0, want: "30"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pageSizes = nil
+			svc := NewSifangService(client, tc.opts...)
+			if _, err := svc.GetWithdrawList(context.Background(), 1001, time.Now(), time.Now(), 1, tc.pageSize); err != nil {
+				t.Fatalf("GetWithdrawList returned error: %v", err)
+			}
+			if len(pageSizes) != 1 || pageSizes[0] != tc.want {
+				t.Fatalf("expected page_size=%s, got %v", tc.want, pageSizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultWithdrawPageSize 提款查询未指定条数时的默认单页条数
+	DefaultWithdrawPageSize = 20
+	// MaxWithdrawPageSize 提款查询单页条数的硬上限（上游最大 100），配置值不能超过该值
+	MaxWithdrawPageSize = 100
+	// withdrawPageSize 自动翻页时请求的单页条数，实际条数受服务配置的上限约束
+	withdrawPageSize = MaxWithdrawPageSize
+	// maxWithdrawPages 自动翻页的页数上限，防止上游分页信息异常导致死循环
+	maxWithdrawPages = 500
+)
+
+// EachWithdraw 自动翻页遍历时间范围内的全部提款记录，逐条回调 fn，fn 返回错误时停止遍历
+func EachWithdraw(ctx context.Context, svc Service, merchantID int64, start, end time.Time, fn func(*Withdraw) error) error {
+	if !Available(svc) {
+		return ErrNotConfigured
+	}
+
+	for page := 1; page <= maxWithdrawPages; page++ {
+		list, err := svc.GetWithdrawList(ctx, merchantID, start, end, page, withdrawPageSize)
+		if err != nil {
+			return fmt.Errorf("get withdraw list page %d: %w", page, err)
+		}
+		if list == nil || len(list.Items) == 0 {
+			return nil
+		}
+
+		for _, item := range list.Items {
+			if item == nil {
+				continue
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if list.TotalPages > 0 && page >= list.TotalPages {
+			return nil
+		}
+		pageSize := withdrawPageSize
+		if list.PageSize > 0 {
+			pageSize = list.PageSize
+		}
+		if list.TotalPages == 0 && len(list.Items) < pageSize {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("withdraw list exceeds %d pages", maxWithdrawPages)
+}
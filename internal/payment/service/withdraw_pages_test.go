@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type pagedWithdrawService struct {
+	Service
+	total int
+	pages []int
+}
+
+func (s *pagedWithdrawService) GetWithdrawList(ctx context.Context, merchantID int64, start, end time.Time, page, pageSize int) (*WithdrawList, error) {
+	s.pages = append(s.pages, page)
+	list := &WithdrawList{Page: page, PageSize: pageSize, Total: s.total, TotalPages: (s.total + pageSize - 1) / pageSize}
+	for i := (page - 1) * pageSize; i < s.total && i < page*pageSize; i++ {
+		list.Items = append(list.Items, &Withdraw{WithdrawNo: strconv.Itoa(i)})
+	}
+	return list, nil
+}
+
+func TestEachWithdrawFollowsAllPages(t *testing.T) {
+	svc := &pagedWithdrawService{total: 250}
+
+	var seen []string
+	err := EachWithdraw(context.Background(), svc, 1, time.Time{}, time.Time{}, func(item *Withdraw) error {
+		seen = append(seen, item.WithdrawNo)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachWithdraw returned error: %v", err)
+	}
+	if len(seen) != 250 || seen[249] != "249" {
+		t.Fatalf("expected 250 withdraws, got %d", len(seen))
+	}
+	if len(svc.pages) != 3 {
+		t.Fatalf("expected 3 page requests, got %v", svc.pages)
+	}
+}
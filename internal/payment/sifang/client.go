@@ -6,6 +6,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,8 +18,18 @@ import (
 
 	"go_bot/internal/config"
 	"go_bot/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	redactedValue     = "***"
+	debugBodyLogLimit = 4096
 )
 
+// sensitiveLogKeyFragments 字段名包含以下片段时不写入日志（签名、各类密钥、谷歌验证码、银行卡与证件信息）
+var sensitiveLogKeyFragments = []string{"sign", "key", "secret", "password", "token", "google_code", "bank_account", "account_no", "card", "id_no", "idcard"}
+
 // Client 封装与四方支付平台的 HTTP 通讯
 type Client struct {
 	baseURL            string
@@ -26,6 +37,8 @@ type Client struct {
 	masterKey          string
 	defaultMerchantKey string
 	merchantKeys       map[int64]string
+	debugLog           bool              // 开启后在 Debug 级别记录脱敏后的请求参数与响应体
+	rawStore           *rawResponseStore // 开启原始响应留存时非空
 
 	httpClient *http.Client
 	nowFunc    func() time.Time
@@ -60,6 +73,7 @@ func NewClient(cfg config.SifangConfig, opts ...Option) (*Client, error) {
 		masterKey:          cfg.MasterKey,
 		defaultMerchantKey: cfg.DefaultMerchantKey,
 		merchantKeys:       make(map[int64]string, len(cfg.MerchantKeys)),
+		debugLog:           cfg.DebugLog,
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
@@ -70,6 +84,10 @@ func NewClient(cfg config.SifangConfig, opts ...Option) (*Client, error) {
 		client.merchantKeys[id] = key
 	}
 
+	if cfg.RawCaptureEnabled {
+		client.rawStore = newRawResponseStore(cfg.RawCaptureRetention)
+	}
+
 	for _, opt := range opts {
 		opt(client)
 	}
@@ -77,6 +95,9 @@ func NewClient(cfg config.SifangConfig, opts ...Option) (*Client, error) {
 	return client, nil
 }
 
+// ErrMerchantKeyNotFound 表示本地未配置该商户的签名密钥（请求不会发出）
+var ErrMerchantKeyNotFound = errors.New("sifang merchant key not found")
+
 // APIError 表示四方支付业务错误
 type APIError struct {
 	Code    int
@@ -124,9 +145,12 @@ func (c *Client) Post(ctx context.Context, action string, merchantID int64, busi
 		form.Set(k, v)
 	}
 
-	logger.L().Infof("Sifang request: action=%s merchant_id=%d params=%v", action, merchantID, sanitizeParamsForLog(params))
-
 	endpoint := c.buildEndpoint(action)
+	logger.L().Infof("Sifang request: action=%s merchant_id=%d", action, merchantID)
+	if c.debugEnabled() {
+		logger.L().Debugf("Sifang request payload: endpoint=%s params=%v", endpoint, redactParamsForLog(params))
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request failed: %w", err)
@@ -145,11 +169,14 @@ func (c *Client) Post(ctx context.Context, action string, merchantID int64, busi
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		logger.L().Warnf("Sifang response: action=%s merchant_id=%d status=%d body=%s", action, merchantID, resp.StatusCode, truncate(string(body), 512))
+		logger.L().Warnf("Sifang response: action=%s merchant_id=%d status=%d body=%s", action, merchantID, resp.StatusCode, truncate(redactBodyForLog(body), 512))
 		return fmt.Errorf("sifang http error: status=%d, body=%s", resp.StatusCode, truncate(string(body), 256))
 	}
 
-	logger.L().Infof("Sifang response: action=%s merchant_id=%d status=%d body=%s", action, merchantID, resp.StatusCode, truncate(string(body), 512))
+	logger.L().Infof("Sifang response: action=%s merchant_id=%d status=%d bytes=%d", action, merchantID, resp.StatusCode, len(body))
+	if c.debugEnabled() {
+		logger.L().Debugf("Sifang response payload: endpoint=%s body=%s", endpoint, truncate(redactBodyForLog(body), debugBodyLogLimit))
+	}
 
 	var envelope struct {
 		Code    int             `json:"code"`
@@ -158,6 +185,7 @@ func (c *Client) Post(ctx context.Context, action string, merchantID int64, busi
 	}
 
 	if err := json.Unmarshal(body, &envelope); err != nil {
+		c.RecordRawResponse(action, merchantID, err.Error(), body)
 		return fmt.Errorf("decode sifang response failed: %w", err)
 	}
 
@@ -167,6 +195,7 @@ func (c *Client) Post(ctx context.Context, action string, merchantID int64, busi
 
 	if out != nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
 		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			c.RecordRawResponse(action, merchantID, err.Error(), body)
 			return fmt.Errorf("decode sifang data failed: %w", err)
 		}
 	}
@@ -202,7 +231,7 @@ func (c *Client) resolveSigningKey(merchantID int64) (string, error) {
 	if c.defaultMerchantKey != "" {
 		return c.defaultMerchantKey, nil
 	}
-	return "", fmt.Errorf("sifang merchant key not found for merchant %d", merchantID)
+	return "", fmt.Errorf("%w for merchant %d", ErrMerchantKeyNotFound, merchantID)
 }
 
 func computeSign(params map[string]string, secret string) string {
@@ -243,14 +272,61 @@ func truncate(s string, limit int) string {
 	return string(runes[:limit])
 }
 
-func sanitizeParamsForLog(params map[string]string) map[string]string {
+// debugEnabled 同时满足配置开关与日志级别时才记录报文
+func (c *Client) debugEnabled() bool {
+	return c.debugLog && logger.L().IsLevelEnabled(logrus.DebugLevel)
+}
+
+// redactParamsForLog 复制请求参数并对签名、密钥、银行卡等敏感字段脱敏
+func redactParamsForLog(params map[string]string) map[string]string {
 	clone := make(map[string]string, len(params))
 	for k, v := range params {
-		if strings.EqualFold(k, "sign") {
-			clone[k] = "***"
+		if isSensitiveLogKey(k) {
+			clone[k] = redactedValue
 			continue
 		}
 		clone[k] = v
 	}
 	return clone
 }
+
+// redactBodyForLog 对 JSON 响应体中的敏感字段脱敏，非 JSON 内容原样返回
+func redactBodyForLog(body []byte) string {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return string(body)
+	}
+	redactJSONValue(payload)
+	redacted, err := json.Marshal(payload)
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactJSONValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveLogKey(key) {
+				v[key] = redactedValue
+				continue
+			}
+			redactJSONValue(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactJSONValue(item)
+		}
+	}
+}
+
+func isSensitiveLogKey(key string) bool {
+	lower := strings.ToLower(strings.TrimSpace(key))
+	for _, fragment := range sensitiveLogKeyFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
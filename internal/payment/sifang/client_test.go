@@ -6,10 +6,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"go_bot/internal/config"
+	"go_bot/internal/logger"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 )
 
 func TestComputeSign(t *testing.T) {
@@ -145,3 +151,127 @@ func TestPostMissingMerchantKey(t *testing.T) {
 		t.Fatalf("expected error when merchant key missing")
 	}
 }
+
+func TestRedactParamsForLog(t *testing.T) {
+	params := map[string]string{
+		"merchant_id":  "1001",
+		"order_no":     "abc",
+		"sign":         "C233FA67",
+		"access_key":   "master-access",
+		"merchant_key": "merchant-secret",
+		"bank_account": "6222020000001234",
+		"google_code":  "123456",
+	}
+
+	redacted := redactParamsForLog(params)
+	for _, key := range []string{"sign", "access_key", "merchant_key", "bank_account", "google_code"} {
+		if redacted[key] != redactedValue {
+			t.Fatalf("expected %s to be redacted, got %q", key, redacted[key])
+		}
+	}
+	if redacted["merchant_id"] != "1001" || redacted["order_no"] != "abc" {
+		t.Fatalf("expected non-sensitive params kept, got %v", redacted)
+	}
+	if params["sign"] != "C233FA67" {
+		t.Fatalf("original params must not be modified")
+	}
+}
+
+func TestPostDebugLogRedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"message":"success","data":{"order":{"amount":"10.00","bank_account":"6222020000001234"}}}`))
+	}))
+	defer server.Close()
+
+	hook := logtest.NewLocal(logger.L())
+	defer hook.Reset()
+	previous := logger.L().GetLevel()
+	logger.L().SetLevel(logrus.DebugLevel)
+	defer logger.L().SetLevel(previous)
+
+	client, err := NewClient(config.SifangConfig{
+		BaseURL:      server.URL,
+		Timeout:      3 * time.Second,
+		DebugLog:     true,
+		MerchantKeys: map[int64]string{1001: "merchant-secret"},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if err := client.Post(context.Background(), "orderdetail", 1001, map[string]string{"merchant_order_no": "abc"}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	var payloads []string
+	for _, entry := range hook.AllEntries() {
+		if strings.Contains(entry.Message, "merchant-secret") || strings.Contains(entry.Message, "6222020000001234") {
+			t.Fatalf("secret leaked into log: %s", entry.Message)
+		}
+		if entry.Level == logrus.DebugLevel {
+			payloads = append(payloads, entry.Message)
+		}
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("expected request and response debug entries, got %v", payloads)
+	}
+	if !strings.Contains(payloads[0], "/orderdetail") || !strings.Contains(payloads[0], "merchant_order_no:abc") {
+		t.Fatalf("expected endpoint and business params in request log, got %s", payloads[0])
+	}
+	if !strings.Contains(payloads[1], `"amount":"10.00"`) {
+		t.Fatalf("expected response body in debug log, got %s", payloads[1])
+	}
+}
+
+func TestPostDecodeFailureRecordsRawResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"data":{"balance":"12.5","secret_key":"abc"}}`))
+	}))
+	defer ts.Close()
+
+	now := time.Unix(1700000000, 0)
+	client, err := NewClient(config.SifangConfig{
+		BaseURL:             ts.URL,
+		DefaultMerchantKey:  "secret",
+		RawCaptureEnabled:   true,
+		RawCaptureRetention: time.Minute,
+	}, WithHTTPClient(ts.Client()), WithNowFunc(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	var out []string
+	if err := client.Post(context.Background(), "balance", 1001, nil, &out); err == nil {
+		t.Fatalf("expected decode error")
+	}
+
+	entry, ok := client.LastRawResponse(1001)
+	if !ok {
+		t.Fatalf("expected raw response recorded on decode failure")
+	}
+	if entry.Action != "balance" || !strings.Contains(entry.Body, `"balance":"12.5"`) || strings.Contains(entry.Body, "abc") {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := client.LastRawResponse(1001); ok {
+		t.Fatalf("expected raw response to expire after retention")
+	}
+}
+
+func TestRecordRawResponseTruncatesLargeBody(t *testing.T) {
+	client, err := NewClient(config.SifangConfig{RawCaptureEnabled: true})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	client.RecordRawResponse("withdrawlist", 1001, "too big", []byte(strings.Repeat("数", rawCaptureMaxBytes)))
+	entry, ok := client.LastRawResponse(1001)
+	if !ok {
+		t.Fatalf("expected raw response recorded")
+	}
+	if !entry.Truncated || len(entry.Body) > rawCaptureMaxBytes || !utf8.ValidString(entry.Body) {
+		t.Fatalf("expected body truncated on a rune boundary, got truncated=%v len=%d", entry.Truncated, len(entry.Body))
+	}
+}
@@ -0,0 +1,115 @@
+package sifang
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRawCaptureRetention 原始响应默认留存时长
+	DefaultRawCaptureRetention = time.Hour
+	// rawCaptureMaxBytes 单条原始响应最多留存的字节数，超出部分截断
+	rawCaptureMaxBytes = 16 * 1024
+)
+
+// RawResponse 解码失败或结果为空时留存的上游原始响应（已脱敏）
+type RawResponse struct {
+	Action     string
+	MerchantID int64
+	Reason     string // 留存原因，例如 decode 错误或 "order detail is empty"
+	Body       string
+	Truncated  bool // Body 超过上限已被截断
+	CapturedAt time.Time
+}
+
+// rawResponseStore 按商户只保留最近一次留存的响应，超过 retention 后视为过期
+type rawResponseStore struct {
+	mu        sync.Mutex
+	retention time.Duration
+	entries   map[int64]*RawResponse
+}
+
+func newRawResponseStore(retention time.Duration) *rawResponseStore {
+	if retention <= 0 {
+		retention = DefaultRawCaptureRetention
+	}
+	return &rawResponseStore{
+		retention: retention,
+		entries:   make(map[int64]*RawResponse),
+	}
+}
+
+func (s *rawResponseStore) record(entry *RawResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredLocked(entry.CapturedAt)
+	s.entries[entry.MerchantID] = entry
+}
+
+func (s *rawResponseStore) last(merchantID int64, now time.Time) (*RawResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredLocked(now)
+	entry, ok := s.entries[merchantID]
+	if !ok {
+		return nil, false
+	}
+	clone := *entry
+	return &clone, true
+}
+
+func (s *rawResponseStore) purgeExpiredLocked(now time.Time) {
+	for merchantID, entry := range s.entries {
+		if now.Sub(entry.CapturedAt) > s.retention {
+			delete(s.entries, merchantID)
+		}
+	}
+}
+
+// RawCaptureEnabled 是否开启了原始响应留存
+func (c *Client) RawCaptureEnabled() bool {
+	return c != nil && c.rawStore != nil
+}
+
+// RecordRawResponse 留存一次解码失败或结果为空的原始响应：敏感字段脱敏、超过上限截断；未开启留存时为空操作
+func (c *Client) RecordRawResponse(action string, merchantID int64, reason string, body []byte) {
+	if !c.RawCaptureEnabled() {
+		return
+	}
+
+	redacted := redactBodyForLog(body)
+	truncated := len(redacted) > rawCaptureMaxBytes
+	if truncated {
+		redacted = truncateBytes(redacted, rawCaptureMaxBytes)
+	}
+	c.rawStore.record(&RawResponse{
+		Action:     action,
+		MerchantID: merchantID,
+		Reason:     reason,
+		Body:       redacted,
+		Truncated:  truncated,
+		CapturedAt: c.nowFunc(),
+	})
+}
+
+// LastRawResponse 返回商户最近一次留存且未过期的原始响应
+func (c *Client) LastRawResponse(merchantID int64) (*RawResponse, bool) {
+	if !c.RawCaptureEnabled() {
+		return nil, false
+	}
+	return c.rawStore.last(merchantID, c.nowFunc())
+}
+
+// truncateBytes 按字节上限截断，并回退到完整的 UTF-8 字符边界
+func truncateBytes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && cut < len(s) && s[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return s[:cut]
+}
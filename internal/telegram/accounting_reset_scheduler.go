@@ -0,0 +1,236 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/models"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// accountingResetScheduler 按群组配置的周期（每日/每周/每月）在北京时间零点发送结算账单并清零记账
+type accountingResetScheduler struct {
+	bot      *Bot
+	cancel   context.CancelFunc
+	done     chan struct{}
+	location *time.Location
+}
+
+func newAccountingResetScheduler(bot *Bot) *accountingResetScheduler {
+	return &accountingResetScheduler{
+		bot:      bot,
+		location: mustLoadChinaLocation(),
+	}
+}
+
+func (s *accountingResetScheduler) start() {
+	if s == nil || s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+	logger.L().Info("Accounting reset scheduler started")
+}
+
+func (s *accountingResetScheduler) stop() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+	s.done = nil
+	logger.L().Info("Accounting reset scheduler stopped")
+}
+
+func (s *accountingResetScheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		now := s.bot.currentTime().In(s.location)
+		next := nextDailyRun(now, s.location)
+		wait := next.Sub(now)
+		if wait <= 0 {
+			wait = time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		logger.L().Debugf("Accounting reset waiting %s until %s", wait.String(), next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.dispatch(ctx)
+		}
+	}
+}
+
+func (s *accountingResetScheduler) dispatch(parent context.Context) {
+	if parent.Err() != nil {
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(parent, 3*time.Minute)
+	defer cancel()
+
+	boundary := accountingResetBoundary(s.bot.currentTime(), s.location)
+	reset, failures, err := s.resetDue(runCtx, boundary)
+	if err != nil {
+		logger.L().Errorf("Accounting reset failed to list groups: %v", err)
+		return
+	}
+	logger.L().Infof("Accounting reset completed: boundary=%s reset=%d failures=%d", boundary.Format("2006-01-02"), reset, len(failures))
+	if len(failures) > 0 {
+		logger.L().Warnf("Accounting reset failures: %v", failures)
+	}
+}
+
+// resetDue 对在 boundary 到达清零周期的群组先发送记录存档（CSV）与结算账单再清空记录，返回清零成功的群组数与失败明细。
+// 存档或结算账单发送失败时不清零，避免记录丢失
+func (s *accountingResetScheduler) resetDue(ctx context.Context, boundary time.Time) (int, []string, error) {
+	groups, err := s.bot.groupService.ListActiveGroups(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	reset := 0
+	var failures []string
+	for _, group := range filterAccountingResetGroups(groups, boundary) {
+		if ctx.Err() != nil {
+			failures = append(failures, fmt.Sprintf("%d: %v", group.TelegramID, ctx.Err()))
+			break
+		}
+
+		cadence := models.AccountingResetCadenceOf(group.Settings)
+		periodStart := accountingResetPeriodStart(cadence, boundary)
+		summary, err := s.bot.accountingService.QueryRecordsByRange(ctx, group.TelegramID, periodStart, boundary)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%d: 生成结算账单失败 (%v)", group.TelegramID, err))
+			continue
+		}
+
+		if err := s.archiveRecords(ctx, group.TelegramID, cadence, periodStart, boundary); err != nil {
+			failures = append(failures, fmt.Sprintf("%d: 存档记账记录失败 (%v)", group.TelegramID, err))
+			continue
+		}
+
+		report := buildAccountingClosingReport(cadence, periodStart, boundary, summary)
+		if _, err := s.bot.sendMessageWithMarkupAndMessage(ctx, group.TelegramID, report, nil); err != nil {
+			failures = append(failures, fmt.Sprintf("%d: 发送结算账单失败 (%v)", group.TelegramID, err))
+			continue
+		}
+
+		// 只清除边界之前（已存档）的记录，导出之后新记的账留到下一周期
+		count, err := s.bot.accountingService.ClearRecordsBefore(ctx, group.TelegramID, boundary)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%d: 清零失败 (%v)", group.TelegramID, err))
+			continue
+		}
+		logger.L().Infof("Accounting reset: chat_id=%d cadence=%s cleared=%d", group.TelegramID, cadence, count)
+		reset++
+	}
+
+	return reset, failures, nil
+}
+
+// archiveRecords 在清零前将群内全部记账记录导出为 CSV 发送到群内存档；没有记录时跳过。
+// 清零会删除该群所有记录，因此导出截止到当前时间而不只是本结算周期
+func (s *accountingResetScheduler) archiveRecords(ctx context.Context, chatID int64, cadence string, periodStart, boundary time.Time) error {
+	var buf bytes.Buffer
+	count, err := s.bot.accountingService.ExportRecords(ctx, chatID, time.Time{}, boundary, &buf)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	lastDay := boundary.AddDate(0, 0, -1)
+	document := &types.Document{
+		Filename: fmt.Sprintf("accounting_%d_%s_%s.csv", chatID, periodStart.Format("20060102"), lastDay.Format("20060102")),
+		Data:     &buf,
+	}
+	caption := fmt.Sprintf("🗄 %s记账存档 %s ~ %s（%d 条）", accountingResetCadenceLabel(cadence), periodStart.Format("2006-01-02"), lastDay.Format("2006-01-02"), count)
+	if _, err := s.bot.sendDocument(ctx, chatID, document, caption, botModels.ParseModeHTML); err != nil {
+		return err
+	}
+	return nil
+}
+
+// filterAccountingResetGroups 选出开启记账且在 boundary 到达清零周期的群组
+func filterAccountingResetGroups(groups []*models.Group, boundary time.Time) []*models.Group {
+	due := make([]*models.Group, 0)
+	for _, group := range groups {
+		if group == nil || !group.IsActive() || !group.Settings.AccountingEnabled {
+			continue
+		}
+		if accountingResetDue(models.AccountingResetCadenceOf(group.Settings), boundary) {
+			due = append(due, group)
+		}
+	}
+	return due
+}
+
+// accountingResetBoundary 返回 now 所在自然日的零点，即本次清零的周期边界
+func accountingResetBoundary(now time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// accountingResetDue 判断 boundary（零点）是否为该周期的清零时刻：每日每天、每周周一、每月 1 日
+func accountingResetDue(cadence string, boundary time.Time) bool {
+	switch cadence {
+	case models.AccountingResetDaily:
+		return true
+	case models.AccountingResetWeekly:
+		return boundary.Weekday() == time.Monday
+	case models.AccountingResetMonthly:
+		return boundary.Day() == 1
+	default:
+		return false
+	}
+}
+
+// accountingResetPeriodStart 返回以 boundary 结束的结算周期起点
+func accountingResetPeriodStart(cadence string, boundary time.Time) time.Time {
+	switch cadence {
+	case models.AccountingResetWeekly:
+		return boundary.AddDate(0, 0, -7)
+	case models.AccountingResetMonthly:
+		return boundary.AddDate(0, -1, 0)
+	default:
+		return boundary.AddDate(0, 0, -1)
+	}
+}
+
+func accountingResetCadenceLabel(cadence string) string {
+	switch cadence {
+	case models.AccountingResetWeekly:
+		return "每周"
+	case models.AccountingResetMonthly:
+		return "每月"
+	default:
+		return "每日"
+	}
+}
+
+// buildAccountingClosingReport 组装清零前发送的结算账单，周期为 [periodStart, boundary)
+func buildAccountingClosingReport(cadence string, periodStart, boundary time.Time, summary string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔒 <b>%s记账结算</b>\n", accountingResetCadenceLabel(cadence)))
+	sb.WriteString(fmt.Sprintf("周期：%s ~ %s\n\n", periodStart.Format("2006-01-02"), boundary.AddDate(0, 0, -1).Format("2006-01-02")))
+	sb.WriteString(strings.TrimRight(summary, "\n"))
+	sb.WriteString("\n\n♻️ 记账已按周期自动清零，新周期从 0 开始")
+	return sb.String()
+}
@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+type resetTestAccountingService struct {
+	service.AccountingService
+	cleared   []int64
+	ranges    [][2]time.Time
+	archived  int
+	exportEnd time.Time
+	clearedTo time.Time
+}
+
+func (s *resetTestAccountingService) QueryRecordsByRange(ctx context.Context, chatID int64, start, end time.Time) (string, error) {
+	s.ranges = append(s.ranges, [2]time.Time{start, end})
+	return "📊 账单 2024-11-11 ~ 2024-11-17\n\n人民币\n总余额: <b>+100</b>\n", nil
+}
+
+func (s *resetTestAccountingService) ExportRecords(ctx context.Context, chatID int64, start, end time.Time, w io.Writer) (int, error) {
+	if _, err := io.WriteString(w, "time,userId,currency,amount,expression\n2024-11-17 10:00:00,7,CNY,100.00,100\n"); err != nil {
+		return 0, err
+	}
+	s.archived++
+	s.exportEnd = end
+	return 1, nil
+}
+
+func (s *resetTestAccountingService) ClearRecordsBefore(ctx context.Context, chatID int64, before time.Time) (int64, error) {
+	s.cleared = append(s.cleared, chatID)
+	s.clearedTo = before
+	return 3, nil
+}
+
+func accountingResetTestGroup(id int64, cadence string) *models.Group {
+	return &models.Group{
+		TelegramID: id,
+		BotStatus:  models.BotStatusActive,
+		Settings:   models.GroupSettings{AccountingEnabled: true, AccountingResetCadence: cadence},
+	}
+}
+
+func TestFilterAccountingResetGroups(t *testing.T) {
+	location := mustLoadChinaLocation()
+	disabled := accountingResetTestGroup(-1005, models.AccountingResetDaily)
+	disabled.Settings.AccountingEnabled = false
+	groups := []*models.Group{
+		accountingResetTestGroup(-1001, models.AccountingResetDaily),
+		accountingResetTestGroup(-1002, models.AccountingResetWeekly),
+		accountingResetTestGroup(-1003, models.AccountingResetMonthly),
+		accountingResetTestGroup(-1004, models.AccountingResetNone),
+		disabled,
+	}
+
+	tests := []struct {
+		name     string
+		boundary time.Time
+		want     []int64
+	}{
+		{name: "ordinary tuesday", boundary: time.Date(2024, 11, 19, 0, 0, 0, 0, location), want: []int64{-1001}},
+		{name: "monday", boundary: time.Date(2024, 11, 18, 0, 0, 0, 0, location), want: []int64{-1001, -1002}},
+		{name: "first of month", boundary: time.Date(2024, 12, 1, 0, 0, 0, 0, location), want: []int64{-1001, -1003}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due := filterAccountingResetGroups(groups, tt.boundary)
+			if len(due) != len(tt.want) {
+				t.Fatalf("expected %d groups, got %d", len(tt.want), len(due))
+			}
+			for i, group := range due {
+				if group.TelegramID != tt.want[i] {
+					t.Fatalf("expected group %d at %d, got %d", tt.want[i], i, group.TelegramID)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildAccountingClosingReport(t *testing.T) {
+	location := mustLoadChinaLocation()
+	boundary := time.Date(2024, 12, 1, 0, 0, 0, 0, location)
+
+	report := buildAccountingClosingReport(models.AccountingResetMonthly, accountingResetPeriodStart(models.AccountingResetMonthly, boundary), boundary, "📊 账单\n总余额: <b>+100</b>\n")
+
+	for _, want := range []string{"🔒 <b>每月记账结算</b>", "周期：2024-11-01 ~ 2024-11-30", "总余额: <b>+100</b>", "自动清零"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in report:\n%s", want, report)
+		}
+	}
+}
+
+func TestAccountingResetSchedulerPostsReportBeforeClearing(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	accounting := &resetTestAccountingService{}
+	b := &Bot{
+		bot: botInstance,
+		groupService: &autoLookupTestGroupService{groups: []*models.Group{
+			accountingResetTestGroup(-1001, models.AccountingResetWeekly),
+			accountingResetTestGroup(-1002, models.AccountingResetMonthly),
+		}},
+		accountingService: accounting,
+	}
+	scheduler := newAccountingResetScheduler(b)
+
+	// 2024-11-18 为周一，仅每周清零的群组到期
+	boundary := time.Date(2024, 11, 18, 0, 0, 0, 0, scheduler.location)
+	reset, failures, err := scheduler.resetDue(context.Background(), boundary)
+	if err != nil || len(failures) != 0 {
+		t.Fatalf("unexpected result: err=%v failures=%v", err, failures)
+	}
+	if reset != 1 || len(accounting.cleared) != 1 || accounting.cleared[0] != -1001 {
+		t.Fatalf("expected only weekly group cleared, got reset=%d cleared=%v", reset, accounting.cleared)
+	}
+	// 结算账单覆盖刚结束的整个周期，而不是零点之后的新一天
+	if len(accounting.ranges) != 1 || !accounting.ranges[0][0].Equal(boundary.AddDate(0, 0, -7)) || !accounting.ranges[0][1].Equal(boundary) {
+		t.Fatalf("expected report range [2024-11-11, 2024-11-18), got %v", accounting.ranges)
+	}
+
+	docs := api.Requests("sendDocument")
+	if accounting.archived != 1 || len(docs) != 1 || docs[0].Get("chat_id") != "-1001" || !strings.Contains(docs[0].Get("caption"), "每周记账存档 2024-11-11 ~ 2024-11-17") {
+		t.Fatalf("expected records archived before clearing, got archived=%d docs=%v", accounting.archived, docs)
+	}
+	// 存档与清零使用同一截止时间，导出之后新记的账不会被清除
+	if !accounting.exportEnd.Equal(boundary) || !accounting.clearedTo.Equal(boundary) {
+		t.Fatalf("expected archive and clear cut off at %s, got export=%s clear=%s", boundary, accounting.exportEnd, accounting.clearedTo)
+	}
+
+	sent := api.Messages()
+	if len(sent) != 1 || sent[0].ChatID != "-1001" || !strings.Contains(sent[0].Text, "周期：2024-11-11 ~ 2024-11-17") {
+		t.Fatalf("expected closing report sent to weekly group, got %+v", sent)
+	}
+
+	// 结算账单发送失败时不清零
+	api.FailNext("sendMessage", 1)
+	accounting.cleared = nil
+	if _, failures, _ := scheduler.resetDue(context.Background(), boundary); len(failures) != 1 || len(accounting.cleared) != 0 {
+		t.Fatalf("expected no clearing when report fails, failures=%v cleared=%v", failures, accounting.cleared)
+	}
+
+	// 存档发送失败时同样不清零
+	api.FailNext("sendDocument", 1)
+	if _, failures, _ := scheduler.resetDue(context.Background(), boundary); len(failures) != 1 || !strings.Contains(failures[0], "存档") || len(accounting.cleared) != 0 {
+		t.Fatalf("expected no clearing when archive fails, failures=%v cleared=%v", failures, accounting.cleared)
+	}
+}
@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_bot/internal/logger"
+)
+
+// balanceLogRetentionScheduler 每日北京时间凌晨清理过期的上游余额日志，每个群至少保留最近 keepLatest 条
+type balanceLogRetentionScheduler struct {
+	bot        *Bot
+	retention  time.Duration
+	keepLatest int
+	cancel     context.CancelFunc
+	done       chan struct{}
+	location   *time.Location
+}
+
+func newBalanceLogRetentionScheduler(bot *Bot, retention time.Duration, keepLatest int) *balanceLogRetentionScheduler {
+	return &balanceLogRetentionScheduler{
+		bot:        bot,
+		retention:  retention,
+		keepLatest: keepLatest,
+		location:   mustLoadChinaLocation(),
+	}
+}
+
+func (s *balanceLogRetentionScheduler) start() {
+	if s == nil || s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+	logger.L().Infof("Balance log retention scheduler started: retention=%s keep_latest=%d", s.retention, s.keepLatest)
+}
+
+func (s *balanceLogRetentionScheduler) stop() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+	s.done = nil
+	logger.L().Info("Balance log retention scheduler stopped")
+}
+
+func (s *balanceLogRetentionScheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		now := s.bot.currentTime().In(s.location)
+		next := nextBalanceLogPruneRun(now, s.location)
+		wait := next.Sub(now)
+		if wait <= 0 {
+			wait = time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		logger.L().Debugf("Balance log retention waiting %s until %s", wait.String(), next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.dispatch(ctx)
+		}
+	}
+}
+
+// nextBalanceLogPruneRun 清理安排在 03:30，避开零点附近的日结与账单推送
+func nextBalanceLogPruneRun(now time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), 3, 30, 0, 0, location)
+	if !next.After(local) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+func (s *balanceLogRetentionScheduler) dispatch(parent context.Context) {
+	if parent.Err() != nil {
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(parent, 5*time.Minute)
+	defer cancel()
+
+	before := s.bot.currentTime().Add(-s.retention)
+	deleted, failures, err := s.prune(runCtx, before)
+	if err != nil {
+		logger.L().Errorf("Balance log retention failed to list balances: %v", err)
+		return
+	}
+	logger.L().Infof("Balance log retention completed: before=%s deleted=%d failures=%d", before.Format(time.RFC3339), deleted, len(failures))
+	if len(failures) > 0 {
+		logger.L().Warnf("Balance log retention failures: %v", failures)
+	}
+}
+
+// prune 逐个上游群清理 before 之前的余额日志，返回删除总数与失败明细
+func (s *balanceLogRetentionScheduler) prune(ctx context.Context, before time.Time) (int64, []string, error) {
+	balances, err := s.bot.balanceService.ListAll(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var deleted int64
+	var failures []string
+	for _, balance := range balances {
+		if ctx.Err() != nil {
+			failures = append(failures, fmt.Sprintf("%d: %v", balance.GroupID, ctx.Err()))
+			break
+		}
+		count, err := s.bot.balanceService.PruneLogs(ctx, balance.GroupID, before, s.keepLatest)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%d: %v", balance.GroupID, err))
+			continue
+		}
+		if count > 0 {
+			logger.L().Infof("Balance logs pruned: group_id=%d deleted=%d", balance.GroupID, count)
+		}
+		deleted += count
+	}
+	return deleted, failures, nil
+}
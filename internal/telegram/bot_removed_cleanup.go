@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"context"
+
+	"go_bot/internal/logger"
+)
+
+// purgeRemovedChatState Bot 被移出群组后清理与该群相关的转单联动状态、失败重试、接口未绑定记录以及转发撤回记录，
+// 该群无论作为商户群还是上游群都已无法投递，避免回调与重试继续指向该群
+func (b *Bot) purgeRemovedChatState(ctx context.Context, chatID int64) {
+	states, failures, mismatches := b.purgeOrderCascadeStateForChat(chatID)
+
+	var forwardRecords int64
+	if b.forwardRecordRepo != nil {
+		deleted, err := b.forwardRecordRepo.DeleteRecordsByTargetGroup(ctx, chatID)
+		if err != nil {
+			logger.L().Warnf("Failed to purge forward records for removed chat %d: %v", chatID, err)
+		}
+		forwardRecords = deleted
+	}
+
+	if states+failures+mismatches > 0 || forwardRecords > 0 {
+		logger.L().Infof("Purged state for removed chat %d: cascade_states=%d cascade_failures=%d unbound=%d forward_records=%d",
+			chatID, states, failures, mismatches, forwardRecords)
+	}
+}
+
+// purgeOrderCascadeStateForChat 删除商户群或上游群为 chatID 的转单相关内存状态，返回各类删除数量
+func (b *Bot) purgeOrderCascadeStateForChat(chatID int64) (states, failures, mismatches int) {
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	for token, state := range b.orderCascadeStates {
+		if state != nil && (state.MerchantChatID == chatID || state.UpstreamChatID == chatID) {
+			delete(b.orderCascadeStates, token)
+			states++
+		}
+	}
+	for token, delivery := range b.orderCascadeFailures {
+		if delivery == nil || delivery.State == nil {
+			continue
+		}
+		if delivery.State.MerchantChatID == chatID || delivery.State.UpstreamChatID == chatID {
+			delete(b.orderCascadeFailures, token)
+			failures++
+		}
+	}
+	for key, item := range b.orderCascadeUnbound {
+		if item != nil && item.UpstreamChatID == chatID {
+			delete(b.orderCascadeUnbound, key)
+			mismatches++
+		}
+	}
+	return states, failures, mismatches
+}
@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/repository"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type removedChatTestRecordRepo struct {
+	repository.ForwardRecordRepository
+	deleted []int64
+}
+
+func (r *removedChatTestRecordRepo) DeleteRecordsByTargetGroup(ctx context.Context, groupID int64) (int64, error) {
+	r.deleted = append(r.deleted, groupID)
+	return 2, nil
+}
+
+func TestHandleMyChatMemberRemovedPurgesChatState(t *testing.T) {
+	botInstance, _ := newTestTelegramBot(t)
+	expires := time.Now().Add(time.Hour)
+	records := &removedChatTestRecordRepo{}
+	b := &Bot{
+		bot:               botInstance,
+		groupService:      &autoLookupTestGroupService{},
+		forwardRecordRepo: records,
+		orderCascadeStates: map[string]*orderCascadeState{
+			"as-merchant": {Token: "as-merchant", MerchantChatID: -100, UpstreamChatID: -200, ExpiresAt: expires},
+			"as-upstream": {Token: "as-upstream", MerchantChatID: -300, UpstreamChatID: -100, ExpiresAt: expires},
+			"other":       {Token: "other", MerchantChatID: -300, UpstreamChatID: -200, ExpiresAt: expires},
+		},
+		orderCascadeFailures: map[string]*orderCascadeDelivery{
+			"failed-upstream": {State: &orderCascadeState{Token: "failed-upstream", MerchantChatID: -300, UpstreamChatID: -100, ExpiresAt: expires}},
+			"failed-other":    {State: &orderCascadeState{Token: "failed-other", MerchantChatID: -300, UpstreamChatID: -200, ExpiresAt: expires}},
+		},
+		orderCascadeUnbound: map[string]*orderCascadeInterfaceMismatch{
+			orderCascadeMismatchKey(-100, "1024"): {UpstreamChatID: -100, InterfaceID: "1024"},
+			orderCascadeMismatchKey(-200, "1024"): {UpstreamChatID: -200, InterfaceID: "1024"},
+		},
+	}
+
+	b.handleMyChatMember(context.Background(), botInstance, &botModels.Update{
+		MyChatMember: &botModels.ChatMemberUpdated{
+			Chat: botModels.Chat{ID: -100, Type: botModels.ChatTypeSupergroup},
+			OldChatMember: botModels.ChatMember{
+				Type:   botModels.ChatMemberTypeMember,
+				Member: &botModels.ChatMemberMember{},
+			},
+			NewChatMember: botModels.ChatMember{
+				Type:   botModels.ChatMemberTypeBanned,
+				Banned: &botModels.ChatMemberBanned{},
+			},
+		},
+	})
+
+	if len(b.orderCascadeStates) != 1 || b.orderCascadeStates["other"] == nil {
+		t.Fatalf("expected only unrelated cascade state to remain, got %v", b.orderCascadeStates)
+	}
+	if len(b.orderCascadeFailures) != 1 || b.orderCascadeFailures["failed-other"] == nil {
+		t.Fatalf("expected only unrelated cascade failure to remain, got %v", b.orderCascadeFailures)
+	}
+	if len(b.orderCascadeUnbound) != 1 || b.orderCascadeUnbound[orderCascadeMismatchKey(-200, "1024")] == nil {
+		t.Fatalf("expected only unrelated mismatch to remain, got %v", b.orderCascadeUnbound)
+	}
+	if len(records.deleted) != 1 || records.deleted[0] != -100 {
+		t.Fatalf("expected forward records of -100 to be purged, got %v", records.deleted)
+	}
+}
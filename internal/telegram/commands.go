@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const commandMenuSyncTimeout = 10 * time.Second
+
+// Telegram 命令菜单仅接受 1-32 位小写字母、数字、下划线
+var menuCommandRegexp = regexp.MustCompile(`^/[a-z0-9_]{1,32}$`)
+
+// commandAccess 命令所需权限
+type commandAccess int
+
+const (
+	commandAccessPublic commandAccess = iota
+	commandAccessAdmin
+	commandAccessOwner
+)
+
+// matchTypeToken 命令词单独出现或后跟空白及参数时才命中。
+// 前缀匹配会让以命令词开头的普通聊天（如"划转一下"）命中命令，无法继续交给记账、功能与消息记录处理
+const matchTypeToken bot.MatchType = -1
+
+// commandSpec 描述一条已注册的文本命令
+type commandSpec struct {
+	pattern     string
+	matchType   bot.MatchType
+	access      commandAccess
+	description string // 简短说明，用于命令菜单与 /help 列表
+	usage       string // 详细用法，用于 /help <命令>
+}
+
+// commandMenu 某个作用域下展示的命令菜单
+type commandMenu struct {
+	scope    botModels.BotCommandScope
+	commands []botModels.BotCommand
+}
+
+// registerCommand 注册文本命令并按权限套上中间件，同时记录到命令表供菜单生成
+func (b *Bot) registerCommand(spec commandSpec, handler bot.HandlerFunc) {
+	switch spec.access {
+	case commandAccessOwner:
+		handler = b.RequireOwner(handler)
+	case commandAccessAdmin:
+		handler = b.RequireAdmin(handler)
+	}
+
+	if spec.matchType == matchTypeToken {
+		pattern := spec.pattern
+		b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
+			return update.Message != nil && matchesCommandToken(update.Message.Text, pattern)
+		}, b.asyncHandler(handler))
+	} else {
+		b.bot.RegisterHandler(bot.HandlerTypeMessageText, spec.pattern, spec.matchType, b.asyncHandler(handler))
+	}
+	b.commands = append(b.commands, spec)
+}
+
+// matchesCommandToken 判断文本是否以独立的命令词开头：与命令词完全相同，或命令词后紧跟空白
+func matchesCommandToken(text, pattern string) bool {
+	rest, ok := strings.CutPrefix(text, pattern)
+	if !ok {
+		return false
+	}
+	return rest == "" || strings.ContainsAny(rest[:1], " \t\n")
+}
+
+// buildCommandMenus 根据已注册命令生成各作用域的菜单：
+// 私聊与默认作用域只展示公开命令，群管理员额外展示 Admin 命令，Owner 私聊展示全部命令
+func buildCommandMenus(specs []commandSpec, ownerIDs []int64) []commandMenu {
+	public := menuCommandsFor(specs, commandAccessPublic)
+	admin := menuCommandsFor(specs, commandAccessAdmin)
+	owner := menuCommandsFor(specs, commandAccessOwner)
+
+	menus := []commandMenu{
+		{scope: &botModels.BotCommandScopeDefault{}, commands: public},
+		{scope: &botModels.BotCommandScopeAllPrivateChats{}, commands: public},
+		{scope: &botModels.BotCommandScopeAllChatAdministrators{}, commands: admin},
+	}
+	for _, ownerID := range ownerIDs {
+		menus = append(menus, commandMenu{
+			scope:    &botModels.BotCommandScopeChat{ChatID: ownerID},
+			commands: owner,
+		})
+	}
+	return menus
+}
+
+// menuCommandsFor 返回权限不高于 maxAccess 且符合菜单命名规则的命令
+func menuCommandsFor(specs []commandSpec, maxAccess commandAccess) []botModels.BotCommand {
+	seen := make(map[string]bool)
+	var commands []botModels.BotCommand
+	for _, spec := range specs {
+		if spec.access > maxAccess || !isMenuCommand(spec) {
+			continue
+		}
+		name := strings.TrimPrefix(spec.pattern, "/")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		commands = append(commands, botModels.BotCommand{
+			Command:     name,
+			Description: spec.description,
+		})
+	}
+	return commands
+}
+
+func isMenuCommand(spec commandSpec) bool {
+	return spec.description != "" && menuCommandRegexp.MatchString(spec.pattern)
+}
+
+// syncCommandMenus 启动时向 Telegram 注册命令菜单，失败仅记录日志
+func (b *Bot) syncCommandMenus(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, commandMenuSyncTimeout)
+	defer cancel()
+
+	for _, menu := range buildCommandMenus(b.commands, b.ownerIDs) {
+		if len(menu.commands) == 0 {
+			continue
+		}
+		if _, err := b.bot.SetMyCommands(ctx, &bot.SetMyCommandsParams{
+			Commands: menu.commands,
+			Scope:    menu.scope,
+		}); err != nil {
+			logger.L().Warnf("Failed to set bot commands: scope=%T err=%v", menu.scope, err)
+			return
+		}
+	}
+	logger.L().Infof("Bot command menus registered: commands=%d owners=%d", len(b.commands), len(b.ownerIDs))
+}
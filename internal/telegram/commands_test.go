@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"testing"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestBuildCommandMenusMatchesRegisteredCommands(t *testing.T) {
+	botInstance, _ := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance, ownerIDs: []int64{42}}
+	b.registerHandlers()
+
+	menus := buildCommandMenus(b.commands, b.ownerIDs)
+	if len(menus) != 4 {
+		t.Fatalf("expected 4 menus (default/private/admins/owner), got %d", len(menus))
+	}
+
+	names := func(commands []botModels.BotCommand) map[string]bool {
+		set := make(map[string]bool, len(commands))
+		for _, cmd := range commands {
+			if cmd.Description == "" {
+				t.Fatalf("command %s has empty description", cmd.Command)
+			}
+			set[cmd.Command] = true
+		}
+		return set
+	}
+
+	private := names(menus[1].commands)
+	admins := names(menus[2].commands)
+	owner := names(menus[3].commands)
+
+	if _, ok := menus[1].scope.(*botModels.BotCommandScopeAllPrivateChats); !ok {
+		t.Fatalf("expected private chat scope, got %T", menus[1].scope)
+	}
+	if _, ok := menus[2].scope.(*botModels.BotCommandScopeAllChatAdministrators); !ok {
+		t.Fatalf("expected chat administrators scope, got %T", menus[2].scope)
+	}
+	if scope, ok := menus[3].scope.(*botModels.BotCommandScopeChat); !ok || scope.ChatID != int64(42) {
+		t.Fatalf("expected owner chat scope, got %#v", menus[3].scope)
+	}
+
+	for _, name := range []string{"start", "ping", "help"} {
+		if !private[name] || !admins[name] || !owner[name] {
+			t.Fatalf("expected public command %s in every scope", name)
+		}
+	}
+	for _, name := range []string{"admins", "configs"} {
+		if private[name] {
+			t.Fatalf("admin command %s must not be shown in private scope", name)
+		}
+		if !admins[name] || !owner[name] {
+			t.Fatalf("expected admin command %s in admin and owner scopes", name)
+		}
+	}
+	if admins["grant"] || !owner["grant"] {
+		t.Fatalf("expected owner command grant only in owner scope")
+	}
+
+	// 每个符合菜单规则的已注册命令都必须出现在 Owner 菜单中，且菜单中不存在未注册的命令
+	registered := make(map[string]bool)
+	for _, spec := range b.commands {
+		if isMenuCommand(spec) {
+			registered[spec.pattern[1:]] = true
+		}
+	}
+	if len(registered) != len(owner) {
+		t.Fatalf("owner menu has %d commands, registered %d", len(owner), len(registered))
+	}
+	for name := range registered {
+		if !owner[name] {
+			t.Fatalf("registered command %s missing from owner menu", name)
+		}
+	}
+}
+
+func TestMenuCommandsSkipNonASCIICommands(t *testing.T) {
+	specs := []commandSpec{
+		{pattern: "/余额", description: "余额"},
+		{pattern: "查询记账", description: "记账"},
+		{pattern: "/ping", description: "在线检测"},
+		{pattern: "/undocumented"},
+	}
+
+	commands := menuCommandsFor(specs, commandAccessOwner)
+	if len(commands) != 1 || commands[0].Command != "ping" {
+		t.Fatalf("expected only ping in menu, got %+v", commands)
+	}
+}
+
+func TestMatchesCommandToken(t *testing.T) {
+	cases := map[string]bool{
+		"划转":                 true,
+		"划转 -1001 -1002 100": true,
+		"划转\n-1001":          true,
+		"划转一下":               false,
+		"先划转":                false,
+		"":                   false,
+	}
+	for text, want := range cases {
+		if got := matchesCommandToken(text, upstreamTransferCommand); got != want {
+			t.Fatalf("matchesCommandToken(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
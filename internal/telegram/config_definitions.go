@@ -113,6 +113,39 @@ func (b *Bot) getConfigItems() []models.ConfigItem {
 			RequireAdmin: true,
 		},
 
+		// 功能输出引用触发消息开关
+		{
+			ID:       "feature_reply_enabled",
+			Name:     "回复原消息",
+			Icon:     "↩️",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsFeatureReplyEnabled(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.FeatureReplyEnabled = val
+				s.FeatureReplyConfigured = true
+			},
+			RequireAdmin: true,
+		},
+
+		// 未知命令提示开关
+		{
+			ID:       "unknown_command_hint",
+			Name:     "未知命令提示",
+			Icon:     "❓",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			ToggleGetter: func(g *models.Group) bool {
+				return g.Settings.UnknownCommandHintEnabled
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.UnknownCommandHintEnabled = val
+			},
+			RequireAdmin: true,
+		},
+
 		// 接收频道转发开关
 		{
 			ID:       "forward_enabled",
@@ -145,6 +178,92 @@ func (b *Bot) getConfigItems() []models.ConfigItem {
 			RequireAdmin: true,
 		},
 
+		// 记账后自动发送账单开关
+		{
+			ID:       "accounting_auto_report",
+			Name:     "记账后发送账单",
+			Icon:     "🧾",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsAccountingAutoReportEnabled(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.AccountingAutoReport = val
+				s.AccountingAutoReportConfigured = true
+			},
+			ToggleDisabled: func(g *models.Group) (bool, string) {
+				if !g.Settings.AccountingEnabled {
+					return true, "需先开启收支记账"
+				}
+				return false, ""
+			},
+			RequireAdmin: true,
+		},
+
+		// 记账自动清零周期
+		{
+			ID:       "accounting_reset_cadence",
+			Name:     "记账自动清零",
+			Icon:     "🗓",
+			Type:     models.ConfigTypeSelect,
+			Category: "功能管理",
+			SelectGetter: func(g *models.Group) string {
+				return models.AccountingResetCadenceOf(g.Settings)
+			},
+			SelectOptions: []models.SelectOption{
+				{Value: models.AccountingResetNone, Label: "不清零", Icon: "⭕"},
+				{Value: models.AccountingResetDaily, Label: "每日", Icon: "🌞"},
+				{Value: models.AccountingResetWeekly, Label: "每周", Icon: "📅"},
+				{Value: models.AccountingResetMonthly, Label: "每月", Icon: "🗓"},
+			},
+			SelectSetter: func(s *models.GroupSettings, val string) {
+				s.AccountingResetCadence = val
+			},
+			RequireAdmin: true,
+		},
+
+		// 记账默认币种
+		{
+			ID:       "accounting_default_currency",
+			Name:     "记账默认币种",
+			Icon:     "💱",
+			Type:     models.ConfigTypeSelect,
+			Category: "功能管理",
+			SelectGetter: func(g *models.Group) string {
+				return models.AccountingDefaultCurrencyOf(g.Settings)
+			},
+			SelectOptions: []models.SelectOption{
+				{Value: models.CurrencyCNY, Label: "CNY", Icon: "💴"},
+				{Value: models.CurrencyUSD, Label: "USD", Icon: "💵"},
+			},
+			SelectSetter: func(s *models.GroupSettings, val string) {
+				s.AccountingDefaultCurrency = val
+			},
+			RequireAdmin: true,
+		},
+
+		// 记账重复检测
+		{
+			ID:       "accounting_dedup_mode",
+			Name:     "记账重复检测",
+			Icon:     "🔁",
+			Type:     models.ConfigTypeSelect,
+			Category: "功能管理",
+			SelectGetter: func(g *models.Group) string {
+				return models.AccountingDedupModeOf(g.Settings)
+			},
+			SelectOptions: []models.SelectOption{
+				{Value: models.AccountingDedupOff, Label: "不检测", Icon: "⭕"},
+				{Value: models.AccountingDedupPrompt, Label: "询问确认", Icon: "❓"},
+				{Value: models.AccountingDedupSkip, Label: "自动跳过", Icon: "⏭"},
+			},
+			SelectSetter: func(s *models.GroupSettings, val string) {
+				s.AccountingDedupMode = val
+			},
+			RequireAdmin: true,
+		},
+
 		// 四方支付功能开关
 		{
 			ID:       "sifang_enabled",
@@ -189,6 +308,113 @@ func (b *Bot) getConfigItems() []models.ConfigItem {
 			RequireAdmin: true,
 		},
 
+		// 下发必须附带谷歌验证码开关（仅商户群）
+		{
+			ID:       "send_money_require_google_code",
+			Name:     "下发需谷歌验证码",
+			Icon:     "🔐",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierMerchant,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return g.Settings.SendMoneyRequireGoogleCode
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.SendMoneyRequireGoogleCode = val
+			},
+			ToggleDisabled: func(g *models.Group) (bool, string) {
+				if !g.Settings.SifangEnabled {
+					return true, "需先开启四方支付"
+				}
+				return false, ""
+			},
+			RequireAdmin: true,
+		},
+
+		// 账单附带提款明细开关（仅商户群）
+		{
+			ID:       "summary_show_withdraws",
+			Name:     "账单显示提款明细",
+			Icon:     "💸",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierMerchant,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsSummaryShowWithdrawsEnabled(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.SummaryShowWithdraws = val
+				s.SummaryShowWithdrawsConfigured = true
+			},
+			RequireAdmin: true,
+		},
+
+		// 账单附带余额开关（仅商户群）
+		{
+			ID:       "summary_show_balance",
+			Name:     "账单显示余额",
+			Icon:     "💼",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierMerchant,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsSummaryShowBalanceEnabled(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.SummaryShowBalance = val
+				s.SummaryShowBalanceConfigured = true
+			},
+			RequireAdmin: true,
+		},
+
+		// 账单收入分开展示开关（仅商户群）
+		{
+			ID:       "summary_income_split",
+			Name:     "账单分开显示商户/代理收入",
+			Icon:     "🧾",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierMerchant,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsSummaryIncomeSplit(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				if val {
+					s.SummaryIncomeMode = models.SummaryIncomeSplit
+				} else {
+					s.SummaryIncomeMode = models.SummaryIncomeCombined
+				}
+			},
+			RequireAdmin: true,
+		},
+
+		// 查询结果附带查询时间开关（仅商户群）
+		{
+			ID:       "query_time_footer",
+			Name:     "查询结果显示查询时间",
+			Icon:     "🕒",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierMerchant,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return g.Settings.QueryTimeFooterEnabled
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.QueryTimeFooterEnabled = val
+			},
+			RequireAdmin: true,
+		},
+
 		// 订单联动回传引用开关（仅商户群）
 		{
 			ID:       "cascade_reply_enabled",
@@ -229,6 +455,27 @@ func (b *Bot) getConfigItems() []models.ConfigItem {
 			RequireAdmin: true,
 		},
 
+		// 转单文案模板（仅上游群，为空时使用默认格式）
+		{
+			ID:       "cascade_caption_template",
+			Name:     "转单文案",
+			Icon:     "📝",
+			Type:     models.ConfigTypeInput,
+			Category: "订单联动",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierUpstream,
+			},
+			InputGetter: func(g *models.Group) string {
+				return g.Settings.CascadeCaptionTemplate
+			},
+			InputSetter: func(s *models.GroupSettings, val string) {
+				s.CascadeCaptionTemplate = normalizeOrderCascadeTemplate(val)
+			},
+			InputPrompt:    orderCascadeTemplatePrompt,
+			InputValidator: validateOrderCascadeTemplate,
+			RequireAdmin:   true,
+		},
+
 		// 上游余额轮询告警开关（仅上游群）
 		{
 			ID:       "balance_monitor_enabled",
@@ -249,6 +496,48 @@ func (b *Bot) getConfigItems() []models.ConfigItem {
 			RequireAdmin: true,
 		},
 
+		// 免打扰时段（期间余额提醒与每日账单延后到时段结束后推送）
+		{
+			ID:       "quiet_hours",
+			Name:     "免打扰时段",
+			Icon:     "🌙",
+			Type:     models.ConfigTypeSelect,
+			Category: "监控告警",
+			SelectGetter: func(g *models.Group) string {
+				return models.QuietHoursOf(g.Settings)
+			},
+			SelectOptions: []models.SelectOption{
+				{Value: models.QuietHoursOff, Label: "不启用", Icon: "⭕"},
+				{Value: models.QuietHoursLate, Label: "22:00-08:00", Icon: "🌙"},
+				{Value: models.QuietHoursNight, Label: "23:00-08:00", Icon: "🌙"},
+				{Value: models.QuietHoursDawn, Label: "00:00-08:00", Icon: "🌙"},
+			},
+			SelectSetter: func(s *models.GroupSettings, val string) {
+				s.QuietHours = val
+			},
+			RequireAdmin: true,
+		},
+
+		// 上游余额是否允许扣成负数（仅上游群，关闭后余额不足的扣款会被拒绝）
+		{
+			ID:       "balance_allow_negative",
+			Name:     "允许负余额",
+			Icon:     "➖",
+			Type:     models.ConfigTypeToggle,
+			Category: "功能管理",
+			AllowedTiers: []models.GroupTier{
+				models.GroupTierUpstream,
+			},
+			ToggleGetter: func(g *models.Group) bool {
+				return models.IsBalanceNegativeAllowed(g.Settings)
+			},
+			ToggleSetter: func(s *models.GroupSettings, val bool) {
+				s.BalanceAllowNegative = val
+				s.BalanceAllowNegativeConfigured = true
+			},
+			RequireAdmin: true,
+		},
+
 		// ========== 扩展示例（已注释）==========
 		//
 		// 需要更多配置？取消注释或添加新配置项即可：
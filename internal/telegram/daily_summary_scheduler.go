@@ -15,10 +15,20 @@ import (
 )
 
 type dailySummaryScheduler struct {
-	bot      *Bot
-	cancel   context.CancelFunc
-	done     chan struct{}
-	location *time.Location
+	bot           *Bot
+	cancel        context.CancelFunc
+	done          chan struct{}
+	location      *time.Location
+	summarySender func(ctx context.Context, group *models.Group, targetDate time.Time) error
+	pendingMu     sync.Mutex
+	pending       []deferredSummaryPush
+}
+
+// deferredSummaryPush 因免打扰时段延后的账单推送
+type deferredSummaryPush struct {
+	group      *models.Group
+	targetDate time.Time
+	due        time.Time
 }
 
 func newDailySummaryScheduler(bot *Bot) *dailySummaryScheduler {
@@ -63,9 +73,14 @@ func (s *dailySummaryScheduler) run(ctx context.Context) {
 	defer close(s.done)
 
 	for {
-		now := time.Now().In(s.location)
+		now := s.bot.currentTime().In(s.location)
 		next := nextDailyRun(now, s.location)
-		wait := time.Until(next)
+		runDaily := true
+		if due, ok := s.nextPendingDue(); ok && due.Before(next) {
+			next = due
+			runDaily = false
+		}
+		wait := next.Sub(now)
 		if wait <= 0 {
 			wait = time.Second
 		}
@@ -78,7 +93,10 @@ func (s *dailySummaryScheduler) run(ctx context.Context) {
 			timer.Stop()
 			return
 		case <-timer.C:
-			s.dispatch(ctx)
+			if runDaily {
+				s.dispatch(ctx)
+			}
+			s.flushDeferred(ctx)
 		}
 	}
 }
@@ -89,7 +107,7 @@ func (s *dailySummaryScheduler) dispatch(parent context.Context) {
 	}
 
 	startTime := time.Now()
-	now := time.Now().In(s.location)
+	now := s.bot.currentTime().In(s.location)
 	targetDate := previousBillingDate(now, s.location)
 
 	runCtx, cancel := context.WithTimeout(parent, 2*time.Minute)
@@ -118,6 +136,7 @@ func (s *dailySummaryScheduler) dispatch(parent context.Context) {
 	const workerLimit = 8
 
 	successCount := 0
+	deferredCount := 0
 	failureDetails := make([]string, 0)
 	aborted := false
 	var mu sync.Mutex
@@ -129,6 +148,13 @@ func (s *dailySummaryScheduler) dispatch(parent context.Context) {
 		group := group
 		merchantID := int64(group.Settings.MerchantID)
 
+		if resumeAt, quiet := models.QuietHoursResumeAt(group.Settings, now); quiet {
+			s.deferPush(group, targetDate, resumeAt)
+			deferredCount++
+			logger.L().Infof("Daily bill push deferred by quiet hours: chat_id=%d, resume_at=%s", group.TelegramID, resumeAt.Format(time.RFC3339))
+			continue
+		}
+
 		groupRunner.Go(func() error {
 			if groupCtx.Err() != nil {
 				return groupCtx.Err()
@@ -137,38 +163,16 @@ func (s *dailySummaryScheduler) dispatch(parent context.Context) {
 			ctxWithTimeout, cancelGroup := context.WithTimeout(groupCtx, 15*time.Second)
 			defer cancelGroup()
 
-			message, err := s.bot.sifangFeature.BuildSummaryMessage(ctxWithTimeout, merchantID, targetDate)
-			if err != nil {
+			if err := s.pushSummary(ctxWithTimeout, group, targetDate); err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					return err
 				}
-				logger.L().Errorf("Daily bill push failed: chat_id=%d, merchant_id=%d, err=%v", group.TelegramID, merchantID, err)
 				mu.Lock()
 				failureDetails = append(failureDetails, fmt.Sprintf("chat_id=%d, merchant_id=%d: %v", group.TelegramID, merchantID, err))
 				mu.Unlock()
 				return nil
 			}
 
-			if message == "" {
-				logger.L().Warnf("Daily bill push produced empty message: chat_id=%d", group.TelegramID)
-				mu.Lock()
-				failureDetails = append(failureDetails, fmt.Sprintf("chat_id=%d: 生成的消息为空", group.TelegramID))
-				mu.Unlock()
-				return nil
-			}
-
-			if _, sendErr := s.bot.sendMessageWithMarkupAndMessage(ctxWithTimeout, group.TelegramID, message, nil); sendErr != nil {
-				if errors.Is(sendErr, context.Canceled) || errors.Is(sendErr, context.DeadlineExceeded) {
-					return sendErr
-				}
-				logger.L().Errorf("Daily bill push failed to send: chat_id=%d, merchant_id=%d, err=%v", group.TelegramID, merchantID, sendErr)
-				mu.Lock()
-				failureDetails = append(failureDetails, fmt.Sprintf("chat_id=%d, merchant_id=%d: 发送失败 (%v)", group.TelegramID, merchantID, sendErr))
-				mu.Unlock()
-				return nil
-			}
-
-			logger.L().Infof("Daily bill push sent: chat_id=%d, merchant_id=%d, target_date=%s", group.TelegramID, merchantID, targetDate.Format("2006-01-02"))
 			mu.Lock()
 			successCount++
 			mu.Unlock()
@@ -192,12 +196,96 @@ func (s *dailySummaryScheduler) dispatch(parent context.Context) {
 	if aborted {
 		note = "任务在完成前被取消。"
 	}
+	if deferredCount > 0 {
+		deferredNote := fmt.Sprintf("%d 个群处于免打扰时段，将在时段结束后推送。", deferredCount)
+		if note != "" {
+			note += "\n"
+		}
+		note += deferredNote
+	}
 
 	logger.L().Infof("Daily bill push completed for %d groups (success=%d, failure=%d), target_date=%s", len(eligible), successCount, failureCount, targetDate.Format("2006-01-02"))
 
 	s.notifyOwners(parent, targetDate, len(eligible), successCount, failureCount, duration, note, failureDetails)
 }
 
+// pushSummary 生成并发送单个群组的账单
+func (s *dailySummaryScheduler) pushSummary(ctx context.Context, group *models.Group, targetDate time.Time) error {
+	if s.summarySender != nil {
+		return s.summarySender(ctx, group, targetDate)
+	}
+
+	merchantID := int64(group.Settings.MerchantID)
+	message, err := s.bot.sifangFeature.BuildSummaryMessage(ctx, merchantID, targetDate, group.Settings)
+	if err != nil {
+		logger.L().Errorf("Daily bill push failed: chat_id=%d, merchant_id=%d, err=%v", group.TelegramID, merchantID, err)
+		return err
+	}
+
+	if message == "" {
+		logger.L().Warnf("Daily bill push produced empty message: chat_id=%d", group.TelegramID)
+		return errors.New("生成的消息为空")
+	}
+
+	if _, err := s.bot.sendMessageWithMarkupAndMessage(ctx, group.TelegramID, message, nil); err != nil {
+		logger.L().Errorf("Daily bill push failed to send: chat_id=%d, merchant_id=%d, err=%v", group.TelegramID, merchantID, err)
+		return fmt.Errorf("发送失败 (%w)", err)
+	}
+
+	logger.L().Infof("Daily bill push sent: chat_id=%d, merchant_id=%d, target_date=%s", group.TelegramID, merchantID, targetDate.Format("2006-01-02"))
+	return nil
+}
+
+// deferPush 将免打扰时段内的推送加入待发队列
+func (s *dailySummaryScheduler) deferPush(group *models.Group, targetDate, due time.Time) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	s.pending = append(s.pending, deferredSummaryPush{group: group, targetDate: targetDate, due: due})
+}
+
+// nextPendingDue 返回待发队列中最早的推送时间
+func (s *dailySummaryScheduler) nextPendingDue() (time.Time, bool) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	var earliest time.Time
+	for _, item := range s.pending {
+		if earliest.IsZero() || item.due.Before(earliest) {
+			earliest = item.due
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// flushDeferred 发送已到免打扰结束时间的延后推送
+func (s *dailySummaryScheduler) flushDeferred(parent context.Context) {
+	now := s.bot.currentTime()
+
+	s.pendingMu.Lock()
+	due := make([]deferredSummaryPush, 0, len(s.pending))
+	remaining := s.pending[:0]
+	for _, item := range s.pending {
+		if item.due.After(now) {
+			remaining = append(remaining, item)
+		} else {
+			due = append(due, item)
+		}
+	}
+	s.pending = remaining
+	s.pendingMu.Unlock()
+
+	for _, item := range due {
+		if parent.Err() != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(parent, 15*time.Second)
+		if err := s.pushSummary(ctx, item.group, item.targetDate); err != nil {
+			logger.L().Warnf("Deferred daily bill push failed: chat_id=%d, err=%v", item.group.TelegramID, err)
+		}
+		cancel()
+	}
+}
+
 func filterEligibleMerchantGroups(groups []*models.Group) []*models.Group {
 	eligible := make([]*models.Group, 0, len(groups))
 	for _, group := range groups {
@@ -284,7 +372,7 @@ func (s *dailySummaryScheduler) notifyOwners(parent context.Context, targetDate
 	report := buildDailySummaryReport(targetDate, total, success, failure, duration, note, failureDetails)
 
 	for _, ownerID := range s.bot.ownerIDs {
-		if _, err := s.bot.sendMessageWithMarkupAndMessage(notifyCtx, ownerID, report, nil); err != nil {
+		if err := s.bot.sendDirectMessage(notifyCtx, ownerID, report); err != nil {
 			logger.L().Errorf("Daily bill push failed to notify owner %d: %v", ownerID, err)
 		}
 	}
@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -183,3 +184,60 @@ func TestBuildDailySummaryReportWithoutFailures(t *testing.T) {
 		t.Fatalf("expected report duration to be rounded to milliseconds, got %q", report)
 	}
 }
+
+func TestDailySummaryDispatchDefersQuietHourGroups(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	now := time.Date(2024, 10, 26, 0, 0, 5, 0, loc)
+	merchant := func(id int64, quietHours string) *models.Group {
+		return &models.Group{
+			TelegramID: id,
+			Tier:       models.GroupTierMerchant,
+			BotStatus:  models.BotStatusActive,
+			Settings: models.GroupSettings{
+				MerchantID:    1001,
+				SifangEnabled: true,
+				QuietHours:    quietHours,
+			},
+		}
+	}
+
+	bot := &Bot{
+		nowFunc:      func() time.Time { return now },
+		groupService: &reminderTestGroupService{groups: []*models.Group{merchant(-1001, ""), merchant(-1002, models.QuietHoursNight)}},
+	}
+	scheduler := newDailySummaryScheduler(bot)
+
+	var pushed []int64
+	scheduler.summarySender = func(ctx context.Context, group *models.Group, targetDate time.Time) error {
+		if got := targetDate.Format("2006-01-02"); got != "2024-10-25" {
+			t.Fatalf("expected target date 2024-10-25, got %s", got)
+		}
+		pushed = append(pushed, group.TelegramID)
+		return nil
+	}
+
+	scheduler.dispatch(context.Background())
+	if len(pushed) != 1 || pushed[0] != -1001 {
+		t.Fatalf("expected only the group outside quiet hours to be pushed, got %v", pushed)
+	}
+	due, ok := scheduler.nextPendingDue()
+	if !ok || !due.Equal(time.Date(2024, 10, 26, 8, 0, 0, 0, loc)) {
+		t.Fatalf("expected deferred push due at 08:00, got %s (ok=%v)", due, ok)
+	}
+
+	// 免打扰未结束时不推送
+	now = time.Date(2024, 10, 26, 7, 59, 0, 0, loc)
+	scheduler.flushDeferred(context.Background())
+	if len(pushed) != 1 {
+		t.Fatalf("expected deferred push to wait for quiet hours end, got %v", pushed)
+	}
+
+	now = time.Date(2024, 10, 26, 8, 0, 0, 0, loc)
+	scheduler.flushDeferred(context.Background())
+	if len(pushed) != 2 || pushed[1] != -1002 {
+		t.Fatalf("expected deferred group to be pushed after quiet hours, got %v", pushed)
+	}
+	if _, ok := scheduler.nextPendingDue(); ok {
+		t.Fatalf("expected pending queue to be empty after flush")
+	}
+}
@@ -0,0 +1,22 @@
+// Package dryrun 统一解析批量/高风险命令的预览参数
+package dryrun
+
+import "strings"
+
+// Flag 预览模式参数
+const Flag = "--dry-run"
+
+// Parse 从命令文本中移除 --dry-run 参数，返回剩余文本及是否为预览模式
+func Parse(text string) (string, bool) {
+	fields := strings.Fields(text)
+	rest := make([]string, 0, len(fields))
+	dryRun := false
+	for _, field := range fields {
+		if strings.EqualFold(field, Flag) {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, field)
+	}
+	return strings.Join(rest, " "), dryRun
+}
@@ -0,0 +1,24 @@
+package dryrun
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input  string
+		rest   string
+		dryRun bool
+	}{
+		{input: "/日结", rest: "/日结", dryRun: false},
+		{input: "/日结 --dry-run", rest: "/日结", dryRun: true},
+		{input: "/settle_all  --DRY-RUN  ", rest: "/settle_all", dryRun: true},
+		{input: "/feature --all --dry-run calculator", rest: "/feature --all calculator", dryRun: true},
+		{input: "/日结 --dry-runx", rest: "/日结 --dry-runx", dryRun: false},
+	}
+
+	for _, tc := range cases {
+		rest, dryRun := Parse(tc.input)
+		if rest != tc.rest || dryRun != tc.dryRun {
+			t.Fatalf("Parse(%q) = (%q, %v), want (%q, %v)", tc.input, rest, dryRun, tc.rest, tc.dryRun)
+		}
+	}
+}
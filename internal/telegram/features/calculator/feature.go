@@ -31,13 +31,21 @@ func (f *CalculatorFeature) Enabled(ctx context.Context, group *models.Group) bo
 	return group.Settings.CalculatorEnabled
 }
 
-// Match 检查消息是否匹配(只处理群组中的数学表达式)
-func (f *CalculatorFeature) Match(ctx context.Context, msg *botModels.Message) bool {
-	// 只处理群组消息
-	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
-		return false
+// EnabledReason 功能开关关闭时说明原因
+func (f *CalculatorFeature) EnabledReason(ctx context.Context, group *models.Group) (bool, string) {
+	if !group.Settings.CalculatorEnabled {
+		return false, "未开启「计算器功能」，请在 /configs 中开启"
 	}
+	return true, ""
+}
 
+// AllowedChatTypes 只处理群组消息
+func (f *CalculatorFeature) AllowedChatTypes() []botModels.ChatType {
+	return types.GroupChatTypes
+}
+
+// Match 检查消息是否为数学表达式
+func (f *CalculatorFeature) Match(ctx context.Context, msg *botModels.Message) bool {
 	// 检查是否为数学表达式
 	return IsMathExpression(msg.Text)
 }
@@ -50,7 +58,7 @@ func (f *CalculatorFeature) Process(ctx context.Context, msg *botModels.Message,
 		// 计算失败
 		logger.L().Warnf("Calculator failed: chat_id=%d, text=%s, error=%v", msg.Chat.ID, msg.Text, err)
 		return &types.Response{
-			Text: fmt.Sprintf("❌ 计算错误: %v", err),
+			Text: fmt.Sprintf("❌ 计算错误: %s", html.EscapeString(err.Error())),
 		}, true, nil
 	}
 
@@ -36,13 +36,21 @@ func (f *CryptoFeature) Enabled(ctx context.Context, group *models.Group) bool {
 	return group.Settings.CryptoEnabled
 }
 
-// Match 检查消息是否匹配（只处理群组中的特定命令）
-func (f *CryptoFeature) Match(ctx context.Context, msg *botModels.Message) bool {
-	// 只处理群组消息
-	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
-		return false
+// EnabledReason 功能开关关闭时说明原因
+func (f *CryptoFeature) EnabledReason(ctx context.Context, group *models.Group) (bool, string) {
+	if !group.Settings.CryptoEnabled {
+		return false, "未开启「USDT价格查询」，请在 /configs 中开启"
 	}
+	return true, ""
+}
 
+// AllowedChatTypes 只处理群组消息
+func (f *CryptoFeature) AllowedChatTypes() []botModels.ChatType {
+	return types.GroupChatTypes
+}
+
+// Match 检查消息是否匹配特定命令
+func (f *CryptoFeature) Match(ctx context.Context, msg *botModels.Message) bool {
 	// 检查是否匹配命令格式
 	_, err := ParseCommand(msg.Text)
 	return err == nil
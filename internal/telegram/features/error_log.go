@@ -0,0 +1,80 @@
+package features
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxRecentErrorsPerGroup 每个群保留的最近错误条数，超出后丢弃最旧的记录
+	maxRecentErrorsPerGroup = 20
+	// maxErrorInputRunes 记录触发消息的最大字符数
+	maxErrorInputRunes = 40
+)
+
+// ErrorEntry 功能处理失败记录
+type ErrorEntry struct {
+	At        time.Time // 发生时间
+	Operation string    // 失败的功能名称
+	Input     string    // 触发的消息文本（截断）
+	Message   string    // 错误内容
+}
+
+// errorLog 按群保存最近的功能错误（仅内存，重启后清空）
+type errorLog struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[int64][]ErrorEntry
+}
+
+func newErrorLog(limit int) *errorLog {
+	return &errorLog{
+		limit:   limit,
+		entries: make(map[int64][]ErrorEntry),
+	}
+}
+
+func (l *errorLog) add(chatID int64, entry ErrorEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := append(l.entries[chatID], entry)
+	if len(entries) > l.limit {
+		entries = append([]ErrorEntry(nil), entries[len(entries)-l.limit:]...)
+	}
+	l.entries[chatID] = entries
+}
+
+// list 返回群组的错误记录，最新的在前
+func (l *errorLog) list(chatID int64) []ErrorEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.entries[chatID]
+	result := make([]ErrorEntry, len(entries))
+	for i, entry := range entries {
+		result[len(entries)-1-i] = entry
+	}
+	return result
+}
+
+// truncateErrorInput 截断过长的触发消息，避免错误列表刷屏
+func truncateErrorInput(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= maxErrorInputRunes {
+		return text
+	}
+	return string([]rune(text)[:maxErrorInputRunes]) + "…"
+}
+
+// responseErrorText 提取以 ❌ 开头的功能回复（支付接口失败等以文本形式返回的错误）
+func responseErrorText(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "❌") {
+		return "", false
+	}
+	line, _, _ := strings.Cut(text, "\n")
+	return strings.TrimSpace(strings.TrimPrefix(line, "❌")), true
+}
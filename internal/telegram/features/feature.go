@@ -78,3 +78,13 @@ type Response = types.Response
 type TierAwareFeature interface {
 	AllowedGroupTiers() []models.GroupTier
 }
+
+// ChatTypeAwareFeature 可选接口：实现后仅在指定聊天类型中运行，由 Manager 统一检查
+type ChatTypeAwareFeature interface {
+	AllowedChatTypes() []botModels.ChatType
+}
+
+// EnabledReasonFeature 可选接口：说明功能在当前群组能否实际运行，不能运行时给出原因（用于「功能状态」）
+type EnabledReasonFeature interface {
+	EnabledReason(ctx context.Context, group *models.Group) (enabled bool, reason string)
+}
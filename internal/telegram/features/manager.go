@@ -5,19 +5,46 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	botModels "github.com/go-telegram/bot/models"
 	"go_bot/internal/logger"
+	"go_bot/internal/metrics"
 	"go_bot/internal/telegram/features/types"
 	"go_bot/internal/telegram/models"
 	"go_bot/internal/telegram/service"
 )
 
+// 功能处理指标结果标签
+const (
+	processResultHandled   = "handled"
+	processResultUnhandled = "unhandled"
+	processResultError     = "error"
+)
+
+var (
+	featureProcessDuration = metrics.NewHistogramVec(
+		"bot_feature_process_seconds",
+		"Feature processing latency in seconds.",
+		[]string{"feature"},
+		nil,
+	)
+	featureProcessTotal = metrics.NewCounterVec(
+		"bot_feature_process_total",
+		"Feature processing count by result.",
+		[]string{"feature", "result"},
+	)
+)
+
 // Manager 功能管理器
 // 负责注册、管理和执行所有功能插件
 type Manager struct {
 	features     []Feature
 	groupService service.GroupService
+	usageService service.FeatureUsageService
+	matchDebug   atomic.Bool // 开启后记录每条消息的功能匹配过程
+	recentErrors *errorLog   // 各群最近的功能错误
 }
 
 // NewManager 创建功能管理器
@@ -25,9 +52,25 @@ func NewManager(groupService service.GroupService) *Manager {
 	return &Manager{
 		features:     make([]Feature, 0),
 		groupService: groupService,
+		recentErrors: newErrorLog(maxRecentErrorsPerGroup),
 	}
 }
 
+// SetUsageService 设置功能使用统计服务（可选）
+func (m *Manager) SetUsageService(usageService service.FeatureUsageService) {
+	m.usageService = usageService
+}
+
+// SetMatchDebug 开启或关闭功能匹配调试日志
+func (m *Manager) SetMatchDebug(enabled bool) {
+	m.matchDebug.Store(enabled)
+}
+
+// MatchDebug 返回功能匹配调试日志是否开启
+func (m *Manager) MatchDebug() bool {
+	return m.matchDebug.Load()
+}
+
 // Register 注册功能插件
 // 功能会按优先级自动排序(优先级低的数字先执行)
 func (m *Manager) Register(feature Feature) {
@@ -48,14 +91,28 @@ func (m *Manager) Register(feature Feature) {
 //   - handled: 是否已被某个功能处理
 //   - error: 处理过程中的错误
 func (m *Manager) Process(ctx context.Context, msg *botModels.Message) (response *types.Response, handled bool, err error) {
+	var trace *matchTrace
+	if m.matchDebug.Load() {
+		trace = newMatchTrace(msg.Chat.ID, msg.Text)
+	}
+
 	// 获取群组配置
 	group, err := m.groupService.GetGroupInfo(ctx, msg.Chat.ID)
 	if err != nil {
 		// 群组不存在或获取失败,跳过功能处理
 		logger.L().Debugf("Skip feature processing: group not found or error, chat_id=%d", msg.Chat.ID)
+		trace.log(fmt.Sprintf("skipped (group not found: %v)", err))
 		return nil, false, nil
 	}
 
+	// 群组配置了命令别名时，替换为内置命令后再交给各功能匹配
+	if text, ok := models.ResolveCommandAlias(msg.Text, group.Settings.CommandAliases); ok {
+		aliased := *msg
+		aliased.Text = text
+		msg = &aliased
+		trace.alias(text)
+	}
+
 	tier := models.NormalizeGroupTier(group.Tier)
 
 	// 按优先级顺序执行功能
@@ -63,19 +120,29 @@ func (m *Manager) Process(ctx context.Context, msg *botModels.Message) (response
 		// 1. 检查功能是否启用
 		if !feature.Enabled(ctx, group) {
 			logger.L().Debugf("Feature %s disabled, skipping", feature.Name())
+			trace.add(feature.Name(), "disabled")
 			continue
 		}
 
-		// 2. 检查消息是否匹配
+		// 2. 检查聊天类型是否允许（例如仅群组可用的功能不处理私聊和频道消息）
+		if !isChatTypeAllowed(feature, msg.Chat.Type) {
+			trace.add(feature.Name(), fmt.Sprintf("skipped (chat type=%s)", msg.Chat.Type))
+			continue
+		}
+
+		// 3. 检查消息是否匹配
 		if !feature.Match(ctx, msg) {
+			trace.add(feature.Name(), "no match")
 			continue
 		}
 
-		// 3. 判断群等级是否允许
+		// 4. 判断群等级是否允许
 		if tierAware, ok := feature.(TierAwareFeature); ok {
 			if allowed := tierAware.AllowedGroupTiers(); len(allowed) > 0 && !models.IsTierAllowed(tier, allowed) {
 				logger.L().Infof("Feature blocked: chat_id=%d feature=%s tier=%s allowed=%v text=%q",
 					msg.Chat.ID, feature.Name(), tier, allowed, strings.TrimSpace(msg.Text))
+				trace.add(feature.Name(), fmt.Sprintf("blocked (tier=%s)", tier))
+				trace.log("tier notice")
 				msgText := fmt.Sprintf("⚠️ 该功能仅适用于：%s\n当前群类型：%s",
 					models.FormatAllowedTierList(allowed), models.GroupTierDisplayName(tier))
 				return &types.Response{
@@ -87,20 +154,134 @@ func (m *Manager) Process(ctx context.Context, msg *botModels.Message) (response
 
 		logger.L().Debugf("Feature %s matched message, processing...", feature.Name())
 
-		// 4. 执行功能处理（传递 group 参数）
+		// 5. 执行功能处理（传递 group 参数）
+		startedAt := time.Now()
 		response, handled, err := feature.Process(ctx, msg, group)
+		recordFeatureProcess(feature.Name(), time.Since(startedAt), handled, err)
 
-		// 5. 如果功能已处理(handled=true)或发生错误,停止后续功能执行
+		// 6. 如果功能已处理(handled=true)或发生错误,停止后续功能执行
 		if handled || err != nil {
 			logger.L().Infof("Feature %s processed message (handled=%v, error=%v)", feature.Name(), handled, err)
+			if handled && err == nil && m.usageService != nil {
+				m.usageService.Record(ctx, msg.Chat.ID, feature.Name())
+			}
+			m.recordError(msg, feature.Name(), response, err)
+			trace.add(feature.Name(), fmt.Sprintf("matched (handled=%v, error=%v)", handled, err))
+			trace.log("handled by " + feature.Name())
 			return response, handled, err
 		}
+		trace.add(feature.Name(), "matched but not handled")
 	}
 
 	// 没有任何功能处理该消息
+	trace.log("fallback (no feature handled, stored as plain message)")
 	return nil, false, nil
 }
 
+// isChatTypeAllowed 判断功能是否允许在该聊天类型中运行，未声明 ChatTypeAwareFeature 的功能不限制
+func isChatTypeAllowed(feature Feature, chatType botModels.ChatType) bool {
+	aware, ok := feature.(ChatTypeAwareFeature)
+	if !ok {
+		return true
+	}
+	allowed := aware.AllowedChatTypes()
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, t := range allowed {
+		if t == chatType {
+			return true
+		}
+	}
+	return false
+}
+
+// recordError 记录功能返回的错误或以 ❌ 开头的失败回复
+func (m *Manager) recordError(msg *botModels.Message, operation string, response *types.Response, err error) {
+	message := ""
+	switch {
+	case err != nil:
+		message = err.Error()
+	case response != nil:
+		text, ok := responseErrorText(response.Text)
+		if !ok {
+			return
+		}
+		message = text
+	default:
+		return
+	}
+
+	m.recentErrors.add(msg.Chat.ID, ErrorEntry{
+		At:        time.Now(),
+		Operation: operation,
+		Input:     truncateErrorInput(msg.Text),
+		Message:   message,
+	})
+}
+
+// FeatureStatus 单个功能在群组中的启用状态
+type FeatureStatus struct {
+	Name    string // 功能名称
+	Enabled bool   // 是否可以运行
+	Reason  string // 不能运行的原因
+}
+
+// FeatureStatuses 按优先级列出各功能在群组中的启用状态与原因
+func (m *Manager) FeatureStatuses(ctx context.Context, group *models.Group) []FeatureStatus {
+	tier := models.NormalizeGroupTier(group.Tier)
+	statuses := make([]FeatureStatus, 0, len(m.features))
+	for _, feature := range m.features {
+		status := FeatureStatus{Name: feature.Name(), Enabled: true}
+		if tierAware, ok := feature.(TierAwareFeature); ok {
+			if allowed := tierAware.AllowedGroupTiers(); len(allowed) > 0 && !models.IsTierAllowed(tier, allowed) {
+				status.Enabled = false
+				status.Reason = fmt.Sprintf("仅适用于：%s（当前：%s）", models.FormatAllowedTierList(allowed), models.GroupTierDisplayName(tier))
+				statuses = append(statuses, status)
+				continue
+			}
+		}
+
+		if reasoner, ok := feature.(EnabledReasonFeature); ok {
+			status.Enabled, status.Reason = reasoner.EnabledReason(ctx, group)
+		} else if !feature.Enabled(ctx, group) {
+			status.Enabled = false
+			status.Reason = "功能未开启"
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// RecentErrors 返回群组最近的功能错误，最新的在前
+func (m *Manager) RecentErrors(chatID int64) []ErrorEntry {
+	return m.recentErrors.list(chatID)
+}
+
+// recordFeatureProcess 记录单个功能的处理耗时与结果
+func recordFeatureProcess(name string, elapsed time.Duration, handled bool, err error) {
+	result := processResultUnhandled
+	switch {
+	case err != nil:
+		result = processResultError
+	case handled:
+		result = processResultHandled
+	}
+	featureProcessDuration.Observe(elapsed.Seconds(), name)
+	featureProcessTotal.Inc(name, result)
+}
+
+// MatchesText 判断文本是否会被某个已注册功能匹配（不检查启用状态），用于校验命令别名
+func (m *Manager) MatchesText(ctx context.Context, text string) bool {
+	msg := &botModels.Message{Text: text, Chat: botModels.Chat{Type: botModels.ChatTypeSupergroup}}
+	for _, feature := range m.features {
+		if feature.Match(ctx, msg) {
+			return true
+		}
+	}
+	return false
+}
+
 // ListFeatures 列出所有已注册的功能(用于调试)
 func (m *Manager) ListFeatures() []string {
 	names := make([]string, len(m.features))
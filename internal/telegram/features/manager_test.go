@@ -0,0 +1,399 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/metrics"
+	paymentservice "go_bot/internal/payment/service"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/features/upstream"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+type managerTestGroupService struct {
+	service.GroupService
+	group *models.Group
+}
+
+func (s *managerTestGroupService) GetGroupInfo(ctx context.Context, telegramID int64) (*models.Group, error) {
+	return s.group, nil
+}
+
+type managerTestUsageService struct {
+	service.FeatureUsageService
+	counts map[int64]map[string]int
+}
+
+func (s *managerTestUsageService) Record(ctx context.Context, groupID int64, feature string) {
+	if s.counts[groupID] == nil {
+		s.counts[groupID] = make(map[string]int)
+	}
+	s.counts[groupID][feature]++
+}
+
+type managerTestFeature struct {
+	name     string
+	priority int
+	match    string
+}
+
+func (f *managerTestFeature) Name() string                                          { return f.name }
+func (f *managerTestFeature) Enabled(ctx context.Context, group *models.Group) bool { return true }
+func (f *managerTestFeature) Priority() int                                         { return f.priority }
+
+func (f *managerTestFeature) Match(ctx context.Context, msg *botModels.Message) bool {
+	return msg.Text == f.match
+}
+
+func (f *managerTestFeature) Process(ctx context.Context, msg *botModels.Message, group *models.Group) (*types.Response, bool, error) {
+	return &types.Response{Text: f.name}, true, nil
+}
+
+func TestManagerProcessRecordsFeatureUsage(t *testing.T) {
+	usage := &managerTestUsageService{counts: make(map[int64]map[string]int)}
+	manager := NewManager(&managerTestGroupService{group: &models.Group{TelegramID: -1001}})
+	manager.SetUsageService(usage)
+	manager.Register(&managerTestFeature{name: "bill", priority: 10, match: "账单"})
+	manager.Register(&managerTestFeature{name: "balance", priority: 20, match: "余额"})
+
+	msg := &botModels.Message{Text: "余额", Chat: botModels.Chat{ID: -1001}}
+	for i := 0; i < 2; i++ {
+		if _, handled, err := manager.Process(context.Background(), msg); !handled || err != nil {
+			t.Fatalf("expected message handled, got handled=%v err=%v", handled, err)
+		}
+	}
+
+	if got := usage.counts[-1001]["balance"]; got != 2 {
+		t.Fatalf("expected balance counter 2, got %d", got)
+	}
+	if got := usage.counts[-1001]["bill"]; got != 0 {
+		t.Fatalf("expected bill counter untouched, got %d", got)
+	}
+
+	if _, handled, _ := manager.Process(context.Background(), &botModels.Message{Text: "hello", Chat: botModels.Chat{ID: -1001}}); handled {
+		t.Fatal("expected unmatched message to be unhandled")
+	}
+	if len(usage.counts[-1001]) != 1 {
+		t.Fatalf("expected no counter for unmatched message, got %v", usage.counts[-1001])
+	}
+}
+
+type managerTestFailingFeature struct {
+	managerTestFeature
+}
+
+func (f *managerTestFailingFeature) Process(ctx context.Context, msg *botModels.Message, group *models.Group) (*types.Response, bool, error) {
+	return nil, false, errors.New("boom")
+}
+
+func TestManagerProcessRecordsLatencyMetrics(t *testing.T) {
+	manager := NewManager(&managerTestGroupService{group: &models.Group{TelegramID: -1001}})
+	manager.Register(&managerTestFeature{name: "metrics_ok", priority: 10, match: "ok"})
+	manager.Register(&managerTestFailingFeature{managerTestFeature{name: "metrics_fail", priority: 20, match: "fail"}})
+
+	observed := featureProcessDuration.Count("metrics_ok")
+	handled := featureProcessTotal.Value("metrics_ok", processResultHandled)
+	failed := featureProcessTotal.Value("metrics_fail", processResultError)
+
+	if _, _, err := manager.Process(context.Background(), &botModels.Message{Text: "ok", Chat: botModels.Chat{ID: -1001}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := manager.Process(context.Background(), &botModels.Message{Text: "fail", Chat: botModels.Chat{ID: -1001}}); err == nil {
+		t.Fatal("expected feature error")
+	}
+
+	if got := featureProcessDuration.Count("metrics_ok"); got != observed+1 {
+		t.Fatalf("expected one latency observation, got %d", got-observed)
+	}
+	if got := featureProcessTotal.Value("metrics_ok", processResultHandled); got != handled+1 {
+		t.Fatalf("expected handled counter +1, got %d", got-handled)
+	}
+	if got := featureProcessTotal.Value("metrics_fail", processResultError); got != failed+1 {
+		t.Fatalf("expected error counter +1, got %d", got-failed)
+	}
+	if got := featureProcessTotal.Value("metrics_ok", processResultError); got != 0 {
+		t.Fatalf("expected no error for metrics_ok, got %d", got)
+	}
+
+	var buf strings.Builder
+	metrics.WriteText(&buf)
+	if !strings.Contains(buf.String(), `bot_feature_process_total{feature="metrics_fail",result="error"}`) {
+		t.Fatalf("expected exported error series, got:\n%s", buf.String())
+	}
+}
+
+func TestManagerProcessResolvesCommandAlias(t *testing.T) {
+	group := &models.Group{
+		TelegramID: -1001,
+		Tier:       models.GroupTierUpstream,
+		Settings: models.GroupSettings{
+			InterfaceBindings: []models.InterfaceBinding{{ID: "pz01", Name: "支付宝"}},
+			CommandAliases:    map[string]string{"bill": "上游账单"},
+		},
+	}
+	usage := &managerTestUsageService{counts: make(map[int64]map[string]int)}
+	manager := NewManager(&managerTestGroupService{group: group})
+	manager.SetUsageService(usage)
+	manager.Register(upstream.NewSummaryFeature(nil))
+
+	msg := &botModels.Message{Text: "BILL 10月26", Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup}}
+	response, handled, err := manager.Process(context.Background(), msg)
+	if !handled || err != nil {
+		t.Fatalf("expected alias to trigger summary feature, got handled=%v err=%v", handled, err)
+	}
+	if response == nil || response.Text != types.PaymentNotConfiguredText {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if usage.counts[-1001]["upstream_summary"] != 1 {
+		t.Fatalf("expected summary usage recorded, got %v", usage.counts[-1001])
+	}
+	if msg.Text != "BILL 10月26" {
+		t.Fatalf("original message must not be modified, got %q", msg.Text)
+	}
+
+	if !manager.MatchesText(context.Background(), "上游账单") || manager.MatchesText(context.Background(), "bill") {
+		t.Fatal("expected MatchesText to recognise built-in commands only")
+	}
+}
+
+type managerTestUserService struct {
+	service.UserService
+}
+
+func (s *managerTestUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return true, nil
+}
+
+type managerTestBalanceService struct {
+	service.UpstreamBalanceService
+}
+
+func (s *managerTestBalanceService) Get(ctx context.Context, groupID int64) (*service.UpstreamBalanceResult, error) {
+	return &service.UpstreamBalanceResult{GroupID: groupID, Balance: 80, MinBalance: 100, AlertLimitPerHour: 3}, nil
+}
+
+type managerTestPaymentService struct {
+	paymentservice.Service
+}
+
+func (s *managerTestPaymentService) GetBalance(ctx context.Context, merchantID int64, historyDays int) (*paymentservice.Balance, error) {
+	return &paymentservice.Balance{Balance: "1234.56"}, nil
+}
+
+func TestManagerBalanceCommandRoutesByGroupTier(t *testing.T) {
+	tests := []struct {
+		name  string
+		group *models.Group
+		want  []string
+	}{
+		{
+			name: "upstream group shows upstream balance",
+			group: &models.Group{TelegramID: -1001, Tier: models.GroupTierUpstream, Settings: models.GroupSettings{
+				SifangEnabled:     true,
+				InterfaceBindings: []models.InterfaceBinding{{Name: "支付宝", ID: "IF-1"}},
+			}},
+			want: []string{"⚠️ 余额低于阈值", "当前余额：80", "最低余额：100"},
+		},
+		{
+			name: "merchant group shows sifang balance",
+			group: &models.Group{TelegramID: -1001, Tier: models.GroupTierMerchant, Settings: models.GroupSettings{
+				SifangEnabled: true,
+				MerchantID:    2024001,
+			}},
+			want: []string{"1234.56"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(&managerTestGroupService{group: tt.group})
+			manager.Register(upstream.NewBalanceFeature(&managerTestBalanceService{}, &managerTestUserService{}, nil))
+			manager.Register(sifangfeature.New(&managerTestPaymentService{}, &managerTestUserService{}))
+
+			msg := &botModels.Message{
+				Text: "余额",
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 1},
+			}
+			response, handled, err := manager.Process(context.Background(), msg)
+			if !handled || err != nil || response == nil {
+				t.Fatalf("expected balance handled, got response=%+v handled=%v err=%v", response, handled, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(response.Text, want) {
+					t.Fatalf("expected %q in response, got %q", want, response.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerMatchDebugLogsUnmatchedMessage(t *testing.T) {
+	hook := logtest.NewLocal(logger.L())
+	defer hook.Reset()
+
+	manager := NewManager(&managerTestGroupService{group: &models.Group{TelegramID: -1001}})
+	manager.Register(&managerTestFeature{name: "bill", priority: 10, match: "账单"})
+	msg := &botModels.Message{Text: "账单不对呀", Chat: botModels.Chat{ID: -1001}}
+
+	if _, handled, _ := manager.Process(context.Background(), msg); handled {
+		t.Fatal("expected message to fall through")
+	}
+	if entries := matchDebugEntries(hook); len(entries) != 0 {
+		t.Fatalf("expected no debug entry when disabled, got %v", entries)
+	}
+
+	manager.SetMatchDebug(true)
+	if _, handled, _ := manager.Process(context.Background(), msg); handled {
+		t.Fatal("expected message to fall through")
+	}
+	entries := matchDebugEntries(hook)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 debug entry, got %v", entries)
+	}
+	if !strings.Contains(entries[0], "bill=no match") || !strings.Contains(entries[0], "result=fallback") {
+		t.Fatalf("unexpected debug entry: %s", entries[0])
+	}
+}
+
+type managerTestGroupOnlyFeature struct {
+	managerTestFeature
+}
+
+func (f *managerTestGroupOnlyFeature) AllowedChatTypes() []botModels.ChatType {
+	return types.GroupChatTypes
+}
+
+func TestManagerSkipsFeatureForDisallowedChatType(t *testing.T) {
+	manager := NewManager(&managerTestGroupService{group: &models.Group{TelegramID: 42}})
+	manager.Register(&managerTestGroupOnlyFeature{managerTestFeature{name: "group-only", priority: 10, match: "账单"}})
+	manager.Register(&managerTestFeature{name: "any", priority: 20, match: "账单"})
+
+	private := &botModels.Message{Text: "账单", Chat: botModels.Chat{ID: 42, Type: botModels.ChatTypePrivate}}
+	response, handled, err := manager.Process(context.Background(), private)
+	if err != nil || !handled || response == nil || response.Text != "any" {
+		t.Fatalf("expected private message to skip group-only feature, got response=%+v handled=%v err=%v", response, handled, err)
+	}
+
+	group := &botModels.Message{Text: "账单", Chat: botModels.Chat{ID: 42, Type: botModels.ChatTypeSupergroup}}
+	response, _, _ = manager.Process(context.Background(), group)
+	if response == nil || response.Text != "group-only" {
+		t.Fatalf("expected group message to reach group-only feature, got %+v", response)
+	}
+}
+
+func matchDebugEntries(hook *logtest.Hook) []string {
+	var entries []string
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "Feature match debug") {
+			entries = append(entries, entry.Message)
+		}
+	}
+	return entries
+}
+
+type managerTestErrorFeature struct {
+	managerTestFeature
+	err  error
+	text string
+}
+
+func (f *managerTestErrorFeature) Process(ctx context.Context, msg *botModels.Message, group *models.Group) (*types.Response, bool, error) {
+	if f.err != nil {
+		return nil, true, f.err
+	}
+	return &types.Response{Text: f.text}, true, nil
+}
+
+func TestManagerProcessRecordsRecentErrors(t *testing.T) {
+	manager := NewManager(&managerTestGroupService{group: &models.Group{TelegramID: -1001}})
+	manager.Register(&managerTestErrorFeature{
+		managerTestFeature: managerTestFeature{name: "balance", priority: 10, match: "余额"},
+		err:                errors.New("payment timeout"),
+	})
+	manager.Register(&managerTestErrorFeature{
+		managerTestFeature: managerTestFeature{name: "bill", priority: 20, match: "账单"},
+		text:               "❌ 查询账单失败：upstream 502\n请稍后重试",
+	})
+	manager.Register(&managerTestFeature{name: "ok", priority: 30, match: "ok"})
+
+	for _, text := range []string{"余额", "账单", "ok"} {
+		manager.Process(context.Background(), &botModels.Message{Text: text, Chat: botModels.Chat{ID: -1001}})
+	}
+
+	entries := manager.RecentErrors(-1001)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 recorded errors, got %+v", entries)
+	}
+	if entries[0].Operation != "bill" || entries[0].Message != "查询账单失败：upstream 502" || entries[0].Input != "账单" {
+		t.Fatalf("unexpected newest entry: %+v", entries[0])
+	}
+	if entries[1].Operation != "balance" || entries[1].Message != "payment timeout" || entries[1].At.IsZero() {
+		t.Fatalf("unexpected oldest entry: %+v", entries[1])
+	}
+	if other := manager.RecentErrors(-2002); len(other) != 0 {
+		t.Fatalf("expected errors to be kept per group, got %+v", other)
+	}
+}
+
+func TestErrorLogDropsOldestEntries(t *testing.T) {
+	log := newErrorLog(3)
+	for _, message := range []string{"e1", "e2", "e3", "e4", "e5"} {
+		log.add(-1001, ErrorEntry{Operation: "bill", Message: message})
+	}
+
+	entries := log.list(-1001)
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Message)
+	}
+	if strings.Join(got, ",") != "e5,e4,e3" {
+		t.Fatalf("expected newest three entries, got %v", got)
+	}
+}
+
+func TestManagerFeatureStatusesExplainDisabledFeatures(t *testing.T) {
+	manager := NewManager(&managerTestGroupService{})
+	manager.Register(sifangfeature.New(&managerTestPaymentService{}, &managerTestUserService{}))
+	manager.Register(upstream.NewBalanceFeature(&managerTestBalanceService{}, &managerTestUserService{}, nil))
+	manager.Register(&managerTestFeature{name: "plain", priority: 90, match: "plain"})
+
+	group := &models.Group{
+		TelegramID: -1001,
+		Tier:       models.GroupTierMerchant,
+		Settings:   models.GroupSettings{SifangEnabled: true},
+	}
+
+	statuses := make(map[string]FeatureStatus)
+	for _, status := range manager.FeatureStatuses(context.Background(), group) {
+		statuses[status.Name] = status
+	}
+
+	sifang := statuses["sifang_payment"]
+	if sifang.Enabled || !strings.Contains(sifang.Reason, "未绑定商户号") {
+		t.Fatalf("expected sifang to report missing merchant binding, got %+v", sifang)
+	}
+	balance := statuses["upstream_balance"]
+	if balance.Enabled || !strings.Contains(balance.Reason, "仅适用于") {
+		t.Fatalf("expected upstream balance to report tier restriction, got %+v", balance)
+	}
+	if plain := statuses["plain"]; !plain.Enabled || plain.Reason != "" {
+		t.Fatalf("expected plain feature enabled, got %+v", plain)
+	}
+
+	group.Settings.MerchantID = 1001
+	for _, status := range manager.FeatureStatuses(context.Background(), group) {
+		if status.Name == "sifang_payment" && !status.Enabled {
+			t.Fatalf("expected sifang enabled after binding, got %+v", status)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+
+	"go_bot/internal/logger"
+)
+
+// matchTrace 记录单条消息的功能匹配过程，仅在调试模式开启时创建
+type matchTrace struct {
+	chatID    int64
+	text      string
+	aliasText string
+	steps     []string
+}
+
+func newMatchTrace(chatID int64, text string) *matchTrace {
+	return &matchTrace{chatID: chatID, text: strings.TrimSpace(text)}
+}
+
+// alias 记录命令别名替换，trace 为 nil 时忽略
+func (t *matchTrace) alias(text string) {
+	if t == nil {
+		return
+	}
+	t.aliasText = text
+}
+
+// add 记录某个功能的判定结果，trace 为 nil 时忽略
+func (t *matchTrace) add(feature, reason string) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, fmt.Sprintf("%s=%s", feature, reason))
+}
+
+// log 输出完整的匹配过程及最终结果
+func (t *matchTrace) log(result string) {
+	if t == nil {
+		return
+	}
+	alias := ""
+	if t.aliasText != "" {
+		alias = fmt.Sprintf(" alias=%q", t.aliasText)
+	}
+	logger.L().Infof("Feature match debug: chat_id=%d text=%q%s steps=[%s] result=%s",
+		t.chatID, t.text, alias, strings.Join(t.steps, ", "), result)
+}
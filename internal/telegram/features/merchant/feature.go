@@ -3,7 +3,9 @@ package merchant
 import (
 	"context"
 	"fmt"
+	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -54,8 +56,8 @@ func (f *Feature) Match(ctx context.Context, msg *botModels.Message) bool {
 		return false
 	}
 
-	// 匹配: "绑定 123456", "解绑", "商户号", "绑定状态"
-	pattern := `^(绑定\s+\d+|解绑|商户号|绑定状态)$`
+	// 匹配: "绑定 123456", "解绑", "商户号", "绑定状态", "添加商户 123456", "移除商户 123456"
+	pattern := `^(绑定\s+\d+|解绑|商户号|绑定状态|添加商户\s+\d+|移除商户\s+\d+)$`
 	matched, _ := regexp.MatchString(pattern, strings.TrimSpace(msg.Text))
 	return matched
 }
@@ -82,6 +84,17 @@ func (f *Feature) Process(ctx context.Context, msg *botModels.Message, group *mo
 		return resp(respText), handled, err
 	}
 
+	// 附加商户号命令
+	if strings.HasPrefix(text, "添加商户") {
+		respText, handled, err := f.handleAddMerchant(ctx, msg, text)
+		return resp(respText), handled, err
+	}
+
+	if strings.HasPrefix(text, "移除商户") {
+		respText, handled, err := f.handleRemoveMerchant(ctx, msg, text)
+		return resp(respText), handled, err
+	}
+
 	// 解绑命令
 	if text == "解绑" {
 		respText, handled, err := f.handleUnbind(ctx, msg)
@@ -175,9 +188,10 @@ func (f *Feature) handleUnbind(ctx context.Context, msg *botModels.Message) (str
 
 	oldMerchantID := group.Settings.MerchantID
 
-	// 执行解绑
+	// 执行解绑（附加商户号一并清除）
 	settings := group.Settings
 	settings.MerchantID = 0
+	settings.MerchantIDs = nil
 
 	if err := f.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
 		logger.L().Errorf("Failed to unbind merchant ID: chat_id=%d, err=%v", msg.Chat.ID, err)
@@ -202,9 +216,105 @@ func (f *Feature) handleQuery(ctx context.Context, msg *botModels.Message) (stri
 		return "ℹ️ 当前群组未绑定商户号\n\n使用「绑定 [商户号]」进行绑定\n例如: 绑定 2025100", true, nil
 	}
 
+	if extras := models.GroupMerchantIDs(group.Settings)[1:]; len(extras) > 0 {
+		return fmt.Sprintf("✅ 当前绑定商户号: %d\n附加商户号: %s\n\n账单/余额将按商户分段展示，使用「移除商户 [商户号]」移除附加商户号",
+			group.Settings.MerchantID, joinMerchantIDs(extras)), true, nil
+	}
+
 	return fmt.Sprintf("✅ 当前绑定商户号: %d\n\n使用「解绑」可以解除绑定", group.Settings.MerchantID), true, nil
 }
 
+// handleAddMerchant 处理"添加商户 [商户号]"命令，为已绑定主商户号的群追加商户号
+func (f *Feature) handleAddMerchant(ctx context.Context, msg *botModels.Message, text string) (string, bool, error) {
+	merchantID, errText := parseMerchantArgument(text, "添加商户")
+	if errText != "" {
+		return errText, true, nil
+	}
+
+	group, err := f.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+	if err != nil {
+		logger.L().Errorf("Failed to get group info: chat_id=%d, err=%v", msg.Chat.ID, err)
+		return "❌ 获取群组信息失败", true, nil
+	}
+
+	if group.Settings.MerchantID == 0 {
+		return "❌ 请先使用「绑定 [商户号]」绑定主商户号", true, nil
+	}
+
+	if slices.Contains(models.GroupMerchantIDs(group.Settings), merchantID) {
+		return fmt.Sprintf("✅ 商户号 %d 已在当前群组中", merchantID), true, nil
+	}
+
+	settings := group.Settings
+	settings.MerchantIDs = append(models.GroupMerchantIDs(group.Settings)[1:], merchantID)
+
+	if err := f.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
+		logger.L().Errorf("Failed to add merchant ID: chat_id=%d, merchant_id=%d, err=%v", msg.Chat.ID, merchantID, err)
+		return "❌ 添加失败，请稍后重试", true, nil
+	}
+
+	logger.L().Infof("Merchant ID added: chat_id=%d, merchant_id=%d, operator=%d", msg.Chat.ID, merchantID, msg.From.ID)
+	return fmt.Sprintf("✅ 已添加商户号: %d\n当前商户号: %s", merchantID, joinMerchantIDs(models.GroupMerchantIDs(settings))), true, nil
+}
+
+// handleRemoveMerchant 处理"移除商户 [商户号]"命令，仅移除附加商户号
+func (f *Feature) handleRemoveMerchant(ctx context.Context, msg *botModels.Message, text string) (string, bool, error) {
+	merchantID, errText := parseMerchantArgument(text, "移除商户")
+	if errText != "" {
+		return errText, true, nil
+	}
+
+	group, err := f.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+	if err != nil {
+		logger.L().Errorf("Failed to get group info: chat_id=%d, err=%v", msg.Chat.ID, err)
+		return "❌ 获取群组信息失败", true, nil
+	}
+
+	if merchantID == int64(group.Settings.MerchantID) {
+		return "❌ 主商户号请使用「解绑」解除", true, nil
+	}
+
+	extras := models.GroupMerchantIDs(group.Settings)
+	if len(extras) > 0 {
+		extras = extras[1:]
+	}
+	index := slices.Index(extras, merchantID)
+	if index < 0 {
+		return fmt.Sprintf("ℹ️ 商户号 %d 不在当前群组中", merchantID), true, nil
+	}
+
+	settings := group.Settings
+	settings.MerchantIDs = slices.Delete(extras, index, index+1)
+	if len(settings.MerchantIDs) == 0 {
+		settings.MerchantIDs = nil
+	}
+
+	if err := f.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
+		logger.L().Errorf("Failed to remove merchant ID: chat_id=%d, merchant_id=%d, err=%v", msg.Chat.ID, merchantID, err)
+		return "❌ 移除失败，请稍后重试", true, nil
+	}
+
+	logger.L().Infof("Merchant ID removed: chat_id=%d, merchant_id=%d, operator=%d", msg.Chat.ID, merchantID, msg.From.ID)
+	return fmt.Sprintf("✅ 已移除商户号: %d", merchantID), true, nil
+}
+
+func parseMerchantArgument(text, command string) (int64, string) {
+	arg := strings.TrimSpace(strings.TrimPrefix(text, command))
+	merchantID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || merchantID <= 0 || merchantID > math.MaxInt32 {
+		return 0, "❌ 商户号格式错误"
+	}
+	return merchantID, ""
+}
+
+func joinMerchantIDs(merchants []int64) string {
+	parts := make([]string, 0, len(merchants))
+	for _, merchantID := range merchants {
+		parts = append(parts, strconv.FormatInt(merchantID, 10))
+	}
+	return strings.Join(parts, "、")
+}
+
 func resp(text string) *types.Response {
 	if strings.TrimSpace(text) == "" {
 		return nil
@@ -31,6 +31,7 @@ var (
 	chinaLocation          = mustLoadChinaLocation()
 	dateSuffixRegexp       = regexp.MustCompile(`^[0-9\s./\-年月日号]*$`)
 	googleCodeSuffixRegexp = regexp.MustCompile(`\s+(\d{6})$`)
+	channelCodeRegexp      = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_\-]*$`)
 	fetchC2COrders         = cryptofeature.FetchC2COrders
 	createOrderPrefixes    = []string{"模拟下单", "模拟创建订单"}
 )
@@ -40,6 +41,7 @@ const (
 	SendMoneyCallbackPrefix = "sifang:sendmoney:"
 	sendMoneyActionConfirm  = "confirm"
 	sendMoneyActionCancel   = "cancel"
+	sendMoneyRecordTimeout  = 5 * time.Second
 )
 
 type pendingSendMoney struct {
@@ -50,7 +52,9 @@ type pendingSendMoney struct {
 	amount     float64
 	quote      *sendMoneyQuoteSnapshot
 	googleCode string
-	createdAt  time.Time
+	// awaitingCode 群组要求谷歌验证码但下发时未附带，等待发起人回复验证码
+	awaitingCode bool
+	createdAt    time.Time
 }
 
 type sendMoneyQuoteSnapshot struct {
@@ -81,8 +85,10 @@ type Feature struct {
 	paymentService    paymentservice.Service
 	userService       service.UserService
 	withdrawQuoteRepo repository.WithdrawQuoteRepository
+	sendMoneyRepo     repository.SendMoneyRecordRepository
 	mu                sync.Mutex
 	pending           map[string]*pendingSendMoney
+	nowFunc           func() time.Time
 }
 
 // New 创建四方支付功能实例
@@ -91,14 +97,28 @@ func New(paymentSvc paymentservice.Service, userSvc service.UserService) *Featur
 		paymentService: paymentSvc,
 		userService:    userSvc,
 		pending:        make(map[string]*pendingSendMoney),
+		nowFunc:        time.Now,
 	}
 }
 
+// currentTime 返回北京时间的当前时间，「今天」的边界统一按北京时间自然日计算
+func (f *Feature) currentTime() time.Time {
+	if f.nowFunc != nil {
+		return f.nowFunc().In(chinaLocation)
+	}
+	return time.Now().In(chinaLocation)
+}
+
 // SetWithdrawQuoteRepository 设置下发汇率快照仓储（可选）
 func (f *Feature) SetWithdrawQuoteRepository(repo repository.WithdrawQuoteRepository) {
 	f.withdrawQuoteRepo = repo
 }
 
+// SetSendMoneyRecordRepository 设置下发记录仓储（可选，用于与上游提现列表对账）
+func (f *Feature) SetSendMoneyRecordRepository(repo repository.SendMoneyRecordRepository) {
+	f.sendMoneyRepo = repo
+}
+
 // Name 功能名称
 func (f *Feature) Name() string {
 	return "sifang_payment"
@@ -116,17 +136,38 @@ func (f *Feature) Enabled(ctx context.Context, group *models.Group) bool {
 	return group.Settings.SifangEnabled
 }
 
+// EnabledReason 说明四方支付命令能否运行：需开启功能、绑定商户号并配置支付服务
+func (f *Feature) EnabledReason(ctx context.Context, group *models.Group) (bool, string) {
+	switch {
+	case !group.Settings.SifangEnabled:
+		return false, "未开启「四方支付查询」，请在 /configs 中开启"
+	case group.Settings.MerchantID == 0:
+		return false, "未绑定商户号，请先使用「绑定 [商户号]」命令"
+	case !paymentservice.Available(f.paymentService):
+		return false, "四方支付服务未配置（SIFANG_BASE_URL）"
+	}
+	return true, ""
+}
+
+// AllowedChatTypes 只处理群组消息
+func (f *Feature) AllowedChatTypes() []botModels.ChatType {
+	return types.GroupChatTypes
+}
+
 // Match 支持命令：
 //   - 余额
 //   - 账单 / 账单10月26（可指定日期）
 //   - 下发 [金额 or 表达式] [可选谷歌验证码]
 //   - 模拟下单 / 模拟创建订单 [金额 or 表达式] [可选通道代码] [可选订单号]
 //   - 下发 [a|z|k|w][序号] [U金额] [可选谷歌验证码]
+//   - 异常订单 [订单号]
+//   - 订单 <订单号>
+//   - 快照（今日账单、余额与通道状态汇总）
+//   - 对比 10月25 10月26（两日账单差异）
+//   - 导出提款 / 导出提款明细 [可选日期]（CSV 文件）
+//   - 订单原文 <订单号> [--full]（仅 Owner）
+//   - 原始响应 [商户号]（仅 Owner，需开启 SIFANG_RAW_CAPTURE）
 func (f *Feature) Match(ctx context.Context, msg *botModels.Message) bool {
-	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
-		return false
-	}
-
 	text := strings.TrimSpace(msg.Text)
 	if text == "" {
 		return false
@@ -140,7 +181,7 @@ func (f *Feature) Match(ctx context.Context, msg *botModels.Message) bool {
 		return true
 	}
 
-	if _, ok := extractDateSuffix(text, "通道账单"); ok {
+	if _, _, ok := extractChannelSummaryArgs(text); ok {
 		return true
 	}
 
@@ -148,6 +189,10 @@ func (f *Feature) Match(ctx context.Context, msg *botModels.Message) bool {
 		return true
 	}
 
+	if _, ok := withdrawExportDateSuffix(text); ok {
+		return true
+	}
+
 	if text == "费率" {
 		return true
 	}
@@ -156,17 +201,45 @@ func (f *Feature) Match(ctx context.Context, msg *botModels.Message) bool {
 		return true
 	}
 
+	if f.isAwaitingGoogleCode(msg, text) {
+		return true
+	}
+
 	if isCreateOrderCommand(text) {
 		return true
 	}
 
+	if isNotifyCheckCommand(text) {
+		return true
+	}
+
+	if isOrderRawCommand(text) {
+		return true
+	}
+
+	if isRawResponseCommand(text) {
+		return true
+	}
+
+	if isOrderQueryCommand(text) {
+		return true
+	}
+
+	if text == snapshotCommand {
+		return true
+	}
+
+	if _, _, ok := extractSummaryCompareArgs(text); ok {
+		return true
+	}
+
 	return false
 }
 
 // Process 执行四方支付查询
 func (f *Feature) Process(ctx context.Context, msg *botModels.Message, group *models.Group) (*types.Response, bool, error) {
-	if f.paymentService == nil {
-		return wrapResponse("❌ 未配置四方支付服务，请联系管理员"), true, nil
+	if !paymentservice.Available(f.paymentService) {
+		return wrapResponse(types.PaymentNotConfiguredText), true, nil
 	}
 
 	if msg.From == nil {
@@ -178,34 +251,47 @@ func (f *Feature) Process(ctx context.Context, msg *botModels.Message, group *mo
 		return wrapResponse("ℹ️ 当前群组未绑定商户号，请先使用「绑定 [商户号]」命令"), true, nil
 	}
 
+	merchants := models.GroupMerchantIDs(group.Settings)
+
 	text := strings.TrimSpace(msg.Text)
 	if suffix, ok := extractDateSuffix(text, "余额"); ok {
+		if len(merchants) > 1 {
+			return wrapResponse(f.withQueryTimeFooter(group.Settings, f.handleMultiMerchantBalance(ctx, merchants, suffix))), true, nil
+		}
 		respText, handled, err := f.handleBalance(ctx, merchantID, suffix)
-		return wrapResponse(respText), handled, err
+		return wrapResponse(f.withQueryTimeFooter(group.Settings, respText)), handled, err
 	}
 
 	if text == "费率" {
 		respText, handled, err := f.handleChannelRates(ctx, merchantID)
-		return wrapResponse(respText), handled, err
+		return wrapResponse(f.withQueryTimeFooter(group.Settings, respText)), handled, err
 	}
 
 	if _, ok := extractDateSuffix(text, "账单"); ok {
-		respText, handled, err := f.handleSummary(ctx, merchantID, text)
-		return wrapResponse(respText), handled, err
+		respText, handled, err := f.handleSummary(ctx, merchants, text, summaryOptionsFor(group.Settings))
+		return wrapResponse(f.withQueryTimeFooter(group.Settings, respText)), handled, err
 	}
 
-	if _, ok := extractDateSuffix(text, "通道账单"); ok {
-		respText, handled, err := f.handleChannelSummary(ctx, merchantID, text)
-		return wrapResponse(respText), handled, err
+	if _, _, ok := extractChannelSummaryArgs(text); ok {
+		respText, handled, err := f.handleChannelSummary(ctx, merchantID, text, summaryOptionsFor(group.Settings))
+		return wrapResponse(f.withQueryTimeFooter(group.Settings, respText)), handled, err
 	}
 
 	if _, ok := extractDateSuffix(text, "提款明细"); ok {
 		respText, handled, err := f.handleWithdrawList(ctx, merchantID, text)
-		return wrapResponse(respText), handled, err
+		return wrapResponse(f.withQueryTimeFooter(group.Settings, respText)), handled, err
+	}
+
+	if suffix, ok := withdrawExportDateSuffix(text); ok {
+		return f.handleWithdrawExport(ctx, merchantID, suffix)
 	}
 
 	if isSendMoneyCommand(text) {
-		return f.handleSendMoney(ctx, msg, merchantID, group.Settings.CryptoFloatRate, text)
+		return f.handleSendMoney(ctx, msg, group, text)
+	}
+
+	if f.isAwaitingGoogleCode(msg, text) {
+		return f.handleSendMoneyGoogleCode(ctx, msg, text)
 	}
 
 	if isCreateOrderCommand(text) {
@@ -213,6 +299,34 @@ func (f *Feature) Process(ctx context.Context, msg *botModels.Message, group *mo
 		return wrapResponse(respText), handled, err
 	}
 
+	if isNotifyCheckCommand(text) {
+		respText, handled, err := f.handleNotifyCheck(ctx, merchantID, text)
+		return wrapResponse(respText), handled, err
+	}
+
+	if isOrderRawCommand(text) {
+		return f.handleOrderRaw(ctx, msg, merchantID, text)
+	}
+
+	if isRawResponseCommand(text) {
+		return f.handleRawResponse(ctx, msg, merchantID, text)
+	}
+
+	if isOrderQueryCommand(text) {
+		respText, handled, err := f.handleOrderQuery(ctx, merchantID, text)
+		return wrapResponse(respText), handled, err
+	}
+
+	if text == snapshotCommand {
+		respText, handled, err := f.handleSnapshot(ctx, merchantID)
+		return wrapResponse(respText), handled, err
+	}
+
+	if _, _, ok := extractSummaryCompareArgs(text); ok {
+		respText, handled, err := f.handleSummaryCompare(ctx, merchantID, text)
+		return wrapResponse(respText), handled, err
+	}
+
 	return nil, false, nil
 }
 
@@ -222,21 +336,19 @@ func (f *Feature) Priority() int {
 }
 
 func (f *Feature) handleBalance(ctx context.Context, merchantID int64, rawSuffix string) (string, bool, error) {
-	now := time.Now().In(chinaLocation)
+	now := f.currentTime()
 	targetDate, err := parseBalanceDate(rawSuffix, now)
 	if err != nil {
 		return fmt.Sprintf("❌ %v", err), true, nil
 	}
 
 	historyDays := calculateHistoryDays(targetDate, now)
-	nowMidnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	if historyDays > 365 {
-		historyDays = 365
-		targetDate = nowMidnight.AddDate(0, 0, -historyDays)
-	}
-
 	balance, err := f.paymentService.GetBalance(ctx, merchantID, historyDays)
 	if err != nil {
+		var windowErr *paymentservice.HistoryWindowError
+		if errors.As(err, &windowErr) {
+			return fmt.Sprintf("❌ %s", windowErr.Error()), true, nil
+		}
 		logger.L().Errorf("Sifang balance query failed: merchant_id=%d, history_days=%d, err=%v", merchantID, historyDays, err)
 		return fmt.Sprintf("❌ 查询余额失败：%v", err), true, nil
 	}
@@ -260,15 +372,56 @@ func (f *Feature) handleBalance(ctx context.Context, merchantID int64, rawSuffix
 	return amount, true, nil
 }
 
-func (f *Feature) handleSummary(ctx context.Context, merchantID int64, text string) (string, bool, error) {
+// handleMultiMerchantBalance 逐个查询群内商户余额并按商户分段展示，全部可解析时附加合计
+func (f *Feature) handleMultiMerchantBalance(ctx context.Context, merchants []int64, rawSuffix string) string {
+	sections := make([]string, 0, len(merchants)+1)
+	total := 0.0
+	summable := true
+	for _, merchantID := range merchants {
+		amount, _, _ := f.handleBalance(ctx, merchantID, rawSuffix)
+		if value, ok := parseAmountToFloat(strings.TrimSpace(amount)); ok {
+			total += value
+		} else {
+			summable = false
+		}
+		sections = append(sections, fmt.Sprintf("商户 <code>%d</code>：%s", merchantID, amount))
+	}
+	if summable {
+		sections = append(sections, fmt.Sprintf("合计：%s", formatFloat(total)))
+	}
+	return strings.Join(sections, "\n")
+}
+
+// summaryOptions 控制账单是否附带提款明细与余额（关闭时跳过对应上游查询）
+type summaryOptions struct {
+	showWithdraws bool
+	showBalance   bool
+	splitIncome   bool // 分开展示商户收入与代理收入，默认合并为"成交"
+}
+
+var defaultSummaryOptions = summaryOptions{showWithdraws: true, showBalance: true}
+
+func summaryOptionsFor(settings models.GroupSettings) summaryOptions {
+	return summaryOptions{
+		showWithdraws: models.IsSummaryShowWithdrawsEnabled(settings),
+		showBalance:   models.IsSummaryShowBalanceEnabled(settings),
+		splitIncome:   models.IsSummaryIncomeSplit(settings),
+	}
+}
+
+func (f *Feature) handleSummary(ctx context.Context, merchants []int64, text string, opts summaryOptions) (string, bool, error) {
 	dateText := strings.TrimSpace(strings.TrimPrefix(text, "账单"))
-	now := time.Now().In(chinaLocation)
+	now := f.currentTime()
 	targetDate, err := parseSummaryDate(dateText, now, "账单")
 	if err != nil {
 		return fmt.Sprintf("❌ %v", err), true, nil
 	}
 
-	message, err := f.buildSummaryMessage(ctx, merchantID, targetDate, now)
+	if len(merchants) > 1 {
+		return f.buildMultiMerchantSummaryMessage(ctx, merchants, targetDate, now, opts), true, nil
+	}
+
+	message, err := f.buildSummaryMessage(ctx, merchants[0], targetDate, now, opts)
 	if err != nil {
 		return fmt.Sprintf("❌ %v", err), true, nil
 	}
@@ -276,13 +429,30 @@ func (f *Feature) handleSummary(ctx context.Context, merchantID int64, text stri
 	return message, true, nil
 }
 
-// BuildSummaryMessage 构建指定日期的账单消息
-func (f *Feature) BuildSummaryMessage(ctx context.Context, merchantID int64, targetDate time.Time) (string, error) {
-	now := time.Now().In(chinaLocation)
-	return f.buildSummaryMessage(ctx, merchantID, targetDate.In(chinaLocation), now)
+// buildMultiMerchantSummaryMessage 多商户群按商户分段展示账单，单个商户查询失败不影响其他商户
+func (f *Feature) buildMultiMerchantSummaryMessage(ctx context.Context, merchants []int64, targetDate, now time.Time, opts summaryOptions) string {
+	sections := make([]string, 0, len(merchants))
+	for _, merchantID := range merchants {
+		message, err := f.buildSummaryMessage(ctx, merchantID, targetDate, now, opts)
+		if err != nil {
+			message = fmt.Sprintf("❌ %v", err)
+		}
+		sections = append(sections, fmt.Sprintf("🏪 商户 <code>%d</code>\n%s", merchantID, message))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// BuildSummaryMessage 构建指定日期的账单消息，按群组配置决定是否附带提款明细与余额
+func (f *Feature) BuildSummaryMessage(ctx context.Context, merchantID int64, targetDate time.Time, settings models.GroupSettings) (string, error) {
+	now := f.currentTime()
+	return f.buildSummaryMessage(ctx, merchantID, targetDate.In(chinaLocation), now, summaryOptionsFor(settings))
 }
 
-func (f *Feature) buildSummaryMessage(ctx context.Context, merchantID int64, targetDate, now time.Time) (string, error) {
+func (f *Feature) buildSummaryMessage(ctx context.Context, merchantID int64, targetDate, now time.Time, opts summaryOptions) (string, error) {
+	if !paymentservice.Available(f.paymentService) {
+		return "", paymentservice.ErrNotConfigured
+	}
+
 	targetDate = time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
 
 	summary, err := f.paymentService.GetSummaryByDay(ctx, merchantID, targetDate)
@@ -299,26 +469,37 @@ func (f *Feature) buildSummaryMessage(ctx context.Context, merchantID int64, tar
 		summary.Date = targetDate.Format("2006-01-02")
 	}
 
-	historyDays := calculateHistoryDays(targetDate, now)
-	balanceAmount, balanceErr := f.queryBalanceAmount(ctx, merchantID, historyDays)
-	withdrawMessage, withdrawErr := f.queryWithdrawMessage(ctx, merchantID, targetDate)
-
 	logger.L().Infof("Sifang summary queried: merchant_id=%d, date=%s", merchantID, summary.Date)
-	message := formatSummaryMessage(summary)
+	message := formatSummaryMessage(summary, opts.splitIncome)
+
+	return f.appendSummaryExtras(ctx, message, merchantID, targetDate, now, opts, "summary"), nil
+}
 
-	if withdrawErr != nil {
-		logger.L().Errorf("Sifang withdraw list in summary failed: merchant_id=%d, date=%s, err=%v", merchantID, targetDate.Format("2006-01-02"), withdrawErr)
-	} else if withdrawMessage != "" {
-		message = fmt.Sprintf("%s\n\n%s", message, withdrawMessage)
+// appendSummaryExtras 按开关追加提款明细与余额，查询失败时仅记录日志
+func (f *Feature) appendSummaryExtras(ctx context.Context, message string, merchantID int64, targetDate, now time.Time, opts summaryOptions, scene string) string {
+	if opts.showWithdraws {
+		withdrawMessage, err := f.queryWithdrawMessage(ctx, merchantID, targetDate)
+		if err != nil {
+			logger.L().Errorf("Sifang withdraw list in %s failed: merchant_id=%d, date=%s, err=%v", scene, merchantID, targetDate.Format("2006-01-02"), err)
+		} else if withdrawMessage != "" {
+			message = fmt.Sprintf("%s\n\n%s", message, withdrawMessage)
+		}
 	}
 
-	if balanceErr != nil {
-		logger.L().Errorf("Sifang balance in summary failed: merchant_id=%d, history_days=%d, err=%v", merchantID, historyDays, balanceErr)
-	} else if balanceAmount != "" {
-		message = fmt.Sprintf("%s\n\n余额：%s", message, balanceAmount)
+	if opts.showBalance {
+		historyDays := calculateHistoryDays(targetDate, now)
+		balanceAmount, err := f.queryBalanceAmount(ctx, merchantID, historyDays)
+		var windowErr *paymentservice.HistoryWindowError
+		if errors.As(err, &windowErr) {
+			message = fmt.Sprintf("%s\n\n余额：%s", message, windowErr.Error())
+		} else if err != nil {
+			logger.L().Errorf("Sifang balance in %s failed: merchant_id=%d, history_days=%d, err=%v", scene, merchantID, historyDays, err)
+		} else if balanceAmount != "" {
+			message = fmt.Sprintf("%s\n\n余额：%s", message, balanceAmount)
+		}
 	}
 
-	return message, nil
+	return message
 }
 
 func (f *Feature) queryBalanceAmount(ctx context.Context, merchantID int64, historyDays int) (string, error) {
@@ -347,7 +528,36 @@ func (f *Feature) queryWithdrawMessage(ctx context.Context, merchantID int64, ta
 
 	filtered := filterSuccessfulWithdrawList(list)
 	quoteLookup := f.loadWithdrawQuoteLookup(ctx, merchantID, start, start.Add(24*time.Hour))
-	return formatWithdrawListMessageWithQuotes(targetDate.Format("2006-01-02"), filtered, quoteLookup), nil
+	all := f.sumAllWithdrawsIfPaginated(ctx, merchantID, start, end, list)
+	return formatWithdrawListPage(targetDate.Format("2006-01-02"), filtered, quoteLookup, all), nil
+}
+
+// sumAllWithdrawsIfPaginated 首页之外还有记录时自动翻页汇总全部成功提款；无需翻页或汇总失败时返回 nil（按当前页计算总计）
+func (f *Feature) sumAllWithdrawsIfPaginated(ctx context.Context, merchantID int64, start, end time.Time, firstPage *paymentservice.WithdrawList) *withdrawTotals {
+	if firstPage == nil {
+		return nil
+	}
+	hasMore := firstPage.TotalPages > 1 || firstPage.Total > len(firstPage.Items)
+	if !hasMore {
+		return nil
+	}
+
+	totals := &withdrawTotals{}
+	err := paymentservice.EachWithdraw(ctx, f.paymentService, merchantID, start, end, func(item *paymentservice.Withdraw) error {
+		if !isSuccessfulWithdraw(item) {
+			return nil
+		}
+		if amount, ok := parseAmountToFloat(item.Amount); ok {
+			totals.amount += amount
+		}
+		totals.count++
+		return nil
+	})
+	if err != nil {
+		logger.L().Errorf("Sifang withdraw total across pages failed: merchant_id=%d, err=%v", merchantID, err)
+		return nil
+	}
+	return totals
 }
 
 func filterSuccessfulWithdrawList(list *paymentservice.WithdrawList) *paymentservice.WithdrawList {
@@ -392,7 +602,7 @@ func parseSummaryDate(raw string, now time.Time, usage string) (time.Time, error
 		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
 	}
 
-	normalized := strings.ToLower(raw)
+	normalized := strings.ToLower(models.NormalizeFullWidth(raw))
 	normalized = strings.ReplaceAll(normalized, "日", "")
 	normalized = strings.ReplaceAll(normalized, "号", "")
 	normalized = strings.ReplaceAll(normalized, "年", "-")
@@ -461,22 +671,20 @@ func parseBalanceDate(raw string, now time.Time) (time.Time, error) {
 	return parseSummaryDate(raw, now, "余额")
 }
 
+// calculateHistoryDays 计算 target 所在自然日距 now 所在自然日的天数（未来日期返回 0）
+// 按日历日期相减而非时长除以 24 小时，避免夏令时切换当天只有 23 小时导致少算一天
 func calculateHistoryDays(target, now time.Time) int {
-	targetMidnight := time.Date(target.Year(), target.Month(), target.Day(), 0, 0, 0, 0, target.Location())
-	nowMidnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	targetDay := time.Date(target.Year(), target.Month(), target.Day(), 0, 0, 0, 0, time.UTC)
+	nowDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
-	if targetMidnight.After(nowMidnight) {
-		return 0
-	}
-
-	days := int(nowMidnight.Sub(targetMidnight).Hours() / 24)
+	days := int(nowDay.Sub(targetDay) / (24 * time.Hour))
 	if days < 0 {
 		days = 0
 	}
 	return days
 }
 
-func formatSummaryMessage(summary *paymentservice.SummaryByDay) string {
+func formatSummaryMessage(summary *paymentservice.SummaryByDay, splitIncome bool) string {
 	var sb strings.Builder
 
 	date := strings.TrimSpace(summary.Date)
@@ -488,7 +696,14 @@ func formatSummaryMessage(summary *paymentservice.SummaryByDay) string {
 	if value := strings.TrimSpace(summary.TotalAmount); value != "" {
 		sb.WriteString(fmt.Sprintf("跑量：%s\n", html.EscapeString(value)))
 	}
-	if combinedIncome := combineAmounts(summary.MerchantIncome, summary.AgentIncome); combinedIncome != "" {
+	if splitIncome {
+		if value := strings.TrimSpace(summary.MerchantIncome); value != "" {
+			sb.WriteString(fmt.Sprintf("商户收入：%s\n", html.EscapeString(value)))
+		}
+		if value := strings.TrimSpace(summary.AgentIncome); value != "" {
+			sb.WriteString(fmt.Sprintf("代理收入：%s\n", html.EscapeString(value)))
+		}
+	} else if combinedIncome := combineAmounts(summary.MerchantIncome, summary.AgentIncome); combinedIncome != "" {
 		sb.WriteString(fmt.Sprintf("成交：%s\n", html.EscapeString(combinedIncome)))
 	}
 	if value := strings.TrimSpace(summary.OrderCount); value != "" {
@@ -498,48 +713,106 @@ func formatSummaryMessage(summary *paymentservice.SummaryByDay) string {
 	return strings.TrimRight(sb.String(), "\n")
 }
 
-func (f *Feature) handleChannelSummary(ctx context.Context, merchantID int64, text string) (string, bool, error) {
-	dateText := strings.TrimSpace(strings.TrimPrefix(text, "通道账单"))
-	now := time.Now().In(chinaLocation)
+func (f *Feature) handleChannelSummary(ctx context.Context, merchantID int64, text string, opts summaryOptions) (string, bool, error) {
+	channelCode, dateText, _ := extractChannelSummaryArgs(text)
+	now := f.currentTime()
 	targetDate, err := parseSummaryDate(dateText, now, "通道账单")
 	if err != nil {
 		return fmt.Sprintf("❌ %v", err), true, nil
 	}
 
-	items, err := f.paymentService.GetSummaryByDayByChannel(ctx, merchantID, targetDate)
+	if channelCode != "" {
+		resolved, errMsg := f.resolveChannelCode(ctx, merchantID, channelCode)
+		if errMsg != "" {
+			return errMsg, true, nil
+		}
+		channelCode = resolved
+	}
+
+	items, err := f.paymentService.GetSummaryByDayByChannel(ctx, merchantID, targetDate, channelCode)
 	if err != nil {
-		logger.L().Errorf("Sifang channel summary query failed: merchant_id=%d, date=%s, err=%v", merchantID, targetDate.Format("2006-01-02"), err)
+		logger.L().Errorf("Sifang channel summary query failed: merchant_id=%d, date=%s, channel=%s, err=%v", merchantID, targetDate.Format("2006-01-02"), channelCode, err)
 		return fmt.Sprintf("❌ 查询通道账单失败：%v", err), true, nil
 	}
 
+	if channelCode != "" {
+		// 上游未按 channel_codes 过滤时在本地过滤，只展示指定通道
+		items = filterChannelSummaries(items, channelCode)
+		if len(items) == 0 {
+			return fmt.Sprintf("ℹ️ %s 通道 %s 暂无账单数据", targetDate.Format("2006-01-02"), html.EscapeString(channelCode)), true, nil
+		}
+	}
+
 	if len(items) == 0 {
 		return fmt.Sprintf("ℹ️ %s 暂无通道账单数据", targetDate.Format("2006-01-02")), true, nil
 	}
 
 	logger.L().Infof("Sifang channel summary queried: merchant_id=%d, date=%s, channels=%d", merchantID, targetDate.Format("2006-01-02"), len(items))
 
-	message := formatChannelSummaryMessage(targetDate.Format("2006-01-02"), items)
+	message := formatChannelSummaryMessage(targetDate.Format("2006-01-02"), items, opts.splitIncome)
+	message = f.appendSummaryExtras(ctx, message, merchantID, targetDate, now, opts, "channel summary")
 
-	historyDays := calculateHistoryDays(targetDate, now)
-	balanceAmount, balanceErr := f.queryBalanceAmount(ctx, merchantID, historyDays)
-	withdrawMessage, withdrawErr := f.queryWithdrawMessage(ctx, merchantID, targetDate)
+	return message, true, nil
+}
 
-	if withdrawErr != nil {
-		logger.L().Errorf("Sifang withdraw list in channel summary failed: merchant_id=%d, date=%s, err=%v", merchantID, targetDate.Format("2006-01-02"), withdrawErr)
-	} else if withdrawMessage != "" {
-		message = fmt.Sprintf("%s\n\n%s", message, withdrawMessage)
+// extractChannelSummaryArgs 解析「通道账单 [通道代码] [日期]」，通道代码须以字母开头以便与日期区分
+func extractChannelSummaryArgs(text string) (channelCode, dateSuffix string, ok bool) {
+	if suffix, ok := extractDateSuffix(text, "通道账单"); ok {
+		return "", suffix, true
+	}
+	if !strings.HasPrefix(text, "通道账单") {
+		return "", "", false
 	}
 
-	if balanceErr != nil {
-		logger.L().Errorf("Sifang balance in channel summary failed: merchant_id=%d, history_days=%d, err=%v", merchantID, historyDays, balanceErr)
-	} else if balanceAmount != "" {
-		message = fmt.Sprintf("%s\n\n余额：%s", message, balanceAmount)
+	fields := strings.Fields(models.NormalizeFullWidth(strings.TrimPrefix(text, "通道账单")))
+	if len(fields) == 0 || !channelCodeRegexp.MatchString(fields[0]) {
+		return "", "", false
 	}
+	dateSuffix = strings.Join(fields[1:], " ")
+	if !isValidDateSuffix(dateSuffix) {
+		return "", "", false
+	}
+	return fields[0], dateSuffix, true
+}
 
-	return message, true, nil
+// resolveChannelCode 按通道状态校验通道代码并返回上游使用的大小写；通道状态查询失败时不校验
+func (f *Feature) resolveChannelCode(ctx context.Context, merchantID int64, code string) (string, string) {
+	statuses, err := f.paymentService.GetChannelStatus(ctx, merchantID)
+	if err != nil {
+		logger.L().Warnf("Sifang channel summary skip channel validation: merchant_id=%d, err=%v", merchantID, err)
+		return code, ""
+	}
+	if len(statuses) == 0 {
+		return code, ""
+	}
+
+	known := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		if status == nil || strings.TrimSpace(status.ChannelCode) == "" {
+			continue
+		}
+		channel := strings.TrimSpace(status.ChannelCode)
+		if strings.EqualFold(channel, code) {
+			return channel, ""
+		}
+		known = append(known, channel)
+	}
+
+	return "", fmt.Sprintf("❌ 未找到通道 %s\n可用通道：%s", html.EscapeString(code), html.EscapeString(strings.Join(known, "、")))
 }
 
-func formatChannelSummaryMessage(date string, items []*paymentservice.SummaryByDayChannel) string {
+// filterChannelSummaries 只保留指定通道的账单
+func filterChannelSummaries(items []*paymentservice.SummaryByDayChannel, code string) []*paymentservice.SummaryByDayChannel {
+	filtered := make([]*paymentservice.SummaryByDayChannel, 0, 1)
+	for _, item := range items {
+		if item != nil && strings.EqualFold(strings.TrimSpace(item.ChannelCode), code) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func formatChannelSummaryMessage(date string, items []*paymentservice.SummaryByDayChannel, splitIncome bool) string {
 	if len(items) == 0 {
 		return fmt.Sprintf("ℹ️ %s 暂无通道账单数据", html.EscapeString(date))
 	}
@@ -569,11 +842,16 @@ func formatChannelSummaryMessage(date string, items []*paymentservice.SummaryByD
 		}
 		sb.WriteString(fmt.Sprintf("跑量：%s\n", html.EscapeString(volume)))
 
-		combined := combineAmounts(item.MerchantIncome, item.AgentIncome)
-		if combined == "" {
-			combined = "0"
+		if splitIncome {
+			sb.WriteString(fmt.Sprintf("商户收入：%s\n", html.EscapeString(emptyFallback(strings.TrimSpace(item.MerchantIncome), "0"))))
+			sb.WriteString(fmt.Sprintf("代理收入：%s\n", html.EscapeString(emptyFallback(strings.TrimSpace(item.AgentIncome), "0"))))
+		} else {
+			combined := combineAmounts(item.MerchantIncome, item.AgentIncome)
+			if combined == "" {
+				combined = "0"
+			}
+			sb.WriteString(fmt.Sprintf("成交：%s\n", html.EscapeString(combined)))
 		}
-		sb.WriteString(fmt.Sprintf("成交：%s\n", html.EscapeString(combined)))
 
 		count := strings.TrimSpace(item.OrderCount)
 		if count == "" {
@@ -594,7 +872,7 @@ func emptyFallback(value, fallback string) string {
 
 func (f *Feature) handleWithdrawList(ctx context.Context, merchantID int64, text string) (string, bool, error) {
 	dateText := strings.TrimSpace(strings.TrimPrefix(text, "提款明细"))
-	now := time.Now().In(chinaLocation)
+	now := f.currentTime()
 	targetDate, err := parseSummaryDate(dateText, now, "提款明细")
 	if err != nil {
 		return fmt.Sprintf("❌ %v", err), true, nil
@@ -611,7 +889,8 @@ func (f *Feature) handleWithdrawList(ctx context.Context, merchantID int64, text
 
 	filtered := filterSuccessfulWithdrawList(list)
 	quoteLookup := f.loadWithdrawQuoteLookup(ctx, merchantID, start, start.Add(24*time.Hour))
-	message := formatWithdrawListMessageWithQuotes(targetDate.Format("2006-01-02"), filtered, quoteLookup)
+	all := f.sumAllWithdrawsIfPaginated(ctx, merchantID, start, end, list)
+	message := formatWithdrawListPage(targetDate.Format("2006-01-02"), filtered, quoteLookup, all)
 	itemCount := 0
 	if filtered != nil {
 		itemCount = len(filtered.Items)
@@ -639,6 +918,17 @@ func formatWithdrawListMessage(date string, list *paymentservice.WithdrawList) s
 }
 
 func formatWithdrawListMessageWithQuotes(date string, list *paymentservice.WithdrawList, quoteLookup map[string]*models.WithdrawQuoteRecord) string {
+	return formatWithdrawListPage(date, list, quoteLookup, nil)
+}
+
+// withdrawTotals 全部分页的成功提款合计
+type withdrawTotals struct {
+	amount float64
+	count  int
+}
+
+// formatWithdrawListPage 展示当前页提款明细；all 非空时总计取自全部分页并标注"总计（全部）"，为空时按当前页计算
+func formatWithdrawListPage(date string, list *paymentservice.WithdrawList, quoteLookup map[string]*models.WithdrawQuoteRecord, all *withdrawTotals) string {
 	var sb strings.Builder
 
 	totalAmount := 0.0
@@ -656,11 +946,18 @@ func formatWithdrawListMessageWithQuotes(date string, list *paymentservice.Withd
 
 	title := "💸 提款明细"
 
-	if itemCount == 0 {
+	if itemCount == 0 && (all == nil || all.count == 0) {
 		return fmt.Sprintf("%s\n暂无提款记录", title)
 	}
 
-	sb.WriteString(fmt.Sprintf("%s（总计 %s｜%d 笔）\n", title, html.EscapeString(formatFloat(totalAmount)), itemCount))
+	if all != nil {
+		sb.WriteString(fmt.Sprintf("%s（总计（全部） %s｜%d 笔，本页 %d 笔）\n", title, html.EscapeString(formatFloat(all.amount)), all.count, itemCount))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s（总计 %s｜%d 笔）\n", title, html.EscapeString(formatFloat(totalAmount)), itemCount))
+	}
+	if itemCount == 0 {
+		return strings.TrimRight(sb.String(), "\n")
+	}
 	sb.WriteString("<blockquote>")
 
 	for _, item := range items {
@@ -722,7 +1019,7 @@ func buildWithdrawQuoteText(item *paymentservice.Withdraw, lookup map[string]*mo
 	if record == nil || record.Rate <= 0 || record.USDTAmount <= 0 {
 		return ""
 	}
-	return fmt.Sprintf("%s ✖️ %s U", formatFloat(record.Rate), formatFloat(record.USDTAmount))
+	return fmt.Sprintf("%s ✖️ %s U", formatFloat(record.Rate), formatUSDT(record.USDTAmount))
 }
 
 func findWithdrawQuoteRecord(item *paymentservice.Withdraw, lookup map[string]*models.WithdrawQuoteRecord) *models.WithdrawQuoteRecord {
@@ -875,21 +1172,13 @@ func formatCreateOrderMessage(merchantID int64, requestAmount float64, result *p
 	return sb.String()
 }
 
-func (f *Feature) handleSendMoney(ctx context.Context, msg *botModels.Message, merchantID int64, floatRate float64, text string) (*types.Response, bool, error) {
-	if f.userService == nil {
-		logger.L().Error("Sifang send money: user service is nil")
-		return wrapResponse("❌ 未配置管理员校验服务，请联系管理员"), true, nil
-	}
-
-	isAdmin, err := f.userService.CheckAdminPermission(ctx, msg.From.ID)
-	if err != nil {
-		logger.L().Errorf("Sifang send money admin check failed: user_id=%d, err=%v", msg.From.ID, err)
-		return wrapResponse("❌ 权限检查失败，请稍后重试"), true, nil
-	}
-	if !isAdmin {
-		logger.L().Warnf("Sifang send money unauthorized: user_id=%d, chat_id=%d", msg.From.ID, msg.Chat.ID)
-		return wrapResponse("❌ 仅管理员可以下发"), true, nil
+func (f *Feature) handleSendMoney(ctx context.Context, msg *botModels.Message, group *models.Group, text string) (*types.Response, bool, error) {
+	if err := service.CheckFundOperation(ctx, f.userService, msg.From.ID, group); err != nil {
+		logger.L().Warnf("Sifang send money rejected: user_id=%d, chat_id=%d, reason=%v", msg.From.ID, msg.Chat.ID, err)
+		return wrapResponse(fmt.Sprintf("❌ %v", err)), true, nil
 	}
+	merchantID := int64(group.Settings.MerchantID)
+	floatRate := group.Settings.CryptoFloatRate
 
 	payload := strings.TrimSpace(strings.TrimPrefix(text, "下发"))
 	amount, googleCode, quote, parseErr := f.resolveSendMoneyPayload(ctx, payload, floatRate)
@@ -904,6 +1193,10 @@ func (f *Feature) handleSendMoney(ctx context.Context, msg *botModels.Message, m
 	}
 	pending.quote = snapshotSendMoneyQuote(quote)
 
+	if group.Settings.SendMoneyRequireGoogleCode && googleCode == "" {
+		return f.promptSendMoneyGoogleCode(pending, quote), true, nil
+	}
+
 	message := buildSendMoneyConfirmationMessage(merchantID, amount, quote)
 	if googleCode != "" {
 		message += "\n🔐 将附带当前谷歌验证码"
@@ -1037,8 +1330,8 @@ func buildSendMoneyConfirmationMessage(merchantID int64, amount float64, quote *
 		}
 	}
 
-	response.WriteString(fmt.Sprintf("\n<code>%.2f</code> ✖️ <code>%s</code> <b>U</b> 🟰 <code>%.2f</code> <b>¥</b>\n",
-		quote.unitPrice, html.EscapeString(formatFloat(quote.usdtAmount)), amount))
+	response.WriteString(fmt.Sprintf("\n<code>%.2f</code> ✖️ <code>%s</code> <b>U</b> 🟰 <code>%s</code> <b>¥</b>\n",
+		quote.unitPrice, html.EscapeString(formatUSDT(quote.usdtAmount)), models.FormatMoney(amount, models.CurrencyCNY)))
 	response.WriteString(fmt.Sprintf("是否确认下发 %s 元 | %s",
 		html.EscapeString(formatFloat(amount)), html.EscapeString(merchantText)))
 
@@ -1130,10 +1423,16 @@ func formatSendMoneyMessage(merchantID int64, requestAmount float64, result *pay
 		}
 	}
 
-	return fmt.Sprintf("已成功下发 <code>%s</code> 元给商户 <code>%s</code>",
+	message := fmt.Sprintf("已成功下发 <code>%s</code> 元给商户 <code>%s</code>",
 		html.EscapeString(amountText),
 		html.EscapeString(merchantText),
 	)
+	if result != nil {
+		if remark := strings.TrimSpace(result.Remark); remark != "" {
+			message += fmt.Sprintf("\n备注：%s", html.EscapeString(remark))
+		}
+	}
+	return message
 }
 
 func combineAmounts(merchant, agent string) string {
@@ -1179,11 +1478,21 @@ func parseAmountToFloat(input string) (float64, bool) {
 	return value, true
 }
 
+// formatFloat 格式化人民币金额，整数不带小数，其余按配置精度
 func formatFloat(value float64) string {
+	return formatCurrencyFloat(value, models.CurrencyCNY)
+}
+
+// formatUSDT 格式化 USDT 金额，整数不带小数，其余按配置精度
+func formatUSDT(value float64) string {
+	return formatCurrencyFloat(value, models.CurrencyUSD)
+}
+
+func formatCurrencyFloat(value float64, currency string) string {
 	if value == float64(int64(value)) {
 		return fmt.Sprintf("%.0f", value)
 	}
-	return fmt.Sprintf("%.2f", value)
+	return models.FormatMoney(value, currency)
 }
 
 func extractTime(datetime string) string {
@@ -1225,7 +1534,7 @@ func extractDateSuffix(text, prefix string) (string, bool) {
 }
 
 func isValidDateSuffix(raw string) bool {
-	trimmed := strings.TrimSpace(raw)
+	trimmed := strings.TrimSpace(models.NormalizeFullWidth(raw))
 	if trimmed == "" {
 		return true
 	}
@@ -1277,7 +1586,7 @@ func (f *Feature) createPendingSend(chatID, userID, merchantID int64, amount flo
 	}
 
 	f.mu.Lock()
-	f.cleanupExpiredLocked()
+	f.cleanupExpiredLocked(time.Now())
 	for {
 		if _, exists := f.pending[pending.token]; !exists {
 			f.pending[pending.token] = pending
@@ -1298,7 +1607,7 @@ func (f *Feature) createPendingSend(chatID, userID, merchantID int64, amount flo
 func (f *Feature) getPendingByToken(token string) (*pendingSendMoney, bool) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.cleanupExpiredLocked()
+	f.cleanupExpiredLocked(time.Now())
 	pending, ok := f.pending[token]
 	return pending, ok
 }
@@ -1309,16 +1618,22 @@ func (f *Feature) deletePending(token string) {
 	f.mu.Unlock()
 }
 
-func (f *Feature) cleanupExpiredLocked() {
-	if len(f.pending) == 0 {
-		return
-	}
-	now := time.Now()
+func (f *Feature) cleanupExpiredLocked(now time.Time) int {
+	purged := 0
 	for token, pending := range f.pending {
 		if now.Sub(pending.createdAt) > SendMoneyConfirmTTL {
 			delete(f.pending, token)
+			purged++
 		}
 	}
+	return purged
+}
+
+// PurgeExpiredPending 立即清理已超时的下发确认请求，返回清理数量
+func (f *Feature) PurgeExpiredPending(now time.Time) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cleanupExpiredLocked(now)
 }
 
 // ExpirePending 在确认超时后删除待处理请求
@@ -1413,9 +1728,23 @@ func (f *Feature) HandleSendMoneyCallback(ctx context.Context, query *botModels.
 		result.Answer = "已取消"
 		return result, nil
 	case sendMoneyActionConfirm:
+		if pending.awaitingCode {
+			result.ShouldEdit = false
+			result.Answer = "请先回复谷歌验证码"
+			result.ShowAlert = true
+			return result, nil
+		}
 		f.deletePending(token)
+		if !paymentservice.Available(f.paymentService) {
+			result.ShouldEdit = true
+			result.Text = types.PaymentNotConfiguredText
+			result.Answer = "支付服务未配置"
+			return result, nil
+		}
 		opts := paymentservice.SendMoneyOptions{GoogleCode: pending.googleCode}
+		sendRecord := f.recordSendMoneyAttempt(ctx, pending)
 		sendResult, err := f.paymentService.SendMoney(ctx, pending.merchantID, pending.amount, opts)
+		f.recordSendMoneyResult(ctx, sendRecord, sendResult, err)
 		if err != nil {
 			logger.L().Errorf("Sifang send money (callback) failed: merchant_id=%d, user_id=%d, amount=%.2f, err=%v", pending.merchantID, pending.userID, pending.amount, err)
 			var apiErr *sifang.APIError
@@ -1440,12 +1769,16 @@ func (f *Feature) HandleSendMoneyCallback(ctx context.Context, query *botModels.
 				strings.TrimSpace(sendResult.Withdraw.Status),
 			)
 		}
-		logger.L().Infof("Sifang send money success: merchant_id=%d, user_id=%d, amount=%.2f", pending.merchantID, pending.userID, pending.amount)
+		remark := ""
+		if sendResult != nil {
+			remark = sendResult.Remark
+		}
+		logger.L().Infof("Sifang send money success: merchant_id=%d, user_id=%d, amount=%.2f, remark=%q", pending.merchantID, pending.userID, pending.amount, remark)
 
 		result.ShouldEdit = true
 		result.Text = message
 		result.Answer = "下发成功"
-		summaryMessage, _, summaryErr := f.handleSummary(ctx, pending.merchantID, "账单")
+		summaryMessage, _, summaryErr := f.handleSummary(ctx, []int64{pending.merchantID}, "账单", defaultSummaryOptions)
 		if summaryErr != nil {
 			logger.L().Errorf("Sifang auto summary after send money failed: merchant_id=%d, err=%v", pending.merchantID, summaryErr)
 		} else if strings.TrimSpace(summaryMessage) != "" {
@@ -1460,6 +1793,58 @@ func (f *Feature) HandleSendMoneyCallback(ctx context.Context, query *botModels.
 	}
 }
 
+// recordSendMoneyAttempt 在调用上游前写入下发记录，避免超时但实际成功的下发无据可查
+func (f *Feature) recordSendMoneyAttempt(ctx context.Context, pending *pendingSendMoney) *models.SendMoneyRecord {
+	if f.sendMoneyRepo == nil || pending == nil {
+		return nil
+	}
+
+	record := &models.SendMoneyRecord{
+		MerchantID: pending.merchantID,
+		ChatID:     pending.chatID,
+		UserID:     pending.userID,
+		Amount:     pending.amount,
+		Status:     models.SendMoneyStatusPending,
+	}
+	if err := f.sendMoneyRepo.Create(ctx, record); err != nil {
+		logger.L().Errorf("Sifang record send money attempt failed: merchant_id=%d, amount=%.2f, err=%v", pending.merchantID, pending.amount, err)
+		return nil
+	}
+	return record
+}
+
+// recordSendMoneyResult 更新下发结果；仅上游明确拒绝时记为失败，其余错误保持待确认
+func (f *Feature) recordSendMoneyResult(ctx context.Context, record *models.SendMoneyRecord, sendResult *paymentservice.SendMoneyResult, sendErr error) {
+	if f.sendMoneyRepo == nil || record == nil {
+		return
+	}
+
+	var apiErr *sifang.APIError
+	switch {
+	case sendErr == nil:
+		record.Status = models.SendMoneyStatusSuccess
+		if sendResult != nil && sendResult.Withdraw != nil {
+			record.WithdrawNo = strings.TrimSpace(sendResult.Withdraw.WithdrawNo)
+			record.OrderNo = strings.TrimSpace(sendResult.Withdraw.OrderNo)
+			if amount, ok := parseAmountToFloat(strings.TrimSpace(sendResult.Withdraw.Amount)); ok && amount > 0 {
+				record.Amount = amount
+			}
+		}
+	case errors.As(sendErr, &apiErr):
+		record.Status = models.SendMoneyStatusFailed
+		record.Error = sendErr.Error()
+	default:
+		record.Error = sendErr.Error()
+	}
+
+	// 下发超时时原 ctx 可能已取消，结果仍需落库
+	updateCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendMoneyRecordTimeout)
+	defer cancel()
+	if err := f.sendMoneyRepo.UpdateResult(updateCtx, record); err != nil {
+		logger.L().Errorf("Sifang update send money record failed: merchant_id=%d, status=%s, err=%v", record.MerchantID, record.Status, err)
+	}
+}
+
 func (f *Feature) persistSendMoneyQuote(ctx context.Context, pending *pendingSendMoney, sendResult *paymentservice.SendMoneyResult) {
 	if f.withdrawQuoteRepo == nil || pending == nil || pending.quote == nil {
 		return
@@ -1491,6 +1876,17 @@ func (f *Feature) persistSendMoneyQuote(ctx context.Context, pending *pendingSen
 	}
 }
 
+// withQueryTimeFooter 群组开启查询时间页脚时，在成功的查询结果后附加北京时间的查询时间
+func (f *Feature) withQueryTimeFooter(settings models.GroupSettings, text string) string {
+	if !settings.QueryTimeFooterEnabled || strings.TrimSpace(text) == "" {
+		return text
+	}
+	if strings.HasPrefix(text, "❌") || strings.HasPrefix(text, "ℹ️") {
+		return text
+	}
+	return fmt.Sprintf("%s\n\n查询时间：%s", text, f.currentTime().Format("15:04:05"))
+}
+
 func wrapResponse(text string) *types.Response {
 	if strings.TrimSpace(text) == "" {
 		return nil
@@ -2,12 +2,14 @@ package sifang
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
 	paymentservice "go_bot/internal/payment/service"
 	cryptofeature "go_bot/internal/telegram/features/crypto"
+	"go_bot/internal/telegram/features/types"
 	"go_bot/internal/telegram/models"
 	"go_bot/internal/telegram/service"
 
@@ -56,6 +58,33 @@ func TestParseSummaryDate_MonthDayPreviousYearWhenFuture(t *testing.T) {
 	}
 }
 
+func TestParseSummaryDate_FullWidthDigitsMatchASCII(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, loc)
+	cases := map[string]string{
+		"１０月２６":      "10月26",
+		"２０２４－１０－２６": "2024-10-26",
+		"１０／２６":      "10/26",
+		"１０．２６号":     "10.26号",
+	}
+	for fullWidth, ascii := range cases {
+		want, err := parseSummaryDate(ascii, now, "账单")
+		if err != nil {
+			t.Fatalf("parse %q: %v", ascii, err)
+		}
+		got, err := parseSummaryDate(fullWidth, now, "账单")
+		if err != nil {
+			t.Fatalf("parse %q: %v", fullWidth, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("parse %q = %v, want %v", fullWidth, got, want)
+		}
+		if !isValidDateSuffix(fullWidth) {
+			t.Fatalf("expected %q to be accepted as date suffix", fullWidth)
+		}
+	}
+}
+
 func TestParseSummaryDate_InvalidFormat(t *testing.T) {
 	if _, err := parseSummaryDate("abc", time.Now(), "账单"); err == nil {
 		t.Fatalf("expected error for invalid format")
@@ -150,6 +179,56 @@ func TestCalculateHistoryDays(t *testing.T) {
 	}
 }
 
+func TestCalculateHistoryDaysAcrossDSTTransition(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 2024-03-10 夏令时开始，当天只有 23 小时
+	springTarget := time.Date(2024, 3, 10, 0, 0, 0, 0, loc)
+	if got := calculateHistoryDays(springTarget, time.Date(2024, 3, 11, 0, 1, 0, 0, loc)); got != 1 {
+		t.Fatalf("expected 1 day after spring-forward, got %d", got)
+	}
+	// 2024-11-03 夏令时结束，当天有 25 小时
+	fallTarget := time.Date(2024, 11, 3, 0, 0, 0, 0, loc)
+	if got := calculateHistoryDays(fallTarget, time.Date(2024, 11, 3, 23, 59, 0, 0, loc)); got != 0 {
+		t.Fatalf("expected 0 days within fall-back day, got %d", got)
+	}
+	if got := calculateHistoryDays(fallTarget, time.Date(2024, 11, 4, 0, 1, 0, 0, loc)); got != 1 {
+		t.Fatalf("expected 1 day after fall-back, got %d", got)
+	}
+}
+
+func TestHandleBalanceHistoryDaysAroundMidnight(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected int
+	}{
+		{name: "BeforeMidnight", now: time.Date(2024, 10, 27, 23, 59, 0, 0, loc), expected: 1},
+		{name: "AfterMidnight", now: time.Date(2024, 10, 28, 0, 1, 0, 0, loc), expected: 2},
+		// 服务器时钟为 UTC 时仍按北京时间划分自然日：UTC 16:01 已是北京时间次日 00:01
+		{name: "UTCClockAfterMidnight", now: time.Date(2024, 10, 27, 16, 1, 0, 0, time.UTC), expected: 2},
+		{name: "UTCClockBeforeMidnight", now: time.Date(2024, 10, 27, 15, 59, 0, 0, time.UTC), expected: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakePaymentService{balanceResp: &paymentservice.Balance{HistoryBalance: "100.00"}}
+			feature := &Feature{paymentService: fake, nowFunc: func() time.Time { return tc.now }}
+
+			if _, handled, err := feature.handleBalance(context.Background(), 1001, "10月26"); !handled || err != nil {
+				t.Fatalf("unexpected result: handled=%v err=%v", handled, err)
+			}
+			if fake.lastHistoryDays != tc.expected {
+				t.Fatalf("expected history days %d, got %d", tc.expected, fake.lastHistoryDays)
+			}
+		})
+	}
+}
+
 func TestFormatSummaryMessage(t *testing.T) {
 	summary := &paymentservice.SummaryByDay{
 		Date:           "2025-10-31",
@@ -159,13 +238,37 @@ func TestFormatSummaryMessage(t *testing.T) {
 		OrderCount:     "40",
 	}
 
-	got := formatSummaryMessage(summary)
+	got := formatSummaryMessage(summary, false)
 	expected := "📑 账单 - 2025-10-31\n跑量：4650.00\n成交：4336.75\n笔数：40"
 	if got != expected {
 		t.Fatalf("unexpected message:\n%s", got)
 	}
 }
 
+func TestFormatSummaryMessageSplitIncome(t *testing.T) {
+	summary := &paymentservice.SummaryByDay{
+		Date:           "2025-10-31",
+		TotalAmount:    "4650.00",
+		MerchantIncome: "4,231.50",
+		AgentIncome:    "105.25",
+		OrderCount:     "40",
+	}
+
+	got := formatSummaryMessage(summary, true)
+	expected := "📑 账单 - 2025-10-31\n跑量：4650.00\n商户收入：4,231.50\n代理收入：105.25\n笔数：40"
+	if got != expected {
+		t.Fatalf("unexpected message:\n%s", got)
+	}
+
+	opts := summaryOptionsFor(models.GroupSettings{SummaryIncomeMode: models.SummaryIncomeSplit})
+	if !opts.splitIncome {
+		t.Fatalf("expected split income option from settings")
+	}
+	if summaryOptionsFor(models.GroupSettings{}).splitIncome {
+		t.Fatalf("expected combined income by default")
+	}
+}
+
 func TestFormatChannelSummaryMessage(t *testing.T) {
 	items := []*paymentservice.SummaryByDayChannel{
 		{
@@ -186,15 +289,21 @@ func TestFormatChannelSummaryMessage(t *testing.T) {
 		},
 	}
 
-	got := formatChannelSummaryMessage("2025-10-31", items)
+	got := formatChannelSummaryMessage("2025-10-31", items, false)
 	expected := "📑 通道账单 - 2025-10-31\n\nUSDT通道：<code>USDT</code>\n跑量：5000.00\n成交：4900\n笔数：20\n\n支付宝：<code>ALIPAY</code>\n跑量：2000\n成交：1800\n笔数：5"
 	if got != expected {
 		t.Fatalf("unexpected channel message:\n%s", got)
 	}
+
+	got = formatChannelSummaryMessage("2025-10-31", items, true)
+	expected = "📑 通道账单 - 2025-10-31\n\nUSDT通道：<code>USDT</code>\n跑量：5000.00\n商户收入：4800.00\n代理收入：100.00\n笔数：20\n\n支付宝：<code>ALIPAY</code>\n跑量：2000\n商户收入：1800\n代理收入：0\n笔数：5"
+	if got != expected {
+		t.Fatalf("unexpected split channel message:\n%s", got)
+	}
 }
 
 func TestFormatChannelSummaryMessage_NoItems(t *testing.T) {
-	got := formatChannelSummaryMessage("2025-10-31", nil)
+	got := formatChannelSummaryMessage("2025-10-31", nil, false)
 	expected := "ℹ️ 2025-10-31 暂无通道账单数据"
 	if got != expected {
 		t.Fatalf("unexpected channel message for no items:\n%s", got)
@@ -505,7 +614,7 @@ func TestHandleSendMoneyCreatesPending(t *testing.T) {
 		Text: "下发 12",
 	}
 
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2023100, cryptofeature.DefaultFloatRate, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2023100, cryptofeature.DefaultFloatRate), msg.Text)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -565,7 +674,7 @@ func TestHandleSendMoneyCreatesPendingFromQuoteCommand(t *testing.T) {
 		Text: "下发 z3 100 123456",
 	}
 
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2023100, 0.12, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2023100, 0.12), msg.Text)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -624,7 +733,7 @@ func TestHandleSendMoneyQuoteCommandRequiresUSDTAmount(t *testing.T) {
 		Text: "下发 z3",
 	}
 
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2023100, 0.12, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2023100, 0.12), msg.Text)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -655,7 +764,7 @@ func TestHandleSendMoneyCallbackConfirm(t *testing.T) {
 		From: &botModels.User{ID: 123},
 		Text: "下发 12",
 	}
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2023100, cryptofeature.DefaultFloatRate, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2023100, cryptofeature.DefaultFloatRate), msg.Text)
 	if err != nil || !handled || resp == nil {
 		t.Fatalf("unexpected setup result: resp=%v handled=%v err=%v", resp, handled, err)
 	}
@@ -724,7 +833,7 @@ func TestHandleSendMoneyCallbackConfirmPersistsQuoteSnapshot(t *testing.T) {
 		From: &botModels.User{ID: 123},
 		Text: "下发 z1 100",
 	}
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2023100, 0.12, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2023100, 0.12), msg.Text)
 	if err != nil || !handled || resp == nil {
 		t.Fatalf("unexpected setup result: resp=%v handled=%v err=%v", resp, handled, err)
 	}
@@ -776,7 +885,7 @@ func TestHandleSendMoneyCallbackCancel(t *testing.T) {
 		From: &botModels.User{ID: 555},
 		Text: "下发 20",
 	}
-	resp, handled, err := feature.handleSendMoney(ctx, msg, 2024001, cryptofeature.DefaultFloatRate, msg.Text)
+	resp, handled, err := feature.handleSendMoney(ctx, msg, sendMoneyTestGroup(2024001, cryptofeature.DefaultFloatRate), msg.Text)
 	if err != nil || !handled || resp == nil {
 		t.Fatalf("unexpected setup result: resp=%v handled=%v err=%v", resp, handled, err)
 	}
@@ -855,6 +964,41 @@ func TestHandleCreateOrder(t *testing.T) {
 	}
 }
 
+func TestHandleSendMoneyRejectsUnauthorizedOrUnbound(t *testing.T) {
+	ctx := context.Background()
+	msg := &botModels.Message{
+		Chat: botModels.Chat{ID: -1, Type: "group"},
+		From: &botModels.User{ID: 123},
+		Text: "下发 100",
+	}
+
+	tests := []struct {
+		name    string
+		isAdmin bool
+		group   *models.Group
+		want    string
+	}{
+		{name: "not admin", isAdmin: false, group: sendMoneyTestGroup(2023100, 0.12), want: "仅管理员可以执行资金操作"},
+		{name: "unbound group", isAdmin: true, group: sendMoneyTestGroup(0, 0.12), want: "未绑定商户号"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feature := New(&fakePaymentService{}, &stubUserService{isAdmin: tt.isAdmin})
+			resp, handled, err := feature.handleSendMoney(ctx, msg, tt.group, msg.Text)
+			if err != nil || !handled {
+				t.Fatalf("unexpected result: handled=%v err=%v", handled, err)
+			}
+			if !strings.Contains(resp.Text, tt.want) {
+				t.Fatalf("expected rejection %q, got %s", tt.want, resp.Text)
+			}
+			if resp.ReplyMarkup != nil {
+				t.Fatalf("expected no confirmation keyboard on rejection")
+			}
+		})
+	}
+}
+
 func TestHandleCreateOrder_RequiresAdmin(t *testing.T) {
 	ctx := context.Background()
 	fakeSvc := &fakePaymentService{}
@@ -930,6 +1074,41 @@ func TestHandleBalanceReturnsCurrentAmount(t *testing.T) {
 	}
 }
 
+func TestProcessAppendsQueryTimeFooterOnlyWhenEnabled(t *testing.T) {
+	fake := &fakePaymentService{balanceResp: &paymentservice.Balance{Balance: "123.45", MerchantID: "1001"}}
+	now := time.Date(2024, 11, 20, 13, 4, 5, 0, chinaLocation)
+	feature := &Feature{paymentService: fake, nowFunc: func() time.Time { return now }}
+	msg := &botModels.Message{
+		Text: "余额",
+		Chat: botModels.Chat{ID: -1001, Type: "group"},
+		From: &botModels.User{ID: 1},
+	}
+
+	group := &models.Group{Settings: models.GroupSettings{MerchantID: 1001}}
+	resp, _, err := feature.Process(context.Background(), msg, group)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp == nil || resp.Text != "123.45" {
+		t.Fatalf("expected plain balance without footer, got %+v", resp)
+	}
+
+	group.Settings.QueryTimeFooterEnabled = true
+	resp, _, err = feature.Process(context.Background(), msg, group)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp == nil || resp.Text != "123.45\n\n查询时间：13:04:05" {
+		t.Fatalf("expected balance with query time footer, got %+v", resp)
+	}
+
+	fake.balanceErr = errors.New("boom")
+	resp, _, _ = feature.Process(context.Background(), msg, group)
+	if resp == nil || strings.Contains(resp.Text, "查询时间") {
+		t.Fatalf("expected no footer on failed query, got %+v", resp)
+	}
+}
+
 func TestHandleBalanceReturnsHistoryAmount(t *testing.T) {
 	fake := &fakePaymentService{
 		balanceResp: &paymentservice.Balance{
@@ -940,7 +1119,8 @@ func TestHandleBalanceReturnsHistoryAmount(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	amount, _, err := feature.handleBalance(context.Background(), 1001, "2000-01-01")
+	target := time.Now().In(chinaLocation).AddDate(0, 0, -10).Format("2006-01-02")
+	amount, _, err := feature.handleBalance(context.Background(), 1001, target)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -952,6 +1132,27 @@ func TestHandleBalanceReturnsHistoryAmount(t *testing.T) {
 	}
 }
 
+func TestHandleBalanceRejectsDateOutsideHistoryWindow(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceResp: &paymentservice.Balance{
+			Balance:        "123.45",
+			HistoryBalance: "67.89",
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	amount, handled, err := feature.handleBalance(context.Background(), 1001, "2020-01-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handled {
+		t.Fatalf("expected handled to be true")
+	}
+	if amount != "❌ 仅支持查询最近 365 天的历史余额" {
+		t.Fatalf("expected history window message, got %s", amount)
+	}
+}
+
 func TestHandleSummaryIncludesWithdrawAndBalance(t *testing.T) {
 	now := time.Now().In(chinaLocation)
 	today := now.Format("2006-01-02")
@@ -968,7 +1169,7 @@ func TestHandleSummaryIncludesWithdrawAndBalance(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	message, _, err := feature.handleSummary(context.Background(), 1001, "账单")
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -999,7 +1200,7 @@ func TestHandleSummaryWithdrawOnlyKeepsSuccessfulItems(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	message, _, err := feature.handleSummary(context.Background(), 1001, "账单")
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1012,6 +1213,134 @@ func TestHandleSummaryWithdrawOnlyKeepsSuccessfulItems(t *testing.T) {
 	}
 }
 
+func TestHandleSummarySingleMerchantHasNoSections(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceResp: &paymentservice.Balance{Balance: "5000"},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", defaultSummaryOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(message, "🏪 商户") {
+		t.Fatalf("expected no merchant section header for single merchant, got %s", message)
+	}
+	if len(fake.summaryMerchantIDs) != 1 || fake.summaryMerchantIDs[0] != 1001 {
+		t.Fatalf("expected single summary query for 1001, got %v", fake.summaryMerchantIDs)
+	}
+}
+
+func TestHandleSummaryMultiMerchantRendersSections(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceByMerchant: map[int64]*paymentservice.Balance{
+			1001: {Balance: "5000"},
+			1002: {Balance: "1200.5"},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001, 1002}, "账单", defaultSummaryOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.summaryMerchantIDs) != 2 || fake.summaryMerchantIDs[0] != 1001 || fake.summaryMerchantIDs[1] != 1002 {
+		t.Fatalf("expected summary queries for both merchants in order, got %v", fake.summaryMerchantIDs)
+	}
+	first := strings.Index(message, "🏪 商户 <code>1001</code>")
+	second := strings.Index(message, "🏪 商户 <code>1002</code>")
+	if first < 0 || second < 0 || first > second {
+		t.Fatalf("expected per-merchant sections in order, got %s", message)
+	}
+	if !strings.Contains(message[first:second], "余额：5000") || !strings.Contains(message[second:], "余额：1200.5") {
+		t.Fatalf("expected each section to carry its own balance, got %s", message)
+	}
+}
+
+func TestHandleMultiMerchantBalanceSumsAmounts(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceByMerchant: map[int64]*paymentservice.Balance{
+			1001: {Balance: "5,000"},
+			1002: {Balance: "1200.5"},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message := feature.handleMultiMerchantBalance(context.Background(), []int64{1001, 1002}, "")
+	expected := "商户 <code>1001</code>：5,000\n商户 <code>1002</code>：1200.5\n合计：6200.50"
+	if message != expected {
+		t.Fatalf("unexpected balance message:\n%s\nwant:\n%s", message, expected)
+	}
+}
+
+func TestHandleMultiMerchantBalanceSkipsTotalOnFailure(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceByMerchant: map[int64]*paymentservice.Balance{
+			1001: {Balance: "5000"},
+			1002: {},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message := feature.handleMultiMerchantBalance(context.Background(), []int64{1001, 1002}, "")
+	if strings.Contains(message, "合计") {
+		t.Fatalf("expected total omitted when a balance is unknown, got %s", message)
+	}
+	if !strings.Contains(message, "商户 <code>1002</code>：未知") {
+		t.Fatalf("expected unknown balance section, got %s", message)
+	}
+}
+
+func TestHandleSummaryWithdrawToggleSkipsWithdrawQuery(t *testing.T) {
+	today := time.Now().In(chinaLocation).Format("2006-01-02")
+	fake := &fakePaymentService{
+		balanceResp: &paymentservice.Balance{Balance: "5000"},
+		withdrawResp: &paymentservice.WithdrawList{
+			Items: []*paymentservice.Withdraw{
+				{Amount: "100", Status: "paid", CreatedAt: today + " 10:00:00"},
+			},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+	opts := summaryOptionsFor(models.GroupSettings{SummaryShowWithdraws: false, SummaryShowWithdrawsConfigured: true})
+
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(message, "提款明细") {
+		t.Fatalf("expected withdraw section omitted, got %s", message)
+	}
+	if fake.withdrawCalls != 0 {
+		t.Fatalf("expected withdraw list not queried, got %d calls", fake.withdrawCalls)
+	}
+	if !strings.Contains(message, "余额：5000") || fake.balanceCalls != 1 {
+		t.Fatalf("expected balance kept, got calls=%d message=%s", fake.balanceCalls, message)
+	}
+}
+
+func TestHandleSummaryBalanceToggleSkipsBalanceQuery(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceResp: &paymentservice.Balance{Balance: "5000"},
+	}
+	feature := &Feature{paymentService: fake}
+	opts := summaryOptionsFor(models.GroupSettings{SummaryShowBalance: false, SummaryShowBalanceConfigured: true})
+
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(message, "余额：") {
+		t.Fatalf("expected balance section omitted, got %s", message)
+	}
+	if fake.balanceCalls != 0 {
+		t.Fatalf("expected balance not queried, got %d calls", fake.balanceCalls)
+	}
+	if fake.withdrawCalls != 1 {
+		t.Fatalf("expected withdraw list still queried, got %d calls", fake.withdrawCalls)
+	}
+}
+
 func TestBuildSummaryMessageMatchesHandleSummary(t *testing.T) {
 	now := time.Now().In(chinaLocation)
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, chinaLocation)
@@ -1030,12 +1359,12 @@ func TestBuildSummaryMessageMatchesHandleSummary(t *testing.T) {
 
 	feature := &Feature{paymentService: fake}
 
-	expected, _, err := feature.handleSummary(context.Background(), 1001, "账单")
+	expected, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error from handleSummary: %v", err)
 	}
 
-	actual, err := feature.BuildSummaryMessage(context.Background(), 1001, today)
+	actual, err := feature.BuildSummaryMessage(context.Background(), 1001, today, models.GroupSettings{})
 	if err != nil {
 		t.Fatalf("unexpected error from BuildSummaryMessage: %v", err)
 	}
@@ -1055,7 +1384,7 @@ func TestHandleSummaryUsesHistoryBalanceForPastDate(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	message, _, err := feature.handleSummary(context.Background(), 1001, "账单01-01")
+	message, _, err := feature.handleSummary(context.Background(), []int64{1001}, "账单01-01", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1093,7 +1422,7 @@ func TestHandleChannelSummaryIncludesWithdrawAndBalance(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	message, _, err := feature.handleChannelSummary(context.Background(), 1001, "通道账单")
+	message, _, err := feature.handleChannelSummary(context.Background(), 1001, "通道账单", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1131,7 +1460,7 @@ func TestHandleChannelSummaryUsesHistoryBalanceForPastDate(t *testing.T) {
 	}
 	feature := &Feature{paymentService: fake}
 
-	message, _, err := feature.handleChannelSummary(context.Background(), 1001, "通道账单01-01")
+	message, _, err := feature.handleChannelSummary(context.Background(), 1001, "通道账单01-01", defaultSummaryOptions)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1143,6 +1472,83 @@ func TestHandleChannelSummaryUsesHistoryBalanceForPastDate(t *testing.T) {
 	}
 }
 
+func TestHandleChannelSummaryFiltersByChannelCode(t *testing.T) {
+	fake := &fakePaymentService{
+		channelSummaryResp: []*paymentservice.SummaryByDayChannel{
+			{ChannelCode: "USDT", ChannelName: "USDT通道", TotalAmount: "5000", OrderCount: "20"},
+			{ChannelCode: "ALIPAY", ChannelName: "支付宝", TotalAmount: "2000", OrderCount: "5"},
+		},
+		channelStatusResp: []*paymentservice.ChannelStatus{
+			{ChannelCode: "USDT"},
+			{ChannelCode: "ALIPAY"},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+	opts := summaryOptions{}
+
+	msg := &botModels.Message{Text: "通道账单 usdt 10月26", Chat: botModels.Chat{Type: "group"}}
+	if !feature.Match(context.Background(), msg) {
+		t.Fatalf("expected channel summary with channel code to match")
+	}
+
+	message, _, err := feature.handleChannelSummary(context.Background(), 1001, msg.Text, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.lastChannelSummaryCode != "USDT" {
+		t.Fatalf("expected channel code USDT passed through, got %q", fake.lastChannelSummaryCode)
+	}
+	if !strings.Contains(message, "-10-26") || !strings.Contains(message, "USDT通道") {
+		t.Fatalf("expected USDT channel summary for 10-26, got %s", message)
+	}
+	if strings.Contains(message, "支付宝") {
+		t.Fatalf("expected other channels to be filtered out, got %s", message)
+	}
+
+	message, _, _ = feature.handleChannelSummary(context.Background(), 1001, "通道账单", opts)
+	if fake.lastChannelSummaryCode != "" || !strings.Contains(message, "支付宝") {
+		t.Fatalf("expected all channels without code, got code=%q message=%s", fake.lastChannelSummaryCode, message)
+	}
+}
+
+func TestHandleChannelSummaryRejectsUnknownChannel(t *testing.T) {
+	fake := &fakePaymentService{
+		channelStatusResp: []*paymentservice.ChannelStatus{{ChannelCode: "USDT"}, {ChannelCode: "ALIPAY"}},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message, _, _ := feature.handleChannelSummary(context.Background(), 1001, "通道账单 WECHAT", summaryOptions{})
+	if !strings.Contains(message, "未找到通道 WECHAT") || !strings.Contains(message, "USDT、ALIPAY") {
+		t.Fatalf("expected unknown channel notice, got %s", message)
+	}
+	if fake.lastChannelSummaryCode != "" {
+		t.Fatalf("expected no summary query for unknown channel, got %q", fake.lastChannelSummaryCode)
+	}
+}
+
+func TestExtractChannelSummaryArgs(t *testing.T) {
+	cases := []struct {
+		text    string
+		channel string
+		date    string
+		ok      bool
+	}{
+		{text: "通道账单", ok: true},
+		{text: "通道账单10月26", date: "10月26", ok: true},
+		{text: "通道账单 USDT", channel: "USDT", ok: true},
+		{text: "通道账单 USDT 10月26", channel: "USDT", date: "10月26", ok: true},
+		{text: "通道账单 ＵＳＤＴ １０月２６", channel: "USDT", date: "10月26", ok: true},
+		{text: "通道账单 USDT 说明"},
+		{text: "通道账单说明"},
+	}
+	for _, tc := range cases {
+		channel, date, ok := extractChannelSummaryArgs(tc.text)
+		if ok != tc.ok || channel != tc.channel || strings.TrimSpace(date) != tc.date {
+			t.Fatalf("extractChannelSummaryArgs(%q) = (%q, %q, %v), want (%q, %q, %v)", tc.text, channel, date, ok, tc.channel, tc.date, tc.ok)
+		}
+	}
+}
+
 func TestHandleWithdrawListOnlyKeepsSuccessfulItems(t *testing.T) {
 	now := time.Now().In(chinaLocation)
 	today := now.Format("2006-01-02")
@@ -1171,19 +1577,55 @@ func TestHandleWithdrawListOnlyKeepsSuccessfulItems(t *testing.T) {
 	}
 }
 
+func TestHandleWithdrawListTotalsAllPages(t *testing.T) {
+	today := time.Now().In(chinaLocation).Format("2006-01-02")
+	fake := &fakePaymentService{
+		withdrawPages: map[int]*paymentservice.WithdrawList{
+			1: {Page: 1, TotalPages: 2, Total: 3, Items: []*paymentservice.Withdraw{
+				{Amount: "100", Status: "paid", CreatedAt: today + " 10:00:00"},
+				{Amount: "50", Status: "cancelled", CreatedAt: today + " 10:30:00"},
+			}},
+			2: {Page: 2, TotalPages: 2, Total: 3, Items: []*paymentservice.Withdraw{
+				{Amount: "300", Status: "paid", CreatedAt: today + " 12:00:00"},
+			}},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message, handled, err := feature.handleWithdrawList(context.Background(), 1001, "提款明细")
+	if err != nil || !handled {
+		t.Fatalf("expected handled without error, got handled=%v err=%v", handled, err)
+	}
+	if !strings.Contains(message, "总计（全部） 400｜2 笔，本页 1 笔") {
+		t.Fatalf("expected total across all pages, got %s", message)
+	}
+	if !strings.Contains(message, "10:00:00      100") || strings.Contains(message, "12:00:00") {
+		t.Fatalf("expected only the first page to be displayed, got %s", message)
+	}
+}
+
 type fakePaymentService struct {
 	balanceResp               *paymentservice.Balance
+	balanceByMerchant         map[int64]*paymentservice.Balance
 	balanceErr                error
+	summaryMerchantIDs        []int64
+	balanceCalls              int
+	withdrawCalls             int
 	summaryResp               *paymentservice.SummaryByDay
+	summaryByDate             map[string]*paymentservice.SummaryByDay
 	summaryErr                error
 	channelSummaryResp        []*paymentservice.SummaryByDayChannel
 	channelSummaryErr         error
+	lastChannelSummaryCode    string
 	withdrawResp              *paymentservice.WithdrawList
+	withdrawPages             map[int]*paymentservice.WithdrawList
 	withdrawErr               error
 	channelStatusResp         []*paymentservice.ChannelStatus
 	channelStatusErr          error
+	channelStatusCalls        int
 	lastHistoryDays           int
 	sendMoneyResult           *paymentservice.SendMoneyResult
+	lastSendGoogleCode        string
 	sendMoneyErr              error
 	lastSendAmount            float64
 	createOrderResp           *paymentservice.CreateOrderResult
@@ -1196,16 +1638,27 @@ type fakePaymentService struct {
 
 func (f *fakePaymentService) GetBalance(ctx context.Context, merchantID int64, historyDays int) (*paymentservice.Balance, error) {
 	f.lastHistoryDays = historyDays
+	f.balanceCalls++
+	if historyDays > paymentservice.DefaultMaxHistoryDays {
+		return nil, &paymentservice.HistoryWindowError{MaxDays: paymentservice.DefaultMaxHistoryDays}
+	}
 	if f.balanceErr != nil {
 		return nil, f.balanceErr
 	}
+	if balance, ok := f.balanceByMerchant[merchantID]; ok {
+		return balance, nil
+	}
 	return f.balanceResp, nil
 }
 
 func (f *fakePaymentService) GetSummaryByDay(ctx context.Context, merchantID int64, date time.Time) (*paymentservice.SummaryByDay, error) {
+	f.summaryMerchantIDs = append(f.summaryMerchantIDs, merchantID)
 	if f.summaryErr != nil {
 		return nil, f.summaryErr
 	}
+	if summary, ok := f.summaryByDate[date.Format("2006-01-02")]; ok {
+		return summary, nil
+	}
 	if f.summaryResp != nil {
 		return f.summaryResp, nil
 	}
@@ -1219,7 +1672,8 @@ func (f *fakePaymentService) GetSummaryByDay(ctx context.Context, merchantID int
 	}, nil
 }
 
-func (f *fakePaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time) ([]*paymentservice.SummaryByDayChannel, error) {
+func (f *fakePaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time, channelCode string) ([]*paymentservice.SummaryByDayChannel, error) {
+	f.lastChannelSummaryCode = channelCode
 	if f.channelSummaryErr != nil {
 		return nil, f.channelSummaryErr
 	}
@@ -1234,9 +1688,16 @@ func (f *fakePaymentService) GetSummaryByDayByPZID(ctx context.Context, pzid str
 }
 
 func (f *fakePaymentService) GetWithdrawList(ctx context.Context, merchantID int64, start, end time.Time, page, pageSize int) (*paymentservice.WithdrawList, error) {
+	f.withdrawCalls++
 	if f.withdrawErr != nil {
 		return nil, f.withdrawErr
 	}
+	if f.withdrawPages != nil {
+		if list, ok := f.withdrawPages[page]; ok {
+			return list, nil
+		}
+		return &paymentservice.WithdrawList{}, nil
+	}
 	if f.withdrawResp != nil {
 		return f.withdrawResp, nil
 	}
@@ -1244,6 +1705,7 @@ func (f *fakePaymentService) GetWithdrawList(ctx context.Context, merchantID int
 }
 
 func (f *fakePaymentService) GetChannelStatus(ctx context.Context, merchantID int64) ([]*paymentservice.ChannelStatus, error) {
+	f.channelStatusCalls++
 	if f.channelStatusErr != nil {
 		return nil, f.channelStatusErr
 	}
@@ -1252,6 +1714,7 @@ func (f *fakePaymentService) GetChannelStatus(ctx context.Context, merchantID in
 
 func (f *fakePaymentService) SendMoney(ctx context.Context, merchantID int64, amount float64, opts paymentservice.SendMoneyOptions) (*paymentservice.SendMoneyResult, error) {
 	f.lastSendAmount = amount
+	f.lastSendGoogleCode = opts.GoogleCode
 	if f.sendMoneyErr != nil {
 		return nil, f.sendMoneyErr
 	}
@@ -1280,6 +1743,7 @@ func (f *fakePaymentService) FindOrderChannelBinding(ctx context.Context, mercha
 
 type stubUserService struct {
 	isAdmin bool
+	isOwner bool
 }
 
 func (s *stubUserService) RegisterOrUpdateUser(ctx context.Context, info *service.TelegramUserInfo) error {
@@ -1303,17 +1767,36 @@ func (s *stubUserService) ListAllAdmins(ctx context.Context) ([]*models.User, er
 }
 
 func (s *stubUserService) CheckOwnerPermission(ctx context.Context, telegramID int64) (bool, error) {
-	return false, nil
+	return s.isOwner, nil
 }
 
 func (s *stubUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
 	return s.isAdmin, nil
 }
 
+func (s *stubUserService) GrantInviterAdmin(ctx context.Context, info *service.TelegramUserInfo) (bool, error) {
+	return false, nil
+}
+
+func (s *stubUserService) SetUserBlocked(ctx context.Context, telegramID int64, blocked bool) error {
+	return nil
+}
+
 func (s *stubUserService) UpdateUserActivity(ctx context.Context, telegramID int64) error {
 	return nil
 }
 
+// sendMoneyTestGroup 构造已绑定商户号的商户群
+func sendMoneyTestGroup(merchantID int64, floatRate float64) *models.Group {
+	return &models.Group{
+		Tier: models.GroupTierMerchant,
+		Settings: models.GroupSettings{
+			MerchantID:      int32(merchantID),
+			CryptoFloatRate: floatRate,
+		},
+	}
+}
+
 type fakeWithdrawQuoteRepo struct {
 	records []*models.WithdrawQuoteRecord
 }
@@ -1334,3 +1817,70 @@ func (r *fakeWithdrawQuoteRepo) ListByMerchantAndDateRange(ctx context.Context,
 func (r *fakeWithdrawQuoteRepo) EnsureIndexes(ctx context.Context) error {
 	return nil
 }
+
+func TestProcessWithoutPaymentServiceReturnsFriendlyMessage(t *testing.T) {
+	feature := New(nil, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{MerchantID: 1001}}
+
+	commands := []string{"余额", "账单", "通道账单", "提款明细", "费率", "下发 100", "模拟下单 100", "异常订单 M-001", "订单 M-001"}
+	for _, text := range commands {
+		t.Run(text, func(t *testing.T) {
+			msg := &botModels.Message{
+				Text: text,
+				Chat: botModels.Chat{ID: -1001, Type: "group"},
+				From: &botModels.User{ID: 1},
+			}
+			if !feature.Match(context.Background(), msg) {
+				t.Fatalf("expected %q to match", text)
+			}
+
+			resp, handled, err := feature.Process(context.Background(), msg, group)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !handled || resp == nil || resp.Text != types.PaymentNotConfiguredText {
+				t.Fatalf("expected not configured message, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestBuildSummaryMessageWithoutPaymentService(t *testing.T) {
+	feature := New(nil, nil)
+	_, err := feature.BuildSummaryMessage(context.Background(), 1001, time.Now(), models.GroupSettings{})
+	if !errors.Is(err, paymentservice.ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestPurgeExpiredPendingCountsExpiredRequests(t *testing.T) {
+	f := New(nil, nil)
+	if _, err := f.createPendingSend(-1001, 1, 1001, 100, "123456"); err != nil {
+		t.Fatalf("createPendingSend returned error: %v", err)
+	}
+
+	if got := f.PurgeExpiredPending(time.Now()); got != 0 {
+		t.Fatalf("expected fresh request kept, purged %d", got)
+	}
+	if got := f.PurgeExpiredPending(time.Now().Add(SendMoneyConfirmTTL + time.Second)); got != 1 {
+		t.Fatalf("expected 1 expired request purged, got %d", got)
+	}
+	if len(f.pending) != 0 {
+		t.Fatalf("expected pending map empty, got %d", len(f.pending))
+	}
+}
+
+func TestFormatCurrencyFloatUsesConfiguredPrecision(t *testing.T) {
+	models.SetMoneyPrecision(map[string]int{"USDT": 4, models.CurrencyCNY: 2})
+	t.Cleanup(func() { models.SetMoneyPrecision(nil) })
+
+	if got := formatUSDT(137.123456); got != "137.1235" {
+		t.Fatalf("expected 4-decimal USDT, got %s", got)
+	}
+	if got := formatFloat(1000.456); got != "1000.46" {
+		t.Fatalf("expected 2-decimal CNY, got %s", got)
+	}
+	if got := formatUSDT(100); got != "100" {
+		t.Fatalf("expected integer without decimals, got %s", got)
+	}
+}
@@ -0,0 +1,210 @@
+package sifang
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+)
+
+const (
+	notifyCheckCommand       = "异常订单"
+	notifyCheckResponseLimit = 120
+	notifyCheckURLLimit      = 80
+)
+
+type notifyOutcome int
+
+const (
+	notifyOutcomeUnknown notifyOutcome = iota
+	notifyOutcomePending
+	notifyOutcomeSuccess
+	notifyOutcomeFailed
+)
+
+func isNotifyCheckCommand(text string) bool {
+	if !strings.HasPrefix(text, notifyCheckCommand) {
+		return false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(text, notifyCheckCommand)) != ""
+}
+
+// handleNotifyCheck 处理 异常订单 <订单号>，诊断商户回调通知情况
+func (f *Feature) handleNotifyCheck(ctx context.Context, merchantID int64, text string) (string, bool, error) {
+	orderNo := strings.TrimSpace(strings.TrimPrefix(text, notifyCheckCommand))
+	if orderNo == "" || strings.ContainsAny(orderNo, " \t\n") {
+		return "❌ 用法：异常订单 <订单号>", true, nil
+	}
+
+	detail, err := f.paymentService.GetOrderDetail(ctx, merchantID, orderNo, paymentservice.OrderNumberTypeAuto)
+	if err != nil {
+		logger.L().Warnf("Sifang notify check failed: merchant_id=%d order_no=%s err=%v", merchantID, orderNo, err)
+		return fmt.Sprintf("❌ 查询订单失败：%s", html.EscapeString(err.Error())), true, nil
+	}
+	if detail == nil || detail.Order == nil {
+		return fmt.Sprintf("ℹ️ 未找到订单 <code>%s</code>", html.EscapeString(orderNo)), true, nil
+	}
+
+	logger.L().Infof("Sifang notify check: merchant_id=%d order_no=%s notify_status=%s logs=%d",
+		merchantID, orderNo, detail.Order.NotifyStatus, len(detail.NotifyLogs))
+	return formatNotifyCheckMessage(orderNo, detail), true, nil
+}
+
+func formatNotifyCheckMessage(orderNo string, detail *paymentservice.OrderDetail) string {
+	order := detail.Order
+	logs := make([]*paymentservice.NotifyLog, 0, len(detail.NotifyLogs))
+	for _, log := range detail.NotifyLogs {
+		if log != nil {
+			logs = append(logs, log)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🔔 <b>回调通知诊断</b>\n")
+	sb.WriteString(fmt.Sprintf("订单号：<code>%s</code>\n", html.EscapeString(emptyFallback(order.MerchantOrderNo, orderNo))))
+	if platformNo := strings.TrimSpace(order.PlatformOrderNo); platformNo != "" {
+		sb.WriteString(fmt.Sprintf("平台单号：<code>%s</code>\n", html.EscapeString(platformNo)))
+	}
+	if status := joinStatusText(order.Status, order.StatusText); status != "" {
+		sb.WriteString(fmt.Sprintf("订单状态：%s\n", html.EscapeString(status)))
+	}
+
+	outcome := resolveNotifyOutcome(order, logs)
+	switch outcome {
+	case notifyOutcomeSuccess:
+		sb.WriteString("回调结果：✅ 商户回调成功\n")
+	case notifyOutcomeFailed:
+		sb.WriteString("回调结果：❌ 商户回调失败\n")
+	case notifyOutcomePending:
+		sb.WriteString("回调结果：⏳ 尚未发起回调\n")
+	default:
+		sb.WriteString("回调结果：❔ 状态未知\n")
+	}
+	if status := joinStatusText(order.NotifyStatus, order.NotifyStatusText); status != "" {
+		sb.WriteString(fmt.Sprintf("通知状态：%s\n", html.EscapeString(status)))
+	}
+	sb.WriteString(fmt.Sprintf("通知次数：%d\n", notifyAttemptCount(order, logs)))
+	if lastErr := strings.TrimSpace(order.NotifyLastError); lastErr != "" {
+		sb.WriteString(fmt.Sprintf("最后错误：%s\n", html.EscapeString(truncateRunes(lastErr, notifyCheckResponseLimit))))
+	}
+	if notifyURL := strings.TrimSpace(order.NotifyURL); notifyURL != "" {
+		sb.WriteString(fmt.Sprintf("回调地址：%s\n", html.EscapeString(truncateRunes(notifyURL, notifyCheckURLLimit))))
+	}
+
+	if len(logs) == 0 {
+		sb.WriteString("\n暂无通知记录")
+		return strings.TrimRight(sb.String(), "\n")
+	}
+
+	sb.WriteString("\n<b>通知时间线</b>")
+	for i, log := range logs {
+		icon := "❔"
+		if isFailureIndicator(log.Status) || isFailureIndicator(log.StatusText) {
+			icon = "❌"
+		} else if isSuccessIndicator(log.Status) || isSuccessIndicator(log.StatusText) {
+			icon = "✅"
+		}
+
+		sb.WriteString(fmt.Sprintf("\n%d. %s %s", i+1, icon, html.EscapeString(emptyFallback(log.AttemptedAt, "-"))))
+		if status := joinStatusText(log.Status, log.StatusText); status != "" {
+			sb.WriteString(fmt.Sprintf(" %s", html.EscapeString(status)))
+		}
+		if duration := strings.TrimSpace(log.Duration); duration != "" {
+			sb.WriteString(fmt.Sprintf("（耗时 %s）", html.EscapeString(duration)))
+		}
+		if response := strings.TrimSpace(log.Response); response != "" {
+			sb.WriteString(fmt.Sprintf("\n   响应：%s", html.EscapeString(truncateRunes(response, notifyCheckResponseLimit))))
+		}
+	}
+
+	return sb.String()
+}
+
+func resolveNotifyOutcome(order *paymentservice.Order, logs []*paymentservice.NotifyLog) notifyOutcome {
+	switch {
+	case isFailureIndicator(order.NotifyStatus) || isFailureIndicator(order.NotifyStatusText):
+		return notifyOutcomeFailed
+	case isSuccessIndicator(order.NotifyStatus) || isSuccessIndicator(order.NotifyStatusText):
+		return notifyOutcomeSuccess
+	}
+
+	// 订单未给出明确状态时，以最后一次通知记录为准
+	if len(logs) > 0 {
+		last := logs[len(logs)-1]
+		if isFailureIndicator(last.Status) || isFailureIndicator(last.StatusText) {
+			return notifyOutcomeFailed
+		}
+		if isSuccessIndicator(last.Status) || isSuccessIndicator(last.StatusText) {
+			return notifyOutcomeSuccess
+		}
+		return notifyOutcomeUnknown
+	}
+
+	if strings.TrimSpace(order.NotifyLastError) != "" {
+		return notifyOutcomeFailed
+	}
+	if notifyAttemptCount(order, logs) == 0 {
+		return notifyOutcomePending
+	}
+	return notifyOutcomeUnknown
+}
+
+func notifyAttemptCount(order *paymentservice.Order, logs []*paymentservice.NotifyLog) int {
+	if times, err := strconv.Atoi(strings.TrimSpace(order.NotifyTimes)); err == nil && times > len(logs) {
+		return times
+	}
+	return len(logs)
+}
+
+func isSuccessIndicator(value string) bool {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	switch trimmed {
+	case "success", "succeed", "succeeded", "ok", "done", "成功", "通知成功", "已通知":
+		return true
+	}
+	return false
+}
+
+func isFailureIndicator(value string) bool {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return false
+	}
+
+	lower := strings.ToLower(trimmed)
+	for _, indicator := range []string{"fail", "error", "timeout", "denied", "reject"} {
+		if strings.Contains(lower, indicator) {
+			return true
+		}
+	}
+	for _, indicator := range []string{"失败", "错误", "超时", "拒绝"} {
+		if strings.Contains(trimmed, indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinStatusText(status, statusText string) string {
+	status = strings.TrimSpace(status)
+	statusText = strings.TrimSpace(statusText)
+	if status == "" {
+		return statusText
+	}
+	if statusText == "" || strings.EqualFold(status, statusText) {
+		return status
+	}
+	return fmt.Sprintf("%s（%s）", status, statusText)
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if limit <= 0 || len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,98 @@
+package sifang
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+)
+
+func TestHandleNotifyCheck_FailedThenSucceeded(t *testing.T) {
+	fake := &fakePaymentService{
+		orderDetailResp: &paymentservice.OrderDetail{
+			Order: &paymentservice.Order{
+				MerchantOrderNo:  "M-001",
+				PlatformOrderNo:  "PF-001",
+				Status:           "paid",
+				StatusText:       "已支付",
+				NotifyStatus:     "success",
+				NotifyStatusText: "通知成功",
+				NotifyTimes:      "2",
+				NotifyLastError:  "HTTP 500 <error>",
+			},
+			NotifyLogs: []*paymentservice.NotifyLog{
+				{
+					Status:      "failed",
+					StatusText:  "失败",
+					Response:    "HTTP 500 <error>",
+					AttemptedAt: "2024-03-18 12:00:00",
+					Duration:    "1200ms",
+				},
+				{
+					Status:      "success",
+					StatusText:  "成功",
+					Response:    "success",
+					AttemptedAt: "2024-03-18 12:01:00",
+				},
+			},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	message, handled, err := feature.handleNotifyCheck(context.Background(), 1001, "异常订单 M-001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handled {
+		t.Fatalf("expected handled to be true")
+	}
+
+	expected := []string{
+		"订单号：<code>M-001</code>",
+		"回调结果：✅ 商户回调成功",
+		"通知次数：2",
+		"最后错误：HTTP 500 &lt;error&gt;",
+		"1. ❌ 2024-03-18 12:00:00 failed（失败）（耗时 1200ms）",
+		"2. ✅ 2024-03-18 12:01:00 success（成功）",
+	}
+	for _, want := range expected {
+		if !strings.Contains(message, want) {
+			t.Fatalf("expected %q in message:\n%s", want, message)
+		}
+	}
+	if strings.Index(message, "1. ❌") > strings.Index(message, "2. ✅") {
+		t.Fatalf("expected timeline to keep attempt order:\n%s", message)
+	}
+}
+
+func TestResolveNotifyOutcome(t *testing.T) {
+	cases := []struct {
+		name  string
+		order *paymentservice.Order
+		logs  []*paymentservice.NotifyLog
+		want  notifyOutcome
+	}{
+		{name: "failed status", order: &paymentservice.Order{NotifyStatus: "failed"}, want: notifyOutcomeFailed},
+		{name: "last log decides", order: &paymentservice.Order{}, logs: []*paymentservice.NotifyLog{{Status: "success"}, {Status: "timeout"}}, want: notifyOutcomeFailed},
+		{name: "last error only", order: &paymentservice.Order{NotifyLastError: "connection refused"}, want: notifyOutcomeFailed},
+		{name: "never notified", order: &paymentservice.Order{NotifyTimes: "0"}, want: notifyOutcomePending},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := resolveNotifyOutcome(tc.order, tc.logs); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchAcceptsNotifyCheckCommand(t *testing.T) {
+	if !isNotifyCheckCommand("异常订单 M-001") {
+		t.Fatalf("expected command with order number to match")
+	}
+	if isNotifyCheckCommand("异常订单") {
+		t.Fatalf("expected command without order number to be ignored")
+	}
+}
@@ -0,0 +1,90 @@
+package sifang
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+)
+
+const orderQueryCommand = "订单"
+
+// isOrderQueryCommand 匹配 订单 <订单号>，与「订单原文」等同前缀命令区分
+func isOrderQueryCommand(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) == 2 && fields[0] == orderQueryCommand
+}
+
+// handleOrderQuery 处理 订单 <订单号>，以卡片形式展示订单金额、状态、通道与回调情况
+func (f *Feature) handleOrderQuery(ctx context.Context, merchantID int64, text string) (string, bool, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return "❌ 用法：订单 <订单号>", true, nil
+	}
+	orderNo := fields[1]
+
+	detail, err := f.paymentService.GetOrderDetail(ctx, merchantID, orderNo, paymentservice.OrderNumberTypeAuto)
+	if err != nil && !paymentservice.IsOrderNotFoundError(err) {
+		logger.L().Warnf("Sifang order query failed: merchant_id=%d order_no=%s err=%v", merchantID, orderNo, err)
+		return fmt.Sprintf("❌ 查询订单失败：%s", html.EscapeString(err.Error())), true, nil
+	}
+	if err != nil || detail == nil || detail.Order == nil {
+		return fmt.Sprintf("ℹ️ 未找到订单 <code>%s</code>，请核对订单号后重试", html.EscapeString(orderNo)), true, nil
+	}
+
+	logger.L().Infof("Sifang order queried: merchant_id=%d order_no=%s status=%s", merchantID, orderNo, detail.Order.Status)
+	return formatOrderQueryMessage(orderNo, detail), true, nil
+}
+
+func formatOrderQueryMessage(orderNo string, detail *paymentservice.OrderDetail) string {
+	order := detail.Order
+
+	var sb strings.Builder
+	sb.WriteString("🧾 <b>订单详情</b>\n")
+	sb.WriteString(fmt.Sprintf("订单号：<code>%s</code>\n", html.EscapeString(emptyFallback(order.MerchantOrderNo, orderNo))))
+	if platformNo := strings.TrimSpace(order.PlatformOrderNo); platformNo != "" {
+		sb.WriteString(fmt.Sprintf("平台单号：<code>%s</code>\n", html.EscapeString(platformNo)))
+	}
+
+	amount := emptyFallback(order.Amount, "-")
+	if realAmount := strings.TrimSpace(order.RealAmount); realAmount != "" && realAmount != strings.TrimSpace(order.Amount) {
+		amount = fmt.Sprintf("%s（实付 %s）", amount, realAmount)
+	}
+	sb.WriteString(fmt.Sprintf("金额：%s\n", html.EscapeString(amount)))
+	sb.WriteString(fmt.Sprintf("状态：%s\n", html.EscapeString(emptyFallback(joinStatusText(order.Status, order.StatusText), "-"))))
+
+	channel := strings.TrimSpace(order.ChannelName)
+	if code := strings.TrimSpace(order.ChannelCode); code != "" {
+		if channel == "" {
+			channel = code
+		} else if channel != code {
+			channel = fmt.Sprintf("%s（%s）", channel, code)
+		}
+	}
+	sb.WriteString(fmt.Sprintf("通道：%s\n", html.EscapeString(emptyFallback(channel, "-"))))
+
+	if createdAt := strings.TrimSpace(order.CreatedAt); createdAt != "" {
+		sb.WriteString(fmt.Sprintf("创建时间：%s\n", html.EscapeString(createdAt)))
+	}
+	sb.WriteString(fmt.Sprintf("支付时间：%s\n", html.EscapeString(emptyFallback(order.PaidAt, "未支付"))))
+
+	notifyStatus := emptyFallback(joinStatusText(order.NotifyStatus, order.NotifyStatusText), "-")
+	sb.WriteString(fmt.Sprintf("回调状态：%s", html.EscapeString(notifyStatus)))
+	if count := notifyAttemptCount(order, detail.NotifyLogs); count > 0 {
+		sb.WriteString(fmt.Sprintf("（共 %d 次）", count))
+	}
+
+	// 仅白名单域名渲染为可点击链接，其余以纯文本展示
+	if link := models.FormatOrderLink("打开订单", order.ManageURL); link != "" {
+		sb.WriteString(fmt.Sprintf("\n订单后台：%s", link))
+	}
+	if link := models.FormatOrderLink("打开支付页", order.PaymentURL); link != "" {
+		sb.WriteString(fmt.Sprintf("\n支付链接：%s", link))
+	}
+
+	return sb.String()
+}
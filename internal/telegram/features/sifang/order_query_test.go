@@ -0,0 +1,137 @@
+package sifang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/payment/sifang"
+	"go_bot/internal/telegram/models"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func orderQueryMessage(text string) *botModels.Message {
+	return &botModels.Message{
+		Text: text,
+		Chat: botModels.Chat{ID: -1001, Type: "group"},
+		From: &botModels.User{ID: 1},
+	}
+}
+
+func TestProcessOrderQueryRendersOrderCard(t *testing.T) {
+	fake := &fakePaymentService{
+		orderDetailResp: &paymentservice.OrderDetail{
+			Order: &paymentservice.Order{
+				MerchantOrderNo:  "M-001",
+				PlatformOrderNo:  "PF-001",
+				Amount:           "100.00",
+				RealAmount:       "99.50",
+				Status:           "paid",
+				StatusText:       "已支付",
+				ChannelCode:      "ALIPAY",
+				ChannelName:      "支付宝",
+				PaidAt:           "2024-03-18 12:00:00",
+				NotifyStatus:     "success",
+				NotifyStatusText: "通知成功",
+				NotifyTimes:      "1",
+			},
+		},
+	}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	msg := orderQueryMessage("订单 M-001")
+	if !feature.Match(context.Background(), msg) {
+		t.Fatalf("expected 订单 command to match")
+	}
+	resp, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("unexpected result: resp=%+v handled=%v err=%v", resp, handled, err)
+	}
+
+	expected := []string{
+		"订单号：<code>M-001</code>",
+		"平台单号：<code>PF-001</code>",
+		"金额：100.00（实付 99.50）",
+		"状态：paid（已支付）",
+		"通道：支付宝（ALIPAY）",
+		"支付时间：2024-03-18 12:00:00",
+		"回调状态：success（通知成功）（共 1 次）",
+	}
+	for _, want := range expected {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected %q in message, got:\n%s", want, resp.Text)
+		}
+	}
+}
+
+func TestProcessOrderQueryLinksOnlyAllowlistedURLs(t *testing.T) {
+	models.SetOrderLinkHosts([]string{"admin.example.com"})
+	t.Cleanup(func() { models.SetOrderLinkHosts(nil) })
+
+	fake := &fakePaymentService{
+		orderDetailResp: &paymentservice.OrderDetail{
+			Order: &paymentservice.Order{
+				MerchantOrderNo: "M-002",
+				Status:          "unpaid",
+				ManageURL:       "https://admin.example.com/order?no=M-002&tab=1",
+				PaymentURL:      "https://pay.phish.example.net/M-002",
+			},
+		},
+	}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	resp, handled, err := feature.Process(context.Background(), orderQueryMessage("订单 M-002"), group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("unexpected result: resp=%+v handled=%v err=%v", resp, handled, err)
+	}
+	if !strings.Contains(resp.Text, `订单后台：<a href="https://admin.example.com/order?no=M-002&amp;tab=1">打开订单</a>`) {
+		t.Fatalf("expected allowlisted manage URL to be linked, got:\n%s", resp.Text)
+	}
+	if !strings.Contains(resp.Text, "支付链接：<code>https://pay.phish.example.net/M-002</code>") {
+		t.Fatalf("expected non-allowlisted payment URL as plain text, got:\n%s", resp.Text)
+	}
+	if strings.Count(resp.Text, "<a ") != 1 {
+		t.Fatalf("expected exactly one anchor, got:\n%s", resp.Text)
+	}
+}
+
+func TestProcessOrderQueryNotFound(t *testing.T) {
+	fake := &fakePaymentService{orderDetailErr: &sifang.APIError{Code: 404, Message: "订单不存在"}}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	resp, handled, err := feature.Process(context.Background(), orderQueryMessage("订单 X-404"), group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("unexpected result: resp=%+v handled=%v err=%v", resp, handled, err)
+	}
+	if !strings.Contains(resp.Text, "未找到订单 <code>X-404</code>") {
+		t.Fatalf("expected friendly not-found message, got %q", resp.Text)
+	}
+
+	fake.orderDetailErr = errors.New("upstream unavailable")
+	resp, _, _ = feature.Process(context.Background(), orderQueryMessage("订单 X-500"), group)
+	if !strings.Contains(resp.Text, "查询订单失败") {
+		t.Fatalf("expected query failure message, got %q", resp.Text)
+	}
+}
+
+func TestIsOrderQueryCommand(t *testing.T) {
+	tests := map[string]bool{
+		"订单 M-001":    true,
+		"订单":          false,
+		"订单 M-001 多余": false,
+		"订单原文 M-001":  false,
+		"异常订单 M-001":  false,
+		"订单M-001":     false,
+	}
+	for text, want := range tests {
+		if got := isOrderQueryCommand(text); got != want {
+			t.Fatalf("isOrderQueryCommand(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package sifang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/features/types"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	orderRawCommand = "订单原文"
+	// orderRawUnmaskFlag 附带时不脱敏敏感字段
+	orderRawUnmaskFlag = "--full"
+	// orderRawInlineLimit JSON 超过该长度时改为文件发送（Telegram 单条消息上限 4096）
+	orderRawInlineLimit = 3500
+)
+
+// orderRawSensitiveKeys 字段名包含以下片段时视为敏感信息，默认脱敏
+var orderRawSensitiveKeys = []string{"bank_account", "account_no", "card", "id_no", "idcard", "phone", "mobile", "buyer_id", "buyer_name", "password", "secret"}
+
+func isOrderRawCommand(text string) bool {
+	if !strings.HasPrefix(text, orderRawCommand) {
+		return false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(text, orderRawCommand)) != ""
+}
+
+// handleOrderRaw 处理 订单原文 <订单号> [--full]，以 JSON 展示上游订单原始字段（仅 Owner）
+func (f *Feature) handleOrderRaw(ctx context.Context, msg *botModels.Message, merchantID int64, text string) (*types.Response, bool, error) {
+	if f.userService == nil {
+		logger.L().Error("Sifang order raw: user service is nil")
+		return wrapResponse("❌ 未配置管理员校验服务，请联系管理员"), true, nil
+	}
+
+	isOwner, err := f.userService.CheckOwnerPermission(ctx, msg.From.ID)
+	if err != nil {
+		logger.L().Errorf("Sifang order raw owner check failed: user_id=%d, err=%v", msg.From.ID, err)
+		return wrapResponse("❌ 权限检查失败，请稍后重试"), true, nil
+	}
+	if !isOwner {
+		return wrapResponse("❌ 仅 Owner 可以查看订单原文"), true, nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, orderRawCommand))
+	unmask := false
+	if len(fields) == 2 && fields[1] == orderRawUnmaskFlag {
+		unmask = true
+		fields = fields[:1]
+	}
+	if len(fields) != 1 {
+		return wrapResponse("❌ 用法：订单原文 <订单号> [--full]"), true, nil
+	}
+	orderNo := fields[0]
+
+	detail, err := f.paymentService.GetOrderDetail(ctx, merchantID, orderNo, paymentservice.OrderNumberTypeAuto)
+	if err != nil {
+		logger.L().Warnf("Sifang order raw failed: merchant_id=%d order_no=%s err=%v", merchantID, orderNo, err)
+		return wrapResponse(fmt.Sprintf("❌ 查询订单失败：%s", html.EscapeString(err.Error()))), true, nil
+	}
+	if detail == nil || detail.Order == nil {
+		return wrapResponse(fmt.Sprintf("ℹ️ 未找到订单 <code>%s</code>", html.EscapeString(orderNo))), true, nil
+	}
+
+	data, err := formatOrderRawJSON(detail, !unmask)
+	if err != nil {
+		return nil, true, err
+	}
+
+	logger.L().Infof("Sifang order raw viewed: merchant_id=%d order_no=%s user_id=%d unmask=%v", merchantID, orderNo, msg.From.ID, unmask)
+	if utf8.RuneCount(data) > orderRawInlineLimit {
+		return &types.Response{
+			Text: fmt.Sprintf("📄 订单原文 %s", html.EscapeString(orderNo)),
+			Document: &types.Document{
+				Filename: fmt.Sprintf("order_%s.json", orderNo),
+				Data:     bytes.NewReader(data),
+			},
+		}, true, nil
+	}
+	return wrapResponse(fmt.Sprintf("📄 订单原文 <code>%s</code>\n<pre><code class=\"language-json\">%s</code></pre>",
+		html.EscapeString(orderNo), html.EscapeString(string(data)))), true, nil
+}
+
+// formatOrderRawJSON 将订单详情（含 Extra 中的未知字段）格式化为 JSON，redact 时对敏感字段脱敏
+func formatOrderRawJSON(detail *paymentservice.OrderDetail, redact bool) ([]byte, error) {
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	if redact {
+		redactOrderRawFields(payload)
+	}
+	return json.MarshalIndent(payload, "", "  ")
+}
+
+// redactOrderRawFields 递归脱敏敏感字段，仅保留末 4 位
+func redactOrderRawFields(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if str, ok := item.(string); ok && str != "" && isOrderRawSensitiveKey(key) {
+				v[key] = maskOrderRawValue(str)
+				continue
+			}
+			redactOrderRawFields(item)
+		}
+	case []any:
+		for _, item := range v {
+			redactOrderRawFields(item)
+		}
+	}
+}
+
+func isOrderRawSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, fragment := range orderRawSensitiveKeys {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskOrderRawValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return "****" + string(runes[len(runes)-4:])
+}
@@ -0,0 +1,58 @@
+package sifang
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func newOrderRawTestFeature(isOwner bool) *Feature {
+	return &Feature{
+		paymentService: &fakePaymentService{
+			orderDetailResp: &paymentservice.OrderDetail{
+				Order: &paymentservice.Order{
+					MerchantOrderNo: "M-001",
+					Amount:          "100.00",
+					BankAccount:     "6222020200001234",
+					Extra:           map[string]string{"payer_card_no": "6217000011112222", "risk_level": "low"},
+				},
+			},
+		},
+		userService: &stubUserService{isOwner: isOwner},
+	}
+}
+
+func TestHandleOrderRawIncludesExtraAndRedacts(t *testing.T) {
+	msg := &botModels.Message{From: &botModels.User{ID: 1}, Chat: botModels.Chat{ID: -1001}}
+
+	resp, handled, err := newOrderRawTestFeature(true).handleOrderRaw(context.Background(), msg, 1001, "订单原文 M-001")
+	if err != nil || !handled {
+		t.Fatalf("unexpected result: handled=%v err=%v", handled, err)
+	}
+	for _, want := range []string{`&#34;risk_level&#34;: &#34;low&#34;`, `&#34;bank_account&#34;: &#34;****1234&#34;`, `&#34;payer_card_no&#34;: &#34;****2222&#34;`, `&#34;amount&#34;: &#34;100.00&#34;`} {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected %s in response:\n%s", want, resp.Text)
+		}
+	}
+	if strings.Contains(resp.Text, "6222020200001234") || strings.Contains(resp.Text, "6217000011112222") {
+		t.Fatalf("sensitive values must be redacted by default:\n%s", resp.Text)
+	}
+
+	resp, _, _ = newOrderRawTestFeature(true).handleOrderRaw(context.Background(), msg, 1001, "订单原文 M-001 --full")
+	if !strings.Contains(resp.Text, "6222020200001234") {
+		t.Fatalf("expected --full to keep raw values:\n%s", resp.Text)
+	}
+}
+
+func TestHandleOrderRawRequiresOwner(t *testing.T) {
+	msg := &botModels.Message{From: &botModels.User{ID: 2}, Chat: botModels.Chat{ID: -1001}}
+
+	resp, handled, _ := newOrderRawTestFeature(false).handleOrderRaw(context.Background(), msg, 1001, "订单原文 M-001")
+	if !handled || !strings.Contains(resp.Text, "仅 Owner") {
+		t.Fatalf("expected owner-only rejection, got %+v", resp)
+	}
+}
@@ -0,0 +1,86 @@
+package sifang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/features/types"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const rawResponseCommand = "原始响应"
+
+func isRawResponseCommand(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) > 0 && fields[0] == rawResponseCommand
+}
+
+// handleRawResponse 处理 原始响应 [商户号]，查看该商户最近一次解码失败或结果为空时留存的上游响应（仅 Owner）
+func (f *Feature) handleRawResponse(ctx context.Context, msg *botModels.Message, merchantID int64, text string) (*types.Response, bool, error) {
+	if f.userService == nil {
+		logger.L().Error("Sifang raw response: user service is nil")
+		return wrapResponse("❌ 未配置管理员校验服务，请联系管理员"), true, nil
+	}
+
+	isOwner, err := f.userService.CheckOwnerPermission(ctx, msg.From.ID)
+	if err != nil {
+		logger.L().Errorf("Sifang raw response owner check failed: user_id=%d, err=%v", msg.From.ID, err)
+		return wrapResponse("❌ 权限检查失败，请稍后重试"), true, nil
+	}
+	if !isOwner {
+		return wrapResponse("❌ 仅 Owner 可以查看原始响应"), true, nil
+	}
+
+	fields := strings.Fields(text)[1:]
+	switch len(fields) {
+	case 0:
+	case 1:
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || id <= 0 {
+			return wrapResponse("❌ 用法：原始响应 [商户号]"), true, nil
+		}
+		merchantID = id
+	default:
+		return wrapResponse("❌ 用法：原始响应 [商户号]"), true, nil
+	}
+
+	source, ok := f.paymentService.(paymentservice.RawResponseSource)
+	if !ok || !source.RawCaptureEnabled() {
+		return wrapResponse("ℹ️ 未开启原始响应留存，请设置 SIFANG_RAW_CAPTURE=true 后重试"), true, nil
+	}
+
+	entry, ok := source.LastRawResponse(merchantID)
+	if !ok {
+		return wrapResponse(fmt.Sprintf("ℹ️ 商户 <code>%d</code> 暂无留存的原始响应（仅保留解码失败或结果为空的响应，过期自动清除）", merchantID)), true, nil
+	}
+
+	logger.L().Infof("Sifang raw response viewed: merchant_id=%d action=%s user_id=%d", merchantID, entry.Action, msg.From.ID)
+
+	header := fmt.Sprintf("📄 原始响应 - 商户 <code>%d</code>\n接口：%s\n时间：%s\n原因：%s",
+		merchantID,
+		html.EscapeString(entry.Action),
+		entry.CapturedAt.In(chinaLocation).Format("2006-01-02 15:04:05"),
+		html.EscapeString(entry.Reason))
+	if entry.Truncated {
+		header += "\n⚠️ 响应过长，已截断"
+	}
+
+	if utf8.RuneCountInString(entry.Body) > orderRawInlineLimit {
+		return &types.Response{
+			Text: header,
+			Document: &types.Document{
+				Filename: fmt.Sprintf("raw_%d_%s.json", merchantID, entry.Action),
+				Data:     bytes.NewReader([]byte(entry.Body)),
+			},
+		}, true, nil
+	}
+	return wrapResponse(fmt.Sprintf("%s\n<pre><code class=\"language-json\">%s</code></pre>", header, html.EscapeString(entry.Body))), true, nil
+}
@@ -0,0 +1,73 @@
+package sifang
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/payment/sifang"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// rawCapturePaymentService 在 fakePaymentService 基础上提供原始响应留存
+type rawCapturePaymentService struct {
+	*fakePaymentService
+	entries map[int64]*sifang.RawResponse
+}
+
+func (s *rawCapturePaymentService) RawCaptureEnabled() bool { return s.entries != nil }
+
+func (s *rawCapturePaymentService) LastRawResponse(merchantID int64) (*sifang.RawResponse, bool) {
+	entry, ok := s.entries[merchantID]
+	return entry, ok
+}
+
+func TestHandleRawResponseShowsLastCapture(t *testing.T) {
+	svc := &rawCapturePaymentService{
+		fakePaymentService: &fakePaymentService{},
+		entries: map[int64]*sifang.RawResponse{
+			2002: {
+				Action:     "orderdetail",
+				MerchantID: 2002,
+				Reason:     "order detail is empty (merchant number)",
+				Body:       `{"status":"pending"}`,
+				CapturedAt: time.Date(2024, 10, 26, 2, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	feature := &Feature{paymentService: svc, userService: &stubUserService{isOwner: true}}
+	msg := &botModels.Message{From: &botModels.User{ID: 1}, Chat: botModels.Chat{ID: -1001}}
+
+	resp, handled, err := feature.handleRawResponse(context.Background(), msg, 1001, "原始响应 2002")
+	if err != nil || !handled {
+		t.Fatalf("unexpected result: handled=%v err=%v", handled, err)
+	}
+	for _, want := range []string{"商户 <code>2002</code>", "接口：orderdetail", "时间：2024-10-26 10:00:00", "order detail is empty", "&#34;status&#34;:&#34;pending&#34;"} {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected %q in response:\n%s", want, resp.Text)
+		}
+	}
+
+	resp, _, _ = feature.handleRawResponse(context.Background(), msg, 1001, "原始响应")
+	if !strings.Contains(resp.Text, "暂无留存") {
+		t.Fatalf("expected empty notice for group merchant, got %s", resp.Text)
+	}
+}
+
+func TestHandleRawResponseRequiresOwnerAndCapture(t *testing.T) {
+	msg := &botModels.Message{From: &botModels.User{ID: 2}, Chat: botModels.Chat{ID: -1001}}
+
+	feature := &Feature{paymentService: &fakePaymentService{}, userService: &stubUserService{}}
+	resp, handled, _ := feature.handleRawResponse(context.Background(), msg, 1001, "原始响应")
+	if !handled || !strings.Contains(resp.Text, "仅 Owner") {
+		t.Fatalf("expected owner-only rejection, got %+v", resp)
+	}
+
+	feature.userService = &stubUserService{isOwner: true}
+	resp, _, _ = feature.handleRawResponse(context.Background(), msg, 1001, "原始响应")
+	if !strings.Contains(resp.Text, "SIFANG_RAW_CAPTURE") {
+		t.Fatalf("expected capture disabled hint, got %s", resp.Text)
+	}
+}
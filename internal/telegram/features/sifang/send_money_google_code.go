@@ -0,0 +1,90 @@
+package sifang
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/features/types"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+var googleCodeReplyRegexp = regexp.MustCompile(`^\d{6}$`)
+
+// promptSendMoneyGoogleCode 群组要求谷歌验证码而下发未附带时，保留待确认下发并提示发起人回复验证码
+func (f *Feature) promptSendMoneyGoogleCode(pending *pendingSendMoney, quote *sendMoneyQuote) *types.Response {
+	f.mu.Lock()
+	pending.awaitingCode = true
+	f.mu.Unlock()
+
+	logger.L().Infof("Sifang send money awaiting google code: merchant_id=%d, user_id=%d, amount=%.2f, token=%s",
+		pending.merchantID, pending.userID, pending.amount, pending.token)
+
+	message := buildSendMoneyConfirmationMessage(pending.merchantID, pending.amount, quote)
+	message += fmt.Sprintf("\n\n🔐 本群下发需谷歌验证码，请在 %d 秒内直接回复 6 位验证码", int(SendMoneyConfirmTTL/time.Second))
+	return &types.Response{
+		Text: message,
+		ReplyMarkup: &botModels.InlineKeyboardMarkup{InlineKeyboard: [][]botModels.InlineKeyboardButton{
+			{{Text: "❌取消", CallbackData: sendMoneyCallbackData(sendMoneyActionCancel, pending.token)}},
+		}},
+	}
+}
+
+// isAwaitingGoogleCode 判断消息是否为发起人对待验证下发回复的 6 位谷歌验证码
+func (f *Feature) isAwaitingGoogleCode(msg *botModels.Message, text string) bool {
+	if msg.From == nil || !googleCodeReplyRegexp.MatchString(text) {
+		return false
+	}
+	_, ok := f.findAwaitingGoogleCode(msg.Chat.ID, msg.From.ID)
+	return ok
+}
+
+func (f *Feature) findAwaitingGoogleCode(chatID, userID int64) (*pendingSendMoney, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cleanupExpiredLocked(time.Now())
+
+	var latest *pendingSendMoney
+	for _, pending := range f.pending {
+		if !pending.awaitingCode || pending.chatID != chatID || pending.userID != userID {
+			continue
+		}
+		if latest == nil || pending.createdAt.After(latest.createdAt) {
+			latest = pending
+		}
+	}
+	return latest, latest != nil
+}
+
+// handleSendMoneyGoogleCode 收到验证码后以新的确认请求替换等待中的下发，确认后随下发一并提交（验证码不写日志）；
+// 验证码已收取，响应要求删除用户的验证码消息，避免其留在群聊记录中
+func (f *Feature) handleSendMoneyGoogleCode(ctx context.Context, msg *botModels.Message, text string) (*types.Response, bool, error) {
+	awaiting, ok := f.findAwaitingGoogleCode(msg.Chat.ID, msg.From.ID)
+	if !ok {
+		return nil, false, nil
+	}
+	f.deletePending(awaiting.token)
+
+	pending, err := f.createPendingSend(awaiting.chatID, awaiting.userID, awaiting.merchantID, awaiting.amount, strings.TrimSpace(text))
+	if err != nil {
+		logger.L().Errorf("Sifang create pending send failed: chat_id=%d, user_id=%d, err=%v", msg.Chat.ID, msg.From.ID, err)
+		resp := wrapResponse("❌ 创建下发确认状态失败，请稍后重试")
+		resp.DeleteTrigger = true
+		return resp, true, nil
+	}
+	pending.quote = awaiting.quote
+
+	logger.L().Infof("Sifang send money google code received: merchant_id=%d, user_id=%d, amount=%.2f, token=%s",
+		pending.merchantID, pending.userID, pending.amount, pending.token)
+
+	message := fmt.Sprintf("是否确认下发 %s 元 | %d\n🔐 将附带当前谷歌验证码", formatFloat(pending.amount), pending.merchantID)
+	return &types.Response{
+		Text:          message,
+		ReplyMarkup:   buildSendMoneyKeyboard(pending.token),
+		DeleteTrigger: true,
+	}, true, nil
+}
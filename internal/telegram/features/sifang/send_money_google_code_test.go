@@ -0,0 +1,120 @@
+package sifang
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	cryptofeature "go_bot/internal/telegram/features/crypto"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func sendMoneyGoogleCodeTestMessage(text string) *botModels.Message {
+	return &botModels.Message{
+		Chat: botModels.Chat{ID: -1, Type: "group"},
+		From: &botModels.User{ID: 123},
+		Text: text,
+	}
+}
+
+func onlyPendingToken(t *testing.T, feature *Feature) string {
+	t.Helper()
+	if len(feature.pending) != 1 {
+		t.Fatalf("expected exactly one pending send, got %d", len(feature.pending))
+	}
+	for token := range feature.pending {
+		return token
+	}
+	return ""
+}
+
+func TestSendMoneyRequiresGoogleCodeWhenPolicyOn(t *testing.T) {
+	ctx := context.Background()
+	fakeSvc := &fakePaymentService{sendMoneyResult: &paymentservice.SendMoneyResult{MerchantID: "2023100"}}
+	feature := New(fakeSvc, &stubUserService{isAdmin: true})
+	group := sendMoneyTestGroup(2023100, cryptofeature.DefaultFloatRate)
+	group.Settings.SifangEnabled = true
+	group.Settings.SendMoneyRequireGoogleCode = true
+	query := &botModels.CallbackQuery{
+		From:    botModels.User{ID: 123},
+		Message: botModels.MaybeInaccessibleMessage{Message: &botModels.Message{Chat: botModels.Chat{ID: -1}, ID: 99}},
+	}
+
+	resp, handled, err := feature.Process(ctx, sendMoneyGoogleCodeTestMessage("下发 12"), group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("unexpected result: resp=%v handled=%v err=%v", resp, handled, err)
+	}
+	if !strings.Contains(resp.Text, "请在 60 秒内直接回复 6 位验证码") {
+		t.Fatalf("expected google code prompt, got %s", resp.Text)
+	}
+	awaitingToken := onlyPendingToken(t, feature)
+
+	// 未提交验证码时无法确认下发
+	blocked, err := feature.HandleSendMoneyCallback(ctx, query, sendMoneyActionConfirm, awaitingToken)
+	if err != nil || blocked.ShouldEdit || !blocked.ShowAlert {
+		t.Fatalf("expected confirm without code to be blocked, got %+v err=%v", blocked, err)
+	}
+	if fakeSvc.lastSendAmount != 0 {
+		t.Fatalf("expected no send without code, got amount %.2f", fakeSvc.lastSendAmount)
+	}
+
+	// 其他成员回复的数字不会被当作验证码
+	other := sendMoneyGoogleCodeTestMessage("654321")
+	other.From = &botModels.User{ID: 456}
+	if feature.Match(ctx, other) {
+		t.Fatalf("expected code from another user not to match")
+	}
+
+	codeMsg := sendMoneyGoogleCodeTestMessage("123456")
+	if !feature.Match(ctx, codeMsg) {
+		t.Fatalf("expected google code reply to match")
+	}
+	resp, handled, err = feature.Process(ctx, codeMsg, group)
+	if err != nil || !handled || resp == nil || resp.ReplyMarkup == nil {
+		t.Fatalf("expected confirmation after code, got resp=%v handled=%v err=%v", resp, handled, err)
+	}
+	if !strings.Contains(resp.Text, "是否确认下发 12 元") || strings.Contains(resp.Text, "123456") {
+		t.Fatalf("unexpected confirmation text: %s", resp.Text)
+	}
+	if !resp.DeleteTrigger {
+		t.Fatalf("expected google code message to be deleted after collection")
+	}
+	token := onlyPendingToken(t, feature)
+	if token == awaitingToken {
+		t.Fatalf("expected awaiting request to be replaced")
+	}
+
+	result, err := feature.HandleSendMoneyCallback(ctx, query, sendMoneyActionConfirm, token)
+	if err != nil || !strings.Contains(result.Text, "已成功下发") {
+		t.Fatalf("expected send success, got %+v err=%v", result, err)
+	}
+	if fakeSvc.lastSendAmount != 12 || fakeSvc.lastSendGoogleCode != "123456" {
+		t.Fatalf("expected send of 12 with code, got amount=%.2f code=%q", fakeSvc.lastSendAmount, fakeSvc.lastSendGoogleCode)
+	}
+}
+
+func TestSendMoneyGoogleCodeOptionalWhenPolicyOff(t *testing.T) {
+	ctx := context.Background()
+	fakeSvc := &fakePaymentService{sendMoneyResult: &paymentservice.SendMoneyResult{MerchantID: "2023100"}}
+	feature := New(fakeSvc, &stubUserService{isAdmin: true})
+	group := sendMoneyTestGroup(2023100, cryptofeature.DefaultFloatRate)
+
+	resp, _, err := feature.handleSendMoney(ctx, sendMoneyGoogleCodeTestMessage("下发 12"), group, "下发 12")
+	if err != nil || resp == nil || resp.ReplyMarkup == nil || strings.Contains(resp.Text, "验证码") {
+		t.Fatalf("expected direct confirmation without code, got resp=%v err=%v", resp, err)
+	}
+	if feature.Match(ctx, sendMoneyGoogleCodeTestMessage("123456")) {
+		t.Fatalf("expected bare code not to match when nothing awaits it")
+	}
+
+	query := &botModels.CallbackQuery{
+		From:    botModels.User{ID: 123},
+		Message: botModels.MaybeInaccessibleMessage{Message: &botModels.Message{Chat: botModels.Chat{ID: -1}, ID: 99}},
+	}
+	result, err := feature.HandleSendMoneyCallback(ctx, query, sendMoneyActionConfirm, onlyPendingToken(t, feature))
+	if err != nil || !strings.Contains(result.Text, "已成功下发") || fakeSvc.lastSendGoogleCode != "" {
+		t.Fatalf("expected send without code, got %+v code=%q err=%v", result, fakeSvc.lastSendGoogleCode, err)
+	}
+}
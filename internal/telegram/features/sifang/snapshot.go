@@ -0,0 +1,129 @@
+package sifang
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const snapshotCommand = "快照"
+
+// snapshotFetchTimeout 快照各子查询的超时，避免单个上游接口拖慢整条消息
+const snapshotFetchTimeout = 15 * time.Second
+
+// handleSnapshot 处理"快照"命令，并发查询今日账单、当前余额与通道状态并合并为一条消息；
+// 任一子查询失败只影响对应分区
+func (f *Feature) handleSnapshot(ctx context.Context, merchantID int64) (string, bool, error) {
+	now := f.currentTime().In(chinaLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, chinaLocation)
+
+	fetchCtx, cancel := context.WithTimeout(ctx, snapshotFetchTimeout)
+	defer cancel()
+
+	var (
+		summary     *paymentservice.SummaryByDay
+		summaryErr  error
+		balance     *paymentservice.Balance
+		balanceErr  error
+		statuses    []*paymentservice.ChannelStatus
+		statusesErr error
+	)
+
+	// 子查询各自记录错误并返回 nil，避免一个失败取消其余查询
+	var eg errgroup.Group
+	eg.Go(func() error {
+		summary, summaryErr = f.paymentService.GetSummaryByDay(fetchCtx, merchantID, today)
+		return nil
+	})
+	eg.Go(func() error {
+		balance, balanceErr = f.paymentService.GetBalance(fetchCtx, merchantID, 0)
+		return nil
+	})
+	eg.Go(func() error {
+		statuses, statusesErr = f.paymentService.GetChannelStatus(fetchCtx, merchantID)
+		return nil
+	})
+	_ = eg.Wait()
+
+	if summaryErr != nil {
+		logger.L().Errorf("Sifang snapshot summary failed: merchant_id=%d, err=%v", merchantID, summaryErr)
+	}
+	if balanceErr != nil {
+		logger.L().Errorf("Sifang snapshot balance failed: merchant_id=%d, err=%v", merchantID, balanceErr)
+	}
+	if statusesErr != nil {
+		logger.L().Errorf("Sifang snapshot channel status failed: merchant_id=%d, err=%v", merchantID, statusesErr)
+	}
+
+	logger.L().Infof("Sifang snapshot queried: merchant_id=%d, date=%s", merchantID, today.Format("2006-01-02"))
+	return formatSnapshotMessage(merchantID, today, summary, summaryErr, balance, balanceErr, statuses, statusesErr), true, nil
+}
+
+func formatSnapshotMessage(
+	merchantID int64,
+	date time.Time,
+	summary *paymentservice.SummaryByDay, summaryErr error,
+	balance *paymentservice.Balance, balanceErr error,
+	statuses []*paymentservice.ChannelStatus, statusesErr error,
+) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📸 今日快照 - %s\n", date.Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("商户：<code>%d</code>\n\n", merchantID))
+
+	sb.WriteString("📑 账单\n")
+	switch {
+	case summaryErr != nil:
+		sb.WriteString("⚠️ 账单查询失败\n")
+	case summary == nil:
+		sb.WriteString("暂无账单数据\n")
+	default:
+		sb.WriteString(fmt.Sprintf("跑量：%s\n", html.EscapeString(emptyFallback(summary.TotalAmount, "-"))))
+		sb.WriteString(fmt.Sprintf("成交：%s\n", html.EscapeString(emptyFallback(combineAmounts(summary.MerchantIncome, summary.AgentIncome), "-"))))
+		sb.WriteString(fmt.Sprintf("笔数：%s\n", html.EscapeString(emptyFallback(summary.OrderCount, "-"))))
+	}
+
+	sb.WriteString("\n💰 余额\n")
+	switch {
+	case balanceErr != nil:
+		sb.WriteString("⚠️ 余额查询失败\n")
+	case balance == nil:
+		sb.WriteString("暂无余额数据\n")
+	default:
+		sb.WriteString(fmt.Sprintf("当前余额：%s\n", html.EscapeString(emptyFallback(balance.Balance, "未知"))))
+	}
+
+	sb.WriteString("\n📡 通道\n")
+	if statusesErr != nil {
+		sb.WriteString("⚠️ 通道状态查询失败")
+	} else {
+		enabled, total := countEnabledChannels(statuses)
+		sb.WriteString(fmt.Sprintf("已开启：%d / %d", enabled, total))
+	}
+
+	return sb.String()
+}
+
+// countEnabledChannels 统计系统与商户均开启的通道数，与费率展示一致跳过测试通道
+func countEnabledChannels(statuses []*paymentservice.ChannelStatus) (int, int) {
+	enabled, total := 0, 0
+	for _, item := range statuses {
+		if item == nil {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(strings.TrimSpace(item.ChannelCode)), "test") {
+			continue
+		}
+		total++
+		if item.SystemEnabled && item.MerchantEnabled {
+			enabled++
+		}
+	}
+	return enabled, total
+}
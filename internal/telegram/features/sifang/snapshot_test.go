@@ -0,0 +1,65 @@
+package sifang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+)
+
+func TestProcessSnapshotCombinesAllSections(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceResp: &paymentservice.Balance{MerchantID: "1001", Balance: "8888.00"},
+		channelStatusResp: []*paymentservice.ChannelStatus{
+			{ChannelCode: "ALIPAY", SystemEnabled: true, MerchantEnabled: true},
+			{ChannelCode: "WECHAT", SystemEnabled: true, MerchantEnabled: false},
+			{ChannelCode: "alipaytest", SystemEnabled: true, MerchantEnabled: true},
+		},
+	}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	msg := orderQueryMessage("快照")
+	if !feature.Match(context.Background(), msg) {
+		t.Fatalf("expected 快照 command to match")
+	}
+	resp, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("expected handled snapshot, got resp=%v handled=%v err=%v", resp, handled, err)
+	}
+
+	if len(fake.summaryMerchantIDs) != 1 || fake.balanceCalls != 1 || fake.channelStatusCalls != 1 {
+		t.Fatalf("expected one call per sub-fetch, got summary=%v balance=%d channels=%d",
+			fake.summaryMerchantIDs, fake.balanceCalls, fake.channelStatusCalls)
+	}
+	if fake.lastHistoryDays != 0 {
+		t.Fatalf("expected current balance, got history_days=%d", fake.lastHistoryDays)
+	}
+	for _, want := range []string{"今日快照", "跑量：1000", "笔数：10", "当前余额：8888.00", "已开启：1 / 2"} {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected snapshot to contain %q, got:\n%s", want, resp.Text)
+		}
+	}
+}
+
+func TestProcessSnapshotDegradesOnPartialFailure(t *testing.T) {
+	fake := &fakePaymentService{
+		balanceErr:       errors.New("upstream timeout"),
+		channelStatusErr: errors.New("boom"),
+	}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	resp, handled, err := feature.Process(context.Background(), orderQueryMessage("快照"), group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("expected handled snapshot, got resp=%v handled=%v err=%v", resp, handled, err)
+	}
+	for _, want := range []string{"跑量：1000", "余额查询失败", "通道状态查询失败"} {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected snapshot to contain %q, got:\n%s", want, resp.Text)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package sifang
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+)
+
+const summaryCompareCommand = "对比"
+
+const summaryCompareUsage = "用法：对比 10月25 10月26（前者为基准日）"
+
+// extractSummaryCompareArgs 解析"对比 <基准日> <对比日>"，两个日期均不可省略
+func extractSummaryCompareArgs(text string) (baseRaw, targetRaw string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || fields[0] != summaryCompareCommand {
+		return "", "", false
+	}
+	if len(fields) != 3 || !isValidDateSuffix(fields[1]) || !isValidDateSuffix(fields[2]) {
+		return "", "", false
+	}
+	return fields[1], fields[2], true
+}
+
+// handleSummaryCompare 处理"对比"命令，分别查询两天的账单并展示跑量、笔数、收入的变化
+func (f *Feature) handleSummaryCompare(ctx context.Context, merchantID int64, text string) (string, bool, error) {
+	baseRaw, targetRaw, _ := extractSummaryCompareArgs(text)
+	now := f.currentTime()
+	baseDate, err := parseSummaryDate(baseRaw, now, summaryCompareCommand)
+	if err != nil {
+		return fmt.Sprintf("❌ %v\n%s", err, summaryCompareUsage), true, nil
+	}
+	targetDate, err := parseSummaryDate(targetRaw, now, summaryCompareCommand)
+	if err != nil {
+		return fmt.Sprintf("❌ %v\n%s", err, summaryCompareUsage), true, nil
+	}
+
+	base, err := f.paymentService.GetSummaryByDay(ctx, merchantID, baseDate)
+	if err != nil {
+		logger.L().Errorf("Sifang summary compare query failed: merchant_id=%d, date=%s, err=%v", merchantID, baseDate.Format("2006-01-02"), err)
+		return fmt.Sprintf("❌ 查询 %s 账单失败：%v", baseDate.Format("2006-01-02"), err), true, nil
+	}
+	target, err := f.paymentService.GetSummaryByDay(ctx, merchantID, targetDate)
+	if err != nil {
+		logger.L().Errorf("Sifang summary compare query failed: merchant_id=%d, date=%s, err=%v", merchantID, targetDate.Format("2006-01-02"), err)
+		return fmt.Sprintf("❌ 查询 %s 账单失败：%v", targetDate.Format("2006-01-02"), err), true, nil
+	}
+
+	return formatSummaryComparison(baseDate, targetDate, base, target), true, nil
+}
+
+// summaryMetric 账单中参与对比的一项指标，无数据时按 0 计算
+type summaryMetric struct {
+	label  string
+	base   float64
+	target float64
+}
+
+func summaryCompareMetrics(base, target *paymentservice.SummaryByDay) []summaryMetric {
+	if base == nil {
+		base = &paymentservice.SummaryByDay{}
+	}
+	if target == nil {
+		target = &paymentservice.SummaryByDay{}
+	}
+	amount := func(raw string) float64 {
+		value, _ := parseAmountToFloat(strings.TrimSpace(raw))
+		return value
+	}
+	return []summaryMetric{
+		{label: "跑量", base: amount(base.TotalAmount), target: amount(target.TotalAmount)},
+		{label: "笔数", base: amount(base.OrderCount), target: amount(target.OrderCount)},
+		{label: "收入", base: amount(combineAmounts(base.MerchantIncome, base.AgentIncome)), target: amount(combineAmounts(target.MerchantIncome, target.AgentIncome))},
+	}
+}
+
+func formatSummaryComparison(baseDate, targetDate time.Time, base, target *paymentservice.SummaryByDay) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 账单对比 - %s → %s\n", baseDate.Format("2006-01-02"), targetDate.Format("2006-01-02")))
+	for _, metric := range summaryCompareMetrics(base, target) {
+		sb.WriteString(fmt.Sprintf("%s：%s → %s（%s）\n",
+			metric.label, formatFloat(metric.base), formatFloat(metric.target), formatSummaryDelta(metric.base, metric.target)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatSummaryDelta 返回"变化量，变化率"；基准为 0 时无法计算比例，显示"新增"
+func formatSummaryDelta(base, target float64) string {
+	delta := roundToTwoDecimals(target - base)
+	if delta == 0 {
+		return "持平"
+	}
+
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+	}
+	change := sign + formatFloat(math.Abs(delta))
+	if base == 0 {
+		return change + "，新增"
+	}
+	return fmt.Sprintf("%s，%s%.2f%%", change, sign, math.Abs(delta)/math.Abs(base)*100)
+}
@@ -0,0 +1,92 @@
+package sifang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+)
+
+func TestFormatSummaryDelta(t *testing.T) {
+	cases := []struct {
+		name         string
+		base, target float64
+		want         string
+	}{
+		{name: "increase", base: 1000, target: 1250, want: "+250，+25.00%"},
+		{name: "decrease", base: 800, target: 600, want: "-200，-25.00%"},
+		{name: "fractional", base: 3, target: 4, want: "+1，+33.33%"},
+		{name: "unchanged", base: 500, target: 500, want: "持平"},
+		{name: "zero baseline", base: 0, target: 300, want: "+300，新增"},
+		{name: "both zero", base: 0, target: 0, want: "持平"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatSummaryDelta(tc.base, tc.target); got != tc.want {
+				t.Fatalf("formatSummaryDelta(%v, %v) = %q, want %q", tc.base, tc.target, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractSummaryCompareArgs(t *testing.T) {
+	base, target, ok := extractSummaryCompareArgs("对比 10月25 10月26")
+	if !ok || base != "10月25" || target != "10月26" {
+		t.Fatalf("unexpected args: base=%q target=%q ok=%v", base, target, ok)
+	}
+	for _, text := range []string{"对比", "对比 10月25", "对比 10月25 abc", "对比一下 10月25 10月26"} {
+		if _, _, ok := extractSummaryCompareArgs(text); ok {
+			t.Fatalf("expected %q not to match", text)
+		}
+	}
+}
+
+func TestProcessSummaryCompareRendersDeltas(t *testing.T) {
+	fake := &fakePaymentService{
+		summaryByDate: map[string]*paymentservice.SummaryByDay{
+			"2024-10-25": {TotalAmount: "1000", OrderCount: "0", MerchantIncome: "900", AgentIncome: "100"},
+			"2024-10-26": {TotalAmount: "1,500.50", OrderCount: "12", MerchantIncome: "800", AgentIncome: "50"},
+		},
+	}
+	feature := New(fake, &stubUserService{})
+	feature.nowFunc = func() time.Time { return time.Date(2024, 10, 27, 12, 0, 0, 0, chinaLocation) }
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	msg := orderQueryMessage("对比 10月25 10月26")
+	if !feature.Match(context.Background(), msg) {
+		t.Fatalf("expected 对比 command to match")
+	}
+	resp, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("expected handled compare, got resp=%v handled=%v err=%v", resp, handled, err)
+	}
+
+	for _, want := range []string{
+		"账单对比 - 2024-10-25 → 2024-10-26",
+		"跑量：1000 → 1500.50（+500.50，+50.05%）",
+		"笔数：0 → 12（+12，新增）",
+		"收入：1000 → 850（-150，-15.00%）",
+	} {
+		if !strings.Contains(resp.Text, want) {
+			t.Fatalf("expected compare to contain %q, got:\n%s", want, resp.Text)
+		}
+	}
+}
+
+func TestProcessSummaryCompareReportsQueryFailure(t *testing.T) {
+	fake := &fakePaymentService{summaryErr: errors.New("upstream down")}
+	feature := New(fake, &stubUserService{})
+	group := &models.Group{Settings: models.GroupSettings{SifangEnabled: true, MerchantID: 1001}}
+
+	resp, handled, err := feature.Process(context.Background(), orderQueryMessage("对比 10月25 10月26"), group)
+	if err != nil || !handled || resp == nil {
+		t.Fatalf("expected handled compare, got resp=%v handled=%v err=%v", resp, handled, err)
+	}
+	if !strings.HasPrefix(resp.Text, "❌ 查询") || !strings.Contains(resp.Text, "upstream down") {
+		t.Fatalf("expected query failure message, got %s", resp.Text)
+	}
+}
@@ -0,0 +1,115 @@
+package sifang
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/features/types"
+)
+
+const (
+	withdrawExportCommand     = "导出提款"
+	withdrawExportCommandFull = "导出提款明细"
+
+	// utf8BOM 写在 CSV 开头，保证 Excel 按 UTF-8 打开
+	utf8BOM = "\ufeff"
+)
+
+var withdrawExportHeader = []string{"withdrawNo", "orderNo", "amount", "fee", "status", "createdAt", "paidAt", "channel"}
+
+// withdrawExportDateSuffix 提取「导出提款明细/导出提款」后的日期参数
+func withdrawExportDateSuffix(text string) (string, bool) {
+	for _, prefix := range []string{withdrawExportCommandFull, withdrawExportCommand} {
+		if suffix, ok := extractDateSuffix(text, prefix); ok {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+// handleWithdrawExport 自动翻页拉取指定日期的全部提款记录，以 CSV 文件发送
+func (f *Feature) handleWithdrawExport(ctx context.Context, merchantID int64, suffix string) (*types.Response, bool, error) {
+	now := f.currentTime()
+	targetDate, err := parseSummaryDate(suffix, now, withdrawExportCommand)
+	if err != nil {
+		return wrapResponse(fmt.Sprintf("❌ %v", err)), true, nil
+	}
+
+	date := targetDate.Format("2006-01-02")
+	start := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
+	end := start.Add(24*time.Hour - time.Second)
+
+	var buf bytes.Buffer
+	writer, err := newWithdrawCSVWriter(&buf)
+	if err != nil {
+		return nil, true, err
+	}
+	if err := paymentservice.EachWithdraw(ctx, f.paymentService, merchantID, start, end, writer.Write); err != nil {
+		logger.L().Errorf("Sifang withdraw export failed: merchant_id=%d, date=%s, err=%v", merchantID, date, err)
+		return wrapResponse(fmt.Sprintf("❌ 导出提款明细失败：%v", err)), true, nil
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, true, err
+	}
+
+	if writer.Count() == 0 {
+		return wrapResponse(fmt.Sprintf("ℹ️ %s 暂无提款记录", date)), true, nil
+	}
+
+	logger.L().Infof("Sifang withdraw exported: merchant_id=%d, date=%s, count=%d", merchantID, date, writer.Count())
+	return &types.Response{
+		Text: fmt.Sprintf("📤 提款明细导出 - %s\n共 %d 条", date, writer.Count()),
+		Document: &types.Document{
+			Filename: fmt.Sprintf("withdraws_%d_%s.csv", merchantID, date),
+			Data:     &buf,
+		},
+	}, true, nil
+}
+
+// withdrawCSVWriter 逐条写入提款记录，翻页结果直接落到输出，不在内存中累积记录
+type withdrawCSVWriter struct {
+	csv   *csv.Writer
+	count int
+}
+
+// newWithdrawCSVWriter 写入 BOM 与表头
+func newWithdrawCSVWriter(w io.Writer) (*withdrawCSVWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	writer := &withdrawCSVWriter{csv: csv.NewWriter(w)}
+	if err := writer.csv.Write(withdrawExportHeader); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *withdrawCSVWriter) Write(item *paymentservice.Withdraw) error {
+	w.count++
+	return w.csv.Write([]string{
+		strings.TrimSpace(item.WithdrawNo),
+		strings.TrimSpace(item.OrderNo),
+		strings.TrimSpace(item.Amount),
+		strings.TrimSpace(item.Fee),
+		strings.TrimSpace(item.Status),
+		strings.TrimSpace(item.CreatedAt),
+		strings.TrimSpace(item.PaidAt),
+		strings.TrimSpace(item.Channel),
+	})
+}
+
+func (w *withdrawCSVWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *withdrawCSVWriter) Count() int {
+	return w.count
+}
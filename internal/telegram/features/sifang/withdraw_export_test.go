@@ -0,0 +1,91 @@
+package sifang
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+)
+
+func TestWithdrawCSVWriterFormatsRows(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := newWithdrawCSVWriter(&buf)
+	if err != nil {
+		t.Fatalf("newWithdrawCSVWriter returned error: %v", err)
+	}
+
+	items := []*paymentservice.Withdraw{
+		{WithdrawNo: "W1", OrderNo: "O1", Amount: "100.00", Fee: "1.00", Status: "success", CreatedAt: "2024-10-26 10:00:00", PaidAt: "2024-10-26 10:05:00", Channel: "银行卡"},
+		{WithdrawNo: "W2", OrderNo: "O2", Amount: "50.00", Fee: "0.50", Status: "processing", CreatedAt: "2024-10-26 11:00:00", PaidAt: "", Channel: "支付宝, 备用"},
+	}
+	for _, item := range items {
+		if err := writer.Write(item); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	want := "\ufeff" +
+		"withdrawNo,orderNo,amount,fee,status,createdAt,paidAt,channel\n" +
+		"W1,O1,100.00,1.00,success,2024-10-26 10:00:00,2024-10-26 10:05:00,银行卡\n" +
+		"W2,O2,50.00,0.50,processing,2024-10-26 11:00:00,,\"支付宝, 备用\"\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected csv:\n%q\nwant:\n%q", buf.String(), want)
+	}
+	if writer.Count() != 2 {
+		t.Fatalf("expected count 2, got %d", writer.Count())
+	}
+}
+
+func TestHandleWithdrawExportSendsDocument(t *testing.T) {
+	fake := &fakePaymentService{
+		withdrawResp: &paymentservice.WithdrawList{
+			Page:       1,
+			TotalPages: 1,
+			Items: []*paymentservice.Withdraw{
+				{WithdrawNo: "W1", Amount: "100.00", Status: "processing"},
+			},
+		},
+	}
+	feature := &Feature{paymentService: fake}
+
+	resp, handled, err := feature.handleWithdrawExport(context.Background(), 1001, "2024-10-26")
+	if err != nil || !handled {
+		t.Fatalf("unexpected result: handled=%v err=%v", handled, err)
+	}
+	if resp.Document == nil {
+		t.Fatalf("expected document response, got %+v", resp)
+	}
+	if resp.Document.Filename != "withdraws_1001_2024-10-26.csv" {
+		t.Fatalf("unexpected filename: %s", resp.Document.Filename)
+	}
+	data, _ := io.ReadAll(resp.Document.Data)
+	if !strings.HasPrefix(string(data), "\ufeffwithdrawNo,") || !strings.Contains(string(data), "W1,,100.00,,processing,,,") {
+		t.Fatalf("unexpected csv: %q", data)
+	}
+	if !strings.Contains(resp.Text, "共 1 条") {
+		t.Fatalf("unexpected caption: %s", resp.Text)
+	}
+
+	empty := &Feature{paymentService: &fakePaymentService{}}
+	resp, _, _ = empty.handleWithdrawExport(context.Background(), 1001, "2024-10-26")
+	if resp.Document != nil || !strings.Contains(resp.Text, "暂无提款记录") {
+		t.Fatalf("expected empty notice, got %+v", resp)
+	}
+}
+
+func TestWithdrawExportDateSuffix(t *testing.T) {
+	for _, text := range []string{"导出提款", "导出提款10月26", "导出提款明细 2024-10-26"} {
+		if _, ok := withdrawExportDateSuffix(text); !ok {
+			t.Fatalf("expected %q to match", text)
+		}
+	}
+	if _, ok := withdrawExportDateSuffix("导出提款记录吧"); ok {
+		t.Fatal("plain chat must not match")
+	}
+}
@@ -1,11 +1,33 @@
 package types
 
-import botModels "github.com/go-telegram/bot/models"
+import (
+	"io"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// ParseModePlain 表示按纯文本发送，不做任何标记解析
+const ParseModePlain botModels.ParseMode = "plain"
+
+// PaymentNotConfiguredText 支付服务未配置时统一返回给用户的提示
+const PaymentNotConfiguredText = "❌ 未配置支付服务，请联系管理员"
 
 // Response 表示功能输出内容。
-// Text 按 HTML 解析，ReplyMarkup 用于附加按钮等交互组件。
+// Text 默认按 HTML 解析，ReplyMarkup 用于附加按钮等交互组件。
 type Response struct {
-	Text        string
-	ReplyMarkup botModels.ReplyMarkup
-	Temporary   bool // 标记为临时消息时由 handler 发送后自动删除
+	Text          string
+	ReplyMarkup   botModels.ReplyMarkup
+	Temporary     bool                // 标记为临时消息时由 handler 发送后自动删除
+	ParseMode     botModels.ParseMode // 为空时按 HTML 发送，ParseModePlain 表示纯文本
+	Document      *Document           // 非空时以文件发送，Text 作为文件说明
+	DeleteTrigger bool                // 处理后尽力删除触发消息（如用户回复的验证码），删除失败时忽略
 }
+
+// Document 以文件形式发送的功能输出（如 CSV 导出）
+type Document struct {
+	Filename string
+	Data     io.Reader
+}
+
+// GroupChatTypes 仅在群组与超级群中运行的功能声明的聊天类型（见 features.ChatTypeAwareFeature）
+var GroupChatTypes = []botModels.ChatType{botModels.ChatTypeGroup, botModels.ChatTypeSupergroup}
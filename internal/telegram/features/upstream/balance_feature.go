@@ -22,6 +22,9 @@ var (
 	setAlertLimitPrefix        = "/set_balance_alert_limit"
 )
 
+// balanceQueryAlias 上游群发送「余额」时查询上游余额，而不是走四方商户余额
+const balanceQueryAlias = "余额"
+
 // BalanceFeature 处理上游余额相关命令
 type BalanceFeature struct {
 	balanceService service.UpstreamBalanceService
@@ -57,6 +60,14 @@ func (f *BalanceFeature) Enabled(ctx context.Context, group *models.Group) bool
 	return len(group.Settings.InterfaceBindings) > 0
 }
 
+// EnabledReason 未绑定上游接口时说明原因
+func (f *BalanceFeature) EnabledReason(ctx context.Context, group *models.Group) (bool, string) {
+	if len(group.Settings.InterfaceBindings) == 0 {
+		return false, "未绑定上游接口，请先绑定接口 ID"
+	}
+	return true, ""
+}
+
 // Match 匹配余额相关指令
 func (f *BalanceFeature) Match(ctx context.Context, msg *botModels.Message) bool {
 	if msg == nil || msg.Text == "" {
@@ -64,13 +75,15 @@ func (f *BalanceFeature) Match(ctx context.Context, msg *botModels.Message) bool
 	}
 	text := strings.TrimSpace(msg.Text)
 	switch {
-	case strings.HasPrefix(text, "/余额"):
+	case strings.HasPrefix(text, "/余额"), text == balanceQueryAlias:
 		return true
 	case strings.HasPrefix(text, setMinBalanceCommandPrefix):
 		return true
 	case strings.HasPrefix(text, setAlertLimitPrefix):
 		return true
-	case text == "/日结":
+	case isSettlementCommand(text):
+		return true
+	case isSettlementCorrectionCommand(text):
 		return true
 	default:
 		return adjustCommandPattern.MatchString(text)
@@ -83,18 +96,17 @@ func (f *BalanceFeature) Process(ctx context.Context, msg *botModels.Message, gr
 		return nil, false, nil
 	}
 
-	isAdmin, err := f.userService.CheckAdminPermission(ctx, msg.From.ID)
-	if err != nil {
-		logger.L().Errorf("Failed to check admin permission: user_id=%d err=%v", msg.From.ID, err)
-		return respond("❌ 权限检查失败"), true, nil
-	}
-	if !isAdmin {
-		return respond("❌ 仅管理员可以操作余额"), true, nil
+	if err := service.CheckFundOperation(ctx, f.userService, msg.From.ID, group); err != nil {
+		logger.L().Warnf("Upstream balance command rejected: user_id=%d chat_id=%d reason=%v", msg.From.ID, msg.Chat.ID, err)
+		return respond(fmt.Sprintf("❌ %v", err)), true, nil
 	}
 
 	text := strings.TrimSpace(msg.Text)
 	switch {
-	case strings.HasPrefix(text, "/余额"):
+	case strings.HasPrefix(text, balanceHistoryCommand):
+		page := f.BalanceHistory(ctx, msg.Chat.ID, text)
+		return &types.Response{Text: page.Text, ReplyMarkup: page.Markup}, true, nil
+	case strings.HasPrefix(text, "/余额"), text == balanceQueryAlias:
 		resp, handlerErr := f.handleQueryBalance(ctx, msg)
 		return respond(resp), true, handlerErr
 	case strings.HasPrefix(text, setMinBalanceCommandPrefix):
@@ -103,9 +115,11 @@ func (f *BalanceFeature) Process(ctx context.Context, msg *botModels.Message, gr
 	case strings.HasPrefix(text, setAlertLimitPrefix):
 		resp, handlerErr := f.handleSetAlertLimit(ctx, msg, text)
 		return respond(resp), true, handlerErr
-	case text == "/日结":
-		resp, handlerErr := f.handleSettlement(ctx, msg)
-		return respond(resp), true, handlerErr
+	case isSettlementCommand(text):
+		prompt := f.PrepareSettlement(ctx, msg.Chat.ID, text)
+		return &types.Response{Text: prompt.Text, ReplyMarkup: prompt.Markup}, true, nil
+	case isSettlementCorrectionCommand(text):
+		return respond(f.handleSettlementCorrection(ctx, msg, text)), true, nil
 	default:
 		if adjustCommandPattern.MatchString(text) {
 			resp, handlerErr := f.handleAdjust(ctx, msg, text)
@@ -182,20 +196,6 @@ func (f *BalanceFeature) handleSetAlertLimit(ctx context.Context, msg *botModels
 	return fmt.Sprintf("✅ 告警频率已更新为 每小时 %d 次\n当前余额：%s CNY", result.AlertLimitPerHour, formatAmount(result.Balance)), nil
 }
 
-func (f *BalanceFeature) handleSettlement(ctx context.Context, msg *botModels.Message) (string, error) {
-	now := f.currentTime()
-	target := previousBillingDate(now, upstreamChinaLocation)
-	operationID := fmt.Sprintf("settle:%s", target.Format("2006-01-02"))
-
-	result, err := f.balanceService.SettleDaily(ctx, msg.Chat.ID, target, msg.From.ID, operationID)
-	if err != nil {
-		logger.L().Errorf("Manual settlement failed: chat_id=%d err=%v", msg.Chat.ID, err)
-		return fmt.Sprintf("❌ 日结失败：%v", err), nil
-	}
-
-	return result.Report, nil
-}
-
 func (f *BalanceFeature) handleAdjust(ctx context.Context, msg *botModels.Message, text string) (string, error) {
 	matches := adjustCommandPattern.FindStringSubmatch(text)
 	if len(matches) < 3 {
@@ -260,7 +260,7 @@ func parseAmount(raw string) (float64, error) {
 }
 
 func formatAmount(value float64) string {
-	return fmt.Sprintf("%.2f", value)
+	return models.FormatMoney(value, models.CurrencyCNY)
 }
 
 func previousBillingDate(now time.Time, location *time.Location) time.Time {
@@ -0,0 +1,247 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	balanceHistoryCommand  = "/余额历史"
+	balanceHistoryPageSize = 10
+	balanceHistoryAllDates = "all"
+
+	// BalanceHistoryCallbackPrefix 余额历史翻页回调前缀
+	BalanceHistoryCallbackPrefix = "balance_log:"
+)
+
+// BalanceHistoryPage 余额历史单页渲染结果
+type BalanceHistoryPage struct {
+	Text   string
+	Markup botModels.ReplyMarkup
+}
+
+// BalanceHistory 处理 /余额历史 [YYYY-MM-DD] 命令，返回第一页
+func (f *BalanceFeature) BalanceHistory(ctx context.Context, chatID int64, text string) *BalanceHistoryPage {
+	dateArg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), balanceHistoryCommand))
+	if dateArg != "" {
+		if _, err := parseHistoryDate(dateArg); err != nil {
+			return &BalanceHistoryPage{Text: "❌ 用法：/余额历史 [YYYY-MM-DD]"}
+		}
+	}
+	return f.renderBalanceHistory(ctx, chatID, dateArg, 1)
+}
+
+// HandleBalanceHistoryCallback 处理余额历史翻页回调
+func (f *BalanceFeature) HandleBalanceHistoryCallback(ctx context.Context, query *botModels.CallbackQuery) (*BalanceHistoryPage, string, error) {
+	if query == nil || query.Message.Message == nil {
+		return nil, "无效的操作", nil
+	}
+
+	isAdmin, err := f.userService.CheckAdminPermission(ctx, query.From.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("check admin permission: %w", err)
+	}
+	if !isAdmin {
+		return nil, "仅管理员可以查看余额历史", nil
+	}
+
+	dateArg, page, ok := parseBalanceHistoryCallback(query.Data)
+	if !ok {
+		return nil, "无效的操作", nil
+	}
+
+	return f.renderBalanceHistory(ctx, query.Message.Message.Chat.ID, dateArg, page), "", nil
+}
+
+func (f *BalanceFeature) renderBalanceHistory(ctx context.Context, chatID int64, dateArg string, page int) *BalanceHistoryPage {
+	if page < 1 {
+		page = 1
+	}
+
+	var start, end time.Time
+	if dateArg != "" {
+		day, err := parseHistoryDate(dateArg)
+		if err != nil {
+			return &BalanceHistoryPage{Text: "❌ 日期格式错误"}
+		}
+		start = day
+		end = day.AddDate(0, 0, 1)
+	}
+
+	skip := int64((page - 1) * balanceHistoryPageSize)
+	// 多取一条用于判断是否存在下一页
+	logs, err := f.balanceService.ListLogs(ctx, chatID, start, end, skip, balanceHistoryPageSize+1)
+	if err != nil {
+		logger.L().Errorf("List balance logs failed: chat_id=%d date=%s page=%d err=%v", chatID, dateArg, page, err)
+		return &BalanceHistoryPage{Text: "❌ 查询余额历史失败"}
+	}
+
+	hasNext := len(logs) > balanceHistoryPageSize
+	if hasNext {
+		logs = logs[:balanceHistoryPageSize]
+	}
+
+	return &BalanceHistoryPage{
+		Text:   formatBalanceHistory(logs, dateArg, page),
+		Markup: buildBalanceHistoryKeyboard(dateArg, page, hasNext),
+	}
+}
+
+func formatBalanceHistory(logs []*models.UpstreamBalanceLog, dateArg string, page int) string {
+	var sb strings.Builder
+	sb.WriteString("📒 <b>余额历史</b>")
+	if dateArg != "" {
+		sb.WriteString(fmt.Sprintf("（%s）", html.EscapeString(dateArg)))
+	}
+	sb.WriteString(fmt.Sprintf(" 第 %d 页\n", page))
+
+	if len(logs) == 0 {
+		sb.WriteString("\n暂无记录")
+		return sb.String()
+	}
+
+	for _, entry := range groupSettlementCorrections(logs) {
+		log := entry.log
+		prefix := "\n"
+		if entry.nested {
+			prefix = "\n  ↳ "
+		}
+		sb.WriteString(fmt.Sprintf("%s%s %s %s → 余额 %s",
+			prefix,
+			log.CreatedAt.In(upstreamChinaLocation).Format("01-02 15:04"),
+			balanceOpLabel(log.Type),
+			formatSignedAmount(log.Delta),
+			formatAmount(log.Balance),
+		))
+		if remark := strings.TrimSpace(log.Remark); remark != "" {
+			sb.WriteString(fmt.Sprintf("（%s）", html.EscapeString(remark)))
+		}
+	}
+	return sb.String()
+}
+
+type balanceHistoryEntry struct {
+	log    *models.UpstreamBalanceLog
+	nested bool
+}
+
+// groupSettlementCorrections 将日结更正挂在同页对应的日结记录之后；找不到对应日结时按原顺序展示
+func groupSettlementCorrections(logs []*models.UpstreamBalanceLog) []balanceHistoryEntry {
+	settled := make(map[string]bool)
+	for _, log := range logs {
+		if date := log.Metadata[models.BalanceLogSettlementDateKey]; date != "" && log.Type != models.BalanceOpSettlementCorrection {
+			settled[date] = true
+		}
+	}
+
+	corrections := make(map[string][]*models.UpstreamBalanceLog)
+	for _, log := range logs {
+		date := log.Metadata[models.BalanceLogSettlementDateKey]
+		if log.Type == models.BalanceOpSettlementCorrection && settled[date] {
+			corrections[date] = append(corrections[date], log)
+		}
+	}
+
+	entries := make([]balanceHistoryEntry, 0, len(logs))
+	for _, log := range logs {
+		date := log.Metadata[models.BalanceLogSettlementDateKey]
+		if log.Type == models.BalanceOpSettlementCorrection {
+			if !settled[date] {
+				entries = append(entries, balanceHistoryEntry{log: log})
+			}
+			continue
+		}
+		entries = append(entries, balanceHistoryEntry{log: log})
+		if date != "" {
+			for _, correction := range corrections[date] {
+				entries = append(entries, balanceHistoryEntry{log: correction, nested: true})
+			}
+			delete(corrections, date)
+		}
+	}
+	return entries
+}
+
+func buildBalanceHistoryKeyboard(dateArg string, page int, hasNext bool) botModels.ReplyMarkup {
+	var row []botModels.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, botModels.InlineKeyboardButton{
+			Text:         "⬅️ 上一页",
+			CallbackData: balanceHistoryCallbackData(dateArg, page-1),
+		})
+	}
+	if hasNext {
+		row = append(row, botModels.InlineKeyboardButton{
+			Text:         "下一页 ➡️",
+			CallbackData: balanceHistoryCallbackData(dateArg, page+1),
+		})
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	return &botModels.InlineKeyboardMarkup{InlineKeyboard: [][]botModels.InlineKeyboardButton{row}}
+}
+
+func balanceHistoryCallbackData(dateArg string, page int) string {
+	if dateArg == "" {
+		dateArg = balanceHistoryAllDates
+	}
+	return fmt.Sprintf("%s%s:%d", BalanceHistoryCallbackPrefix, dateArg, page)
+}
+
+func parseBalanceHistoryCallback(data string) (string, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(data, BalanceHistoryCallbackPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil || page < 1 {
+		return "", 0, false
+	}
+	dateArg := parts[0]
+	if dateArg == balanceHistoryAllDates {
+		dateArg = ""
+	} else if _, err := parseHistoryDate(dateArg); err != nil {
+		return "", 0, false
+	}
+	return dateArg, page, true
+}
+
+func parseHistoryDate(raw string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", strings.TrimSpace(raw), upstreamChinaLocation)
+}
+
+func balanceOpLabel(opType models.BalanceOperationType) string {
+	switch opType {
+	case models.BalanceOpCredit:
+		return "加款"
+	case models.BalanceOpDebit:
+		return "扣款"
+	case models.BalanceOpSettlement:
+		return "日结"
+	case models.BalanceOpSetMinBalance:
+		return "设置阈值"
+	case models.BalanceOpAlertLimit:
+		return "设置告警"
+	case models.BalanceOpSettlementCorrection:
+		return "日结更正"
+	default:
+		return string(opType)
+	}
+}
+
+func formatSignedAmount(value float64) string {
+	if value > 0 {
+		return "+" + formatAmount(value)
+	}
+	return formatAmount(value)
+}
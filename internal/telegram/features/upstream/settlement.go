@@ -0,0 +1,168 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/dryrun"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	settlementCommand   = "/日结"
+	settlementCommandCN = "日结"
+
+	// SettlementCallbackPrefix 日结确认回调前缀
+	SettlementCallbackPrefix = "settle_confirm:"
+
+	settlementActionConfirm = "ok"
+	settlementActionCancel  = "cancel"
+)
+
+// SettlementPrompt 日结命令的回复（需确认时附带按钮）
+type SettlementPrompt struct {
+	Text   string
+	Markup botModels.ReplyMarkup
+}
+
+// PrepareSettlement 解析「日结 [日期]」命令：预览模式直接返回报告，否则返回预览并等待确认后才扣费
+func (f *BalanceFeature) PrepareSettlement(ctx context.Context, chatID int64, text string) *SettlementPrompt {
+	_, dryRun := dryrun.Parse(text)
+	arg, ok := settlementDateArgument(text)
+	if !ok {
+		return &SettlementPrompt{Text: "❌ 用法：日结 [日期] [--dry-run]，例如：日结、日结10月26"}
+	}
+	target, err := parseSettlementDate(arg, f.currentTime())
+	if err != nil {
+		return &SettlementPrompt{Text: fmt.Sprintf("❌ %v", err)}
+	}
+
+	preview, err := f.balanceService.PreviewSettlement(ctx, chatID, target)
+	if err != nil {
+		logger.L().Errorf("Preview settlement failed: chat_id=%d date=%s err=%v", chatID, target.Format("2006-01-02"), err)
+		return &SettlementPrompt{Text: fmt.Sprintf("❌ 日结预览失败：%v", err)}
+	}
+
+	if dryRun || preview.AlreadySettled {
+		return &SettlementPrompt{Text: preview.Report}
+	}
+
+	return &SettlementPrompt{
+		Text:   fmt.Sprintf("%s\n\n⚠️ 确认按以上结果对 %s 执行日结扣费？", preview.Report, target.Format("2006-01-02")),
+		Markup: buildSettlementConfirmKeyboard(target),
+	}
+}
+
+// HandleSettlementCallback 处理日结确认/取消按钮，返回替换原消息的文本与回调提示
+func (f *BalanceFeature) HandleSettlementCallback(ctx context.Context, query *botModels.CallbackQuery) (string, string, error) {
+	if query == nil || query.Message.Message == nil {
+		return "", "无效的操作", nil
+	}
+
+	isAdmin, err := f.userService.CheckAdminPermission(ctx, query.From.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("check admin permission: %w", err)
+	}
+	if !isAdmin {
+		return "", "仅管理员可以执行日结", nil
+	}
+
+	action, target, ok := parseSettlementCallback(query.Data)
+	if !ok {
+		return "", "无效的操作", nil
+	}
+	if action == settlementActionCancel {
+		return fmt.Sprintf("🚫 已取消 %s 日结", target.Format("2006-01-02")), "已取消", nil
+	}
+
+	// 确认时再次校验，防止跨天后点击旧按钮
+	if _, err := parseSettlementDate(target.Format("2006-01-02"), f.currentTime()); err != nil {
+		return fmt.Sprintf("❌ %v", err), "日期无效", nil
+	}
+
+	chatID := query.Message.Message.Chat.ID
+	operationID := service.SettlementOperationID(chatID, target)
+	result, err := f.balanceService.SettleDaily(ctx, chatID, target, query.From.ID, operationID)
+	if err != nil {
+		logger.L().Errorf("Manual settlement failed: chat_id=%d date=%s err=%v", chatID, target.Format("2006-01-02"), err)
+		return fmt.Sprintf("❌ 日结失败：%v", err), "日结失败", nil
+	}
+
+	return result.Report, "日结完成", nil
+}
+
+func isSettlementCommand(text string) bool {
+	_, ok := settlementDateArgument(text)
+	return ok
+}
+
+// settlementDateArgument 提取「/日结」「日结」后的日期参数；参数需以数字开头，避免误伤以“日结”开头的普通聊天
+func settlementDateArgument(text string) (string, bool) {
+	command, _ := dryrun.Parse(text)
+	for _, prefix := range []string{settlementCommand, settlementCommandCN} {
+		if !strings.HasPrefix(command, prefix) {
+			continue
+		}
+		arg := strings.TrimSpace(strings.TrimPrefix(command, prefix))
+		if arg == "" {
+			return "", true
+		}
+		if strings.ContainsAny(arg, " \t") || arg[0] < '0' || arg[0] > '9' {
+			return "", false
+		}
+		return arg, true
+	}
+	return "", false
+}
+
+// parseSettlementDate 解析日结日期（北京时间），缺省为上一自然日；仅允许今天之前的日期
+func parseSettlementDate(raw string, now time.Time) (time.Time, error) {
+	now = now.In(upstreamChinaLocation)
+	latest := previousBillingDate(now, upstreamChinaLocation)
+	if strings.TrimSpace(raw) == "" {
+		return latest, nil
+	}
+
+	target, err := sifangfeature.ParseSummaryDate(raw, now, settlementCommandCN)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if target.After(latest) {
+		return time.Time{}, fmt.Errorf("无法日结 %s：只能日结今天之前的日期（最近可日结 %s）",
+			target.Format("2006-01-02"), latest.Format("2006-01-02"))
+	}
+	return target, nil
+}
+
+func buildSettlementConfirmKeyboard(target time.Time) *botModels.InlineKeyboardMarkup {
+	date := target.Format("20060102")
+	return &botModels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]botModels.InlineKeyboardButton{
+			{
+				{Text: "✅ 确认日结", CallbackData: SettlementCallbackPrefix + settlementActionConfirm + ":" + date},
+				{Text: "❌ 取消", CallbackData: SettlementCallbackPrefix + settlementActionCancel + ":" + date},
+			},
+		},
+	}
+}
+
+func parseSettlementCallback(data string) (string, time.Time, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(data, SettlementCallbackPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", time.Time{}, false
+	}
+	if parts[0] != settlementActionConfirm && parts[0] != settlementActionCancel {
+		return "", time.Time{}, false
+	}
+	target, err := time.ParseInLocation("20060102", parts[1], upstreamChinaLocation)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[0], target, true
+}
@@ -0,0 +1,73 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"go_bot/internal/logger"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	settlementDebitCommand  = "追加扣费"
+	settlementCreditCommand = "补偿"
+	settlementCorrectionUse = "❌ 用法：追加扣费/补偿 日期 金额 [备注]，例如：追加扣费 10月26 50 漏算接口"
+)
+
+// settlementCorrectionPattern 匹配「追加扣费/补偿 日期 金额 [备注]」；日期需以数字开头，避免误伤普通聊天
+var settlementCorrectionPattern = regexp.MustCompile(`^(追加扣费|补偿)(?:\s+(\d\S*)(?:\s+(\S+)(?:\s+(.*))?)?)?$`)
+
+func isSettlementCorrectionCommand(text string) bool {
+	return settlementCorrectionPattern.MatchString(text)
+}
+
+// handleSettlementCorrection 对已日结日期追加扣费或补偿，并回复更新后的余额
+func (f *BalanceFeature) handleSettlementCorrection(ctx context.Context, msg *botModels.Message, text string) string {
+	matches := settlementCorrectionPattern.FindStringSubmatch(text)
+	if len(matches) < 5 || matches[2] == "" || matches[3] == "" {
+		return settlementCorrectionUse
+	}
+
+	target, err := parseSettlementDate(matches[2], f.currentTime())
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	amount, err := parseAmount(matches[3])
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if amount <= 0 {
+		return "❌ 金额必须大于 0"
+	}
+
+	action := matches[1]
+	delta := amount
+	if action == settlementDebitCommand {
+		delta = -amount
+	}
+	note := strings.TrimSpace(matches[4])
+	date := target.Format("2006-01-02")
+
+	operationID := fmt.Sprintf("settle-fix:%d:%d", msg.Chat.ID, msg.ID)
+	result, below, err := f.balanceService.CorrectSettlement(ctx, msg.Chat.ID, target, delta, msg.From.ID, note, operationID)
+	if err != nil {
+		logger.L().Errorf("Settlement correction failed: chat_id=%d date=%s delta=%.2f err=%v", msg.Chat.ID, date, delta, err)
+		return fmt.Sprintf("❌ %s失败：%v", action, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("✅ 已%s：%s CNY（关联 %s 日结）", action, formatAmount(amount), date))
+	if note != "" {
+		sb.WriteString(fmt.Sprintf("\n备注：%s", html.EscapeString(note)))
+	}
+	sb.WriteString(fmt.Sprintf("\n当前余额：%s CNY", formatAmount(result.Balance)))
+	if below {
+		sb.WriteString(fmt.Sprintf("\n⚠️ 余额低于阈值 %s CNY", formatAmount(result.MinBalance)))
+	}
+	return sb.String()
+}
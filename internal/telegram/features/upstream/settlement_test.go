@@ -0,0 +1,217 @@
+package upstream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type stubSettlementBalanceService struct {
+	service.UpstreamBalanceService
+	previewTargets []time.Time
+	settleTargets  []time.Time
+}
+
+func (s *stubSettlementBalanceService) PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*service.SettlementResult, error) {
+	s.previewTargets = append(s.previewTargets, targetDate)
+	return &service.SettlementResult{GroupID: groupID, TargetDate: targetDate, Report: "📊 日结 - " + targetDate.Format("2006-01-02"), DryRun: true}, nil
+}
+
+func (s *stubSettlementBalanceService) SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*service.SettlementResult, error) {
+	s.settleTargets = append(s.settleTargets, targetDate)
+	return &service.SettlementResult{GroupID: groupID, TargetDate: targetDate, Report: "✅ 已日结 " + operationID}, nil
+}
+
+type correctionCall struct {
+	date        string
+	delta       float64
+	note        string
+	operationID string
+}
+
+type stubCorrectionBalanceService struct {
+	service.UpstreamBalanceService
+	balance float64
+	calls   []correctionCall
+}
+
+func (s *stubCorrectionBalanceService) CorrectSettlement(ctx context.Context, groupID int64, settlementDate time.Time, delta float64, operatorID int64, note string, operationID string) (*service.UpstreamBalanceResult, bool, error) {
+	s.calls = append(s.calls, correctionCall{date: settlementDate.Format("2006-01-02"), delta: delta, note: note, operationID: operationID})
+	s.balance += delta
+	return &service.UpstreamBalanceResult{GroupID: groupID, Balance: s.balance}, false, nil
+}
+
+type stubAdminUserService struct {
+	service.UserService
+}
+
+func (s *stubAdminUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return true, nil
+}
+
+func newSettlementTestFeature(balanceSvc service.UpstreamBalanceService) *BalanceFeature {
+	feature := NewBalanceFeature(balanceSvc, &stubAdminUserService{}, nil)
+	feature.nowFunc = func() time.Time {
+		return time.Date(2024, 10, 26, 12, 0, 0, 0, upstreamChinaLocation)
+	}
+	return feature
+}
+
+func TestPrepareSettlementDefaultsToPreviousDay(t *testing.T) {
+	balanceSvc := &stubSettlementBalanceService{}
+	feature := newSettlementTestFeature(balanceSvc)
+
+	prompt := feature.PrepareSettlement(context.Background(), 1001, "日结")
+	if len(balanceSvc.previewTargets) != 1 || balanceSvc.previewTargets[0].Format("2006-01-02") != "2024-10-25" {
+		t.Fatalf("expected preview for 2024-10-25, got %v", balanceSvc.previewTargets)
+	}
+	if len(balanceSvc.settleTargets) != 0 {
+		t.Fatalf("expected no charge before confirmation, got %v", balanceSvc.settleTargets)
+	}
+	if prompt.Markup == nil || !strings.Contains(prompt.Text, "确认按以上结果对 2024-10-25 执行日结扣费") {
+		t.Fatalf("expected confirmation prompt, got %+v", prompt)
+	}
+}
+
+func TestPrepareSettlementExplicitPastDate(t *testing.T) {
+	balanceSvc := &stubSettlementBalanceService{}
+	feature := newSettlementTestFeature(balanceSvc)
+
+	feature.PrepareSettlement(context.Background(), 1001, "/日结 10月20")
+	if len(balanceSvc.previewTargets) != 1 || balanceSvc.previewTargets[0].Format("2006-01-02") != "2024-10-20" {
+		t.Fatalf("expected preview for 2024-10-20, got %v", balanceSvc.previewTargets)
+	}
+
+	prompt := feature.PrepareSettlement(context.Background(), 1001, "日结2024-09-30 --dry-run")
+	if prompt.Markup != nil {
+		t.Fatalf("expected dry-run to skip confirmation, got %+v", prompt)
+	}
+	if prompt.Text != "📊 日结 - 2024-09-30" {
+		t.Fatalf("unexpected dry-run report: %s", prompt.Text)
+	}
+}
+
+func TestPrepareSettlementRejectsTodayAndFutureDates(t *testing.T) {
+	for _, text := range []string{"日结 2024-10-26", "日结 2024-10-27", "日结2025-01-01"} {
+		balanceSvc := &stubSettlementBalanceService{}
+		feature := newSettlementTestFeature(balanceSvc)
+
+		prompt := feature.PrepareSettlement(context.Background(), 1001, text)
+		if !strings.Contains(prompt.Text, "只能日结今天之前的日期（最近可日结 2024-10-25）") {
+			t.Fatalf("%s: expected future-date rejection, got %s", text, prompt.Text)
+		}
+		if prompt.Markup != nil || len(balanceSvc.previewTargets) != 0 {
+			t.Fatalf("%s: expected no preview for rejected date", text)
+		}
+	}
+}
+
+func TestHandleSettlementCallbackChargesOnConfirm(t *testing.T) {
+	balanceSvc := &stubSettlementBalanceService{}
+	feature := newSettlementTestFeature(balanceSvc)
+
+	query := &botModels.CallbackQuery{
+		From: botModels.User{ID: 42},
+		Data: SettlementCallbackPrefix + settlementActionConfirm + ":20241025",
+		Message: botModels.MaybeInaccessibleMessage{
+			Message: &botModels.Message{ID: 7, Chat: botModels.Chat{ID: 1001}},
+		},
+	}
+
+	text, _, err := feature.HandleSettlementCallback(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "✅ 已日结 settle:1001:2024-10-25" {
+		t.Fatalf("unexpected settlement text: %s", text)
+	}
+	if len(balanceSvc.settleTargets) != 1 || balanceSvc.settleTargets[0].Format("2006-01-02") != "2024-10-25" {
+		t.Fatalf("expected settlement for 2024-10-25, got %v", balanceSvc.settleTargets)
+	}
+
+	query.Data = SettlementCallbackPrefix + settlementActionCancel + ":20241025"
+	if text, _, _ := feature.HandleSettlementCallback(context.Background(), query); !strings.Contains(text, "已取消") {
+		t.Fatalf("expected cancel text, got %s", text)
+	}
+	if len(balanceSvc.settleTargets) != 1 {
+		t.Fatalf("expected cancel not to charge, got %v", balanceSvc.settleTargets)
+	}
+}
+
+func TestIsSettlementCommand(t *testing.T) {
+	cases := map[string]bool{
+		"/日结":           true,
+		"日结":            true,
+		"日结 10月26":      true,
+		"日结10月26":       true,
+		"/日结 --dry-run": true,
+		"日结了吗":          false,
+		"日结 今天 报告":      false,
+	}
+	for text, want := range cases {
+		if got := isSettlementCommand(text); got != want {
+			t.Fatalf("isSettlementCommand(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestSettlementCorrectionCommand(t *testing.T) {
+	balanceSvc := &stubCorrectionBalanceService{balance: 500}
+	feature := newSettlementTestFeature(balanceSvc)
+	msg := &botModels.Message{ID: 42, Chat: botModels.Chat{ID: 1001}, From: &botModels.User{ID: 7}}
+
+	reply := feature.handleSettlementCorrection(context.Background(), msg, "追加扣费 10月20 50 漏算接口")
+	if len(balanceSvc.calls) != 1 {
+		t.Fatalf("expected 1 correction, got %+v", balanceSvc.calls)
+	}
+	want := correctionCall{date: "2024-10-20", delta: -50, note: "漏算接口", operationID: "settle-fix:1001:42"}
+	if balanceSvc.calls[0] != want {
+		t.Fatalf("correction = %+v, want %+v", balanceSvc.calls[0], want)
+	}
+	if !strings.Contains(reply, "关联 2024-10-20 日结") || !strings.Contains(reply, "当前余额：450.00 CNY") {
+		t.Fatalf("unexpected reply: %s", reply)
+	}
+
+	feature.handleSettlementCorrection(context.Background(), msg, "补偿 10月20 20")
+	if balanceSvc.calls[1].delta != 20 || balanceSvc.balance != 470 {
+		t.Fatalf("expected compensation to credit 20, got %+v balance=%.2f", balanceSvc.calls[1], balanceSvc.balance)
+	}
+
+	if reply := feature.handleSettlementCorrection(context.Background(), msg, "补偿 10月26 20"); !strings.Contains(reply, "只能日结今天之前的日期") {
+		t.Fatalf("expected today to be rejected, got %s", reply)
+	}
+	if reply := feature.handleSettlementCorrection(context.Background(), msg, "追加扣费"); reply != settlementCorrectionUse {
+		t.Fatalf("expected usage, got %s", reply)
+	}
+	if isSettlementCorrectionCommand("补偿一下") || isSettlementCorrectionCommand("补偿 给客户") {
+		t.Fatal("plain chat must not match correction command")
+	}
+}
+
+func TestFormatBalanceHistoryGroupsCorrectionsWithSettlement(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 10, 26, hour, 0, 0, 0, upstreamChinaLocation)
+	}
+	settlementMeta := map[string]string{models.BalanceLogSettlementDateKey: "2024-10-25"}
+	logs := []*models.UpstreamBalanceLog{
+		{Type: models.BalanceOpSettlementCorrection, Delta: -50, Balance: 350, Remark: "日结 2024-10-25 更正", Metadata: settlementMeta, CreatedAt: at(12)},
+		{Type: models.BalanceOpCredit, Delta: 100, Balance: 400, CreatedAt: at(11)},
+		{Type: models.BalanceOpDebit, Delta: -200, Balance: 300, Remark: "日结 2024-10-25", Metadata: settlementMeta, CreatedAt: at(1)},
+	}
+
+	entries := groupSettlementCorrections(logs)
+	if len(entries) != 3 || entries[0].log != logs[1] || entries[1].log != logs[2] || entries[2].log != logs[0] || !entries[2].nested {
+		t.Fatalf("expected correction nested after its settlement, got %+v", entries)
+	}
+
+	text := formatBalanceHistory(logs, "", 1)
+	if !strings.Contains(text, "\n  ↳ 10-26 12:00 日结更正 -50.00") {
+		t.Fatalf("expected nested correction line, got %s", text)
+	}
+}
@@ -59,20 +59,37 @@ func (f *SummaryFeature) Enabled(ctx context.Context, group *models.Group) bool
 	return len(group.Settings.InterfaceBindings) > 0
 }
 
+// EnabledReason 未绑定上游接口时说明原因
+func (f *SummaryFeature) EnabledReason(ctx context.Context, group *models.Group) (bool, string) {
+	if len(group.Settings.InterfaceBindings) == 0 {
+		return false, "未绑定上游接口，请先绑定接口 ID"
+	}
+	if !paymentservice.Available(f.paymentService) {
+		return false, "四方支付服务未配置（SIFANG_BASE_URL）"
+	}
+	return true, ""
+}
+
+// AllowedChatTypes 只处理群组消息
+func (f *SummaryFeature) AllowedChatTypes() []botModels.ChatType {
+	return types.GroupChatTypes
+}
+
 // Match 匹配「上游账单」指令
 func (f *SummaryFeature) Match(ctx context.Context, msg *botModels.Message) bool {
 	if msg == nil || msg.Text == "" {
 		return false
 	}
-	if msg.Chat.Type != "" && msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
-		return false
-	}
 	text := strings.TrimSpace(msg.Text)
 	return strings.HasPrefix(text, "上游账单")
 }
 
 // Process 处理指令
 func (f *SummaryFeature) Process(ctx context.Context, msg *botModels.Message, group *models.Group) (*types.Response, bool, error) {
+	if !paymentservice.Available(f.paymentService) {
+		return respond(types.PaymentNotConfiguredText), true, nil
+	}
+
 	bindings := group.Settings.InterfaceBindings
 	if len(bindings) == 0 {
 		return respond(fmt.Sprintf("ℹ️ 当前群未绑定任何接口 ID，请先使用「%s」完成绑定", bindCommandGuide)), true, nil
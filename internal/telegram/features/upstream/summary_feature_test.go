@@ -7,6 +7,7 @@ import (
 	"time"
 
 	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/features/types"
 	"go_bot/internal/telegram/models"
 
 	botModels "github.com/go-telegram/bot/models"
@@ -194,6 +195,30 @@ func TestSummaryFeature_InvalidInterfaceReturnsError(t *testing.T) {
 	}
 }
 
+func TestSummaryFeature_NilPaymentService(t *testing.T) {
+	feature := NewSummaryFeature(nil)
+	group := &models.Group{
+		Settings: models.GroupSettings{
+			InterfaceBindings: []models.InterfaceBinding{
+				{Name: "渠道A", ID: "1001"},
+			},
+		},
+	}
+	msg := &botModels.Message{
+		Text: "上游账单",
+		Chat: botModels.Chat{ID: 1005, Type: "supergroup"},
+		From: &botModels.User{ID: 2},
+	}
+
+	resp, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handled || resp == nil || resp.Text != types.PaymentNotConfiguredText {
+		t.Fatalf("expected not configured message, got %+v", resp)
+	}
+}
+
 type stubPaymentService struct {
 	summaryByPZID            *paymentservice.SummaryByPZID
 	summaryByPZIDByInterface map[string]*paymentservice.SummaryByPZID
@@ -212,7 +237,7 @@ func (s *stubPaymentService) GetSummaryByDay(ctx context.Context, merchantID int
 	panic("not implemented")
 }
 
-func (s *stubPaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time) ([]*paymentservice.SummaryByDayChannel, error) {
+func (s *stubPaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time, channelCode string) ([]*paymentservice.SummaryByDayChannel, error) {
 	panic("not implemented")
 }
 
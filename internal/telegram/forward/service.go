@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,6 +25,7 @@ const (
 	forwardMaxRetryAttempts      = 5
 	defaultForwardRetryDelay     = 2 * time.Second
 	maxForwardExponentialBackoff = 10 * time.Second
+	channelPostPreviewLimit      = 30 // 报告中消息摘要的最大字符数
 )
 
 // Service 转发服务实现
@@ -179,7 +181,7 @@ func (s *Service) forwardTask(ctx context.Context, botInstance *bot.Bot, message
 		taskID, successCount, failedCount, duration)
 
 	// 发送报告给管理员
-	s.sendReportToAdmins(ctx, botInstance, taskID, successCount, failedCount, duration)
+	s.sendReportToAdmins(ctx, botInstance, taskID, channelPostPreview(message), successCount, failedCount, duration)
 }
 
 // forwardToGroup 转发到单个群组（带重试）
@@ -301,7 +303,7 @@ func (s *Service) RecallForwardedMessages(ctx context.Context, botInterface inte
 }
 
 // sendReportToAdmins 发送报告给所有管理员
-func (s *Service) sendReportToAdmins(ctx context.Context, botInstance *bot.Bot, taskID string, successCount, failedCount int, duration time.Duration) {
+func (s *Service) sendReportToAdmins(ctx context.Context, botInstance *bot.Bot, taskID, preview string, successCount, failedCount int, duration time.Duration) {
 	// 查询所有管理员
 	admins, err := s.userService.ListAllAdmins(ctx)
 	if err != nil {
@@ -317,10 +319,11 @@ func (s *Service) sendReportToAdmins(ctx context.Context, botInstance *bot.Bot,
 	// 构造报告消息
 	reportText := fmt.Sprintf(
 		"📊 频道消息转发完成\n\n"+
+			"📝 内容: %s\n"+
 			"✅ 成功: %d 个群组\n"+
 			"❌ 失败: %d 个群组\n"+
 			"⏱️ 耗时: %.2f 秒",
-		successCount, failedCount, duration.Seconds(),
+		preview, successCount, failedCount, duration.Seconds(),
 	)
 
 	// 添加撤回按钮
@@ -345,6 +348,42 @@ func (s *Service) sendReportToAdmins(ctx context.Context, botInstance *bot.Bot,
 	}
 }
 
+// channelPostPreview 生成报告中的消息摘要：纯媒体消息没有 Text，使用媒体类型加说明文字
+func channelPostPreview(message *botModels.Message) string {
+	kind := ""
+	switch {
+	case len(message.Photo) > 0:
+		kind = "[图片]"
+	case message.Video != nil:
+		kind = "[视频]"
+	case message.Document != nil:
+		kind = "[文件]"
+	case message.Animation != nil:
+		kind = "[动图]"
+	case message.Audio != nil, message.Voice != nil:
+		kind = "[音频]"
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if text == "" {
+		text = strings.TrimSpace(message.Caption)
+	}
+	if runes := []rune(text); len(runes) > channelPostPreviewLimit {
+		text = string(runes[:channelPostPreviewLimit]) + "…"
+	}
+
+	switch {
+	case kind != "" && text != "":
+		return kind + " " + text
+	case kind != "":
+		return kind
+	case text != "":
+		return text
+	default:
+		return "(无文字内容)"
+	}
+}
+
 // handleMediaGroupMessage 处理媒体组消息
 func (s *Service) handleMediaGroupMessage(ctx context.Context, botInstance *bot.Bot, message *botModels.Message, groups []*models.Group) error {
 	mediaGroupID := message.MediaGroupID
@@ -441,7 +480,8 @@ func (s *Service) forwardMediaGroup(ctx context.Context, botInstance *bot.Bot, m
 		taskID, len(messages), successCount, failedCount, duration)
 
 	// 发送报告给管理员
-	s.sendReportToAdmins(ctx, botInstance, taskID, successCount, failedCount, duration)
+	preview := fmt.Sprintf("%s（共 %d 个媒体）", channelPostPreview(messages[0]), len(messages))
+	s.sendReportToAdmins(ctx, botInstance, taskID, preview, successCount, failedCount, duration)
 }
 
 // forwardMediaGroupToGroup 转发媒体组到单个群组（带重试）
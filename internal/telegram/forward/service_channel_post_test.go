@@ -0,0 +1,160 @@
+package forward
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type channelPostTestGroupService struct {
+	service.GroupService
+	groups []*models.Group
+}
+
+func (s *channelPostTestGroupService) ListActiveGroups(ctx context.Context) ([]*models.Group, error) {
+	return s.groups, nil
+}
+
+type channelPostTestUserService struct {
+	service.UserService
+}
+
+func (s *channelPostTestUserService) ListAllAdmins(ctx context.Context) ([]*models.User, error) {
+	return []*models.User{{TelegramID: 42}}, nil
+}
+
+type channelPostTestRecordRepo struct {
+	repository.ForwardRecordRepository
+	saved chan []*models.ForwardRecord
+}
+
+func (r *channelPostTestRecordRepo) BulkCreateRecords(ctx context.Context, records []*models.ForwardRecord) error {
+	r.saved <- records
+	return nil
+}
+
+func TestHandleChannelMessageForwardsCaptionOnlyPhoto(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string][]url.Values)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseMultipartForm(1 << 20)
+		method := path.Base(r.URL.Path)
+		mu.Lock()
+		requests[method] = append(requests[method], r.Form)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":777,"date":0,"chat":{"id":-2001,"type":"supergroup"}}}`))
+	}))
+	defer ts.Close()
+
+	botInstance, err := bot.New("test-token", bot.WithServerURL(ts.URL), bot.WithSkipGetMe())
+	if err != nil {
+		t.Fatalf("create bot: %v", err)
+	}
+
+	records := &channelPostTestRecordRepo{saved: make(chan []*models.ForwardRecord, 1)}
+	svc := NewService(-1009,
+		&channelPostTestGroupService{groups: []*models.Group{
+			{TelegramID: -2001, Type: "supergroup", Settings: models.GroupSettings{ForwardEnabled: true}},
+		}},
+		&channelPostTestUserService{},
+		records,
+	)
+
+	update := &botModels.Update{ChannelPost: &botModels.Message{
+		ID:      55,
+		Chat:    botModels.Chat{ID: -1009, Type: botModels.ChatTypeChannel},
+		Caption: "新品上架",
+		Photo: []botModels.PhotoSize{
+			{FileID: "small"},
+			{FileID: "large"},
+		},
+	}}
+	if err := svc.HandleChannelMessage(context.Background(), botInstance, update); err != nil {
+		t.Fatalf("HandleChannelMessage failed: %v", err)
+	}
+
+	var saved []*models.ForwardRecord
+	select {
+	case saved = <-records.saved:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for forward records")
+	}
+
+	if len(saved) != 1 {
+		t.Fatalf("expected one forward record, got %d", len(saved))
+	}
+	record := saved[0]
+	if record.Status != models.ForwardStatusSuccess || record.ChannelMessageID != 55 || record.TargetGroupID != -2001 || record.ForwardedMessageID != 777 || record.TaskID == "" {
+		t.Fatalf("unexpected recall record: %+v", record)
+	}
+
+	mu.Lock()
+	forwards := requests["forwardMessage"]
+	mu.Unlock()
+	if len(forwards) != 1 {
+		t.Fatalf("expected one forwardMessage call, got %d", len(forwards))
+	}
+	if forwards[0].Get("from_chat_id") != "-1009" || forwards[0].Get("message_id") != "55" || forwards[0].Get("chat_id") != "-2001" {
+		t.Fatalf("unexpected forward params: %v", forwards[0])
+	}
+
+	// 报告在记录保存后发送，等待其完成以校验撤回按钮与内容摘要
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		reports := requests["sendMessage"]
+		mu.Unlock()
+		if len(reports) > 0 {
+			text := reports[0].Get("text")
+			if !strings.Contains(text, "[图片] 新品上架") {
+				t.Fatalf("expected report to describe media post, got %q", text)
+			}
+			if !strings.Contains(reports[0].Get("reply_markup"), "recall:"+record.TaskID) {
+				t.Fatalf("expected recall button for task %s, got %s", record.TaskID, reports[0].Get("reply_markup"))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for admin report")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestChannelPostPreview(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *botModels.Message
+		want    string
+	}{
+		{name: "text", message: &botModels.Message{Text: "公告"}, want: "公告"},
+		{name: "photo with caption", message: &botModels.Message{Photo: []botModels.PhotoSize{{FileID: "p"}}, Caption: "图说"}, want: "[图片] 图说"},
+		{name: "video without caption", message: &botModels.Message{Video: &botModels.Video{FileID: "v"}}, want: "[视频]"},
+		{name: "empty", message: &botModels.Message{}, want: "(无文字内容)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := channelPostPreview(tt.message); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
 	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/features/upstream"
 	"go_bot/internal/telegram/forward"
 	"go_bot/internal/telegram/models"
 	"go_bot/internal/telegram/service"
@@ -21,48 +24,54 @@ import (
 // registerHandlers 注册所有命令处理器（异步执行）
 func (b *Bot) registerHandlers() {
 	// 普通命令 - 异步执行
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact,
-		b.asyncHandler(b.handleStart))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/ping", bot.MatchTypeExact,
-		b.asyncHandler(b.handlePing))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleHelp)))
+	b.registerCommand(commandSpec{pattern: "/start", matchType: bot.MatchTypeExact, description: "开始使用"}, b.handleStart)
+	b.registerCommand(commandSpec{pattern: "/ping", matchType: bot.MatchTypeExact, description: "检测 Bot 是否在线"}, b.handlePing)
+	b.registerCommand(commandSpec{pattern: "/whoami", matchType: bot.MatchTypeExact, description: "查看我的 ID 与角色"}, b.handleWhoAmI)
+	b.registerCommand(commandSpec{pattern: helpCommand, matchType: bot.MatchTypePrefix, description: "查看帮助", usage: "/help [命令]"}, b.handleHelp)
 
 	// 管理员命令（仅 Owner） - 异步执行
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/grant", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireOwner(b.handleGrantAdmin)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/revoke", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireOwner(b.handleRevokeAdmin)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/validate", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireOwner(b.handleValidateGroupsCommand)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/repair", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireOwner(b.handleRepairGroupsCommand)))
+	b.registerCommand(commandSpec{pattern: "/grant", matchType: bot.MatchTypePrefix, access: commandAccessOwner, description: "授予管理员权限", usage: "/grant <user_id>"}, b.handleGrantAdmin)
+	b.registerCommand(commandSpec{pattern: "/revoke", matchType: bot.MatchTypePrefix, access: commandAccessOwner, description: "撤销管理员权限", usage: "/revoke <user_id>"}, b.handleRevokeAdmin)
+	b.registerCommand(commandSpec{pattern: "/validate", matchType: bot.MatchTypeExact, access: commandAccessOwner, description: "校验群组数据"}, b.handleValidateGroupsCommand)
+	b.registerCommand(commandSpec{pattern: "/repair", matchType: bot.MatchTypeExact, access: commandAccessOwner, description: "修复群组数据"}, b.handleRepairGroupsCommand)
+	b.registerCommand(commandSpec{pattern: sifangTestCommand, matchType: bot.MatchTypePrefix, access: commandAccessOwner, description: "测试四方连接与签名", usage: "/sifangtest <商户号>（查询余额验证连接，区分签名失败与网络异常）"}, b.handleSifangTest)
+	b.registerCommand(commandSpec{pattern: bindingCheckCommand, matchType: bot.MatchTypeExact, access: commandAccessOwner, description: "检查商户号与接口绑定"}, b.handleBindingCheck)
+	b.registerCommand(commandSpec{pattern: matchDebugCommand, matchType: bot.MatchTypePrefix, access: commandAccessOwner, description: "开关功能匹配调试日志", usage: "/match_debug [on|off]（不带参数查看当前状态）"}, b.handleMatchDebug)
+	b.registerCommand(commandSpec{pattern: orderCascadeSimulateCommand, matchType: matchTypeToken, access: commandAccessOwner, description: "向上游群发送测试转单", usage: "测试转单 <上游群ID或接口ID>"}, b.handleOrderCascadeSimulate)
+	b.registerCommand(commandSpec{pattern: cleanupCommand, matchType: bot.MatchTypeExact, access: commandAccessOwner, description: "立即清理过期的内存状态"}, b.handleCleanup)
+	b.registerCommand(commandSpec{pattern: settlementForecastCommand, matchType: matchTypeToken, access: commandAccessOwner, description: "汇总预览所有上游群的日结扣减", usage: "日结汇总 [日期]（默认昨天，仅预览不扣款）"}, b.handleSettlementForecast)
+	b.registerCommand(commandSpec{pattern: upstreamTransferCommand, matchType: matchTypeToken, access: commandAccessOwner, description: "在上游群之间划转余额", usage: "划转 <转出群ID> <转入群ID> <金额> [--allow-negative]（默认不允许转出群余额不足）"}, b.handleUpstreamTransfer)
+	b.registerCommand(commandSpec{pattern: groupTierCommand, matchType: matchTypeToken, access: commandAccessOwner, description: "手动设置当前群类型", usage: "设置群类型 <basic|merchant|upstream>（仅限群组内执行，缺少对应绑定时给出提示）"}, b.handleSetGroupTier)
+	b.registerCommand(commandSpec{pattern: balanceEventStatusCommand, matchType: bot.MatchTypeExact, access: commandAccessOwner, description: "查看余额事件通道积压、处理与丢弃数量"}, b.handleBalanceEventStatus)
+	b.registerCommand(commandSpec{pattern: featureUsageCommand, matchType: matchTypeToken, access: commandAccessOwner, description: "查看功能使用次数排行", usage: "功能使用 [群组ID]（不填群组ID时统计全部群组）"}, b.handleFeatureUsage)
 
 	// 上游余额相关（Admin+）
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/余额", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireAdmin(b.handleUpstreamBalanceQuery)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/set_min_balance", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireAdmin(b.handleUpstreamSetMinBalance)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/set_balance_alert_limit", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireAdmin(b.handleUpstreamSetAlertLimit)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/日结", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleUpstreamSettlement)))
+	b.registerCommand(commandSpec{pattern: "/余额历史", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "分页查看上游余额变动记录", usage: "/余额历史 [YYYY-MM-DD]"}, b.handleUpstreamBalanceHistory)
+	b.registerCommand(commandSpec{pattern: balanceExportCommand, matchType: matchTypeToken, access: commandAccessAdmin, description: "导出上游群余额变动记录（CSV）", usage: "导出余额记录 [开始日期] [结束日期]（含首尾，最多 31 天，不填日期时导出今天）"}, b.handleBalanceExport)
+	b.registerCommand(commandSpec{pattern: "/余额", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "查询上游群余额与告警阈值"}, b.handleUpstreamBalanceQuery)
+	b.registerCommand(commandSpec{pattern: "/set_min_balance", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "设置最低余额阈值", usage: "/set_min_balance <金额>"}, b.handleUpstreamSetMinBalance)
+	b.registerCommand(commandSpec{pattern: "/set_balance_alert_limit", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "设置每小时余额告警次数", usage: "/set_balance_alert_limit <每小时次数>"}, b.handleUpstreamSetAlertLimit)
+	b.registerCommand(commandSpec{pattern: "/日结", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "预览并确认上游群日结扣费", usage: "/日结 [日期] [--dry-run]（默认昨天，确认后才扣费；不能日结今天及之后的日期）"}, b.handleUpstreamSettlement)
+
+	// 订单联动转单重试（Admin+）
+	b.registerCommand(commandSpec{pattern: orderCascadeRetryCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "立即重试本群发送失败的订单联动转单"}, b.handleRetryOrderCascade)
+	b.registerCommand(commandSpec{pattern: orderCascadePendingCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "列出等待上游反馈的转单", usage: "待处理转单（群内列出本群的转单，Owner 私聊时列出全部）"}, b.handleOrderCascadePending)
+	b.registerCommand(commandSpec{pattern: orderCascadeMismatchCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "列出转单时上游群未绑定的接口"}, b.handleOrderCascadeMismatchCheck)
 
 	// 管理员命令（Admin+） - 异步执行
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/admins", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleListAdmins)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/userinfo", bot.MatchTypePrefix,
-		b.asyncHandler(b.RequireAdmin(b.handleUserInfo)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/leave", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleLeave)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/configs", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleConfigs)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, billStyleDemoCommandSlash, bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleBillStyleDemo)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, billStyleDemoCommandCN, bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleBillStyleDemo)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, billStyleDemoCommandCNSimple, bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleBillStyleDemo)))
+	b.registerCommand(commandSpec{pattern: "/admins", matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "查看管理员列表"}, b.handleListAdmins)
+	b.registerCommand(commandSpec{pattern: messageExportCommand, matchType: matchTypeToken, access: commandAccessAdmin, description: "导出本群指定日期区间的消息（JSON Lines）", usage: "导出消息 <开始日期> <结束日期>（含首尾，最多 31 天）"}, b.handleMessageExport)
+	b.registerCommand(commandSpec{pattern: "/userinfo", matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "查看用户信息", usage: "/userinfo <user_id>"}, b.handleUserInfo)
+	b.registerCommand(commandSpec{pattern: permsCommand, matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "查看用户的全局角色、可管理的群组与私聊状态", usage: "/perms <user_id>"}, b.handlePerms)
+	b.registerCommand(commandSpec{pattern: clearBlockedCommand, matchType: bot.MatchTypePrefix, access: commandAccessAdmin, description: "清除用户屏蔽 Bot 的标记，恢复私聊通知", usage: "/clearblocked <user_id>"}, b.handleClearBlocked)
+	b.registerCommand(commandSpec{pattern: featureStatusCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "查看本群各功能能否使用及原因", usage: "功能状态（仅限群组内执行）"}, b.handleFeatureStatus)
+	b.registerCommand(commandSpec{pattern: recentErrorsCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "查看本群最近的功能错误", usage: "最近错误（仅限群组内执行，仅保留内存中最近 20 条）"}, b.handleRecentErrors)
+	b.registerCommand(commandSpec{pattern: "/leave", matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "让 Bot 退出当前群组", usage: "/leave（仅限群组内执行）"}, b.handleLeave)
+	b.registerCommand(commandSpec{pattern: "/configs", matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "打开群组配置菜单", usage: "/configs（仅限群组内执行）"}, b.handleConfigs)
+	b.registerCommand(commandSpec{pattern: welcomePreviewCommand, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "预览 Bot 入群欢迎消息", usage: "预览欢迎（仅限群组内执行）"}, b.handleWelcomePreview)
+	b.registerCommand(commandSpec{pattern: billStyleDemoCommandSlash, matchType: bot.MatchTypeExact, access: commandAccessAdmin, description: "预览账单样式"}, b.handleBillStyleDemo)
+	b.registerCommand(commandSpec{pattern: billStyleDemoCommandCN, matchType: bot.MatchTypeExact, access: commandAccessAdmin}, b.handleBillStyleDemo)
+	b.registerCommand(commandSpec{pattern: billStyleDemoCommandCNSimple, matchType: bot.MatchTypeExact, access: commandAccessAdmin}, b.handleBillStyleDemo)
 
 	// 配置菜单回调查询处理器
 	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
@@ -79,6 +88,16 @@ func (b *Bot) registerHandlers() {
 		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, sifangfeature.SendMoneyCallbackPrefix)
 	}, b.asyncHandler(b.handleSifangSendMoneyCallback))
 
+	// 余额历史翻页回调
+	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, upstream.BalanceHistoryCallbackPrefix)
+	}, b.asyncHandler(b.handleBalanceHistoryCallback))
+
+	// 日结确认回调
+	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, upstream.SettlementCallbackPrefix)
+	}, b.asyncHandler(b.handleSettlementCallback))
+
 	// 订单联动反馈回调处理
 	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
 		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, orderCascadeCallbackPrefix)
@@ -95,18 +114,28 @@ func (b *Bot) registerHandlers() {
 	}
 
 	// 收支记账命令
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "查询记账", bot.MatchTypeExact,
-		b.asyncHandler(b.handleQueryAccounting))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "删除记账记录", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleDeleteAccounting)))
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "清零记账", bot.MatchTypeExact,
-		b.asyncHandler(b.RequireAdmin(b.handleClearAccounting)))
+	b.registerCommand(commandSpec{pattern: accountingQueryCommand, matchType: matchTypeToken}, b.handleQueryAccounting)
+	b.registerCommand(commandSpec{pattern: "删除记账记录", matchType: bot.MatchTypeExact, access: commandAccessAdmin}, b.handleDeleteAccounting)
+	b.registerCommand(commandSpec{pattern: "清零记账", matchType: bot.MatchTypeExact, access: commandAccessAdmin}, b.handleClearAccounting)
+	b.registerCommand(commandSpec{pattern: currencySymbolCommand, matchType: matchTypeToken, access: commandAccessAdmin}, b.handleSetCurrencySymbol)
+	b.registerCommand(commandSpec{pattern: accountingCurrencyCommand, matchType: matchTypeToken, access: commandAccessAdmin}, b.handleSetAccountingCurrencies)
+	b.registerCommand(commandSpec{pattern: commandAliasCommand, matchType: matchTypeToken, access: commandAccessAdmin, description: "设置本群功能命令别名", usage: "设置别名 [别名] [命令]（不带参数列出别名，省略命令删除别名），例如：设置别名 bill 账单"}, b.handleSetCommandAlias)
 
 	// 收支记账删除回调处理器
 	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
 		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, "acc_del:")
 	}, b.asyncHandler(b.handleAccountingDeleteCallback))
 
+	// 收支记账重复确认回调处理器
+	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, accountingDedupCallbackPrefix)
+	}, b.asyncHandler(b.handleAccountingDedupCallback))
+
+	// 内联查单（@bot <订单号>）
+	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
+		return update.InlineQuery != nil
+	}, b.asyncHandler(b.handleInlineQuery))
+
 	// Bot 状态变化事件 (MyChatMember)
 	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
 		return update.MyChatMember != nil
@@ -147,6 +176,9 @@ func (b *Bot) registerHandlers() {
 		return update.Message != nil && update.Message.LeftChatMember != nil
 	}, b.asyncHandler(b.handleLeftChatMember))
 
+	// 未匹配任何处理器的斜杠命令（群组开启提示时回复 /help 指引）
+	b.bot.RegisterHandlerMatchFunc(isUnknownCommandCandidate, b.asyncHandler(b.handleUnknownCommand))
+
 	// 普通文本消息（放在最后，作为 fallback）
 	b.bot.RegisterHandlerMatchFunc(func(update *botModels.Update) bool {
 		if update.Message == nil || update.Message.Text == "" {
@@ -186,8 +218,8 @@ func (b *Bot) handleStart(ctx context.Context, botInstance *bot.Bot, update *bot
 	}
 
 	welcomeText := fmt.Sprintf(
-		"👋 你好, %s!\n\n欢迎使用本 Bot。\n\n可用命令:\n/start - 开始\n/ping - 测试连接\n/admins - 查看管理员列表（需要管理员权限）",
-		update.Message.From.FirstName,
+		"👋 你好, %s!\n\n欢迎使用本 Bot。\n\n可用命令:\n/start - 开始\n/ping - 测试连接\n/whoami - 查看我的 ID 与角色\n/admins - 查看管理员列表（需要管理员权限）",
+		escapeHTML(update.Message.From.FirstName),
 	)
 
 	b.sendMessage(ctx, update.Message.Chat.ID, welcomeText)
@@ -208,72 +240,16 @@ func (b *Bot) handlePing(ctx context.Context, botInstance *bot.Bot, update *botM
 	b.sendMessage(ctx, update.Message.Chat.ID, message)
 }
 
-// handleHelp 处理 /help 命令（仅 Admin+）
-func (b *Bot) handleHelp(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
-	if update.Message == nil {
+func (b *Bot) handleUpstreamBalanceHistory(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil || b.balanceFeature == nil {
 		return
 	}
 
-	var text strings.Builder
-	text.WriteString("<b>🆘 管理员帮助总览</b>\n\n")
-
-	text.WriteString("<b>通用命令（所有成员）</b>\n")
-	text.WriteString("/start - 与机器人建立会话并登记用户信息\n")
-	text.WriteString("/ping - 测试机器人连接状态\n\n")
-
-	text.WriteString("<b>管理员命令（Admin+）</b>\n")
-	text.WriteString("/help - 查看本帮助\n")
-	text.WriteString("/admins - 查看管理员列表\n")
-	text.WriteString("/userinfo &lt;user_id&gt; - 查询指定用户信息\n")
-	text.WriteString("/leave - 让机器人离开当前群组（仅限群组内执行）\n")
-	text.WriteString("/configs - 打开群组功能配置菜单（仅限群组内执行）\n")
-	text.WriteString("撤回 - 在群组中引用机器人的消息发送“撤回”以删除该消息\n\n")
-
-	text.WriteString("<b>Owner 专属命令</b>\n")
-	text.WriteString("/grant &lt;user_id&gt; - 授予管理员权限\n")
-	text.WriteString("/revoke &lt;user_id&gt; - 撤销管理员权限\n\n")
-	text.WriteString("/validate - 校验数据库中的群组配置状态\n")
-	text.WriteString("/repair - 自动修复可识别的群组配置问题（例如缺少 tier）\n\n")
-
-	text.WriteString("<b>商户号管理（Admin+，群组）</b>\n")
-	text.WriteString("绑定 <code>[商户号]</code> - 绑定当前群组的四方商户号\n")
-	text.WriteString("解绑 - 解除已绑定的商户号\n")
-	text.WriteString("商户号 / 绑定状态 - 查看当前绑定情况\n\n")
-
-	text.WriteString("<b>接口管理（Admin+，群组）</b>\n")
-	text.WriteString("绑定接口 <code>[接口名称] [接口ID] [费率]</code> - 绑定上游接口并保存名称/费率，可重复执行绑定多个接口\n")
-	text.WriteString("解绑接口 <code>[接口ID]</code> - 解除指定接口；仅发送“解绑接口”可清空全部\n")
-	text.WriteString("接口ID / 接口状态 - 查看当前已绑定的接口列表\n\n")
-
-	text.WriteString("<b>上游账单查询（Admin+，上游群）</b>\n")
-	text.WriteString("上游账单 <code>[接口ID或名称] [可选日期]</code> - 查询指定接口的跑量、商户实收、代理收益和订单数，日期默认为当天\n\n")
-
-	text.WriteString("<b>四方支付查询（需开启“🏦 四方支付查询”功能并完成商户号绑定）</b>\n")
-	text.WriteString("余额[可选日期] - 查询余额，例如：余额、余额10月26\n")
-	text.WriteString("账单[可选日期] - 查询日汇总，例如：账单2023/10/26\n")
-	text.WriteString("每日00:00:05（北京时间）自动向已绑定商户号的群推送昨日账单\n")
-	text.WriteString("通道账单[可选日期] - 查看通道维度汇总\n")
-	text.WriteString("提款明细[可选日期] - 查看提款记录\n")
-	text.WriteString("费率 - 查看通道费率\n")
-	text.WriteString("自动查单 - 默认开启，自动识别群内文字/图片/视频标题/文件名中的订单号（长度10-60，含数字，支持机器人消息）并异步查询，可在 /configs 的“🔍 四方自动查单”中关闭\n")
-	text.WriteString("下发 <code>金额</code> [谷歌验证码] - 申请下发，支持表达式和谷歌验证码，需在 60 秒内按钮确认\n")
-	text.WriteString("下发 <code>[a|z|k|w][序号] [U金额]</code> [谷歌验证码] - 按欧易报价换算后申请下发，例如：下发 z3 100\n\n")
-	text.WriteString("模拟下单 <code>金额</code> [通道代码] [订单号] - 调用 /createorder 模拟创建订单（会真实写单）\n")
-	text.WriteString("模拟创建订单 <code>金额</code> [通道代码] [订单号] - 模拟下单同义命令\n\n")
-
-	text.WriteString("<b>USDT 价格查询（需开启“💰 USDT价格查询”功能，群组）</b>\n")
-	text.WriteString("<code>[a|z|k|w][序号] [金额]</code> - a=全部、z=支付宝、k=银行卡、w=微信；示例：z3 100\n\n")
-
-	text.WriteString("<b>计算器（需开启“🧮 计算器功能”，群组）</b>\n")
-	text.WriteString("直接发送数学表达式，例如：<code>(100+20)*1.5</code>\n\n")
-
-	text.WriteString("<b>收支记账（需开启“💳 收支记账”功能，仅 Admin+，群组）</b>\n")
-	text.WriteString("查询记账 - 查看今日账单\n")
-	text.WriteString("删除记账记录 - 打开最近记录删除菜单\n")
-	text.WriteString("清零记账 - 清空所有记录\n")
-	text.WriteString("记账输入格式示例：<code>+100U</code>、<code>-50Y</code>、<code>入100*7.2</code>、<code>出50/2Y</code>\n")
-
-	b.sendMessage(ctx, update.Message.Chat.ID, text.String())
+	page := b.balanceFeature.BalanceHistory(ctx, msg.Chat.ID, msg.Text)
+	if _, err := b.sendMessageWithMarkupAndMessage(ctx, msg.Chat.ID, page.Text, page.Markup, msg.ID); err != nil {
+		logger.L().Errorf("Send balance history failed: chat_id=%d err=%v", msg.Chat.ID, err)
+	}
 }
 
 func (b *Bot) handleUpstreamBalanceQuery(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
@@ -289,6 +265,8 @@ func (b *Bot) handleUpstreamBalanceQuery(ctx context.Context, botInstance *bot.B
 		return
 	}
 
+	b.recordFeatureUsage(ctx, msg.Chat.ID, usageBalanceQuery)
+
 	status := "✅ 余额正常"
 	if result.Balance < result.MinBalance {
 		status = "⚠️ 余额低于阈值"
@@ -361,18 +339,48 @@ func (b *Bot) handleUpstreamSettlement(ctx context.Context, botInstance *bot.Bot
 		return
 	}
 
-	loc := mustLoadChinaLocation()
-	target := previousBillingDate(time.Now().In(loc), loc)
-	operationID := fmt.Sprintf("settle:%s", target.Format("2006-01-02"))
+	if !paymentservice.Available(b.paymentService) {
+		b.sendMessage(ctx, msg.Chat.ID, types.PaymentNotConfiguredText, msg.ID)
+		return
+	}
+
+	if b.balanceFeature == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "上游余额功能未启用", msg.ID)
+		return
+	}
+
+	prompt := b.balanceFeature.PrepareSettlement(ctx, msg.Chat.ID, msg.Text)
+	if _, err := b.sendMessageWithMarkupAndMessage(ctx, msg.Chat.ID, prompt.Text, prompt.Markup, msg.ID); err != nil {
+		logger.L().Errorf("Failed to send settlement prompt: chat_id=%d err=%v", msg.Chat.ID, err)
+	}
+}
+
+func (b *Bot) handleSettlementCallback(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	query := update.CallbackQuery
+	if query == nil {
+		return
+	}
+
+	if b.balanceFeature == nil {
+		b.answerCallback(ctx, botInstance, query.ID, "功能未启用", true)
+		return
+	}
 
-	result, err := b.balanceService.SettleDaily(ctx, msg.Chat.ID, target, msg.From.ID, operationID)
+	text, answer, err := b.balanceFeature.HandleSettlementCallback(ctx, query)
 	if err != nil {
-		logger.L().Errorf("Manual upstream settlement failed: chat_id=%d err=%v", msg.Chat.ID, err)
-		b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("日结失败：%v", err), msg.ID)
+		logger.L().Errorf("handle settlement callback failed: data=%s err=%v", query.Data, err)
+		b.answerCallback(ctx, botInstance, query.ID, "处理失败，请稍后重试", true)
+		return
+	}
+	if text == "" {
+		b.answerCallback(ctx, botInstance, query.ID, answer, true)
 		return
 	}
 
-	b.sendSuccessMessage(ctx, msg.Chat.ID, result.Report, msg.ID)
+	if msg := query.Message.Message; msg != nil {
+		b.editMessage(ctx, msg.Chat.ID, msg.ID, text, nil)
+	}
+	b.answerCallback(ctx, botInstance, query.ID, answer, false)
 }
 
 // handleGrantAdmin 处理 /grant 命令（授予管理员权限）
@@ -385,7 +393,7 @@ func (b *Bot) handleGrantAdmin(ctx context.Context, botInstance *bot.Bot, update
 	parts := strings.Fields(update.Message.Text)
 	if len(parts) < 2 {
 		b.sendErrorMessage(ctx, update.Message.Chat.ID,
-			"用法: /grant <user_id>\n例如: /grant 123456789")
+			"用法: /grant &lt;user_id&gt;\n例如: /grant 123456789")
 		return
 	}
 
@@ -416,7 +424,7 @@ func (b *Bot) handleRevokeAdmin(ctx context.Context, botInstance *bot.Bot, updat
 	parts := strings.Fields(update.Message.Text)
 	if len(parts) < 2 {
 		b.sendErrorMessage(ctx, update.Message.Chat.ID,
-			"用法: /revoke <user_id>\n例如: /revoke 123456789")
+			"用法: /revoke &lt;user_id&gt;\n例如: /revoke 123456789")
 		return
 	}
 
@@ -524,7 +532,7 @@ func (b *Bot) handleListAdmins(ctx context.Context, botInstance *bot.Bot, update
 	// 使用 Service 获取管理员列表
 	admins, err := b.userService.ListAllAdmins(ctx)
 	if err != nil {
-		b.sendErrorMessage(ctx, update.Message.Chat.ID, "查询失败")
+		b.sendStorageError(ctx, update.Message.Chat.ID, err, "查询失败")
 		return
 	}
 
@@ -543,8 +551,8 @@ func (b *Bot) handleListAdmins(ctx context.Context, botInstance *bot.Bot, update
 		text.WriteString(fmt.Sprintf("%d. %s %s (@%s) - ID: %d\n",
 			i+1,
 			roleEmoji,
-			admin.FirstName,
-			admin.Username,
+			escapeHTML(admin.FirstName),
+			escapeHTML(admin.Username),
 			admin.TelegramID,
 		))
 	}
@@ -562,7 +570,7 @@ func (b *Bot) handleUserInfo(ctx context.Context, botInstance *bot.Bot, update *
 	parts := strings.Fields(update.Message.Text)
 	if len(parts) < 2 {
 		b.sendErrorMessage(ctx, update.Message.Chat.ID,
-			"用法: /userinfo <user_id>\n例如: /userinfo 123456789")
+			"用法: /userinfo &lt;user_id&gt;\n例如: /userinfo 123456789")
 		return
 	}
 
@@ -576,19 +584,11 @@ func (b *Bot) handleUserInfo(ctx context.Context, botInstance *bot.Bot, update *
 	// 使用 Service 查询用户信息
 	user, err := b.userService.GetUserInfo(ctx, targetID)
 	if err != nil {
-		b.sendErrorMessage(ctx, update.Message.Chat.ID, "用户不存在或查询失败")
+		b.sendStorageError(ctx, update.Message.Chat.ID, err, "用户不存在或查询失败")
 		return
 	}
 
-	var roleEmoji string
-	switch user.Role {
-	case models.RoleOwner:
-		roleEmoji = "👑"
-	case models.RoleAdmin:
-		roleEmoji = "⭐"
-	default:
-		roleEmoji = "👤"
-	}
+	roleEmoji := userRoleEmoji(user.Role)
 
 	premiumBadge := ""
 	if user.IsPremium {
@@ -602,16 +602,18 @@ func (b *Bot) handleUserInfo(ctx context.Context, botInstance *bot.Bot, update *
 			"用户名: @%s\n"+
 			"角色: %s %s\n"+
 			"语言: %s\n"+
+			"私聊状态: %s\n"+
 			"创建时间: %s\n"+
 			"最后活跃: %s",
 		user.TelegramID,
-		user.FirstName,
-		user.LastName,
+		escapeHTML(user.FirstName),
+		escapeHTML(user.LastName),
 		premiumBadge,
-		user.Username,
+		escapeHTML(user.Username),
 		roleEmoji,
-		user.Role,
-		user.LanguageCode,
+		escapeHTML(user.Role),
+		escapeHTML(user.LanguageCode),
+		userReachabilityText(user),
 		user.CreatedAt.Format("2006-01-02 15:04:05"),
 		user.LastActiveAt.Format("2006-01-02 15:04:05"),
 	)
@@ -636,18 +638,46 @@ func (b *Bot) handleLeave(ctx context.Context, botInstance *bot.Bot, update *bot
 	// 发送离别消息
 	b.sendMessage(ctx, chatID, "👋 再见！我将离开这个群组。")
 
-	// 标记 Bot 离开并删除群组记录
+	// 先让 Bot 离开群组，成功后才删除群组记录，避免数据库显示已离开而 Bot 仍在群内
+	if err := b.leaveChatWithRetry(ctx, botInstance, chatID); err != nil {
+		logger.L().Errorf("Failed to leave chat: chat_id=%d, error=%v", chatID, err)
+		b.sendErrorMessage(ctx, chatID, "退出群组失败，请稍后重试或手动移除 Bot")
+		return
+	}
+
+	// 删除失败时由随后的 MyChatMember（left）事件将群组标记为已离开
 	if err := b.groupService.LeaveGroup(ctx, chatID); err != nil {
 		logger.L().Errorf("Failed to mark group as left: chat_id=%d, error=%v", chatID, err)
 	}
+}
 
-	// 让 Bot 离开群组
-	_, err := botInstance.LeaveChat(ctx, &bot.LeaveChatParams{
-		ChatID: chatID,
-	})
-	if err != nil {
-		logger.L().Errorf("Failed to leave chat: chat_id=%d, error=%v", chatID, err)
+const (
+	// leaveChatMaxAttempts /leave 调用 LeaveChat 的最大尝试次数
+	leaveChatMaxAttempts = 2
+	// leaveChatRetryDelay LeaveChat 失败后的重试间隔
+	leaveChatRetryDelay = 500 * time.Millisecond
+)
+
+// leaveChatWithRetry 调用 LeaveChat，失败时短暂等待后重试
+func (b *Bot) leaveChatWithRetry(ctx context.Context, botInstance *bot.Bot, chatID int64) error {
+	var lastErr error
+	for attempt := 1; attempt <= leaveChatMaxAttempts; attempt++ {
+		_, err := botInstance.LeaveChat(ctx, &bot.LeaveChatParams{ChatID: chatID})
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		logger.L().Warnf("Leave chat attempt %d failed: chat_id=%d, error=%v", attempt, chatID, err)
+		if attempt == leaveChatMaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(leaveChatRetryDelay):
+		}
 	}
+	return lastErr
 }
 
 // handleMyChatMember 处理 Bot 状态变化（被添加到群组/被踢出群组）
@@ -681,12 +711,8 @@ func (b *Bot) handleMyChatMember(ctx context.Context, botInstance *bot.Bot, upda
 
 		// 发送欢迎消息（频道除外）
 		if chat.Type != "channel" {
-			welcomeText := fmt.Sprintf(
-				"👋 你好！我是 Bot，感谢邀请我加入 %s！\n\n"+
-					"使用 /configs 查看可用配置命令。",
-				chat.Title,
-			)
-			b.sendMessage(ctx, chat.ID, welcomeText)
+			b.sendMessage(ctx, chat.ID, buildGroupWelcomeText(chat.Title))
+			b.autoGrantInviterAdmin(ctx, chat.ID, &chatMember.From)
 		}
 	}
 
@@ -701,6 +727,7 @@ func (b *Bot) handleMyChatMember(ctx context.Context, botInstance *bot.Bot, upda
 		if err := b.groupService.HandleBotRemovedFromGroup(ctx, chat.ID, reason); err != nil {
 			logger.L().Errorf("Failed to handle bot removed from group: %v", err)
 		}
+		b.purgeRemovedChatState(ctx, chat.ID)
 	}
 }
 
@@ -784,20 +811,10 @@ func (b *Bot) handleTextMessage(ctx context.Context, botInstance *bot.Bot, updat
 	// 这里替代了原来硬编码的计算器功能检测
 	response, handled, err := b.featureManager.Process(ctx, msg)
 	if handled {
+		replyTo := b.featureReplyTo(ctx, msg)
 		sendFeatureResponse := func() {
-			if response == nil || response.Text == "" {
-				return
-			}
-
-			var sendFunc func(context.Context, int64, string, botModels.ReplyMarkup, ...int) (*botModels.Message, error)
-			if response.Temporary {
-				sendFunc = b.sendTemporaryMessageWithMarkup
-			} else {
-				sendFunc = b.sendMessageWithMarkupAndMessage
-			}
-
-			sent, sendErr := sendFunc(ctx, msg.Chat.ID, response.Text, response.ReplyMarkup, msg.ID)
-			if sendErr == nil {
+			sent, sendErr := b.sendFeatureResponse(ctx, msg.Chat.ID, response, replyTo...)
+			if sendErr == nil && sent != nil {
 				b.tryScheduleSifangSendMoneyExpiration(sent, response.ReplyMarkup)
 			}
 		}
@@ -806,11 +823,14 @@ func (b *Bot) handleTextMessage(ctx context.Context, botInstance *bot.Bot, updat
 			if response != nil && response.Text != "" {
 				sendFeatureResponse()
 			} else {
-				b.sendErrorMessage(ctx, msg.Chat.ID, "处理失败，请稍后重试", msg.ID)
+				b.sendErrorMessage(ctx, msg.Chat.ID, "处理失败，请稍后重试", replyTo...)
 			}
 		} else {
 			sendFeatureResponse()
 		}
+		if response != nil && response.DeleteTrigger {
+			b.deleteMessageBestEffort(ctx, msg.Chat.ID, msg.ID)
+		}
 		return // 功能已处理，不再记录为普通消息
 	}
 
@@ -937,6 +957,34 @@ func (b *Bot) handleSifangSendMoneyCallback(ctx context.Context, botInstance *bo
 	}
 }
 
+func (b *Bot) handleBalanceHistoryCallback(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	query := update.CallbackQuery
+	if query == nil {
+		return
+	}
+
+	if b.balanceFeature == nil {
+		b.answerCallback(ctx, botInstance, query.ID, "功能未启用", true)
+		return
+	}
+
+	page, answer, err := b.balanceFeature.HandleBalanceHistoryCallback(ctx, query)
+	if err != nil {
+		logger.L().Errorf("handle balance history callback failed: data=%s err=%v", query.Data, err)
+		b.answerCallback(ctx, botInstance, query.ID, "处理失败，请稍后重试", true)
+		return
+	}
+	if page == nil {
+		b.answerCallback(ctx, botInstance, query.ID, answer, true)
+		return
+	}
+
+	if msg := query.Message.Message; msg != nil {
+		b.editMessage(ctx, msg.Chat.ID, msg.ID, page.Text, page.Markup)
+	}
+	b.answerCallback(ctx, botInstance, query.ID, "", false)
+}
+
 func (b *Bot) handleOrderCascadeCallback(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
 	query := update.CallbackQuery
 	if query == nil || query.Data == "" {
@@ -980,6 +1028,7 @@ func (b *Bot) handleOrderCascadeCallback(ctx context.Context, botInstance *bot.B
 		b.answerCallback(ctx, botInstance, query.ID, "反馈发送失败", true)
 		return
 	}
+	b.markOrderCascadeFeedback(state.Token)
 
 	var cascadeMsg *botModels.Message
 	if query.Message.Message != nil {
@@ -1168,10 +1217,9 @@ func (b *Bot) handleChannelPost(ctx context.Context, botInstance *bot.Bot, updat
 
 	post := update.ChannelPost
 	messageType := models.MessageTypeChannelPost
-	text := post.Text
 	fileID := ""
 
-	// 如果是媒体消息，提取 file_id
+	// 如果是媒体消息，提取 file_id；纯媒体消息没有 Text，说明文字在 Caption 中
 	if len(post.Photo) > 0 {
 		fileID = post.Photo[len(post.Photo)-1].FileID
 	} else if post.Video != nil {
@@ -1184,7 +1232,8 @@ func (b *Bot) handleChannelPost(ctx context.Context, botInstance *bot.Bot, updat
 		TelegramMessageID: int64(post.ID),
 		ChatID:            post.Chat.ID,
 		MessageType:       messageType,
-		Text:              text,
+		Text:              post.Text,
+		Caption:           post.Caption,
 		MediaFileID:       fileID,
 		SentAt:            time.Unix(int64(post.Date), 0),
 	}
@@ -1300,6 +1349,29 @@ func (b *Bot) registerUserFromTelegram(ctx context.Context, tgUser *botModels.Us
 	}
 }
 
+// autoGrantInviterAdmin 开启 AUTO_GRANT_INVITER_ADMIN 时，将邀请 Bot 入群的用户设为管理员（频道不授权）
+func (b *Bot) autoGrantInviterAdmin(ctx context.Context, chatID int64, inviter *botModels.User) {
+	if !b.autoGrantInviter || inviter == nil || inviter.ID == 0 || inviter.IsBot {
+		return
+	}
+
+	granted, err := b.userService.GrantInviterAdmin(ctx, &service.TelegramUserInfo{
+		TelegramID:   inviter.ID,
+		Username:     inviter.Username,
+		FirstName:    inviter.FirstName,
+		LastName:     inviter.LastName,
+		LanguageCode: inviter.LanguageCode,
+		IsPremium:    inviter.IsPremium,
+	})
+	if err != nil {
+		logger.L().Warnf("Failed to auto grant admin to inviter %d (chat_id=%d): %v", inviter.ID, chatID, err)
+		return
+	}
+	if granted {
+		logger.L().Infof("Inviter %d auto granted admin after adding bot to chat %d", inviter.ID, chatID)
+	}
+}
+
 // ==================== 收支记账相关 Handlers ====================
 
 // handleAccountingInput 处理记账输入（私有函数，由 handleTextMessage 调用）
@@ -1331,6 +1403,11 @@ func (b *Bot) handleAccountingInput(ctx context.Context, botInstance *bot.Bot, u
 		return false
 	}
 
+	// 短时间内重复提交时按配置询问或跳过
+	if b.handleAccountingDuplicate(ctx, update.Message, group.Settings) {
+		return true
+	}
+
 	// 尝试添加记账记录
 	if err := b.accountingService.AddRecord(ctx, chatID, userID, text); err != nil {
 		// 如果是格式错误，返回 false（让后续 handler 处理）
@@ -1338,7 +1415,15 @@ func (b *Bot) handleAccountingInput(ctx context.Context, botInstance *bot.Bot, u
 			return false
 		}
 		// 其他错误，显示错误消息
-		b.sendErrorMessage(ctx, chatID, err.Error())
+		b.sendStorageError(ctx, chatID, err, err.Error())
+		return true
+	}
+
+	b.recordFeatureUsage(ctx, chatID, usageAccountingAdd)
+
+	// 关闭自动账单时仅确认记录，避免高频记账刷屏
+	if !models.IsAccountingAutoReportEnabled(group.Settings) {
+		b.sendSuccessMessage(ctx, chatID, "已记录")
 		return true
 	}
 
@@ -1353,12 +1438,17 @@ func (b *Bot) handleAccountingInput(ctx context.Context, botInstance *bot.Bot, u
 	return true
 }
 
-// handleQueryAccounting 处理"查询记账"命令
+// handleQueryAccounting 处理"查询记账 [日期] [结束日期]"命令，不带日期时查看今日账单
 func (b *Bot) handleQueryAccounting(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
 	if update.Message == nil {
 		return
 	}
 
+	args, ok := accountingQueryArgs(update.Message.Text)
+	if !ok {
+		return
+	}
+
 	chatID := update.Message.Chat.ID
 	chat := update.Message.Chat
 
@@ -1371,7 +1461,7 @@ func (b *Bot) handleQueryAccounting(ctx context.Context, botInstance *bot.Bot, u
 	}
 	group, err := b.groupService.GetOrCreateGroup(ctx, chatInfo)
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, "查询失败")
+		b.sendStorageError(ctx, chatID, err, "查询失败")
 		return
 	}
 
@@ -1381,12 +1471,23 @@ func (b *Bot) handleQueryAccounting(ctx context.Context, botInstance *bot.Bot, u
 	}
 
 	// 查询账单
-	report, err := b.accountingService.QueryRecords(ctx, chatID)
+	var report string
+	if len(args) == 0 {
+		report, err = b.accountingService.QueryRecords(ctx, chatID)
+	} else {
+		start, end, rangeErr := parseAccountingQueryRange(args, b.currentTime().In(mustLoadChinaLocation()))
+		if rangeErr != nil {
+			b.sendErrorMessage(ctx, chatID, rangeErr.Error())
+			return
+		}
+		report, err = b.accountingService.QueryRecordsByRange(ctx, chatID, start, end)
+	}
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, err.Error())
+		b.sendStorageError(ctx, chatID, err, err.Error())
 		return
 	}
 
+	b.recordFeatureUsage(ctx, chatID, usageAccountingQuery)
 	b.sendMessage(ctx, chatID, report)
 }
 
@@ -1408,7 +1509,7 @@ func (b *Bot) handleDeleteAccounting(ctx context.Context, botInstance *bot.Bot,
 	}
 	group, err := b.groupService.GetOrCreateGroup(ctx, chatInfo)
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, "查询失败")
+		b.sendStorageError(ctx, chatID, err, "查询失败")
 		return
 	}
 
@@ -1420,7 +1521,7 @@ func (b *Bot) handleDeleteAccounting(ctx context.Context, botInstance *bot.Bot,
 	// 获取最近2天的记录
 	records, err := b.accountingService.GetRecentRecordsForDeletion(ctx, chatID)
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, err.Error())
+		b.sendStorageError(ctx, chatID, err, err.Error())
 		return
 	}
 
@@ -1434,7 +1535,7 @@ func (b *Bot) handleDeleteAccounting(ctx context.Context, botInstance *bot.Bot,
 	for _, record := range records {
 		// 格式：MM-DD HH:MM | ±金额 货币 [删除]
 		dateStr := record.RecordedAt.Format("01-02 15:04")
-		amountStr := formatRecordAmount(record.Amount, record.Currency)
+		amountStr := formatRecordAmount(record.Amount, record.Currency, group.Settings.CurrencySymbols)
 		buttonText := fmt.Sprintf("%s | %s", dateStr, amountStr)
 
 		keyboard = append(keyboard, []botModels.InlineKeyboardButton{
@@ -1458,27 +1559,23 @@ func (b *Bot) handleDeleteAccounting(ctx context.Context, botInstance *bot.Bot,
 	}
 }
 
-// formatRecordAmount 格式化记录金额（用于删除界面）
-func formatRecordAmount(amount float64, currency string) string {
-	var currencySymbol string
-	if currency == models.CurrencyUSD {
-		currencySymbol = "U"
-	} else {
-		currencySymbol = "Y"
-	}
-
+// formatRecordAmount 格式化记录金额（用于删除界面），symbols 为群组自定义的货币符号
+func formatRecordAmount(amount float64, currency string, symbols map[string]string) string {
+	var amountStr string
 	if amount == float64(int64(amount)) {
 		// 整数
 		if amount >= 0 {
-			return fmt.Sprintf("+%d%s", int64(amount), currencySymbol)
+			amountStr = fmt.Sprintf("+%d", int64(amount))
+		} else {
+			amountStr = fmt.Sprintf("%d", int64(amount))
 		}
-		return fmt.Sprintf("%d%s", int64(amount), currencySymbol)
-	}
-	// 小数
-	if amount >= 0 {
-		return fmt.Sprintf("+%.2f%s", amount, currencySymbol)
+	} else if amount >= 0 {
+		// 小数
+		amountStr = fmt.Sprintf("+%.2f", amount)
+	} else {
+		amountStr = fmt.Sprintf("%.2f", amount)
 	}
-	return fmt.Sprintf("%.2f%s", amount, currencySymbol)
+	return models.FormatCurrencyAmount(amountStr, models.CurrencySymbol(currency, symbols))
 }
 
 // handleAccountingDeleteCallback 处理删除按钮回调
@@ -1547,7 +1644,7 @@ func (b *Bot) handleClearAccounting(ctx context.Context, botInstance *bot.Bot, u
 	}
 	group, err := b.groupService.GetOrCreateGroup(ctx, chatInfo)
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, "查询失败")
+		b.sendStorageError(ctx, chatID, err, "查询失败")
 		return
 	}
 
@@ -1559,7 +1656,7 @@ func (b *Bot) handleClearAccounting(ctx context.Context, botInstance *bot.Bot, u
 	// 清空所有记录
 	count, err := b.accountingService.ClearAllRecords(ctx, chatID)
 	if err != nil {
-		b.sendErrorMessage(ctx, chatID, err.Error())
+		b.sendStorageError(ctx, chatID, err, err.Error())
 		return
 	}
 
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// 记账重复确认回调：acc_dup:ok 仍要记录，acc_dup:no 取消；待记录内容取自提示消息回复的原消息
+const (
+	accountingDedupCallbackPrefix  = "acc_dup:"
+	accountingDedupCallbackConfirm = accountingDedupCallbackPrefix + "ok"
+	accountingDedupCallbackCancel  = accountingDedupCallbackPrefix + "no"
+
+	// accountingDedupClaimTTL 确认提示占用记录的保留时长，之后清理（此时提示早已被编辑为处理结果）
+	accountingDedupClaimTTL = time.Hour
+)
+
+// handleAccountingDuplicate 按群组配置检测重复记账，已询问或跳过时返回 true
+func (b *Bot) handleAccountingDuplicate(ctx context.Context, msg *botModels.Message, settings models.GroupSettings) bool {
+	mode := models.AccountingDedupModeOf(settings)
+	if mode == models.AccountingDedupOff {
+		return false
+	}
+
+	// 解析或查询失败时交给 AddRecord 统一处理
+	duplicate, err := b.accountingService.IsDuplicateRecord(ctx, msg.Chat.ID, msg.From.ID, msg.Text)
+	if err != nil || !duplicate {
+		return false
+	}
+
+	if mode == models.AccountingDedupSkip {
+		b.sendMessage(ctx, msg.Chat.ID, "⏭ 检测到重复记录，已自动跳过", msg.ID)
+		return true
+	}
+
+	keyboard := &botModels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]botModels.InlineKeyboardButton{
+			{
+				{Text: "✅ 仍要记录", CallbackData: accountingDedupCallbackConfirm},
+				{Text: "❌ 取消", CallbackData: accountingDedupCallbackCancel},
+			},
+		},
+	}
+	_, _ = b.sendMessageWithMarkupAndMessage(ctx, msg.Chat.ID, "⚠️ 检测到重复，是否仍要记录？", keyboard, msg.ID)
+	return true
+}
+
+// handleAccountingDedupCallback 处理重复记账确认按钮，仅原记账人可操作
+func (b *Bot) handleAccountingDedupCallback(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	query := update.CallbackQuery
+	if query == nil {
+		return
+	}
+	prompt := query.Message.Message
+	if prompt == nil {
+		b.answerCallback(ctx, botInstance, query.ID, "消息已失效", true)
+		return
+	}
+
+	original := prompt.ReplyToMessage
+	if original == nil || original.From == nil {
+		b.editMessage(ctx, prompt.Chat.ID, prompt.ID, "⚠️ 原记账消息已不存在，请重新输入", nil)
+		b.answerCallback(ctx, botInstance, query.ID, "原记账消息已不存在", true)
+		return
+	}
+	if query.From.ID != original.From.ID {
+		b.answerCallback(ctx, botInstance, query.ID, "仅记账人可以确认", true)
+		return
+	}
+	if !b.claimAccountingDedupPrompt(prompt.Chat.ID, prompt.ID) {
+		b.answerCallback(ctx, botInstance, query.ID, "该提示已处理", false)
+		return
+	}
+
+	if query.Data != accountingDedupCallbackConfirm {
+		b.editMessage(ctx, prompt.Chat.ID, prompt.ID, "❌ 已取消，本次未记录", nil)
+		b.answerCallback(ctx, botInstance, query.ID, "已取消", false)
+		return
+	}
+
+	chatID := prompt.Chat.ID
+	if err := b.accountingService.AddRecord(ctx, chatID, original.From.ID, original.Text); err != nil {
+		logger.L().Warnf("Accounting duplicate confirm failed: chat_id=%d, err=%v", chatID, err)
+		b.releaseAccountingDedupPrompt(chatID, prompt.ID)
+		b.answerCallback(ctx, botInstance, query.ID, err.Error(), true)
+		return
+	}
+
+	b.recordFeatureUsage(ctx, chatID, usageAccountingAdd)
+	b.editMessage(ctx, chatID, prompt.ID, "✅ 已记录", nil)
+	b.answerCallback(ctx, botInstance, query.ID, "已记录", false)
+
+	group, err := b.groupService.GetGroupInfo(ctx, chatID)
+	if err != nil || group == nil || !models.IsAccountingAutoReportEnabled(group.Settings) {
+		return
+	}
+
+	report, err := b.accountingService.QueryRecords(ctx, chatID)
+	if err != nil {
+		b.sendErrorMessage(ctx, chatID, "记录成功，但查询账单失败")
+		return
+	}
+	b.sendMessage(ctx, chatID, report)
+}
+
+// claimAccountingDedupPrompt 占用重复确认提示，同一提示只处理一次，防止连续点击「仍要记录」写入重复记录
+func (b *Bot) claimAccountingDedupPrompt(chatID int64, messageID int) bool {
+	now := b.currentTime()
+	b.accountingDedupClaims.Range(func(key, value any) bool {
+		if claimedAt, ok := value.(time.Time); !ok || now.Sub(claimedAt) > accountingDedupClaimTTL {
+			b.accountingDedupClaims.Delete(key)
+		}
+		return true
+	})
+
+	_, loaded := b.accountingDedupClaims.LoadOrStore(accountingDedupClaimKey(chatID, messageID), now)
+	return !loaded
+}
+
+// releaseAccountingDedupPrompt 记录失败时释放占用，允许用户重新确认
+func (b *Bot) releaseAccountingDedupPrompt(chatID int64, messageID int) {
+	b.accountingDedupClaims.Delete(accountingDedupClaimKey(chatID, messageID))
+}
+
+func accountingDedupClaimKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+)
+
+const (
+	accountingQueryCommand = "查询记账"
+	accountingQueryUsage   = "用法：查询记账 [日期] [结束日期]，例如：查询记账 10月26、查询记账 10月1 10月7"
+	// accountingQueryMaxDays 按日期区间查询账单允许的最大天数
+	accountingQueryMaxDays = 31
+)
+
+// accountingQueryArgs 提取"查询记账"后的日期参数；命令后需以空白分隔，避免误伤以"查询记账"开头的普通聊天
+func accountingQueryArgs(text string) ([]string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, accountingQueryCommand) {
+		return nil, false
+	}
+	rest := strings.TrimPrefix(text, accountingQueryCommand)
+	if rest != "" && !strings.ContainsAny(rest[:1], " \t\n") {
+		return nil, false
+	}
+	return strings.Fields(rest), true
+}
+
+// parseAccountingQueryRange 解析账单查询日期（北京时间），返回 [start, end) 区间（end 为结束日期次日零点）
+func parseAccountingQueryRange(args []string, now time.Time) (time.Time, time.Time, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s", accountingQueryUsage)
+	}
+
+	start, err := sifangfeature.ParseSummaryDate(args[0], now, accountingQueryCommand)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%v\n%s", err, accountingQueryUsage)
+	}
+	last := start
+	if len(args) == 2 {
+		last, err = sifangfeature.ParseSummaryDate(args[1], now, accountingQueryCommand)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%v\n%s", err, accountingQueryUsage)
+		}
+	}
+	if last.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if last.After(start.AddDate(0, 0, accountingQueryMaxDays-1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("单次最多查询 %d 天的账单", accountingQueryMaxDays)
+	}
+	return start, last.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,361 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type accountingTestGroupService struct {
+	service.GroupService
+	group *models.Group
+}
+
+func (s *accountingTestGroupService) GetOrCreateGroup(ctx context.Context, chatInfo *service.TelegramChatInfo) (*models.Group, error) {
+	return s.group, nil
+}
+
+func (s *accountingTestGroupService) GetGroupInfo(ctx context.Context, telegramID int64) (*models.Group, error) {
+	return s.group, nil
+}
+
+type accountingTestService struct {
+	service.AccountingService
+	added        int
+	addedInputs  []string
+	queryRecords int
+	duplicate    bool
+	rangeStart   time.Time
+	rangeEnd     time.Time
+	queryErr     error
+}
+
+func (s *accountingTestService) AddRecord(ctx context.Context, chatID, userID int64, input string) error {
+	s.added++
+	s.addedInputs = append(s.addedInputs, input)
+	return nil
+}
+
+func (s *accountingTestService) IsDuplicateRecord(ctx context.Context, chatID, userID int64, input string) (bool, error) {
+	return s.duplicate, nil
+}
+
+func (s *accountingTestService) QueryRecords(ctx context.Context, chatID int64) (string, error) {
+	s.queryRecords++
+	if s.queryErr != nil {
+		return "", s.queryErr
+	}
+	return "📒 今日账单", nil
+}
+
+func (s *accountingTestService) QueryRecordsByRange(ctx context.Context, chatID int64, start, end time.Time) (string, error) {
+	s.rangeStart, s.rangeEnd = start, end
+	return "📒 区间账单", nil
+}
+
+func TestHandleQueryAccountingScopesByDate(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		text      string
+		wantStart string
+		wantEnd   string
+		wantText  string
+	}{
+		{name: "no args", text: "查询记账", wantText: "📒 今日账单"},
+		{name: "single day", text: "查询记账 10月26", wantStart: "2024-10-26", wantEnd: "2024-10-27", wantText: "📒 区间账单"},
+		{name: "range", text: "查询记账 10月1 10月7", wantStart: "2024-10-01", wantEnd: "2024-10-08", wantText: "📒 区间账单"},
+		{name: "invalid date", text: "查询记账 13月40", wantText: "❌ 日期不存在"},
+		{name: "reversed range", text: "查询记账 10月7 10月1", wantText: "❌ 结束日期不能早于开始日期"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			accounting := &accountingTestService{}
+			b := &Bot{
+				bot:               botInstance,
+				groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: models.GroupSettings{AccountingEnabled: true}}},
+				accountingService: accounting,
+				nowFunc:           func() time.Time { return time.Date(2024, 10, 27, 12, 0, 0, 0, mustLoadChinaLocation()) },
+			}
+
+			b.handleQueryAccounting(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: tc.text,
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 7},
+			}})
+
+			sent := api.Messages()
+			if len(sent) != 1 || !strings.HasPrefix(sent[0].Text, tc.wantText) {
+				t.Fatalf("expected reply starting with %q, got %+v", tc.wantText, sent)
+			}
+			if tc.wantStart == "" {
+				if !accounting.rangeStart.IsZero() {
+					t.Fatalf("expected no range query, got start=%s", accounting.rangeStart)
+				}
+				return
+			}
+			if got := accounting.rangeStart.Format("2006-01-02"); got != tc.wantStart {
+				t.Fatalf("expected range start %s, got %s", tc.wantStart, got)
+			}
+			if got := accounting.rangeEnd.Format("2006-01-02"); got != tc.wantEnd {
+				t.Fatalf("expected range end %s, got %s", tc.wantEnd, got)
+			}
+		})
+	}
+}
+
+func TestHandleQueryAccountingReportsDatabaseTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "deadline exceeded", err: fmt.Errorf("查询失败: %w", context.DeadlineExceeded), want: "❌ 数据库超时，请稍后重试"},
+		{name: "other error", err: errors.New("查询失败"), want: "❌ 查询失败"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			b := &Bot{
+				bot:               botInstance,
+				groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: models.GroupSettings{AccountingEnabled: true}}},
+				accountingService: &accountingTestService{queryErr: tc.err},
+			}
+
+			b.handleQueryAccounting(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: "查询记账",
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 7},
+			}})
+
+			sent := api.Messages()
+			if len(sent) != 1 || sent[0].Text != tc.want {
+				t.Fatalf("expected reply %q, got %+v", tc.want, sent)
+			}
+		})
+	}
+}
+
+func TestAccountingQueryArgsRequiresSeparator(t *testing.T) {
+	if _, ok := accountingQueryArgs("查询记账呢"); ok {
+		t.Fatal("expected text without separator not to match")
+	}
+	if args, ok := accountingQueryArgs("查询记账  10月26 "); !ok || len(args) != 1 || args[0] != "10月26" {
+		t.Fatalf("unexpected args: %v ok=%v", args, ok)
+	}
+}
+
+func TestFormatRecordAmountCustomSymbols(t *testing.T) {
+	record := &models.AccountingRecord{Amount: -50.5, Currency: models.CurrencyCNY}
+
+	if got := formatRecordAmount(record.Amount, record.Currency, nil); got != "-50.50Y" {
+		t.Fatalf("expected default symbol output, got %q", got)
+	}
+
+	symbols := map[string]string{models.CurrencyCNY: "¥", models.CurrencyUSD: "$"}
+	if got := formatRecordAmount(record.Amount, record.Currency, symbols); got != "-¥50.50" {
+		t.Fatalf("expected custom symbol output, got %q", got)
+	}
+	if got := formatRecordAmount(100, models.CurrencyUSD, symbols); got != "+$100" {
+		t.Fatalf("expected custom USD symbol output, got %q", got)
+	}
+
+	if got := formatRecordAmount(50, models.CurrencyEUR, nil); got != "+50E" {
+		t.Fatalf("expected default EUR symbol output, got %q", got)
+	}
+
+	if record.Amount != -50.5 || record.Currency != models.CurrencyCNY {
+		t.Fatalf("formatting must not alter stored record: %+v", record)
+	}
+}
+
+func TestHandleAccountingInputAutoReportToggle(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		settings    models.GroupSettings
+		wantQueries int
+		wantText    string
+	}{
+		{name: "default", settings: models.GroupSettings{AccountingEnabled: true}, wantQueries: 1, wantText: "📒 今日账单"},
+		{name: "disabled", settings: models.GroupSettings{AccountingEnabled: true, AccountingAutoReportConfigured: true}, wantQueries: 0, wantText: "✅ 已记录"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			accounting := &accountingTestService{}
+			b := &Bot{
+				bot:               botInstance,
+				groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: tc.settings}},
+				userService:       &helpTestUserService{admins: map[int64]bool{7: true}},
+				accountingService: accounting,
+			}
+
+			handled := b.handleAccountingInput(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: "+100U",
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 7},
+			}})
+			if !handled || accounting.added != 1 {
+				t.Fatalf("expected record to be added, handled=%v added=%d", handled, accounting.added)
+			}
+			if accounting.queryRecords != tc.wantQueries {
+				t.Fatalf("expected %d QueryRecords calls, got %d", tc.wantQueries, accounting.queryRecords)
+			}
+			sent := api.Messages()
+			if len(sent) != 1 || sent[0].Text != tc.wantText {
+				t.Fatalf("expected reply %q, got %+v", tc.wantText, sent)
+			}
+		})
+	}
+}
+
+func TestHandleAccountingInputDuplicateModes(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mode      string
+		duplicate bool
+		wantAdded int
+		wantText  string
+	}{
+		{name: "off", mode: models.AccountingDedupOff, duplicate: true, wantAdded: 1, wantText: "📒 今日账单"},
+		{name: "prompt not duplicate", mode: models.AccountingDedupPrompt, duplicate: false, wantAdded: 1, wantText: "📒 今日账单"},
+		{name: "prompt", mode: models.AccountingDedupPrompt, duplicate: true, wantAdded: 0, wantText: "⚠️ 检测到重复，是否仍要记录？"},
+		{name: "skip", mode: models.AccountingDedupSkip, duplicate: true, wantAdded: 0, wantText: "⏭ 检测到重复记录，已自动跳过"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			accounting := &accountingTestService{duplicate: tc.duplicate}
+			b := &Bot{
+				bot:               botInstance,
+				groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: models.GroupSettings{AccountingEnabled: true, AccountingDedupMode: tc.mode}}},
+				userService:       &helpTestUserService{admins: map[int64]bool{7: true}},
+				accountingService: accounting,
+			}
+
+			handled := b.handleAccountingInput(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: "+100U",
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 7},
+			}})
+			if !handled || accounting.added != tc.wantAdded {
+				t.Fatalf("expected handled with %d records added, handled=%v added=%d", tc.wantAdded, handled, accounting.added)
+			}
+			sent := api.Messages()
+			if len(sent) != 1 || sent[0].Text != tc.wantText {
+				t.Fatalf("expected reply %q, got %+v", tc.wantText, sent)
+			}
+			if tc.mode == models.AccountingDedupPrompt && tc.duplicate {
+				markup := api.Requests("sendMessage")[0].Get("reply_markup")
+				if !strings.Contains(markup, accountingDedupCallbackConfirm) || !strings.Contains(markup, accountingDedupCallbackCancel) {
+					t.Fatalf("expected confirm/cancel buttons, got %s", markup)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleAccountingDedupCallback(t *testing.T) {
+	newQuery := func(data string, clicker int64) *botModels.Update {
+		return &botModels.Update{CallbackQuery: &botModels.CallbackQuery{
+			ID:   "cb",
+			From: botModels.User{ID: clicker},
+			Data: data,
+			Message: botModels.MaybeInaccessibleMessage{Message: &botModels.Message{
+				ID:   2,
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				ReplyToMessage: &botModels.Message{
+					ID:   1,
+					Text: "+100U",
+					From: &botModels.User{ID: 7},
+				},
+			}},
+		}}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		data        string
+		clicker     int64
+		wantAdded   int
+		wantEdit    string
+		wantReports int
+	}{
+		{name: "confirm", data: accountingDedupCallbackConfirm, clicker: 7, wantAdded: 1, wantEdit: "✅ 已记录", wantReports: 1},
+		{name: "cancel", data: accountingDedupCallbackCancel, clicker: 7, wantAdded: 0, wantEdit: "❌ 已取消，本次未记录"},
+		{name: "other user", data: accountingDedupCallbackConfirm, clicker: 8, wantAdded: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			accounting := &accountingTestService{}
+			b := &Bot{
+				bot:               botInstance,
+				groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: models.GroupSettings{AccountingEnabled: true}}},
+				accountingService: accounting,
+			}
+
+			b.handleAccountingDedupCallback(context.Background(), botInstance, newQuery(tc.data, tc.clicker))
+
+			if accounting.added != tc.wantAdded {
+				t.Fatalf("expected %d records added, got %d", tc.wantAdded, accounting.added)
+			}
+			if tc.wantAdded > 0 && accounting.addedInputs[0] != "+100U" {
+				t.Fatalf("expected original input to be recorded, got %v", accounting.addedInputs)
+			}
+			edits := api.Requests("editMessageText")
+			if tc.wantEdit == "" {
+				if len(edits) != 0 {
+					t.Fatalf("expected prompt untouched, got %d edits", len(edits))
+				}
+			} else if len(edits) != 1 || edits[0].Get("text") != tc.wantEdit {
+				t.Fatalf("expected prompt edited to %q, got %v", tc.wantEdit, edits)
+			}
+			if accounting.queryRecords != tc.wantReports {
+				t.Fatalf("expected %d reports, got %d", tc.wantReports, accounting.queryRecords)
+			}
+		})
+	}
+}
+
+func TestHandleAccountingDedupCallbackIsSingleUse(t *testing.T) {
+	botInstance, _ := newTestTelegramBot(t)
+	accounting := &accountingTestService{}
+	b := &Bot{
+		bot:               botInstance,
+		groupService:      &accountingTestGroupService{group: &models.Group{TelegramID: -1001, Settings: models.GroupSettings{AccountingEnabled: true}}},
+		accountingService: accounting,
+	}
+	update := &botModels.Update{CallbackQuery: &botModels.CallbackQuery{
+		ID:   "cb",
+		From: botModels.User{ID: 7},
+		Data: accountingDedupCallbackConfirm,
+		Message: botModels.MaybeInaccessibleMessage{Message: &botModels.Message{
+			ID:             2,
+			Chat:           botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+			ReplyToMessage: &botModels.Message{ID: 1, Text: "+100U", From: &botModels.User{ID: 7}},
+		}},
+	}}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.handleAccountingDedupCallback(context.Background(), botInstance, update)
+		}()
+	}
+	wg.Wait()
+
+	if accounting.added != 1 {
+		t.Fatalf("expected double tap to record once, got %d", accounting.added)
+	}
+}
@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type autoGrantTestUserService struct {
+	service.UserService
+	granted []int64
+}
+
+func (s *autoGrantTestUserService) GrantInviterAdmin(ctx context.Context, info *service.TelegramUserInfo) (bool, error) {
+	s.granted = append(s.granted, info.TelegramID)
+	return true, nil
+}
+
+func botAddedUpdate(chatType botModels.ChatType, inviterID int64) *botModels.Update {
+	return &botModels.Update{
+		MyChatMember: &botModels.ChatMemberUpdated{
+			Chat: botModels.Chat{ID: -1001, Type: chatType, Title: "测试群"},
+			From: botModels.User{ID: inviterID, FirstName: "Inviter"},
+			OldChatMember: botModels.ChatMember{
+				Type: botModels.ChatMemberTypeLeft,
+				Left: &botModels.ChatMemberLeft{},
+			},
+			NewChatMember: botModels.ChatMember{
+				Type:   botModels.ChatMemberTypeMember,
+				Member: &botModels.ChatMemberMember{},
+			},
+		},
+	}
+}
+
+func TestHandleMyChatMemberAutoGrantsInviterWhenEnabled(t *testing.T) {
+	botInstance, _ := newTestTelegramBot(t)
+	users := &autoGrantTestUserService{}
+	b := &Bot{
+		bot:              botInstance,
+		userService:      users,
+		groupService:     &autoLookupTestGroupService{},
+		autoGrantInviter: true,
+	}
+
+	b.handleMyChatMember(context.Background(), botInstance, botAddedUpdate(botModels.ChatTypeSupergroup, 42))
+
+	if len(users.granted) != 1 || users.granted[0] != 42 {
+		t.Fatalf("expected inviter 42 to be granted admin, got %v", users.granted)
+	}
+}
+
+func TestHandleMyChatMemberSkipsAutoGrant(t *testing.T) {
+	cases := []struct {
+		name     string
+		enabled  bool
+		chatType botModels.ChatType
+	}{
+		{name: "toggle off", enabled: false, chatType: botModels.ChatTypeSupergroup},
+		{name: "channel", enabled: true, chatType: botModels.ChatTypeChannel},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, _ := newTestTelegramBot(t)
+			users := &autoGrantTestUserService{}
+			b := &Bot{
+				bot:              botInstance,
+				userService:      users,
+				groupService:     &autoLookupTestGroupService{},
+				autoGrantInviter: tc.enabled,
+			}
+
+			b.handleMyChatMember(context.Background(), botInstance, botAddedUpdate(tc.chatType, 42))
+
+			if len(users.granted) != 0 {
+				t.Fatalf("expected no admin grant, got %v", users.granted)
+			}
+		})
+	}
+}
@@ -63,7 +63,8 @@ func TestHandleTextMessage_BotMessageTriggersSifangAutoLookup(t *testing.T) {
 }
 
 type autoLookupTestGroupService struct {
-	group *models.Group
+	group  *models.Group
+	groups []*models.Group
 }
 
 func (s *autoLookupTestGroupService) CreateOrUpdateGroup(ctx context.Context, group *models.Group) error {
@@ -87,13 +88,17 @@ func (s *autoLookupTestGroupService) MarkBotLeft(ctx context.Context, telegramID
 }
 
 func (s *autoLookupTestGroupService) ListActiveGroups(ctx context.Context) ([]*models.Group, error) {
-	return nil, nil
+	return s.groups, nil
 }
 
 func (s *autoLookupTestGroupService) UpdateGroupSettings(ctx context.Context, telegramID int64, settings models.GroupSettings) error {
 	return nil
 }
 
+func (s *autoLookupTestGroupService) SetGroupTier(ctx context.Context, telegramID int64, tier models.GroupTier) ([]string, error) {
+	return nil, nil
+}
+
 func (s *autoLookupTestGroupService) LeaveGroup(ctx context.Context, telegramID int64) error {
 	return nil
 }
@@ -114,8 +119,14 @@ func (s *autoLookupTestGroupService) RepairGroups(ctx context.Context) (*service
 	return nil, nil
 }
 
+func (s *autoLookupTestGroupService) CheckBindings(ctx context.Context) (*service.BindingCheckResult, error) {
+	return nil, nil
+}
+
 type autoLookupTestPaymentService struct {
 	orderDetailCalled chan string
+	orderDetail       *paymentservice.OrderDetail
+	channelStatuses   []*paymentservice.ChannelStatus
 }
 
 func (s *autoLookupTestPaymentService) GetBalance(ctx context.Context, merchantID int64, historyDays int) (*paymentservice.Balance, error) {
@@ -126,7 +137,7 @@ func (s *autoLookupTestPaymentService) GetSummaryByDay(ctx context.Context, merc
 	return nil, nil
 }
 
-func (s *autoLookupTestPaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time) ([]*paymentservice.SummaryByDayChannel, error) {
+func (s *autoLookupTestPaymentService) GetSummaryByDayByChannel(ctx context.Context, merchantID int64, date time.Time, channelCode string) ([]*paymentservice.SummaryByDayChannel, error) {
 	return nil, nil
 }
 
@@ -135,7 +146,7 @@ func (s *autoLookupTestPaymentService) GetSummaryByDayByPZID(ctx context.Context
 }
 
 func (s *autoLookupTestPaymentService) GetChannelStatus(ctx context.Context, merchantID int64) ([]*paymentservice.ChannelStatus, error) {
-	return nil, nil
+	return s.channelStatuses, nil
 }
 
 func (s *autoLookupTestPaymentService) GetWithdrawList(ctx context.Context, merchantID int64, start, end time.Time, page, pageSize int) (*paymentservice.WithdrawList, error) {
@@ -151,7 +162,12 @@ func (s *autoLookupTestPaymentService) CreateOrder(ctx context.Context, merchant
 }
 
 func (s *autoLookupTestPaymentService) GetOrderDetail(ctx context.Context, merchantID int64, orderNo string, numberType paymentservice.OrderNumberType) (*paymentservice.OrderDetail, error) {
-	s.orderDetailCalled <- orderNo
+	if s.orderDetailCalled != nil {
+		s.orderDetailCalled <- orderNo
+	}
+	if s.orderDetail != nil {
+		return s.orderDetail, nil
+	}
 	return nil, &sifang.APIError{Code: 404, Message: "not found"}
 }
 
@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const balanceEventStatusCommand = "事件状态"
+
+// handleBalanceEventStatus 处理"事件状态"命令，查看余额事件通道积压、处理与丢弃数量（Owner）
+func (b *Bot) handleBalanceEventStatus(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if b.balanceService == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "上游余额功能未启用", msg.ID)
+		return
+	}
+
+	processed, monitorRunning := uint64(0), false
+	if b.balanceMonitor != nil {
+		processed, monitorRunning = b.balanceMonitor.processed.Load(), true
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatBalanceEventStatus(b.balanceService.EventStats(), processed, monitorRunning), msg.ID)
+}
+
+// formatBalanceEventStatus 生成余额事件状态报告
+func formatBalanceEventStatus(stats service.UpstreamBalanceEventStats, processed uint64, monitorRunning bool) string {
+	var sb strings.Builder
+	sb.WriteString("📡 <b>余额事件状态</b>\n")
+	sb.WriteString(fmt.Sprintf("通道积压：%d / %d\n", stats.Queued, stats.Capacity))
+	sb.WriteString(fmt.Sprintf("已发布：%d\n", stats.Published))
+	sb.WriteString(fmt.Sprintf("已处理：%d\n", processed))
+	sb.WriteString(fmt.Sprintf("已丢弃：%d", stats.Dropped))
+
+	if !monitorRunning {
+		sb.WriteString("\n\n⚠️ 余额监控未运行，事件不会被处理")
+	} else if stats.Dropped > 0 {
+		sb.WriteString("\n\n⚠️ 存在丢弃事件，余额提醒处理速度跟不上余额变动")
+	}
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	balanceExportCommand = "导出余额记录"
+	balanceExportUsage   = "用法：导出余额记录 [开始日期] [结束日期]，例如：导出余额记录 10月1 10月7（不填日期时导出今天）"
+	// balanceExportMaxDays 单次导出允许的最大天数
+	balanceExportMaxDays = 31
+)
+
+// handleBalanceExport 处理"导出余额记录 [开始] [结束]"命令，将上游群该日期区间（含首尾，北京时间）的余额变动以 CSV 文件发送。
+// 查询结果通过管道边读边上传，不在内存中拼接整个文件
+func (b *Bot) handleBalanceExport(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	args := strings.Fields(strings.TrimPrefix(strings.TrimSpace(msg.Text), balanceExportCommand))
+	if len(args) > 2 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, balanceExportUsage, msg.ID)
+		return
+	}
+
+	group, err := b.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+	if err != nil {
+		b.sendStorageError(ctx, msg.Chat.ID, err, "获取群组信息失败", msg.ID)
+		return
+	}
+	if models.NormalizeGroupTier(group.Tier) != models.GroupTierUpstream {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "仅上游群可以导出余额记录", msg.ID)
+		return
+	}
+
+	start, end, err := parseBalanceExportRange(args, b.currentTime().In(mustLoadChinaLocation()))
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, err.Error(), msg.ID)
+		return
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan int, 1)
+	go func() {
+		count, err := b.balanceService.ExportLogs(ctx, msg.Chat.ID, start, end, pw)
+		pw.CloseWithError(err)
+		done <- count
+	}()
+
+	// CSV 至少包含 BOM 与表头，先读出表头之后的首字节判断是否有数据，避免上传只有表头的文件
+	reader := bufio.NewReader(pr)
+	header, err := reader.ReadString('\n')
+	if err == nil {
+		_, err = reader.Peek(1)
+	}
+	if err != nil {
+		pr.Close()
+		<-done
+		if errors.Is(err, io.EOF) {
+			b.sendMessage(ctx, msg.Chat.ID, "该时间段内没有余额变动记录", msg.ID)
+			return
+		}
+		b.sendStorageError(ctx, msg.Chat.ID, err, "导出余额记录失败，请稍后重试", msg.ID)
+		return
+	}
+
+	lastDay := end.AddDate(0, 0, -1)
+	document := &types.Document{
+		Filename: fmt.Sprintf("balance_logs_%d_%s_%s.csv", msg.Chat.ID, start.Format("20060102"), lastDay.Format("20060102")),
+		Data:     io.MultiReader(strings.NewReader(header), reader),
+	}
+	caption := fmt.Sprintf("📤 余额记录导出 %s ~ %s", start.Format("2006-01-02"), lastDay.Format("2006-01-02"))
+	_, sendErr := b.sendDocument(ctx, msg.Chat.ID, document, caption, botModels.ParseModeHTML, msg.ID)
+	pr.Close()
+	count := <-done
+
+	if sendErr != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "导出余额记录失败，请稍后重试", msg.ID)
+		return
+	}
+	logger.L().Infof("Balance logs exported: chat_id=%d range=%s~%s count=%d user_id=%d",
+		msg.Chat.ID, start.Format("2006-01-02"), lastDay.Format("2006-01-02"), count, msg.From.ID)
+}
+
+// parseBalanceExportRange 解析导出的起止日期，返回 [start, end) 区间；不填日期时为今天，只填一个日期时为当天
+func parseBalanceExportRange(args []string, now time.Time) (time.Time, time.Time, error) {
+	if len(args) == 0 {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return today, today.AddDate(0, 0, 1), nil
+	}
+	start, err := sifangfeature.ParseSummaryDate(args[0], now, balanceExportCommand)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	last := start
+	if len(args) > 1 {
+		if last, err = sifangfeature.ParseSummaryDate(args[1], now, balanceExportCommand); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if last.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if last.After(start.AddDate(0, 0, balanceExportMaxDays-1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("单次最多导出 %d 天的余额记录", balanceExportMaxDays)
+	}
+	return start, last.AddDate(0, 0, 1), nil
+}
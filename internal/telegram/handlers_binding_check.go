@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const bindingCheckCommand = "/bindings"
+
+// bindingIssueLabels 绑定问题类型的展示名称
+var bindingIssueLabels = map[service.BindingIssueKind]string{
+	service.BindingIssueMissingMerchant:    "缺少商户号",
+	service.BindingIssueMissingInterface:   "缺少接口",
+	service.BindingIssueOrphanInterface:    "孤立接口",
+	service.BindingIssueDuplicateInterface: "重复接口",
+}
+
+// handleBindingCheck 处理 /bindings 命令，检查商户号与接口绑定是否存在配置错误
+func (b *Bot) handleBindingCheck(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	result, err := b.groupService.CheckBindings(ctx)
+	if err != nil {
+		b.sendStorageError(ctx, msg.Chat.ID, err, html.EscapeString("绑定检查失败："+err.Error()), msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatBindingCheckResult(result), msg.ID)
+}
+
+// formatBindingCheckResult 生成绑定检查报告
+func formatBindingCheckResult(result *service.BindingCheckResult) string {
+	var text strings.Builder
+	text.WriteString("🔗 绑定健康检查完成\n")
+	text.WriteString(fmt.Sprintf("总群组数：%d（商户群 %d，上游群 %d）\n", result.TotalGroups, result.MerchantGroups, result.UpstreamGroups))
+	text.WriteString(fmt.Sprintf("发现问题：%d\n", len(result.Issues)))
+
+	if len(result.Issues) == 0 {
+		text.WriteString("\n✅ 所有商户号与接口绑定均正常")
+		return text.String()
+	}
+
+	text.WriteString("\n⚠️ 以下绑定需要处理：\n")
+	for i, issue := range result.Issues {
+		text.WriteString(fmt.Sprintf("%d. [%s] %s (%d)\n", i+1, bindingIssueLabels[issue.Kind], html.EscapeString(issue.Title), issue.GroupID))
+		if issue.InterfaceID != "" {
+			text.WriteString(fmt.Sprintf("   接口：<code>%s</code>\n", html.EscapeString(issue.InterfaceID)))
+		}
+		if len(issue.GroupIDs) > 0 {
+			ids := make([]string, 0, len(issue.GroupIDs))
+			for _, id := range issue.GroupIDs {
+				ids = append(ids, fmt.Sprintf("%d", id))
+			}
+			text.WriteString(fmt.Sprintf("   涉及群组：%s\n", strings.Join(ids, "、")))
+		}
+		text.WriteString(fmt.Sprintf("   - %s\n", html.EscapeString(issue.Detail)))
+	}
+	return text.String()
+}
@@ -0,0 +1,156 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	commandAliasCommand   = "设置别名"
+	commandAliasMaxLength = 16
+	commandAliasMaxCount  = 20
+)
+
+// handleSetCommandAlias 处理"设置别名 [别名] [命令]"命令：不带参数时列出别名，省略命令时删除别名
+func (b *Bot) handleSetCommandAlias(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	group, err := b.groupService.GetOrCreateGroup(ctx, &service.TelegramChatInfo{
+		ChatID:   msg.Chat.ID,
+		Type:     string(msg.Chat.Type),
+		Title:    msg.Chat.Title,
+		Username: msg.Chat.Username,
+	})
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "获取群组信息失败", msg.ID)
+		return
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(msg.Text), commandAliasCommand))
+	if len(fields) == 0 {
+		b.sendMessage(ctx, msg.Chat.ID, formatCommandAliases(group.Settings.CommandAliases), msg.ID)
+		return
+	}
+
+	alias := models.NormalizeCommandAlias(fields[0])
+	target := strings.Join(fields[1:], " ")
+	if target != "" {
+		if errMsg := b.validateCommandAlias(ctx, alias, target); errMsg != "" {
+			b.sendErrorMessage(ctx, msg.Chat.ID, errMsg, msg.ID)
+			return
+		}
+	}
+
+	settings := group.Settings
+	aliases := maps.Clone(settings.CommandAliases)
+	if aliases == nil {
+		aliases = make(map[string]string, 1)
+	}
+	if target == "" {
+		if _, ok := aliases[alias]; !ok {
+			b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("别名 %s 不存在", escapeHTML(alias)), msg.ID)
+			return
+		}
+		delete(aliases, alias)
+	} else {
+		if _, ok := aliases[alias]; !ok && len(aliases) >= commandAliasMaxCount {
+			b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("每个群最多设置 %d 个别名", commandAliasMaxCount), msg.ID)
+			return
+		}
+		aliases[alias] = target
+	}
+	if len(aliases) == 0 {
+		aliases = nil
+	}
+	settings.CommandAliases = aliases
+
+	if err := b.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
+		logger.L().Errorf("Update command alias failed: chat_id=%d alias=%s err=%v", msg.Chat.ID, alias, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, "保存命令别名失败", msg.ID)
+		return
+	}
+
+	if target == "" {
+		b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("已删除别名 %s", escapeHTML(alias)), msg.ID)
+		return
+	}
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("已设置别名 <code>%s</code> → <code>%s</code>", escapeHTML(alias), escapeHTML(target)), msg.ID)
+}
+
+// validateCommandAlias 校验别名格式，且不能与系统命令或内置功能指令冲突；目标必须是内置功能指令
+func (b *Bot) validateCommandAlias(ctx context.Context, alias, target string) string {
+	if utf8.RuneCountInString(alias) > commandAliasMaxLength {
+		return fmt.Sprintf("别名最多 %d 个字符", commandAliasMaxLength)
+	}
+	if strings.HasPrefix(alias, "/") {
+		return "别名不能以 / 开头"
+	}
+	if pattern, ok := b.matchRegisteredCommand(alias); ok {
+		return fmt.Sprintf("别名 %s 与系统命令「%s」冲突", escapeHTML(alias), escapeHTML(pattern))
+	}
+	if b.featureManager == nil {
+		return "功能模块未初始化"
+	}
+	if b.featureManager.MatchesText(ctx, alias) {
+		return fmt.Sprintf("别名 %s 与内置指令冲突", escapeHTML(alias))
+	}
+	if !b.featureManager.MatchesText(ctx, target) {
+		return fmt.Sprintf("%s 不是可识别的功能指令", escapeHTML(target))
+	}
+	return ""
+}
+
+// matchRegisteredCommand 判断文本是否会命中已注册的文本命令，返回命中的命令
+func (b *Bot) matchRegisteredCommand(text string) (string, bool) {
+	for _, spec := range b.commands {
+		pattern := strings.ToLower(spec.pattern)
+		switch spec.matchType {
+		case bot.MatchTypePrefix:
+			if strings.HasPrefix(text, pattern) {
+				return spec.pattern, true
+			}
+		case bot.MatchTypeContains:
+			if strings.Contains(text, pattern) {
+				return spec.pattern, true
+			}
+		case matchTypeToken:
+			if matchesCommandToken(text, pattern) {
+				return spec.pattern, true
+			}
+		default:
+			if text == pattern {
+				return spec.pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// formatCommandAliases 生成本群别名列表
+func formatCommandAliases(aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return "ℹ️ 本群暂未设置命令别名\n用法：设置别名 &lt;别名&gt; &lt;命令&gt;，例如：设置别名 bill 账单"
+	}
+
+	var text strings.Builder
+	text.WriteString("🔤 本群命令别名：\n")
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		text.WriteString(fmt.Sprintf("• <code>%s</code> → <code>%s</code>\n", escapeHTML(alias), escapeHTML(aliases[alias])))
+	}
+	text.WriteString("\n删除别名：设置别名 &lt;别名&gt;")
+	return text.String()
+}
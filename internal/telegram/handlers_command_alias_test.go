@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/features"
+	"go_bot/internal/telegram/features/upstream"
+
+	"github.com/go-telegram/bot"
+)
+
+func TestValidateCommandAliasRejectsCollisions(t *testing.T) {
+	manager := features.NewManager(nil)
+	manager.Register(upstream.NewSummaryFeature(nil))
+	b := &Bot{
+		featureManager: manager,
+		commands: []commandSpec{
+			{pattern: "查询记账", matchType: bot.MatchTypeExact},
+			{pattern: upstreamTransferCommand, matchType: matchTypeToken},
+		},
+	}
+
+	cases := map[string]string{
+		"查询记账":  "系统命令",
+		"划转":    "系统命令",
+		"上游账单":  "内置指令",
+		"/bill": "不能以 / 开头",
+	}
+	for alias, want := range cases {
+		if got := b.validateCommandAlias(context.Background(), alias, "上游账单"); !strings.Contains(got, want) {
+			t.Fatalf("alias %q: expected error containing %q, got %q", alias, want, got)
+		}
+	}
+
+	if got := b.validateCommandAlias(context.Background(), "bill", "hello"); !strings.Contains(got, "不是可识别的功能指令") {
+		t.Fatalf("expected unknown target to be rejected, got %q", got)
+	}
+	for _, alias := range []string{"bill", "划转all"} {
+		if got := b.validateCommandAlias(context.Background(), alias, "上游账单"); got != "" {
+			t.Fatalf("alias %q: expected valid alias, got %q", alias, got)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"html"
 	"strings"
 
+	"go_bot/internal/config"
 	"go_bot/internal/logger"
 	"go_bot/internal/telegram/models"
 	"go_bot/internal/telegram/service"
@@ -14,6 +15,9 @@ import (
 	botModels "github.com/go-telegram/bot/models"
 )
 
+// configMenuClosedText 配置菜单关闭后的占位提示
+const configMenuClosedText = "✅ 配置菜单已关闭"
+
 // handleConfigs 处理 /configs 命令
 // 显示交互式配置菜单
 // 注意：权限检查由 RequireAdmin 中间件完成
@@ -152,20 +156,43 @@ func (b *Bot) handleConfigCallback(ctx context.Context, botInstance *bot.Bot, up
 			ReplyMarkup: keyboard,
 		})
 
-		if err != nil {
+		if err != nil && !isMessageNotModified(err) {
 			logger.L().Errorf("Failed to update config menu: %v", err)
 		}
 	}
 
 	// 处理特殊操作：关闭菜单
 	if callbackData == "config:close" {
+		b.closeConfigMenu(ctx, botInstance, chatID, messageID)
+	}
+}
+
+// closeConfigMenu 关闭配置菜单：默认直接删除菜单消息；edit 模式下改为"已关闭"提示并在数秒后自动删除。
+// 删除失败（如缺少删除权限、消息超过 48 小时）时退化为编辑提示，至少移除按钮
+func (b *Bot) closeConfigMenu(ctx context.Context, botInstance *bot.Bot, chatID int64, messageID int) {
+	editMode := b.configMenuCloseMode == config.ConfigMenuCloseEdit
+	if !editMode {
 		_, err := botInstance.DeleteMessage(ctx, &bot.DeleteMessageParams{
 			ChatID:    chatID,
 			MessageID: messageID,
 		})
-		if err != nil {
-			logger.L().Errorf("Failed to delete config menu: %v", err)
+		if err == nil {
+			return
 		}
+		logger.L().Warnf("Failed to delete config menu, falling back to edit: chat_id=%d message_id=%d err=%v", chatID, messageID, err)
+	}
+
+	// 不携带 ReplyMarkup 时 Telegram 会同时移除内联键盘
+	if _, err := botInstance.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Text:      configMenuClosedText,
+	}); err != nil {
+		logger.L().Errorf("Failed to mark config menu closed: chat_id=%d message_id=%d err=%v", chatID, messageID, err)
+		return
+	}
+	if editMode {
+		b.scheduleTemporaryDeletion(chatID, messageID)
 	}
 }
 
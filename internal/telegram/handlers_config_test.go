@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"go_bot/internal/config"
+)
+
+func TestCloseConfigMenuDeletesMessage(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	b.closeConfigMenu(context.Background(), botInstance, -1001, 42)
+
+	deletes := api.Requests("deleteMessage")
+	if len(deletes) != 1 {
+		t.Fatalf("expected one deleteMessage call, got %d", len(deletes))
+	}
+	if deletes[0].Get("chat_id") != "-1001" || deletes[0].Get("message_id") != "42" {
+		t.Fatalf("unexpected delete params: %v", deletes[0])
+	}
+	if edits := api.Requests("editMessageText"); len(edits) != 0 {
+		t.Fatalf("expected no edit after successful delete, got %d", len(edits))
+	}
+}
+
+func TestCloseConfigMenuFallsBackToEditWhenDeleteFails(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+	api.FailNext("deleteMessage", 1)
+
+	b.closeConfigMenu(context.Background(), botInstance, -1001, 42)
+
+	edits := api.Requests("editMessageText")
+	if len(edits) != 1 {
+		t.Fatalf("expected fallback edit, got %d", len(edits))
+	}
+	if edits[0].Get("text") != configMenuClosedText || edits[0].Get("reply_markup") != "" {
+		t.Fatalf("unexpected edit params: %v", edits[0])
+	}
+}
+
+func TestCloseConfigMenuEditMode(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &Bot{bot: botInstance, configMenuCloseMode: config.ConfigMenuCloseEdit, tempMessageCtx: ctx}
+
+	b.closeConfigMenu(context.Background(), botInstance, -1001, 42)
+
+	if edits := api.Requests("editMessageText"); len(edits) != 1 || edits[0].Get("text") != configMenuClosedText {
+		t.Fatalf("expected menu edited to closed notice, got %v", edits)
+	}
+	// 自动删除在临时消息生命周期结束后才执行
+	if deletes := api.Requests("deleteMessage"); len(deletes) != 0 {
+		t.Fatalf("expected delete to be deferred, got %d calls", len(deletes))
+	}
+}
@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	currencySymbolCommand     = "设置货币符号"
+	currencySymbolMaxLength   = 4
+	accountingCurrencyCommand = "设置记账币种"
+)
+
+// handleSetCurrencySymbol 处理"设置货币符号 <币种> [符号]"命令，省略符号时恢复默认
+func (b *Bot) handleSetCurrencySymbol(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(msg.Text), currencySymbolCommand))
+	if len(fields) == 0 || len(fields) > 2 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法：设置货币符号 &lt;币种&gt; [符号]，例如：设置货币符号 USD $", msg.ID)
+		return
+	}
+
+	currency := strings.ToUpper(fields[0])
+	if !models.IsSupportedCurrency(currency) {
+		b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("不支持的币种：%s", escapeHTML(fields[0])), msg.ID)
+		return
+	}
+
+	var symbol string
+	if len(fields) == 2 {
+		symbol = fields[1]
+		if utf8.RuneCountInString(symbol) > currencySymbolMaxLength {
+			b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("货币符号最多 %d 个字符", currencySymbolMaxLength), msg.ID)
+			return
+		}
+	}
+
+	group, err := b.groupService.GetOrCreateGroup(ctx, &service.TelegramChatInfo{
+		ChatID:   msg.Chat.ID,
+		Type:     string(msg.Chat.Type),
+		Title:    msg.Chat.Title,
+		Username: msg.Chat.Username,
+	})
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "获取群组信息失败", msg.ID)
+		return
+	}
+
+	settings := group.Settings
+	symbols := make(map[string]string, len(settings.CurrencySymbols)+1)
+	for code, value := range settings.CurrencySymbols {
+		symbols[code] = value
+	}
+	if symbol == "" {
+		delete(symbols, currency)
+	} else {
+		symbols[currency] = symbol
+	}
+	if len(symbols) == 0 {
+		symbols = nil
+	}
+	settings.CurrencySymbols = symbols
+
+	if err := b.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
+		logger.L().Errorf("Update currency symbol failed: chat_id=%d currency=%s err=%v", msg.Chat.ID, currency, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, "保存货币符号失败", msg.ID)
+		return
+	}
+
+	display := models.CurrencySymbol(currency, symbols)
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("%s 显示符号已设置为 <code>%s</code>，示例：%s",
+		currency, escapeHTML(display), escapeHTML(formatRecordAmount(100, currency, symbols))), msg.ID)
+}
+
+// handleSetAccountingCurrencies 处理"设置记账币种 <币种...>"命令，省略币种时恢复默认（USD/CNY）
+func (b *Bot) handleSetAccountingCurrencies(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	var currencies []string
+	for _, field := range strings.Fields(strings.TrimPrefix(strings.TrimSpace(msg.Text), accountingCurrencyCommand)) {
+		currency := strings.ToUpper(field)
+		if !models.IsSupportedCurrency(currency) {
+			b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("不支持的币种：%s，可用币种：%s",
+				escapeHTML(field), strings.Join(models.SupportedCurrencies, "、")), msg.ID)
+			return
+		}
+		if !slices.Contains(currencies, currency) {
+			currencies = append(currencies, currency)
+		}
+	}
+
+	group, err := b.groupService.GetOrCreateGroup(ctx, &service.TelegramChatInfo{
+		ChatID:   msg.Chat.ID,
+		Type:     string(msg.Chat.Type),
+		Title:    msg.Chat.Title,
+		Username: msg.Chat.Username,
+	})
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "获取群组信息失败", msg.ID)
+		return
+	}
+
+	settings := group.Settings
+	settings.AccountingCurrencies = currencies
+
+	if err := b.groupService.UpdateGroupSettings(ctx, msg.Chat.ID, settings); err != nil {
+		logger.L().Errorf("Update accounting currencies failed: chat_id=%d currencies=%v err=%v", msg.Chat.ID, currencies, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, "保存记账币种失败", msg.ID)
+		return
+	}
+
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("本群记账币种已设置为：%s",
+		strings.Join(models.AllowedAccountingCurrencies(settings), "、")), msg.ID)
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/features"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const featureStatusCommand = "功能状态"
+
+// featureDisplayNames 功能插件的展示名称，未列出的直接显示插件名
+var featureDisplayNames = map[string]string{
+	"calculator":       "计算器",
+	"merchant":         "商户号绑定",
+	"upstream":         "上游接口绑定",
+	"upstream_balance": "上游余额",
+	"upstream_summary": "上游账单",
+	"sifang_payment":   "四方支付（余额 / 账单 / 下发等）",
+	"crypto":           "USDT价格查询",
+}
+
+// handleFeatureStatus 处理"功能状态"命令，列出本群各功能能否使用及原因
+func (b *Bot) handleFeatureStatus(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "此命令只能在群组中使用", msg.ID)
+		return
+	}
+	if b.featureManager == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "功能模块未初始化", msg.ID)
+		return
+	}
+
+	group, err := b.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+	if err != nil || group == nil {
+		logger.L().Errorf("Feature status load group failed: chat_id=%d err=%v", msg.Chat.ID, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, "获取群组信息失败", msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatFeatureStatuses(b.featureManager.FeatureStatuses(ctx, group)), msg.ID)
+}
+
+func formatFeatureStatuses(statuses []features.FeatureStatus) string {
+	var sb strings.Builder
+	sb.WriteString("📋 <b>功能状态</b>\n")
+	for _, status := range statuses {
+		name := featureDisplayNames[status.Name]
+		if name == "" {
+			name = status.Name
+		}
+		if status.Enabled {
+			sb.WriteString(fmt.Sprintf("\n✅ %s", html.EscapeString(name)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n❌ %s：%s", html.EscapeString(name), html.EscapeString(status.Reason)))
+	}
+	return sb.String()
+}
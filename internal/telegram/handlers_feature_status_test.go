@@ -0,0 +1,22 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/features"
+)
+
+func TestFormatFeatureStatuses(t *testing.T) {
+	text := formatFeatureStatuses([]features.FeatureStatus{
+		{Name: "calculator", Enabled: true},
+		{Name: "sifang_payment", Reason: "未绑定商户号，请先使用「绑定 [商户号]」命令"},
+		{Name: "custom", Reason: "<off>"},
+	})
+
+	for _, want := range []string{"✅ 计算器", "❌ 四方支付（余额 / 账单 / 下发等）：未绑定商户号", "❌ custom：&lt;off&gt;"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	featureUsageCommand = "功能使用"
+	featureUsageLimit   = 20
+
+	usageAccountingAdd   = "accounting_add"
+	usageAccountingQuery = "accounting_query"
+	usageBalanceQuery    = "upstream_balance_query"
+)
+
+// recordFeatureUsage 记录 handler 层直接处理的命令使用次数
+func (b *Bot) recordFeatureUsage(ctx context.Context, chatID int64, feature string) {
+	if b.featureUsageService == nil {
+		return
+	}
+	b.featureUsageService.Record(ctx, chatID, feature)
+}
+
+// handleFeatureUsage 处理"功能使用 [群组ID]"命令（Owner）
+func (b *Bot) handleFeatureUsage(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if b.featureUsageService == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "功能使用统计未启用", msg.ID)
+		return
+	}
+
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(msg.Text), featureUsageCommand))
+	var groupID int64
+	if arg != "" {
+		parsed, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			b.sendErrorMessage(ctx, msg.Chat.ID, "用法：功能使用 [群组ID]", msg.ID)
+			return
+		}
+		groupID = parsed
+	}
+
+	usages, err := b.featureUsageService.Top(ctx, groupID, featureUsageLimit)
+	if err != nil {
+		logger.L().Errorf("Query feature usage failed: group_id=%d err=%v", groupID, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, "查询功能使用统计失败", msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatFeatureUsage(groupID, usages), msg.ID)
+}
+
+func formatFeatureUsage(groupID int64, usages []*models.FeatureUsage) string {
+	var sb strings.Builder
+	if groupID == 0 {
+		sb.WriteString("📈 <b>功能使用排行（全部群组）</b>\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("📈 <b>功能使用排行</b>（群组 <code>%d</code>）\n", groupID))
+	}
+
+	if len(usages) == 0 {
+		sb.WriteString("\n暂无使用记录")
+		return sb.String()
+	}
+
+	sb.WriteString("\n")
+	for i, usage := range usages {
+		line := fmt.Sprintf("%d. %s - %d 次", i+1, html.EscapeString(usage.Feature), usage.Count)
+		if !usage.LastUsedAt.IsZero() {
+			line += fmt.Sprintf("（最近：%s）", usage.LastUsedAt.In(mustLoadChinaLocation()).Format("2006-01-02 15:04"))
+		}
+		sb.WriteString(line + "\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const groupTierCommand = "设置群类型"
+
+const groupTierUsage = "用法：设置群类型 &lt;basic|merchant|upstream&gt;"
+
+// handleSetGroupTier 处理"设置群类型"命令，手动设置当前群的等级
+func (b *Bot) handleSetGroupTier(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "此命令只能在群组中使用", msg.ID)
+		return
+	}
+
+	fields := strings.Fields(msg.Text)
+	if len(fields) != 2 || fields[0] != groupTierCommand {
+		b.sendErrorMessage(ctx, msg.Chat.ID, groupTierUsage, msg.ID)
+		return
+	}
+	tier, ok := models.ParseGroupTier(fields[1])
+	if !ok {
+		b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("无效的群类型：%s\n%s", html.EscapeString(fields[1]), groupTierUsage), msg.ID)
+		return
+	}
+
+	warnings, err := b.groupService.SetGroupTier(ctx, msg.Chat.ID, tier)
+	if err != nil {
+		logger.L().Errorf("Set group tier failed: chat_id=%d tier=%s err=%v", msg.Chat.ID, tier, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, err.Error(), msg.ID)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("群类型已设置为：%s（%s）", models.GroupTierDisplayName(tier), tier))
+	for _, warning := range warnings {
+		sb.WriteString("\n⚠️ ")
+		sb.WriteString(warning)
+	}
+	b.sendSuccessMessage(ctx, msg.Chat.ID, sb.String(), msg.ID)
+}
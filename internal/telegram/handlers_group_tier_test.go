@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type groupTierTestService struct {
+	service.GroupService
+	tiers    []models.GroupTier
+	warnings []string
+}
+
+func (s *groupTierTestService) SetGroupTier(ctx context.Context, telegramID int64, tier models.GroupTier) ([]string, error) {
+	s.tiers = append(s.tiers, tier)
+	return s.warnings, nil
+}
+
+func TestHandleSetGroupTier(t *testing.T) {
+	cases := []struct {
+		name      string
+		text      string
+		warnings  []string
+		wantTiers int
+		want      []string
+	}{
+		{name: "valid", text: "设置群类型 merchant", wantTiers: 1, want: []string{"群类型已设置为：商户群（merchant）"}},
+		{name: "case insensitive with warning", text: "设置群类型 Upstream", warnings: []string{"本群尚未绑定接口 ID"}, wantTiers: 1, want: []string{"上游群（upstream）", "⚠️ 本群尚未绑定接口 ID"}},
+		{name: "invalid", text: "设置群类型 vip", want: []string{"无效的群类型：vip", "用法"}},
+		{name: "missing", text: "设置群类型", want: []string{"用法"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			groups := &groupTierTestService{warnings: tc.warnings}
+			b := &Bot{bot: botInstance, groupService: groups}
+
+			b.handleSetGroupTier(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   3,
+				Text: tc.text,
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 1},
+			}})
+
+			if len(groups.tiers) != tc.wantTiers {
+				t.Fatalf("expected %d tier updates, got %v", tc.wantTiers, groups.tiers)
+			}
+			sent := api.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 reply, got %d", len(sent))
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(sent[0].Text, want) {
+					t.Fatalf("expected reply to contain %q, got:\n%s", want, sent[0].Text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const helpCommand = "/help"
+
+// helpSectionTitles 帮助列表中各权限分组的标题
+var helpSectionTitles = []struct {
+	access commandAccess
+	title  string
+}{
+	{access: commandAccessPublic, title: "通用命令（所有成员）"},
+	{access: commandAccessAdmin, title: "管理员命令（Admin+）"},
+	{access: commandAccessOwner, title: "Owner 专属命令"},
+}
+
+// handleHelp 处理 /help 命令：按调用者权限列出已注册命令，/help <命令> 查看详细用法
+func (b *Bot) handleHelp(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 || !isHelpCommand(fields[0]) {
+		return
+	}
+
+	var userID int64
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+	access := b.callerCommandAccess(ctx, userID)
+
+	if len(fields) > 1 {
+		spec, ok := findHelpCommand(b.commands, fields[1], access)
+		if !ok {
+			b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("未找到可用命令 %s，发送 /help 查看全部命令", html.EscapeString(fields[1])), msg.ID)
+			return
+		}
+		b.sendMessage(ctx, msg.Chat.ID, buildCommandHelpText(spec), msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, buildHelpText(b.commands, access), msg.ID)
+}
+
+// isHelpCommand 匹配 /help 与 /help@bot_name，排除 /helpxxx
+func isHelpCommand(command string) bool {
+	if at := strings.Index(command, "@"); at >= 0 {
+		command = command[:at]
+	}
+	return command == helpCommand
+}
+
+// callerCommandAccess 返回调用者可使用的最高命令权限，查询失败时按普通成员处理
+func (b *Bot) callerCommandAccess(ctx context.Context, userID int64) commandAccess {
+	if userID == 0 || b.userService == nil {
+		return commandAccessPublic
+	}
+
+	isOwner, err := b.userService.CheckOwnerPermission(ctx, userID)
+	if err != nil {
+		logger.L().Warnf("Help failed to check owner permission: user_id=%d err=%v", userID, err)
+		return commandAccessPublic
+	}
+	if isOwner {
+		return commandAccessOwner
+	}
+
+	isAdmin, err := b.userService.CheckAdminPermission(ctx, userID)
+	if err != nil {
+		logger.L().Warnf("Help failed to check admin permission: user_id=%d err=%v", userID, err)
+		return commandAccessPublic
+	}
+	if isAdmin {
+		return commandAccessAdmin
+	}
+	return commandAccessPublic
+}
+
+// helpCommands 返回调用者有权使用且带说明的命令（同名命令只保留第一条）
+func helpCommands(specs []commandSpec, maxAccess commandAccess) []commandSpec {
+	seen := make(map[string]bool)
+	var commands []commandSpec
+	for _, spec := range specs {
+		if spec.access > maxAccess || spec.description == "" || seen[spec.pattern] {
+			continue
+		}
+		seen[spec.pattern] = true
+		commands = append(commands, spec)
+	}
+	return commands
+}
+
+func buildHelpText(specs []commandSpec, access commandAccess) string {
+	commands := helpCommands(specs, access)
+
+	var text strings.Builder
+	text.WriteString("<b>🆘 帮助</b>\n")
+	for _, section := range helpSectionTitles {
+		var lines []string
+		for _, spec := range commands {
+			if spec.access == section.access {
+				lines = append(lines, fmt.Sprintf("%s - %s", html.EscapeString(spec.pattern), html.EscapeString(spec.description)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		text.WriteString(fmt.Sprintf("\n<b>%s</b>\n", section.title))
+		text.WriteString(strings.Join(lines, "\n"))
+		text.WriteString("\n")
+	}
+
+	if access >= commandAccessAdmin {
+		text.WriteString("\n")
+		text.WriteString(featureHelpText)
+	}
+
+	text.WriteString("\n发送 <code>/help 命令</code> 查看详细用法，例如：/help /whoami")
+	return text.String()
+}
+
+// findHelpCommand 按名称查找调用者可用的命令，名称可省略开头的 /
+func findHelpCommand(specs []commandSpec, name string, access commandAccess) (commandSpec, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return commandSpec{}, false
+	}
+	for _, spec := range helpCommands(specs, access) {
+		if spec.pattern == name || strings.TrimPrefix(spec.pattern, "/") == strings.TrimPrefix(name, "/") {
+			return spec, true
+		}
+	}
+	return commandSpec{}, false
+}
+
+func buildCommandHelpText(spec commandSpec) string {
+	usage := spec.usage
+	if usage == "" {
+		usage = spec.pattern
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(spec.pattern)))
+	text.WriteString(html.EscapeString(spec.description))
+	text.WriteString(fmt.Sprintf("\n用法：<code>%s</code>", html.EscapeString(usage)))
+	for _, section := range helpSectionTitles {
+		if section.access == spec.access {
+			text.WriteString(fmt.Sprintf("\n权限：%s", section.title))
+			break
+		}
+	}
+	return text.String()
+}
+
+// featureHelpText 由功能插件按文本匹配处理的指令（不在命令注册表中），仅向 Admin+ 展示
+const featureHelpText = `<b>群组消息指令</b>
+撤回 - 在群组中引用机器人的消息发送“撤回”以删除该消息
+
+<b>商户号管理（Admin+，群组）</b>
+绑定 <code>[商户号]</code> - 绑定当前群组的四方商户号
+解绑 - 解除已绑定的商户号（含附加商户号）
+添加商户 <code>[商户号]</code> / 移除商户 <code>[商户号]</code> - 管理附加商户号，余额/账单按商户分段展示
+商户号 / 绑定状态 - 查看当前绑定情况
+
+<b>接口管理（Admin+，群组）</b>
+绑定接口 <code>[接口名称] [接口ID] [费率]</code> - 绑定上游接口并保存名称/费率，可重复执行绑定多个接口
+解绑接口 <code>[接口ID]</code> - 解除指定接口；仅发送“解绑接口”可清空全部
+接口ID / 接口状态 - 查看当前已绑定的接口列表
+
+<b>上游账单查询（上游群）</b>
+上游账单 <code>[接口ID或名称] [可选日期]</code> - 查询指定接口的跑量、商户实收、代理收益和订单数，日期默认为当天
+日结 <code>[可选日期] [--dry-run]</code> - 与 /日结 相同，预览后确认才扣费
+追加扣费 / 补偿 <code>日期 金额 [备注]</code> - 日结后人工更正，记录关联该日结日期并回复最新余额
+导出余额记录 <code>[开始日期] [结束日期]</code> - Admin+，将余额变动记录以 CSV 文件（UTF-8 BOM）发送，默认当天，最多 31 天
+
+<b>四方支付查询（需开启“🏦 四方支付查询”功能并完成商户号绑定）</b>
+余额[可选日期] - 查询余额，例如：余额、余额10月26
+账单[可选日期] - 查询日汇总，例如：账单2023/10/26
+每日00:00:05（北京时间）自动向已绑定商户号的群推送昨日账单
+通道账单[可选日期] - 查看通道维度汇总
+提款明细[可选日期] - 查看提款记录
+导出提款[可选日期] - 自动翻页拉取当日全部提款记录，以 CSV 文件（UTF-8 BOM，可直接用 Excel 打开）发送
+费率 - 查看通道费率
+@机器人 <code>[商户号] 订单号</code> - 在任意聊天中内联查单（需开启 Inline 模式，仅管理员可见结果）
+异常订单 <code>订单号</code> - 诊断商户回调是否成功，展示通知次数、最后错误与通知时间线
+订单原文 <code>订单号</code> [--full] - 仅 Owner，以 JSON 展示上游订单原始字段（含未知字段），默认脱敏银行卡/手机号等，附带 --full 查看原值
+自动查单 - 默认开启，自动识别群内文字/图片/视频标题/文件名中的订单号（长度10-60，含数字，支持机器人消息）并异步查询，可在 /configs 的“🔍 四方自动查单”中关闭
+下发 <code>金额</code> [谷歌验证码] - 申请下发，支持表达式和谷歌验证码，需在 60 秒内按钮确认
+下发 <code>[a|z|k|w][序号] [U金额]</code> [谷歌验证码] - 按欧易报价换算后申请下发，例如：下发 z3 100
+模拟下单 <code>金额</code> [通道代码] [订单号] - 调用 /createorder 模拟创建订单（会真实写单）
+模拟创建订单 <code>金额</code> [通道代码] [订单号] - 模拟下单同义命令
+
+<b>USDT 价格查询（需开启“💰 USDT价格查询”功能，群组）</b>
+<code>[a|z|k|w][序号] [金额]</code> - a=全部、z=支付宝、k=银行卡、w=微信；示例：z3 100
+
+<b>计算器（需开启“🧮 计算器功能”，群组）</b>
+直接发送数学表达式，例如：<code>(100+20)*1.5</code>
+
+<b>收支记账（需开启“💳 收支记账”功能，仅 Admin+，群组）</b>
+查询记账 [日期] [结束日期] - 查看今日或指定日期的账单
+删除记账记录 - 打开最近记录删除菜单
+清零记账 - 清空所有记录
+设置货币符号 <code>币种 [符号]</code> - 自定义账单展示符号，例如：设置货币符号 USD $；省略符号恢复默认
+设置记账币种 <code>币种...</code> - 配置本群可记账币种（USD/CNY/EUR），例如：设置记账币种 USD CNY EUR；省略币种恢复默认 USD/CNY
+记账输入格式示例：<code>+100U</code>、<code>-50Y</code>、<code>+50e</code>、<code>入100*7.2</code>、<code>出50/2Y</code>（后缀 U=USDT、Y=CNY、E=EUR，不区分大小写）
+`
@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type helpTestUserService struct {
+	service.UserService
+	owners map[int64]bool
+	admins map[int64]bool
+}
+
+func (s *helpTestUserService) CheckOwnerPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return s.owners[telegramID], nil
+}
+
+func (s *helpTestUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return s.owners[telegramID] || s.admins[telegramID], nil
+}
+
+func sendHelp(t *testing.T, userID int64, text string) string {
+	t.Helper()
+
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance, userService: &helpTestUserService{
+		owners: map[int64]bool{1: true},
+		admins: map[int64]bool{2: true},
+	}}
+	b.registerHandlers()
+
+	b.handleHelp(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   10,
+		Text: text,
+		Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+		From: &botModels.User{ID: userID},
+	}})
+
+	sent := api.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sent))
+	}
+	return sent[0].Text
+}
+
+func TestHelpHidesOwnerCommandsFromNonOwners(t *testing.T) {
+	member := sendHelp(t, 3, "/help")
+	for _, hidden := range []string{"/grant", "/sifangtest", "划转", "/configs", "管理员命令"} {
+		if strings.Contains(member, hidden) {
+			t.Fatalf("member help must not contain %s: %s", hidden, member)
+		}
+	}
+	if !strings.Contains(member, "/whoami") || !strings.Contains(member, "/help") {
+		t.Fatalf("member help should list public commands: %s", member)
+	}
+
+	admin := sendHelp(t, 2, "/help")
+	if !strings.Contains(admin, "/configs") || !strings.Contains(admin, "重试转单") {
+		t.Fatalf("admin help should list admin commands: %s", admin)
+	}
+	for _, hidden := range []string{"/grant", "/sifangtest", "划转", "Owner 专属命令"} {
+		if strings.Contains(admin, hidden) {
+			t.Fatalf("admin help must not contain owner command %s: %s", hidden, admin)
+		}
+	}
+
+	owner := sendHelp(t, 1, "/help")
+	for _, expected := range []string{"/grant", "/sifangtest", "划转", "Owner 专属命令"} {
+		if !strings.Contains(owner, expected) {
+			t.Fatalf("owner help should contain %s: %s", expected, owner)
+		}
+	}
+}
+
+func TestHelpCommandDetail(t *testing.T) {
+	detail := sendHelp(t, 1, "/help grant")
+	if !strings.Contains(detail, "用法：<code>/grant &lt;user_id&gt;</code>") {
+		t.Fatalf("expected grant usage, got %s", detail)
+	}
+
+	denied := sendHelp(t, 3, "/help /grant")
+	if !strings.Contains(denied, "未找到可用命令") {
+		t.Fatalf("expected owner command detail to be hidden from members, got %s", denied)
+	}
+}
+
+func TestHelpListsEveryDescribedCommand(t *testing.T) {
+	botInstance, _ := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+	b.registerHandlers()
+
+	text := buildHelpText(b.commands, commandAccessOwner)
+	for _, spec := range b.commands {
+		if spec.description == "" {
+			continue
+		}
+		if !strings.Contains(text, spec.pattern+" - ") {
+			t.Fatalf("registered command %s missing from help", spec.pattern)
+		}
+	}
+}
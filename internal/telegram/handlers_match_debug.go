@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const matchDebugCommand = "/match_debug"
+
+// handleMatchDebug 处理 /match_debug [on|off]，开启后在日志中记录每条消息的功能匹配过程
+func (b *Bot) handleMatchDebug(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+	if b.featureManager == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "功能模块未初始化", msg.ID)
+		return
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(msg.Text), matchDebugCommand)))
+	switch arg {
+	case "":
+		status := "关闭"
+		if b.featureManager.MatchDebug() {
+			status = "开启"
+		}
+		b.sendMessage(ctx, msg.Chat.ID, "🐞 功能匹配调试日志："+status+"\n用法：/match_debug on|off", msg.ID)
+	case "on", "off":
+		enabled := arg == "on"
+		b.featureManager.SetMatchDebug(enabled)
+		logger.L().Infof("Feature match debug toggled: enabled=%v user_id=%d", enabled, msg.From.ID)
+		if enabled {
+			b.sendSuccessMessage(ctx, msg.Chat.ID, "已开启功能匹配调试日志，每条消息的匹配过程将写入日志", msg.ID)
+			return
+		}
+		b.sendSuccessMessage(ctx, msg.Chat.ID, "已关闭功能匹配调试日志", msg.ID)
+	default:
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法：/match_debug on|off", msg.ID)
+	}
+}
@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	messageExportCommand = "导出消息"
+	messageExportUsage   = "用法：导出消息 &lt;开始日期&gt; &lt;结束日期&gt;，例如：导出消息 10月1 10月7"
+	// messageExportMaxDays 单次导出允许的最大天数
+	messageExportMaxDays = 31
+)
+
+// handleMessageExport 处理"导出消息 <开始> <结束>"命令，将本群该日期区间（含首尾，北京时间）的消息以 JSON Lines 文件发送。
+// 查询结果通过管道边读边上传，不在内存中拼接整个文件
+func (b *Bot) handleMessageExport(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	args := strings.Fields(strings.TrimPrefix(strings.TrimSpace(msg.Text), messageExportCommand))
+	if len(args) != 2 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, messageExportUsage, msg.ID)
+		return
+	}
+
+	start, end, err := parseMessageExportRange(args[0], args[1], b.currentTime().In(mustLoadChinaLocation()))
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, err.Error(), msg.ID)
+		return
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan int, 1)
+	go func() {
+		count, err := b.messageService.ExportMessages(ctx, msg.Chat.ID, start, end, pw)
+		pw.CloseWithError(err)
+		done <- count
+	}()
+
+	// 先探测是否有数据，避免上传空文件
+	reader := bufio.NewReader(pr)
+	if _, err := reader.Peek(1); err != nil {
+		pr.Close()
+		<-done
+		if errors.Is(err, io.EOF) {
+			b.sendMessage(ctx, msg.Chat.ID, "该时间段内没有消息记录", msg.ID)
+			return
+		}
+		b.sendErrorMessage(ctx, msg.Chat.ID, "导出消息失败，请稍后重试", msg.ID)
+		return
+	}
+
+	lastDay := end.AddDate(0, 0, -1)
+	document := &types.Document{
+		Filename: fmt.Sprintf("messages_%d_%s_%s.jsonl", msg.Chat.ID, start.Format("20060102"), lastDay.Format("20060102")),
+		Data:     reader,
+	}
+	caption := fmt.Sprintf("📤 消息导出 %s ~ %s", start.Format("2006-01-02"), lastDay.Format("2006-01-02"))
+	_, sendErr := b.sendDocument(ctx, msg.Chat.ID, document, caption, botModels.ParseModeHTML, msg.ID)
+	pr.Close()
+	count := <-done
+
+	if sendErr != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "导出消息失败，请稍后重试", msg.ID)
+		return
+	}
+	logger.L().Infof("Messages exported: chat_id=%d range=%s~%s count=%d user_id=%d",
+		msg.Chat.ID, start.Format("2006-01-02"), lastDay.Format("2006-01-02"), count, msg.From.ID)
+}
+
+// parseMessageExportRange 解析导出的起止日期，返回 [start, end) 区间（end 为结束日期次日零点）
+func parseMessageExportRange(rawStart, rawEnd string, now time.Time) (time.Time, time.Time, error) {
+	start, err := sifangfeature.ParseSummaryDate(rawStart, now, messageExportCommand)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	last, err := sifangfeature.ParseSummaryDate(rawEnd, now, messageExportCommand)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if last.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if last.After(start.AddDate(0, 0, messageExportMaxDays-1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("单次最多导出 %d 天的消息", messageExportMaxDays)
+	}
+	return start, last.AddDate(0, 0, 1), nil
+}
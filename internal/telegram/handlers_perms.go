@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	permsCommand = "/perms"
+	// permsGroupListLimit 权限报告中最多列出的群组数量
+	permsGroupListLimit = 20
+)
+
+// userPermissionView 用户权限汇总：全局角色、可管理的群组与私聊可达状态
+type userPermissionView struct {
+	User   *models.User
+	Role   string
+	Groups []*models.Group // 可管理的群组；管理员权限为全局权限，覆盖全部活跃群组
+}
+
+// handlePerms 处理 /perms 命令，查看用户的全局角色、可管理的群组以及是否屏蔽 Bot
+func (b *Bot) handlePerms(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法: /perms &lt;user_id&gt;", msg.ID)
+		return
+	}
+	targetID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "无效的用户 ID", msg.ID)
+		return
+	}
+
+	view, err := b.buildUserPermissionView(ctx, targetID)
+	if err != nil {
+		b.sendStorageError(ctx, msg.Chat.ID, err, "用户不存在或查询失败", msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatUserPermissionView(view), msg.ID)
+}
+
+// buildUserPermissionView 汇总用户记录与群组记录，生成用户的权限视图
+func (b *Bot) buildUserPermissionView(ctx context.Context, userID int64) (*userPermissionView, error) {
+	user, err := b.userService.GetUserInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &userPermissionView{User: user, Role: user.Role}
+	if view.Role == "" {
+		view.Role = models.RoleUser
+	}
+	if !user.IsAdmin() {
+		return view, nil
+	}
+
+	groups, err := b.groupService.ListActiveGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	view.Groups = groups
+	return view, nil
+}
+
+// formatUserPermissionView 生成权限报告
+func formatUserPermissionView(view *userPermissionView) string {
+	user := view.User
+	username := "-"
+	if user.Username != "" {
+		username = "@" + user.Username
+	}
+
+	var text strings.Builder
+	text.WriteString("🔐 用户权限\n\n")
+	text.WriteString(fmt.Sprintf("ID: <code>%d</code>\n", user.TelegramID))
+	text.WriteString(fmt.Sprintf("用户名: %s\n", escapeHTML(username)))
+	text.WriteString(fmt.Sprintf("全局角色: %s %s\n", userRoleEmoji(view.Role), escapeHTML(view.Role)))
+	text.WriteString(fmt.Sprintf("私聊状态: %s\n", userReachabilityText(user)))
+
+	if view.Role != models.RoleOwner && view.Role != models.RoleAdmin {
+		text.WriteString("\n可管理的群组: 无")
+		return text.String()
+	}
+
+	text.WriteString(fmt.Sprintf("\n可管理的群组: 全部活跃群组（%d 个，管理员权限对所有群生效）", len(view.Groups)))
+	for i, group := range view.Groups {
+		if i == permsGroupListLimit {
+			text.WriteString(fmt.Sprintf("\n… 其余 %d 个群组未列出", len(view.Groups)-permsGroupListLimit))
+			break
+		}
+		title := strings.TrimSpace(group.Title)
+		if title == "" {
+			title = "未命名群组"
+		}
+		text.WriteString(fmt.Sprintf("\n• %s (<code>%d</code>)", escapeHTML(title), group.TelegramID))
+	}
+	return text.String()
+}
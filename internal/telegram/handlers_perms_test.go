@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+func TestBuildUserPermissionViewForMultiGroupAdmin(t *testing.T) {
+	blockedAt := time.Date(2024, 11, 20, 8, 30, 0, 0, time.UTC)
+	b := &Bot{
+		userService: &whoamiTestUserService{users: map[int64]*models.User{
+			42: {TelegramID: 42, Username: "alice", Role: models.RoleAdmin, Blocked: true, BlockedAt: &blockedAt},
+			7:  {TelegramID: 7, Role: models.RoleUser},
+		}},
+		groupService: &autoLookupTestGroupService{groups: []*models.Group{
+			{TelegramID: -1001, Title: "商户A"},
+			{TelegramID: -1002, Title: "上游<B>"},
+			{TelegramID: -1003},
+		}},
+	}
+
+	view, err := b.buildUserPermissionView(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("buildUserPermissionView returned error: %v", err)
+	}
+	if view.Role != models.RoleAdmin || len(view.Groups) != 3 {
+		t.Fatalf("unexpected view: role=%s groups=%d", view.Role, len(view.Groups))
+	}
+
+	text := formatUserPermissionView(view)
+	for _, want := range []string{
+		"全局角色: ⭐ admin",
+		"已屏蔽 Bot（2024-11-20 08:30:00 检测到",
+		"全部活跃群组（3 个",
+		"商户A (<code>-1001</code>)",
+		"上游&lt;B&gt; (<code>-1002</code>)",
+		"未命名群组 (<code>-1003</code>)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in report:\n%s", want, text)
+		}
+	}
+
+	view, err = b.buildUserPermissionView(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("buildUserPermissionView returned error: %v", err)
+	}
+	if len(view.Groups) != 0 || !strings.Contains(formatUserPermissionView(view), "可管理的群组: 无") {
+		t.Fatalf("expected regular user to administer no groups, got %+v", view.Groups)
+	}
+
+	if _, err := b.buildUserPermissionView(context.Background(), 99); err == nil {
+		t.Fatal("expected error for unknown user")
+	}
+}
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/telegram/features"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const recentErrorsCommand = "最近错误"
+
+// handleRecentErrors 处理"最近错误"命令，列出本群最近的功能处理失败记录
+func (b *Bot) handleRecentErrors(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "此命令只能在群组中使用", msg.ID)
+		return
+	}
+	if b.featureManager == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "功能模块未初始化", msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatRecentErrors(b.featureManager.RecentErrors(msg.Chat.ID)), msg.ID)
+}
+
+func formatRecentErrors(entries []features.ErrorEntry) string {
+	if len(entries) == 0 {
+		return "✅ 本群暂无错误记录"
+	}
+
+	loc := mustLoadChinaLocation()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧯 <b>最近错误</b>（共 %d 条，最新在前）\n", len(entries)))
+	for _, entry := range entries {
+		sb.WriteString(fmt.Sprintf("\n%s · %s\n", entry.At.In(loc).Format("01-02 15:04:05"), html.EscapeString(entry.Operation)))
+		if entry.Input != "" {
+			sb.WriteString(fmt.Sprintf("触发：<code>%s</code>\n", html.EscapeString(entry.Input)))
+		}
+		sb.WriteString(fmt.Sprintf("错误：%s\n", html.EscapeString(entry.Message)))
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,24 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/features"
+)
+
+func TestFormatRecentErrors(t *testing.T) {
+	if got := formatRecentErrors(nil); !strings.Contains(got, "暂无错误记录") {
+		t.Fatalf("expected empty notice, got %q", got)
+	}
+
+	text := formatRecentErrors([]features.ErrorEntry{
+		{At: time.Date(2024, 10, 26, 2, 3, 4, 0, time.UTC), Operation: "sifang", Input: "余额<1>", Message: "timeout"},
+	})
+	for _, want := range []string{"共 1 条", "10-26 10:03:04 · sifang", "<code>余额&lt;1&gt;</code>", "错误：timeout"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/payment/sifang"
+	"go_bot/internal/telegram/features/types"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	sifangTestCommand = "/sifangtest"
+	sifangTestUsage   = "用法：/sifangtest <商户号>"
+	sifangTestTimeout = 10 * time.Second
+	// sifangTestErrorLimit 报告中错误信息的最大字符数，避免原始响应刷屏
+	sifangTestErrorLimit = 200
+)
+
+// handleSifangTest 处理 /sifangtest 命令：以 0 天历史查询余额，检查四方连接与签名是否可用（Owner）
+func (b *Bot) handleSifangTest(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if !paymentservice.Available(b.paymentService) {
+		b.sendMessage(ctx, msg.Chat.ID, types.PaymentNotConfiguredText, msg.ID)
+		return
+	}
+
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(msg.Text), sifangTestCommand))
+	merchantID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || merchantID <= 0 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, sifangTestUsage, msg.ID)
+		return
+	}
+
+	testCtx, cancel := context.WithTimeout(ctx, sifangTestTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	_, err = b.paymentService.GetBalance(testCtx, merchantID, 0)
+	elapsed := time.Since(startedAt)
+	if err != nil {
+		logger.L().Warnf("Sifang connectivity test failed: merchant_id=%d err=%v", merchantID, err)
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatSifangTestReport(merchantID, elapsed, err), msg.ID)
+}
+
+// formatSifangTestReport 生成连接测试报告，区分签名/鉴权失败、密钥未配置、网络异常与其他业务错误
+func formatSifangTestReport(merchantID int64, elapsed time.Duration, err error) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔌 <b>四方连接测试</b>（商户号 <code>%d</code>）\n", merchantID))
+	sb.WriteString(fmt.Sprintf("耗时：%s\n\n", elapsed.Round(time.Millisecond)))
+
+	var apiErr *sifang.APIError
+	switch {
+	case err == nil:
+		sb.WriteString("✅ 连接正常\n✅ 签名校验通过")
+	case errors.Is(err, sifang.ErrMerchantKeyNotFound):
+		sb.WriteString("❌ 未发送请求：本地未配置该商户的签名密钥\n请检查商户密钥配置")
+	case paymentservice.IsAuthError(err):
+		sb.WriteString("✅ 连接正常\n❌ 签名校验失败：四方拒绝了请求签名或凭证\n")
+		errors.As(err, &apiErr)
+		sb.WriteString(fmt.Sprintf("返回：code=%d，%s\n", apiErr.Code, html.EscapeString(apiErr.Message)))
+		sb.WriteString("请检查商户号与密钥是否匹配")
+	case errors.As(err, &apiErr) && apiErr.Code < 500:
+		sb.WriteString("✅ 连接正常\n✅ 签名校验通过\n⚠️ 四方返回业务错误：")
+		sb.WriteString(fmt.Sprintf("code=%d，%s", apiErr.Code, html.EscapeString(apiErr.Message)))
+	default:
+		sb.WriteString("❌ 连接失败：网络异常或四方服务不可用，未能确认签名\n")
+		sb.WriteString(fmt.Sprintf("错误：%s", html.EscapeString(truncateForDisplay(err.Error(), sifangTestErrorLimit))))
+	}
+	return sb.String()
+}
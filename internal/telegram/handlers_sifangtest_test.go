@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/payment/sifang"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type sifangTestPaymentService struct {
+	paymentservice.Service
+	err         error
+	historyDays []int
+}
+
+func (s *sifangTestPaymentService) GetBalance(ctx context.Context, merchantID int64, historyDays int) (*paymentservice.Balance, error) {
+	s.historyDays = append(s.historyDays, historyDays)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &paymentservice.Balance{}, nil
+}
+
+func TestHandleSifangTestDistinguishesAuthErrorFromSuccess(t *testing.T) {
+	run := func(t *testing.T, err error) string {
+		t.Helper()
+		botInstance, api := newTestTelegramBot(t)
+		payment := &sifangTestPaymentService{err: err}
+		b := &Bot{bot: botInstance, paymentService: payment}
+
+		b.handleSifangTest(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+			ID:   1,
+			Chat: botModels.Chat{ID: 100, Type: botModels.ChatTypePrivate},
+			Text: "/sifangtest 8001",
+		}})
+
+		if len(payment.historyDays) != 1 || payment.historyDays[0] != 0 {
+			t.Fatalf("expected a single balance query with 0 history days, got %v", payment.historyDays)
+		}
+		messages := api.Messages()
+		if len(messages) != 1 {
+			t.Fatalf("expected one report, got %d", len(messages))
+		}
+		return messages[0].Text
+	}
+
+	success := run(t, nil)
+	if !strings.Contains(success, "签名校验通过") || strings.Contains(success, "签名校验失败") {
+		t.Fatalf("unexpected success report: %s", success)
+	}
+
+	authFailure := run(t, &sifang.APIError{Code: 1, Message: "签名错误"})
+	if !strings.Contains(authFailure, "签名校验失败") || !strings.Contains(authFailure, "签名错误") {
+		t.Fatalf("unexpected auth failure report: %s", authFailure)
+	}
+	if authFailure == success {
+		t.Fatal("expected auth failure and success to produce distinct reports")
+	}
+}
+
+func TestFormatSifangTestReportSeparatesNetworkAndMissingKey(t *testing.T) {
+	network := formatSifangTestReport(8001, 0, context.DeadlineExceeded)
+	if !strings.Contains(network, "连接失败") || strings.Contains(network, "签名校验") {
+		t.Fatalf("unexpected network report: %s", network)
+	}
+
+	missingKey := formatSifangTestReport(8001, 0, sifang.ErrMerchantKeyNotFound)
+	if !strings.Contains(missingKey, "未配置该商户的签名密钥") {
+		t.Fatalf("unexpected missing key report: %s", missingKey)
+	}
+
+	business := formatSifangTestReport(8001, 0, &sifang.APIError{Code: 1, Message: "商户不存在"})
+	if !strings.Contains(business, "签名校验通过") || !strings.Contains(business, "商户不存在") {
+		t.Fatalf("unexpected business error report: %s", business)
+	}
+}
@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type adminListTestUserService struct {
+	service.UserService
+	admins []*models.User
+}
+
+func (s *adminListTestUserService) ListAllAdmins(ctx context.Context) ([]*models.User, error) {
+	return s.admins, nil
+}
+
+func TestHandleListAdminsEscapesUserFields(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance, userService: &adminListTestUserService{admins: []*models.User{
+		{TelegramID: 1, FirstName: "<b>Boss</b>", Username: "a&b", Role: models.RoleOwner},
+	}}}
+
+	b.handleListAdmins(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   1,
+		Text: "/admins",
+		Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+		From: &botModels.User{ID: 1},
+	}})
+
+	sent := api.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sent))
+	}
+	if sent[0].ParseMode != string(botModels.ParseModeHTML) {
+		t.Fatalf("expected HTML parse mode, got %q", sent[0].ParseMode)
+	}
+	if !strings.Contains(sent[0].Text, "&lt;b&gt;Boss&lt;/b&gt; (@a&amp;b)") {
+		t.Fatalf("expected escaped admin fields, got %q", sent[0].Text)
+	}
+	if strings.Contains(sent[0].Text, "<b>Boss") {
+		t.Fatalf("admin name must not be rendered as HTML: %q", sent[0].Text)
+	}
+}
+
+type leaveTestGroupService struct {
+	service.GroupService
+	left []int64
+}
+
+func (s *leaveTestGroupService) LeaveGroup(ctx context.Context, telegramID int64) error {
+	s.left = append(s.left, telegramID)
+	return nil
+}
+
+func TestHandleLeaveKeepsGroupWhenLeaveChatFails(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		failures     int
+		wantAttempts int
+		wantLeft     bool
+	}{
+		{name: "retry succeeds", failures: 1, wantAttempts: 2, wantLeft: true},
+		{name: "all attempts fail", failures: leaveChatMaxAttempts, wantAttempts: leaveChatMaxAttempts, wantLeft: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			groups := &leaveTestGroupService{}
+			b := &Bot{bot: botInstance, groupService: groups}
+			api.FailNext("leaveChat", tc.failures)
+
+			b.handleLeave(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: "/leave",
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 1},
+			}})
+
+			if got := len(api.Requests("leaveChat")); got != tc.wantAttempts {
+				t.Fatalf("unexpected leaveChat attempts: %d", got)
+			}
+			if left := len(groups.left) == 1; left != tc.wantLeft {
+				t.Fatalf("expected group marked left=%v, got %v", tc.wantLeft, groups.left)
+			}
+			if !tc.wantLeft {
+				sent := api.Messages()
+				if len(sent) == 0 || !strings.Contains(sent[len(sent)-1].Text, "退出群组失败") {
+					t.Fatalf("expected failure notice, got %+v", sent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// isUnknownCommandCandidate 判断未被其他处理器匹配的消息是否为斜杠命令
+func isUnknownCommandCandidate(update *botModels.Update) bool {
+	return update.Message != nil && strings.HasPrefix(update.Message.Text, "/")
+}
+
+// handleUnknownCommand 群组开启「未知命令提示」后，对未注册的斜杠命令回复 /help 指引。
+// 带 @ 的命令（/cmd@otherbot）可能发给其他 Bot，一律忽略
+func (b *Bot) handleUnknownCommand(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil || msg.From == nil || msg.From.IsBot {
+		return
+	}
+	if msg.Chat.Type != botModels.ChatTypeGroup && msg.Chat.Type != botModels.ChatTypeSupergroup {
+		return
+	}
+
+	command, ok := unknownCommandName(msg.Text)
+	if !ok || isRegisteredCommand(b.commands, command) {
+		return
+	}
+
+	if b.groupService == nil {
+		return
+	}
+	group, err := b.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+	if err != nil || group == nil || !group.Settings.UnknownCommandHintEnabled {
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, fmt.Sprintf("❓ 未知命令 %s，发送 %s 查看可用命令", html.EscapeString(command), helpCommand), msg.ID)
+}
+
+// unknownCommandName 提取消息中的命令名，带 @ 后缀或仅有 / 时返回 false
+func unknownCommandName(text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", false
+	}
+	command := fields[0]
+	if len(command) < 2 || !strings.HasPrefix(command, "/") || strings.Contains(command, "@") {
+		return "", false
+	}
+	return command, true
+}
+
+// isRegisteredCommand 判断命令名是否为已注册命令（例如精确匹配命令附带了多余参数）
+func isRegisteredCommand(specs []commandSpec, command string) bool {
+	for _, spec := range specs {
+		if spec.pattern == command {
+			return true
+		}
+	}
+	return false
+}
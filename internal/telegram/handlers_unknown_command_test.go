@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestHandleUnknownCommand(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		enabled  bool
+		wantHint bool
+	}{
+		{name: "enabled bogus command", text: "/foo", enabled: true, wantHint: true},
+		{name: "disabled bogus command", text: "/foo", enabled: false, wantHint: false},
+		{name: "addressed to other bot", text: "/foo@otherbot", enabled: true, wantHint: false},
+		{name: "registered command with extra args", text: "/ping now", enabled: true, wantHint: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			b := &Bot{
+				bot: botInstance,
+				groupService: &autoLookupTestGroupService{group: &models.Group{
+					TelegramID: -1001,
+					Settings:   models.GroupSettings{UnknownCommandHintEnabled: tc.enabled},
+				}},
+				commands: []commandSpec{{pattern: "/ping", matchType: bot.MatchTypeExact}},
+			}
+
+			update := &botModels.Update{Message: &botModels.Message{
+				ID:   9,
+				Text: tc.text,
+				Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+				From: &botModels.User{ID: 1},
+			}}
+			if !isUnknownCommandCandidate(update) {
+				t.Fatalf("expected slash message to reach unknown command handler")
+			}
+			b.handleUnknownCommand(context.Background(), botInstance, update)
+
+			sent := api.Messages()
+			if !tc.wantHint {
+				if len(sent) != 0 {
+					t.Fatalf("expected no reply, got %+v", sent)
+				}
+				return
+			}
+			if len(sent) != 1 || !strings.Contains(sent[0].Text, "未知命令 /foo") || !strings.Contains(sent[0].Text, "/help") {
+				t.Fatalf("expected unknown command hint, got %+v", sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	upstreamTransferCommand           = "划转"
+	upstreamTransferAllowNegativeFlag = "--allow-negative"
+)
+
+// handleUpstreamTransfer 处理"划转"命令，在两个上游群之间划转余额
+func (b *Bot) handleUpstreamTransfer(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	fromGroupID, toGroupID, amount, allowNegative, ok := parseUpstreamTransferCommand(msg.Text)
+	if !ok {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法：划转 <转出群ID> <转入群ID> <金额> [--allow-negative]", msg.ID)
+		return
+	}
+
+	operationID := fmt.Sprintf("transfer:%d:%d", msg.Chat.ID, msg.ID)
+	result, err := b.balanceService.Transfer(ctx, fromGroupID, toGroupID, amount, msg.From.ID, allowNegative, operationID)
+	if err != nil {
+		logger.L().Errorf("Upstream transfer failed: from=%d to=%d amount=%.2f err=%v", fromGroupID, toGroupID, amount, err)
+		b.sendStorageError(ctx, msg.Chat.ID, err, html.EscapeString("划转失败："+err.Error()), msg.ID)
+		return
+	}
+
+	text := fmt.Sprintf("划转成功：%.2f CNY\n转出群 <code>%d</code> 余额：%.2f CNY\n转入群 <code>%d</code> 余额：%.2f CNY",
+		amount, result.From.GroupID, result.From.Balance, result.To.GroupID, result.To.Balance)
+	b.sendSuccessMessage(ctx, msg.Chat.ID, text, msg.ID)
+}
+
+// parseUpstreamTransferCommand 解析"划转 <转出群> <转入群> <金额> [--allow-negative]"
+func parseUpstreamTransferCommand(text string) (int64, int64, float64, bool, bool) {
+	fields := strings.Fields(strings.TrimSpace(text))
+	allowNegative := false
+	args := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if strings.EqualFold(field, upstreamTransferAllowNegativeFlag) {
+			allowNegative = true
+			continue
+		}
+		args = append(args, field)
+	}
+	if len(args) != 4 || args[0] != upstreamTransferCommand {
+		return 0, 0, 0, false, false
+	}
+
+	fromGroupID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, false, false
+	}
+	toGroupID, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return 0, 0, 0, false, false
+	}
+	amount, err := strconv.ParseFloat(args[3], 64)
+	if err != nil || amount <= 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, 0, 0, false, false
+	}
+	return fromGroupID, toGroupID, amount, allowNegative, true
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type transferErrorBalanceService struct {
+	service.UpstreamBalanceService
+	err error
+}
+
+func (s *transferErrorBalanceService) Transfer(ctx context.Context, fromGroupID, toGroupID int64, amount float64, operatorID int64, allowNegative bool, operationID string) (*service.UpstreamTransferResult, error) {
+	return nil, s.err
+}
+
+func TestParseUpstreamTransferCommand(t *testing.T) {
+	cases := []struct {
+		text          string
+		from, to      int64
+		amount        float64
+		allowNegative bool
+		ok            bool
+	}{
+		{text: "划转 -1001 -1002 100", from: -1001, to: -1002, amount: 100, ok: true},
+		{text: "划转 -1001 -1002 12.5 --allow-negative", from: -1001, to: -1002, amount: 12.5, allowNegative: true, ok: true},
+		{text: "划转 -1001 -1002", ok: false},
+		{text: "划转 -1001 -1002 -5", ok: false},
+		{text: "划转 abc -1002 5", ok: false},
+		{text: "划转记录 -1001 -1002 5", ok: false},
+	}
+
+	for _, tc := range cases {
+		from, to, amount, allowNegative, ok := parseUpstreamTransferCommand(tc.text)
+		if ok != tc.ok {
+			t.Fatalf("parse %q ok=%v, want %v", tc.text, ok, tc.ok)
+		}
+		if !ok {
+			continue
+		}
+		if from != tc.from || to != tc.to || amount != tc.amount || allowNegative != tc.allowNegative {
+			t.Fatalf("parse %q = (%d, %d, %v, %v)", tc.text, from, to, amount, allowNegative)
+		}
+	}
+}
+
+func TestHandleUpstreamTransferEscapesError(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{
+		bot:            botInstance,
+		balanceService: &transferErrorBalanceService{err: &service.ValidationError{Message: "转出群余额不足 <当前 0>"}},
+	}
+
+	b.handleUpstreamTransfer(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   1,
+		Text: "划转 -1001 -1002 100",
+		From: &botModels.User{ID: 7},
+		Chat: botModels.Chat{ID: 42, Type: botModels.ChatTypePrivate},
+	}})
+
+	sent := api.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "划转失败：转出群余额不足 &lt;当前 0&gt;") {
+		t.Fatalf("expected escaped transfer error, got %+v", sent)
+	}
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const clearBlockedCommand = "/clearblocked"
+
+// errUserBlocked 用户已屏蔽 Bot，私聊发送被跳过
+var errUserBlocked = errors.New("user has blocked the bot")
+
+// isBotBlockedError 判断是否为用户屏蔽 Bot 导致的 403 错误
+func isBotBlockedError(err error) bool {
+	return errors.Is(err, bot.ErrorForbidden) && strings.Contains(err.Error(), "bot was blocked by the user")
+}
+
+// noteSendFailure 私聊发送因用户屏蔽 Bot 失败时标记该用户不可达（正数 chatID 即用户私聊）
+func (b *Bot) noteSendFailure(ctx context.Context, chatID int64, err error) {
+	if chatID <= 0 || b.userService == nil || !isBotBlockedError(err) {
+		return
+	}
+	if setErr := b.userService.SetUserBlocked(ctx, chatID, true); setErr != nil {
+		logger.L().Warnf("Failed to mark user %d as blocked: %v", chatID, setErr)
+		return
+	}
+	logger.L().Infof("User %d has blocked the bot, direct messages will be skipped", chatID)
+}
+
+// sendDirectMessage 向用户私聊发送通知，用户已屏蔽 Bot 时跳过并返回 errUserBlocked
+func (b *Bot) sendDirectMessage(ctx context.Context, userID int64, text string) error {
+	if b.userService != nil {
+		if user, err := b.userService.GetUserInfo(ctx, userID); err == nil && user != nil && user.Blocked {
+			logger.L().Debugf("Skip direct message to user %d: bot is blocked", userID)
+			return errUserBlocked
+		}
+	}
+	_, err := b.sendMessageWithMarkupAndMessage(ctx, userID, text, nil)
+	return err
+}
+
+// handleClearBlocked 处理 /clearblocked 命令，清除用户的屏蔽标记（用户解除屏蔽后恢复私聊通知）
+func (b *Bot) handleClearBlocked(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法: /clearblocked &lt;user_id&gt;", msg.ID)
+		return
+	}
+
+	var targetID int64
+	if _, err := fmt.Sscanf(parts[1], "%d", &targetID); err != nil || targetID <= 0 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "无效的用户 ID", msg.ID)
+		return
+	}
+
+	if err := b.userService.SetUserBlocked(ctx, targetID, false); err != nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用户不存在或更新失败", msg.ID)
+		return
+	}
+
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("已清除用户 %d 的屏蔽标记，私聊通知将恢复发送", targetID), msg.ID)
+}
+
+// userReachabilityText 返回 /userinfo 中展示的私聊可达状态
+func userReachabilityText(user *models.User) string {
+	if !user.Blocked {
+		return "✅ 可达"
+	}
+	if user.BlockedAt != nil {
+		return fmt.Sprintf("🚫 已屏蔽 Bot（%s 检测到，/clearblocked 可清除）", user.BlockedAt.Format("2006-01-02 15:04:05"))
+	}
+	return "🚫 已屏蔽 Bot（/clearblocked 可清除）"
+}
@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type blockedTestUserService struct {
+	service.UserService
+	users map[int64]*models.User
+}
+
+func (s *blockedTestUserService) GetUserInfo(ctx context.Context, telegramID int64) (*models.User, error) {
+	user, ok := s.users[telegramID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *blockedTestUserService) SetUserBlocked(ctx context.Context, telegramID int64, blocked bool) error {
+	user, ok := s.users[telegramID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.Blocked = blocked
+	return nil
+}
+
+func TestBlockedSendMarksUserAndSkipsLaterDirectMessages(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	users := &blockedTestUserService{users: map[int64]*models.User{42: {TelegramID: 42}}}
+	b := &Bot{bot: botInstance, userService: users}
+
+	api.FailNextWithCode("sendMessage", 1, http.StatusForbidden, "Forbidden: bot was blocked by the user")
+	if err := b.sendDirectMessage(context.Background(), 42, "报告"); err == nil {
+		t.Fatalf("expected blocked send to fail")
+	}
+	if !users.users[42].Blocked {
+		t.Fatalf("expected user to be marked blocked after 403")
+	}
+
+	if err := b.sendDirectMessage(context.Background(), 42, "第二份报告"); !errors.Is(err, errUserBlocked) {
+		t.Fatalf("expected errUserBlocked, got %v", err)
+	}
+	if got := len(api.Requests("sendMessage")); got != 1 {
+		t.Fatalf("expected later direct message to be skipped, got %d sendMessage requests", got)
+	}
+}
+
+func TestSendFailureOnlyMarksBlockedForPrivateChats(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	users := &blockedTestUserService{users: map[int64]*models.User{42: {TelegramID: 42}}}
+	b := &Bot{bot: botInstance, userService: users}
+
+	api.FailNextWithCode("sendMessage", 1, http.StatusForbidden, "Forbidden: bot was kicked from the group chat")
+	b.sendMessage(context.Background(), 42, "hi")
+	api.FailNextWithCode("sendMessage", 1, http.StatusForbidden, "Forbidden: bot was blocked by the user")
+	b.sendMessage(context.Background(), -1001, "hi")
+
+	if users.users[42].Blocked {
+		t.Fatalf("expected unrelated 403 errors not to mark the user blocked")
+	}
+}
+
+func TestClearBlockedCommandResetsFlag(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	users := &blockedTestUserService{users: map[int64]*models.User{42: {TelegramID: 42, Blocked: true}}}
+	b := &Bot{bot: botInstance, userService: users}
+
+	b.handleClearBlocked(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   10,
+		Text: "/clearblocked 42",
+		Chat: botModels.Chat{ID: 1, Type: botModels.ChatTypePrivate},
+		From: &botModels.User{ID: 1},
+	}})
+
+	if users.users[42].Blocked {
+		t.Fatalf("expected blocked flag cleared")
+	}
+	sent := api.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "已清除用户 42 的屏蔽标记") {
+		t.Fatalf("unexpected reply: %+v", sent)
+	}
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const welcomePreviewCommand = "预览欢迎"
+
+// buildGroupWelcomeText 生成 Bot 加入群组时发送的欢迎消息
+func buildGroupWelcomeText(title string) string {
+	return fmt.Sprintf(
+		"👋 你好！我是 Bot，感谢邀请我加入 %s！\n\n"+
+			"使用 /configs 查看可用配置命令。",
+		escapeHTML(title),
+	)
+}
+
+// handleWelcomePreview 处理"预览欢迎"命令，按当前群名重新发送一次欢迎消息，不影响入群逻辑
+func (b *Bot) handleWelcomePreview(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if msg.Chat.Type != "group" && msg.Chat.Type != "supergroup" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "此命令只能在群组中使用", msg.ID)
+		return
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, buildGroupWelcomeText(msg.Chat.Title), msg.ID)
+}
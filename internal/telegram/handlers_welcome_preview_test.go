@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestHandleWelcomePreviewUsesGroupTitle(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	b.handleWelcomePreview(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   7,
+		Text: welcomePreviewCommand,
+		Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup, Title: "A&B 商户群"},
+		From: &botModels.User{ID: 1},
+	}})
+
+	sent := api.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sent))
+	}
+	if sent[0].Text != buildGroupWelcomeText("A&B 商户群") {
+		t.Fatalf("expected preview to match onboarding text, got:\n%s", sent[0].Text)
+	}
+	if !strings.Contains(sent[0].Text, "感谢邀请我加入 A&amp;B 商户群") {
+		t.Fatalf("expected escaped group title in preview, got:\n%s", sent[0].Text)
+	}
+}
+
+func TestHandleWelcomePreviewRejectsPrivateChat(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	b.handleWelcomePreview(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+		ID:   7,
+		Text: welcomePreviewCommand,
+		Chat: botModels.Chat{ID: 1, Type: botModels.ChatTypePrivate},
+		From: &botModels.User{ID: 1},
+	}})
+
+	sent := api.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "只能在群组中使用") {
+		t.Fatalf("expected group-only error, got %+v", sent)
+	}
+}
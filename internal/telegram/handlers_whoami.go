@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+// handleWhoAmI 处理 /whoami 命令，回复调用者的 ID、用户名、角色及（群组中）群管理员身份
+func (b *Bot) handleWhoAmI(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil || msg.From == nil {
+		return
+	}
+
+	user, err := b.userService.GetUserInfo(ctx, msg.From.ID)
+	if err != nil || user == nil {
+		// 首次使用的用户自动注册
+		if regErr := b.userService.RegisterOrUpdateUser(ctx, &service.TelegramUserInfo{
+			TelegramID:   msg.From.ID,
+			Username:     msg.From.Username,
+			FirstName:    msg.From.FirstName,
+			LastName:     msg.From.LastName,
+			LanguageCode: msg.From.LanguageCode,
+			IsPremium:    msg.From.IsPremium,
+		}); regErr != nil {
+			b.sendErrorMessage(ctx, msg.Chat.ID, "查询失败，请稍后重试", msg.ID)
+			return
+		}
+		user, err = b.userService.GetUserInfo(ctx, msg.From.ID)
+		if err != nil || user == nil {
+			b.sendErrorMessage(ctx, msg.Chat.ID, "查询失败，请稍后重试", msg.ID)
+			return
+		}
+	}
+
+	username := "-"
+	if msg.From.Username != "" {
+		username = "@" + msg.From.Username
+	}
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	var text strings.Builder
+	text.WriteString("🪪 我的信息\n\n")
+	text.WriteString(fmt.Sprintf("ID: <code>%d</code>\n", msg.From.ID))
+	text.WriteString(fmt.Sprintf("用户名: %s\n", escapeHTML(username)))
+	text.WriteString(fmt.Sprintf("角色: %s %s", userRoleEmoji(role), escapeHTML(role)))
+	if msg.Chat.Type == botModels.ChatTypeGroup || msg.Chat.Type == botModels.ChatTypeSupergroup {
+		text.WriteString(fmt.Sprintf("\n群管理员: %s", b.describeChatAdmin(ctx, botInstance, msg.Chat.ID, msg.From.ID)))
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, text.String(), msg.ID)
+}
+
+// describeChatAdmin 查询用户在群内是否为管理员，查询失败时返回"未知"
+func (b *Bot) describeChatAdmin(ctx context.Context, botInstance *bot.Bot, chatID, userID int64) string {
+	member, err := botInstance.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+	if err != nil || member == nil {
+		logger.L().Warnf("Failed to get chat member for whoami: chat_id=%d user_id=%d err=%v", chatID, userID, err)
+		return "未知"
+	}
+	switch member.Type {
+	case botModels.ChatMemberTypeOwner:
+		return "是（群主）"
+	case botModels.ChatMemberTypeAdministrator:
+		return "是"
+	default:
+		return "否"
+	}
+}
+
+// userRoleEmoji 返回角色对应的展示图标
+func userRoleEmoji(role string) string {
+	switch role {
+	case models.RoleOwner:
+		return "👑"
+	case models.RoleAdmin:
+		return "⭐"
+	default:
+		return "👤"
+	}
+}
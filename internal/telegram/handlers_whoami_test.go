@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type whoamiTestUserService struct {
+	service.UserService
+	users      map[int64]*models.User
+	registered []int64
+}
+
+func (s *whoamiTestUserService) GetUserInfo(ctx context.Context, telegramID int64) (*models.User, error) {
+	user, ok := s.users[telegramID]
+	if !ok {
+		return nil, errors.New("获取用户信息失败")
+	}
+	return user, nil
+}
+
+func (s *whoamiTestUserService) RegisterOrUpdateUser(ctx context.Context, info *service.TelegramUserInfo) error {
+	s.registered = append(s.registered, info.TelegramID)
+	s.users[info.TelegramID] = &models.User{TelegramID: info.TelegramID, Username: info.Username, Role: models.RoleUser}
+	return nil
+}
+
+func TestHandleWhoAmI(t *testing.T) {
+	cases := []struct {
+		name         string
+		users        map[int64]*models.User
+		from         *botModels.User
+		wantText     []string
+		wantRegister bool
+	}{
+		{
+			name:     "known admin",
+			users:    map[int64]*models.User{1001: {TelegramID: 1001, Username: "alice", Role: models.RoleAdmin}},
+			from:     &botModels.User{ID: 1001, Username: "alice"},
+			wantText: []string{"ID: <code>1001</code>", "用户名: @alice", "角色: ⭐ admin"},
+		},
+		{
+			name:         "unknown user",
+			users:        map[int64]*models.User{},
+			from:         &botModels.User{ID: 2002, Username: "a<b"},
+			wantText:     []string{"ID: <code>2002</code>", "用户名: @a&lt;b", "角色: 👤 user"},
+			wantRegister: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			userSvc := &whoamiTestUserService{users: tc.users}
+			b := &Bot{bot: botInstance, userService: userSvc}
+
+			b.handleWhoAmI(context.Background(), botInstance, &botModels.Update{Message: &botModels.Message{
+				ID:   5,
+				Text: "/whoami",
+				Chat: botModels.Chat{ID: tc.from.ID, Type: botModels.ChatTypePrivate},
+				From: tc.from,
+			}})
+
+			sent := api.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 message, got %d", len(sent))
+			}
+			for _, want := range tc.wantText {
+				if !strings.Contains(sent[0].Text, want) {
+					t.Fatalf("expected %q in reply:\n%s", want, sent[0].Text)
+				}
+			}
+			if strings.Contains(sent[0].Text, "群管理员") {
+				t.Fatalf("private chat reply should not include group admin status:\n%s", sent[0].Text)
+			}
+			if registered := len(userSvc.registered) == 1; registered != tc.wantRegister {
+				t.Fatalf("expected register=%v, got %v", tc.wantRegister, userSvc.registered)
+			}
+		})
+	}
+}
@@ -2,12 +2,18 @@ package telegram
 
 import (
 	"context"
+	"errors"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/go-telegram/bot"
 	botModels "github.com/go-telegram/bot/models"
 
 	"go_bot/internal/logger"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
 )
 
 const (
@@ -15,6 +21,12 @@ const (
 	temporaryDeleteTimeout   = 5 * time.Second
 )
 
+// escapeHTML 转义用户或群组提供的文本（姓名、用户名、群名、订单号等）
+// 除显式声明纯文本的消息外，Bot 消息统一按 HTML 发送，插值前必须经过此函数
+func escapeHTML(value string) string {
+	return html.EscapeString(value)
+}
+
 // sendMessage 发送消息（统一错误处理，使用 HTML 格式）
 func (b *Bot) sendMessage(ctx context.Context, chatID int64, text string, replyTo ...int) {
 	_, _ = b.sendMessageWithMarkupAndMessage(ctx, chatID, text, nil, replyTo...)
@@ -22,15 +34,22 @@ func (b *Bot) sendMessage(ctx context.Context, chatID int64, text string, replyT
 
 // sendMessageWithMarkupAndMessage 发送消息并返回 Telegram Message
 func (b *Bot) sendMessageWithMarkupAndMessage(ctx context.Context, chatID int64, text string, markup botModels.ReplyMarkup, replyTo ...int) (*botModels.Message, error) {
+	return b.sendMessageWithParseMode(ctx, chatID, text, botModels.ParseModeHTML, markup, replyTo...)
+}
+
+// sendMessageWithParseMode 按指定解析模式发送消息，parseMode 为空时按纯文本发送
+func (b *Bot) sendMessageWithParseMode(ctx context.Context, chatID int64, text string, parseMode botModels.ParseMode, markup botModels.ReplyMarkup, replyTo ...int) (*botModels.Message, error) {
 	params := &bot.SendMessageParams{
 		ChatID:    chatID,
 		Text:      text,
-		ParseMode: botModels.ParseModeHTML,
+		ParseMode: parseMode,
 	}
 
 	if len(replyTo) > 0 && replyTo[0] > 0 {
+		// 原消息已被删除时仍正常发送
 		params.ReplyParameters = &botModels.ReplyParameters{
-			MessageID: replyTo[0],
+			MessageID:                replyTo[0],
+			AllowSendingWithoutReply: true,
 		}
 	}
 
@@ -41,17 +60,114 @@ func (b *Bot) sendMessageWithMarkupAndMessage(ctx context.Context, chatID int64,
 	msg, err := b.bot.SendMessage(ctx, params)
 	if err != nil {
 		logger.L().Errorf("Failed to send message to chat %d: %v", chatID, err)
+		b.noteSendFailure(ctx, chatID, err)
 		return nil, err
 	}
 
 	return msg, nil
 }
 
+// sendFeatureResponse 发送功能插件输出，按 Response 声明的解析模式发送（默认 HTML）
+func (b *Bot) sendFeatureResponse(ctx context.Context, chatID int64, response *types.Response, replyTo ...int) (*botModels.Message, error) {
+	if response == nil || (response.Text == "" && response.Document == nil) {
+		return nil, nil
+	}
+
+	parseMode := response.ParseMode
+	if parseMode == "" {
+		parseMode = botModels.ParseModeHTML
+	} else if parseMode == types.ParseModePlain {
+		parseMode = ""
+	}
+
+	if response.Document != nil {
+		return b.sendDocument(ctx, chatID, response.Document, response.Text, parseMode, replyTo...)
+	}
+
+	msg, err := b.sendLongMessage(ctx, chatID, response.Text, parseMode, response.ReplyMarkup, replyTo...)
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	if response.Temporary {
+		b.scheduleTemporaryDeletion(chatID, msg.ID)
+	}
+	return msg, nil
+}
+
+// deleteMessageBestEffort 删除消息，失败（如 Bot 无删除权限）时仅记录日志
+func (b *Bot) deleteMessageBestEffort(ctx context.Context, chatID int64, messageID int) {
+	if _, err := b.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+	}); err != nil {
+		logger.L().Warnf("Failed to delete message: chat_id=%d message_id=%d err=%v", chatID, messageID, err)
+	}
+}
+
+// sendDocument 上传文件，caption 按 parseMode 解析
+func (b *Bot) sendDocument(ctx context.Context, chatID int64, document *types.Document, caption string, parseMode botModels.ParseMode, replyTo ...int) (*botModels.Message, error) {
+	params := &bot.SendDocumentParams{
+		ChatID:    chatID,
+		Document:  &botModels.InputFileUpload{Filename: document.Filename, Data: document.Data},
+		Caption:   caption,
+		ParseMode: parseMode,
+	}
+	if len(replyTo) > 0 && replyTo[0] > 0 {
+		params.ReplyParameters = &botModels.ReplyParameters{
+			MessageID:                replyTo[0],
+			AllowSendingWithoutReply: true,
+		}
+	}
+
+	msg, err := b.bot.SendDocument(ctx, params)
+	if err != nil {
+		logger.L().Errorf("Failed to send document %s to chat %d: %v", document.Filename, chatID, err)
+		b.noteSendFailure(ctx, chatID, err)
+		return nil, err
+	}
+	return msg, nil
+}
+
+// featureReplyTo 返回功能输出需要引用的消息 ID，群组关闭"回复原消息"时返回空
+func (b *Bot) featureReplyTo(ctx context.Context, msg *botModels.Message) []int {
+	if msg == nil {
+		return nil
+	}
+	if msg.Chat.Type == botModels.ChatTypeGroup || msg.Chat.Type == botModels.ChatTypeSupergroup {
+		if b.groupService != nil {
+			group, err := b.groupService.GetGroupInfo(ctx, msg.Chat.ID)
+			if err == nil && group != nil && !models.IsFeatureReplyEnabled(group.Settings) {
+				return nil
+			}
+		}
+	}
+	return []int{msg.ID}
+}
+
 // sendErrorMessage 发送错误消息
 func (b *Bot) sendErrorMessage(ctx context.Context, chatID int64, message string, replyTo ...int) {
 	b.sendMessage(ctx, chatID, "❌ "+message, replyTo...)
 }
 
+// dbTimeoutMessage 数据库超时时返回给用户的提示
+const dbTimeoutMessage = "数据库超时，请稍后重试"
+
+// storageErrorText 返回数据读写失败时展示给用户的文案：数据库超时单独提示，其余使用 fallback
+func storageErrorText(err error, fallback string) string {
+	if repository.IsTimeout(err) {
+		return dbTimeoutMessage
+	}
+	return fallback
+}
+
+// sendStorageError 发送数据读写失败的错误消息，数据库超时按 warn 记录并提示稍后重试
+func (b *Bot) sendStorageError(ctx context.Context, chatID int64, err error, fallback string, replyTo ...int) {
+	if repository.IsTimeout(err) {
+		logger.L().Warnf("Database timeout: chat_id=%d err=%v", chatID, err)
+	}
+	b.sendErrorMessage(ctx, chatID, storageErrorText(err, fallback), replyTo...)
+}
+
 // sendTemporaryMessage 发送临时消息，会在短时间后自动删除
 func (b *Bot) sendTemporaryMessage(ctx context.Context, chatID int64, text string, replyTo ...int) (*botModels.Message, error) {
 	return b.sendTemporaryMessageWithMarkup(ctx, chatID, text, nil, replyTo...)
@@ -74,6 +190,12 @@ func (b *Bot) sendTemporaryMessageWithMarkup(ctx context.Context, chatID int64,
 		return msg, err
 	}
 
+	b.scheduleTemporaryDeletion(chatID, msg.ID)
+	return msg, nil
+}
+
+// scheduleTemporaryDeletion 在临时消息生命周期结束后删除消息
+func (b *Bot) scheduleTemporaryDeletion(chatID int64, messageID int) {
 	deleteCtx := b.tempMessageCtx
 	if deleteCtx == nil {
 		deleteCtx = context.Background()
@@ -99,12 +221,11 @@ func (b *Bot) sendTemporaryMessageWithMarkup(ctx context.Context, chatID int64,
 		case <-deleteCtx.Done():
 			return
 		}
-	}(chatID, msg.ID)
-
-	return msg, nil
+	}(chatID, messageID)
 }
 
-func (b *Bot) editMessage(ctx context.Context, chatID int64, messageID int, text string, markup botModels.ReplyMarkup) {
+// editMessage 编辑消息文本，内容未变化（message is not modified）时视为成功，其余错误记录日志并返回
+func (b *Bot) editMessage(ctx context.Context, chatID int64, messageID int, text string, markup botModels.ReplyMarkup) error {
 	params := &bot.EditMessageTextParams{
 		ChatID:    chatID,
 		MessageID: messageID,
@@ -115,6 +236,17 @@ func (b *Bot) editMessage(ctx context.Context, chatID int64, messageID int, text
 		params.ReplyMarkup = markup
 	}
 	if _, err := b.bot.EditMessageText(ctx, params); err != nil {
+		if isMessageNotModified(err) {
+			logger.L().Debugf("Edit message skipped, content not modified: chat_id=%d message_id=%d", chatID, messageID)
+			return nil
+		}
 		logger.L().Errorf("Failed to edit message %d in chat %d: %v", messageID, chatID, err)
+		return err
 	}
+	return nil
+}
+
+// isMessageNotModified 判断编辑错误是否因新内容与原消息完全相同（重复刷新时常见，可忽略）
+func isMessageNotModified(err error) bool {
+	return errors.Is(err, bot.ErrorBadRequest) && strings.Contains(err.Error(), "message is not modified")
 }
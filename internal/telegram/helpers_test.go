@@ -0,0 +1,319 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type sentMessage struct {
+	ChatID    string
+	Text      string
+	ParseMode string
+}
+
+type fakeTelegramAPI struct {
+	mu       sync.Mutex
+	messages []sentMessage
+	requests map[string][]url.Values
+	failures map[string]int
+	failDesc map[string]string
+	failCode map[string]int
+}
+
+// newTestTelegramBot 创建指向本地假 Telegram API 的 bot 实例，记录所有请求
+func newTestTelegramBot(t *testing.T) (*bot.Bot, *fakeTelegramAPI) {
+	t.Helper()
+
+	api := &fakeTelegramAPI{requests: make(map[string][]url.Values)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+			t.Errorf("parse request form: %v", err)
+		}
+		method := path.Base(r.URL.Path)
+
+		api.mu.Lock()
+		api.requests[method] = append(api.requests[method], r.Form)
+		if api.failures[method] > 0 {
+			api.failures[method]--
+			description := api.failDesc[method]
+			code := api.failCode[method]
+			api.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			if description == "" {
+				description = "Bad Request: chat not found"
+			}
+			if code == 0 {
+				code = http.StatusBadRequest
+			}
+			_, _ = fmt.Fprintf(w, `{"ok":false,"error_code":%d,"description":%q}`, code, description)
+			return
+		}
+		if method == "sendMessage" {
+			api.messages = append(api.messages, sentMessage{
+				ChatID:    r.FormValue("chat_id"),
+				Text:      r.FormValue("text"),
+				ParseMode: r.FormValue("parse_mode"),
+			})
+		}
+		api.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch method {
+		case "sendMessage", "sendDocument", "editMessageText", "editMessageCaption":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":-1001,"type":"group"}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	botInstance, err := bot.New("test-token", bot.WithServerURL(ts.URL), bot.WithSkipGetMe())
+	if err != nil {
+		t.Fatalf("create bot: %v", err)
+	}
+	return botInstance, api
+}
+
+func (a *fakeTelegramAPI) Messages() []sentMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]sentMessage(nil), a.messages...)
+}
+
+// FailNext 让接下来 n 次指定方法的请求返回失败
+func (a *fakeTelegramAPI) FailNext(method string, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failures == nil {
+		a.failures = make(map[string]int)
+	}
+	a.failures[method] = n
+}
+
+// FailNextWith 让接下来 n 次指定方法的请求返回带指定描述的 400 错误
+func (a *fakeTelegramAPI) FailNextWith(method string, n int, description string) {
+	a.FailNextWithCode(method, n, http.StatusBadRequest, description)
+}
+
+// FailNextWithCode 让接下来 n 次指定方法的请求返回指定错误码与描述
+func (a *fakeTelegramAPI) FailNextWithCode(method string, n int, code int, description string) {
+	a.FailNext(method, n)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failDesc == nil {
+		a.failDesc = make(map[string]string)
+		a.failCode = make(map[string]int)
+	}
+	a.failDesc[method] = description
+	a.failCode[method] = code
+}
+
+func (a *fakeTelegramAPI) Requests(method string) []url.Values {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]url.Values(nil), a.requests[method]...)
+}
+
+func TestSendFeatureResponse_RatesMessageUsesHTML(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	paymentSvc := &autoLookupTestPaymentService{
+		channelStatuses: []*paymentservice.ChannelStatus{
+			{ChannelCode: "zft", ChannelName: "直付通", SystemEnabled: true, MerchantEnabled: true, Rate: "0.09"},
+		},
+	}
+	feature := sifangfeature.New(paymentSvc, nil)
+	msg := &botModels.Message{
+		ID:   10,
+		Text: "费率",
+		Chat: botModels.Chat{ID: -1001, Type: "group"},
+		From: &botModels.User{ID: 1},
+	}
+	group := &models.Group{TelegramID: -1001, Settings: models.GroupSettings{MerchantID: 123456}}
+
+	response, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil || !handled {
+		t.Fatalf("expected rates command to be handled, handled=%v err=%v", handled, err)
+	}
+
+	if _, err := b.sendFeatureResponse(context.Background(), msg.Chat.ID, response, msg.ID); err != nil {
+		t.Fatalf("send feature response: %v", err)
+	}
+
+	sent := api.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sent))
+	}
+	if sent[0].ParseMode != string(botModels.ParseModeHTML) {
+		t.Fatalf("expected HTML parse mode, got %q", sent[0].ParseMode)
+	}
+	if !strings.Contains(sent[0].Text, "<pre>") || !strings.Contains(sent[0].Text, "</pre>") {
+		t.Fatalf("expected <pre> block in rates message: %s", sent[0].Text)
+	}
+}
+
+func TestSendFeatureResponse_Document(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	response := &types.Response{
+		Text:     "📤 导出",
+		Document: &types.Document{Filename: "export.csv", Data: strings.NewReader("a,b\n")},
+	}
+	if _, err := b.sendFeatureResponse(context.Background(), -1001, response, 10); err != nil {
+		t.Fatalf("send feature response: %v", err)
+	}
+
+	if len(api.Messages()) != 0 {
+		t.Fatalf("expected no text message, got %+v", api.Messages())
+	}
+	docs := api.Requests("sendDocument")
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got %d", len(docs))
+	}
+	if docs[0].Get("caption") != "📤 导出" || docs[0].Get("parse_mode") != string(botModels.ParseModeHTML) {
+		t.Fatalf("unexpected document params: %v", docs[0])
+	}
+}
+
+func TestSendFeatureResponse_PlainParseMode(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	response := &types.Response{Text: "a < b", ParseMode: types.ParseModePlain}
+	if _, err := b.sendFeatureResponse(context.Background(), -1001, response); err != nil {
+		t.Fatalf("send feature response: %v", err)
+	}
+
+	sent := api.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sent))
+	}
+	if sent[0].ParseMode != "" {
+		t.Fatalf("expected no parse mode for plain response, got %q", sent[0].ParseMode)
+	}
+	if sent[0].Text != "a < b" {
+		t.Fatalf("expected raw text, got %q", sent[0].Text)
+	}
+}
+
+func TestPaymentCommandsWithoutPaymentService(t *testing.T) {
+	cases := []struct {
+		text    string
+		handler func(*Bot) bot.HandlerFunc
+	}{
+		{text: "/日结", handler: func(b *Bot) bot.HandlerFunc { return b.handleUpstreamSettlement }},
+		{text: "/日结 --dry-run", handler: func(b *Bot) bot.HandlerFunc { return b.handleUpstreamSettlement }},
+		{text: "日结汇总", handler: func(b *Bot) bot.HandlerFunc { return b.handleSettlementForecast }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			b := &Bot{bot: botInstance}
+			update := &botModels.Update{Message: &botModels.Message{
+				ID:   1,
+				Text: tc.text,
+				Chat: botModels.Chat{ID: -1001, Type: "group"},
+				From: &botModels.User{ID: 1},
+			}}
+
+			tc.handler(b)(context.Background(), botInstance, update)
+
+			sent := api.Messages()
+			if len(sent) != 1 || sent[0].Text != types.PaymentNotConfiguredText {
+				t.Fatalf("expected not configured message, got %+v", sent)
+			}
+		})
+	}
+}
+
+func TestFeatureResponseReplyOption(t *testing.T) {
+	msg := &botModels.Message{
+		ID:   42,
+		Text: "余额",
+		Chat: botModels.Chat{ID: -1001, Type: botModels.ChatTypeSupergroup},
+		From: &botModels.User{ID: 1},
+	}
+	response := &types.Response{Text: "余额：100"}
+
+	cases := []struct {
+		name      string
+		settings  models.GroupSettings
+		wantReply bool
+	}{
+		{name: "default on", settings: models.GroupSettings{}, wantReply: true},
+		{name: "disabled", settings: models.GroupSettings{FeatureReplyConfigured: true}, wantReply: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			botInstance, api := newTestTelegramBot(t)
+			b := &Bot{
+				bot:          botInstance,
+				groupService: &autoLookupTestGroupService{group: &models.Group{TelegramID: -1001, Settings: tc.settings}},
+			}
+
+			if _, err := b.sendFeatureResponse(context.Background(), msg.Chat.ID, response, b.featureReplyTo(context.Background(), msg)...); err != nil {
+				t.Fatalf("send feature response: %v", err)
+			}
+
+			requests := api.Requests("sendMessage")
+			if len(requests) != 1 {
+				t.Fatalf("expected 1 sendMessage request, got %d", len(requests))
+			}
+			replyParams := requests[0].Get("reply_parameters")
+			if !tc.wantReply {
+				if replyParams != "" {
+					t.Fatalf("expected no reply parameters, got %s", replyParams)
+				}
+				return
+			}
+			if !strings.Contains(replyParams, `"message_id":42`) || !strings.Contains(replyParams, `"allow_sending_without_reply":true`) {
+				t.Fatalf("expected reply to original message tolerating deletion, got %q", replyParams)
+			}
+		})
+	}
+}
+
+func TestEditMessageIgnoresNotModifiedError(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	api.FailNextWith("editMessageText", 1, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	if err := b.editMessage(context.Background(), -1001, 10, "同样的内容", nil); err != nil {
+		t.Fatalf("expected not modified error to be swallowed, got %v", err)
+	}
+
+	api.FailNextWith("editMessageText", 1, "Bad Request: message to edit not found")
+	err := b.editMessage(context.Background(), -1001, 10, "新内容", nil)
+	if err == nil || !strings.Contains(err.Error(), "message to edit not found") {
+		t.Fatalf("expected real edit error to propagate, got %v", err)
+	}
+	if isMessageNotModified(err) {
+		t.Fatalf("expected real edit error not to be classified as not modified")
+	}
+
+	if err := b.editMessage(context.Background(), -1001, 10, "新内容", nil); err != nil {
+		t.Fatalf("expected successful edit, got %v", err)
+	}
+	if got := len(api.Requests("editMessageText")); got != 3 {
+		t.Fatalf("expected 3 edit requests, got %d", got)
+	}
+}
@@ -0,0 +1,215 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	minInlineOrderQueryLength = 6
+	inlineLookupCacheSeconds  = 5
+	// inlineLookupMaxCandidates 单次内联查询最多尝试的商户号/订单号组合
+	inlineLookupMaxCandidates = 10
+	// inlineMerchantCacheTTL 内联查单缓存已知商户号的时长
+	inlineMerchantCacheTTL = time.Minute
+)
+
+// handleInlineQuery 处理 @bot <订单号> 内联查单，仅管理员可获得结果
+// 单独的订单号会在已知商户中查找，也支持「商户号 订单号」指定商户
+func (b *Bot) handleInlineQuery(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	query := update.InlineQuery
+	if query == nil || query.From == nil {
+		return
+	}
+
+	results := b.buildInlineOrderResults(ctx, query)
+	if _, err := botInstance.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     inlineLookupCacheSeconds,
+		IsPersonal:    true,
+	}); err != nil {
+		logger.L().Errorf("Failed to answer inline query: user_id=%d err=%v", query.From.ID, err)
+	}
+}
+
+func (b *Bot) buildInlineOrderResults(ctx context.Context, query *botModels.InlineQuery) []botModels.InlineQueryResult {
+	results := []botModels.InlineQueryResult{}
+
+	text := strings.TrimSpace(query.Query)
+	if len([]rune(text)) < minInlineOrderQueryLength || !paymentservice.Available(b.paymentService) {
+		return results
+	}
+
+	isAdmin, err := b.userService.CheckAdminPermission(ctx, query.From.ID)
+	if err != nil || !isAdmin {
+		logger.L().Infof("Inline order lookup denied: user_id=%d", query.From.ID)
+		return results
+	}
+
+	candidates := resolveInlineOrderCandidates(text, b.knownMerchantIDs(ctx))
+	details := make([]*paymentservice.OrderDetail, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookupCtx, cancel := context.WithTimeout(ctx, orderLookupTimeout)
+			defer cancel()
+			detail, err := b.paymentService.GetOrderDetail(lookupCtx, candidate.merchantID, candidate.orderNo, paymentservice.OrderNumberTypeAuto)
+			if err != nil {
+				if !paymentservice.IsOrderNotFoundError(err) {
+					logger.L().Warnf("Inline order lookup failed: merchant_id=%d order_no=%s err=%v", candidate.merchantID, candidate.orderNo, err)
+				}
+				return
+			}
+			details[i] = detail
+		}()
+	}
+	wg.Wait()
+
+	// 多个商户都查到同号订单时全部列出，由用户选择，避免商户号互为前缀时猜错
+	for i, candidate := range candidates {
+		detail := details[i]
+		if detail == nil || detail.Order == nil {
+			continue
+		}
+		results = append(results, buildInlineOrderArticle(candidate.merchantID, candidate.orderNo, detail))
+	}
+	return results
+}
+
+// buildInlineOrderArticle 生成单个订单的内联结果
+func buildInlineOrderArticle(merchantID int64, orderNo string, detail *paymentservice.OrderDetail) *botModels.InlineQueryResultArticle {
+	status := strings.TrimSpace(detail.Order.StatusText)
+	if status == "" {
+		status = strings.TrimSpace(detail.Order.Status)
+	}
+	amount := strings.TrimSpace(detail.Order.RealAmount)
+	if amount == "" {
+		amount = strings.TrimSpace(detail.Order.Amount)
+	}
+
+	displayOrderNo := formatLookupOrderNo(merchantID, orderNo)
+	return &botModels.InlineQueryResultArticle{
+		ID:          inlineOrderResultID(merchantID, orderNo),
+		Title:       fmt.Sprintf("%s：%s", displayOrderNo, emptyDash(status)),
+		Description: fmt.Sprintf("商户号：%d，金额：%s", merchantID, emptyDash(amount)),
+		InputMessageContent: &botModels.InputTextMessageContent{
+			MessageText: formatLookupSuccess(merchantID, orderNo, detail),
+			ParseMode:   botModels.ParseModeHTML,
+		},
+	}
+}
+
+// inlineOrderResultID 返回内联结果 ID：Telegram 限制 ID 不超过 64 字节，订单号可能更长，因此取哈希
+func inlineOrderResultID(merchantID int64, orderNo string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", merchantID, orderNo)))
+	return hex.EncodeToString(sum[:12])
+}
+
+// knownMerchantIDs 返回活跃群组绑定的商户号（缓存 inlineMerchantCacheTTL），避免每次按键都查询群组列表
+func (b *Bot) knownMerchantIDs(ctx context.Context) []int64 {
+	if b.groupService == nil {
+		return nil
+	}
+
+	now := b.currentTime()
+	b.inlineMerchantMu.Lock()
+	defer b.inlineMerchantMu.Unlock()
+	if now.Before(b.inlineMerchantExpires) {
+		return b.inlineMerchantIDs
+	}
+
+	groups, err := b.groupService.ListActiveGroups(ctx)
+	if err != nil {
+		logger.L().Warnf("Failed to list groups for inline lookup: %v", err)
+		return b.inlineMerchantIDs
+	}
+
+	seen := make(map[int64]bool)
+	var merchants []int64
+	for _, group := range groups {
+		merchantID := int64(group.Settings.MerchantID)
+		if merchantID == 0 || seen[merchantID] {
+			continue
+		}
+		seen[merchantID] = true
+		merchants = append(merchants, merchantID)
+	}
+	sort.Slice(merchants, func(i, j int) bool { return merchants[i] < merchants[j] })
+
+	b.inlineMerchantIDs = merchants
+	b.inlineMerchantExpires = now.Add(inlineMerchantCacheTTL)
+	return merchants
+}
+
+// inlineOrderCandidate 内联查单要尝试的商户号与订单号组合
+type inlineOrderCandidate struct {
+	merchantID int64
+	orderNo    string
+}
+
+// resolveInlineOrderCandidates 解析内联查询，返回要尝试的商户号与订单号组合（最多 inlineLookupMaxCandidates 个）：
+// 「商户号 订单号」只查该商户；单独的订单号先按带商户号前缀拆分（所有前缀匹配的商户都尝试），
+// 再以完整订单号依次尝试各已知商户
+func resolveInlineOrderCandidates(text string, merchants []int64) []inlineOrderCandidate {
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 2:
+		merchantID, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || merchantID <= 0 {
+			return nil
+		}
+		return []inlineOrderCandidate{{merchantID: merchantID, orderNo: fields[1]}}
+	case 1:
+	default:
+		return nil
+	}
+
+	orderNo := fields[0]
+	var candidates []inlineOrderCandidate
+	seen := make(map[inlineOrderCandidate]bool)
+	add := func(candidate inlineOrderCandidate) {
+		if len(candidates) < inlineLookupMaxCandidates && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	// 优先尝试更长的商户号前缀
+	prefixed := append([]int64(nil), merchants...)
+	sort.SliceStable(prefixed, func(i, j int) bool {
+		return len(strconv.FormatInt(prefixed[i], 10)) > len(strconv.FormatInt(prefixed[j], 10))
+	})
+	for _, merchantID := range prefixed {
+		prefix := strconv.FormatInt(merchantID, 10)
+		if strings.HasPrefix(orderNo, prefix) && len(orderNo) > len(prefix) {
+			add(inlineOrderCandidate{merchantID: merchantID, orderNo: strings.TrimPrefix(orderNo, prefix)})
+		}
+	}
+	for _, merchantID := range merchants {
+		add(inlineOrderCandidate{merchantID: merchantID, orderNo: orderNo})
+	}
+	return candidates
+}
+
+func emptyDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/payment/sifang"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type inlineTestUserService struct {
+	service.UserService
+	admins map[int64]bool
+}
+
+func (s *inlineTestUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return s.admins[telegramID], nil
+}
+
+// inlineTestPaymentService 只有 orders 中登记的「商户号:订单号」能查到订单
+type inlineTestPaymentService struct {
+	autoLookupTestPaymentService
+	orders map[string]*paymentservice.Order
+}
+
+func (s *inlineTestPaymentService) GetOrderDetail(ctx context.Context, merchantID int64, orderNo string, numberType paymentservice.OrderNumberType) (*paymentservice.OrderDetail, error) {
+	if order, ok := s.orders[fmt.Sprintf("%d:%s", merchantID, orderNo)]; ok {
+		return &paymentservice.OrderDetail{Order: order}, nil
+	}
+	return nil, &sifang.APIError{Code: 404, Message: "not found"}
+}
+
+// inlineTestGroupService 统计群组列表查询次数，验证商户号缓存
+type inlineTestGroupService struct {
+	autoLookupTestGroupService
+	lists atomic.Int32
+}
+
+func (s *inlineTestGroupService) ListActiveGroups(ctx context.Context) ([]*models.Group, error) {
+	s.lists.Add(1)
+	return s.autoLookupTestGroupService.ListActiveGroups(ctx)
+}
+
+func newInlineTestBot(t *testing.T) (*Bot, *fakeTelegramAPI) {
+	t.Helper()
+	botInstance, api := newTestTelegramBot(t)
+	paid := &paymentservice.Order{Status: "paid", StatusText: "已支付", Amount: "100.00"}
+	b := &Bot{
+		bot:         botInstance,
+		userService: &inlineTestUserService{admins: map[int64]bool{1: true}},
+		groupService: &inlineTestGroupService{autoLookupTestGroupService: autoLookupTestGroupService{groups: []*models.Group{
+			{TelegramID: -1001, Settings: models.GroupSettings{MerchantID: 2023111}},
+			{TelegramID: -1002, Settings: models.GroupSettings{MerchantID: 3001}},
+		}}},
+		paymentService: &inlineTestPaymentService{orders: map[string]*paymentservice.Order{
+			"2023111:M-123456":  paid,
+			"3001:ORDER-998877": {Status: "unpaid", StatusText: "未支付", Amount: "50.00"},
+		}},
+	}
+	return b, api
+}
+
+func sendInlineQuery(t *testing.T, b *Bot, api *fakeTelegramAPI, userID int64, query string) string {
+	t.Helper()
+	b.handleInlineQuery(context.Background(), b.bot, &botModels.Update{InlineQuery: &botModels.InlineQuery{
+		ID:    "q",
+		From:  &botModels.User{ID: userID},
+		Query: query,
+	}})
+	requests := api.Requests("answerInlineQuery")
+	if len(requests) == 0 {
+		t.Fatal("expected answerInlineQuery call")
+	}
+	return requests[len(requests)-1].Get("results")
+}
+
+func TestHandleInlineQuery_AdminGetsOrderArticle(t *testing.T) {
+	b, api := newInlineTestBot(t)
+	update := &botModels.Update{InlineQuery: &botModels.InlineQuery{
+		ID:    "q1",
+		From:  &botModels.User{ID: 1},
+		Query: "2023111M-123456",
+	}}
+
+	b.handleInlineQuery(context.Background(), b.bot, update)
+
+	requests := api.Requests("answerInlineQuery")
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 answerInlineQuery call, got %d", len(requests))
+	}
+	results := requests[0].Get("results")
+	for _, want := range []string{`"type":"article"`, "2023111M-123456", "已支付"} {
+		if !strings.Contains(results, want) {
+			t.Fatalf("expected %q in results: %s", want, results)
+		}
+	}
+}
+
+func TestHandleInlineQuery_UnauthorizedUserGetsNothing(t *testing.T) {
+	b, api := newInlineTestBot(t)
+	update := &botModels.Update{InlineQuery: &botModels.InlineQuery{
+		ID:    "q2",
+		From:  &botModels.User{ID: 2},
+		Query: "2023111M-123456",
+	}}
+
+	b.handleInlineQuery(context.Background(), b.bot, update)
+
+	requests := api.Requests("answerInlineQuery")
+	if len(requests) != 1 || requests[0].Get("results") != "[]" {
+		t.Fatalf("expected empty results for unauthorized user, got %+v", requests)
+	}
+}
+
+func TestHandleInlineQuery_BareOrderNumberSearchesKnownMerchants(t *testing.T) {
+	b, api := newInlineTestBot(t)
+
+	results := sendInlineQuery(t, b, api, 1, "ORDER-998877")
+	for _, want := range []string{"3001ORDER-998877", "未支付", "商户号：3001"} {
+		if !strings.Contains(results, want) {
+			t.Fatalf("expected %q in results: %s", want, results)
+		}
+	}
+	if strings.Contains(results, "2023111") {
+		t.Fatalf("expected only the merchant owning the order: %s", results)
+	}
+
+	sendInlineQuery(t, b, api, 1, "ORDER-998877x")
+	if got := b.groupService.(*inlineTestGroupService).lists.Load(); got != 1 {
+		t.Fatalf("expected merchant set to be cached, listed groups %d times", got)
+	}
+}
+
+func TestResolveInlineOrderCandidates(t *testing.T) {
+	merchants := []int64{2023, 20231}
+
+	got := resolveInlineOrderCandidates("20231999", merchants)
+	want := []inlineOrderCandidate{
+		{merchantID: 20231, orderNo: "999"},
+		{merchantID: 2023, orderNo: "1999"},
+		{merchantID: 2023, orderNo: "20231999"},
+		{merchantID: 20231, orderNo: "20231999"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected candidates: %+v", got)
+	}
+
+	if got := resolveInlineOrderCandidates("2023111 M-123", merchants); len(got) != 1 || got[0] != (inlineOrderCandidate{merchantID: 2023111, orderNo: "M-123"}) {
+		t.Fatalf("expected explicit merchant candidate, got %+v", got)
+	}
+	if got := resolveInlineOrderCandidates("abc M-123", merchants); len(got) != 0 {
+		t.Fatalf("expected no candidates for invalid merchant, got %+v", got)
+	}
+}
+
+func TestInlineOrderResultIDFitsTelegramLimit(t *testing.T) {
+	id := inlineOrderResultID(2023111, strings.Repeat("M", 80))
+	if len(id) > 64 || id == inlineOrderResultID(2023111, strings.Repeat("M", 81)) {
+		t.Fatalf("unexpected result id %q", id)
+	}
+}
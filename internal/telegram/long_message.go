@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	// telegramMessageLimit Telegram 单条消息的最大长度（按 UTF-16 编码单元计算）
+	telegramMessageLimit = 4096
+
+	preCloseTag     = "</pre>"
+	preCodeCloseTag = "</code></pre>"
+)
+
+// sendLongMessage 发送可能超过 Telegram 单条上限的消息：按行拆分为多条依次发送，
+// 回复引用只加在第一条，按钮只加在最后一条，返回最后一条消息
+func (b *Bot) sendLongMessage(ctx context.Context, chatID int64, text string, parseMode botModels.ParseMode, markup botModels.ReplyMarkup, replyTo ...int) (*botModels.Message, error) {
+	chunks := splitLongMessage(text, telegramMessageLimit, parseMode == botModels.ParseModeHTML)
+
+	var last *botModels.Message
+	for i, chunk := range chunks {
+		var chunkMarkup botModels.ReplyMarkup
+		if i == len(chunks)-1 {
+			chunkMarkup = markup
+		}
+		var chunkReplyTo []int
+		if i == 0 {
+			chunkReplyTo = replyTo
+		}
+
+		msg, err := b.sendMessageWithParseMode(ctx, chatID, chunk, parseMode, chunkMarkup, chunkReplyTo...)
+		if err != nil {
+			return last, err
+		}
+		last = msg
+	}
+	return last, nil
+}
+
+// splitLongMessage 按行边界把文本拆成不超过 limit 的多段；html 为 true 时，
+// 跨段的 <pre> 块会在段尾补齐闭合标签并在下一段开头重新打开，保证每段都是合法 HTML
+func splitLongMessage(text string, limit int, html bool) []string {
+	if messageLength(text) <= limit {
+		return []string{text}
+	}
+
+	reserve := 0
+	if html {
+		reserve = messageLength(preCodeCloseTag)
+	}
+
+	var (
+		chunks  []string
+		current strings.Builder
+		length  int
+		hasBody bool
+		openTag string
+	)
+	start := func() {
+		current.Reset()
+		length, hasBody = 0, false
+		if openTag != "" {
+			current.WriteString(openTag)
+			length = messageLength(openTag)
+		}
+	}
+	flush := func() {
+		chunk := strings.TrimRight(current.String(), "\n")
+		if openTag != "" {
+			chunk += preCloseTagFor(openTag)
+		}
+		if strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+		start()
+	}
+	write := func(piece string) {
+		current.WriteString(piece)
+		length += messageLength(piece)
+		hasBody = true
+		if html {
+			openTag = nextPreState(piece, openTag)
+		}
+	}
+
+	start()
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if length+messageLength(line)+reserve > limit && hasBody {
+			flush()
+		}
+		// 单行本身超长时按字符强制切分
+		for length+messageLength(line)+reserve > limit {
+			cut := cutMessagePrefix(line, limit-reserve-length)
+			if cut == 0 {
+				break
+			}
+			write(line[:cut])
+			line = line[cut:]
+			flush()
+		}
+		write(line)
+	}
+	flush()
+	return chunks
+}
+
+// messageLength 按 Telegram 的计数方式（UTF-16 编码单元）计算长度
+func messageLength(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
+
+// cutMessagePrefix 返回不超过 budget 长度的前缀字节数，避免把 HTML 标签或实体切断
+func cutMessagePrefix(text string, budget int) int {
+	cut, length := 0, 0
+	for cut < len(text) {
+		r, size := utf8.DecodeRuneInString(text[cut:])
+		if length+utf16.RuneLen(r) > budget {
+			break
+		}
+		length += utf16.RuneLen(r)
+		cut += size
+	}
+
+	prefix := text[:cut]
+	if open := strings.LastIndex(prefix, "<"); open > strings.LastIndex(prefix, ">") && open > 0 {
+		cut = open
+	}
+	if amp := strings.LastIndex(text[:cut], "&"); amp > strings.LastIndex(text[:cut], ";") && amp > 0 {
+		cut = amp
+	}
+	return cut
+}
+
+// nextPreState 根据一段 HTML 更新 <pre> 块的打开状态，返回段末仍未闭合的开始标签（含紧随的 <code>）
+func nextPreState(text, openTag string) string {
+	for {
+		if openTag != "" {
+			idx := strings.Index(text, preCloseTag)
+			if idx < 0 {
+				return openTag
+			}
+			openTag = ""
+			text = text[idx+len(preCloseTag):]
+			continue
+		}
+
+		idx := strings.Index(text, "<pre")
+		if idx < 0 {
+			return ""
+		}
+		rest := text[idx:]
+		end := strings.Index(rest, ">")
+		if end < 0 || (rest[4] != '>' && rest[4] != ' ') {
+			text = rest[4:]
+			continue
+		}
+		tag := rest[:end+1]
+		rest = rest[end+1:]
+		if strings.HasPrefix(rest, "<code") {
+			if codeEnd := strings.Index(rest, ">"); codeEnd >= 0 {
+				tag += rest[:codeEnd+1]
+				rest = rest[codeEnd+1:]
+			}
+		}
+		openTag = tag
+		text = rest
+	}
+}
+
+func preCloseTagFor(openTag string) string {
+	if strings.Contains(openTag, "<code") {
+		return preCodeCloseTag
+	}
+	return preCloseTag
+}
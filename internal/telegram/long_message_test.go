@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	paymentservice "go_bot/internal/payment/service"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/models"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestSendFeatureResponseSplitsLongRatesTable(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	statuses := make([]*paymentservice.ChannelStatus, 0, 200)
+	for i := 0; i < 200; i++ {
+		statuses = append(statuses, &paymentservice.ChannelStatus{
+			ChannelCode:     fmt.Sprintf("ch%03d", i),
+			ChannelName:     fmt.Sprintf("通道名称<%d>", i),
+			SystemEnabled:   true,
+			MerchantEnabled: true,
+			Rate:            "0.09",
+		})
+	}
+	feature := sifangfeature.New(&autoLookupTestPaymentService{channelStatuses: statuses}, nil)
+	msg := &botModels.Message{ID: 10, Text: "费率", Chat: botModels.Chat{ID: -1001, Type: "group"}, From: &botModels.User{ID: 1}}
+	group := &models.Group{TelegramID: -1001, Settings: models.GroupSettings{MerchantID: 123456}}
+
+	response, handled, err := feature.Process(context.Background(), msg, group)
+	if err != nil || !handled {
+		t.Fatalf("expected rates command to be handled, handled=%v err=%v", handled, err)
+	}
+	if messageLength(response.Text) <= telegramMessageLimit {
+		t.Fatalf("expected rates table over the limit, got %d", messageLength(response.Text))
+	}
+	if _, err := b.sendFeatureResponse(context.Background(), msg.Chat.ID, response, msg.ID); err != nil {
+		t.Fatalf("send feature response: %v", err)
+	}
+
+	sent := api.Messages()
+	if len(sent) < 2 {
+		t.Fatalf("expected rates table split into several messages, got %d", len(sent))
+	}
+	var rows int
+	for i, message := range sent {
+		if n := messageLength(message.Text); n > telegramMessageLimit {
+			t.Fatalf("chunk %d exceeds limit: %d", i, n)
+		}
+		if !strings.HasPrefix(strings.TrimPrefix(message.Text, "📡 通道费率\n"), "<pre>") || !strings.HasSuffix(message.Text, "</pre>") {
+			t.Fatalf("chunk %d has unbalanced <pre> block: %q ... %q", i, message.Text[:20], message.Text[len(message.Text)-20:])
+		}
+		if strings.Count(message.Text, "<pre>") != 1 || strings.Count(message.Text, "</pre>") != 1 {
+			t.Fatalf("chunk %d should contain exactly one <pre> block", i)
+		}
+		rows += strings.Count(message.Text, "&lt;")
+	}
+	if rows != 200 {
+		t.Fatalf("expected all 200 rows delivered, got %d", rows)
+	}
+}
+
+func TestSplitLongMessageReopensPreCodeBlock(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("标题\n<pre><code class=\"language-json\">")
+	for i := 0; i < 40; i++ {
+		sb.WriteString(fmt.Sprintf("  \"key_%02d\": \"&lt;value&gt;\",\n", i))
+	}
+	sb.WriteString("</code></pre>\n结尾")
+
+	chunks := splitLongMessage(sb.String(), 300, true)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if messageLength(chunk) > 300 {
+			t.Fatalf("chunk %d exceeds limit: %d", i, messageLength(chunk))
+		}
+		if strings.Count(chunk, "<pre>") != strings.Count(chunk, "</pre>") {
+			t.Fatalf("chunk %d breaks <pre> block: %q", i, chunk)
+		}
+		if i > 0 && i < len(chunks)-1 && !strings.HasPrefix(chunk, "<pre><code class=\"language-json\">") {
+			t.Fatalf("chunk %d should reopen the code block: %q", i, chunk)
+		}
+	}
+	if joined := strings.Join(chunks, "\n"); !strings.Contains(joined, "key_39") || !strings.HasSuffix(joined, "结尾") {
+		t.Fatalf("expected content preserved, got %q", joined)
+	}
+
+	if chunks := splitLongMessage("short", 300, true); len(chunks) != 1 || chunks[0] != "short" {
+		t.Fatalf("expected short text untouched, got %v", chunks)
+	}
+}
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -10,15 +12,87 @@ import (
 const (
 	CurrencyUSD = "USD" // USDT
 	CurrencyCNY = "CNY" // 人民币
+	CurrencyEUR = "EUR" // 欧元
 )
 
+// SupportedCurrencies 可用于记账的全部币种（账单按此顺序展示）
+var SupportedCurrencies = []string{CurrencyUSD, CurrencyCNY, CurrencyEUR}
+
+// DefaultAccountingCurrencies 群组未配置时允许的记账币种
+var DefaultAccountingCurrencies = []string{CurrencyUSD, CurrencyCNY}
+
+// currencyTokens 记账输入中的币种后缀（不区分大小写），如 +100u、+50e
+var currencyTokens = map[string]string{
+	"U": CurrencyUSD,
+	"Y": CurrencyCNY,
+	"E": CurrencyEUR,
+}
+
+// CurrencyFromToken 将记账输入中的币种后缀转换为币种代码
+func CurrencyFromToken(token string) (string, bool) {
+	currency, ok := currencyTokens[strings.ToUpper(token)]
+	return currency, ok
+}
+
+// IsSupportedCurrency 判断币种代码是否可用于记账
+func IsSupportedCurrency(currency string) bool {
+	for _, candidate := range SupportedCurrencies {
+		if candidate == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultCurrencySymbols 默认货币展示符号
+var DefaultCurrencySymbols = map[string]string{
+	CurrencyUSD: "U",
+	CurrencyCNY: "Y",
+	CurrencyEUR: "E",
+}
+
+// CurrencySymbol 返回货币展示符号，优先使用群组自定义配置，未知币种回退为币种代码
+func CurrencySymbol(currency string, custom map[string]string) string {
+	if symbol := strings.TrimSpace(custom[currency]); symbol != "" {
+		return symbol
+	}
+	if symbol, ok := DefaultCurrencySymbols[currency]; ok {
+		return symbol
+	}
+	return currency
+}
+
+// FormatCurrencyAmount 组合带正负号的金额与货币符号
+// 字母符号后置（+100U），其余符号紧跟正负号前置（+$100）
+func FormatCurrencyAmount(signedAmount, symbol string) string {
+	if symbol == "" {
+		return signedAmount
+	}
+	if isLetterSymbol(symbol) {
+		return signedAmount + symbol
+	}
+	if signedAmount != "" && (signedAmount[0] == '+' || signedAmount[0] == '-') {
+		return signedAmount[:1] + symbol + signedAmount[1:]
+	}
+	return symbol + signedAmount
+}
+
+func isLetterSymbol(symbol string) bool {
+	for _, r := range symbol {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // AccountingRecord 收支记账记录
 type AccountingRecord struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty"`
 	ChatID       int64              `bson:"chat_id"`       // 群组 Chat ID
 	UserID       int64              `bson:"user_id"`       // 操作用户 ID
 	Amount       float64            `bson:"amount"`        // 金额（正数为收入，负数为支出）
-	Currency     string             `bson:"currency"`      // 货币类型：USD/CNY
+	Currency     string             `bson:"currency"`      // 货币类型：USD/CNY/EUR
 	OriginalExpr string             `bson:"original_expr"` // 原始表达式（如 "100*7.2"）
 	RecordedAt   time.Time          `bson:"recorded_at"`   // 记录时间（容器时区：Asia/Shanghai）
 	CreatedAt    time.Time          `bson:"created_at"`    // 数据库创建时间
@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+func TestCurrencySymbolFallsBackToDefault(t *testing.T) {
+	if got := CurrencySymbol(CurrencyUSD, nil); got != "U" {
+		t.Fatalf("expected default U, got %q", got)
+	}
+	if got := CurrencySymbol(CurrencyUSD, map[string]string{CurrencyUSD: "$"}); got != "$" {
+		t.Fatalf("expected custom $, got %q", got)
+	}
+	if got := CurrencySymbol("XYZ", nil); got != "XYZ" {
+		t.Fatalf("expected unknown currency code as symbol, got %q", got)
+	}
+}
+
+func TestFormatCurrencyAmountPlacement(t *testing.T) {
+	cases := map[string]struct {
+		amount string
+		symbol string
+	}{
+		"+100U":  {amount: "+100", symbol: "U"},
+		"+$100":  {amount: "+100", symbol: "$"},
+		"-¥50.5": {amount: "-50.5", symbol: "¥"},
+		"+100":   {amount: "+100", symbol: ""},
+	}
+	for want, tc := range cases {
+		if got := FormatCurrencyAmount(tc.amount, tc.symbol); got != want {
+			t.Fatalf("FormatCurrencyAmount(%q, %q) = %q, want %q", tc.amount, tc.symbol, got, want)
+		}
+	}
+}
+
+func TestAllowedAccountingCurrencies(t *testing.T) {
+	if got := AllowedAccountingCurrencies(GroupSettings{}); len(got) != 2 || got[0] != CurrencyUSD || got[1] != CurrencyCNY {
+		t.Fatalf("expected default USD/CNY, got %v", got)
+	}
+
+	settings := GroupSettings{AccountingCurrencies: []string{CurrencyEUR, "XYZ", CurrencyUSD}}
+	if got := AllowedAccountingCurrencies(settings); len(got) != 2 || got[0] != CurrencyEUR || got[1] != CurrencyUSD {
+		t.Fatalf("expected unsupported currencies to be dropped, got %v", got)
+	}
+
+	if currency, ok := CurrencyFromToken("e"); !ok || currency != CurrencyEUR {
+		t.Fatalf("expected lowercase e to map to EUR, got %q %v", currency, ok)
+	}
+}
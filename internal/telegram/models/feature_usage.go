@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeatureUsage 群组功能使用计数
+type FeatureUsage struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	GroupID    int64              `bson:"group_id"`     // 群组 Chat ID（汇总查询时为 0）
+	Feature    string             `bson:"feature"`      // 功能/命令名称
+	Count      int64              `bson:"count"`        // 累计使用次数
+	LastUsedAt time.Time          `bson:"last_used_at"` // 最近一次使用时间
+	CreatedAt  time.Time          `bson:"created_at"`
+}
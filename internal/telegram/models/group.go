@@ -5,6 +5,7 @@ import (
 	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -53,22 +54,45 @@ type Group struct {
 
 // GroupSettings 群组配置
 type GroupSettings struct {
-	CalculatorEnabled        bool               `bson:"calculator_enabled"`           // 是否启用计算器功能
-	CryptoEnabled            bool               `bson:"crypto_enabled"`               // 是否启用加密货币价格查询功能
-	CryptoFloatRate          float64            `bson:"crypto_float_rate"`            // 加密货币价格浮动费率（默认 0.12）
-	ForwardEnabled           bool               `bson:"forward_enabled"`              // 是否接收频道转发消息
-	AccountingEnabled        bool               `bson:"accounting_enabled"`           // 是否启用收支记账功能
-	MerchantID               int32              `bson:"merchant_id"`                  // 商户号（数字类型，0 表示未绑定）
-	InterfaceBindings        []InterfaceBinding `bson:"interface_bindings,omitempty"` // 接口绑定信息
-	SifangEnabled            bool               `bson:"sifang_enabled"`               // 是否启用四方支付功能
-	SifangAutoLookupEnabled  bool               `bson:"sifang_auto_lookup_enabled"`   // 是否启用四方支付自动查单
-	CascadeForwardEnabled    bool               `bson:"cascade_forward_enabled"`      // 是否启用订单联动转发
-	CascadeForwardConfigured bool               `bson:"cascade_forward_configured"`   // 是否已手动配置转单开关
-	CascadeReplyEnabled      bool               `bson:"cascade_reply_enabled"`        // 订单联动回传时是否引用商户原消息
-	CascadeReplyConfigured   bool               `bson:"cascade_reply_configured"`     // 是否已手动配置回传引用开关
-	BalanceMonitorEnabled    bool               `bson:"balance_monitor_enabled"`      // 是否启用上游余额轮询告警
-	BalanceMonitorConfigured bool               `bson:"balance_monitor_configured"`   // 是否已手动配置轮询告警
-	BalanceMonitorInterval   int                `bson:"balance_monitor_interval"`     // 轮询间隔（分钟），0 表示使用默认
+	CalculatorEnabled              bool               `bson:"calculator_enabled"`                 // 是否启用计算器功能
+	CryptoEnabled                  bool               `bson:"crypto_enabled"`                     // 是否启用加密货币价格查询功能
+	CryptoFloatRate                float64            `bson:"crypto_float_rate"`                  // 加密货币价格浮动费率（默认 0.12）
+	ForwardEnabled                 bool               `bson:"forward_enabled"`                    // 是否接收频道转发消息
+	AccountingEnabled              bool               `bson:"accounting_enabled"`                 // 是否启用收支记账功能
+	MerchantID                     int32              `bson:"merchant_id"`                        // 商户号（数字类型，0 表示未绑定），多商户时为主商户号
+	MerchantIDs                    []int64            `bson:"merchant_ids,omitempty"`             // 附加商户号（账单/余额按商户分段展示）
+	InterfaceBindings              []InterfaceBinding `bson:"interface_bindings,omitempty"`       // 接口绑定信息
+	SifangEnabled                  bool               `bson:"sifang_enabled"`                     // 是否启用四方支付功能
+	SifangAutoLookupEnabled        bool               `bson:"sifang_auto_lookup_enabled"`         // 是否启用四方支付自动查单
+	CascadeForwardEnabled          bool               `bson:"cascade_forward_enabled"`            // 是否启用订单联动转发
+	CascadeForwardConfigured       bool               `bson:"cascade_forward_configured"`         // 是否已手动配置转单开关
+	CascadeReplyEnabled            bool               `bson:"cascade_reply_enabled"`              // 订单联动回传时是否引用商户原消息
+	CascadeReplyConfigured         bool               `bson:"cascade_reply_configured"`           // 是否已手动配置回传引用开关
+	BalanceMonitorEnabled          bool               `bson:"balance_monitor_enabled"`            // 是否启用上游余额轮询告警
+	BalanceMonitorConfigured       bool               `bson:"balance_monitor_configured"`         // 是否已手动配置轮询告警
+	BalanceMonitorInterval         int                `bson:"balance_monitor_interval"`           // 轮询间隔（分钟），0 表示使用默认
+	BalanceAllowNegative           bool               `bson:"balance_allow_negative"`             // 上游余额是否允许扣成负数
+	BalanceAllowNegativeConfigured bool               `bson:"balance_allow_negative_configured"`  // 是否已手动配置负余额开关
+	CurrencySymbols                map[string]string  `bson:"currency_symbols,omitempty"`         // 记账货币展示符号（币种 → 符号），为空时使用默认
+	AccountingCurrencies           []string           `bson:"accounting_currencies,omitempty"`    // 允许记账的币种，为空时使用 USD/CNY
+	AccountingDefaultCurrency      string             `bson:"default_currency,omitempty"`         // 未带币种后缀的记账金额使用的币种，为空时为 USD
+	AccountingAutoReport           bool               `bson:"accounting_auto_report"`             // 记账成功后是否自动发送完整账单
+	AccountingAutoReportConfigured bool               `bson:"accounting_auto_report_configured"`  // 是否已手动配置自动账单开关
+	FeatureReplyEnabled            bool               `bson:"feature_reply_enabled"`              // 功能输出是否引用触发命令的消息
+	FeatureReplyConfigured         bool               `bson:"feature_reply_configured"`           // 是否已手动配置引用开关
+	SummaryShowWithdraws           bool               `bson:"summary_show_withdraws"`             // 账单是否附带提款明细
+	SummaryShowWithdrawsConfigured bool               `bson:"summary_show_withdraws_configured"`  // 是否已手动配置提款明细开关
+	SummaryShowBalance             bool               `bson:"summary_show_balance"`               // 账单是否附带余额
+	SummaryShowBalanceConfigured   bool               `bson:"summary_show_balance_configured"`    // 是否已手动配置余额开关
+	SummaryIncomeMode              string             `bson:"summary_income_mode,omitempty"`      // 账单收入展示方式（combined / split），为空时合并展示
+	CommandAliases                 map[string]string  `bson:"command_aliases,omitempty"`          // 功能命令别名（小写别名 → 内置命令），例如 bill → 账单
+	AccountingResetCadence         string             `bson:"accounting_reset,omitempty"`         // 记账自动清零周期（daily / weekly / monthly），为空时不自动清零
+	UnknownCommandHintEnabled      bool               `bson:"unknown_command_hint"`               // 是否对未知斜杠命令回复 /help 指引（默认关闭）
+	QueryTimeFooterEnabled         bool               `bson:"query_time_footer"`                  // 余额、账单、费率、提款明细查询结果是否附带查询时间（默认关闭）
+	AccountingDedupMode            string             `bson:"accounting_dedup,omitempty"`         // 记账重复检测方式（prompt / skip），为空时不检测
+	QuietHours                     string             `bson:"quiet_hours,omitempty"`              // 免打扰时段（HH-HH，北京时间），期间余额提醒与每日账单延后推送
+	SendMoneyRequireGoogleCode     bool               `bson:"send_money_require_google_code"`     // 下发是否必须附带谷歌验证码
+	CascadeCaptionTemplate         string             `bson:"cascade_caption_template,omitempty"` // 订单联动转单文案模板（上游群），为空时使用默认格式
 }
 
 // InterfaceBinding 描述单个上游接口绑定
@@ -150,6 +174,21 @@ func NormalizeGroupTier(tier GroupTier) GroupTier {
 	return tier
 }
 
+// GroupMerchantIDs 返回群组绑定的全部商户号，主商户号在前；未绑定主商户号时返回空
+func GroupMerchantIDs(settings GroupSettings) []int64 {
+	if settings.MerchantID <= 0 {
+		return nil
+	}
+	merchants := []int64{int64(settings.MerchantID)}
+	for _, merchantID := range settings.MerchantIDs {
+		if merchantID <= 0 || slices.Contains(merchants, merchantID) {
+			continue
+		}
+		merchants = append(merchants, merchantID)
+	}
+	return merchants
+}
+
 // IsBalanceMonitorEnabled 返回是否启用余额轮询告警（未配置时默认开启）
 func IsBalanceMonitorEnabled(settings GroupSettings) bool {
 	if settings.BalanceMonitorConfigured {
@@ -158,6 +197,14 @@ func IsBalanceMonitorEnabled(settings GroupSettings) bool {
 	return true
 }
 
+// IsBalanceNegativeAllowed 返回上游余额是否允许扣成负数（未配置时默认允许）
+func IsBalanceNegativeAllowed(settings GroupSettings) bool {
+	if settings.BalanceAllowNegativeConfigured {
+		return settings.BalanceAllowNegative
+	}
+	return true
+}
+
 // IsCascadeReplyEnabled 返回订单联动回传时是否引用商户原消息（未配置时默认开启）
 func IsCascadeReplyEnabled(settings GroupSettings) bool {
 	if settings.CascadeReplyConfigured {
@@ -166,6 +213,84 @@ func IsCascadeReplyEnabled(settings GroupSettings) bool {
 	return true
 }
 
+// IsFeatureReplyEnabled 返回功能输出是否以回复形式引用触发命令的消息（未配置时默认开启）
+func IsFeatureReplyEnabled(settings GroupSettings) bool {
+	if settings.FeatureReplyConfigured {
+		return settings.FeatureReplyEnabled
+	}
+	return true
+}
+
+// IsAccountingAutoReportEnabled 返回记账成功后是否自动发送完整账单（未配置时默认开启）
+func IsAccountingAutoReportEnabled(settings GroupSettings) bool {
+	if settings.AccountingAutoReportConfigured {
+		return settings.AccountingAutoReport
+	}
+	return true
+}
+
+// IsSummaryShowWithdrawsEnabled 返回账单是否附带提款明细（未配置时默认开启）
+func IsSummaryShowWithdrawsEnabled(settings GroupSettings) bool {
+	if settings.SummaryShowWithdrawsConfigured {
+		return settings.SummaryShowWithdraws
+	}
+	return true
+}
+
+// IsSummaryShowBalanceEnabled 返回账单是否附带余额（未配置时默认开启）
+func IsSummaryShowBalanceEnabled(settings GroupSettings) bool {
+	if settings.SummaryShowBalanceConfigured {
+		return settings.SummaryShowBalance
+	}
+	return true
+}
+
+// 账单收入展示方式
+const (
+	SummaryIncomeCombined = "combined" // 商户收入与代理收入合并为"成交"
+	SummaryIncomeSplit    = "split"    // 分别展示商户收入与代理收入
+)
+
+// IsSummaryIncomeSplit 返回账单是否分开展示商户收入与代理收入（未配置时合并展示）
+func IsSummaryIncomeSplit(settings GroupSettings) bool {
+	return settings.SummaryIncomeMode == SummaryIncomeSplit
+}
+
+// 记账自动清零周期
+const (
+	AccountingResetNone    = ""        // 不自动清零
+	AccountingResetDaily   = "daily"   // 每天 00:00 清零
+	AccountingResetWeekly  = "weekly"  // 每周一 00:00 清零
+	AccountingResetMonthly = "monthly" // 每月 1 日 00:00 清零
+)
+
+// AccountingResetCadenceOf 返回群组的记账清零周期，未知取值视为不清零
+func AccountingResetCadenceOf(settings GroupSettings) string {
+	switch settings.AccountingResetCadence {
+	case AccountingResetDaily, AccountingResetWeekly, AccountingResetMonthly:
+		return settings.AccountingResetCadence
+	default:
+		return AccountingResetNone
+	}
+}
+
+// 记账重复检测方式
+const (
+	AccountingDedupOff    = ""       // 不检测重复
+	AccountingDedupPrompt = "prompt" // 检测到重复时询问是否仍要记录
+	AccountingDedupSkip   = "skip"   // 检测到重复时自动跳过
+)
+
+// AccountingDedupModeOf 返回群组的记账重复检测方式，未知取值视为不检测
+func AccountingDedupModeOf(settings GroupSettings) string {
+	switch settings.AccountingDedupMode {
+	case AccountingDedupPrompt, AccountingDedupSkip:
+		return settings.AccountingDedupMode
+	default:
+		return AccountingDedupOff
+	}
+}
+
 // BalanceMonitorIntervalMinutes 返回轮询间隔（分钟），默认 10 分钟
 func BalanceMonitorIntervalMinutes(settings GroupSettings) time.Duration {
 	if settings.BalanceMonitorInterval > 0 {
@@ -174,6 +299,55 @@ func BalanceMonitorIntervalMinutes(settings GroupSettings) time.Duration {
 	return 10 * time.Minute
 }
 
+// AllowedAccountingCurrencies 返回群组允许的记账币种，未配置时使用默认币种
+func AllowedAccountingCurrencies(settings GroupSettings) []string {
+	var allowed []string
+	for _, currency := range settings.AccountingCurrencies {
+		if IsSupportedCurrency(currency) {
+			allowed = append(allowed, currency)
+		}
+	}
+	if len(allowed) == 0 {
+		return DefaultAccountingCurrencies
+	}
+	return allowed
+}
+
+// AccountingDefaultCurrencyOf 返回未带币种后缀时使用的记账币种，未配置或不支持时为 USD
+func AccountingDefaultCurrencyOf(settings GroupSettings) string {
+	if IsSupportedCurrency(settings.AccountingDefaultCurrency) {
+		return settings.AccountingDefaultCurrency
+	}
+	return CurrencyUSD
+}
+
+// NormalizeCommandAlias 统一命令别名格式（去除首尾空白并转小写），用于存储与匹配
+func NormalizeCommandAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// ResolveCommandAlias 消息首个词命中群组别名时，返回替换为内置命令后的文本，其余参数原样保留
+func ResolveCommandAlias(text string, aliases map[string]string) (string, bool) {
+	if len(aliases) == 0 {
+		return text, false
+	}
+
+	trimmed := strings.TrimSpace(text)
+	head, rest := trimmed, ""
+	if idx := strings.IndexFunc(trimmed, unicode.IsSpace); idx >= 0 {
+		head, rest = trimmed[:idx], strings.TrimSpace(trimmed[idx:])
+	}
+
+	target, ok := aliases[NormalizeCommandAlias(head)]
+	if !ok || target == "" {
+		return text, false
+	}
+	if rest == "" {
+		return target, true
+	}
+	return target + " " + rest, true
+}
+
 // IsTierAllowed 判断当前群等级是否在允许列表中
 func IsTierAllowed(current GroupTier, allowed []GroupTier) bool {
 	if len(allowed) == 0 {
@@ -187,6 +361,16 @@ func IsTierAllowed(current GroupTier, allowed []GroupTier) bool {
 	return false
 }
 
+// ParseGroupTier 解析群等级取值（basic/merchant/upstream，不区分大小写）
+func ParseGroupTier(value string) (GroupTier, bool) {
+	switch tier := GroupTier(strings.ToLower(strings.TrimSpace(value))); tier {
+	case GroupTierBasic, GroupTierMerchant, GroupTierUpstream:
+		return tier, true
+	default:
+		return "", false
+	}
+}
+
 // GroupTierDisplayName 返回群等级的可读名称
 func GroupTierDisplayName(tier GroupTier) string {
 	switch tier {
@@ -1,6 +1,9 @@
 package models
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestDetermineGroupTier(t *testing.T) {
 	tests := []struct {
@@ -112,4 +115,63 @@ func TestTierHelpers(t *testing.T) {
 	}) {
 		t.Fatalf("expected configured cascade reply switch to be honored")
 	}
+
+	if !IsFeatureReplyEnabled(GroupSettings{}) {
+		t.Fatalf("expected feature reply to be enabled by default")
+	}
+
+	if IsFeatureReplyEnabled(GroupSettings{FeatureReplyConfigured: true}) {
+		t.Fatalf("expected configured feature reply switch to be honored")
+	}
+
+	if !IsAccountingAutoReportEnabled(GroupSettings{}) {
+		t.Fatalf("expected accounting auto report to be enabled by default")
+	}
+
+	if IsAccountingAutoReportEnabled(GroupSettings{AccountingAutoReportConfigured: true}) {
+		t.Fatalf("expected configured accounting auto report switch to be honored")
+	}
+
+	if !IsSummaryShowWithdrawsEnabled(GroupSettings{}) || !IsSummaryShowBalanceEnabled(GroupSettings{}) {
+		t.Fatalf("expected summary sections to be shown by default")
+	}
+
+	if IsSummaryShowWithdrawsEnabled(GroupSettings{SummaryShowWithdrawsConfigured: true}) ||
+		IsSummaryShowBalanceEnabled(GroupSettings{SummaryShowBalanceConfigured: true}) {
+		t.Fatalf("expected configured summary section switches to be honored")
+	}
+}
+
+func TestGroupMerchantIDs(t *testing.T) {
+	if merchants := GroupMerchantIDs(GroupSettings{MerchantIDs: []int64{2002}}); merchants != nil {
+		t.Fatalf("expected no merchants without primary, got %v", merchants)
+	}
+
+	merchants := GroupMerchantIDs(GroupSettings{MerchantID: 1001})
+	if len(merchants) != 1 || merchants[0] != 1001 {
+		t.Fatalf("expected primary merchant only, got %v", merchants)
+	}
+
+	merchants = GroupMerchantIDs(GroupSettings{MerchantID: 1001, MerchantIDs: []int64{2002, 1001, 0, 2002, 3003}})
+	expected := []int64{1001, 2002, 3003}
+	if !slices.Equal(merchants, expected) {
+		t.Fatalf("expected %v, got %v", expected, merchants)
+	}
+}
+
+func TestResolveCommandAlias(t *testing.T) {
+	aliases := map[string]string{"bill": "账单"}
+
+	if got, ok := ResolveCommandAlias(" Bill ", aliases); !ok || got != "账单" {
+		t.Fatalf("expected alias resolved, got %q ok=%v", got, ok)
+	}
+	if got, ok := ResolveCommandAlias("bill  10月26", aliases); !ok || got != "账单 10月26" {
+		t.Fatalf("expected alias with args resolved, got %q ok=%v", got, ok)
+	}
+	if got, ok := ResolveCommandAlias("billing", aliases); ok || got != "billing" {
+		t.Fatalf("expected partial word untouched, got %q ok=%v", got, ok)
+	}
+	if _, ok := ResolveCommandAlias("bill", nil); ok {
+		t.Fatal("expected no alias without configuration")
+	}
 }
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMoneyPrecision 未单独配置的币种默认保留的小数位数
+const DefaultMoneyPrecision = 2
+
+// MaxMoneyPrecision 允许配置的最大小数位数
+const MaxMoneyPrecision = 8
+
+var (
+	moneyPrecisionMu sync.RWMutex
+	moneyPrecision   map[string]int
+)
+
+// normalizeMoneyCurrency 统一币种代码，USDT 与记账中的 USD 视为同一币种
+func normalizeMoneyCurrency(currency string) string {
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if code == "USDT" {
+		return CurrencyUSD
+	}
+	return code
+}
+
+// SetMoneyPrecision 设置各币种金额展示的小数位数（币种 → 位数），传 nil 恢复默认
+func SetMoneyPrecision(precisions map[string]int) {
+	normalized := make(map[string]int, len(precisions))
+	for currency, digits := range precisions {
+		if digits < 0 || digits > MaxMoneyPrecision {
+			continue
+		}
+		normalized[normalizeMoneyCurrency(currency)] = digits
+	}
+
+	moneyPrecisionMu.Lock()
+	moneyPrecision = normalized
+	moneyPrecisionMu.Unlock()
+}
+
+// MoneyPrecision 返回币种金额展示的小数位数，未配置时为 DefaultMoneyPrecision
+func MoneyPrecision(currency string) int {
+	moneyPrecisionMu.RLock()
+	defer moneyPrecisionMu.RUnlock()
+
+	if digits, ok := moneyPrecision[normalizeMoneyCurrency(currency)]; ok {
+		return digits
+	}
+	return DefaultMoneyPrecision
+}
+
+// FormatMoney 按币种精度格式化金额（不带符号与单位）
+func FormatMoney(value float64, currency string) string {
+	return strconv.FormatFloat(value, 'f', MoneyPrecision(currency), 64)
+}
@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestFormatMoneyUsesCurrencyPrecision(t *testing.T) {
+	t.Cleanup(func() { SetMoneyPrecision(nil) })
+
+	if got := FormatMoney(12.3456789, "USDT"); got != "12.35" {
+		t.Fatalf("expected default 2 decimals, got %s", got)
+	}
+
+	SetMoneyPrecision(map[string]int{"usdt": 4, CurrencyCNY: 2, CurrencyEUR: 99})
+	if got := FormatMoney(12.3456789, "USDT"); got != "12.3457" {
+		t.Fatalf("expected 4-decimal USDT, got %s", got)
+	}
+	if got := FormatMoney(12.3456789, CurrencyUSD); got != "12.3457" {
+		t.Fatalf("expected USD to share USDT precision, got %s", got)
+	}
+	if got := FormatMoney(12.3456789, CurrencyCNY); got != "12.35" {
+		t.Fatalf("expected 2-decimal CNY, got %s", got)
+	}
+	if got := MoneyPrecision(CurrencyEUR); got != DefaultMoneyPrecision {
+		t.Fatalf("expected out-of-range precision ignored, got %d", got)
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	orderLinkHostsMu sync.RWMutex
+	orderLinkHosts   []string
+)
+
+// SetOrderLinkHosts 设置允许渲染为可点击链接的订单链接域名（含其子域名），传 nil 时不渲染任何链接
+func SetOrderLinkHosts(hosts []string) {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+
+	orderLinkHostsMu.Lock()
+	orderLinkHosts = normalized
+	orderLinkHostsMu.Unlock()
+}
+
+// IsTrustedOrderLink 判断链接是否为 http(s) 且域名在白名单内
+func IsTrustedOrderLink(rawURL string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "" {
+		return false
+	}
+
+	orderLinkHostsMu.RLock()
+	defer orderLinkHostsMu.RUnlock()
+	for _, allowed := range orderLinkHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatOrderLink 渲染订单链接：白名单内的链接渲染为 HTML 超链接，其余以不可点击的纯文本展示，避免钓鱼链接
+func FormatOrderLink(label, rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if IsTrustedOrderLink(rawURL) {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(rawURL), html.EscapeString(label))
+	}
+	return fmt.Sprintf("<code>%s</code>", html.EscapeString(rawURL))
+}
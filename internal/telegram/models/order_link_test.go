@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestFormatOrderLinkAllowlist(t *testing.T) {
+	SetOrderLinkHosts([]string{"pay.example.com", " Admin.Example.org "})
+	t.Cleanup(func() { SetOrderLinkHosts(nil) })
+
+	cases := map[string]string{
+		"https://pay.example.com/order?id=1&x=2": `<a href="https://pay.example.com/order?id=1&amp;x=2">打开订单</a>`,
+		"https://cn.admin.example.org/o/1":       `<a href="https://cn.admin.example.org/o/1">打开订单</a>`,
+		"https://evil.com/pay.example.com":       "<code>https://evil.com/pay.example.com</code>",
+		"https://pay.example.com.evil.com/":      "<code>https://pay.example.com.evil.com/</code>",
+		"javascript://pay.example.com/%0aalert":  "<code>javascript://pay.example.com/%0aalert</code>",
+		"":                                       "",
+	}
+	for rawURL, want := range cases {
+		if got := FormatOrderLink("打开订单", rawURL); got != want {
+			t.Fatalf("FormatOrderLink(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+
+	SetOrderLinkHosts(nil)
+	if IsTrustedOrderLink("https://pay.example.com/order") {
+		t.Fatalf("expected no trusted hosts after reset")
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 免打扰时段预设，格式 "HH-HH"（开始含、结束不含，按群组时区计算，可跨零点）
+const (
+	QuietHoursOff   = ""      // 不启用免打扰
+	QuietHoursLate  = "22-08" // 22:00 至次日 08:00
+	QuietHoursNight = "23-08" // 23:00 至次日 08:00
+	QuietHoursDawn  = "00-08" // 00:00 至 08:00
+)
+
+// ParseQuietHours 解析 "HH-HH" 格式的免打扰时段，开始与结束相同视为无效
+func ParseQuietHours(value string) (start, end int, ok bool) {
+	if _, err := fmt.Sscanf(value, "%d-%d", &start, &end); err != nil {
+		return 0, 0, false
+	}
+	if start < 0 || start > 23 || end < 0 || end > 23 || start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// QuietHoursOf 返回群组的免打扰时段，未设置或格式无效时视为不启用
+func QuietHoursOf(settings GroupSettings) string {
+	if _, _, ok := ParseQuietHours(settings.QuietHours); !ok {
+		return QuietHoursOff
+	}
+	return settings.QuietHours
+}
+
+// QuietHoursResumeAt 判断 now 是否处于免打扰时段，是则返回时段结束（恢复推送）的时间。
+// 小时按 now 所在时区计算，调用方需先转换到群组时区
+func QuietHoursResumeAt(settings GroupSettings, now time.Time) (time.Time, bool) {
+	start, end, ok := ParseQuietHours(settings.QuietHours)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	hour := now.Hour()
+	var quiet bool
+	if start < end {
+		quiet = hour >= start && hour < end
+	} else {
+		quiet = hour >= start || hour < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	resume := time.Date(now.Year(), now.Month(), now.Day(), end, 0, 0, 0, now.Location())
+	if !resume.After(now) {
+		resume = resume.AddDate(0, 0, 1)
+	}
+	return resume, true
+}
+
+// IsInQuietHours 判断 now 是否处于群组的免打扰时段
+func IsInQuietHours(settings GroupSettings, now time.Time) bool {
+	_, quiet := QuietHoursResumeAt(settings, now)
+	return quiet
+}
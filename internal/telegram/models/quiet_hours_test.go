@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursResumeAt(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 10, day, hour, minute, 0, 0, loc)
+	}
+
+	cases := []struct {
+		name   string
+		window string
+		now    time.Time
+		quiet  bool
+		resume time.Time
+	}{
+		{name: "disabled", window: "", now: at(26, 3, 0)},
+		{name: "invalid", window: "08-08", now: at(26, 8, 0)},
+		{name: "overnight before midnight", window: QuietHoursLate, now: at(26, 23, 30), quiet: true, resume: at(27, 8, 0)},
+		{name: "overnight after midnight", window: QuietHoursLate, now: at(26, 3, 0), quiet: true, resume: at(26, 8, 0)},
+		{name: "overnight end exclusive", window: QuietHoursLate, now: at(26, 8, 0)},
+		{name: "overnight daytime", window: QuietHoursLate, now: at(26, 15, 0)},
+		{name: "same day window", window: QuietHoursDawn, now: at(26, 0, 0), quiet: true, resume: at(26, 8, 0)},
+		{name: "same day outside", window: QuietHoursDawn, now: at(26, 22, 0)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resume, quiet := QuietHoursResumeAt(GroupSettings{QuietHours: tc.window}, tc.now)
+			if quiet != tc.quiet {
+				t.Fatalf("expected quiet=%v, got %v", tc.quiet, quiet)
+			}
+			if quiet && !resume.Equal(tc.resume) {
+				t.Fatalf("expected resume at %s, got %s", tc.resume, resume)
+			}
+		})
+	}
+}
+
+func TestQuietHoursOfRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"", "abc", "24-08", "22-22", "-1-08"} {
+		if got := QuietHoursOf(GroupSettings{QuietHours: value}); got != QuietHoursOff {
+			t.Fatalf("QuietHoursOf(%q) = %q, want off", value, got)
+		}
+	}
+	if got := QuietHoursOf(GroupSettings{QuietHours: QuietHoursNight}); got != QuietHoursNight {
+		t.Fatalf("expected %q, got %q", QuietHoursNight, got)
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 下发记录状态
+const (
+	SendMoneyStatusPending = "pending" // 已发起但未得到确定结果（如超时），需对账确认
+	SendMoneyStatusSuccess = "success" // 上游返回成功
+	SendMoneyStatusFailed  = "failed"  // 上游明确拒绝
+)
+
+// SendMoneyRecord Bot 发起的下发记录，用于与上游提现列表对账
+type SendMoneyRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	MerchantID int64              `bson:"merchant_id"`
+	ChatID     int64              `bson:"chat_id,omitempty"`
+	UserID     int64              `bson:"user_id,omitempty"`
+	Amount     float64            `bson:"amount"`
+	Status     string             `bson:"status"`
+	WithdrawNo string             `bson:"withdraw_no,omitempty"`
+	OrderNo    string             `bson:"order_no,omitempty"`
+	Error      string             `bson:"error,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Setting 全局（非群组）运行时配置项，以键值对形式存储
+type Setting struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Key       string             `bson:"key"`        // 配置键（唯一）
+	Value     string             `bson:"value"`      // 配置值（统一按字符串存储，读取时按类型解析）
+	UpdatedBy int64              `bson:"updated_by"` // 最近修改人 Telegram ID（0 表示系统）
+	UpdatedAt time.Time          `bson:"updated_at"` // 最近修改时间
+	CreatedAt time.Time          `bson:"created_at"`
+}
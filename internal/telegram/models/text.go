@@ -0,0 +1,18 @@
+package models
+
+import "strings"
+
+// NormalizeFullWidth 将全角 ASCII 字符（如 １０、＋、Ｕ）与全角空格转换为半角，其余字符保持不变
+// 部分手机输入法会输出全角数字，导致日期或金额解析失败
+func NormalizeFullWidth(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		default:
+			return r
+		}
+	}, text)
+}
@@ -0,0 +1,19 @@
+package models
+
+import "testing"
+
+func TestNormalizeFullWidth(t *testing.T) {
+	cases := map[string]string{
+		"１０月２６":      "10月26",
+		"２０２４－１０－２６": "2024-10-26",
+		"＋１００＊７．２Ｕ":  "+100*7.2U",
+		"出５０／２ｙ":     "出50/2y",
+		"账单　10月26":   "账单 10月26",
+		"+100U":      "+100U",
+	}
+	for input, want := range cases {
+		if got := NormalizeFullWidth(input); got != want {
+			t.Fatalf("NormalizeFullWidth(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
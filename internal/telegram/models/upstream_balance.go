@@ -15,8 +15,21 @@ const (
 	BalanceOpSettlement    BalanceOperationType = "settlement"
 	BalanceOpSetMinBalance BalanceOperationType = "set_min_balance"
 	BalanceOpAlertLimit    BalanceOperationType = "set_alert_limit"
+	// BalanceOpSettlementCorrection 日结后的追加扣费/补偿
+	BalanceOpSettlementCorrection BalanceOperationType = "settlement_correction"
 )
 
+// BalanceChangingOps 会改变余额的操作类型，阈值与告警设置日志不影响余额
+var BalanceChangingOps = []BalanceOperationType{
+	BalanceOpCredit,
+	BalanceOpDebit,
+	BalanceOpSettlement,
+	BalanceOpSettlementCorrection,
+}
+
+// BalanceLogSettlementDateKey 余额日志 metadata 中关联的日结日期（YYYY-MM-DD）
+const BalanceLogSettlementDateKey = "settlement_date"
+
 // UpstreamBalance 表示单个上游群的余额与阈值
 type UpstreamBalance struct {
 	ID                primitive.ObjectID `bson:"_id,omitempty"`
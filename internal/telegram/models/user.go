@@ -29,6 +29,8 @@ type User struct {
 	CreatedAt    time.Time          `bson:"created_at"`              // 创建时间
 	UpdatedAt    time.Time          `bson:"updated_at"`              // 更新时间
 	LastActiveAt time.Time          `bson:"last_active_at"`          // 最后活跃时间
+	Blocked      bool               `bson:"blocked,omitempty"`       // 用户已屏蔽 Bot，私聊通知将被跳过
+	BlockedAt    *time.Time         `bson:"blocked_at,omitempty"`    // 检测到屏蔽的时间
 }
 
 // IsOwner 是否为 Owner
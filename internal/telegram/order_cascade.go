@@ -24,6 +24,7 @@ const (
 	orderCascadeLookupTimeout = 8 * time.Second
 	orderCascadeSendTimeout   = 5 * time.Second
 	orderCascadeStateTTL      = 2 * time.Hour
+	orderCascadeTestStateTTL  = 10 * time.Minute
 	orderCascadeGroupTimeout  = 3 * time.Second
 )
 
@@ -74,18 +75,33 @@ type orderCascadeState struct {
 	SourceGroupTitle   string
 	UpstreamGroupTitle string
 	BaseMessageText    string
+	IsTest             bool // 测试转单生成的临时状态，不进入失败重试与接口检查
 	CreatedAt          time.Time
 	ExpiresAt          time.Time
+	FeedbackAt         time.Time // 上游反馈（回复或按钮）首次同步到商户群的时间，零值表示仍在等待
 }
 
 type orderCascadeMessagePayload struct {
 	MerchantOrderNoFull string
 	OrderNo             string
 	StatusText          string
+	ManageURL           string
+	PaymentURL          string
+	InterfaceName       string
+	ChannelName         string
+	Template            string // 上游群自定义文案模板，为空时使用默认格式
+}
+
+// orderNo 返回展示用订单号，优先使用完整商户订单号
+func (p orderCascadeMessagePayload) orderNo() string {
+	if orderNo := strings.TrimSpace(p.MerchantOrderNoFull); orderNo != "" {
+		return orderNo
+	}
+	return strings.TrimSpace(p.OrderNo)
 }
 
 func (b *Bot) startOrderCascadeWorkflow(group *models.Group, msg *botModels.Message, orderNos []string) {
-	if b.paymentService == nil || b.groupService == nil || group == nil || msg == nil {
+	if !paymentservice.Available(b.paymentService) || b.groupService == nil || group == nil || msg == nil {
 		return
 	}
 
@@ -94,7 +110,7 @@ func (b *Bot) startOrderCascadeWorkflow(group *models.Group, msg *botModels.Mess
 		return
 	}
 
-	detectionTime := time.Now()
+	detectionTime := b.currentTime()
 	processedOrders := make(map[string]struct{})
 
 	for _, orderNo := range orderNos {
@@ -136,7 +152,12 @@ func (b *Bot) startOrderCascadeWorkflow(group *models.Group, msg *botModels.Mess
 			continue
 		}
 
-		interfaceName, _ := resolveCascadeInterfaceDescriptor(upstreamGroup.Settings.InterfaceBindings, interfaceID, binding.PZName)
+		interfaceName, _, configured := resolveCascadeInterfaceDescriptor(upstreamGroup.Settings.InterfaceBindings, interfaceID, binding.PZName)
+		if !configured {
+			logger.L().Warnf("Order cascade interface not bound in upstream group: group_id=%d interface_id=%s order_no=%s", upstreamGroup.TelegramID, interfaceID, orderUpper)
+			b.recordOrderCascadeInterfaceMismatch(upstreamGroup, interfaceID, orderUpper)
+			interfaceName += orderCascadeUnboundInterfaceNote
+		}
 		statusText := strings.TrimSpace(binding.StatusText)
 		if statusText == "" {
 			statusText = strings.TrimSpace(binding.Status)
@@ -155,67 +176,59 @@ func (b *Bot) startOrderCascadeWorkflow(group *models.Group, msg *botModels.Mess
 			MerchantOrderNoFull: orderFull,
 			OrderNo:             orderNo,
 			StatusText:          statusText,
+			ManageURL:           binding.ManageURL,
+			PaymentURL:          binding.PaymentURL,
+			InterfaceName:       interfaceName,
+			ChannelName:         cascadeChannelLabel(binding.ChannelName, binding.ChannelCode),
+			Template:            upstreamGroup.Settings.CascadeCaptionTemplate,
 		}
 
 		token := generateOrderCascadeToken()
-		markup := buildOrderCascadeKeyboard(token)
-
 		caption := buildOrderCascadeMessage(payload)
 
-		stateHasMedia := false
-		var sent *botModels.Message
-		var err error
-		sendCtx, cancel := context.WithTimeout(context.Background(), orderCascadeSendTimeout)
+		delivery := &orderCascadeDelivery{
+			State: &orderCascadeState{
+				Token:              token,
+				MerchantChatID:     msg.Chat.ID,
+				MerchantMessageID:  msg.ID,
+				MerchantReplyOn:    models.IsCascadeReplyEnabled(group.Settings),
+				UpstreamChatID:     upstreamGroup.TelegramID,
+				OrderNo:            orderUpper,
+				MerchantOrderNo:    orderNo,
+				MerchantOrderFull:  orderFull,
+				InterfaceID:        interfaceID,
+				InterfaceName:      interfaceName,
+				ChannelName:        binding.ChannelName,
+				ChannelCode:        binding.ChannelCode,
+				SourceGroupTitle:   group.Title,
+				UpstreamGroupTitle: upstreamGroup.Title,
+				BaseMessageText:    caption,
+				CreatedAt:          detectionTime,
+				ExpiresAt:          detectionTime.Add(orderCascadeStateTTL),
+			},
+		}
 		switch {
 		case len(msg.Photo) > 0:
-			sent, err = b.bot.SendPhoto(sendCtx, &bot.SendPhotoParams{
-				ChatID:      upstreamGroup.TelegramID,
-				Photo:       &botModels.InputFileString{Data: msg.Photo[len(msg.Photo)-1].FileID},
-				Caption:     caption,
-				ParseMode:   botModels.ParseModeHTML,
-				ReplyMarkup: markup,
-			})
-			stateHasMedia = true
+			delivery.MediaType = orderCascadeMediaPhoto
+			delivery.MediaFileID = msg.Photo[len(msg.Photo)-1].FileID
 		case msg.Video != nil:
-			sent, err = b.bot.SendVideo(sendCtx, &bot.SendVideoParams{
-				ChatID:      upstreamGroup.TelegramID,
-				Video:       &botModels.InputFileString{Data: msg.Video.FileID},
-				Caption:     caption,
-				ParseMode:   botModels.ParseModeHTML,
-				ReplyMarkup: markup,
-			})
-			stateHasMedia = true
-		default:
-			sent, err = b.sendMessageWithMarkupAndMessage(sendCtx, upstreamGroup.TelegramID, caption, markup)
+			delivery.MediaType = orderCascadeMediaVideo
+			delivery.MediaFileID = msg.Video.FileID
 		}
+		delivery.State.HasMedia = delivery.MediaType != ""
+
+		sendCtx, cancel := context.WithTimeout(context.Background(), orderCascadeSendTimeout)
+		sent, err := b.deliverOrderCascade(sendCtx, delivery)
 		cancel()
 		if err != nil || sent == nil {
 			logger.L().Errorf("Failed to send order cascade message: upstream_chat=%d order_no=%s err=%v",
 				upstreamGroup.TelegramID, orderUpper, err)
+			b.recordOrderCascadeFailure(delivery, err, b.currentTime())
 			continue
 		}
 
-		state := &orderCascadeState{
-			Token:              token,
-			MerchantChatID:     msg.Chat.ID,
-			MerchantMessageID:  msg.ID,
-			MerchantReplyOn:    models.IsCascadeReplyEnabled(group.Settings),
-			UpstreamChatID:     upstreamGroup.TelegramID,
-			UpstreamMessageID:  sent.ID,
-			OrderNo:            orderUpper,
-			MerchantOrderNo:    orderNo,
-			MerchantOrderFull:  orderFull,
-			InterfaceID:        interfaceID,
-			InterfaceName:      interfaceName,
-			ChannelName:        binding.ChannelName,
-			ChannelCode:        binding.ChannelCode,
-			SourceGroupTitle:   group.Title,
-			UpstreamGroupTitle: upstreamGroup.Title,
-			BaseMessageText:    caption,
-			HasMedia:           stateHasMedia,
-			CreatedAt:          detectionTime,
-			ExpiresAt:          detectionTime.Add(orderCascadeStateTTL),
-		}
+		state := delivery.State
+		state.UpstreamMessageID = sent.ID
 
 		b.saveOrderCascadeState(state)
 		logger.L().Infof("Order cascade forwarded: merchant_chat=%d upstream_chat=%d order_no=%s interface_id=%s",
@@ -252,23 +265,42 @@ func (b *Bot) findUpstreamGroupByInterfaceID(interfaceID string) *models.Group {
 	return group
 }
 
+// buildOrderCascadeMessage 生成转单文案；上游群设置了模板时按模板渲染，订单后台与支付链接附在其后
 func buildOrderCascadeMessage(payload orderCascadeMessagePayload) string {
 	builder := &strings.Builder{}
-	builder.WriteString("📦 <b>订单联动提醒</b>\n")
-	orderNo := strings.TrimSpace(payload.MerchantOrderNoFull)
-	if orderNo == "" {
-		orderNo = strings.TrimSpace(payload.OrderNo)
+	if template := strings.TrimSpace(payload.Template); template != "" {
+		builder.WriteString(renderOrderCascadeTemplate(template, payload))
+		links := &strings.Builder{}
+		writeOrderCascadeLinks(links, payload)
+		if links.Len() > 0 {
+			builder.WriteString("\n")
+			builder.WriteString(strings.TrimSuffix(links.String(), "\n"))
+		}
+		return builder.String()
 	}
-	if orderNo != "" {
+
+	builder.WriteString("📦 <b>订单联动提醒</b>\n")
+	if orderNo := payload.orderNo(); orderNo != "" {
 		builder.WriteString(fmt.Sprintf("订单号：<code>%s</code>\n", html.EscapeString(orderNo)))
 	}
 	if payload.StatusText != "" {
 		builder.WriteString(fmt.Sprintf("订单状态：%s\n", html.EscapeString(payload.StatusText)))
 	}
+	writeOrderCascadeLinks(builder, payload)
 	builder.WriteString("🤖 Bot 自动转单")
 	return builder.String()
 }
 
+// writeOrderCascadeLinks 写入订单后台与支付链接行（每行以换行结尾）
+func writeOrderCascadeLinks(builder *strings.Builder, payload orderCascadeMessagePayload) {
+	if link := models.FormatOrderLink("打开订单", payload.ManageURL); link != "" {
+		builder.WriteString(fmt.Sprintf("订单后台：%s\n", link))
+	}
+	if link := models.FormatOrderLink("打开支付页", payload.PaymentURL); link != "" {
+		builder.WriteString(fmt.Sprintf("支付链接：%s\n", link))
+	}
+}
+
 func buildOrderCascadeKeyboard(token string) *botModels.InlineKeyboardMarkup {
 	prefix := func(action string) string {
 		return orderCascadeCallbackPrefix + action + ":" + token
@@ -287,7 +319,8 @@ func buildOrderCascadeKeyboard(token string) *botModels.InlineKeyboardMarkup {
 	}
 }
 
-func resolveCascadeInterfaceDescriptor(bindings []models.InterfaceBinding, interfaceID, fallbackName string) (name string, rate string) {
+// resolveCascadeInterfaceDescriptor 从上游群接口绑定中解析接口名称与费率；configured 为 false 表示上游群未绑定该接口，名称为兜底值
+func resolveCascadeInterfaceDescriptor(bindings []models.InterfaceBinding, interfaceID, fallbackName string) (name string, rate string, configured bool) {
 	for _, binding := range bindings {
 		if strings.EqualFold(binding.ID, interfaceID) {
 			resolved := strings.TrimSpace(binding.Name)
@@ -297,7 +330,7 @@ func resolveCascadeInterfaceDescriptor(bindings []models.InterfaceBinding, inter
 			if resolved == "" {
 				resolved = fmt.Sprintf("接口 %s", interfaceID)
 			}
-			return resolved, strings.TrimSpace(binding.Rate)
+			return resolved, strings.TrimSpace(binding.Rate), true
 		}
 	}
 
@@ -305,7 +338,7 @@ func resolveCascadeInterfaceDescriptor(bindings []models.InterfaceBinding, inter
 	if cleanName == "" {
 		cleanName = fmt.Sprintf("接口 %s", interfaceID)
 	}
-	return cleanName, ""
+	return cleanName, "", false
 }
 
 func resolveCascadeMerchantOrderNoFull(binding *paymentservice.OrderChannelBinding, fallback string) string {
@@ -385,14 +418,20 @@ func (b *Bot) saveOrderCascadeState(state *orderCascadeState) {
 		b.orderCascadeStates = make(map[string]*orderCascadeState)
 	}
 
-	now := time.Now()
+	b.purgeExpiredOrderCascadeStatesLocked(b.currentTime())
+	b.orderCascadeStates[state.Token] = state
+}
+
+// purgeExpiredOrderCascadeStatesLocked 清理已过期的转单状态，调用方需持有 orderCascadeMu
+func (b *Bot) purgeExpiredOrderCascadeStatesLocked(now time.Time) int {
+	purged := 0
 	for token, existing := range b.orderCascadeStates {
 		if existing == nil || now.After(existing.ExpiresAt) {
 			delete(b.orderCascadeStates, token)
+			purged++
 		}
 	}
-
-	b.orderCascadeStates[state.Token] = state
+	return purged
 }
 
 func (b *Bot) getOrderCascadeState(token string) (*orderCascadeState, bool) {
@@ -407,7 +446,7 @@ func (b *Bot) getOrderCascadeState(token string) (*orderCascadeState, bool) {
 		return nil, false
 	}
 
-	if time.Now().After(state.ExpiresAt) {
+	if b.currentTime().After(state.ExpiresAt) {
 		b.orderCascadeMu.Lock()
 		delete(b.orderCascadeStates, token)
 		b.orderCascadeMu.Unlock()
@@ -417,12 +456,24 @@ func (b *Bot) getOrderCascadeState(token string) (*orderCascadeState, bool) {
 	return state, true
 }
 
+// markOrderCascadeFeedback 记录上游反馈已同步到商户群，之后不再计入待处理转单
+func (b *Bot) markOrderCascadeFeedback(token string) {
+	now := b.currentTime()
+
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	if state, ok := b.orderCascadeStates[token]; ok && state != nil && state.FeedbackAt.IsZero() {
+		state.FeedbackAt = now
+	}
+}
+
 func (b *Bot) findOrderCascadeStateByUpstreamMessage(upstreamChatID int64, upstreamMessageID int) (*orderCascadeState, bool) {
 	if upstreamChatID == 0 || upstreamMessageID == 0 {
 		return nil, false
 	}
 
-	now := time.Now()
+	now := b.currentTime()
 
 	b.orderCascadeMu.Lock()
 	defer b.orderCascadeMu.Unlock()
@@ -450,7 +501,7 @@ func isOrderCascadeRelayContent(msg *botModels.Message) bool {
 	return msg.Text != "" || len(msg.Photo) > 0 || msg.Video != nil
 }
 
-func (b *Bot) tryRelayOrderCascadeReply(ctx context.Context, msg *botModels.Message) bool {
+func (b *Bot) tryRelayOrderCascadeReply(ctx context.Context, msg *botModels.Message) (relayed bool) {
 	if msg == nil || msg.From == nil || msg.From.IsBot || msg.ReplyToMessage == nil {
 		return false
 	}
@@ -463,6 +514,11 @@ func (b *Bot) tryRelayOrderCascadeReply(ctx context.Context, msg *botModels.Mess
 	if !ok || state == nil || state.MerchantChatID == 0 {
 		return false
 	}
+	defer func() {
+		if relayed {
+			b.markOrderCascadeFeedback(state.Token)
+		}
+	}()
 
 	merchantReplyOn := b.resolveCascadeMerchantReplyMode(state)
 	if !merchantReplyOn {
@@ -671,11 +727,11 @@ func formatCascadeActor(user *botModels.User) string {
 		return "未知成员"
 	}
 	if username := strings.TrimSpace(user.Username); username != "" {
-		return fmt.Sprintf("@%s", html.EscapeString(username))
+		return fmt.Sprintf("@%s", escapeHTML(username))
 	}
 	name := strings.TrimSpace(strings.TrimSpace(user.FirstName) + " " + strings.TrimSpace(user.LastName))
 	if name != "" {
-		return html.EscapeString(name)
+		return escapeHTML(name)
 	}
 	return fmt.Sprintf("#%d", user.ID)
 }
@@ -688,7 +744,7 @@ func (b *Bot) editCascadeMessage(ctx context.Context, state *orderCascadeState,
 	actionLabel := orderCascadeActionLabel(action)
 	actorText := formatCascadeActor(actor)
 	if timestamp.IsZero() {
-		timestamp = time.Now()
+		timestamp = b.currentTime()
 	}
 
 	builder := &strings.Builder{}
@@ -713,7 +769,7 @@ func (b *Bot) editCascadeMessage(ctx context.Context, state *orderCascadeState,
 			ParseMode:   botModels.ParseModeHTML,
 			ReplyMarkup: markup,
 		})
-		if err != nil {
+		if err != nil && !isMessageNotModified(err) {
 			logger.L().Errorf("Failed to edit cascade caption: chat_id=%d message_id=%d err=%v",
 				state.UpstreamChatID, state.UpstreamMessageID, err)
 		}
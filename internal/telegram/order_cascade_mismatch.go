@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	orderCascadeMismatchCommand = "转单接口检查"
+
+	// orderCascadeUnboundInterfaceNote 上游群未绑定转单接口时追加在接口名称后的提示
+	orderCascadeUnboundInterfaceNote = "（未配置接口）"
+)
+
+// orderCascadeInterfaceMismatch 转单时发现上游群接口绑定中缺少该接口的记录
+type orderCascadeInterfaceMismatch struct {
+	UpstreamChatID int64
+	UpstreamTitle  string
+	InterfaceID    string
+	LastOrderNo    string
+	Count          int
+	LastSeen       time.Time
+}
+
+func orderCascadeMismatchKey(chatID int64, interfaceID string) string {
+	return fmt.Sprintf("%d:%s", chatID, strings.ToUpper(interfaceID))
+}
+
+// recordOrderCascadeInterfaceMismatch 记录一次接口未绑定的转单，供「转单接口检查」汇总
+func (b *Bot) recordOrderCascadeInterfaceMismatch(group *models.Group, interfaceID, orderNo string) {
+	if group == nil {
+		return
+	}
+
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	if b.orderCascadeUnbound == nil {
+		b.orderCascadeUnbound = make(map[string]*orderCascadeInterfaceMismatch)
+	}
+	key := orderCascadeMismatchKey(group.TelegramID, interfaceID)
+	item, ok := b.orderCascadeUnbound[key]
+	if !ok {
+		item = &orderCascadeInterfaceMismatch{UpstreamChatID: group.TelegramID, InterfaceID: interfaceID}
+		b.orderCascadeUnbound[key] = item
+	}
+	item.UpstreamTitle = group.Title
+	item.LastOrderNo = orderNo
+	item.Count++
+	item.LastSeen = b.currentTime()
+}
+
+// listOrderCascadeInterfaceMismatches 重新读取上游群配置，剔除已补绑接口的记录，返回仍未绑定的列表（按最近出现时间倒序）
+func (b *Bot) listOrderCascadeInterfaceMismatches(ctx context.Context) []orderCascadeInterfaceMismatch {
+	b.orderCascadeMu.RLock()
+	items := make([]orderCascadeInterfaceMismatch, 0, len(b.orderCascadeUnbound))
+	for _, item := range b.orderCascadeUnbound {
+		items = append(items, *item)
+	}
+	b.orderCascadeMu.RUnlock()
+
+	result := make([]orderCascadeInterfaceMismatch, 0, len(items))
+	for _, item := range items {
+		group, err := b.groupService.GetGroupInfo(ctx, item.UpstreamChatID)
+		if err != nil {
+			logger.L().Warnf("Order cascade mismatch check failed to load group: group_id=%d err=%v", item.UpstreamChatID, err)
+			result = append(result, item)
+			continue
+		}
+		if _, _, configured := resolveCascadeInterfaceDescriptor(group.Settings.InterfaceBindings, item.InterfaceID, ""); configured {
+			b.orderCascadeMu.Lock()
+			delete(b.orderCascadeUnbound, orderCascadeMismatchKey(item.UpstreamChatID, item.InterfaceID))
+			b.orderCascadeMu.Unlock()
+			continue
+		}
+		item.UpstreamTitle = group.Title
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastSeen.Equal(result[j].LastSeen) {
+			return result[i].LastSeen.After(result[j].LastSeen)
+		}
+		if result[i].UpstreamChatID != result[j].UpstreamChatID {
+			return result[i].UpstreamChatID < result[j].UpstreamChatID
+		}
+		return result[i].InterfaceID < result[j].InterfaceID
+	})
+	return result
+}
+
+// handleOrderCascadeMismatchCheck 处理"转单接口检查"命令，列出转单时上游群未绑定接口的记录
+func (b *Bot) handleOrderCascadeMismatchCheck(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	items := b.listOrderCascadeInterfaceMismatches(ctx)
+	if len(items) == 0 {
+		b.sendSuccessMessage(ctx, msg.Chat.ID, "转单接口检查通过，未发现上游群未绑定的接口", msg.ID)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("⚠️ 发现 %d 个转单接口未在上游群绑定：\n\n", len(items)))
+	for i, item := range items {
+		text.WriteString(fmt.Sprintf("%d. %s (%d)\n", i+1, html.EscapeString(item.UpstreamTitle), item.UpstreamChatID))
+		text.WriteString(fmt.Sprintf("   接口：<code>%s</code>，出现 %d 次，最近订单 %s（%s）\n",
+			html.EscapeString(item.InterfaceID), item.Count, html.EscapeString(item.LastOrderNo), item.LastSeen.Format("01-02 15:04")))
+	}
+	text.WriteString("\n请在对应上游群补充接口绑定")
+	b.sendMessage(ctx, msg.Chat.ID, text.String(), msg.ID)
+}
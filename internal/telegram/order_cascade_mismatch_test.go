@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+type mismatchTestGroupService struct {
+	service.GroupService
+	groups map[int64]*models.Group
+}
+
+func (s *mismatchTestGroupService) GetGroupInfo(ctx context.Context, telegramID int64) (*models.Group, error) {
+	return s.groups[telegramID], nil
+}
+
+func TestResolveCascadeInterfaceDescriptorDetectsUnboundInterface(t *testing.T) {
+	bindings := []models.InterfaceBinding{{ID: "pz01", Name: "支付宝", Rate: "3.5"}}
+
+	name, rate, configured := resolveCascadeInterfaceDescriptor(bindings, "PZ01", "")
+	if !configured || name != "支付宝" || rate != "3.5" {
+		t.Fatalf("expected bound interface, got name=%q rate=%q configured=%v", name, rate, configured)
+	}
+
+	name, rate, configured = resolveCascadeInterfaceDescriptor(bindings, "PZ02", "微信")
+	if configured || name != "微信" || rate != "" {
+		t.Fatalf("expected unbound interface with fallback name, got name=%q rate=%q configured=%v", name, rate, configured)
+	}
+}
+
+func TestListOrderCascadeInterfaceMismatchesDropsRebound(t *testing.T) {
+	upstreamA := &models.Group{TelegramID: -100, Title: "上游A"}
+	upstreamB := &models.Group{TelegramID: -200, Title: "上游B"}
+	b := &Bot{groupService: &mismatchTestGroupService{groups: map[int64]*models.Group{-100: upstreamA, -200: upstreamB}}}
+
+	b.recordOrderCascadeInterfaceMismatch(upstreamA, "PZ01", "ORDER1")
+	b.recordOrderCascadeInterfaceMismatch(upstreamA, "pz01", "ORDER2")
+	b.recordOrderCascadeInterfaceMismatch(upstreamB, "PZ02", "ORDER3")
+
+	items := b.listOrderCascadeInterfaceMismatches(context.Background())
+	if len(items) != 2 {
+		t.Fatalf("expected 2 mismatches, got %+v", items)
+	}
+	if items[0].UpstreamChatID != -200 || items[1].Count != 2 || items[1].LastOrderNo != "ORDER2" {
+		t.Fatalf("unexpected mismatches: %+v", items)
+	}
+
+	upstreamA.Settings.InterfaceBindings = []models.InterfaceBinding{{ID: "PZ01", Name: "支付宝"}}
+	items = b.listOrderCascadeInterfaceMismatches(context.Background())
+	if len(items) != 1 || items[0].InterfaceID != "PZ02" {
+		t.Fatalf("expected rebound interface to be dropped, got %+v", items)
+	}
+}
@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	orderCascadePendingCommand = "待处理转单"
+	// orderCascadePendingListLimit 待处理转单最多列出的条数
+	orderCascadePendingListLimit = 30
+)
+
+// listPendingOrderCascades 返回未过期且尚未收到上游反馈的转单状态（按发起时间从早到晚），chatID 为 0 时返回全部，
+// 否则只返回该群作为商户群或上游群参与的转单
+func (b *Bot) listPendingOrderCascades(chatID int64) []orderCascadeState {
+	now := b.currentTime()
+
+	b.orderCascadeMu.RLock()
+	pending := make([]orderCascadeState, 0, len(b.orderCascadeStates))
+	for _, state := range b.orderCascadeStates {
+		if state == nil || now.After(state.ExpiresAt) || !state.FeedbackAt.IsZero() {
+			continue
+		}
+		if chatID != 0 && state.MerchantChatID != chatID && state.UpstreamChatID != chatID {
+			continue
+		}
+		pending = append(pending, *state)
+	}
+	b.orderCascadeMu.RUnlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].CreatedAt.Equal(pending[j].CreatedAt) {
+			return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+		}
+		return pending[i].Token < pending[j].Token
+	})
+	return pending
+}
+
+// handleOrderCascadePending 处理"待处理转单"命令：群内列出本群等待上游反馈的转单，Owner 私聊时列出全部
+func (b *Bot) handleOrderCascadePending(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	var chatID int64
+	if msg.Chat.Type == "group" || msg.Chat.Type == "supergroup" {
+		chatID = msg.Chat.ID
+	} else {
+		isOwner := false
+		if msg.From != nil {
+			owner, err := b.userService.CheckOwnerPermission(ctx, msg.From.ID)
+			if err != nil {
+				logger.L().Warnf("Pending cascades failed to check owner permission: user_id=%d err=%v", msg.From.ID, err)
+			}
+			isOwner = owner
+		}
+		if !isOwner {
+			b.sendErrorMessage(ctx, msg.Chat.ID, "此命令只能在群组中使用（Owner 可在私聊中查看全部）", msg.ID)
+			return
+		}
+	}
+
+	b.sendMessage(ctx, msg.Chat.ID, formatPendingOrderCascades(b.listPendingOrderCascades(chatID), chatID == 0, b.currentTime()), msg.ID)
+}
+
+// formatPendingOrderCascades 生成待处理转单列表，展示订单号、接口、上游群与已等待时长
+func formatPendingOrderCascades(pending []orderCascadeState, all bool, now time.Time) string {
+	scope := "本群"
+	if all {
+		scope = "全部群组"
+	}
+	if len(pending) == 0 {
+		return fmt.Sprintf("✅ %s暂无等待上游反馈的转单", scope)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("⏳ %s待处理转单（%d 条）\n", scope, len(pending)))
+	for i, state := range pending {
+		if i == orderCascadePendingListLimit {
+			text.WriteString(fmt.Sprintf("\n… 其余 %d 条未列出", len(pending)-orderCascadePendingListLimit))
+			break
+		}
+
+		orderNo := state.OrderNo
+		if orderNo == "" {
+			orderNo = state.MerchantOrderNo
+		}
+		testMark := ""
+		if state.IsTest {
+			testMark = "（测试）"
+		}
+		interfaceLabel := html.EscapeString(state.InterfaceID)
+		if name := strings.TrimSpace(state.InterfaceName); name != "" {
+			interfaceLabel = fmt.Sprintf("%s (%s)", html.EscapeString(name), interfaceLabel)
+		}
+		upstreamTitle := strings.TrimSpace(state.UpstreamGroupTitle)
+		if upstreamTitle == "" {
+			upstreamTitle = "未命名群组"
+		}
+
+		text.WriteString(fmt.Sprintf("\n%d. 订单 <code>%s</code>%s\n", i+1, html.EscapeString(orderNo), testMark))
+		text.WriteString(fmt.Sprintf("   接口：%s\n", interfaceLabel))
+		text.WriteString(fmt.Sprintf("   上游群：%s (%d)\n", html.EscapeString(upstreamTitle), state.UpstreamChatID))
+		text.WriteString(fmt.Sprintf("   已等待：%s\n", formatDuration(now.Sub(state.CreatedAt))))
+	}
+	return strings.TrimRight(text.String(), "\n")
+}
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestListPendingOrderCascadesExcludesExpired(t *testing.T) {
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	b := &Bot{
+		nowFunc: func() time.Time { return now },
+		orderCascadeStates: map[string]*orderCascadeState{
+			"active-old": {
+				Token: "active-old", MerchantChatID: -2001, UpstreamChatID: -1001, UpstreamGroupTitle: "上游A",
+				OrderNo: "ORDER1", InterfaceID: "pz-1", InterfaceName: "支付宝",
+				CreatedAt: now.Add(-25 * time.Minute), ExpiresAt: now.Add(time.Hour),
+			},
+			"active-new": {
+				Token: "active-new", MerchantChatID: -2002, UpstreamChatID: -1001, UpstreamGroupTitle: "上游A",
+				OrderNo: "ORDER2", InterfaceID: "pz-2",
+				CreatedAt: now.Add(-5 * time.Minute), ExpiresAt: now.Add(time.Hour),
+			},
+			"expired": {
+				Token: "expired", MerchantChatID: -2001, UpstreamChatID: -1001,
+				OrderNo: "ORDER3", InterfaceID: "pz-1",
+				CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Minute),
+			},
+		},
+	}
+
+	all := b.listPendingOrderCascades(0)
+	if len(all) != 2 || all[0].OrderNo != "ORDER1" || all[1].OrderNo != "ORDER2" {
+		t.Fatalf("expected two active states ordered by age, got %+v", all)
+	}
+
+	merchant := b.listPendingOrderCascades(-2001)
+	if len(merchant) != 1 || merchant[0].OrderNo != "ORDER1" {
+		t.Fatalf("expected only the active state of merchant group, got %+v", merchant)
+	}
+	if upstream := b.listPendingOrderCascades(-1001); len(upstream) != 2 {
+		t.Fatalf("expected upstream group to see both active states, got %d", len(upstream))
+	}
+
+	text := formatPendingOrderCascades(all, true, now)
+	for _, want := range []string{"全部群组待处理转单（2 条）", "订单 <code>ORDER1</code>", "接口：支付宝 (pz-1)", "上游群：上游A (-1001)", "已等待：25分钟"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in list:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "ORDER3") {
+		t.Fatalf("expected expired cascade to be excluded:\n%s", text)
+	}
+
+	if got := formatPendingOrderCascades(nil, false, now); got != "✅ 本群暂无等待上游反馈的转单" {
+		t.Fatalf("unexpected empty list text: %s", got)
+	}
+}
+
+func TestListPendingOrderCascadesExcludesRelayedFeedback(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	b := &Bot{
+		bot:     botInstance,
+		nowFunc: func() time.Time { return now },
+		orderCascadeStates: map[string]*orderCascadeState{
+			"replied": {
+				Token: "replied", MerchantChatID: -2001, UpstreamChatID: -1001, UpstreamMessageID: 50,
+				OrderNo: "ORDER1", CreatedAt: now.Add(-10 * time.Minute), ExpiresAt: now.Add(time.Hour),
+			},
+			"clicked": {
+				Token: "clicked", MerchantChatID: -2001, UpstreamChatID: -1001, UpstreamMessageID: 51,
+				OrderNo: "ORDER2", CreatedAt: now.Add(-8 * time.Minute), ExpiresAt: now.Add(time.Hour),
+			},
+			"waiting": {
+				Token: "waiting", MerchantChatID: -2001, UpstreamChatID: -1001, UpstreamMessageID: 52,
+				OrderNo: "ORDER3", CreatedAt: now.Add(-5 * time.Minute), ExpiresAt: now.Add(time.Hour),
+			},
+		},
+	}
+
+	reply := &botModels.Message{
+		ID:             60,
+		Text:           "已处理",
+		Chat:           botModels.Chat{ID: -1001, Type: "group"},
+		From:           &botModels.User{ID: 7},
+		ReplyToMessage: &botModels.Message{ID: 50},
+	}
+	if !b.tryRelayOrderCascadeReply(context.Background(), reply) {
+		t.Fatal("expected upstream reply to be relayed")
+	}
+	if len(api.Messages()) != 1 {
+		t.Fatalf("expected reply relayed to merchant group, got %+v", api.Messages())
+	}
+	b.markOrderCascadeFeedback("clicked")
+
+	pending := b.listPendingOrderCascades(-2001)
+	if len(pending) != 1 || pending[0].OrderNo != "ORDER3" {
+		t.Fatalf("expected only the cascade without feedback, got %+v", pending)
+	}
+	if !b.orderCascadeStates["replied"].FeedbackAt.Equal(now) {
+		t.Fatalf("expected feedback time recorded, got %v", b.orderCascadeStates["replied"].FeedbackAt)
+	}
+}
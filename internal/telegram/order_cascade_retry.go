@@ -0,0 +1,286 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	orderCascadeRetryCommand = "重试转单"
+
+	orderCascadeMediaPhoto = "photo"
+	orderCascadeMediaVideo = "video"
+
+	orderCascadeRetryInterval   = 30 * time.Second
+	orderCascadeRetryBaseDelay  = 30 * time.Second
+	orderCascadeRetryMaxDelay   = 10 * time.Minute
+	orderCascadeMaxAutoAttempts = 5
+)
+
+// orderCascadeDelivery 一次待发送的转单，失败时保留完整上下文以便重新发送
+type orderCascadeDelivery struct {
+	State       *orderCascadeState // 转单状态（UpstreamMessageID 在发送成功后写入）
+	MediaType   string             // photo / video，为空表示纯文本
+	MediaFileID string             // 商户原消息中的媒体 file_id
+	Attempts    int                // 已失败次数
+	LastError   string
+	NextRetryAt time.Time
+}
+
+// deliverOrderCascade 将转单发送到上游群；媒体被 Telegram 拒绝（如 file_id 过期）时降级为纯文本转单
+func (b *Bot) deliverOrderCascade(ctx context.Context, delivery *orderCascadeDelivery) (*botModels.Message, error) {
+	sent, err := b.sendOrderCascadeDelivery(ctx, delivery)
+	if err == nil || delivery.MediaType == "" || !errors.Is(err, bot.ErrorBadRequest) {
+		return sent, err
+	}
+
+	logger.L().Warnf("Order cascade media rejected, falling back to text: upstream_chat=%d order_no=%s media=%s err=%v",
+		delivery.State.UpstreamChatID, delivery.State.OrderNo, delivery.MediaType, err)
+	delivery.MediaType = ""
+	delivery.MediaFileID = ""
+	delivery.State.HasMedia = false
+	return b.sendOrderCascadeDelivery(ctx, delivery)
+}
+
+// sendOrderCascadeDelivery 按媒体类型发送转单消息
+func (b *Bot) sendOrderCascadeDelivery(ctx context.Context, delivery *orderCascadeDelivery) (*botModels.Message, error) {
+	state := delivery.State
+	markup := buildOrderCascadeKeyboard(state.Token)
+
+	switch delivery.MediaType {
+	case orderCascadeMediaPhoto:
+		return b.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:      state.UpstreamChatID,
+			Photo:       &botModels.InputFileString{Data: delivery.MediaFileID},
+			Caption:     state.BaseMessageText,
+			ParseMode:   botModels.ParseModeHTML,
+			ReplyMarkup: markup,
+		})
+	case orderCascadeMediaVideo:
+		return b.bot.SendVideo(ctx, &bot.SendVideoParams{
+			ChatID:      state.UpstreamChatID,
+			Video:       &botModels.InputFileString{Data: delivery.MediaFileID},
+			Caption:     state.BaseMessageText,
+			ParseMode:   botModels.ParseModeHTML,
+			ReplyMarkup: markup,
+		})
+	default:
+		return b.sendMessageWithMarkupAndMessage(ctx, state.UpstreamChatID, state.BaseMessageText, markup)
+	}
+}
+
+// orderCascadeRetryDelay 指数退避：30s、1m、2m……最长 10 分钟
+func orderCascadeRetryDelay(attempts int) time.Duration {
+	delay := orderCascadeRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= orderCascadeRetryMaxDelay {
+			return orderCascadeRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// recordOrderCascadeFailure 记录发送失败的转单，等待自动或手动重试
+func (b *Bot) recordOrderCascadeFailure(delivery *orderCascadeDelivery, sendErr error, now time.Time) {
+	if delivery == nil || delivery.State == nil || delivery.State.Token == "" || delivery.State.IsTest {
+		return
+	}
+
+	if sendErr == nil {
+		sendErr = errors.New("empty send result")
+	}
+	delivery.Attempts++
+	delivery.LastError = sendErr.Error()
+	delivery.NextRetryAt = now.Add(orderCascadeRetryDelay(delivery.Attempts))
+
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	if b.orderCascadeFailures == nil {
+		b.orderCascadeFailures = make(map[string]*orderCascadeDelivery)
+	}
+	b.orderCascadeFailures[delivery.State.Token] = delivery
+}
+
+// takeOrderCascadeFailures 取出待重试的转单；merchantChatID 为 0 表示全部群组，dueOnly 时仅取到期且未超过自动重试上限的记录
+func (b *Bot) takeOrderCascadeFailures(merchantChatID int64, now time.Time, dueOnly bool) []*orderCascadeDelivery {
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	var due []*orderCascadeDelivery
+	for token, delivery := range b.orderCascadeFailures {
+		if delivery == nil || delivery.State == nil || now.After(delivery.State.ExpiresAt) {
+			delete(b.orderCascadeFailures, token)
+			continue
+		}
+		if merchantChatID != 0 && delivery.State.MerchantChatID != merchantChatID {
+			continue
+		}
+		if dueOnly && (delivery.Attempts > orderCascadeMaxAutoAttempts || now.Before(delivery.NextRetryAt)) {
+			continue
+		}
+		delete(b.orderCascadeFailures, token)
+		due = append(due, delivery)
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].State.CreatedAt.Before(due[j].State.CreatedAt)
+	})
+	return due
+}
+
+// purgeExpiredOrderCascadeFailures 清理已过期（超过转单有效期）的失败转单，返回清理数量
+func (b *Bot) purgeExpiredOrderCascadeFailures(now time.Time) int {
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	purged := 0
+	for token, delivery := range b.orderCascadeFailures {
+		if delivery == nil || delivery.State == nil || now.After(delivery.State.ExpiresAt) {
+			delete(b.orderCascadeFailures, token)
+			purged++
+		}
+	}
+	return purged
+}
+
+// pendingOrderCascadeFailures 返回群组当前失败待重试的转单数量
+func (b *Bot) pendingOrderCascadeFailures(merchantChatID int64) int {
+	b.orderCascadeMu.RLock()
+	defer b.orderCascadeMu.RUnlock()
+
+	count := 0
+	for _, delivery := range b.orderCascadeFailures {
+		if delivery != nil && delivery.State != nil && delivery.State.MerchantChatID == merchantChatID {
+			count++
+		}
+	}
+	return count
+}
+
+// retryOrderCascadeFailures 重新发送失败的转单，成功后转为正常的联动状态，失败则按退避重新排队
+func (b *Bot) retryOrderCascadeFailures(ctx context.Context, merchantChatID int64, dueOnly bool) (succeeded, failed int) {
+	for _, delivery := range b.takeOrderCascadeFailures(merchantChatID, b.currentTime(), dueOnly) {
+		if ctx.Err() != nil {
+			b.requeueOrderCascadeFailure(delivery)
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, orderCascadeSendTimeout)
+		sent, err := b.deliverOrderCascade(sendCtx, delivery)
+		cancel()
+
+		state := delivery.State
+		if err != nil || sent == nil {
+			logger.L().Warnf("Order cascade retry failed: upstream_chat=%d order_no=%s attempts=%d err=%v",
+				state.UpstreamChatID, state.OrderNo, delivery.Attempts+1, err)
+			b.recordOrderCascadeFailure(delivery, err, b.currentTime())
+			failed++
+			continue
+		}
+
+		state.UpstreamMessageID = sent.ID
+		b.saveOrderCascadeState(state)
+		logger.L().Infof("Order cascade retried: merchant_chat=%d upstream_chat=%d order_no=%s attempts=%d",
+			state.MerchantChatID, state.UpstreamChatID, state.OrderNo, delivery.Attempts)
+		succeeded++
+	}
+	return succeeded, failed
+}
+
+func (b *Bot) requeueOrderCascadeFailure(delivery *orderCascadeDelivery) {
+	b.orderCascadeMu.Lock()
+	defer b.orderCascadeMu.Unlock()
+
+	if b.orderCascadeFailures == nil {
+		b.orderCascadeFailures = make(map[string]*orderCascadeDelivery)
+	}
+	b.orderCascadeFailures[delivery.State.Token] = delivery
+}
+
+// handleRetryOrderCascade 处理"重试转单"命令，立即重试本群发送失败的转单
+func (b *Bot) handleRetryOrderCascade(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if b.pendingOrderCascadeFailures(msg.Chat.ID) == 0 {
+		b.sendMessage(ctx, msg.Chat.ID, "ℹ️ 当前群组没有发送失败的转单", msg.ID)
+		return
+	}
+
+	succeeded, failed := b.retryOrderCascadeFailures(ctx, msg.Chat.ID, false)
+	if failed > 0 {
+		b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("重试完成：成功 %d 笔，失败 %d 笔（稍后自动重试）", succeeded, failed), msg.ID)
+		return
+	}
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("重试完成：成功 %d 笔", succeeded), msg.ID)
+}
+
+// orderCascadeRetryWorker 定期自动重试发送失败的转单
+type orderCascadeRetryWorker struct {
+	bot      *Bot
+	cancel   context.CancelFunc
+	done     chan struct{}
+	interval time.Duration
+}
+
+func newOrderCascadeRetryWorker(bot *Bot) *orderCascadeRetryWorker {
+	return &orderCascadeRetryWorker{
+		bot:      bot,
+		interval: orderCascadeRetryInterval,
+	}
+}
+
+func (w *orderCascadeRetryWorker) start() {
+	if w == nil || w.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.run(ctx)
+	logger.L().Info("Order cascade retry worker started")
+}
+
+func (w *orderCascadeRetryWorker) stop() {
+	if w == nil || w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	<-w.done
+	w.cancel = nil
+	w.done = nil
+	logger.L().Info("Order cascade retry worker stopped")
+}
+
+func (w *orderCascadeRetryWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if succeeded, failed := w.bot.retryOrderCascadeFailures(ctx, 0, true); succeeded+failed > 0 {
+				logger.L().Infof("Order cascade auto retry: succeeded=%d failed=%d", succeeded, failed)
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+type cascadeTestPaymentService struct {
+	paymentservice.Service
+	binding *paymentservice.OrderChannelBinding
+}
+
+func (s *cascadeTestPaymentService) FindOrderChannelBinding(ctx context.Context, merchantID int64, orderNo string, numberType paymentservice.OrderNumberType) (*paymentservice.OrderChannelBinding, error) {
+	return s.binding, nil
+}
+
+type cascadeTestGroupService struct {
+	service.GroupService
+	upstream *models.Group
+}
+
+func (s *cascadeTestGroupService) FindGroupByInterfaceID(ctx context.Context, interfaceID string) (*models.Group, error) {
+	return s.upstream, nil
+}
+
+func newTestOrderCascadeDelivery(now time.Time) *orderCascadeDelivery {
+	return &orderCascadeDelivery{
+		State: &orderCascadeState{
+			Token:             "retry-token",
+			MerchantChatID:    -20001,
+			MerchantMessageID: 11,
+			UpstreamChatID:    -1001,
+			OrderNo:           "ORDER123",
+			InterfaceID:       "pz-1",
+			BaseMessageText:   "订单联动",
+			CreatedAt:         now,
+			ExpiresAt:         now.Add(orderCascadeStateTTL),
+		},
+	}
+}
+
+func TestRetryOrderCascadeFailuresTransitionsToLiveState(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance, orderCascadeStates: make(map[string]*orderCascadeState)}
+
+	now := time.Now()
+	delivery := newTestOrderCascadeDelivery(now)
+
+	api.FailNext("sendMessage", 1)
+	sent, err := b.deliverOrderCascade(context.Background(), delivery)
+	if err == nil || sent != nil {
+		t.Fatalf("expected initial cascade send to fail, got sent=%v err=%v", sent, err)
+	}
+	b.recordOrderCascadeFailure(delivery, err, now)
+
+	if got := b.pendingOrderCascadeFailures(-20001); got != 1 {
+		t.Fatalf("expected 1 pending failure, got %d", got)
+	}
+
+	// 自动重试未到退避时间时不应发送
+	if succeeded, failed := b.retryOrderCascadeFailures(context.Background(), 0, true); succeeded != 0 || failed != 0 {
+		t.Fatalf("expected no due retries, got succeeded=%d failed=%d", succeeded, failed)
+	}
+
+	succeeded, failed := b.retryOrderCascadeFailures(context.Background(), -20001, false)
+	if succeeded != 1 || failed != 0 {
+		t.Fatalf("expected retry to succeed, got succeeded=%d failed=%d", succeeded, failed)
+	}
+	if got := b.pendingOrderCascadeFailures(-20001); got != 0 {
+		t.Fatalf("expected failure to be cleared, got %d", got)
+	}
+
+	state, ok := b.findOrderCascadeStateByUpstreamMessage(-1001, 1)
+	if !ok || state.Token != "retry-token" {
+		t.Fatalf("expected retried cascade to become live, got ok=%v state=%+v", ok, state)
+	}
+	if len(api.Requests("sendMessage")) != 2 {
+		t.Fatalf("expected 2 send attempts, got %d", len(api.Requests("sendMessage")))
+	}
+}
+
+func TestRetryOrderCascadeFailuresBacksOffOnRepeatedFailure(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{bot: botInstance}
+
+	now := time.Now()
+	delivery := newTestOrderCascadeDelivery(now)
+	b.recordOrderCascadeFailure(delivery, context.DeadlineExceeded, now.Add(-time.Hour))
+
+	api.FailNext("sendMessage", 1)
+	succeeded, failed := b.retryOrderCascadeFailures(context.Background(), 0, true)
+	if succeeded != 0 || failed != 1 {
+		t.Fatalf("expected due retry to fail, got succeeded=%d failed=%d", succeeded, failed)
+	}
+
+	pending := b.orderCascadeFailures["retry-token"]
+	if pending == nil || pending.Attempts != 2 {
+		t.Fatalf("expected failure to be re-queued with 2 attempts, got %+v", pending)
+	}
+	if wait := time.Until(pending.NextRetryAt); wait < 50*time.Second || wait > orderCascadeRetryDelay(2) {
+		t.Fatalf("expected backoff of about %s, got %s", orderCascadeRetryDelay(2), wait)
+	}
+}
+
+func TestOrderCascadeRetryDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: orderCascadeRetryMaxDelay,
+	}
+	for attempts, want := range cases {
+		if got := orderCascadeRetryDelay(attempts); got != want {
+			t.Fatalf("attempts=%d: expected %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestOrderCascadeFallsBackToTextWhenMediaRejected(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	b := &Bot{
+		bot:                botInstance,
+		paymentService:     &cascadeTestPaymentService{binding: &paymentservice.OrderChannelBinding{PZID: "pz-1", PZName: "支付宝"}},
+		groupService:       &cascadeTestGroupService{upstream: &models.Group{TelegramID: -1001, BotStatus: models.BotStatusActive, Settings: models.GroupSettings{CascadeForwardEnabled: true}}},
+		orderCascadeStates: make(map[string]*orderCascadeState),
+	}
+	merchant := &models.Group{TelegramID: -20001, Settings: models.GroupSettings{MerchantID: 1001}}
+	msg := &botModels.Message{
+		ID:      11,
+		Chat:    botModels.Chat{ID: -20001},
+		Caption: "ORDER123",
+		Photo:   []botModels.PhotoSize{{FileID: "expired-file-id"}},
+	}
+
+	api.FailNext("sendPhoto", 1)
+	b.startOrderCascadeWorkflow(merchant, msg, []string{"ORDER123"})
+
+	if len(api.Requests("sendPhoto")) != 1 || len(api.Requests("sendMessage")) != 1 {
+		t.Fatalf("expected one rejected photo and one text fallback, got photo=%d text=%d",
+			len(api.Requests("sendPhoto")), len(api.Requests("sendMessage")))
+	}
+	state, ok := b.findOrderCascadeStateByUpstreamMessage(-1001, 1)
+	if !ok {
+		t.Fatal("expected text fallback cascade state to be saved")
+	}
+	if state.HasMedia {
+		t.Fatal("expected fallback state to be marked as text-only")
+	}
+	if got := b.pendingOrderCascadeFailures(-20001); got != 0 {
+		t.Fatalf("expected no pending failures, got %d", got)
+	}
+}
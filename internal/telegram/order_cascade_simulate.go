@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const (
+	orderCascadeSimulateCommand = "测试转单"
+
+	// orderCascadeTestOrderPrefix 测试转单的虚拟订单号前缀
+	orderCascadeTestOrderPrefix = "TEST-"
+	// orderCascadeTestBanner 测试转单消息顶部的提示，避免上游误当真实订单处理
+	orderCascadeTestBanner = "🧪 <b>测试转单（非真实订单，请勿处理）</b>\n"
+)
+
+// handleOrderCascadeSimulate 处理"测试转单 <上游群ID或接口ID>"命令，向上游群发送一条测试转单并登记临时状态，
+// 用于在没有真实订单时确认按钮反馈与回复同步是否正常。测试状态带 IsTest 标记，不会进入失败重试与接口检查
+func (b *Bot) handleOrderCascadeSimulate(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	target := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(msg.Text), orderCascadeSimulateCommand))
+	if target == "" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "用法：测试转单 &lt;上游群ID或接口ID&gt;", msg.ID)
+		return
+	}
+
+	upstreamGroup, interfaceID, errMsg := b.resolveOrderCascadeSimulateTarget(ctx, target)
+	if errMsg != "" {
+		b.sendErrorMessage(ctx, msg.Chat.ID, errMsg, msg.ID)
+		return
+	}
+	if upstreamGroup.TelegramID == msg.Chat.ID {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "请在上游群以外的群组发起测试转单", msg.ID)
+		return
+	}
+	if upstreamGroup.BotStatus != models.BotStatusActive {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "Bot 已不在该上游群中", msg.ID)
+		return
+	}
+
+	state := b.newOrderCascadeTestState(msg, upstreamGroup, interfaceID)
+	sendCtx, cancel := context.WithTimeout(ctx, orderCascadeSendTimeout)
+	sent, err := b.deliverOrderCascade(sendCtx, &orderCascadeDelivery{State: state})
+	cancel()
+	if err != nil || sent == nil {
+		logger.L().Warnf("Order cascade test send failed: upstream_chat=%d interface_id=%s err=%v", upstreamGroup.TelegramID, interfaceID, err)
+		b.sendErrorMessage(ctx, msg.Chat.ID, fmt.Sprintf("测试转单发送失败：%s", html.EscapeString(fmt.Sprint(err))), msg.ID)
+		return
+	}
+
+	state.UpstreamMessageID = sent.ID
+	b.saveOrderCascadeState(state)
+	logger.L().Infof("Order cascade test sent: merchant_chat=%d upstream_chat=%d interface_id=%s token=%s user_id=%d",
+		msg.Chat.ID, upstreamGroup.TelegramID, interfaceID, state.Token, msg.From.ID)
+
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf(
+		"已向 %s (%d) 发送测试转单 <code>%s</code>\n请在上游群点击按钮或回复该消息，反馈会同步到本群（%d 分钟内有效）",
+		html.EscapeString(upstreamGroup.Title), upstreamGroup.TelegramID, state.OrderNo, int(orderCascadeTestStateTTL.Minutes())), msg.ID)
+}
+
+// resolveOrderCascadeSimulateTarget 按群 ID 或接口 ID 找到目标上游群，返回群组、使用的接口 ID 与错误提示
+func (b *Bot) resolveOrderCascadeSimulateTarget(ctx context.Context, target string) (*models.Group, string, string) {
+	if chatID, err := strconv.ParseInt(target, 10, 64); err == nil {
+		group, err := b.groupService.GetGroupInfo(ctx, chatID)
+		if err != nil || group == nil {
+			return nil, "", fmt.Sprintf("未找到群组 %d", chatID)
+		}
+		bindings := models.NormalizeInterfaceBindings(group.Settings.InterfaceBindings)
+		if len(bindings) == 0 {
+			return nil, "", fmt.Sprintf("群组 %d 不是上游群（未绑定接口）", chatID)
+		}
+		return group, bindings[0].ID, ""
+	}
+
+	group, err := b.groupService.FindGroupByInterfaceID(ctx, target)
+	if err != nil {
+		return nil, "", fmt.Sprintf("查询接口绑定失败：%s", html.EscapeString(err.Error()))
+	}
+	if group == nil {
+		return nil, "", fmt.Sprintf("接口 %s 未绑定任何上游群", html.EscapeString(target))
+	}
+	return group, target, ""
+}
+
+// newOrderCascadeTestState 构造测试转单状态，发起命令的群作为"商户群"接收反馈
+func (b *Bot) newOrderCascadeTestState(msg *botModels.Message, upstreamGroup *models.Group, interfaceID string) *orderCascadeState {
+	now := b.currentTime()
+	token := generateOrderCascadeToken()
+	orderNo := orderCascadeTestOrderPrefix + strings.ToUpper(token)
+	interfaceName, _, _ := resolveCascadeInterfaceDescriptor(upstreamGroup.Settings.InterfaceBindings, interfaceID, "")
+
+	caption := orderCascadeTestBanner + buildOrderCascadeMessage(orderCascadeMessagePayload{
+		MerchantOrderNoFull: orderNo,
+		OrderNo:             orderNo,
+		StatusText:          "测试",
+		InterfaceName:       interfaceName,
+		Template:            upstreamGroup.Settings.CascadeCaptionTemplate,
+	})
+
+	return &orderCascadeState{
+		Token:              token,
+		MerchantChatID:     msg.Chat.ID,
+		MerchantMessageID:  msg.ID,
+		MerchantReplyOn:    true,
+		UpstreamChatID:     upstreamGroup.TelegramID,
+		OrderNo:            orderNo,
+		MerchantOrderNo:    orderNo,
+		MerchantOrderFull:  orderNo,
+		InterfaceID:        interfaceID,
+		InterfaceName:      interfaceName,
+		SourceGroupTitle:   msg.Chat.Title,
+		UpstreamGroupTitle: upstreamGroup.Title,
+		BaseMessageText:    caption,
+		IsTest:             true,
+		CreatedAt:          now,
+		ExpiresAt:          now.Add(orderCascadeTestStateTTL),
+	}
+}
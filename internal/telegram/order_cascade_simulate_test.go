@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+
+	botModels "github.com/go-telegram/bot/models"
+)
+
+func TestHandleOrderCascadeSimulateCreatesTestState(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+	upstream := &models.Group{
+		TelegramID: -1001,
+		Title:      "上游A",
+		BotStatus:  models.BotStatusActive,
+		Settings:   models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{ID: "PZ01", Name: "支付宝"}}},
+	}
+	b := &Bot{
+		bot:                botInstance,
+		groupService:       &cascadeTestGroupService{upstream: upstream},
+		orderCascadeStates: make(map[string]*orderCascadeState),
+	}
+
+	update := &botModels.Update{Message: &botModels.Message{
+		ID:   7,
+		Text: "测试转单 PZ01",
+		Chat: botModels.Chat{ID: -20001, Title: "运维群"},
+		From: &botModels.User{ID: 1},
+	}}
+	b.handleOrderCascadeSimulate(context.Background(), botInstance, update)
+
+	state, ok := b.findOrderCascadeStateByUpstreamMessage(-1001, 1)
+	if !ok {
+		t.Fatal("expected test cascade state to be registered")
+	}
+	if !state.IsTest || !strings.HasPrefix(state.OrderNo, orderCascadeTestOrderPrefix) {
+		t.Fatalf("expected state marked as test, got %+v", state)
+	}
+	if state.MerchantChatID != -20001 || state.InterfaceName != "支付宝" {
+		t.Fatalf("unexpected state routing: %+v", state)
+	}
+
+	messages := api.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected cascade and confirmation messages, got %+v", messages)
+	}
+	if messages[0].ChatID != "-1001" || !strings.Contains(messages[0].Text, "测试转单") {
+		t.Fatalf("expected test banner in upstream message, got %+v", messages[0])
+	}
+
+	// 测试转单发送失败时不进入失败重试队列
+	api.FailNext("sendMessage", 1)
+	b.handleOrderCascadeSimulate(context.Background(), botInstance, update)
+	if got := b.pendingOrderCascadeFailures(-20001); got != 0 {
+		t.Fatalf("expected test cascade failure not queued for retry, got %d", got)
+	}
+}
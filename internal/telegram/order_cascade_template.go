@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// orderCascadeTemplateMaxLen 转单文案模板最大字符数
+	orderCascadeTemplateMaxLen = 500
+	// orderCascadeTemplateReset 输入该值时恢复默认转单文案
+	orderCascadeTemplateReset = "默认"
+)
+
+// orderCascadeTemplatePlaceholders 转单文案模板支持的占位符
+var orderCascadeTemplatePlaceholders = []string{"order", "status", "interface", "channel"}
+
+// orderCascadeTemplateTagPattern 模板中允许使用的 HTML 标签（Telegram HTML 子集，不含属性）
+var orderCascadeTemplateTagPattern = regexp.MustCompile(`^<(/?)(b|strong|i|em|u|s|code|pre)>`)
+
+// orderCascadeTemplateEntityPattern Telegram HTML 支持的字符实体
+var orderCascadeTemplateEntityPattern = regexp.MustCompile(`^&(lt|gt|amp|quot|#[0-9]+|#x[0-9a-fA-F]+);`)
+
+// orderCascadeTemplatePrompt 配置菜单中编辑转单文案时的提示（以回调提示展示，需控制在 200 字以内）
+var orderCascadeTemplatePrompt = fmt.Sprintf(
+	"请输入转单文案模板，可用占位符：{order} 订单号、{status} 状态、{interface} 接口、{channel} 通道；支持 b/i/u/s/code/pre 标签，最多 %d 字，链接自动附在文案后；发送“%s”恢复默认格式",
+	orderCascadeTemplateMaxLen, orderCascadeTemplateReset,
+)
+
+// validateOrderCascadeTemplate 校验转单文案模板：占位符必须已知且括号成对，HTML 标签仅限白名单并正确闭合，& 仅用于字符实体
+func validateOrderCascadeTemplate(template string) error {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		return fmt.Errorf("模板不能为空")
+	}
+	if template == orderCascadeTemplateReset {
+		return nil
+	}
+	if utf8.RuneCountInString(template) > orderCascadeTemplateMaxLen {
+		return fmt.Errorf("模板不能超过 %d 字", orderCascadeTemplateMaxLen)
+	}
+
+	var openTags []string
+	for i := 0; i < len(template); {
+		switch template[i] {
+		case '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("占位符缺少右括号 }")
+			}
+			name := template[i+1 : i+end]
+			if !isOrderCascadePlaceholder(name) {
+				return fmt.Errorf("未知占位符 {%s}，可用：{order} {status} {interface} {channel}", html.EscapeString(name))
+			}
+			i += end + 1
+		case '}':
+			return fmt.Errorf("多余的右括号 }")
+		case '<':
+			match := orderCascadeTemplateTagPattern.FindStringSubmatch(template[i:])
+			if match == nil {
+				return fmt.Errorf("仅支持 b/i/u/s/code/pre 标签，如需显示小于号请写作 &amp;lt;")
+			}
+			tag := match[2]
+			if match[1] == "" {
+				openTags = append(openTags, tag)
+			} else {
+				if len(openTags) == 0 || openTags[len(openTags)-1] != tag {
+					return fmt.Errorf("HTML 标签 %s 的结束标签未正确配对", tag)
+				}
+				openTags = openTags[:len(openTags)-1]
+			}
+			i += len(match[0])
+		case '>':
+			return fmt.Errorf("多余的大于号，如需显示请写作 &amp;gt;")
+		case '&':
+			entity := orderCascadeTemplateEntityPattern.FindString(template[i:])
+			if entity == "" {
+				return fmt.Errorf("&amp; 需写作 &amp;amp;")
+			}
+			i += len(entity)
+		default:
+			i++
+		}
+	}
+	if len(openTags) > 0 {
+		return fmt.Errorf("HTML 标签 %s 未闭合", openTags[len(openTags)-1])
+	}
+	return nil
+}
+
+// normalizeOrderCascadeTemplate 返回需要保存的模板，输入“默认”时返回空字符串表示使用默认格式
+func normalizeOrderCascadeTemplate(template string) string {
+	template = strings.TrimSpace(template)
+	if template == orderCascadeTemplateReset {
+		return ""
+	}
+	return template
+}
+
+func isOrderCascadePlaceholder(name string) bool {
+	for _, placeholder := range orderCascadeTemplatePlaceholders {
+		if name == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// renderOrderCascadeTemplate 替换模板占位符，替换值按 HTML 转义
+func renderOrderCascadeTemplate(template string, payload orderCascadeMessagePayload) string {
+	replacer := strings.NewReplacer(
+		"{order}", html.EscapeString(payload.orderNo()),
+		"{status}", html.EscapeString(strings.TrimSpace(payload.StatusText)),
+		"{interface}", html.EscapeString(strings.TrimSpace(payload.InterfaceName)),
+		"{channel}", html.EscapeString(strings.TrimSpace(payload.ChannelName)),
+	)
+	return replacer.Replace(template)
+}
+
+// cascadeChannelLabel 返回通道展示名称，缺少名称时使用通道代码
+func cascadeChannelLabel(name, code string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return strings.TrimSpace(code)
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+)
+
+func TestBuildOrderCascadeMessageCustomTemplate(t *testing.T) {
+	models.SetOrderLinkHosts([]string{"pay.example.com"})
+	t.Cleanup(func() { models.SetOrderLinkHosts(nil) })
+
+	template := "🔔 <b>{order}</b> {status}\n接口：{interface} / {channel}"
+	if err := validateOrderCascadeTemplate(template); err != nil {
+		t.Fatalf("expected template to be valid, got %v", err)
+	}
+
+	msg := buildOrderCascadeMessage(orderCascadeMessagePayload{
+		MerchantOrderNoFull: "FULL-<1>",
+		OrderNo:             "ORD-1",
+		StatusText:          "未支付",
+		InterfaceName:       "支付宝 & 微信",
+		ChannelName:         "USDT",
+		PaymentURL:          "https://pay.example.com/cashier/ORD-1",
+		Template:            template,
+	})
+
+	want := "🔔 <b>FULL-&lt;1&gt;</b> 未支付\n接口：支付宝 &amp; 微信 / USDT\n" +
+		`支付链接：<a href="https://pay.example.com/cashier/ORD-1">打开支付页</a>`
+	if msg != want {
+		t.Fatalf("unexpected message:\n%s\nwant:\n%s", msg, want)
+	}
+}
+
+func TestValidateOrderCascadeTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		wantErr  string
+	}{
+		{template: "订单 {order} &lt;新&gt; <code>{channel}</code>"},
+		{template: orderCascadeTemplateReset},
+		{template: "  ", wantErr: "不能为空"},
+		{template: "订单 {orderNo}", wantErr: "未知占位符"},
+		{template: "订单 {order", wantErr: "缺少右括号"},
+		{template: "订单 order}", wantErr: "多余的右括号"},
+		{template: `<a href="x">{order}</a>`, wantErr: "仅支持"},
+		{template: "<b>{order}", wantErr: "未闭合"},
+		{template: "<b>{order}</i>", wantErr: "未正确配对"},
+		{template: "A & B", wantErr: "需写作"},
+		{template: strings.Repeat("字", orderCascadeTemplateMaxLen+1), wantErr: "不能超过"},
+	} {
+		err := validateOrderCascadeTemplate(tc.template)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("template %q: expected valid, got %v", tc.template, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("template %q: expected error containing %q, got %v", tc.template, tc.wantErr, err)
+		}
+	}
+
+	if got := normalizeOrderCascadeTemplate(" 默认 "); got != "" {
+		t.Fatalf("expected reset keyword to clear template, got %q", got)
+	}
+}
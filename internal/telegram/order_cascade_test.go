@@ -112,6 +112,28 @@ func TestBuildOrderCascadeMessageIncludesFields(t *testing.T) {
 	}
 }
 
+func TestBuildOrderCascadeMessageOrderLinks(t *testing.T) {
+	models.SetOrderLinkHosts([]string{"pay.example.com"})
+	t.Cleanup(func() { models.SetOrderLinkHosts(nil) })
+
+	msg := buildOrderCascadeMessage(orderCascadeMessagePayload{
+		OrderNo:    "ORD-1",
+		ManageURL:  "https://evil.example.org/ORD-1",
+		PaymentURL: "https://pay.example.com/cashier/ORD-1",
+	})
+	if !strings.Contains(msg, `支付链接：<a href="https://pay.example.com/cashier/ORD-1">打开支付页</a>`) {
+		t.Fatalf("expected allowlisted payment URL to be linked, got %s", msg)
+	}
+	if !strings.Contains(msg, "订单后台：<code>https://evil.example.org/ORD-1</code>") {
+		t.Fatalf("expected non-allowlisted manage URL as plain text, got %s", msg)
+	}
+
+	plain := buildOrderCascadeMessage(orderCascadeMessagePayload{OrderNo: "ORD-1"})
+	if strings.Contains(plain, "链接") || strings.Contains(plain, "订单后台") {
+		t.Fatalf("expected no link lines without URLs, got %s", plain)
+	}
+}
+
 func TestBuildOrderCascadeFeedbackMessage(t *testing.T) {
 	user := &botModels.User{Username: "tester"}
 	when := time.Date(2024, 11, 20, 10, 30, 0, 0, time.UTC)
@@ -303,3 +325,32 @@ func TestResolveCascadeMerchantReplyMode(t *testing.T) {
 		}
 	})
 }
+
+func TestOrderCascadeStateExpiresWithInjectedClock(t *testing.T) {
+	now := time.Date(2024, 10, 26, 12, 0, 0, 0, time.UTC)
+	b := &Bot{nowFunc: func() time.Time { return now }}
+
+	b.saveOrderCascadeState(&orderCascadeState{
+		Token:             "clock-token",
+		UpstreamChatID:    -1001,
+		UpstreamMessageID: 7,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(orderCascadeStateTTL),
+	})
+
+	now = now.Add(orderCascadeStateTTL - time.Second)
+	if _, ok := b.getOrderCascadeState("clock-token"); !ok {
+		t.Fatal("expected state to be alive just before TTL")
+	}
+	if _, ok := b.findOrderCascadeStateByUpstreamMessage(-1001, 7); !ok {
+		t.Fatal("expected upstream lookup to find state before TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := b.getOrderCascadeState("clock-token"); ok {
+		t.Fatal("expected state to expire after TTL")
+	}
+	if len(b.orderCascadeStates) != 0 {
+		t.Fatalf("expected expired state removed, got %d", len(b.orderCascadeStates))
+	}
+}
@@ -103,6 +103,22 @@ func (r *MongoAccountingRepository) GetRecentRecords(ctx context.Context, chatID
 	return records, nil
 }
 
+// GetLastRecord 获取群组最新一条记录，没有记录时返回 nil
+func (r *MongoAccountingRepository) GetLastRecord(ctx context.Context, chatID int64) (*models.AccountingRecord, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "recorded_at", Value: -1}})
+
+	var record models.AccountingRecord
+	err := r.collection.FindOne(ctx, bson.M{"chat_id": chatID}, opts).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query last accounting record: %w", err)
+	}
+
+	return &record, nil
+}
+
 // DeleteRecord 删除单条记录
 func (r *MongoAccountingRepository) DeleteRecord(ctx context.Context, recordID string) error {
 	objID, err := primitive.ObjectIDFromHex(recordID)
@@ -134,6 +150,20 @@ func (r *MongoAccountingRepository) DeleteAllByChatID(ctx context.Context, chatI
 	return result.DeletedCount, nil
 }
 
+// DeleteBefore 删除群组 before 之前记录的账目
+func (r *MongoAccountingRepository) DeleteBefore(ctx context.Context, chatID int64, before time.Time) (int64, error) {
+	filter := bson.M{
+		"chat_id":     chatID,
+		"recorded_at": bson.M{"$lt": before},
+	}
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete accounting records before %s: %w", before.Format(time.RFC3339), err)
+	}
+
+	return result.DeletedCount, nil
+}
+
 // EnsureIndexes 确保索引存在
 func (r *MongoAccountingRepository) EnsureIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
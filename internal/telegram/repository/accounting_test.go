@@ -258,6 +258,48 @@ func TestMongoAccountingRepositoryGetRecentRecords(t *testing.T) {
 	})
 }
 
+func TestMongoAccountingRepositoryGetLastRecord(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("success", func(mt *mtest.T) {
+		repo := &MongoAccountingRepository{collection: mt.Coll}
+		now := time.Now().UTC().Truncate(time.Second)
+		mt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			accountingNamespace(mt),
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "chat_id", Value: int64(-3101)},
+				{Key: "user_id", Value: int64(4101)},
+				{Key: "amount", Value: 100.0},
+				{Key: "currency", Value: models.CurrencyUSD},
+				{Key: "original_expr", Value: "100"},
+				{Key: "recorded_at", Value: now},
+				{Key: "created_at", Value: now},
+			},
+		))
+
+		record, err := repo.GetLastRecord(context.Background(), -3101)
+		if err != nil {
+			t.Fatalf("GetLastRecord failed: %v", err)
+		}
+		if record == nil || record.UserID != 4101 || record.OriginalExpr != "100" {
+			t.Fatalf("unexpected record: %+v", record)
+		}
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		repo := &MongoAccountingRepository{collection: mt.Coll}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, accountingNamespace(mt), mtest.FirstBatch))
+
+		record, err := repo.GetLastRecord(context.Background(), -3102)
+		if err != nil || record != nil {
+			t.Fatalf("expected nil record without error, got record=%+v err=%v", record, err)
+		}
+	})
+}
+
 func TestMongoAccountingRepositoryDeleteRecord(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
@@ -389,3 +431,31 @@ func TestMongoAccountingRepositoryEnsureIndexes(t *testing.T) {
 func accountingNamespace(mt *mtest.T) string {
 	return mt.DB.Name() + "." + mt.Coll.Name()
 }
+
+func TestMongoAccountingRepositoryDeleteBefore(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("success", func(mt *mtest.T) {
+		repo := &MongoAccountingRepository{collection: mt.Coll}
+		before := time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 2},
+		))
+
+		deleted, err := repo.DeleteBefore(context.Background(), -4001, before)
+		if err != nil {
+			t.Fatalf("DeleteBefore failed: %v", err)
+		}
+		if deleted != 2 {
+			t.Fatalf("unexpected deleted count: got %d, want %d", deleted, 2)
+		}
+
+		filter := mt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		if got := filter.Lookup("chat_id").AsInt64(); got != -4001 {
+			t.Fatalf("unexpected chat filter: got %d", got)
+		}
+		if got := filter.Lookup("recorded_at", "$lt").Time().UTC(); !got.Equal(before) {
+			t.Fatalf("unexpected cutoff: got %s, want %s", got, before)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IsTimeout 判断错误是否由数据库超时（context 截止或驱动超时）导致，而非查询本身出错
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsTimeout(t *testing.T) {
+	if !IsTimeout(fmt.Errorf("find: %w", context.DeadlineExceeded)) {
+		t.Fatal("expected wrapped deadline exceeded to be a timeout")
+	}
+	if IsTimeout(errors.New("duplicate key")) {
+		t.Fatal("expected plain error not to be a timeout")
+	}
+	if IsTimeout(nil) {
+		t.Fatal("expected nil not to be a timeout")
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/telegram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFeatureUsageRepository 功能使用计数数据访问层（MongoDB 实现）
+type MongoFeatureUsageRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoFeatureUsageRepository 创建功能使用计数 Repository
+func NewMongoFeatureUsageRepository(db *mongo.Database) FeatureUsageRepository {
+	return &MongoFeatureUsageRepository{
+		collection: db.Collection("feature_usage"),
+	}
+}
+
+// Increment 累加指定群组某功能的使用次数
+func (r *MongoFeatureUsageRepository) Increment(ctx context.Context, groupID int64, feature string) error {
+	feature = strings.TrimSpace(feature)
+	if feature == "" {
+		return fmt.Errorf("feature is required")
+	}
+
+	now := time.Now()
+	filter := bson.M{"group_id": groupID, "feature": feature}
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$set":         bson.M{"last_used_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to increment feature usage: %w", err)
+	}
+	return nil
+}
+
+// ListTop 按使用次数倒序列出功能；groupID 为 0 时汇总所有群组
+func (r *MongoFeatureUsageRepository) ListTop(ctx context.Context, groupID int64, limit int64) ([]*models.FeatureUsage, error) {
+	if groupID != 0 {
+		opts := options.Find().SetSort(bson.D{{Key: "count", Value: -1}})
+		if limit > 0 {
+			opts.SetLimit(limit)
+		}
+
+		cursor, err := r.collection.Find(ctx, bson.M{"group_id": groupID}, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list feature usage: %w", err)
+		}
+		defer cursor.Close(ctx)
+
+		var usages []*models.FeatureUsage
+		if err := cursor.All(ctx, &usages); err != nil {
+			return nil, fmt.Errorf("failed to decode feature usage: %w", err)
+		}
+		return usages, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$feature",
+			"count":        bson.M{"$sum": "$count"},
+			"last_used_at": bson.M{"$max": "$last_used_at"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate feature usage: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Feature    string    `bson:"_id"`
+		Count      int64     `bson:"count"`
+		LastUsedAt time.Time `bson:"last_used_at"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode feature usage: %w", err)
+	}
+
+	usages := make([]*models.FeatureUsage, 0, len(rows))
+	for _, row := range rows {
+		usages = append(usages, &models.FeatureUsage{
+			Feature:    row.Feature,
+			Count:      row.Count,
+			LastUsedAt: row.LastUsedAt,
+		})
+	}
+	return usages, nil
+}
+
+// EnsureIndexes 确保索引存在
+func (r *MongoFeatureUsageRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "group_id", Value: 1},
+				{Key: "feature", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "count", Value: -1}},
+		},
+	}
+
+	if _, err := r.collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create feature usage indexes: %w", err)
+	}
+	return nil
+}
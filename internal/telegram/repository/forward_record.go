@@ -82,6 +82,15 @@ func (r *forwardRecordRepository) DeleteRecordsByTaskID(ctx context.Context, tas
 	return nil
 }
 
+// DeleteRecordsByTargetGroup 删除目标群组的全部转发记录（Bot 被移出群组后无法撤回），返回删除数量
+func (r *forwardRecordRepository) DeleteRecordsByTargetGroup(ctx context.Context, groupID int64) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"target_group_id": groupID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete forward records by target group: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
 // EnsureIndexes 确保索引存在
 func (r *forwardRecordRepository) EnsureIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
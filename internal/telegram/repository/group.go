@@ -16,6 +16,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrGroupNotFound 群组记录不存在
+var ErrGroupNotFound = errors.New("group not found")
+
 // MongoGroupRepository 群组数据访问层（MongoDB 实现）
 type MongoGroupRepository struct {
 	collection *mongo.Collection
@@ -98,13 +101,53 @@ func (r *MongoGroupRepository) CreateOrUpdate(ctx context.Context, group *models
 	return nil
 }
 
+// GetOrCreate 原子地获取或创建群组：仅通过 $setOnInsert 写入，已存在的群组（含配置）保持不变。
+// 并发 upsert 触发唯一索引冲突时，改为读取先插入的那份文档
+func (r *MongoGroupRepository) GetOrCreate(ctx context.Context, group *models.Group) (*models.Group, error) {
+	now := time.Now()
+	tier := group.Tier
+	if tier == "" {
+		tier = models.GroupTierBasic
+	}
+
+	filter := bson.M{"telegram_id": group.TelegramID}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"telegram_id":   group.TelegramID,
+			"type":          group.Type,
+			"title":         group.Title,
+			"username":      group.Username,
+			"description":   group.Description,
+			"member_count":  group.MemberCount,
+			"bot_status":    group.BotStatus,
+			"tier":          tier,
+			"settings":      group.Settings,
+			"stats":         models.GroupStats{LastMessageAt: now},
+			"bot_joined_at": now,
+			"created_at":    now,
+			"updated_at":    now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.Group
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.collection.FindOne(ctx, filter).Decode(&stored)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create group: %w", err)
+	}
+	return &stored, nil
+}
+
 // GetByTelegramID 根据 Telegram ID 获取群组
 func (r *MongoGroupRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.Group, error) {
 	var group models.Group
 	err := r.collection.FindOne(ctx, bson.M{"telegram_id": telegramID}).Decode(&group)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("group not found: %d", telegramID)
+			return nil, fmt.Errorf("%w: %d", ErrGroupNotFound, telegramID)
 		}
 		return nil, fmt.Errorf("failed to get group: %w", err)
 	}
@@ -157,7 +200,7 @@ func (r *MongoGroupRepository) UpdateBotStatus(ctx context.Context, telegramID i
 		return fmt.Errorf("failed to update bot status: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("group not found: %d", telegramID)
+		return fmt.Errorf("%w: %d", ErrGroupNotFound, telegramID)
 	}
 	return nil
 }
@@ -171,7 +214,7 @@ func (r *MongoGroupRepository) DeleteGroup(ctx context.Context, telegramID int64
 		return fmt.Errorf("failed to delete group: %w", err)
 	}
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("group not found: %d", telegramID)
+		return fmt.Errorf("%w: %d", ErrGroupNotFound, telegramID)
 	}
 
 	return nil
@@ -226,7 +269,7 @@ func (r *MongoGroupRepository) UpdateSettings(ctx context.Context, telegramID in
 		return fmt.Errorf("failed to update settings: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("group not found: %d", telegramID)
+		return fmt.Errorf("%w: %d", ErrGroupNotFound, telegramID)
 	}
 	return nil
 }
@@ -246,7 +289,7 @@ func (r *MongoGroupRepository) UpdateStats(ctx context.Context, telegramID int64
 		return fmt.Errorf("failed to update stats: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("group not found: %d", telegramID)
+		return fmt.Errorf("%w: %d", ErrGroupNotFound, telegramID)
 	}
 	return nil
 }
@@ -61,6 +61,71 @@ func TestMongoGroupRepositoryCreateOrUpdate(t *testing.T) {
 	})
 }
 
+func TestMongoGroupRepositoryGetOrCreate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("inserts defaults", func(mt *mtest.T) {
+		repo := &MongoGroupRepository{collection: mt.Coll}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{
+			Key: "value",
+			Value: bson.D{
+				{Key: "telegram_id", Value: int64(-3001)},
+				{Key: "title", Value: "New Group"},
+				{Key: "tier", Value: string(models.GroupTierBasic)},
+				{Key: "settings", Value: bson.D{{Key: "calculator_enabled", Value: true}}},
+			},
+		}))
+
+		group, err := repo.GetOrCreate(context.Background(), &models.Group{
+			TelegramID: -3001,
+			Title:      "New Group",
+			Settings:   models.GroupSettings{CalculatorEnabled: true},
+		})
+		if err != nil {
+			t.Fatalf("GetOrCreate failed: %v", err)
+		}
+		if group.TelegramID != -3001 || !group.Settings.CalculatorEnabled {
+			t.Fatalf("unexpected group: %+v", group)
+		}
+
+		started := mt.GetStartedEvent()
+		update := started.Command.Lookup("update").Document()
+		if _, err := update.LookupErr("$set"); err == nil {
+			t.Fatalf("expected only $setOnInsert, got %s", update)
+		}
+	})
+
+	mt.Run("concurrent insert falls back to existing document", func(mt *mtest.T) {
+		repo := &MongoGroupRepository{collection: mt.Coll}
+		mt.AddMockResponses(
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{
+				Code:    11000,
+				Message: "E11000 duplicate key error collection: groups index: telegram_id_1",
+			}),
+			mtest.CreateCursorResponse(0, groupNamespace(mt), mtest.FirstBatch, bson.D{
+				{Key: "telegram_id", Value: int64(-3002)},
+				{Key: "title", Value: "Existing"},
+				{Key: "settings", Value: bson.D{
+					{Key: "merchant_id", Value: int32(88)},
+					{Key: "calculator_enabled", Value: false},
+				}},
+			}),
+		)
+
+		group, err := repo.GetOrCreate(context.Background(), &models.Group{
+			TelegramID: -3002,
+			Title:      "Racing",
+			Settings:   models.GroupSettings{CalculatorEnabled: true},
+		})
+		if err != nil {
+			t.Fatalf("GetOrCreate failed: %v", err)
+		}
+		if group.Title != "Existing" || group.Settings.MerchantID != 88 || group.Settings.CalculatorEnabled {
+			t.Fatalf("expected existing settings to be preserved, got %+v", group)
+		}
+	})
+}
+
 func TestMongoGroupRepositoryGetByTelegramID(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
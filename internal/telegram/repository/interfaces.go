@@ -30,6 +30,9 @@ type UserRepository interface {
 	// GetUserInfo 获取用户完整信息
 	GetUserInfo(ctx context.Context, telegramID int64) (*models.User, error)
 
+	// SetBlocked 设置或清除用户屏蔽 Bot 的标记
+	SetBlocked(ctx context.Context, telegramID int64, blocked bool) error
+
 	// EnsureIndexes 确保索引存在
 	EnsureIndexes(ctx context.Context, ttlSeconds int32) error
 }
@@ -39,6 +42,9 @@ type GroupRepository interface {
 	// CreateOrUpdate 创建或更新群组
 	CreateOrUpdate(ctx context.Context, group *models.Group) error
 
+	// GetOrCreate 原子地获取或创建群组，已存在时不修改任何字段
+	GetOrCreate(ctx context.Context, group *models.Group) (*models.Group, error)
+
 	// GetByTelegramID 根据 Telegram ID 获取群组
 	GetByTelegramID(ctx context.Context, telegramID int64) (*models.Group, error)
 
@@ -81,6 +87,9 @@ type MessageRepository interface {
 	// ListMessagesByChat 列出聊天消息历史（分页）
 	ListMessagesByChat(ctx context.Context, chatID int64, limit, offset int64) ([]*models.Message, error)
 
+	// GetMessagesByDateRange 按发送时间升序逐条遍历 [start, end) 内的消息
+	GetMessagesByDateRange(ctx context.Context, chatID int64, start, end time.Time, fn func(*models.Message) error) error
+
 	// CountMessagesByType 按类型统计消息数量
 	CountMessagesByType(ctx context.Context, chatID int64) (map[string]int64, error)
 
@@ -102,6 +111,9 @@ type ForwardRecordRepository interface {
 	// DeleteRecordsByTaskID 删除转发记录（撤回后清理）
 	DeleteRecordsByTaskID(ctx context.Context, taskID string) error
 
+	// DeleteRecordsByTargetGroup 删除目标群组的全部转发记录（Bot 被移出群组后无法撤回），返回删除数量
+	DeleteRecordsByTargetGroup(ctx context.Context, groupID int64) (int64, error)
+
 	// EnsureIndexes 确保索引存在
 	EnsureIndexes(ctx context.Context) error
 }
@@ -117,12 +129,18 @@ type AccountingRepository interface {
 	// GetRecentRecords 获取最近N天的记录（用于删除界面）
 	GetRecentRecords(ctx context.Context, chatID int64, days int) ([]*models.AccountingRecord, error)
 
+	// GetLastRecord 获取群组最新一条记录（用于重复检测），没有记录时返回 nil
+	GetLastRecord(ctx context.Context, chatID int64) (*models.AccountingRecord, error)
+
 	// DeleteRecord 删除单条记录
 	DeleteRecord(ctx context.Context, recordID string) error
 
 	// DeleteAllByChatID 清空群组所有记录
 	DeleteAllByChatID(ctx context.Context, chatID int64) (int64, error)
 
+	// DeleteBefore 删除群组 before 之前记录的账目
+	DeleteBefore(ctx context.Context, chatID int64, before time.Time) (int64, error)
+
 	// EnsureIndexes 确保索引存在
 	EnsureIndexes(ctx context.Context) error
 }
@@ -139,14 +157,62 @@ type WithdrawQuoteRepository interface {
 	EnsureIndexes(ctx context.Context) error
 }
 
+// SendMoneyRecordRepository Bot 下发记录数据访问接口
+type SendMoneyRecordRepository interface {
+	// Create 创建下发记录（写入生成的 ID）
+	Create(ctx context.Context, record *models.SendMoneyRecord) error
+
+	// UpdateResult 更新下发结果
+	UpdateResult(ctx context.Context, record *models.SendMoneyRecord) error
+
+	// ListByDateRange 按创建时间范围查询全部商户的下发记录
+	ListByDateRange(ctx context.Context, startTime, endTime time.Time) ([]*models.SendMoneyRecord, error)
+
+	// EnsureIndexes 确保索引存在
+	EnsureIndexes(ctx context.Context) error
+}
+
+// FeatureUsageRepository 功能使用计数数据访问接口
+type FeatureUsageRepository interface {
+	// Increment 累加群组功能使用次数
+	Increment(ctx context.Context, groupID int64, feature string) error
+
+	// ListTop 按使用次数倒序列出（groupID 为 0 时汇总全部群组）
+	ListTop(ctx context.Context, groupID int64, limit int64) ([]*models.FeatureUsage, error)
+
+	// EnsureIndexes 确保索引存在
+	EnsureIndexes(ctx context.Context) error
+}
+
+// SettingRepository 全局配置数据访问接口
+type SettingRepository interface {
+	// Get 按键读取配置，不存在时返回 nil, nil
+	Get(ctx context.Context, key string) (*models.Setting, error)
+
+	// Set 写入配置（存在则覆盖）
+	Set(ctx context.Context, key, value string, updatedBy int64) error
+
+	// EnsureIndexes 确保索引存在
+	EnsureIndexes(ctx context.Context) error
+}
+
 // UpstreamBalanceRepository 上游群余额数据访问接口
 type UpstreamBalanceRepository interface {
 	// Get 获取或创建余额记录
 	Get(ctx context.Context, groupID int64) (*models.UpstreamBalance, error)
 
+	// Find 只读获取余额记录，不存在时返回 nil 且不创建记录
+	Find(ctx context.Context, groupID int64) (*models.UpstreamBalance, error)
+
+	// FindLogByOperation 按操作 ID 查找已写入的余额日志，不存在时返回 nil
+	FindLogByOperation(ctx context.Context, groupID int64, operationID string) (*models.UpstreamBalanceLog, error)
+
 	// Adjust 调整余额（正为加款，负为扣款），同时写入日志
 	Adjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string, metadata map[string]string) (*models.UpstreamBalance, error)
 
+	// AdjustNonNegative 同 Adjust，但扣款后余额将低于 0 时以条件更新拒绝并返回 ErrInsufficientBalance
+	AdjustNonNegative(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string, metadata map[string]string) (*models.UpstreamBalance, error)
+
 	// SetMinBalance 设置最低余额阈值并记录日志
 	SetMinBalance(ctx context.Context, groupID int64, threshold float64, operatorID int64) (*models.UpstreamBalance, error)
 
@@ -156,6 +222,15 @@ type UpstreamBalanceRepository interface {
 	// ListAll 列出所有余额记录
 	ListAll(ctx context.Context) ([]*models.UpstreamBalance, error)
 
+	// ListLogs 按时间倒序分页列出余额变动日志（startTime/endTime 为零值时不限制）
+	ListLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, skip, limit int64) ([]*models.UpstreamBalanceLog, error)
+
+	// StreamLogs 按时间升序遍历 [startTime, endTime) 内的余额变动日志，逐条回调 fn，避免一次性加载全部结果
+	StreamLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, fn func(*models.UpstreamBalanceLog) error) error
+
+	// PruneLogs 删除 before 之前的余额日志，始终保留最近 keepLatest 条及确定当前余额的最后一条余额变动日志，返回删除条数
+	PruneLogs(ctx context.Context, groupID int64, before time.Time, keepLatest int) (int64, error)
+
 	// EnsureIndexes 确保索引存在
 	EnsureIndexes(ctx context.Context) error
 }
@@ -143,6 +143,40 @@ func (r *MongoMessageRepository) ListMessagesByChat(ctx context.Context, chatID
 	return messages, nil
 }
 
+// GetMessagesByDateRange 按发送时间升序遍历聊天在 [start, end) 内的消息，逐条回调 fn，避免一次性加载全部结果
+func (r *MongoMessageRepository) GetMessagesByDateRange(ctx context.Context, chatID int64, start, end time.Time, fn func(*models.Message) error) error {
+	filter := bson.M{
+		"chat_id": chatID,
+		"sent_at": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "sent_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find messages by date range: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var message models.Message
+		if err := cursor.Decode(&message); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		if err := fn(&message); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	return nil
+}
+
 // CountMessagesByType 按类型统计消息数量
 func (r *MongoMessageRepository) CountMessagesByType(ctx context.Context, chatID int64) (map[string]int64, error) {
 	pipeline := []bson.M{
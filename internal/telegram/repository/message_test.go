@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -266,6 +267,81 @@ func TestMongoMessageRepositoryListMessagesByChat(t *testing.T) {
 	})
 }
 
+func TestMongoMessageRepositoryGetMessagesByDateRange(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("iterates in range", func(mt *mtest.T) {
+		repo := &MongoMessageRepository{collection: mt.Coll}
+		start := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		end := start.AddDate(0, 0, 2)
+		mt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			messageNamespace(mt),
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "telegram_message_id", Value: int64(1)},
+				{Key: "chat_id", Value: int64(-888)},
+				{Key: "message_type", Value: models.MessageTypeText},
+				{Key: "text", Value: "first"},
+				{Key: "sent_at", Value: start.Add(time.Hour)},
+			},
+			bson.D{
+				{Key: "telegram_message_id", Value: int64(2)},
+				{Key: "chat_id", Value: int64(-888)},
+				{Key: "message_type", Value: models.MessageTypeText},
+				{Key: "text", Value: "second"},
+				{Key: "sent_at", Value: start.Add(25 * time.Hour)},
+			},
+		))
+
+		var texts []string
+		err := repo.GetMessagesByDateRange(context.Background(), -888, start, end, func(message *models.Message) error {
+			texts = append(texts, message.Text)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("GetMessagesByDateRange failed: %v", err)
+		}
+		if len(texts) != 2 || texts[0] != "first" || texts[1] != "second" {
+			t.Fatalf("unexpected messages: %v", texts)
+		}
+
+		started := mt.GetStartedEvent()
+		filter := started.Command.Lookup("filter").Document()
+		if chatID := filter.Lookup("chat_id").Int64(); chatID != -888 {
+			t.Fatalf("unexpected chat filter: %d", chatID)
+		}
+		sentAt := filter.Lookup("sent_at").Document()
+		if got := sentAt.Lookup("$gte").Time().UTC(); !got.Equal(start) {
+			t.Fatalf("unexpected range start: %v", got)
+		}
+		if got := sentAt.Lookup("$lt").Time().UTC(); !got.Equal(end) {
+			t.Fatalf("unexpected range end: %v", got)
+		}
+	})
+
+	mt.Run("callback error stops iteration", func(mt *mtest.T) {
+		repo := &MongoMessageRepository{collection: mt.Coll}
+		mt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			messageNamespace(mt),
+			mtest.FirstBatch,
+			bson.D{{Key: "telegram_message_id", Value: int64(1)}},
+			bson.D{{Key: "telegram_message_id", Value: int64(2)}},
+		))
+
+		calls := 0
+		stop := errors.New("stop")
+		err := repo.GetMessagesByDateRange(context.Background(), -888, time.Now().Add(-time.Hour), time.Now(), func(message *models.Message) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Fatalf("expected iteration to stop after first callback error, calls=%d err=%v", calls, err)
+		}
+	})
+}
+
 func TestMongoMessageRepositoryCountMessagesByType(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
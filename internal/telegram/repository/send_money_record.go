@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/telegram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSendMoneyRecordRepository Bot 下发记录数据访问层（MongoDB 实现）
+type MongoSendMoneyRecordRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSendMoneyRecordRepository 创建下发记录 Repository
+func NewMongoSendMoneyRecordRepository(db *mongo.Database) SendMoneyRecordRepository {
+	return &MongoSendMoneyRecordRepository{
+		collection: db.Collection("send_money_records"),
+	}
+}
+
+// Create 创建下发记录
+func (r *MongoSendMoneyRecordRepository) Create(ctx context.Context, record *models.SendMoneyRecord) error {
+	if record == nil {
+		return fmt.Errorf("record is nil")
+	}
+	if record.MerchantID == 0 {
+		return fmt.Errorf("merchant id is required")
+	}
+
+	now := time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	record.UpdatedAt = now
+	if record.Status == "" {
+		record.Status = models.SendMoneyStatusPending
+	}
+
+	result, err := r.collection.InsertOne(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to insert send money record: %w", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		record.ID = id
+	}
+	return nil
+}
+
+// UpdateResult 更新下发结果（状态、提现单号、错误信息）
+func (r *MongoSendMoneyRecordRepository) UpdateResult(ctx context.Context, record *models.SendMoneyRecord) error {
+	if record == nil || record.ID.IsZero() {
+		return fmt.Errorf("record id is required")
+	}
+
+	record.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":      record.Status,
+			"amount":      record.Amount,
+			"withdraw_no": strings.TrimSpace(record.WithdrawNo),
+			"order_no":    strings.TrimSpace(record.OrderNo),
+			"error":       record.Error,
+			"updated_at":  record.UpdatedAt,
+		},
+	}
+
+	if _, err := r.collection.UpdateByID(ctx, record.ID, update); err != nil {
+		return fmt.Errorf("failed to update send money record: %w", err)
+	}
+	return nil
+}
+
+// ListByDateRange 按创建时间范围查询下发记录
+func (r *MongoSendMoneyRecordRepository) ListByDateRange(ctx context.Context, startTime, endTime time.Time) ([]*models.SendMoneyRecord, error) {
+	filter := bson.M{
+		"created_at": bson.M{
+			"$gte": startTime,
+			"$lt":  endTime,
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query send money records: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.SendMoneyRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode send money records: %w", err)
+	}
+	return records, nil
+}
+
+// EnsureIndexes 确保索引存在
+func (r *MongoSendMoneyRecordRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{
+				{Key: "merchant_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		},
+	}
+
+	if _, err := r.collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create send money record indexes: %w", err)
+	}
+	return nil
+}
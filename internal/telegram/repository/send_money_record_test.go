@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoSendMoneyRecordRepositoryCreate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("create defaults to pending", func(mt *mtest.T) {
+		repo := &MongoSendMoneyRecordRepository{collection: mt.Coll}
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		record := &models.SendMoneyRecord{MerchantID: 2023001, Amount: 100}
+		if err := repo.Create(context.Background(), record); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if record.Status != models.SendMoneyStatusPending {
+			t.Fatalf("expected pending status, got %q", record.Status)
+		}
+		if record.ID.IsZero() || record.CreatedAt.IsZero() {
+			t.Fatalf("expected id and created_at to be populated: %+v", record)
+		}
+	})
+
+	mt.Run("merchant id required", func(mt *mtest.T) {
+		repo := &MongoSendMoneyRecordRepository{collection: mt.Coll}
+		if err := repo.Create(context.Background(), &models.SendMoneyRecord{Amount: 100}); err == nil {
+			t.Fatalf("expected error for missing merchant id")
+		}
+	})
+
+	mt.Run("update requires id", func(mt *mtest.T) {
+		repo := &MongoSendMoneyRecordRepository{collection: mt.Coll}
+		if err := repo.UpdateResult(context.Background(), &models.SendMoneyRecord{MerchantID: 1}); err == nil {
+			t.Fatalf("expected error for missing record id")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/telegram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSettingRepository 全局配置数据访问层（MongoDB 实现）
+type MongoSettingRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSettingRepository 创建全局配置 Repository
+func NewMongoSettingRepository(db *mongo.Database) SettingRepository {
+	return &MongoSettingRepository{
+		collection: db.Collection("settings"),
+	}
+}
+
+// Get 按键读取配置，不存在时返回 nil, nil
+func (r *MongoSettingRepository) Get(ctx context.Context, key string) (*models.Setting, error) {
+	var setting models.Setting
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&setting)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get setting %s: %w", key, err)
+	}
+	return &setting, nil
+}
+
+// Set 写入配置（存在则覆盖）
+func (r *MongoSettingRepository) Set(ctx context.Context, key, value string, updatedBy int64) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("setting key is required")
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"value":      value,
+			"updated_by": updatedBy,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"key": key}, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to set setting %s: %w", key, err)
+	}
+	return nil
+}
+
+// EnsureIndexes 确保索引存在
+func (r *MongoSettingRepository) EnsureIndexes(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := r.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create setting indexes: %w", err)
+	}
+	return nil
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go_bot/internal/telegram/models"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
@@ -17,17 +20,34 @@ import (
 
 const defaultBalanceAlertLimit = 3
 
+// DefaultWriteConflictRetries 事务遇到写冲突时的默认最大重试次数
+const DefaultWriteConflictRetries = 3
+
+// writeConflictCode MongoDB WriteConflict 错误码
+const writeConflictCode = 112
+
+// ErrInsufficientBalance 禁止负余额时扣款后余额将低于 0，未写入任何变更
+var ErrInsufficientBalance = errors.New("insufficient upstream balance")
+
 // MongoUpstreamBalanceRepository 上游群余额数据访问层（MongoDB 实现）
 type MongoUpstreamBalanceRepository struct {
 	balanceColl *mongo.Collection
 	logColl     *mongo.Collection
+	// txnUnsupported 检测到部署不支持事务（单机 MongoDB）后置位，之后直接走非事务路径
+	txnUnsupported atomic.Bool
+	// writeConflictRetries 事务遇到写冲突 / 瞬时事务错误时的最大重试次数
+	writeConflictRetries int
 }
 
-// NewMongoUpstreamBalanceRepository 创建仓储实例
-func NewMongoUpstreamBalanceRepository(db *mongo.Database) UpstreamBalanceRepository {
+// NewMongoUpstreamBalanceRepository 创建仓储实例，writeConflictRetries 为写冲突最大重试次数（0 表示不重试，负数使用默认值）
+func NewMongoUpstreamBalanceRepository(db *mongo.Database, writeConflictRetries int) UpstreamBalanceRepository {
+	if writeConflictRetries < 0 {
+		writeConflictRetries = DefaultWriteConflictRetries
+	}
 	return &MongoUpstreamBalanceRepository{
-		balanceColl: db.Collection("upstream_balances"),
-		logColl:     db.Collection("upstream_balance_logs"),
+		balanceColl:          db.Collection("upstream_balances"),
+		logColl:              db.Collection("upstream_balance_logs"),
+		writeConflictRetries: writeConflictRetries,
 	}
 }
 
@@ -58,6 +78,27 @@ func (r *MongoUpstreamBalanceRepository) Get(ctx context.Context, groupID int64)
 	return &balance, nil
 }
 
+// Find 只读获取余额记录，不存在时返回 nil，不会创建记录或更新 updated_at
+func (r *MongoUpstreamBalanceRepository) Find(ctx context.Context, groupID int64) (*models.UpstreamBalance, error) {
+	var balance models.UpstreamBalance
+	if err := r.balanceColl.FindOne(ctx, balanceFilter(groupID)).Decode(&balance); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find upstream balance: %w", err)
+	}
+	return &balance, nil
+}
+
+// FindLogByOperation 按操作 ID 查找已写入的余额日志，不存在时返回 nil
+func (r *MongoUpstreamBalanceRepository) FindLogByOperation(ctx context.Context, groupID int64, operationID string) (*models.UpstreamBalanceLog, error) {
+	log, err := r.findLogByOperation(ctx, groupID, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find balance log: %w", err)
+	}
+	return log, nil
+}
+
 // Adjust 调整余额并写入日志（事务）
 func (r *MongoUpstreamBalanceRepository) Adjust(
 	ctx context.Context,
@@ -69,15 +110,39 @@ func (r *MongoUpstreamBalanceRepository) Adjust(
 	operationID string,
 	metadata map[string]string,
 ) (*models.UpstreamBalance, error) {
-	client := r.balanceColl.Database().Client()
-	session, err := client.StartSession()
-	if err != nil {
-		return nil, fmt.Errorf("start mongo session: %w", err)
+	return r.adjust(ctx, groupID, delta, false, operatorID, remark, opType, operationID, metadata)
+}
+
+// AdjustNonNegative 同 Adjust，但扣款后余额低于 0 时返回 ErrInsufficientBalance 且不写入
+func (r *MongoUpstreamBalanceRepository) AdjustNonNegative(
+	ctx context.Context,
+	groupID int64,
+	delta float64,
+	operatorID int64,
+	remark string,
+	opType models.BalanceOperationType,
+	operationID string,
+	metadata map[string]string,
+) (*models.UpstreamBalance, error) {
+	return r.adjust(ctx, groupID, delta, true, operatorID, remark, opType, operationID, metadata)
+}
+
+func (r *MongoUpstreamBalanceRepository) adjust(
+	ctx context.Context,
+	groupID int64,
+	delta float64,
+	nonNegative bool,
+	operatorID int64,
+	remark string,
+	opType models.BalanceOperationType,
+	operationID string,
+	metadata map[string]string,
+) (*models.UpstreamBalance, error) {
+	if r.txnUnsupported.Load() {
+		return r.adjustWithoutTransaction(ctx, groupID, delta, nonNegative, operatorID, remark, opType, operationID, metadata)
 	}
-	defer session.EndSession(ctx)
 
-	txnOpts := options.Transaction().SetWriteConcern(writeconcern.Majority())
-	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+	result, err := r.withWriteConflictRetry(ctx, func(sc mongo.SessionContext) (interface{}, error) {
 		if operationID != "" {
 			if existing, err := r.findLogByOperation(sc, groupID, operationID); err == nil && existing != nil {
 				return r.Get(sc, groupID)
@@ -85,26 +150,11 @@ func (r *MongoUpstreamBalanceRepository) Adjust(
 		}
 
 		now := time.Now()
-		filter := balanceFilter(groupID)
-		update := bson.M{
-			"$inc": bson.M{
-				"balance": delta,
-			},
-			"$set": bson.M{
-				"updated_at": now,
-			},
-			"$setOnInsert": bson.M{
-				"chat_id":              groupID,
-				"group_id":             groupID,
-				"min_balance":          0.0,
-				"alert_limit_per_hour": defaultBalanceAlertLimit,
-				"created_at":           now,
-			},
-		}
-
-		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
-		var balance models.UpstreamBalance
-		if err := r.balanceColl.FindOneAndUpdate(sc, filter, update, opts).Decode(&balance); err != nil {
+		balance, err := r.incrementBalance(sc, groupID, delta, nonNegative, now)
+		if err != nil {
+			if errors.Is(err, ErrInsufficientBalance) {
+				return nil, err
+			}
 			return nil, fmt.Errorf("update balance failed: %w", err)
 		}
 
@@ -124,12 +174,12 @@ func (r *MongoUpstreamBalanceRepository) Adjust(
 			return nil, fmt.Errorf("insert balance log failed: %w", err)
 		}
 
-		return &balance, nil
-	}, txnOpts)
+		return balance, nil
+	})
 
 	if err != nil {
-		if isTransactionNotSupported(err) {
-			return r.adjustWithoutTransaction(ctx, groupID, delta, operatorID, remark, opType, operationID, metadata)
+		if r.detectTransactionNotSupported(err) {
+			return r.adjustWithoutTransaction(ctx, groupID, delta, nonNegative, operatorID, remark, opType, operationID, metadata)
 		}
 		return nil, fmt.Errorf("balance adjust transaction failed: %w", err)
 	}
@@ -146,6 +196,7 @@ func (r *MongoUpstreamBalanceRepository) adjustWithoutTransaction(
 	ctx context.Context,
 	groupID int64,
 	delta float64,
+	nonNegative bool,
 	operatorID int64,
 	remark string,
 	opType models.BalanceOperationType,
@@ -159,7 +210,47 @@ func (r *MongoUpstreamBalanceRepository) adjustWithoutTransaction(
 	}
 
 	now := time.Now()
+	balance, err := r.incrementBalance(ctx, groupID, delta, nonNegative, now)
+	if err != nil {
+		if errors.Is(err, ErrInsufficientBalance) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("update balance failed (non-txn): %w", err)
+	}
+
+	logEntry := &models.UpstreamBalanceLog{
+		GroupID:     groupID,
+		OperatorID:  operatorID,
+		Delta:       delta,
+		Balance:     balance.Balance,
+		Type:        opType,
+		Remark:      remark,
+		OperationID: operationID,
+		CreatedAt:   now,
+		Metadata:    metadata,
+	}
+
+	if _, err := r.logColl.InsertOne(ctx, logEntry); err != nil {
+		return nil, fmt.Errorf("insert balance log failed (non-txn): %w", err)
+	}
+
+	return balance, nil
+}
+
+// incrementBalance 原子增减余额。nonNegative 且为扣款时先确保记录存在，再以 balance >= -delta 为条件更新，
+// 条件不满足（余额不足）时返回 ErrInsufficientBalance，避免先查后写的竞态
+func (r *MongoUpstreamBalanceRepository) incrementBalance(ctx context.Context, groupID int64, delta float64, nonNegative bool, now time.Time) (*models.UpstreamBalance, error) {
+	guarded := nonNegative && delta < 0
+	if guarded {
+		if _, err := r.Get(ctx, groupID); err != nil {
+			return nil, err
+		}
+	}
+
 	filter := balanceFilter(groupID)
+	if guarded {
+		filter = bson.M{"$and": []bson.M{filter, {"balance": bson.M{"$gte": -delta}}}}
+	}
 	update := bson.M{
 		"$inc": bson.M{
 			"balance": delta,
@@ -176,28 +267,15 @@ func (r *MongoUpstreamBalanceRepository) adjustWithoutTransaction(
 		},
 	}
 
-	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	// 条件更新不能 upsert，否则余额不足时会插入一条新记录
+	opts := options.FindOneAndUpdate().SetUpsert(!guarded).SetReturnDocument(options.After)
 	var balance models.UpstreamBalance
 	if err := r.balanceColl.FindOneAndUpdate(ctx, filter, update, opts).Decode(&balance); err != nil {
-		return nil, fmt.Errorf("update balance failed (non-txn): %w", err)
-	}
-
-	logEntry := &models.UpstreamBalanceLog{
-		GroupID:     groupID,
-		OperatorID:  operatorID,
-		Delta:       delta,
-		Balance:     balance.Balance,
-		Type:        opType,
-		Remark:      remark,
-		OperationID: operationID,
-		CreatedAt:   now,
-		Metadata:    metadata,
-	}
-
-	if _, err := r.logColl.InsertOne(ctx, logEntry); err != nil {
-		return nil, fmt.Errorf("insert balance log failed (non-txn): %w", err)
+		if guarded && errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInsufficientBalance
+		}
+		return nil, err
 	}
-
 	return &balance, nil
 }
 
@@ -212,15 +290,11 @@ func (r *MongoUpstreamBalanceRepository) SetAlertLimit(ctx context.Context, grou
 }
 
 func (r *MongoUpstreamBalanceRepository) updateSettings(ctx context.Context, groupID int64, setFields bson.M, operatorID int64, opType models.BalanceOperationType, remark string) (*models.UpstreamBalance, error) {
-	client := r.balanceColl.Database().Client()
-	session, err := client.StartSession()
-	if err != nil {
-		return nil, fmt.Errorf("start mongo session: %w", err)
+	if r.txnUnsupported.Load() {
+		return r.updateSettingsWithoutTransaction(ctx, groupID, setFields, operatorID, opType, remark)
 	}
-	defer session.EndSession(ctx)
 
-	txnOpts := options.Transaction().SetWriteConcern(writeconcern.Majority())
-	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+	result, err := r.withWriteConflictRetry(ctx, func(sc mongo.SessionContext) (interface{}, error) {
 		now := time.Now()
 		filter := balanceFilter(groupID)
 		update := bson.M{
@@ -256,10 +330,10 @@ func (r *MongoUpstreamBalanceRepository) updateSettings(ctx context.Context, gro
 		}
 
 		return &balance, nil
-	}, txnOpts)
+	})
 
 	if err != nil {
-		if isTransactionNotSupported(err) {
+		if r.detectTransactionNotSupported(err) {
 			return r.updateSettingsWithoutTransaction(ctx, groupID, setFields, operatorID, opType, remark)
 		}
 		return nil, fmt.Errorf("balance settings transaction failed: %w", err)
@@ -325,6 +399,138 @@ func (r *MongoUpstreamBalanceRepository) ListAll(ctx context.Context) ([]*models
 	return balances, nil
 }
 
+// ListLogs 按时间倒序分页列出余额变动日志
+func (r *MongoUpstreamBalanceRepository) ListLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, skip, limit int64) ([]*models.UpstreamBalanceLog, error) {
+	filter := bson.M{"group_id": groupID}
+	createdAt := bson.M{}
+	if !startTime.IsZero() {
+		createdAt["$gte"] = startTime
+	}
+	if !endTime.IsZero() {
+		createdAt["$lt"] = endTime
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if skip > 0 {
+		opts.SetSkip(skip)
+	}
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.logColl.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list balance logs failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var logs []*models.UpstreamBalanceLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, fmt.Errorf("decode balance logs failed: %w", err)
+	}
+	return logs, nil
+}
+
+// StreamLogs 按时间升序遍历 [startTime, endTime) 内的余额变动日志，逐条回调 fn
+func (r *MongoUpstreamBalanceRepository) StreamLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, fn func(*models.UpstreamBalanceLog) error) error {
+	filter := bson.M{
+		"group_id": groupID,
+		"created_at": bson.M{
+			"$gte": startTime,
+			"$lt":  endTime,
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.logColl.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("stream balance logs failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var log models.UpstreamBalanceLog
+		if err := cursor.Decode(&log); err != nil {
+			return fmt.Errorf("decode balance log failed: %w", err)
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+	return nil
+}
+
+// PruneLogs 删除 before 之前的余额日志；最近 keepLatest 条、确定当前余额的最后一条余额变动日志，
+// 以及带 operation_id 的日志（日结、更正、API 调整等操作的幂等记录，删除后重放会重复扣款）始终保留
+func (r *MongoUpstreamBalanceRepository) PruneLogs(ctx context.Context, groupID int64, before time.Time, keepLatest int) (int64, error) {
+	protected, err := r.protectedLogIDs(ctx, groupID, keepLatest)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.logColl.DeleteMany(ctx, balanceLogPruneFilter(groupID, before, protected))
+	if err != nil {
+		return 0, fmt.Errorf("prune balance logs failed: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// protectedLogIDs 返回清理时必须保留的日志：最近 keepLatest 条，以及最后一条余额变动日志（当前余额的来源）
+func (r *MongoUpstreamBalanceRepository) protectedLogIDs(ctx context.Context, groupID int64, keepLatest int) ([]primitive.ObjectID, error) {
+	newestFirst := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	idOnly := bson.M{"_id": 1}
+
+	var protected []primitive.ObjectID
+	if keepLatest > 0 {
+		opts := options.Find().SetSort(newestFirst).SetLimit(int64(keepLatest)).SetProjection(idOnly)
+		cursor, err := r.logColl.Find(ctx, bson.M{"group_id": groupID}, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list latest balance logs failed: %w", err)
+		}
+		var latest []models.UpstreamBalanceLog
+		if err := cursor.All(ctx, &latest); err != nil {
+			return nil, fmt.Errorf("decode latest balance logs failed: %w", err)
+		}
+		for _, log := range latest {
+			protected = append(protected, log.ID)
+		}
+	}
+
+	anchorFilter := bson.M{
+		"group_id": groupID,
+		"type":     bson.M{"$in": models.BalanceChangingOps},
+	}
+	var anchor models.UpstreamBalanceLog
+	err := r.logColl.FindOne(ctx, anchorFilter, options.FindOne().SetSort(newestFirst).SetProjection(idOnly)).Decode(&anchor)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+	case err != nil:
+		return nil, fmt.Errorf("find balance anchor log failed: %w", err)
+	case !slices.Contains(protected, anchor.ID):
+		protected = append(protected, anchor.ID)
+	}
+	return protected, nil
+}
+
+// balanceLogPruneFilter 清理条件：属于该群、早于 before 且不在保留列表中
+func balanceLogPruneFilter(groupID int64, before time.Time, protected []primitive.ObjectID) bson.M {
+	filter := bson.M{
+		"group_id":     groupID,
+		"created_at":   bson.M{"$lt": before},
+		"operation_id": bson.M{"$in": bson.A{nil, ""}},
+	}
+	if len(protected) > 0 {
+		filter["_id"] = bson.M{"$nin": protected}
+	}
+	return filter
+}
+
 // EnsureIndexes 创建需要的索引
 func (r *MongoUpstreamBalanceRepository) EnsureIndexes(ctx context.Context) error {
 	balanceIndexes := []mongo.IndexModel{
@@ -393,6 +599,53 @@ func mergeBson(a, b bson.M) bson.M {
 	return result
 }
 
+// withWriteConflictRetry 在事务中执行 fn；遇到写冲突或瞬时事务错误时按退避有限次重试整个事务。
+// 仅用于事务路径：事务失败时所有写入均已回滚，重试不会重复扣款；非事务路径不做重试
+func (r *MongoUpstreamBalanceRepository) withWriteConflictRetry(ctx context.Context, fn func(sc mongo.SessionContext) (interface{}, error)) (interface{}, error) {
+	client := r.balanceColl.Database().Client()
+	txnOpts := options.Transaction().SetWriteConcern(writeconcern.Majority())
+
+	for attempt := 0; ; attempt++ {
+		session, err := client.StartSession()
+		if err != nil {
+			return nil, fmt.Errorf("start mongo session: %w", err)
+		}
+		result, err := session.WithTransaction(ctx, fn, txnOpts)
+		session.EndSession(ctx)
+
+		if err == nil || attempt >= r.writeConflictRetries || !isWriteConflict(err) {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt+1) * 20 * time.Millisecond):
+		}
+	}
+}
+
+// isWriteConflict 判断错误是否为写冲突（112）或带 TransientTransactionError 标签的瞬时事务错误，与 isTransactionNotSupported 互斥
+func isWriteConflict(err error) bool {
+	if err == nil || isTransactionNotSupported(err) {
+		return false
+	}
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.HasErrorCode(writeConflictCode) || serverErr.HasErrorLabel("TransientTransactionError")
+	}
+	return false
+}
+
+// detectTransactionNotSupported 判断错误是否因部署不支持事务，是则缓存结果供后续调用跳过事务尝试
+func (r *MongoUpstreamBalanceRepository) detectTransactionNotSupported(err error) bool {
+	if !isTransactionNotSupported(err) {
+		return false
+	}
+	r.txnUnsupported.Store(true)
+	return true
+}
+
 func isTransactionNotSupported(err error) bool {
 	var cmdErr mongo.CommandError
 	if errors.As(err, &cmdErr) {
@@ -11,6 +11,7 @@ import (
 	"go_bot/internal/telegram/models"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
@@ -94,6 +95,7 @@ func TestMongoUpstreamBalanceRepositoryAdjustWithoutTransaction(t *testing.T) {
 			context.Background(),
 			-2001,
 			20,
+			false,
 			9001,
 			"manual credit",
 			models.BalanceOpCredit,
@@ -143,6 +145,7 @@ func TestMongoUpstreamBalanceRepositoryAdjustWithoutTransaction(t *testing.T) {
 			context.Background(),
 			-2002,
 			100,
+			false,
 			9002,
 			"idempotent check",
 			models.BalanceOpCredit,
@@ -169,6 +172,7 @@ func TestMongoUpstreamBalanceRepositoryAdjustWithoutTransaction(t *testing.T) {
 			context.Background(),
 			-2003,
 			-10,
+			false,
 			9003,
 			"manual debit",
 			models.BalanceOpDebit,
@@ -211,6 +215,7 @@ func TestMongoUpstreamBalanceRepositoryAdjustWithoutTransaction(t *testing.T) {
 			context.Background(),
 			-2004,
 			10,
+			false,
 			9004,
 			"insert log fail",
 			models.BalanceOpCredit,
@@ -226,6 +231,193 @@ func TestMongoUpstreamBalanceRepositoryAdjustWithoutTransaction(t *testing.T) {
 	})
 }
 
+func TestMongoUpstreamBalanceRepositoryAdjustNonNegative(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	balanceResponse := func(balance float64) bson.D {
+		return mtest.CreateSuccessResponse(bson.E{
+			Key: "value",
+			Value: bson.D{
+				{Key: "group_id", Value: int64(-2301)},
+				{Key: "balance", Value: balance},
+			},
+		})
+	}
+
+	mt.Run("rejects debit when conditional update matches nothing", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(
+			balanceResponse(50), // 确保记录存在
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}), // 条件不满足
+		)
+
+		_, err := repo.adjustWithoutTransaction(context.Background(), -2301, -80, true, 9001, "", models.BalanceOpDebit, "", nil)
+		if !errors.Is(err, ErrInsufficientBalance) {
+			t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+		}
+
+		events := mt.GetAllStartedEvents()
+		if len(events) != 2 {
+			t.Fatalf("expected ensure + conditional update without log insert, got %d commands", len(events))
+		}
+		update := events[1].Command
+		if upsert, _ := update.Lookup("upsert").BooleanOK(); upsert {
+			t.Fatalf("conditional update must not upsert")
+		}
+		if !strings.Contains(update.Lookup("query").String(), `"$gte"`) {
+			t.Fatalf("expected balance guard in filter, got %s", update.Lookup("query"))
+		}
+	})
+
+	mt.Run("allows debit exactly to zero", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(
+			balanceResponse(80),
+			balanceResponse(0),
+			mtest.CreateSuccessResponse(),
+		)
+
+		balance, err := repo.adjustWithoutTransaction(context.Background(), -2301, -80, true, 9001, "", models.BalanceOpDebit, "", nil)
+		if err != nil {
+			t.Fatalf("adjustWithoutTransaction failed: %v", err)
+		}
+		if balance.Balance != 0 {
+			t.Fatalf("unexpected balance: got %.2f, want 0", balance.Balance)
+		}
+	})
+
+	mt.Run("credits skip the guard", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(balanceResponse(30), mtest.CreateSuccessResponse())
+
+		if _, err := repo.adjustWithoutTransaction(context.Background(), -2301, 30, true, 9001, "", models.BalanceOpCredit, "", nil); err != nil {
+			t.Fatalf("adjustWithoutTransaction failed: %v", err)
+		}
+		events := mt.GetAllStartedEvents()
+		if len(events) != 2 || strings.Contains(events[0].Command.Lookup("query").String(), `"$gte"`) {
+			t.Fatalf("expected single unguarded update + log insert, got %d commands", len(events))
+		}
+	})
+}
+
+func TestMongoUpstreamBalanceRepositoryAdjustCachesTransactionSupport(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("skips transaction after detecting standalone deployment", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		now := time.Now().UTC().Truncate(time.Second)
+		balanceResponse := func(balance float64) bson.D {
+			return mtest.CreateSuccessResponse(bson.E{
+				Key: "value",
+				Value: bson.D{
+					{Key: "group_id", Value: int64(-2101)},
+					{Key: "balance", Value: balance},
+					{Key: "created_at", Value: now},
+					{Key: "updated_at", Value: now},
+				},
+			})
+		}
+
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{
+				Code:    20,
+				Name:    "IllegalOperation",
+				Message: "Transaction numbers are only allowed on a replica set member or mongos",
+			}),
+			mtest.CreateSuccessResponse(), // abortTransaction
+			balanceResponse(10),
+			mtest.CreateSuccessResponse(),
+			balanceResponse(20),
+			mtest.CreateSuccessResponse(),
+		)
+
+		first, err := repo.Adjust(context.Background(), -2101, 10, 9001, "", models.BalanceOpCredit, "", nil)
+		if err != nil {
+			t.Fatalf("first adjust failed: %v", err)
+		}
+		if first.Balance != 10 {
+			t.Fatalf("unexpected first balance: %.2f", first.Balance)
+		}
+		if !repo.txnUnsupported.Load() {
+			t.Fatalf("expected transaction support detection to be cached")
+		}
+
+		mt.ClearEvents()
+		second, err := repo.Adjust(context.Background(), -2101, 10, 9001, "", models.BalanceOpCredit, "", nil)
+		if err != nil {
+			t.Fatalf("second adjust failed: %v", err)
+		}
+		if second.Balance != 20 {
+			t.Fatalf("unexpected second balance: %.2f", second.Balance)
+		}
+
+		events := mt.GetAllStartedEvents()
+		if len(events) != 2 {
+			t.Fatalf("expected findAndModify + insert only, got %d commands", len(events))
+		}
+		for _, event := range events {
+			if _, ok := event.Command.Lookup("startTransaction").BooleanOK(); ok {
+				t.Fatalf("expected no transaction after detection, got %s with startTransaction", event.CommandName)
+			}
+		}
+	})
+}
+
+func TestMongoUpstreamBalanceRepositoryAdjustRetriesWriteConflict(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	writeConflict := mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    112,
+		Name:    "WriteConflict",
+		Message: "WriteConflict error: this operation conflicted with another operation",
+	})
+
+	mt.Run("succeeds after write conflict", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		repo.writeConflictRetries = 2
+		now := time.Now().UTC().Truncate(time.Second)
+
+		mt.AddMockResponses(
+			writeConflict,
+			mtest.CreateSuccessResponse(), // abortTransaction
+			mtest.CreateSuccessResponse(
+				bson.E{
+					Key: "value",
+					Value: bson.D{
+						{Key: "group_id", Value: int64(-2201)},
+						{Key: "balance", Value: 15.0},
+						{Key: "created_at", Value: now},
+						{Key: "updated_at", Value: now},
+					},
+				},
+			),
+			mtest.CreateSuccessResponse(), // insert log
+			mtest.CreateSuccessResponse(), // commitTransaction
+		)
+
+		balance, err := repo.Adjust(context.Background(), -2201, 15, 9001, "", models.BalanceOpCredit, "", nil)
+		if err != nil {
+			t.Fatalf("expected adjust to succeed after retry, got %v", err)
+		}
+		if balance.Balance != 15 {
+			t.Fatalf("unexpected balance: %.2f", balance.Balance)
+		}
+	})
+
+	mt.Run("gives up without retries", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		repo.writeConflictRetries = 0
+
+		mt.AddMockResponses(
+			writeConflict,
+			mtest.CreateSuccessResponse(), // abortTransaction
+		)
+
+		if _, err := repo.Adjust(context.Background(), -2201, 15, 9001, "", models.BalanceOpCredit, "", nil); err == nil {
+			t.Fatalf("expected write conflict error when retries disabled")
+		}
+	})
+}
+
 func TestMongoUpstreamBalanceRepositoryUpdateSettingsWithoutTransaction(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
@@ -462,6 +654,42 @@ func TestMongoUpstreamBalanceRepositoryEnsureIndexes(t *testing.T) {
 	})
 }
 
+func TestMongoUpstreamBalanceRepositoryFind(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("not found does not create", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamBalanceNamespace(mt), mtest.FirstBatch))
+
+		balance, err := repo.Find(context.Background(), -5101)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if balance != nil {
+			t.Fatalf("expected nil balance, got %+v", balance)
+		}
+		if started := mt.GetStartedEvent(); started == nil || started.CommandName != "find" {
+			t.Fatalf("expected a read-only find command, got %+v", started)
+		}
+	})
+
+	mt.Run("found", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamBalanceNamespace(mt), mtest.FirstBatch, bson.D{
+			{Key: "group_id", Value: int64(-5102)},
+			{Key: "balance", Value: 88.5},
+		}))
+
+		balance, err := repo.Find(context.Background(), -5102)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if balance == nil || balance.Balance != 88.5 {
+			t.Fatalf("unexpected balance: %+v", balance)
+		}
+	})
+}
+
 func TestMongoUpstreamBalanceRepositoryFindLogByOperation(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
@@ -599,6 +827,293 @@ func TestUpstreamBalanceHelpers(t *testing.T) {
 	})
 }
 
+func TestMongoUpstreamBalanceRepositoryListLogs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("date range filter", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		start := time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)
+		end := start.AddDate(0, 0, 1)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+			bson.D{
+				{Key: "group_id", Value: int64(-1001)},
+				{Key: "delta", Value: 50.0},
+				{Key: "balance", Value: 150.0},
+				{Key: "type", Value: string(models.BalanceOpCredit)},
+				{Key: "created_at", Value: start.Add(time.Hour)},
+			},
+		))
+
+		logs, err := repo.ListLogs(context.Background(), -1001, start, end, 0, 10)
+		if err != nil {
+			t.Fatalf("ListLogs failed: %v", err)
+		}
+		if len(logs) != 1 || logs[0].Delta != 50 {
+			t.Fatalf("unexpected logs: %+v", logs)
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		createdAt, ok := cmd.Lookup("filter", "created_at").DocumentOK()
+		if !ok {
+			t.Fatalf("expected created_at range in filter, got %s", cmd)
+		}
+		if got := createdAt.Lookup("$gte").Time().UTC(); !got.Equal(start) {
+			t.Fatalf("unexpected $gte: got %s, want %s", got, start)
+		}
+		if got := createdAt.Lookup("$lt").Time().UTC(); !got.Equal(end) {
+			t.Fatalf("unexpected $lt: got %s, want %s", got, end)
+		}
+		if _, err := cmd.LookupErr("skip"); err == nil {
+			t.Fatalf("expected no skip for first page, got %s", cmd)
+		}
+	})
+
+	mt.Run("paging offsets", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch))
+
+		logs, err := repo.ListLogs(context.Background(), -1001, time.Time{}, time.Time{}, 20, 11)
+		if err != nil {
+			t.Fatalf("ListLogs failed: %v", err)
+		}
+		if len(logs) != 0 {
+			t.Fatalf("expected empty page, got %d", len(logs))
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		if _, err := cmd.LookupErr("filter", "created_at"); err == nil {
+			t.Fatalf("expected no created_at filter without bounds, got %s", cmd)
+		}
+		if got := cmd.Lookup("skip").AsInt64(); got != 20 {
+			t.Fatalf("unexpected skip: got %d, want 20", got)
+		}
+		if got := cmd.Lookup("limit").AsInt64(); got != 11 {
+			t.Fatalf("unexpected limit: got %d, want 11", got)
+		}
+	})
+
+	mt.Run("find error", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    13,
+			Name:    "Unauthorized",
+			Message: "mock find error",
+		}))
+
+		if _, err := repo.ListLogs(context.Background(), -1001, time.Time{}, time.Time{}, 0, 10); err == nil {
+			t.Fatalf("expected error but got nil")
+		}
+	})
+}
+
+func TestMongoUpstreamBalanceRepositoryStreamLogs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("ascending callbacks", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		start := time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)
+		end := start.AddDate(0, 0, 2)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+			bson.D{
+				{Key: "group_id", Value: int64(-1001)},
+				{Key: "delta", Value: 50.0},
+				{Key: "type", Value: string(models.BalanceOpCredit)},
+				{Key: "created_at", Value: start.Add(time.Hour)},
+			},
+			bson.D{
+				{Key: "group_id", Value: int64(-1001)},
+				{Key: "delta", Value: 0.0},
+				{Key: "type", Value: string(models.BalanceOpSetMinBalance)},
+				{Key: "created_at", Value: start.Add(2 * time.Hour)},
+			},
+		))
+
+		var types []models.BalanceOperationType
+		err := repo.StreamLogs(context.Background(), -1001, start, end, func(log *models.UpstreamBalanceLog) error {
+			types = append(types, log.Type)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StreamLogs failed: %v", err)
+		}
+		if len(types) != 2 || types[0] != models.BalanceOpCredit || types[1] != models.BalanceOpSetMinBalance {
+			t.Fatalf("unexpected logs: %v", types)
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		if got := cmd.Lookup("sort", "created_at").AsInt64(); got != 1 {
+			t.Fatalf("expected ascending sort, got %s", cmd)
+		}
+		if got := cmd.Lookup("filter", "created_at", "$lt").Time().UTC(); !got.Equal(end) {
+			t.Fatalf("unexpected $lt: got %s, want %s", got, end)
+		}
+	})
+
+	mt.Run("callback error stops iteration", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+			bson.D{{Key: "group_id", Value: int64(-1001)}},
+			bson.D{{Key: "group_id", Value: int64(-1001)}},
+		))
+
+		calls := 0
+		stop := errors.New("stop")
+		err := repo.StreamLogs(context.Background(), -1001, time.Time{}, time.Now(), func(log *models.UpstreamBalanceLog) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Fatalf("expected iteration to stop after first callback, calls=%d err=%v", calls, err)
+		}
+	})
+}
+
+func TestMongoUpstreamBalanceRepositoryPruneLogs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mt.Run("keeps latest entries and balance anchor", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		latest1, latest2 := primitive.NewObjectID(), primitive.NewObjectID()
+		anchor := primitive.NewObjectID()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: latest1}},
+				bson.D{{Key: "_id", Value: latest2}},
+			),
+			mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: anchor}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(5)}),
+		)
+
+		deleted, err := repo.PruneLogs(context.Background(), -1001, before, 2)
+		if err != nil {
+			t.Fatalf("PruneLogs failed: %v", err)
+		}
+		if deleted != 5 {
+			t.Fatalf("unexpected deleted count: got %d, want 5", deleted)
+		}
+
+		latestCmd := mt.GetStartedEvent().Command
+		if got := latestCmd.Lookup("limit").AsInt64(); got != 2 {
+			t.Fatalf("unexpected keep latest limit: got %d, want 2", got)
+		}
+
+		anchorCmd := mt.GetStartedEvent().Command
+		types, ok := anchorCmd.Lookup("filter", "type", "$in").ArrayOK()
+		if !ok {
+			t.Fatalf("expected anchor filter on balance changing types, got %s", anchorCmd)
+		}
+		values, _ := types.Values()
+		if len(values) != len(models.BalanceChangingOps) {
+			t.Fatalf("unexpected anchor types: %s", types)
+		}
+
+		deleteCmd := mt.GetStartedEvent().Command
+		filter := deleteCmd.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		if got := filter.Lookup("group_id").AsInt64(); got != -1001 {
+			t.Fatalf("unexpected group filter: got %d", got)
+		}
+		if got := filter.Lookup("created_at", "$lt").Time().UTC(); !got.Equal(before) {
+			t.Fatalf("unexpected age cutoff: got %s, want %s", got, before)
+		}
+		if _, ok := filter.Lookup("operation_id", "$in").ArrayOK(); !ok {
+			t.Fatalf("expected logs with operation_id to be kept, got %s", filter)
+		}
+		protected := objectIDsOf(t, filter.Lookup("_id", "$nin").Array())
+		want := []primitive.ObjectID{latest1, latest2, anchor}
+		if !reflect.DeepEqual(protected, want) {
+			t.Fatalf("unexpected protected ids: got %v, want %v", protected, want)
+		}
+	})
+
+	mt.Run("anchor within latest is not duplicated", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		latest := primitive.NewObjectID()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: latest}},
+			),
+			mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: latest}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(0)}),
+		)
+
+		if _, err := repo.PruneLogs(context.Background(), -1001, before, 1); err != nil {
+			t.Fatalf("PruneLogs failed: %v", err)
+		}
+
+		mt.GetStartedEvent()
+		mt.GetStartedEvent()
+		filter := mt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		protected := objectIDsOf(t, filter.Lookup("_id", "$nin").Array())
+		if !reflect.DeepEqual(protected, []primitive.ObjectID{latest}) {
+			t.Fatalf("unexpected protected ids: got %v", protected)
+		}
+	})
+
+	mt.Run("without minimum only age and anchor apply", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, upstreamLogNamespace(mt), mtest.FirstBatch),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(3)}),
+		)
+
+		deleted, err := repo.PruneLogs(context.Background(), -1001, before, 0)
+		if err != nil {
+			t.Fatalf("PruneLogs failed: %v", err)
+		}
+		if deleted != 3 {
+			t.Fatalf("unexpected deleted count: got %d, want 3", deleted)
+		}
+
+		if name := mt.GetStartedEvent().CommandName; name != "find" {
+			t.Fatalf("expected anchor lookup first, got %s", name)
+		}
+		filter := mt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		if _, err := filter.LookupErr("_id"); err == nil {
+			t.Fatalf("expected no protected ids without logs, got %s", filter)
+		}
+	})
+
+	mt.Run("lookup error aborts deletion", func(mt *mtest.T) {
+		repo := newUpstreamRepoForTest(mt)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    13,
+			Name:    "Unauthorized",
+			Message: "mock find error",
+		}))
+
+		if _, err := repo.PruneLogs(context.Background(), -1001, before, 10); err == nil {
+			t.Fatalf("expected error but got nil")
+		}
+		mt.GetStartedEvent()
+		if evt := mt.GetStartedEvent(); evt != nil {
+			t.Fatalf("expected no delete after lookup failure, got %s", evt.CommandName)
+		}
+	})
+}
+
+func objectIDsOf(t *testing.T, arr bson.Raw) []primitive.ObjectID {
+	t.Helper()
+	values, err := arr.Values()
+	if err != nil {
+		t.Fatalf("decode array: %v", err)
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		ids = append(ids, v.ObjectID())
+	}
+	return ids
+}
+
 func newUpstreamRepoForTest(mt *mtest.T) *MongoUpstreamBalanceRepository {
 	return &MongoUpstreamBalanceRepository{
 		balanceColl: mt.DB.Collection("upstream_balances"),
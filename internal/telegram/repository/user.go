@@ -105,6 +105,34 @@ func (r *MongoUserRepository) UpdateLastActive(ctx context.Context, telegramID i
 	return nil
 }
 
+// SetBlocked 设置或清除用户屏蔽 Bot 的标记，清除时一并移除屏蔽时间
+func (r *MongoUserRepository) SetBlocked(ctx context.Context, telegramID int64, blocked bool) error {
+	now := time.Now()
+	filter := bson.M{"telegram_id": telegramID}
+	update := bson.M{
+		"$set": bson.M{
+			"blocked":    true,
+			"blocked_at": now,
+			"updated_at": now,
+		},
+	}
+	if !blocked {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": now},
+			"$unset": bson.M{"blocked": "", "blocked_at": ""},
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to set user blocked: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found: %d", telegramID)
+	}
+	return nil
+}
+
 // GrantAdmin 授予管理员权限
 func (r *MongoUserRepository) GrantAdmin(ctx context.Context, telegramID int64, grantedBy int64) error {
 	now := time.Now()
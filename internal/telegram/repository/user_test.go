@@ -208,6 +208,44 @@ func TestMongoUserRepositoryGrantAdmin(t *testing.T) {
 	})
 }
 
+func TestMongoUserRepositorySetBlocked(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("set and clear", func(mt *mtest.T) {
+		repo := &MongoUserRepository{collection: mt.Coll}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		if err := repo.SetBlocked(context.Background(), 5001, true); err != nil {
+			t.Fatalf("SetBlocked(true) failed: %v", err)
+		}
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		if !update.Lookup("$set", "blocked").Boolean() {
+			t.Fatalf("expected blocked=true in update, got %s", update)
+		}
+
+		if err := repo.SetBlocked(context.Background(), 5001, false); err != nil {
+			t.Fatalf("SetBlocked(false) failed: %v", err)
+		}
+		update = mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		if _, err := update.LookupErr("$unset", "blocked"); err != nil {
+			t.Fatalf("expected blocked to be unset, got %s", update)
+		}
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		repo := &MongoUserRepository{collection: mt.Coll}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		err := repo.SetBlocked(context.Background(), 5002, true)
+		if err == nil || !strings.Contains(err.Error(), "user not found") {
+			t.Fatalf("expected user not found error, got %v", err)
+		}
+	})
+}
+
 func TestMongoUserRepositoryRevokeAdmin(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
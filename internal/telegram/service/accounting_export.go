@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+// accountingRecordCSVHeader 记账记录导出的表头
+var accountingRecordCSVHeader = []string{"time", "userId", "currency", "amount", "expression"}
+
+// ExportRecords 将 [start, end) 内的记账记录按时间升序写为 CSV（含 BOM），时间按 end 的时区格式化，返回导出条数
+func (s *AccountingServiceImpl) ExportRecords(ctx context.Context, chatID int64, start, end time.Time, w io.Writer) (int, error) {
+	records, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, start, end, "")
+	if err != nil {
+		logStorageError(err, "Failed to export accounting records for chat %d: %v", chatID, err)
+		return 0, wrapStorageError("导出失败", err)
+	}
+
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString(utf8BOM); err != nil {
+		return 0, fmt.Errorf("failed to export accounting records: %w", err)
+	}
+	writer := csv.NewWriter(buf)
+	if err := writer.Write(accountingRecordCSVHeader); err != nil {
+		return 0, fmt.Errorf("failed to export accounting records: %w", err)
+	}
+	for _, record := range records {
+		if err := writer.Write(accountingRecordCSVRecord(record, end.Location())); err != nil {
+			return 0, fmt.Errorf("failed to export accounting records: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, fmt.Errorf("failed to export accounting records: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to export accounting records: %w", err)
+	}
+	return len(records), nil
+}
+
+// accountingRecordCSVRecord 将一条记账记录转换为 CSV 行
+func accountingRecordCSVRecord(record *models.AccountingRecord, loc *time.Location) []string {
+	return []string{
+		record.RecordedAt.In(loc).Format("2006-01-02 15:04:05"),
+		strconv.FormatInt(record.UserID, 10),
+		record.Currency,
+		strconv.FormatFloat(record.Amount, 'f', 2, 64),
+		record.OriginalExpr,
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+func TestExportRecordsWritesCSV(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 11, day, hour, 0, 0, 0, loc).UTC()
+	}
+	repo := &memoryAccountingRepository{records: []*models.AccountingRecord{
+		{ChatID: -1001, UserID: 7, Amount: 100, Currency: models.CurrencyCNY, OriginalExpr: "100", RecordedAt: at(17, 9)},
+		{ChatID: -1001, UserID: 8, Amount: -12.5, Currency: models.CurrencyUSD, OriginalExpr: "25/2", RecordedAt: at(17, 10)},
+		{ChatID: -1002, UserID: 7, Amount: 50, Currency: models.CurrencyCNY, OriginalExpr: "50", RecordedAt: at(17, 11)},
+		{ChatID: -1001, UserID: 7, Amount: 1, Currency: models.CurrencyCNY, OriginalExpr: "1", RecordedAt: at(18, 9)},
+	}}
+	svc := NewAccountingService(repo, nil)
+
+	var buf bytes.Buffer
+	count, err := svc.ExportRecords(context.Background(), -1001, time.Time{}, time.Date(2024, 11, 18, 0, 0, 0, 0, loc), &buf)
+	if err != nil {
+		t.Fatalf("ExportRecords returned error: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 rows, got %d", count)
+	}
+
+	want := "\ufeff" + strings.Join([]string{
+		"time,userId,currency,amount,expression",
+		"2024-11-17 09:00:00,7,CNY,100.00,100",
+		"2024-11-17 10:00:00,8,USD,-12.50,25/2",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -15,10 +16,10 @@ import (
 
 // 正则表达式
 var (
-	// 符号格式：+100*7.2U 或 -50/2Y
-	symbolPattern = regexp.MustCompile(`^([+-])((?:\d+(?:\.\d+)?)(?:[\+\-\*/]\d+(?:\.\d+)?)*)([UY])$`)
+	// 符号格式：+100*7.2U、-50/2Y 或 +50e（币种后缀不区分大小写）
+	symbolPattern = regexp.MustCompile(`^([+-])((?:\d+(?:\.\d+)?)(?:[\+\-\*/]\d+(?:\.\d+)?)*)([A-Za-z])?$`)
 	// 中文格式：入100*7.2 或 出50Y
-	chinesePattern = regexp.MustCompile(`^(入|出)((?:\d+(?:\.\d+)?)(?:[\+\-\*/]\d+(?:\.\d+)?)*)([UY])?$`)
+	chinesePattern = regexp.MustCompile(`^(入|出)((?:\d+(?:\.\d+)?)(?:[\+\-\*/]\d+(?:\.\d+)?)*)([A-Za-z])?$`)
 )
 
 // AccountingServiceImpl 收支记账服务实现
@@ -35,19 +36,77 @@ func NewAccountingService(accountingRepo repository.AccountingRepository, groupR
 	}
 }
 
+// accountingDuplicateWindow 同一用户连续提交相同金额与表达式视为重复的时间窗口
+const accountingDuplicateWindow = 10 * time.Second
+
 // AddRecord 添加记账记录
 func (s *AccountingServiceImpl) AddRecord(ctx context.Context, chatID, userID int64, input string) error {
-	// 解析输入
-	isIncome, expression, currency, err := s.parseInput(input)
+	record, err := s.buildRecord(ctx, chatID, userID, input)
 	if err != nil {
 		return err
 	}
 
+	if err := s.accountingRepo.CreateRecord(ctx, record); err != nil {
+		logStorageError(err, "Failed to create accounting record: %v", err)
+		return wrapStorageError("记录保存失败", err)
+	}
+
+	logger.L().Infof("Accounting record created: chat_id=%d, user_id=%d, amount=%.2f, currency=%s", chatID, userID, record.Amount, record.Currency)
+	return nil
+}
+
+// IsDuplicateRecord 判断输入是否与群组上一条记录重复（同一用户、金额、币种与表达式，且在时间窗口内）
+func (s *AccountingServiceImpl) IsDuplicateRecord(ctx context.Context, chatID, userID int64, input string) (bool, error) {
+	record, err := s.buildRecord(ctx, chatID, userID, input)
+	if err != nil {
+		return false, err
+	}
+
+	last, err := s.accountingRepo.GetLastRecord(ctx, chatID)
+	if err != nil {
+		logStorageError(err, "Failed to load last accounting record: chat_id=%d, err=%v", chatID, err)
+		return false, wrapStorageError("查询上一条记录失败", err)
+	}
+
+	return isDuplicateRecord(last, record, accountingDuplicateWindow), nil
+}
+
+// isDuplicateRecord 判断 record 是否为 last 在 window 内的重复提交
+func isDuplicateRecord(last, record *models.AccountingRecord, window time.Duration) bool {
+	if last == nil || record == nil {
+		return false
+	}
+	if last.UserID != record.UserID || last.Currency != record.Currency || last.OriginalExpr != record.OriginalExpr {
+		return false
+	}
+	if math.Abs(last.Amount-record.Amount) > 1e-9 {
+		return false
+	}
+	elapsed := record.RecordedAt.Sub(last.RecordedAt)
+	return elapsed >= 0 && elapsed <= window
+}
+
+// buildRecord 解析输入并生成待保存的记录
+func (s *AccountingServiceImpl) buildRecord(ctx context.Context, chatID, userID int64, input string) (*models.AccountingRecord, error) {
+	settings := s.loadGroupSettings(ctx, chatID)
+
+	// 解析输入，未带币种后缀时使用群组默认币种
+	isIncome, expression, currency, err := s.parseInput(input, models.AccountingDefaultCurrencyOf(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验群组允许的币种
+	allowed := models.AllowedAccountingCurrencies(settings)
+	if !containsCurrency(allowed, currency) {
+		return nil, fmt.Errorf("本群未启用 %s 记账，可用币种：%s", currency, strings.Join(allowed, "、"))
+	}
+
 	// 计算表达式
 	amount, err := calculator.Calculate(expression)
 	if err != nil {
 		logger.L().Errorf("Failed to calculate expression %s: %v", expression, err)
-		return fmt.Errorf("计算失败: %v", err)
+		return nil, fmt.Errorf("计算失败: %v", err)
 	}
 
 	// 如果是支出，金额为负数
@@ -55,37 +114,28 @@ func (s *AccountingServiceImpl) AddRecord(ctx context.Context, chatID, userID in
 		amount = -amount
 	}
 
-	// 创建记录
-	record := &models.AccountingRecord{
+	return &models.AccountingRecord{
 		ChatID:       chatID,
 		UserID:       userID,
 		Amount:       amount,
 		Currency:     currency,
 		OriginalExpr: expression,
 		RecordedAt:   time.Now(),
-	}
-
-	if err := s.accountingRepo.CreateRecord(ctx, record); err != nil {
-		logger.L().Errorf("Failed to create accounting record: %v", err)
-		return fmt.Errorf("记录保存失败")
-	}
-
-	logger.L().Infof("Accounting record created: chat_id=%d, user_id=%d, amount=%.2f, currency=%s", chatID, userID, amount, currency)
-	return nil
+	}, nil
 }
 
-// parseInput 解析记账输入
-func (s *AccountingServiceImpl) parseInput(input string) (isIncome bool, expression string, currency string, err error) {
-	input = strings.TrimSpace(input)
+// parseInput 解析记账输入，未带币种后缀时使用 defaultCurrency
+func (s *AccountingServiceImpl) parseInput(input, defaultCurrency string) (isIncome bool, expression string, currency string, err error) {
+	input = strings.TrimSpace(models.NormalizeFullWidth(input))
 
-	// 尝试符号格式：+100*7.2U 或 -50/2Y
+	// 尝试符号格式：+100*7.2U、-50/2Y 或 +100
 	if matches := symbolPattern.FindStringSubmatch(input); matches != nil {
 		sign := matches[1]
 		expression = matches[2]
 		currencyCode := matches[3]
 
 		isIncome = (sign == "+")
-		currency = parseCurrency(currencyCode)
+		currency, err = resolveCurrency(currencyCode, defaultCurrency)
 		return
 	}
 
@@ -96,12 +146,7 @@ func (s *AccountingServiceImpl) parseInput(input string) (isIncome bool, express
 		currencyCode := matches[3]
 
 		isIncome = (action == "入")
-		// 如果没有货币后缀，默认为USDT
-		if currencyCode == "" {
-			currency = models.CurrencyUSD
-		} else {
-			currency = parseCurrency(currencyCode)
-		}
+		currency, err = resolveCurrency(currencyCode, defaultCurrency)
 		return
 	}
 
@@ -109,62 +154,180 @@ func (s *AccountingServiceImpl) parseInput(input string) (isIncome bool, express
 	return
 }
 
-// parseCurrency 解析货币代码
-func parseCurrency(code string) string {
-	if code == "U" {
-		return models.CurrencyUSD
+// parseCurrency 解析货币后缀，未知后缀视为格式错误
+func parseCurrency(code string) (string, error) {
+	currency, ok := models.CurrencyFromToken(code)
+	if !ok {
+		return "", fmt.Errorf("输入格式错误")
 	}
-	return models.CurrencyCNY
+	return currency, nil
+}
+
+// resolveCurrency 解析货币后缀，没有后缀时使用默认币种
+func resolveCurrency(code, defaultCurrency string) (string, error) {
+	if code == "" {
+		return defaultCurrency, nil
+	}
+	return parseCurrency(code)
+}
+
+// containsCurrency 判断币种是否在列表中
+func containsCurrency(currencies []string, currency string) bool {
+	for _, candidate := range currencies {
+		if candidate == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// currencyReport 单一币种的账单数据
+type currencyReport struct {
+	Currency         string
+	YesterdayBalance float64
+	TodayRecords     []*models.AccountingRecord
+	Balance          float64
+}
+
+// currencyReportTitles 账单中各币种的小标题
+var currencyReportTitles = map[string]string{
+	models.CurrencyUSD: "💵 USDT",
+	models.CurrencyCNY: "💴 CNY",
+	models.CurrencyEUR: "💶 EUR",
 }
 
 // QueryRecords 查询并格式化账单
+// 群组允许的币种始终展示，已停用但仍有余额或今日记录的币种也会展示
 func (s *AccountingServiceImpl) QueryRecords(ctx context.Context, chatID int64) (string, error) {
 	now := time.Now()
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	todayEnd := todayStart.Add(24 * time.Hour)
 	yesterdayStart := todayStart.Add(-24 * time.Hour)
 
-	// 查询昨日结余（历史累计）
-	usdYesterdayBalance, err := s.calculateBalance(ctx, chatID, time.Time{}, yesterdayStart, models.CurrencyUSD)
-	if err != nil {
-		return "", err
+	settings := s.loadGroupSettings(ctx, chatID)
+	allowed := models.AllowedAccountingCurrencies(settings)
+
+	var reports []currencyReport
+	for _, currency := range models.SupportedCurrencies {
+		// 查询昨日结余（历史累计）
+		yesterdayBalance, err := s.calculateBalance(ctx, chatID, time.Time{}, yesterdayStart, currency)
+		if err != nil {
+			return "", err
+		}
+
+		// 查询今日明细
+		todayRecords, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, todayStart, todayEnd, currency)
+		if err != nil {
+			logStorageError(err, "Failed to query %s records: %v", currency, err)
+			return "", wrapStorageError("查询失败", err)
+		}
+
+		if !containsCurrency(allowed, currency) && yesterdayBalance == 0 && len(todayRecords) == 0 {
+			continue
+		}
+
+		reports = append(reports, currencyReport{
+			Currency:         currency,
+			YesterdayBalance: yesterdayBalance,
+			TodayRecords:     todayRecords,
+			Balance:          yesterdayBalance + s.sumRecords(todayRecords),
+		})
 	}
 
-	cnyYesterdayBalance, err := s.calculateBalance(ctx, chatID, time.Time{}, yesterdayStart, models.CurrencyCNY)
-	if err != nil {
-		return "", err
+	return s.formatAccountingReport(now, settings.CurrencySymbols, reports), nil
+}
+
+// QueryRecordsByRange 查询并格式化 [start, end) 区间的账单，期初结余为 start 之前的历史累计
+// 区间跨多天时明细附带日期；币种展示规则与 QueryRecords 一致
+func (s *AccountingServiceImpl) QueryRecordsByRange(ctx context.Context, chatID int64, start, end time.Time) (string, error) {
+	settings := s.loadGroupSettings(ctx, chatID)
+	allowed := models.AllowedAccountingCurrencies(settings)
+
+	var reports []currencyReport
+	for _, currency := range models.SupportedCurrencies {
+		openingBalance, err := s.calculateBalance(ctx, chatID, time.Time{}, start, currency)
+		if err != nil {
+			return "", err
+		}
+
+		records, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, start, end, currency)
+		if err != nil {
+			logStorageError(err, "Failed to query %s records: %v", currency, err)
+			return "", wrapStorageError("查询失败", err)
+		}
+
+		if !containsCurrency(allowed, currency) && openingBalance == 0 && len(records) == 0 {
+			continue
+		}
+
+		reports = append(reports, currencyReport{
+			Currency:         currency,
+			YesterdayBalance: openingBalance,
+			TodayRecords:     records,
+			Balance:          openingBalance + s.sumRecords(records),
+		})
 	}
 
-	// 查询今日明细
-	usdTodayRecords, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, todayStart, todayEnd, models.CurrencyUSD)
-	if err != nil {
-		logger.L().Errorf("Failed to query USD records: %v", err)
-		return "", fmt.Errorf("查询失败")
+	return s.formatAccountingRangeReport(start, end, settings.CurrencySymbols, reports), nil
+}
+
+// formatAccountingRangeReport 格式化区间账单报告
+func (s *AccountingServiceImpl) formatAccountingRangeReport(start, end time.Time, symbols map[string]string, reports []currencyReport) string {
+	var sb strings.Builder
+
+	last := end.AddDate(0, 0, -1)
+	multiDay := last.After(start)
+	if multiDay {
+		sb.WriteString(fmt.Sprintf("📊 账单 - %s ~ %s\n", start.Format("2006-01-02"), last.Format("2006-01-02")))
+	} else {
+		sb.WriteString(fmt.Sprintf("📊 账单 - %s\n", start.Format("2006-01-02")))
 	}
 
-	cnyTodayRecords, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, todayStart, todayEnd, models.CurrencyCNY)
-	if err != nil {
-		logger.L().Errorf("Failed to query CNY records: %v", err)
-		return "", fmt.Errorf("查询失败")
+	timeLayout := "15:04"
+	if multiDay {
+		timeLayout = "01-02 15:04"
 	}
+	for _, report := range reports {
+		title, ok := currencyReportTitles[report.Currency]
+		if !ok {
+			title = report.Currency
+		}
 
-	// 计算今日总额
-	usdTodayTotal := s.sumRecords(usdTodayRecords)
-	cnyTodayTotal := s.sumRecords(cnyTodayRecords)
+		sb.WriteString("\n")
+		sb.WriteString(title + "\n")
+		sb.WriteString(fmt.Sprintf("期初结余: %s\n", formatReportAmount(report.YesterdayBalance, report.Currency, symbols)))
+		if len(report.TodayRecords) > 0 {
+			sb.WriteString("明细:\n")
+			for _, r := range report.TodayRecords {
+				sb.WriteString(fmt.Sprintf("  %s %s\n", r.RecordedAt.In(start.Location()).Format(timeLayout), formatReportAmount(r.Amount, r.Currency, symbols)))
+			}
+		} else {
+			sb.WriteString("明细: 无\n")
+		}
+		sb.WriteString(fmt.Sprintf("期末结余: <b>%s</b>\n", formatReportAmount(report.Balance, report.Currency, symbols)))
+	}
 
-	// 计算总余额
-	usdBalance := usdYesterdayBalance + usdTodayTotal
-	cnyBalance := cnyYesterdayBalance + cnyTodayTotal
+	return sb.String()
+}
 
-	// 格式化输出
-	return s.formatAccountingReport(now, usdYesterdayBalance, usdTodayRecords, usdBalance, cnyYesterdayBalance, cnyTodayRecords, cnyBalance), nil
+// loadGroupSettings 读取群组配置，失败时返回空配置（使用默认币种与符号）
+func (s *AccountingServiceImpl) loadGroupSettings(ctx context.Context, chatID int64) models.GroupSettings {
+	if s.groupRepo == nil {
+		return models.GroupSettings{}
+	}
+	group, err := s.groupRepo.GetByTelegramID(ctx, chatID)
+	if err != nil || group == nil {
+		return models.GroupSettings{}
+	}
+	return group.Settings
 }
 
 // calculateBalance 计算余额
 func (s *AccountingServiceImpl) calculateBalance(ctx context.Context, chatID int64, startTime, endTime time.Time, currency string) (float64, error) {
 	records, err := s.accountingRepo.GetRecordsByDateRange(ctx, chatID, startTime, endTime, currency)
 	if err != nil {
-		return 0, err
+		logStorageError(err, "Failed to calculate %s balance: %v", currency, err)
+		return 0, wrapStorageError("查询失败", err)
 	}
 	return s.sumRecords(records), nil
 }
@@ -178,46 +341,32 @@ func (s *AccountingServiceImpl) sumRecords(records []*models.AccountingRecord) f
 	return sum
 }
 
-// formatAccountingReport 格式化账单报告
-func (s *AccountingServiceImpl) formatAccountingReport(
-	now time.Time,
-	usdYesterdayBalance float64,
-	usdTodayRecords []*models.AccountingRecord,
-	usdBalance float64,
-	cnyYesterdayBalance float64,
-	cnyTodayRecords []*models.AccountingRecord,
-	cnyBalance float64,
-) string {
+// formatAccountingReport 格式化账单报告，按币种分段小计
+func (s *AccountingServiceImpl) formatAccountingReport(now time.Time, symbols map[string]string, reports []currencyReport) string {
 	var sb strings.Builder
 
 	// 标题
-	sb.WriteString(fmt.Sprintf("📊 账单 - %s\n\n", now.Format("2006-01-02")))
-
-	// USDT 部分
-	sb.WriteString("💵 USDT\n")
-	sb.WriteString(fmt.Sprintf("昨日结余: %s\n", formatAmount(usdYesterdayBalance)))
-	if len(usdTodayRecords) > 0 {
-		sb.WriteString("今日明细:\n")
-		for _, r := range usdTodayRecords {
-			sb.WriteString(fmt.Sprintf("  %s %s\n", r.RecordedAt.Format("15:04"), formatAmount(r.Amount)))
+	sb.WriteString(fmt.Sprintf("📊 账单 - %s\n", now.Format("2006-01-02")))
+
+	for _, report := range reports {
+		title, ok := currencyReportTitles[report.Currency]
+		if !ok {
+			title = report.Currency
 		}
-	} else {
-		sb.WriteString("今日明细: 无\n")
-	}
-	sb.WriteString(fmt.Sprintf("总余额: <b>%s</b>\n\n", formatAmount(usdBalance)))
 
-	// CNY 部分
-	sb.WriteString("💴 CNY\n")
-	sb.WriteString(fmt.Sprintf("昨日结余: %s\n", formatAmount(cnyYesterdayBalance)))
-	if len(cnyTodayRecords) > 0 {
-		sb.WriteString("今日明细:\n")
-		for _, r := range cnyTodayRecords {
-			sb.WriteString(fmt.Sprintf("  %s %s\n", r.RecordedAt.Format("15:04"), formatAmount(r.Amount)))
+		sb.WriteString("\n")
+		sb.WriteString(title + "\n")
+		sb.WriteString(fmt.Sprintf("昨日结余: %s\n", formatReportAmount(report.YesterdayBalance, report.Currency, symbols)))
+		if len(report.TodayRecords) > 0 {
+			sb.WriteString("今日明细:\n")
+			for _, r := range report.TodayRecords {
+				sb.WriteString(fmt.Sprintf("  %s %s\n", r.RecordedAt.Format("15:04"), formatReportAmount(r.Amount, r.Currency, symbols)))
+			}
+		} else {
+			sb.WriteString("今日明细: 无\n")
 		}
-	} else {
-		sb.WriteString("今日明细: 无\n")
+		sb.WriteString(fmt.Sprintf("总余额: <b>%s</b>\n", formatReportAmount(report.Balance, report.Currency, symbols)))
 	}
-	sb.WriteString(fmt.Sprintf("总余额: <b>%s</b>\n", formatAmount(cnyBalance)))
 
 	return sb.String()
 }
@@ -238,12 +387,20 @@ func formatAmount(amount float64) string {
 	return fmt.Sprintf("%.2f", amount)
 }
 
+// formatReportAmount 格式化账单金额，仅在群组自定义了该币种符号时附加符号，保持默认账单样式不变
+func formatReportAmount(amount float64, currency string, symbols map[string]string) string {
+	if strings.TrimSpace(symbols[currency]) == "" {
+		return formatAmount(amount)
+	}
+	return models.FormatCurrencyAmount(formatAmount(amount), models.CurrencySymbol(currency, symbols))
+}
+
 // GetRecentRecordsForDeletion 获取最近2天记录（用于删除界面）
 func (s *AccountingServiceImpl) GetRecentRecordsForDeletion(ctx context.Context, chatID int64) ([]*models.AccountingRecord, error) {
 	records, err := s.accountingRepo.GetRecentRecords(ctx, chatID, 2)
 	if err != nil {
-		logger.L().Errorf("Failed to get recent records: %v", err)
-		return nil, fmt.Errorf("查询失败")
+		logStorageError(err, "Failed to get recent records: %v", err)
+		return nil, wrapStorageError("查询失败", err)
 	}
 	return records, nil
 }
@@ -251,8 +408,8 @@ func (s *AccountingServiceImpl) GetRecentRecordsForDeletion(ctx context.Context,
 // DeleteRecord 删除记录
 func (s *AccountingServiceImpl) DeleteRecord(ctx context.Context, recordID string) error {
 	if err := s.accountingRepo.DeleteRecord(ctx, recordID); err != nil {
-		logger.L().Errorf("Failed to delete record %s: %v", recordID, err)
-		return fmt.Errorf("删除失败")
+		logStorageError(err, "Failed to delete record %s: %v", recordID, err)
+		return wrapStorageError("删除失败", err)
 	}
 	logger.L().Infof("Accounting record %s deleted", recordID)
 	return nil
@@ -262,9 +419,20 @@ func (s *AccountingServiceImpl) DeleteRecord(ctx context.Context, recordID strin
 func (s *AccountingServiceImpl) ClearAllRecords(ctx context.Context, chatID int64) (int64, error) {
 	count, err := s.accountingRepo.DeleteAllByChatID(ctx, chatID)
 	if err != nil {
-		logger.L().Errorf("Failed to clear all records for chat %d: %v", chatID, err)
-		return 0, fmt.Errorf("清空失败")
+		logStorageError(err, "Failed to clear all records for chat %d: %v", chatID, err)
+		return 0, wrapStorageError("清空失败", err)
 	}
 	logger.L().Infof("Cleared %d accounting records for chat %d", count, chatID)
 	return count, nil
 }
+
+// ClearRecordsBefore 清除 before 之前的记录
+func (s *AccountingServiceImpl) ClearRecordsBefore(ctx context.Context, chatID int64, before time.Time) (int64, error) {
+	count, err := s.accountingRepo.DeleteBefore(ctx, chatID, before)
+	if err != nil {
+		logStorageError(err, "Failed to clear records before %s for chat %d: %v", before.Format(time.RFC3339), chatID, err)
+		return 0, wrapStorageError("清空失败", err)
+	}
+	logger.L().Infof("Cleared %d accounting records before %s for chat %d", count, before.Format(time.RFC3339), chatID)
+	return count, nil
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+type memoryAccountingRepository struct {
+	records  []*models.AccountingRecord
+	queryErr error
+}
+
+func (r *memoryAccountingRepository) CreateRecord(ctx context.Context, record *models.AccountingRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *memoryAccountingRepository) GetRecordsByDateRange(ctx context.Context, chatID int64, startTime, endTime time.Time, currency string) ([]*models.AccountingRecord, error) {
+	if r.queryErr != nil {
+		return nil, r.queryErr
+	}
+	var result []*models.AccountingRecord
+	for _, record := range r.records {
+		if record.ChatID != chatID || (currency != "" && record.Currency != currency) {
+			continue
+		}
+		if record.RecordedAt.Before(startTime) || !record.RecordedAt.Before(endTime) {
+			continue
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+func (r *memoryAccountingRepository) GetRecentRecords(ctx context.Context, chatID int64, days int) ([]*models.AccountingRecord, error) {
+	return r.records, nil
+}
+
+func (r *memoryAccountingRepository) GetLastRecord(ctx context.Context, chatID int64) (*models.AccountingRecord, error) {
+	var last *models.AccountingRecord
+	for _, record := range r.records {
+		if record.ChatID == chatID && (last == nil || record.RecordedAt.After(last.RecordedAt)) {
+			last = record
+		}
+	}
+	return last, nil
+}
+
+func (r *memoryAccountingRepository) DeleteRecord(ctx context.Context, recordID string) error {
+	return nil
+}
+
+func (r *memoryAccountingRepository) DeleteAllByChatID(ctx context.Context, chatID int64) (int64, error) {
+	count := int64(len(r.records))
+	r.records = nil
+	return count, nil
+}
+
+func (r *memoryAccountingRepository) DeleteBefore(ctx context.Context, chatID int64, before time.Time) (int64, error) {
+	kept := r.records[:0]
+	for _, record := range r.records {
+		if record.ChatID != chatID || !record.RecordedAt.Before(before) {
+			kept = append(kept, record)
+		}
+	}
+	count := int64(len(r.records) - len(kept))
+	r.records = kept
+	return count, nil
+}
+
+func (r *memoryAccountingRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func TestQueryRecordsKeepsTimeoutCause(t *testing.T) {
+	accountingRepo := &memoryAccountingRepository{queryErr: fmt.Errorf("find: %w", context.DeadlineExceeded)}
+	groupRepo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: -1001}}
+	s := NewAccountingService(accountingRepo, groupRepo)
+
+	_, err := s.QueryRecords(context.Background(), -1001)
+	if err == nil || err.Error() != "查询失败" {
+		t.Fatalf("expected 查询失败, got %v", err)
+	}
+	if !repository.IsTimeout(err) {
+		t.Fatalf("expected timeout cause to be preserved, got %v", err)
+	}
+}
+
+func TestParseInputCurrencyTokens(t *testing.T) {
+	s := &AccountingServiceImpl{}
+	cases := map[string]struct {
+		income   bool
+		expr     string
+		currency string
+	}{
+		"+100U":    {income: true, expr: "100", currency: models.CurrencyUSD},
+		"+100u":    {income: true, expr: "100", currency: models.CurrencyUSD},
+		"-50/2Y":   {income: false, expr: "50/2", currency: models.CurrencyCNY},
+		"+50e":     {income: true, expr: "50", currency: models.CurrencyEUR},
+		"出20*3E":   {income: false, expr: "20*3", currency: models.CurrencyEUR},
+		"入100*7.2": {income: true, expr: "100*7.2", currency: models.CurrencyUSD},
+	}
+	for input, want := range cases {
+		income, expr, currency, err := s.parseInput(input, models.CurrencyUSD)
+		if err != nil {
+			t.Fatalf("parseInput(%q) returned error: %v", input, err)
+		}
+		if income != want.income || expr != want.expr || currency != want.currency {
+			t.Fatalf("parseInput(%q) = (%v, %q, %q), want (%v, %q, %q)", input, income, expr, currency, want.income, want.expr, want.currency)
+		}
+	}
+
+	if _, _, _, err := s.parseInput("+100x", models.CurrencyUSD); err == nil || !strings.Contains(err.Error(), "输入格式错误") {
+		t.Fatalf("expected unknown token to be a format error, got %v", err)
+	}
+}
+
+func TestParseInputFullWidthMatchesASCII(t *testing.T) {
+	s := &AccountingServiceImpl{}
+	cases := map[string]string{
+		"＋１００＊７．２Ｕ": "+100*7.2U",
+		"－５０／２ｙ":    "-50/2y",
+		"入１００":      "入100",
+		"出２０＊３Ｅ":    "出20*3E",
+	}
+	for fullWidth, ascii := range cases {
+		wantIncome, wantExpr, wantCurrency, err := s.parseInput(ascii, models.CurrencyUSD)
+		if err != nil {
+			t.Fatalf("parseInput(%q) returned error: %v", ascii, err)
+		}
+		income, expr, currency, err := s.parseInput(fullWidth, models.CurrencyUSD)
+		if err != nil {
+			t.Fatalf("parseInput(%q) returned error: %v", fullWidth, err)
+		}
+		if income != wantIncome || expr != wantExpr || currency != wantCurrency {
+			t.Fatalf("parseInput(%q) = (%v, %q, %q), want (%v, %q, %q)", fullWidth, income, expr, currency, wantIncome, wantExpr, wantCurrency)
+		}
+	}
+}
+
+func TestAddRecordValidatesAllowedCurrencies(t *testing.T) {
+	accountingRepo := &memoryAccountingRepository{}
+	groupRepo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: -1001}}
+	s := NewAccountingService(accountingRepo, groupRepo)
+
+	err := s.AddRecord(context.Background(), -1001, 1, "+50e")
+	if err == nil || !strings.Contains(err.Error(), "EUR") {
+		t.Fatalf("expected EUR to be rejected by default, got %v", err)
+	}
+
+	groupRepo.storedGroup.Settings.AccountingCurrencies = []string{models.CurrencyUSD, models.CurrencyCNY, models.CurrencyEUR}
+	if err := s.AddRecord(context.Background(), -1001, 1, "+50e"); err != nil {
+		t.Fatalf("expected EUR to be accepted once allowed, got %v", err)
+	}
+	if len(accountingRepo.records) != 1 || accountingRepo.records[0].Currency != models.CurrencyEUR {
+		t.Fatalf("expected one EUR record, got %+v", accountingRepo.records)
+	}
+}
+
+func TestAddRecordUsesGroupDefaultCurrency(t *testing.T) {
+	accountingRepo := &memoryAccountingRepository{}
+	groupRepo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: -1001}}
+	s := NewAccountingService(accountingRepo, groupRepo)
+	ctx := context.Background()
+
+	if err := s.AddRecord(ctx, -1001, 1, "+100"); err != nil {
+		t.Fatalf("AddRecord bare amount without default failed: %v", err)
+	}
+
+	groupRepo.storedGroup.Settings.AccountingDefaultCurrency = models.CurrencyCNY
+	for _, input := range []string{"+100", "入50", "-20*2", "+100u", "出30U"} {
+		if err := s.AddRecord(ctx, -1001, 1, input); err != nil {
+			t.Fatalf("AddRecord(%q) failed: %v", input, err)
+		}
+	}
+
+	want := []string{models.CurrencyUSD, models.CurrencyCNY, models.CurrencyCNY, models.CurrencyCNY, models.CurrencyUSD, models.CurrencyUSD}
+	if len(accountingRepo.records) != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), len(accountingRepo.records))
+	}
+	for i, currency := range want {
+		if got := accountingRepo.records[i].Currency; got != currency {
+			t.Fatalf("record %d (%s): currency = %s, want %s", i, accountingRepo.records[i].OriginalExpr, got, currency)
+		}
+	}
+}
+
+func TestIsDuplicateRecordWindow(t *testing.T) {
+	now := time.Now()
+	last := &models.AccountingRecord{UserID: 7, Amount: 720, Currency: models.CurrencyUSD, OriginalExpr: "100*7.2", RecordedAt: now}
+	next := func(mutate func(r *models.AccountingRecord)) *models.AccountingRecord {
+		record := *last
+		record.RecordedAt = now.Add(3 * time.Second)
+		if mutate != nil {
+			mutate(&record)
+		}
+		return &record
+	}
+
+	cases := map[string]struct {
+		record *models.AccountingRecord
+		want   bool
+	}{
+		"within window":   {record: next(nil), want: true},
+		"window boundary": {record: next(func(r *models.AccountingRecord) { r.RecordedAt = now.Add(accountingDuplicateWindow) }), want: true},
+		"after window":    {record: next(func(r *models.AccountingRecord) { r.RecordedAt = now.Add(accountingDuplicateWindow + time.Second) }), want: false},
+		"other user":      {record: next(func(r *models.AccountingRecord) { r.UserID = 8 }), want: false},
+		"other expr":      {record: next(func(r *models.AccountingRecord) { r.OriginalExpr = "720" }), want: false},
+		"other currency":  {record: next(func(r *models.AccountingRecord) { r.Currency = models.CurrencyCNY }), want: false},
+		"other sign":      {record: next(func(r *models.AccountingRecord) { r.Amount = -720 }), want: false},
+	}
+	for name, tc := range cases {
+		if got := isDuplicateRecord(last, tc.record, accountingDuplicateWindow); got != tc.want {
+			t.Fatalf("%s: isDuplicateRecord = %v, want %v", name, got, tc.want)
+		}
+	}
+	if isDuplicateRecord(nil, next(nil), accountingDuplicateWindow) {
+		t.Fatalf("expected no duplicate without previous record")
+	}
+}
+
+func TestIsDuplicateRecordUsesLastRecord(t *testing.T) {
+	repo := &memoryAccountingRepository{}
+	svc := &AccountingServiceImpl{accountingRepo: repo}
+	ctx := context.Background()
+
+	if dup, err := svc.IsDuplicateRecord(ctx, -1001, 7, "+100*7.2U"); err != nil || dup {
+		t.Fatalf("expected no duplicate on empty chat, got dup=%v err=%v", dup, err)
+	}
+	if err := svc.AddRecord(ctx, -1001, 7, "+100*7.2U"); err != nil {
+		t.Fatalf("AddRecord failed: %v", err)
+	}
+	if dup, err := svc.IsDuplicateRecord(ctx, -1001, 7, "+100*7.2U"); err != nil || !dup {
+		t.Fatalf("expected duplicate right after identical record, got dup=%v err=%v", dup, err)
+	}
+	if dup, _ := svc.IsDuplicateRecord(ctx, -1001, 7, "-100*7.2U"); dup {
+		t.Fatalf("expected opposite direction not to be a duplicate")
+	}
+	if dup, _ := svc.IsDuplicateRecord(ctx, -1002, 7, "+100*7.2U"); dup {
+		t.Fatalf("expected other chat not to be a duplicate")
+	}
+	if _, err := svc.IsDuplicateRecord(ctx, -1001, 7, "hello"); err == nil {
+		t.Fatalf("expected format error for non-accounting input")
+	}
+}
+
+func TestQueryRecordsSubtotalsThreeCurrencies(t *testing.T) {
+	now := time.Now()
+	yesterday := now.Add(-48 * time.Hour)
+	accountingRepo := &memoryAccountingRepository{records: []*models.AccountingRecord{
+		{ChatID: -1001, Amount: 10, Currency: models.CurrencyUSD, RecordedAt: yesterday},
+		{ChatID: -1001, Amount: 100, Currency: models.CurrencyUSD, RecordedAt: now},
+		{ChatID: -1001, Amount: -30, Currency: models.CurrencyCNY, RecordedAt: now},
+		{ChatID: -1001, Amount: 50, Currency: models.CurrencyEUR, RecordedAt: now},
+		{ChatID: -1001, Amount: 25, Currency: models.CurrencyEUR, RecordedAt: now},
+	}}
+	groupRepo := &stubGroupRepository{storedGroup: &models.Group{
+		TelegramID: -1001,
+		Settings: models.GroupSettings{
+			AccountingCurrencies: []string{models.CurrencyUSD, models.CurrencyCNY, models.CurrencyEUR},
+		},
+	}}
+	s := NewAccountingService(accountingRepo, groupRepo)
+
+	report, err := s.QueryRecords(context.Background(), -1001)
+	if err != nil {
+		t.Fatalf("QueryRecords returned error: %v", err)
+	}
+
+	sections := strings.Split(report, "\n\n")
+	if len(sections) != 4 {
+		t.Fatalf("expected title and three currency sections, got %d:\n%s", len(sections), report)
+	}
+	expectations := []struct {
+		title   string
+		balance string
+	}{
+		{title: "💵 USDT", balance: "总余额: <b>+110</b>"},
+		{title: "💴 CNY", balance: "总余额: <b>-30</b>"},
+		{title: "💶 EUR", balance: "总余额: <b>+75</b>"},
+	}
+	for i, want := range expectations {
+		section := sections[i+1]
+		if !strings.HasPrefix(section, want.title) || !strings.Contains(section, want.balance) {
+			t.Fatalf("unexpected section %d, want %q with %q:\n%s", i, want.title, want.balance, section)
+		}
+	}
+}
+
+func TestQueryRecordsByRangeScopesRecords(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	day := func(d, hour int) time.Time { return time.Date(2024, 10, d, hour, 0, 0, 0, loc) }
+	accountingRepo := &memoryAccountingRepository{records: []*models.AccountingRecord{
+		{ChatID: -1001, Amount: 100, Currency: models.CurrencyUSD, RecordedAt: day(24, 10)},
+		{ChatID: -1001, Amount: 20, Currency: models.CurrencyUSD, RecordedAt: day(25, 9)},
+		{ChatID: -1001, Amount: -5, Currency: models.CurrencyUSD, RecordedAt: day(26, 14)},
+		{ChatID: -1001, Amount: 999, Currency: models.CurrencyUSD, RecordedAt: day(27, 8)},
+	}}
+	s := NewAccountingService(accountingRepo, &stubGroupRepository{storedGroup: &models.Group{TelegramID: -1001}})
+
+	report, err := s.QueryRecordsByRange(context.Background(), -1001, day(25, 0), day(27, 0))
+	if err != nil {
+		t.Fatalf("QueryRecordsByRange returned error: %v", err)
+	}
+	for _, want := range []string{
+		"📊 账单 - 2024-10-25 ~ 2024-10-26",
+		"期初结余: +100",
+		"10-25 09:00 +20",
+		"10-26 14:00 -5",
+		"期末结余: <b>+115</b>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected report to contain %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "999") {
+		t.Fatalf("expected records after range to be excluded:\n%s", report)
+	}
+
+	single, err := s.QueryRecordsByRange(context.Background(), -1001, day(26, 0), day(27, 0))
+	if err != nil {
+		t.Fatalf("QueryRecordsByRange returned error: %v", err)
+	}
+	if !strings.HasPrefix(single, "📊 账单 - 2024-10-26\n") || !strings.Contains(single, "  14:00 -5") {
+		t.Fatalf("unexpected single day report:\n%s", single)
+	}
+}
+
+func TestQueryRecordsDefaultCurrenciesOnly(t *testing.T) {
+	s := NewAccountingService(&memoryAccountingRepository{}, &stubGroupRepository{storedGroup: &models.Group{TelegramID: -1001}})
+
+	report, err := s.QueryRecords(context.Background(), -1001)
+	if err != nil {
+		t.Fatalf("QueryRecords returned error: %v", err)
+	}
+	if strings.Contains(report, "EUR") || !strings.Contains(report, "💵 USDT") || !strings.Contains(report, "💴 CNY") {
+		t.Fatalf("expected only default currencies in report:\n%s", report)
+	}
+}
+
+func TestFormatAccountingReportCurrencySymbols(t *testing.T) {
+	s := &AccountingServiceImpl{}
+	now := time.Date(2024, 10, 26, 12, 0, 0, 0, time.UTC)
+	reports := []currencyReport{
+		{
+			Currency:     models.CurrencyUSD,
+			TodayRecords: []*models.AccountingRecord{{Amount: 100, Currency: models.CurrencyUSD, RecordedAt: now}},
+			Balance:      100,
+		},
+		{
+			Currency:     models.CurrencyCNY,
+			TodayRecords: []*models.AccountingRecord{{Amount: -50, Currency: models.CurrencyCNY, RecordedAt: now}},
+			Balance:      -50,
+		},
+	}
+
+	defaultReport := s.formatAccountingReport(now, nil, reports)
+	if !strings.Contains(defaultReport, "12:00 +100\n") || !strings.Contains(defaultReport, "总余额: <b>-50</b>") {
+		t.Fatalf("expected default report without symbols:\n%s", defaultReport)
+	}
+
+	symbols := map[string]string{models.CurrencyUSD: "$", models.CurrencyCNY: "¥"}
+	customReport := s.formatAccountingReport(now, symbols, reports)
+	for _, want := range []string{"12:00 +$100", "12:00 -¥50", "总余额: <b>+$100</b>", "总余额: <b>-¥50</b>"} {
+		if !strings.Contains(customReport, want) {
+			t.Fatalf("expected %q in custom report:\n%s", want, customReport)
+		}
+	}
+
+	if reports[0].TodayRecords[0].Amount != 100 || reports[1].TodayRecords[0].Currency != models.CurrencyCNY {
+		t.Fatalf("formatting must not alter stored records")
+	}
+}
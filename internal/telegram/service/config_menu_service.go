@@ -378,6 +378,20 @@ func (s *ConfigMenuService) ClearUserState(chatID, userID int64) {
 	s.userStates.Delete(key)
 }
 
+// PurgeExpiredUserStates 清理已过期的配置输入状态，返回清理数量
+func (s *ConfigMenuService) PurgeExpiredUserStates(now time.Time) int {
+	purged := 0
+	s.userStates.Range(func(key, value any) bool {
+		state, ok := value.(*models.UserState)
+		if !ok || now.Unix() > state.ExpiresAt {
+			s.userStates.Delete(key)
+			purged++
+		}
+		return true
+	})
+	return purged
+}
+
 // findItemByID 根据 ID 查找配置项
 func findItemByID(items []models.ConfigItem, id string) *models.ConfigItem {
 	for i := range items {
@@ -42,6 +42,10 @@ func (s *stubGroupService) UpdateGroupSettings(ctx context.Context, telegramID i
 	return nil
 }
 
+func (s *stubGroupService) SetGroupTier(ctx context.Context, telegramID int64, tier models.GroupTier) ([]string, error) {
+	return nil, nil
+}
+
 func (s *stubGroupService) LeaveGroup(ctx context.Context, telegramID int64) error {
 	return nil
 }
@@ -62,6 +66,10 @@ func (s *stubGroupService) RepairGroups(ctx context.Context) (*GroupRepairResult
 	return &GroupRepairResult{}, nil
 }
 
+func (s *stubGroupService) CheckBindings(ctx context.Context) (*BindingCheckResult, error) {
+	return &BindingCheckResult{}, nil
+}
+
 func TestConfigMenuServiceHandleToggle_DisabledWhenSifangOff(t *testing.T) {
 	svc := NewConfigMenuService(&stubGroupService{})
 	group := &models.Group{Settings: models.GroupSettings{
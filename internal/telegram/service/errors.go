@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGroupNotFound 表示群组记录不存在
+var ErrGroupNotFound = errors.New("群组不存在")
+
+// ValidationError 表示参数或群组状态不满足要求，文案可直接展示给调用方
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError 按 format 生成校验错误
+func newValidationError(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
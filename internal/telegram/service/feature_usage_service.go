@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+// FeatureUsageServiceImpl 功能使用统计服务实现
+type FeatureUsageServiceImpl struct {
+	repo repository.FeatureUsageRepository
+}
+
+// NewFeatureUsageService 创建功能使用统计服务
+func NewFeatureUsageService(repo repository.FeatureUsageRepository) FeatureUsageService {
+	return &FeatureUsageServiceImpl{repo: repo}
+}
+
+// Record 记录一次功能使用
+func (s *FeatureUsageServiceImpl) Record(ctx context.Context, groupID int64, feature string) {
+	if err := s.repo.Increment(ctx, groupID, feature); err != nil {
+		logger.L().Warnf("Failed to record feature usage: chat_id=%d feature=%s err=%v", groupID, feature, err)
+	}
+}
+
+// Top 查询使用最多的功能
+func (s *FeatureUsageServiceImpl) Top(ctx context.Context, groupID int64, limit int64) ([]*models.FeatureUsage, error) {
+	return s.repo.ListTop(ctx, groupID, limit)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+)
+
+// FundRejectReason 资金操作被拒绝的原因
+type FundRejectReason string
+
+const (
+	FundRejectCheckFailed FundRejectReason = "check_failed" // 权限查询失败
+	FundRejectNotAdmin    FundRejectReason = "not_admin"    // 操作人不是管理员 / Owner
+	FundRejectUnbound     FundRejectReason = "unbound"      // 群组未完成绑定
+)
+
+// FundOperationError 资金操作前置校验失败，Error 返回可直接展示给用户的提示
+type FundOperationError struct {
+	Reason  FundRejectReason
+	Message string
+}
+
+func (e *FundOperationError) Error() string {
+	return e.Message
+}
+
+// CheckFundOperation 资金操作（下发、余额调整等）的统一前置校验：
+// 操作人须为管理员或 Owner，且群组已完成绑定（商户群绑定商户号，上游群绑定接口）
+func CheckFundOperation(ctx context.Context, users UserService, userID int64, group *models.Group) error {
+	if users == nil {
+		logger.L().Error("Fund operation check: user service is nil")
+		return &FundOperationError{Reason: FundRejectCheckFailed, Message: "未配置管理员校验服务，请联系管理员"}
+	}
+
+	isAdmin, err := users.CheckAdminPermission(ctx, userID)
+	if err != nil {
+		logger.L().Errorf("Fund operation admin check failed: user_id=%d, err=%v", userID, err)
+		return &FundOperationError{Reason: FundRejectCheckFailed, Message: "权限检查失败，请稍后重试"}
+	}
+	if !isAdmin {
+		return &FundOperationError{Reason: FundRejectNotAdmin, Message: "仅管理员可以执行资金操作"}
+	}
+
+	if group == nil {
+		return &FundOperationError{Reason: FundRejectUnbound, Message: "未找到群组配置，无法执行资金操作"}
+	}
+	switch bindingCheckTier(group) {
+	case models.GroupTierMerchant:
+		if group.Settings.MerchantID > 0 {
+			return nil
+		}
+		return &FundOperationError{Reason: FundRejectUnbound, Message: "当前群组未绑定商户号，请先使用「绑定 [商户号]」命令"}
+	case models.GroupTierUpstream:
+		if len(models.NormalizeInterfaceBindings(group.Settings.InterfaceBindings)) > 0 {
+			return nil
+		}
+		return &FundOperationError{Reason: FundRejectUnbound, Message: "当前上游群未绑定接口，请先绑定接口"}
+	default:
+		return &FundOperationError{Reason: FundRejectUnbound, Message: "当前群组未绑定商户号或接口，无法执行资金操作"}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+)
+
+type fundGuardUserService struct {
+	UserService
+	isAdmin bool
+	err     error
+}
+
+func (s *fundGuardUserService) CheckAdminPermission(ctx context.Context, telegramID int64) (bool, error) {
+	return s.isAdmin, s.err
+}
+
+func TestCheckFundOperation(t *testing.T) {
+	merchantGroup := &models.Group{Tier: models.GroupTierMerchant, Settings: models.GroupSettings{MerchantID: 2024001}}
+	upstreamGroup := &models.Group{Tier: models.GroupTierUpstream, Settings: models.GroupSettings{
+		InterfaceBindings: []models.InterfaceBinding{{Name: "支付宝", ID: "IF-1"}},
+	}}
+
+	tests := []struct {
+		name   string
+		users  UserService
+		group  *models.Group
+		reason FundRejectReason
+	}{
+		{name: "admin in bound merchant group", users: &fundGuardUserService{isAdmin: true}, group: merchantGroup},
+		{name: "admin in bound upstream group", users: &fundGuardUserService{isAdmin: true}, group: upstreamGroup},
+		{name: "not admin", users: &fundGuardUserService{isAdmin: false}, group: merchantGroup, reason: FundRejectNotAdmin},
+		{name: "permission lookup failed", users: &fundGuardUserService{err: errors.New("boom")}, group: merchantGroup, reason: FundRejectCheckFailed},
+		{name: "missing user service", users: nil, group: merchantGroup, reason: FundRejectCheckFailed},
+		{name: "basic group unbound", users: &fundGuardUserService{isAdmin: true}, group: &models.Group{Tier: models.GroupTierBasic}, reason: FundRejectUnbound},
+		{name: "upstream without interfaces", users: &fundGuardUserService{isAdmin: true}, group: &models.Group{Tier: models.GroupTierUpstream}, reason: FundRejectUnbound},
+		{name: "merchant tier without merchant id", users: &fundGuardUserService{isAdmin: true}, group: &models.Group{Tier: models.GroupTierMerchant}, reason: FundRejectUnbound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckFundOperation(context.Background(), tt.users, 1, tt.group)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("expected success, got %v", err)
+				}
+				return
+			}
+
+			var fundErr *FundOperationError
+			if !errors.As(err, &fundErr) {
+				t.Fatalf("expected FundOperationError, got %v", err)
+			}
+			if fundErr.Reason != tt.reason {
+				t.Fatalf("expected reason %s, got %s (%s)", tt.reason, fundErr.Reason, fundErr.Message)
+			}
+			if fundErr.Message == "" {
+				t.Fatalf("expected user-facing message")
+			}
+		})
+	}
+}
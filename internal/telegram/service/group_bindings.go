@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+)
+
+// BindingIssueKind 绑定问题类型
+type BindingIssueKind string
+
+const (
+	BindingIssueMissingMerchant    BindingIssueKind = "missing_merchant"    // 商户群未绑定有效商户号
+	BindingIssueMissingInterface   BindingIssueKind = "missing_interface"   // 上游群没有任何接口绑定
+	BindingIssueOrphanInterface    BindingIssueKind = "orphan_interface"    // 接口无法按 ID 反查回所在上游群
+	BindingIssueDuplicateInterface BindingIssueKind = "duplicate_interface" // 同一接口被多个群绑定
+)
+
+// BindingCheckResult 保存商户号 / 接口绑定健康检查结果
+type BindingCheckResult struct {
+	TotalGroups    int            // 扫描的群组总数
+	MerchantGroups int            // 商户群数量
+	UpstreamGroups int            // 上游群数量
+	Issues         []BindingIssue // 发现的问题
+}
+
+// BindingIssue 描述单个绑定问题
+type BindingIssue struct {
+	Kind        BindingIssueKind
+	GroupID     int64
+	Title       string
+	InterfaceID string  // 接口相关问题时填写
+	GroupIDs    []int64 // 重复绑定时的全部群组 ID（升序）
+	Detail      string
+}
+
+// CheckBindings 扫描所有群组，校验商户群商户号与上游群接口绑定是否能被唯一解析
+func (s *GroupServiceImpl) CheckBindings(ctx context.Context) (*BindingCheckResult, error) {
+	groups, err := s.groupRepo.ListAllGroups(ctx)
+	if err != nil {
+		logStorageError(err, "Failed to list groups for binding check: %v", err)
+		return nil, wrapStorageError("获取群组列表失败", err)
+	}
+
+	result := &BindingCheckResult{}
+	owners := make(map[string][]*models.Group)
+	var interfaceIDs []string
+	displayIDs := make(map[string]string)
+
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		result.TotalGroups++
+
+		tier := bindingCheckTier(group)
+		bindings := models.NormalizeInterfaceBindings(group.Settings.InterfaceBindings)
+		switch tier {
+		case models.GroupTierMerchant:
+			result.MerchantGroups++
+			if group.Settings.MerchantID <= 0 {
+				result.Issues = append(result.Issues, newBindingIssue(BindingIssueMissingMerchant, group, "", "商户群未绑定商户号"))
+			}
+		case models.GroupTierUpstream:
+			result.UpstreamGroups++
+			if len(bindings) == 0 {
+				result.Issues = append(result.Issues, newBindingIssue(BindingIssueMissingInterface, group, "", "上游群没有任何接口绑定"))
+			}
+		}
+
+		for _, binding := range bindings {
+			key := strings.ToLower(binding.ID)
+			if _, ok := owners[key]; !ok {
+				interfaceIDs = append(interfaceIDs, key)
+				displayIDs[key] = binding.ID
+			}
+			owners[key] = append(owners[key], group)
+		}
+	}
+
+	for _, key := range interfaceIDs {
+		bound := owners[key]
+		interfaceID := displayIDs[key]
+		if len(bound) > 1 {
+			issue := newBindingIssue(BindingIssueDuplicateInterface, bound[0], interfaceID, "")
+			for _, group := range bound {
+				issue.GroupIDs = append(issue.GroupIDs, group.TelegramID)
+			}
+			slices.Sort(issue.GroupIDs)
+			issue.GroupID = issue.GroupIDs[0]
+			issue.Detail = fmt.Sprintf("接口被 %d 个群重复绑定，转单只会命中其中一个", len(bound))
+			result.Issues = append(result.Issues, issue)
+			continue
+		}
+
+		owner := bound[0]
+		if tier := bindingCheckTier(owner); tier != models.GroupTierUpstream {
+			result.Issues = append(result.Issues, newBindingIssue(BindingIssueOrphanInterface, owner, interfaceID,
+				fmt.Sprintf("接口绑定在 %s 群，不是上游群", tier)))
+			continue
+		}
+
+		resolved, err := s.groupRepo.FindByInterfaceID(ctx, interfaceID)
+		if err != nil {
+			logStorageError(err, "Binding check failed to resolve interface %s: %v", interfaceID, err)
+			return nil, wrapStorageError("查询接口绑定失败", err)
+		}
+		if resolved == nil || resolved.TelegramID != owner.TelegramID {
+			result.Issues = append(result.Issues, newBindingIssue(BindingIssueOrphanInterface, owner, interfaceID, "按接口 ID 无法反查到该群"))
+		}
+	}
+
+	slices.SortStableFunc(result.Issues, func(a, b BindingIssue) int {
+		switch {
+		case a.GroupID < b.GroupID:
+			return -1
+		case a.GroupID > b.GroupID:
+			return 1
+		default:
+			return strings.Compare(a.InterfaceID, b.InterfaceID)
+		}
+	})
+
+	logger.L().Infof("Binding check finished: total=%d merchant=%d upstream=%d issues=%d",
+		result.TotalGroups, result.MerchantGroups, result.UpstreamGroups, len(result.Issues))
+	return result, nil
+}
+
+// bindingCheckTier 优先使用存储的 tier，缺失时按配置推导
+func bindingCheckTier(group *models.Group) models.GroupTier {
+	if group.Tier != "" {
+		return group.Tier
+	}
+	tier, _ := models.DetermineGroupTier(group.Settings)
+	return tier
+}
+
+func newBindingIssue(kind BindingIssueKind, group *models.Group, interfaceID, detail string) BindingIssue {
+	title := group.Title
+	if title == "" {
+		title = "(未命名群组)"
+	}
+	return BindingIssue{
+		Kind:        kind,
+		GroupID:     group.TelegramID,
+		Title:       title,
+		InterfaceID: interfaceID,
+		Detail:      detail,
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+// defaultGroupCacheTTL 群组缓存有效期，热点群在该时间内不再重复读库
+const defaultGroupCacheTTL = 30 * time.Second
+
+// interfaceCacheTTL 接口 ID → 群组的缓存有效期（转单热点路径）
+const interfaceCacheTTL = 10 * time.Second
+
+// maxInterfaceCacheEntries 接口缓存条目上限，超出时先清理过期条目，仍满则整体清空
+const maxInterfaceCacheEntries = 1024
+
+type groupCacheEntry struct {
+	group     *models.Group
+	expiresAt time.Time
+}
+
+// groupCache 按 chat 缓存群组记录。
+// 每个 chat 维护一个版本号，写操作后递增；读库前记录版本，回填时版本已变化则放弃，
+// 避免与更新并发的旧读取把过期配置写回缓存。
+// 另按接口 ID 缓存绑定群组（仅缓存命中结果，新绑定的接口无需等待过期）；
+// 任一群组写操作都可能改变接口绑定，因此会清空全部接口缓存。
+type groupCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	entries  map[int64]groupCacheEntry
+	versions map[int64]uint64
+
+	interfaces       map[string]groupCacheEntry
+	interfaceVersion uint64
+}
+
+func newGroupCache(ttl time.Duration) *groupCache {
+	return &groupCache{
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    make(map[int64]groupCacheEntry),
+		versions:   make(map[int64]uint64),
+		interfaces: make(map[string]groupCacheEntry),
+	}
+}
+
+// get 返回未过期的缓存副本，以及当前版本号（供 set 校验）
+func (c *groupCache) get(chatID int64) (*models.Group, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	version := c.versions[chatID]
+	entry, ok := c.entries[chatID]
+	if !ok {
+		return nil, version
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, chatID)
+		return nil, version
+	}
+	return cloneGroup(entry.group), version
+}
+
+// set 回填缓存；读取期间发生过写操作时放弃回填
+func (c *groupCache) set(chatID int64, group *models.Group, version uint64) {
+	if group == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.versions[chatID] != version {
+		return
+	}
+	c.entries[chatID] = groupCacheEntry{
+		group:     cloneGroup(group),
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// invalidate 丢弃缓存并递增版本号，同时清空接口缓存
+func (c *groupCache) invalidate(chatID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, chatID)
+	c.versions[chatID]++
+	clear(c.interfaces)
+	c.interfaceVersion++
+}
+
+// getByInterface 返回接口 ID 对应的未过期缓存副本，以及当前接口版本号
+func (c *groupCache) getByInterface(interfaceID string) (*models.Group, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.ToLower(interfaceID)
+	entry, ok := c.interfaces[key]
+	if !ok {
+		return nil, c.interfaceVersion
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.interfaces, key)
+		return nil, c.interfaceVersion
+	}
+	return cloneGroup(entry.group), c.interfaceVersion
+}
+
+// setByInterface 回填接口缓存；读取期间发生过写操作或未找到群组时不回填
+func (c *groupCache) setByInterface(interfaceID string, group *models.Group, version uint64) {
+	if group == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.interfaceVersion != version {
+		return
+	}
+	now := c.now()
+	if len(c.interfaces) >= maxInterfaceCacheEntries {
+		for key, entry := range c.interfaces {
+			if !now.Before(entry.expiresAt) {
+				delete(c.interfaces, key)
+			}
+		}
+		if len(c.interfaces) >= maxInterfaceCacheEntries {
+			clear(c.interfaces)
+		}
+	}
+	c.interfaces[strings.ToLower(interfaceID)] = groupCacheEntry{
+		group:     cloneGroup(group),
+		expiresAt: now.Add(interfaceCacheTTL),
+	}
+}
+
+// cloneGroup 深拷贝群组，防止调用方修改返回值污染缓存
+func cloneGroup(group *models.Group) *models.Group {
+	if group == nil {
+		return nil
+	}
+
+	clone := *group
+	if group.BotLeftAt != nil {
+		leftAt := *group.BotLeftAt
+		clone.BotLeftAt = &leftAt
+	}
+	clone.Settings.MerchantIDs = slices.Clone(group.Settings.MerchantIDs)
+	clone.Settings.InterfaceBindings = slices.Clone(group.Settings.InterfaceBindings)
+	clone.Settings.AccountingCurrencies = slices.Clone(group.Settings.AccountingCurrencies)
+	clone.Settings.CurrencySymbols = maps.Clone(group.Settings.CurrencySymbols)
+	clone.Settings.CommandAliases = maps.Clone(group.Settings.CommandAliases)
+	return &clone
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+func newCachedGroupService(repo *stubGroupRepository) (*GroupServiceImpl, *time.Time) {
+	svc := NewGroupService(repo).(*GroupServiceImpl)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.cache.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestGroupCacheServesHotGroupWithoutRepoRead(t *testing.T) {
+	repo := &stubGroupRepository{storedGroup: &models.Group{
+		TelegramID: 1,
+		Settings:   models.GroupSettings{MerchantIDs: []int64{10}},
+	}}
+	svc, _ := newCachedGroupService(repo)
+	chatInfo := &TelegramChatInfo{ChatID: 1}
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.GetOrCreateGroup(context.Background(), chatInfo); err != nil {
+			t.Fatalf("GetOrCreateGroup returned error: %v", err)
+		}
+	}
+	group, err := svc.GetGroupInfo(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetGroupInfo returned error: %v", err)
+	}
+	if repo.getCalls != 1 {
+		t.Fatalf("expected 1 repo read, got %d", repo.getCalls)
+	}
+
+	// 修改返回值不能污染缓存
+	group.Settings.MerchantIDs[0] = 99
+	group.Settings.CalculatorEnabled = true
+	again, _ := svc.GetGroupInfo(context.Background(), 1)
+	if again.Settings.MerchantIDs[0] != 10 || again.Settings.CalculatorEnabled {
+		t.Fatalf("cached group mutated by caller: %+v", again.Settings)
+	}
+}
+
+func TestGroupCacheInvalidatedOnSettingsUpdate(t *testing.T) {
+	repo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: 1}}
+	svc, _ := newCachedGroupService(repo)
+
+	if _, err := svc.GetGroupInfo(context.Background(), 1); err != nil {
+		t.Fatalf("GetGroupInfo returned error: %v", err)
+	}
+	if err := svc.UpdateGroupSettings(context.Background(), 1, models.GroupSettings{CalculatorEnabled: true}); err != nil {
+		t.Fatalf("UpdateGroupSettings returned error: %v", err)
+	}
+
+	group, err := svc.GetGroupInfo(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetGroupInfo returned error: %v", err)
+	}
+	if !group.Settings.CalculatorEnabled {
+		t.Fatal("expected updated settings after invalidation")
+	}
+	if repo.getCalls != 2 {
+		t.Fatalf("expected repo re-read after update, got %d reads", repo.getCalls)
+	}
+
+	if err := svc.MarkBotLeft(context.Background(), 1); err != nil {
+		t.Fatalf("MarkBotLeft returned error: %v", err)
+	}
+	if _, err := svc.GetGroupInfo(context.Background(), 1); err != nil {
+		t.Fatalf("GetGroupInfo returned error: %v", err)
+	}
+	if repo.getCalls != 3 {
+		t.Fatalf("expected repo re-read after bot status update, got %d reads", repo.getCalls)
+	}
+}
+
+func TestGroupCacheExpiresAfterTTL(t *testing.T) {
+	repo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: 1}}
+	svc, now := newCachedGroupService(repo)
+
+	svc.GetGroupInfo(context.Background(), 1)
+	*now = now.Add(defaultGroupCacheTTL - time.Second)
+	svc.GetGroupInfo(context.Background(), 1)
+	if repo.getCalls != 1 {
+		t.Fatalf("expected cache hit before TTL, got %d reads", repo.getCalls)
+	}
+
+	*now = now.Add(time.Second)
+	svc.GetGroupInfo(context.Background(), 1)
+	if repo.getCalls != 2 {
+		t.Fatalf("expected repo read after TTL, got %d reads", repo.getCalls)
+	}
+}
+
+func TestGroupCacheDropsFillRacingWithUpdate(t *testing.T) {
+	cache := newGroupCache(time.Minute)
+
+	_, version := cache.get(1)
+	cache.invalidate(1) // 读库期间发生了更新
+	cache.set(1, &models.Group{TelegramID: 1}, version)
+
+	if cached, _ := cache.get(1); cached != nil {
+		t.Fatal("stale fill must not be cached")
+	}
+}
+
+func TestGroupCacheServesInterfaceLookupUntilExpiry(t *testing.T) {
+	repo := &stubGroupRepository{storedGroup: &models.Group{
+		TelegramID: 1,
+		Settings:   models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{ID: "337"}}},
+	}}
+	svc, now := newCachedGroupService(repo)
+
+	for _, id := range []string{"337", " 337 ", "337"} {
+		group, err := svc.FindGroupByInterfaceID(context.Background(), id)
+		if err != nil || group == nil || group.TelegramID != 1 {
+			t.Fatalf("FindGroupByInterfaceID(%q) = %+v, %v", id, group, err)
+		}
+	}
+	if repo.interfaceCalls != 1 {
+		t.Fatalf("expected 1 repo lookup, got %d", repo.interfaceCalls)
+	}
+
+	*now = now.Add(interfaceCacheTTL)
+	if _, err := svc.FindGroupByInterfaceID(context.Background(), "337"); err != nil {
+		t.Fatalf("FindGroupByInterfaceID returned error: %v", err)
+	}
+	if repo.interfaceCalls != 2 {
+		t.Fatalf("expected repo lookup after expiry, got %d", repo.interfaceCalls)
+	}
+}
+
+func TestGroupCacheInterfaceLookupInvalidatedOnBindingChange(t *testing.T) {
+	repo := &stubGroupRepository{storedGroup: &models.Group{
+		TelegramID: 1,
+		Settings:   models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{ID: "337"}}},
+	}}
+	svc, _ := newCachedGroupService(repo)
+	ctx := context.Background()
+
+	// 未绑定的接口不缓存，绑定后立即可解析
+	if group, _ := svc.FindGroupByInterfaceID(ctx, "338"); group != nil {
+		t.Fatalf("expected unbound interface to resolve to nil, got %+v", group)
+	}
+	if group, _ := svc.FindGroupByInterfaceID(ctx, "337"); group == nil {
+		t.Fatal("expected bound interface to resolve")
+	}
+
+	settings := models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{ID: "338"}}}
+	if err := svc.UpdateGroupSettings(ctx, 1, settings); err != nil {
+		t.Fatalf("UpdateGroupSettings returned error: %v", err)
+	}
+	calls := repo.interfaceCalls
+
+	if group, _ := svc.FindGroupByInterfaceID(ctx, "338"); group == nil || group.TelegramID != 1 {
+		t.Fatalf("expected newly bound interface to resolve, got %+v", group)
+	}
+	if group, _ := svc.FindGroupByInterfaceID(ctx, "337"); group != nil {
+		t.Fatalf("expected unbound interface to drop from cache, got %+v", group)
+	}
+	if repo.interfaceCalls != calls+2 {
+		t.Fatalf("expected repo lookups after invalidation, got %d", repo.interfaceCalls-calls)
+	}
+}
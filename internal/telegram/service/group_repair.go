@@ -57,7 +57,9 @@ func (s *GroupServiceImpl) RepairGroups(ctx context.Context) (*GroupRepairResult
 			result.AutoLookupDisabled++
 		}
 
-		if err := s.groupRepo.UpdateSettings(ctx, group.TelegramID, settings, expectedTier); err != nil {
+		err := s.groupRepo.UpdateSettings(ctx, group.TelegramID, settings, expectedTier)
+		s.cache.invalidate(group.TelegramID)
+		if err != nil {
 			logger.L().Errorf("Failed to repair group %d: %v", group.TelegramID, err)
 			result.SkippedGroups++
 			continue
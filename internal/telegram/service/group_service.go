@@ -13,17 +13,21 @@ import (
 // GroupServiceImpl 群组服务实现
 type GroupServiceImpl struct {
 	groupRepo repository.GroupRepository
+	cache     *groupCache
 }
 
 // NewGroupService 创建群组服务
 func NewGroupService(groupRepo repository.GroupRepository) GroupService {
 	return &GroupServiceImpl{
 		groupRepo: groupRepo,
+		cache:     newGroupCache(defaultGroupCacheTTL),
 	}
 }
 
 // CreateOrUpdateGroup 创建或更新群组
 func (s *GroupServiceImpl) CreateOrUpdateGroup(ctx context.Context, group *models.Group) error {
+	defer s.cache.invalidate(group.TelegramID)
+
 	if err := s.groupRepo.CreateOrUpdate(ctx, group); err != nil {
 		logger.L().Errorf("Failed to create/update group %d: %v", group.TelegramID, err)
 		return fmt.Errorf("failed to create/update group: %w", err)
@@ -35,21 +39,33 @@ func (s *GroupServiceImpl) CreateOrUpdateGroup(ctx context.Context, group *model
 
 // GetGroupInfo 获取群组信息
 func (s *GroupServiceImpl) GetGroupInfo(ctx context.Context, telegramID int64) (*models.Group, error) {
+	cached, version := s.cache.get(telegramID)
+	if cached != nil {
+		return cached, nil
+	}
+
 	group, err := s.groupRepo.GetByTelegramID(ctx, telegramID)
 	if err != nil {
-		logger.L().Errorf("Failed to get group info for %d: %v", telegramID, err)
-		return nil, fmt.Errorf("获取群组信息失败")
+		logStorageError(err, "Failed to get group info for %d: %v", telegramID, err)
+		return nil, wrapStorageError("获取群组信息失败", err)
 	}
 	ensureGroupTier(group)
+	s.cache.set(telegramID, group, version)
 	return group, nil
 }
 
 // GetOrCreateGroup 获取或创建群组记录（智能处理，群组不存在时自动创建）
 func (s *GroupServiceImpl) GetOrCreateGroup(ctx context.Context, chatInfo *TelegramChatInfo) (*models.Group, error) {
-	// 先尝试获取
+	// 先查缓存，再查库
+	cached, version := s.cache.get(chatInfo.ChatID)
+	if cached != nil {
+		return cached, nil
+	}
+
 	group, err := s.groupRepo.GetByTelegramID(ctx, chatInfo.ChatID)
 	if err == nil {
 		ensureGroupTier(group)
+		s.cache.set(chatInfo.ChatID, group, version)
 		return group, nil
 	}
 
@@ -75,21 +91,18 @@ func (s *GroupServiceImpl) GetOrCreateGroup(ctx context.Context, chatInfo *Teleg
 			CascadeForwardConfigured: true,
 			CascadeReplyEnabled:      true,
 			CascadeReplyConfigured:   true,
+			BalanceMonitorConfigured: true,
+			BalanceMonitorInterval:   10,
 		},
-		Stats: models.GroupStats{},
-		// BotJoinedAt、CreatedAt、UpdatedAt 由 CreateOrUpdate 的 $setOnInsert 自动设置
-	}
-
-	if err := s.groupRepo.CreateOrUpdate(ctx, newGroup); err != nil {
-		logger.L().Errorf("Failed to auto-create group %d: %v", chatInfo.ChatID, err)
-		return nil, fmt.Errorf("自动创建群组失败")
+		// Stats、BotJoinedAt、CreatedAt、UpdatedAt 由 GetOrCreate 的 $setOnInsert 自动设置
 	}
 
-	// 再次查询以获取数据库填充的默认值
-	createdGroup, err := s.groupRepo.GetByTelegramID(ctx, chatInfo.ChatID)
+	// 使用原子 upsert，避免同一新群的并发消息重复创建或覆盖已写入的配置
+	defer s.cache.invalidate(chatInfo.ChatID)
+	createdGroup, err := s.groupRepo.GetOrCreate(ctx, newGroup)
 	if err != nil {
-		logger.L().Errorf("Failed to reload group %d after creation: %v", chatInfo.ChatID, err)
-		return nil, fmt.Errorf("自动创建群组失败")
+		logStorageError(err, "Failed to auto-create group %d: %v", chatInfo.ChatID, err)
+		return nil, wrapStorageError("自动创建群组失败", err)
 	}
 	ensureGroupTier(createdGroup)
 
@@ -104,6 +117,11 @@ func (s *GroupServiceImpl) FindGroupByInterfaceID(ctx context.Context, interface
 		return nil, fmt.Errorf("接口 ID 不能为空")
 	}
 
+	cached, version := s.cache.getByInterface(cleanID)
+	if cached != nil {
+		return cached, nil
+	}
+
 	group, err := s.groupRepo.FindByInterfaceID(ctx, cleanID)
 	if err != nil {
 		logger.L().Errorf("Failed to find group by interface ID %s: %v", cleanID, err)
@@ -111,11 +129,14 @@ func (s *GroupServiceImpl) FindGroupByInterfaceID(ctx context.Context, interface
 	}
 
 	ensureGroupTier(group)
+	s.cache.setByInterface(cleanID, group, version)
 	return group, nil
 }
 
 // MarkBotLeft 标记 Bot 离开群组
 func (s *GroupServiceImpl) MarkBotLeft(ctx context.Context, telegramID int64) error {
+	defer s.cache.invalidate(telegramID)
+
 	if err := s.groupRepo.UpdateBotStatus(ctx, telegramID, models.BotStatusLeft); err != nil {
 		logger.L().Errorf("Failed to mark bot left for group %d: %v", telegramID, err)
 		return fmt.Errorf("标记失败: %w", err)
@@ -148,6 +169,7 @@ func (s *GroupServiceImpl) UpdateGroupSettings(ctx context.Context, telegramID i
 		return fmt.Errorf("更新群组配置失败: %w", err)
 	}
 
+	defer s.cache.invalidate(telegramID)
 	if err := s.groupRepo.UpdateSettings(ctx, telegramID, settings, tier); err != nil {
 		logger.L().Errorf("Failed to update group settings for %d: %v", telegramID, err)
 		return fmt.Errorf("更新群组配置失败: %w", err)
@@ -157,6 +179,47 @@ func (s *GroupServiceImpl) UpdateGroupSettings(ctx context.Context, telegramID i
 	return nil
 }
 
+// SetGroupTier 手动设置群组等级，返回缺少前置绑定等需要提示的警告
+func (s *GroupServiceImpl) SetGroupTier(ctx context.Context, telegramID int64, tier models.GroupTier) ([]string, error) {
+	if _, ok := models.ParseGroupTier(string(tier)); !ok {
+		return nil, fmt.Errorf("无效的群类型：%s", tier)
+	}
+
+	group, err := s.groupRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil || group == nil {
+		logger.L().Errorf("Failed to load group %d for tier update: %v", telegramID, err)
+		return nil, fmt.Errorf("获取群组信息失败")
+	}
+
+	defer s.cache.invalidate(telegramID)
+	if err := s.groupRepo.UpdateSettings(ctx, telegramID, group.Settings, tier); err != nil {
+		logger.L().Errorf("Failed to update tier for group %d: %v", telegramID, err)
+		return nil, fmt.Errorf("更新群类型失败: %w", err)
+	}
+
+	logger.L().Infof("Group tier set manually: group_id=%d tier=%s", telegramID, tier)
+	return groupTierWarnings(group.Settings, tier), nil
+}
+
+// groupTierWarnings 检查目标群等级所需的绑定是否齐全
+func groupTierWarnings(settings models.GroupSettings, tier models.GroupTier) []string {
+	var warnings []string
+	switch tier {
+	case models.GroupTierUpstream:
+		if len(models.NormalizeInterfaceBindings(settings.InterfaceBindings)) == 0 {
+			warnings = append(warnings, "本群尚未绑定接口 ID，上游相关功能（余额、日结、转单）暂不可用")
+		}
+	case models.GroupTierMerchant:
+		if settings.MerchantID <= 0 {
+			warnings = append(warnings, "本群尚未绑定商户号，四方支付相关功能暂不可用")
+		}
+	}
+	if expected, err := models.DetermineGroupTier(settings); err == nil && expected != tier {
+		warnings = append(warnings, fmt.Sprintf("按当前绑定推导应为%s，下次修改群组配置时将按绑定重新推导", models.GroupTierDisplayName(expected)))
+	}
+	return warnings
+}
+
 // LeaveGroup Bot 离开群组（删除群组记录）
 func (s *GroupServiceImpl) LeaveGroup(ctx context.Context, telegramID int64) error {
 	// 检查群组是否存在
@@ -167,6 +230,7 @@ func (s *GroupServiceImpl) LeaveGroup(ctx context.Context, telegramID int64) err
 	}
 
 	// 删除群组记录
+	defer s.cache.invalidate(telegramID)
 	if err := s.groupRepo.DeleteGroup(ctx, telegramID); err != nil {
 		logger.L().Errorf("Failed to delete group %d: %v", telegramID, err)
 		return fmt.Errorf("离开群组失败: %w", err)
@@ -180,6 +244,7 @@ func (s *GroupServiceImpl) LeaveGroup(ctx context.Context, telegramID int64) err
 func (s *GroupServiceImpl) HandleBotAddedToGroup(ctx context.Context, group *models.Group) error {
 	// 设置状态为活跃
 	group.BotStatus = models.BotStatusActive
+	defer s.cache.invalidate(group.TelegramID)
 
 	if err := s.groupRepo.CreateOrUpdate(ctx, group); err != nil {
 		logger.L().Errorf("Failed to handle bot added to group %d: %v", group.TelegramID, err)
@@ -211,6 +276,11 @@ func (s *GroupServiceImpl) HandleBotRemovedFromGroup(ctx context.Context, telegr
 			changed = true
 		}
 
+		if len(settings.MerchantIDs) > 0 {
+			settings.MerchantIDs = nil
+			changed = true
+		}
+
 		if len(settings.InterfaceBindings) > 0 {
 			logger.L().Infof("Auto-unbinding interface bindings after bot removal: group_id=%d, count=%d", telegramID, len(settings.InterfaceBindings))
 			settings.InterfaceBindings = nil
@@ -225,6 +295,7 @@ func (s *GroupServiceImpl) HandleBotRemovedFromGroup(ctx context.Context, telegr
 	}
 
 	// 标记 Bot 离开
+	defer s.cache.invalidate(telegramID)
 	if err := s.groupRepo.UpdateBotStatus(ctx, telegramID, status); err != nil {
 		logger.L().Errorf("Failed to handle bot removed from group %d: %v", telegramID, err)
 		return fmt.Errorf("记录 Bot 离开群组失败: %w", err)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,6 +25,8 @@ type stubGroupRepository struct {
 	lastUpdatedTier models.GroupTier
 	updateCalls     int
 	updateHistory   []groupUpdateRecord
+	getCalls        int
+	interfaceCalls  int
 }
 
 func (s *stubGroupRepository) CreateOrUpdate(ctx context.Context, group *models.Group) error {
@@ -34,7 +37,17 @@ func (s *stubGroupRepository) CreateOrUpdate(ctx context.Context, group *models.
 	return nil
 }
 
+func (s *stubGroupRepository) GetOrCreate(ctx context.Context, group *models.Group) (*models.Group, error) {
+	if s.storedGroup == nil {
+		if err := s.CreateOrUpdate(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+	return s.storedGroup, nil
+}
+
 func (s *stubGroupRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.Group, error) {
+	s.getCalls++
 	if s.storedGroup == nil {
 		return nil, errors.New("not found")
 	}
@@ -42,6 +55,7 @@ func (s *stubGroupRepository) GetByTelegramID(ctx context.Context, telegramID in
 }
 
 func (s *stubGroupRepository) FindByInterfaceID(ctx context.Context, interfaceID string) (*models.Group, error) {
+	s.interfaceCalls++
 	target := strings.TrimSpace(interfaceID)
 	if target == "" {
 		return nil, fmt.Errorf("empty interface id")
@@ -189,6 +203,51 @@ func TestUpdateGroupSettingsRejectsConflictingBindings(t *testing.T) {
 	}
 }
 
+func TestSetGroupTierValidatesAndWarns(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     models.GroupSettings
+		tier         models.GroupTier
+		wantErr      bool
+		wantWarnings []string
+	}{
+		{name: "invalid", tier: "vip", wantErr: true},
+		{name: "upstream with binding", settings: models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{ID: "abc"}}}, tier: models.GroupTierUpstream},
+		{name: "upstream without binding", tier: models.GroupTierUpstream, wantWarnings: []string{"尚未绑定接口 ID", "应为普通群"}},
+		{name: "merchant without merchant id", tier: models.GroupTierMerchant, wantWarnings: []string{"尚未绑定商户号", "应为普通群"}},
+		{name: "basic with merchant id", settings: models.GroupSettings{MerchantID: 1001}, tier: models.GroupTierBasic, wantWarnings: []string{"应为商户群"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &stubGroupRepository{storedGroup: &models.Group{TelegramID: 1, Settings: tc.settings}}
+			svc := NewGroupService(repo)
+
+			warnings, err := svc.SetGroupTier(context.Background(), 1, tc.tier)
+			if tc.wantErr {
+				if err == nil || repo.updateCalls != 0 {
+					t.Fatalf("expected error without update, got err=%v updates=%d", err, repo.updateCalls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo.lastUpdatedTier != tc.tier {
+				t.Fatalf("expected tier %s, got %s", tc.tier, repo.lastUpdatedTier)
+			}
+			if len(warnings) != len(tc.wantWarnings) {
+				t.Fatalf("expected %d warnings, got %v", len(tc.wantWarnings), warnings)
+			}
+			for i, want := range tc.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Fatalf("expected warning %d to contain %q, got %q", i, want, warnings[i])
+				}
+			}
+		})
+	}
+}
+
 func TestHandleBotRemovedFromGroupResetsBindings(t *testing.T) {
 	repo := &stubGroupRepository{
 		storedGroup: &models.Group{
@@ -431,3 +490,135 @@ func TestRepairGroupsSkipsConflictingSettings(t *testing.T) {
 }
 
 var _ repository.GroupRepository = (*stubGroupRepository)(nil)
+
+func TestCheckBindingsDetectsDuplicateInterfaceAndMissingMerchant(t *testing.T) {
+	repo := &stubGroupRepository{
+		allGroups: []*models.Group{
+			{
+				TelegramID: 300,
+				Title:      "Merchant OK",
+				Tier:       models.GroupTierMerchant,
+				Settings:   models.GroupSettings{MerchantID: 1001},
+			},
+			{
+				TelegramID: 301,
+				Title:      "Merchant Missing",
+				Tier:       models.GroupTierMerchant,
+			},
+			{
+				TelegramID: 400,
+				Title:      "Upstream A",
+				Tier:       models.GroupTierUpstream,
+				Settings: models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{
+					{Name: "A", ID: "IF-A"},
+					{Name: "Shared", ID: "IF-SHARED"},
+				}},
+			},
+			{
+				TelegramID: 401,
+				Title:      "Upstream B",
+				Tier:       models.GroupTierUpstream,
+				Settings: models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{
+					{Name: "Shared", ID: "if-shared"},
+				}},
+			},
+		},
+	}
+
+	service := NewGroupService(repo)
+	result, err := service.CheckBindings(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if result.TotalGroups != 4 || result.MerchantGroups != 2 || result.UpstreamGroups != 2 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", result.Issues)
+	}
+
+	missing := result.Issues[0]
+	if missing.Kind != BindingIssueMissingMerchant || missing.GroupID != 301 {
+		t.Fatalf("expected missing merchant on 301, got %+v", missing)
+	}
+
+	duplicate := result.Issues[1]
+	if duplicate.Kind != BindingIssueDuplicateInterface || !strings.EqualFold(duplicate.InterfaceID, "IF-SHARED") {
+		t.Fatalf("expected duplicate IF-SHARED, got %+v", duplicate)
+	}
+	if len(duplicate.GroupIDs) != 2 || duplicate.GroupIDs[0] != 400 || duplicate.GroupIDs[1] != 401 {
+		t.Fatalf("expected duplicate across 400 and 401, got %v", duplicate.GroupIDs)
+	}
+}
+
+// upsertGroupRepository 模拟 MongoDB upsert 语义：GetOrCreate 原子执行，读取可能落后于并发写入
+type upsertGroupRepository struct {
+	repository.GroupRepository
+	mu      sync.Mutex
+	groups  map[int64]*models.Group
+	inserts int
+	stale   bool // 为 true 时 GetByTelegramID 始终返回未找到，模拟并发下读到旧状态
+}
+
+func (r *upsertGroupRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, ok := r.groups[telegramID]
+	if !ok || r.stale {
+		return nil, errors.New("not found")
+	}
+	clone := *group
+	return &clone, nil
+}
+
+func (r *upsertGroupRepository) GetOrCreate(ctx context.Context, group *models.Group) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[group.TelegramID]; !ok {
+		clone := *group
+		r.groups[group.TelegramID] = &clone
+		r.inserts++
+	}
+	clone := *r.groups[group.TelegramID]
+	return &clone, nil
+}
+
+func TestGetOrCreateGroupConcurrentCreatesSingleDocument(t *testing.T) {
+	repo := &upsertGroupRepository{groups: make(map[int64]*models.Group), stale: true}
+	chatInfo := &TelegramChatInfo{ChatID: -5001, Type: "supergroup", Title: "Race"}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			// 每个协程使用独立服务实例，绕开进程内缓存，模拟多条消息同时命中新群
+			if _, err := NewGroupService(repo).GetOrCreateGroup(context.Background(), chatInfo); err != nil {
+				t.Errorf("GetOrCreateGroup failed: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if repo.inserts != 1 || len(repo.groups) != 1 {
+		t.Fatalf("expected a single document, got inserts=%d docs=%d", repo.inserts, len(repo.groups))
+	}
+
+	// 配置写入后，读取仍落后的请求也不能覆盖已有配置
+	repo.groups[-5001].Settings.MerchantID = 88
+	repo.groups[-5001].Settings.CalculatorEnabled = false
+	group, err := NewGroupService(repo).GetOrCreateGroup(context.Background(), chatInfo)
+	if err != nil {
+		t.Fatalf("GetOrCreateGroup failed: %v", err)
+	}
+	if group.Settings.MerchantID != 88 || group.Settings.CalculatorEnabled {
+		t.Fatalf("expected existing settings to be preserved, got %+v", group.Settings)
+	}
+	if repo.groups[-5001].Settings.MerchantID != 88 || repo.inserts != 1 {
+		t.Fatalf("expected stored settings untouched, got %+v inserts=%d", repo.groups[-5001].Settings, repo.inserts)
+	}
+}
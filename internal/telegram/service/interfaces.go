@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"go_bot/internal/telegram/models"
@@ -32,6 +33,12 @@ type UserService interface {
 
 	// UpdateUserActivity 更新用户活跃时间
 	UpdateUserActivity(ctx context.Context, telegramID int64) error
+
+	// SetUserBlocked 设置或清除用户屏蔽 Bot 的标记
+	SetUserBlocked(ctx context.Context, telegramID int64, blocked bool) error
+
+	// GrantInviterAdmin 将邀请 Bot 入群的用户自动设为管理员，已是管理员或 Owner 时返回 false
+	GrantInviterAdmin(ctx context.Context, info *TelegramUserInfo) (bool, error)
 }
 
 // GroupService 群组业务逻辑接口
@@ -57,6 +64,9 @@ type GroupService interface {
 	// UpdateGroupSettings 更新群组配置
 	UpdateGroupSettings(ctx context.Context, telegramID int64, settings models.GroupSettings) error
 
+	// SetGroupTier 手动设置群组等级，返回缺少前置绑定等警告
+	SetGroupTier(ctx context.Context, telegramID int64, tier models.GroupTier) ([]string, error)
+
 	// LeaveGroup Bot 离开群组（删除群组记录）
 	LeaveGroup(ctx context.Context, telegramID int64) error
 
@@ -71,6 +81,9 @@ type GroupService interface {
 
 	// RepairGroups 自动修复可矫正的问题（例如缺失 tier、冲突开关）
 	RepairGroups(ctx context.Context) (*GroupRepairResult, error)
+
+	// CheckBindings 检查商户号与接口绑定的健康状况
+	CheckBindings(ctx context.Context) (*BindingCheckResult, error)
 }
 
 // MessageService 消息业务逻辑接口
@@ -89,6 +102,9 @@ type MessageService interface {
 
 	// GetChatMessageHistory 获取聊天消息历史
 	GetChatMessageHistory(ctx context.Context, chatID int64, limit int) ([]*models.Message, error)
+
+	// ExportMessages 将 [start, end) 内的消息逐行写为 JSON Lines，返回导出条数
+	ExportMessages(ctx context.Context, chatID int64, start, end time.Time, w io.Writer) (int, error)
 }
 
 // TelegramUserInfo Telegram 用户信息 DTO
@@ -138,6 +154,7 @@ type ChannelPostInfo struct {
 	ChatID            int64
 	MessageType       string // text/photo/video...
 	Text              string
+	Caption           string // 媒体消息的说明文字（纯媒体频道消息没有 Text）
 	MediaFileID       string
 	SentAt            time.Time
 }
@@ -156,9 +173,15 @@ type AccountingService interface {
 	// AddRecord 添加记账记录
 	AddRecord(ctx context.Context, chatID, userID int64, input string) error
 
+	// IsDuplicateRecord 判断输入是否与上一条记录重复（同一用户短时间内提交相同金额与表达式）
+	IsDuplicateRecord(ctx context.Context, chatID, userID int64, input string) (bool, error)
+
 	// QueryRecords 查询并格式化账单
 	QueryRecords(ctx context.Context, chatID int64) (string, error)
 
+	// QueryRecordsByRange 查询并格式化 [start, end) 区间的账单（期初结余 + 区间明细）
+	QueryRecordsByRange(ctx context.Context, chatID int64, start, end time.Time) (string, error)
+
 	// GetRecentRecordsForDeletion 获取最近2天记录（用于删除界面）
 	GetRecentRecordsForDeletion(ctx context.Context, chatID int64) ([]*models.AccountingRecord, error)
 
@@ -167,6 +190,39 @@ type AccountingService interface {
 
 	// ClearAllRecords 清空所有记录
 	ClearAllRecords(ctx context.Context, chatID int64) (int64, error)
+
+	// ClearRecordsBefore 清除 before 之前的记录（周期清零使用，之后新记的账保留）
+	ClearRecordsBefore(ctx context.Context, chatID int64, before time.Time) (int64, error)
+
+	// ExportRecords 将 [start, end) 内的记账记录写为 CSV，返回导出条数
+	ExportRecords(ctx context.Context, chatID int64, start, end time.Time, w io.Writer) (int, error)
+}
+
+// FeatureUsageService 功能使用统计业务接口
+type FeatureUsageService interface {
+	// Record 记录一次功能使用（失败仅记录日志，不影响主流程）
+	Record(ctx context.Context, groupID int64, feature string)
+
+	// Top 查询使用最多的功能（groupID 为 0 时统计全部群组）
+	Top(ctx context.Context, groupID int64, limit int64) ([]*models.FeatureUsage, error)
+}
+
+// SettingService 全局（非群组）运行时配置业务接口，读取失败或未设置时返回默认值
+type SettingService interface {
+	// GetString 读取字符串配置
+	GetString(ctx context.Context, key, def string) string
+
+	// GetBool 读取布尔配置
+	GetBool(ctx context.Context, key string, def bool) bool
+
+	// GetInt 读取整数配置
+	GetInt(ctx context.Context, key string, def int) int
+
+	// GetDuration 读取时长配置（time.ParseDuration 格式，如 "500ms"）
+	GetDuration(ctx context.Context, key string, def time.Duration) time.Duration
+
+	// Set 写入配置
+	Set(ctx context.Context, key, value string, operatorID int64) error
 }
 
 // UpstreamBalanceService 上游群余额业务接口
@@ -176,8 +232,24 @@ type UpstreamBalanceService interface {
 	SetAlertLimit(ctx context.Context, groupID int64, limit int, operatorID int64) (*UpstreamBalanceResult, error)
 	Get(ctx context.Context, groupID int64) (*UpstreamBalanceResult, error)
 	ListAll(ctx context.Context) ([]*UpstreamBalanceResult, error)
+	ListLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, skip, limit int64) ([]*models.UpstreamBalanceLog, error)
+	ExportLogs(ctx context.Context, groupID int64, start, end time.Time, w io.Writer) (int, error)
+	PruneLogs(ctx context.Context, groupID int64, before time.Time, keepLatest int) (int64, error)
+	Transfer(ctx context.Context, fromGroupID, toGroupID int64, amount float64, operatorID int64, allowNegative bool, operationID string) (*UpstreamTransferResult, error)
 	SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*SettlementResult, error)
+	SettleRange(ctx context.Context, groupID int64, start, end time.Time, operatorID int64, dryRun bool) (*SettlementRangeResult, error)
+	CorrectSettlement(ctx context.Context, groupID int64, settlementDate time.Time, delta float64, operatorID int64, note string, operationID string) (*UpstreamBalanceResult, bool, error)
+	PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*SettlementResult, error)
 	SubscribeEvents() <-chan *models.UpstreamBalanceEvent
+	EventStats() UpstreamBalanceEventStats
+}
+
+// UpstreamBalanceEventStats 余额事件通道统计
+type UpstreamBalanceEventStats struct {
+	Queued    int    // 通道中等待处理的事件数
+	Capacity  int    // 通道容量
+	Published uint64 // 累计写入通道的事件数
+	Dropped   uint64 // 通道已满被丢弃的事件数
 }
 
 // UpstreamBalanceResult 返回余额及阈值信息
@@ -189,6 +261,12 @@ type UpstreamBalanceResult struct {
 	UpdatedAt         time.Time
 }
 
+// UpstreamTransferResult 返回划转后双方的余额
+type UpstreamTransferResult struct {
+	From *UpstreamBalanceResult
+	To   *UpstreamBalanceResult
+}
+
 // SettlementResult 返回日结结果
 type SettlementResult struct {
 	GroupID        int64
@@ -197,4 +275,31 @@ type SettlementResult struct {
 	Balance        float64
 	BelowMin       bool
 	Report         string
+	DryRun         bool
+	AlreadySettled bool                      // 目标日期已日结，本次未扣款
+	Bindings       []SettlementBindingResult // 各接口的日结结果（含失败接口）
+	Errors         []string                  // 查询或解析失败的接口说明，非空表示部分失败
+}
+
+// SettlementRangeResult 返回按日期区间日结的汇总结果
+type SettlementRangeResult struct {
+	GroupID        int64
+	Start          time.Time
+	End            time.Time
+	Days           []*SettlementResult // 逐日日结结果，按日期升序
+	TotalDeduction float64
+	Balance        float64 // 预览模式下为预计余额
+	BelowMin       bool
+	Report         string
+	DryRun         bool
+}
+
+// SettlementBindingResult 单个接口的日结结果
+type SettlementBindingResult struct {
+	InterfaceID   string
+	InterfaceName string
+	Volume        float64
+	Rate          float64
+	Deduction     float64
+	Error         string // 失败原因，为空表示该接口已计入日结
 }
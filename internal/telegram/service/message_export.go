@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+)
+
+// messageExportRecord 消息导出的单行 JSON 结构
+type messageExportRecord struct {
+	MessageID        int64      `json:"message_id"`
+	ChatID           int64      `json:"chat_id"`
+	UserID           int64      `json:"user_id"`
+	Type             string     `json:"type"`
+	Text             string     `json:"text,omitempty"`
+	Caption          string     `json:"caption,omitempty"`
+	MediaFileID      string     `json:"media_file_id,omitempty"`
+	ReplyToMessageID int64      `json:"reply_to_message_id,omitempty"`
+	IsEdited         bool       `json:"is_edited"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	SentAt           time.Time  `json:"sent_at"`
+}
+
+// ExportMessages 将 [start, end) 内的消息逐行写为 JSON Lines，边查边写，不在内存中累积
+func (s *MessageServiceImpl) ExportMessages(ctx context.Context, chatID int64, start, end time.Time, w io.Writer) (int, error) {
+	buf := bufio.NewWriter(w)
+	count := 0
+	err := s.messageRepo.GetMessagesByDateRange(ctx, chatID, start, end, func(message *models.Message) error {
+		if err := writeMessageExportLine(buf, message); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		logger.L().Errorf("Failed to export messages: chat_id=%d, exported=%d, error=%v", chatID, count, err)
+		return count, fmt.Errorf("failed to export messages: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return count, fmt.Errorf("failed to export messages: %w", err)
+	}
+	return count, nil
+}
+
+// writeMessageExportLine 写入一条消息对应的 JSON 行（以换行结尾）
+func writeMessageExportLine(w io.Writer, message *models.Message) error {
+	record := messageExportRecord{
+		MessageID:        message.TelegramMessageID,
+		ChatID:           message.ChatID,
+		UserID:           message.UserID,
+		Type:             message.MessageType,
+		Text:             message.Text,
+		Caption:          message.Caption,
+		MediaFileID:      message.MediaFileID,
+		ReplyToMessageID: message.ReplyToMessageID,
+		IsEdited:         message.IsEdited,
+		EditedAt:         message.EditedAt,
+		SentAt:           message.SentAt,
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(record)
+}
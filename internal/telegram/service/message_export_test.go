@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+type exportTestMessageRepository struct {
+	repository.MessageRepository
+	messages []*models.Message
+}
+
+func (r *exportTestMessageRepository) GetMessagesByDateRange(ctx context.Context, chatID int64, start, end time.Time, fn func(*models.Message) error) error {
+	for _, message := range r.messages {
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportMessagesWritesJSONLines(t *testing.T) {
+	sentAt := time.Date(2024, 10, 1, 8, 30, 0, 0, time.UTC)
+	editedAt := sentAt.Add(5 * time.Minute)
+	repo := &exportTestMessageRepository{messages: []*models.Message{
+		{TelegramMessageID: 11, ChatID: -100, UserID: 7, MessageType: models.MessageTypeText, Text: "a<b>&\\n换行", SentAt: sentAt, IsEdited: true, EditedAt: &editedAt},
+		{TelegramMessageID: 12, ChatID: -100, UserID: 8, MessageType: models.MessageTypePhoto, Caption: "图", MediaFileID: "file-1", ReplyToMessageID: 11, SentAt: sentAt.Add(time.Minute)},
+	}}
+	svc := NewMessageService(repo, nil)
+
+	var buf bytes.Buffer
+	count, err := svc.ExportMessages(context.Background(), -100, sentAt, sentAt.Add(time.Hour), &buf)
+	if err != nil {
+		t.Fatalf("ExportMessages failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 exported messages, got %d", count)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	want := `{"message_id":11,"chat_id":-100,"user_id":7,"type":"text","text":"a<b>&\\n换行","is_edited":true,"edited_at":"2024-10-01T08:35:00Z","sent_at":"2024-10-01T08:30:00Z"}`
+	if lines[0] != want {
+		t.Fatalf("unexpected first line:\n got %s\nwant %s", lines[0], want)
+	}
+
+	var second map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("second line is not valid JSON: %v", err)
+	}
+	if second["type"] != "photo" || second["caption"] != "图" || second["reply_to_message_id"] != float64(11) || second["is_edited"] != false {
+		t.Fatalf("unexpected second line: %s", lines[1])
+	}
+	if _, ok := second["edited_at"]; ok {
+		t.Fatalf("expected edited_at to be omitted for unedited message: %s", lines[1])
+	}
+}
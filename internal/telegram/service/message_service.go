@@ -98,6 +98,7 @@ func (s *MessageServiceImpl) RecordChannelPost(ctx context.Context, msg *Channel
 		UserID:            0, // 频道消息没有 user_id
 		MessageType:       msg.MessageType,
 		Text:              msg.Text,
+		Caption:           msg.Caption,
 		MediaFileID:       msg.MediaFileID,
 		SentAt:            msg.SentAt,
 	}
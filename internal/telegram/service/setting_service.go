@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/repository"
+)
+
+// SettingServiceImpl 全局配置服务实现
+type SettingServiceImpl struct {
+	repo repository.SettingRepository
+}
+
+// NewSettingService 创建全局配置服务
+func NewSettingService(repo repository.SettingRepository) SettingService {
+	return &SettingServiceImpl{repo: repo}
+}
+
+// GetString 读取字符串配置，未设置或读取失败时返回 def
+func (s *SettingServiceImpl) GetString(ctx context.Context, key, def string) string {
+	value, ok := s.lookup(ctx, key)
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// GetBool 读取布尔配置，无法解析时返回 def
+func (s *SettingServiceImpl) GetBool(ctx context.Context, key string, def bool) bool {
+	value, ok := s.lookup(ctx, key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.L().Warnf("Invalid bool setting: key=%s value=%q", key, value)
+		return def
+	}
+	return parsed
+}
+
+// GetInt 读取整数配置，无法解析时返回 def
+func (s *SettingServiceImpl) GetInt(ctx context.Context, key string, def int) int {
+	value, ok := s.lookup(ctx, key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logger.L().Warnf("Invalid int setting: key=%s value=%q", key, value)
+		return def
+	}
+	return parsed
+}
+
+// GetDuration 读取时长配置，无法解析时返回 def
+func (s *SettingServiceImpl) GetDuration(ctx context.Context, key string, def time.Duration) time.Duration {
+	value, ok := s.lookup(ctx, key)
+	if !ok {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		logger.L().Warnf("Invalid duration setting: key=%s value=%q", key, value)
+		return def
+	}
+	return parsed
+}
+
+// Set 写入配置
+func (s *SettingServiceImpl) Set(ctx context.Context, key, value string, operatorID int64) error {
+	if err := s.repo.Set(ctx, key, strings.TrimSpace(value), operatorID); err != nil {
+		return err
+	}
+	logger.L().Infof("Global setting updated: key=%s value=%q operator=%d", key, value, operatorID)
+	return nil
+}
+
+// lookup 返回已设置的非空配置值
+func (s *SettingServiceImpl) lookup(ctx context.Context, key string) (string, bool) {
+	setting, err := s.repo.Get(ctx, key)
+	if err != nil {
+		logger.L().Warnf("Failed to read setting, using default: key=%s err=%v", key, err)
+		return "", false
+	}
+	if setting == nil || strings.TrimSpace(setting.Value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(setting.Value), true
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+type memorySettingRepository struct {
+	values map[string]string
+	getErr error
+}
+
+func (r *memorySettingRepository) Get(ctx context.Context, key string) (*models.Setting, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	value, ok := r.values[key]
+	if !ok {
+		return nil, nil
+	}
+	return &models.Setting{Key: key, Value: value}, nil
+}
+
+func (r *memorySettingRepository) Set(ctx context.Context, key, value string, updatedBy int64) error {
+	r.values[key] = value
+	return nil
+}
+
+func (r *memorySettingRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func TestSettingServiceSetAndGet(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSettingService(&memorySettingRepository{values: map[string]string{}})
+
+	for key, value := range map[string]string{
+		"log_level":       " debug ",
+		"scheduler.daily": "false",
+		"broadcast.limit": "25",
+		"broadcast.delay": "250ms",
+	} {
+		if err := svc.Set(ctx, key, value, 1); err != nil {
+			t.Fatalf("Set(%s) failed: %v", key, err)
+		}
+	}
+
+	if got := svc.GetString(ctx, "log_level", "info"); got != "debug" {
+		t.Fatalf("GetString = %q, want debug", got)
+	}
+	if got := svc.GetBool(ctx, "scheduler.daily", true); got {
+		t.Fatalf("GetBool = %v, want false", got)
+	}
+	if got := svc.GetInt(ctx, "broadcast.limit", 10); got != 25 {
+		t.Fatalf("GetInt = %d, want 25", got)
+	}
+	if got := svc.GetDuration(ctx, "broadcast.delay", time.Second); got != 250*time.Millisecond {
+		t.Fatalf("GetDuration = %v, want 250ms", got)
+	}
+}
+
+func TestSettingServiceReturnsDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unset key", func(t *testing.T) {
+		svc := NewSettingService(&memorySettingRepository{values: map[string]string{}})
+		if got := svc.GetString(ctx, "missing", "info"); got != "info" {
+			t.Fatalf("GetString = %q, want default", got)
+		}
+		if got := svc.GetBool(ctx, "missing", true); !got {
+			t.Fatalf("GetBool = %v, want default", got)
+		}
+		if got := svc.GetInt(ctx, "missing", 7); got != 7 {
+			t.Fatalf("GetInt = %d, want default", got)
+		}
+		if got := svc.GetDuration(ctx, "missing", time.Second); got != time.Second {
+			t.Fatalf("GetDuration = %v, want default", got)
+		}
+	})
+
+	t.Run("unparsable value", func(t *testing.T) {
+		svc := NewSettingService(&memorySettingRepository{values: map[string]string{"n": "abc", "b": "maybe"}})
+		if got := svc.GetInt(ctx, "n", 3); got != 3 {
+			t.Fatalf("GetInt = %d, want default", got)
+		}
+		if got := svc.GetBool(ctx, "b", true); !got {
+			t.Fatalf("GetBool = %v, want default", got)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		svc := NewSettingService(&memorySettingRepository{getErr: errors.New("db down")})
+		if got := svc.GetString(ctx, "log_level", "info"); got != "info" {
+			t.Fatalf("GetString = %q, want default", got)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"math"
+
+	"go_bot/internal/config"
+)
+
+// settlementRoundingEpsilon 吸收浮点误差（如 100.5*0.01 = 1.00499…），避免在分位边界取错方向
+const settlementRoundingEpsilon = 1e-6
+
+// roundSettlementAmount 按取整方式（config.SettlementRound*，未知取值按四舍五入）保留 2 位小数，使实际扣款与日结报告展示的金额一致
+func roundSettlementAmount(amount float64, mode string) float64 {
+	cents := amount * 100
+	switch mode {
+	case config.SettlementRoundCeil:
+		cents = math.Ceil(cents - settlementRoundingEpsilon)
+	case config.SettlementRoundFloor:
+		cents = math.Floor(cents + settlementRoundingEpsilon)
+	default:
+		cents = math.Floor(math.Abs(cents) + 0.5 + settlementRoundingEpsilon)
+		if amount < 0 {
+			cents = -cents
+		}
+	}
+	return cents / 100
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_bot/internal/config"
+	paymentservice "go_bot/internal/payment/service"
+)
+
+func TestRoundSettlementAmount(t *testing.T) {
+	cases := []struct {
+		name   string
+		amount float64
+		mode   string
+		want   float64
+	}{
+		// 100.5 * 0.01 在浮点下为 1.00499…，四舍五入仍应得到 1.01
+		{name: "half up at float boundary", amount: 100.5 * 0.01, mode: config.SettlementRoundHalfUp, want: 1.01},
+		{name: "default is half up", amount: 100.5 * 0.01, mode: "", want: 1.01},
+		{name: "ceil at float boundary", amount: 100.5 * 0.01, mode: config.SettlementRoundCeil, want: 1.01},
+		{name: "floor at float boundary", amount: 100.5 * 0.01, mode: config.SettlementRoundFloor, want: 1},
+		{name: "half up below half", amount: 3.7035, mode: config.SettlementRoundHalfUp, want: 3.7},
+		{name: "ceil below half", amount: 3.7035, mode: config.SettlementRoundCeil, want: 3.71},
+		{name: "floor below half", amount: 3.7035, mode: config.SettlementRoundFloor, want: 3.7},
+		// 0.07 * 100 = 7.000000000000001，向上取整不应多扣 1 分
+		{name: "ceil keeps exact cents", amount: 0.07, mode: config.SettlementRoundCeil, want: 0.07},
+		{name: "floor keeps exact cents", amount: 0.29, mode: config.SettlementRoundFloor, want: 0.29},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := roundSettlementAmount(tc.amount, tc.mode); got != tc.want {
+				t.Fatalf("roundSettlementAmount(%v, %q) = %v, want %v", tc.amount, tc.mode, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamBalanceSettleDailyChargesRoundedDeduction(t *testing.T) {
+	for mode, want := range map[string]float64{
+		config.SettlementRoundHalfUp: 3.7,
+		config.SettlementRoundCeil:   3.71,
+		config.SettlementRoundFloor:  3.7,
+	} {
+		t.Run(mode, func(t *testing.T) {
+			svc, repo := newTransferTestService(map[int64]float64{-1001: 100})
+			svc.rounding = mode
+			svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+				"1024": {Items: []*paymentservice.SummaryByPZIDItem{{Date: "2024-10-25", GrossAmount: "1234.5"}}},
+			}}
+			svc.groupRepo.(*transferTestGroupRepository).groups[-1001].Settings.InterfaceBindings[0].Rate = "0.3%"
+
+			target := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+			result, err := svc.SettleDaily(context.Background(), -1001, target, 7, "settle:-1001:2024-10-25")
+			if err != nil {
+				t.Fatalf("SettleDaily returned error: %v", err)
+			}
+			if result.TotalDeduction != want || len(repo.adjusts) != 1 || repo.adjusts[0].delta != -want {
+				t.Fatalf("expected charged deduction %.2f, got total=%v adjusts=%+v", want, result.TotalDeduction, repo.adjusts)
+			}
+		})
+	}
+}
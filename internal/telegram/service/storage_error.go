@@ -0,0 +1,34 @@
+package service
+
+import (
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/repository"
+)
+
+// storageError 保留面向用户的提示文案，同时保留底层仓储错误，便于上层通过 errors.Is 识别数据库超时
+type storageError struct {
+	message string
+	cause   error
+}
+
+func (e *storageError) Error() string {
+	return e.message
+}
+
+func (e *storageError) Unwrap() error {
+	return e.cause
+}
+
+// wrapStorageError 返回文案为 message、底层错误为 cause 的错误
+func wrapStorageError(message string, cause error) error {
+	return &storageError{message: message, cause: cause}
+}
+
+// logStorageError 记录仓储错误，数据库超时按 warn 记录以减少告警噪音
+func logStorageError(err error, format string, args ...interface{}) {
+	if repository.IsTimeout(err) {
+		logger.L().Warnf(format, args...)
+		return
+	}
+	logger.L().Errorf(format, args...)
+}
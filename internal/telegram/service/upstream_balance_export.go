@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	"go_bot/internal/telegram/models"
+)
+
+// balanceLogCSVHeader 余额记录导出的表头
+var balanceLogCSVHeader = []string{"time", "type", "delta", "balance", "operator", "operationId", "remark"}
+
+// utf8BOM 写在 CSV 开头，便于 Excel 正确识别中文
+const utf8BOM = "\ufeff"
+
+// ExportLogs 将 [start, end) 内的余额变动日志按时间升序写为 CSV（含 BOM），边查边写，返回导出条数
+func (s *UpstreamBalanceServiceImpl) ExportLogs(ctx context.Context, groupID int64, start, end time.Time, w io.Writer) (int, error) {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString(utf8BOM); err != nil {
+		return 0, fmt.Errorf("failed to export balance logs: %w", err)
+	}
+	writer := csv.NewWriter(buf)
+	if err := writer.Write(balanceLogCSVHeader); err != nil {
+		return 0, fmt.Errorf("failed to export balance logs: %w", err)
+	}
+
+	operators := make(map[int64]string)
+	count := 0
+	err := s.repo.StreamLogs(ctx, groupID, start, end, func(log *models.UpstreamBalanceLog) error {
+		name, ok := operators[log.OperatorID]
+		if !ok {
+			name = s.operatorName(ctx, log.OperatorID)
+			operators[log.OperatorID] = name
+		}
+		if err := writer.Write(balanceLogCSVRecord(log, name, s.location)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		logger.L().Errorf("Failed to export balance logs: group_id=%d, exported=%d, error=%v", groupID, count, err)
+		return count, fmt.Errorf("failed to export balance logs: %w", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, fmt.Errorf("failed to export balance logs: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return count, fmt.Errorf("failed to export balance logs: %w", err)
+	}
+	return count, nil
+}
+
+// operatorName 解析操作人名称：优先 @username，其次姓名，查不到时返回 ID；0 表示系统操作
+func (s *UpstreamBalanceServiceImpl) operatorName(ctx context.Context, operatorID int64) string {
+	if operatorID == 0 {
+		return "system"
+	}
+	fallback := strconv.FormatInt(operatorID, 10)
+	if s.userRepo == nil {
+		return fallback
+	}
+	user, err := s.userRepo.GetByTelegramID(ctx, operatorID)
+	if err != nil || user == nil {
+		return fallback
+	}
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// balanceLogCSVRecord 将一条余额日志转换为 CSV 行，时间按 loc 格式化
+func balanceLogCSVRecord(log *models.UpstreamBalanceLog, operator string, loc *time.Location) []string {
+	return []string{
+		log.CreatedAt.In(loc).Format("2006-01-02 15:04:05"),
+		string(log.Type),
+		strconv.FormatFloat(log.Delta, 'f', 2, 64),
+		strconv.FormatFloat(log.Balance, 'f', 2, 64),
+		operator,
+		log.OperationID,
+		log.Remark,
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+type exportTestBalanceRepository struct {
+	repository.UpstreamBalanceRepository
+	logs []*models.UpstreamBalanceLog
+	err  error
+}
+
+func (r *exportTestBalanceRepository) StreamLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, fn func(*models.UpstreamBalanceLog) error) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, log := range r.logs {
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type exportTestUserRepository struct {
+	repository.UserRepository
+	users   map[int64]*models.User
+	lookups int
+}
+
+func (r *exportTestUserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.User, error) {
+	r.lookups++
+	if user, ok := r.users[telegramID]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func TestExportLogsWritesCSV(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 10, 26, hour, minute, 0, 0, loc).UTC()
+	}
+	repo := &exportTestBalanceRepository{logs: []*models.UpstreamBalanceLog{
+		{OperatorID: 7, Delta: 100, Balance: 100, Type: models.BalanceOpCredit, Remark: "充值, 补单", OperationID: "op-1", CreatedAt: at(9, 0)},
+		{OperatorID: 7, Delta: 0, Balance: 100, Type: models.BalanceOpSetMinBalance, CreatedAt: at(9, 5)},
+		{OperatorID: 8, Delta: 0, Balance: 100, Type: models.BalanceOpAlertLimit, CreatedAt: at(9, 10)},
+		{OperatorID: 99, Delta: -12.5, Balance: 87.5, Type: models.BalanceOpDebit, CreatedAt: at(10, 0)},
+		{OperatorID: 0, Delta: -20, Balance: 67.5, Type: models.BalanceOpSettlement, OperationID: "settle:-1001:2024-10-25", CreatedAt: at(23, 59)},
+	}}
+	users := &exportTestUserRepository{users: map[int64]*models.User{
+		7: {TelegramID: 7, Username: "alice"},
+		8: {TelegramID: 8, FirstName: "Bob", LastName: "Lee"},
+	}}
+	svc := NewUpstreamBalanceService(repo, nil, users, nil, "").(*UpstreamBalanceServiceImpl)
+
+	var buf bytes.Buffer
+	count, err := svc.ExportLogs(context.Background(), -1001, at(0, 0), at(0, 0).AddDate(0, 0, 1), &buf)
+	if err != nil {
+		t.Fatalf("ExportLogs returned error: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("expected 5 rows, got %d", count)
+	}
+
+	want := "\ufeff" + strings.Join([]string{
+		"time,type,delta,balance,operator,operationId,remark",
+		`2024-10-26 09:00:00,credit,100.00,100.00,@alice,op-1,"充值, 补单"`,
+		"2024-10-26 09:05:00,set_min_balance,0.00,100.00,@alice,,",
+		"2024-10-26 09:10:00,set_alert_limit,0.00,100.00,Bob Lee,,",
+		"2024-10-26 10:00:00,debit,-12.50,87.50,99,,",
+		"2024-10-26 23:59:00,settlement,-20.00,67.50,system,settle:-1001:2024-10-25,",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", got, want)
+	}
+	if users.lookups != 3 {
+		t.Fatalf("expected operator lookups to be cached (3), got %d", users.lookups)
+	}
+}
+
+func TestExportLogsReturnsRepositoryError(t *testing.T) {
+	repo := &exportTestBalanceRepository{err: context.DeadlineExceeded}
+	svc := NewUpstreamBalanceService(repo, nil, nil, nil, "").(*UpstreamBalanceServiceImpl)
+
+	var buf bytes.Buffer
+	if _, err := svc.ExportLogs(context.Background(), -1001, time.Time{}, time.Now(), &buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go_bot/internal/config"
 	"go_bot/internal/logger"
+	"go_bot/internal/metrics"
 	paymentservice "go_bot/internal/payment/service"
 	"go_bot/internal/telegram/models"
 	"go_bot/internal/telegram/repository"
@@ -17,13 +21,25 @@ const (
 	defaultAlertLimitPerHour = 3
 )
 
+// upstreamBalanceEventsDropped 事件通道已满而被丢弃的余额事件数（说明消费者处理不过来）
+var upstreamBalanceEventsDropped = metrics.NewCounterVec(
+	"bot_upstream_balance_events_dropped_total",
+	"Upstream balance events dropped because the event channel was full.",
+	nil,
+)
+
 // UpstreamBalanceServiceImpl 上游群余额服务
 type UpstreamBalanceServiceImpl struct {
 	repo           repository.UpstreamBalanceRepository
 	groupRepo      repository.GroupRepository
+	userRepo       repository.UserRepository // 可选，用于导出时解析操作人名称
 	paymentService paymentservice.Service
 	events         chan *models.UpstreamBalanceEvent
+	published      atomic.Uint64 // 已写入事件通道的事件数
+	dropped        atomic.Uint64 // 通道已满被丢弃的事件数
 	location       *time.Location
+	nowFunc        func() time.Time
+	rounding       string // 日结扣减金额取整方式（config.SettlementRound*）
 }
 
 type settlementItem struct {
@@ -41,24 +57,38 @@ type settlementItem struct {
 func NewUpstreamBalanceService(
 	repo repository.UpstreamBalanceRepository,
 	groupRepo repository.GroupRepository,
+	userRepo repository.UserRepository,
 	paymentSvc paymentservice.Service,
+	rounding string,
 ) UpstreamBalanceService {
 	return &UpstreamBalanceServiceImpl{
 		repo:           repo,
 		groupRepo:      groupRepo,
+		userRepo:       userRepo,
 		paymentService: paymentSvc,
+		rounding:       rounding,
 		events:         make(chan *models.UpstreamBalanceEvent, 128),
 		location:       mustLoadChinaLocation(),
+		nowFunc:        time.Now,
 	}
 }
 
+// currentTime 返回当前时间，测试中可通过 nowFunc 注入固定时钟
+func (s *UpstreamBalanceServiceImpl) currentTime() time.Time {
+	if s.nowFunc != nil {
+		return s.nowFunc()
+	}
+	return time.Now()
+}
+
 // Adjust 调整余额
 func (s *UpstreamBalanceServiceImpl) Adjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, operationID string) (*UpstreamBalanceResult, bool, error) {
 	if delta == 0 {
-		return nil, false, fmt.Errorf("调整金额不能为 0")
+		return nil, false, newValidationError("调整金额不能为 0")
 	}
 
-	if err := s.ensureUpstreamGroup(ctx, groupID); err != nil {
+	group, err := s.loadUpstreamGroup(ctx, groupID)
+	if err != nil {
 		return nil, false, err
 	}
 
@@ -67,11 +97,61 @@ func (s *UpstreamBalanceServiceImpl) Adjust(ctx context.Context, groupID int64,
 		opType = models.BalanceOpCredit
 	}
 
-	balance, err := s.repo.Adjust(ctx, groupID, delta, operatorID, remark, opType, operationID, nil)
+	if delta < 0 && !models.IsBalanceNegativeAllowed(group.Settings) {
+		return s.applyNonNegativeAdjust(ctx, groupID, delta, operatorID, remark, opType, operationID)
+	}
+	return s.applyAdjust(ctx, groupID, delta, operatorID, remark, opType, operationID, nil, "adjust")
+}
+
+// applyNonNegativeAdjust 群组禁止负余额时的扣款：由仓储条件更新保证余额不低于 0，余额不足时不写入
+func (s *UpstreamBalanceServiceImpl) applyNonNegativeAdjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string) (*UpstreamBalanceResult, bool, error) {
+	balance, err := s.repo.AdjustNonNegative(ctx, groupID, delta, operatorID, remark, opType, operationID, nil)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		if current, getErr := s.repo.Get(ctx, groupID); getErr == nil {
+			return nil, false, newValidationError("余额不足：当前 %.2f，扣款 %.2f 后将为负数（本群已禁止负余额）", current.Balance, -delta)
+		}
+		return nil, false, newValidationError("余额不足：扣款 %.2f 后将为负数（本群已禁止负余额）", -delta)
+	}
 	if err != nil {
 		return nil, false, err
 	}
+	return s.publishAdjust(groupID, balance, "adjust")
+}
+
+// CorrectSettlement 日结后追加扣费（delta<0）或补偿（delta>0），日志关联日结日期
+func (s *UpstreamBalanceServiceImpl) CorrectSettlement(ctx context.Context, groupID int64, settlementDate time.Time, delta float64, operatorID int64, note string, operationID string) (*UpstreamBalanceResult, bool, error) {
+	if delta == 0 {
+		return nil, false, fmt.Errorf("调整金额不能为 0")
+	}
+	if settlementDate.IsZero() {
+		return nil, false, fmt.Errorf("缺少关联的日结日期")
+	}
+
+	if err := s.ensureUpstreamGroup(ctx, groupID); err != nil {
+		return nil, false, err
+	}
+
+	date := settlementDate.Format("2006-01-02")
+	remark := fmt.Sprintf("日结 %s 更正", date)
+	if note = strings.TrimSpace(note); note != "" {
+		remark += "：" + note
+	}
+	metadata := map[string]string{models.BalanceLogSettlementDateKey: date}
 
+	return s.applyAdjust(ctx, groupID, delta, operatorID, remark, models.BalanceOpSettlementCorrection, operationID, metadata, "settlement_correction")
+}
+
+// applyAdjust 写入余额调整并发布事件
+func (s *UpstreamBalanceServiceImpl) applyAdjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string, metadata map[string]string, trigger string) (*UpstreamBalanceResult, bool, error) {
+	balance, err := s.repo.Adjust(ctx, groupID, delta, operatorID, remark, opType, operationID, metadata)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.publishAdjust(groupID, balance, trigger)
+}
+
+// publishAdjust 将调整后的余额转换为结果并发布事件
+func (s *UpstreamBalanceServiceImpl) publishAdjust(groupID int64, balance *models.UpstreamBalance, trigger string) (*UpstreamBalanceResult, bool, error) {
 	result := toBalanceResult(balance)
 	below := result.Balance < result.MinBalance
 	s.publishEvent(&models.UpstreamBalanceEvent{
@@ -80,8 +160,8 @@ func (s *UpstreamBalanceServiceImpl) Adjust(ctx context.Context, groupID int64,
 		MinBalance:        result.MinBalance,
 		AlertLimitPerHour: result.AlertLimitPerHour,
 		BelowMin:          below,
-		OccurredAt:        time.Now(),
-		Trigger:           "adjust",
+		OccurredAt:        s.currentTime(),
+		Trigger:           trigger,
 	})
 
 	return result, below, nil
@@ -109,7 +189,7 @@ func (s *UpstreamBalanceServiceImpl) SetMinBalance(ctx context.Context, groupID
 		MinBalance:        result.MinBalance,
 		AlertLimitPerHour: result.AlertLimitPerHour,
 		BelowMin:          result.Balance < result.MinBalance,
-		OccurredAt:        time.Now(),
+		OccurredAt:        s.currentTime(),
 		Trigger:           "set_min_balance",
 	})
 	return result, nil
@@ -137,7 +217,7 @@ func (s *UpstreamBalanceServiceImpl) SetAlertLimit(ctx context.Context, groupID
 		MinBalance:        result.MinBalance,
 		AlertLimitPerHour: result.AlertLimitPerHour,
 		BelowMin:          result.Balance < result.MinBalance,
-		OccurredAt:        time.Now(),
+		OccurredAt:        s.currentTime(),
 		Trigger:           "set_alert_limit",
 	})
 	return result, nil
@@ -171,10 +251,306 @@ func (s *UpstreamBalanceServiceImpl) ListAll(ctx context.Context) ([]*UpstreamBa
 	return results, nil
 }
 
-// SettleDaily 日结扣费
+// ListLogs 查询余额变动日志
+func (s *UpstreamBalanceServiceImpl) ListLogs(ctx context.Context, groupID int64, startTime, endTime time.Time, skip, limit int64) ([]*models.UpstreamBalanceLog, error) {
+	if !startTime.IsZero() && !endTime.IsZero() && !endTime.After(startTime) {
+		return nil, newValidationError("结束时间必须晚于开始时间")
+	}
+
+	if err := s.ensureUpstreamGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListLogs(ctx, groupID, startTime, endTime, skip, limit)
+}
+
+// PruneLogs 清理 before 之前的余额日志，保留最近 keepLatest 条、当前余额对应的变动日志及带操作 ID 的幂等日志
+func (s *UpstreamBalanceServiceImpl) PruneLogs(ctx context.Context, groupID int64, before time.Time, keepLatest int) (int64, error) {
+	if before.IsZero() {
+		return 0, fmt.Errorf("清理截止时间不能为空")
+	}
+	if keepLatest < 0 {
+		keepLatest = 0
+	}
+	return s.repo.PruneLogs(ctx, groupID, before, keepLatest)
+}
+
+// Transfer 在两个上游群之间划转余额：先扣转出群、再加转入群，两笔调整使用成对的 operationID 保证幂等；
+// 未允许负余额（或转出群设置禁止负余额）时转出由仓储条件更新保证余额不低于 0；加款失败时回滚已扣款项，已回滚的划转重放时不再加款
+func (s *UpstreamBalanceServiceImpl) Transfer(ctx context.Context, fromGroupID, toGroupID int64, amount float64, operatorID int64, allowNegative bool, operationID string) (*UpstreamTransferResult, error) {
+	if amount <= 0 {
+		return nil, newValidationError("划转金额必须大于 0")
+	}
+	if fromGroupID == toGroupID {
+		return nil, newValidationError("转出群与转入群不能相同")
+	}
+	if strings.TrimSpace(operationID) == "" {
+		return nil, newValidationError("缺少操作 ID")
+	}
+	fromGroup, err := s.loadUpstreamGroup(ctx, fromGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("转出群 %d：%w", fromGroupID, err)
+	}
+	if err := s.ensureUpstreamGroup(ctx, toGroupID); err != nil {
+		return nil, fmt.Errorf("转入群 %d：%w", toGroupID, err)
+	}
+	// 群组设置禁止负余额时，--allow-negative 不能绕过
+	if allowNegative && !models.IsBalanceNegativeAllowed(fromGroup.Settings) {
+		return nil, newValidationError("转出群 %d 已禁止负余额，不能使用 --allow-negative", fromGroupID)
+	}
+
+	outID, inID := transferOperationIDs(operationID)
+	rollbackID := operationID + ":rollback"
+	outRemark := fmt.Sprintf("划转至 %d", toGroupID)
+	inRemark := fmt.Sprintf("由 %d 划入", fromGroupID)
+	metadata := map[string]string{"transfer_id": operationID}
+
+	var fromBalance *models.UpstreamBalance
+	if allowNegative {
+		fromBalance, err = s.repo.Adjust(ctx, fromGroupID, -amount, operatorID, outRemark, models.BalanceOpDebit, outID, metadata)
+	} else {
+		fromBalance, err = s.repo.AdjustNonNegative(ctx, fromGroupID, -amount, operatorID, outRemark, models.BalanceOpDebit, outID, metadata)
+	}
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		if current, getErr := s.repo.Get(ctx, fromGroupID); getErr == nil {
+			return nil, newValidationError("转出群余额不足：当前 %.2f，需划转 %.2f", current.Balance, amount)
+		}
+		return nil, newValidationError("转出群余额不足：需划转 %.2f", amount)
+	}
+	if err != nil {
+		logStorageError(err, "Upstream transfer debit failed: from=%d operation=%s err=%v", fromGroupID, operationID, err)
+		return nil, wrapStorageError("转出群扣款失败", err)
+	}
+
+	// 转出已回滚的划转重放时，转出扣款为幂等空操作，不能再给转入群加款
+	rolledBack, err := s.repo.FindLogByOperation(ctx, fromGroupID, rollbackID)
+	if err != nil {
+		logStorageError(err, "Upstream transfer rollback lookup failed: from=%d operation=%s err=%v", fromGroupID, operationID, err)
+		return nil, wrapStorageError("查询划转状态失败，请人工核对", err)
+	}
+	if rolledBack != nil {
+		return nil, newValidationError("该划转已失败并回滚，请重新发起划转")
+	}
+
+	toBalance, err := s.repo.Adjust(ctx, toGroupID, amount, operatorID, inRemark, models.BalanceOpCredit, inID, metadata)
+	if err != nil {
+		rollbackRemark := fmt.Sprintf("划转至 %d 失败回滚", toGroupID)
+		if _, rollbackErr := s.repo.Adjust(ctx, fromGroupID, amount, operatorID, rollbackRemark, models.BalanceOpCredit, rollbackID, metadata); rollbackErr != nil {
+			logger.L().Errorf("Upstream transfer rollback failed: from=%d to=%d amount=%.2f operation=%s err=%v",
+				fromGroupID, toGroupID, amount, operationID, rollbackErr)
+			return nil, wrapStorageError("转入失败且回滚失败，请人工核对", err)
+		}
+		logStorageError(err, "Upstream transfer credit failed, rolled back: to=%d operation=%s err=%v", toGroupID, operationID, err)
+		return nil, wrapStorageError("转入失败，已回滚转出群扣款", err)
+	}
+
+	result := &UpstreamTransferResult{
+		From: toBalanceResult(fromBalance),
+		To:   toBalanceResult(toBalance),
+	}
+	for _, side := range []*UpstreamBalanceResult{result.From, result.To} {
+		s.publishEvent(&models.UpstreamBalanceEvent{
+			GroupID:           side.GroupID,
+			Balance:           side.Balance,
+			MinBalance:        side.MinBalance,
+			AlertLimitPerHour: side.AlertLimitPerHour,
+			BelowMin:          side.Balance < side.MinBalance,
+			OccurredAt:        s.currentTime(),
+			Trigger:           "transfer",
+		})
+	}
+	return result, nil
+}
+
+// transferOperationIDs 返回划转的转出/转入操作 ID
+func transferOperationIDs(operationID string) (string, string) {
+	return operationID + ":out", operationID + ":in"
+}
+
+// SettlementOperationID 日结扣费的操作 ID，手动日结与自动日结共用，同一群同一日期只扣一次
+func SettlementOperationID(groupID int64, target time.Time) string {
+	return fmt.Sprintf("settle:%d:%s", groupID, target.Format("2006-01-02"))
+}
+
+// legacySettlementOperationIDs 旧版本写入的日结操作 ID（手动 settle:<日期>、自动 auto-settle:<群>:<日期>），判断是否已日结时一并检查
+func legacySettlementOperationIDs(groupID int64, target time.Time) []string {
+	date := target.Format("2006-01-02")
+	return []string{"settle:" + date, fmt.Sprintf("auto-settle:%d:%s", groupID, date)}
+}
+
+// SettleDaily 日结扣费；目标日期已由手动或自动日结扣过时不再扣款，只返回当前余额
 func (s *UpstreamBalanceServiceImpl) SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*SettlementResult, error) {
-	if s.paymentService == nil {
-		return nil, fmt.Errorf("支付服务未配置，无法日结")
+	plan, err := s.planSettlement(ctx, groupID, targetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	settled, err := s.settlementApplied(ctx, groupID, plan.target)
+	if err != nil {
+		return nil, err
+	}
+	if settled {
+		current, err := s.peekBalance(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+		return alreadySettledResult(groupID, plan.target, toBalanceResult(current)), nil
+	}
+
+	var balanceResult *UpstreamBalanceResult
+	below := false
+	if plan.total > 0 {
+		date := plan.target.Format("2006-01-02")
+		remark := fmt.Sprintf("日结 %s", date)
+		metadata := map[string]string{models.BalanceLogSettlementDateKey: date}
+		balance, belowMin, adjustErr := s.applyAdjust(ctx, groupID, -plan.total, operatorID, remark, models.BalanceOpDebit, operationID, metadata, "adjust")
+		if adjustErr != nil {
+			return nil, adjustErr
+		}
+		below = belowMin
+		balanceResult = balance
+	} else {
+		current, getErr := s.repo.Get(ctx, groupID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		balanceResult = toBalanceResult(current)
+		below = balanceResult.Balance < balanceResult.MinBalance
+	}
+
+	report := s.buildSettlementReport(plan.group, plan.target, plan.items, plan.total, balanceResult, plan.errors)
+
+	return &SettlementResult{
+		GroupID:        groupID,
+		TargetDate:     plan.target,
+		TotalDeduction: plan.total,
+		Balance:        balanceResult.Balance,
+		BelowMin:       below,
+		Report:         report,
+		Bindings:       plan.results,
+		Errors:         plan.errors,
+	}, nil
+}
+
+// PreviewSettlement 预览日结结果（不扣款、不写日志，余额只读查询）；目标日期已日结时不再预估扣减
+func (s *UpstreamBalanceServiceImpl) PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*SettlementResult, error) {
+	plan, err := s.planSettlement(ctx, groupID, targetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.peekBalance(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	projected := toBalanceResult(current)
+
+	settled, err := s.settlementApplied(ctx, groupID, plan.target)
+	if err != nil {
+		return nil, err
+	}
+	if settled {
+		result := alreadySettledResult(groupID, plan.target, projected)
+		result.Report = "🧪 预览模式（未实际扣款）\n" + result.Report
+		result.DryRun = true
+		return result, nil
+	}
+
+	projected.Balance -= plan.total
+	report := s.buildSettlementReport(plan.group, plan.target, plan.items, plan.total, projected, plan.errors)
+
+	return &SettlementResult{
+		GroupID:        groupID,
+		TargetDate:     plan.target,
+		TotalDeduction: plan.total,
+		Balance:        projected.Balance,
+		BelowMin:       projected.Balance < projected.MinBalance,
+		Report:         "🧪 预览模式（未实际扣款）\n" + report,
+		DryRun:         true,
+		Bindings:       plan.results,
+		Errors:         plan.errors,
+	}, nil
+}
+
+// peekBalance 只读获取余额，记录不存在时视为 0 余额，供预览等不应写库的场景使用
+func (s *UpstreamBalanceServiceImpl) peekBalance(ctx context.Context, groupID int64) (*models.UpstreamBalance, error) {
+	balance, err := s.repo.Find(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if balance == nil {
+		balance = &models.UpstreamBalance{GroupID: groupID}
+	}
+	return balance, nil
+}
+
+// alreadySettledResult 目标日期已日结时的结果：不扣减，只报告当前余额
+func alreadySettledResult(groupID int64, target time.Time, balance *UpstreamBalanceResult) *SettlementResult {
+	return &SettlementResult{
+		GroupID:        groupID,
+		TargetDate:     target,
+		Balance:        balance.Balance,
+		BelowMin:       balance.Balance < balance.MinBalance,
+		Report:         fmt.Sprintf("ℹ️ %s 已日结，不会重复扣款\n当前余额：%s CNY", target.Format("2006-01-02"), formatMoney(balance.Balance)),
+		AlreadySettled: true,
+	}
+}
+
+// settlementApplied 判断目标日期是否已通过手动或自动日结扣费
+func (s *UpstreamBalanceServiceImpl) settlementApplied(ctx context.Context, groupID int64, target time.Time) (bool, error) {
+	operationIDs := append([]string{SettlementOperationID(groupID, target)}, legacySettlementOperationIDs(groupID, target)...)
+	for _, operationID := range operationIDs {
+		log, err := s.repo.FindLogByOperation(ctx, groupID, operationID)
+		if err != nil {
+			return false, err
+		}
+		if log != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type settlementPlan struct {
+	group   *models.Group
+	target  time.Time
+	items   []settlementItem
+	total   float64
+	errors  []string
+	results []SettlementBindingResult
+}
+
+// fail 记录接口失败原因，该接口不计入日结
+func (p *settlementPlan) fail(binding models.InterfaceBinding, reason string) {
+	p.errors = append(p.errors, reason)
+	p.results = append(p.results, SettlementBindingResult{
+		InterfaceID:   binding.ID,
+		InterfaceName: binding.Name,
+		Error:         reason,
+	})
+}
+
+// add 记录计入日结的接口，扣减金额已按取整方式保留 2 位小数
+func (p *settlementPlan) add(item settlementItem) {
+	p.total = roundSettlementAmount(p.total+item.Deduction, config.SettlementRoundHalfUp)
+	p.items = append(p.items, item)
+	name := item.Binding.Name
+	if name == "" {
+		name = item.PZName
+	}
+	p.results = append(p.results, SettlementBindingResult{
+		InterfaceID:   item.Binding.ID,
+		InterfaceName: name,
+		Volume:        item.Volume,
+		Rate:          item.Rate,
+		Deduction:     item.Deduction,
+	})
+}
+
+// planSettlement 汇总各接口跑量并计算扣减金额
+func (s *UpstreamBalanceServiceImpl) planSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*settlementPlan, error) {
+	if !paymentservice.Available(s.paymentService) {
+		return nil, fmt.Errorf("%w，无法日结", paymentservice.ErrNotConfigured)
 	}
 
 	group, err := s.groupRepo.GetByTelegramID(ctx, groupID)
@@ -191,28 +567,31 @@ func (s *UpstreamBalanceServiceImpl) SettleDaily(ctx context.Context, groupID in
 	}
 	target := targetDate.In(loc)
 	if target.IsZero() {
-		now := time.Now().In(loc)
+		now := s.currentTime().In(loc)
 		target = previousBillingDate(now, loc)
 	}
 
 	start := time.Date(target.Year(), target.Month(), target.Day(), 0, 0, 0, 0, loc)
 	end := start.Add(24*time.Hour - time.Second)
 
-	items := make([]settlementItem, 0, len(group.Settings.InterfaceBindings))
-	errors := make([]string, 0)
-	totalDeduction := 0.0
+	plan := &settlementPlan{
+		group:  group,
+		target: target,
+		items:  make([]settlementItem, 0, len(group.Settings.InterfaceBindings)),
+		errors: make([]string, 0),
+	}
 
 	for _, binding := range group.Settings.InterfaceBindings {
 		summary, sumErr := s.paymentService.GetSummaryByDayByPZID(ctx, binding.ID, start, end)
 		if sumErr != nil {
 			logger.L().Errorf("SettleDaily summary failed: chat_id=%d pzid=%s err=%v", groupID, binding.ID, sumErr)
-			errors = append(errors, fmt.Sprintf("接口 %s 查询失败: %v", binding.ID, sumErr))
+			plan.fail(binding, fmt.Sprintf("接口 %s 查询失败: %v", binding.ID, sumErr))
 			continue
 		}
 
 		itemSummary := pickPZIDItem(summary, target)
 		if itemSummary == nil {
-			items = append(items, settlementItem{
+			plan.add(settlementItem{
 				Binding:     binding,
 				Volume:      0,
 				Rate:        0,
@@ -227,58 +606,28 @@ func (s *UpstreamBalanceServiceImpl) SettleDaily(ctx context.Context, groupID in
 
 		volume, parseVolumeErr := parseAmount(itemSummary.GrossAmount)
 		if parseVolumeErr != nil {
-			errors = append(errors, fmt.Sprintf("接口 %s 跑量解析失败: %v", binding.ID, parseVolumeErr))
+			plan.fail(binding, fmt.Sprintf("接口 %s 跑量解析失败: %v", binding.ID, parseVolumeErr))
 			continue
 		}
 
 		rate, parseRateErr := parseRate(binding.Rate)
 		if parseRateErr != nil {
-			errors = append(errors, fmt.Sprintf("接口 %s 费率解析失败: %v", binding.ID, parseRateErr))
+			plan.fail(binding, fmt.Sprintf("接口 %s 费率解析失败: %v", binding.ID, parseRateErr))
 			continue
 		}
 
-		deduction := volume * rate
-		totalDeduction += deduction
-		items = append(items, settlementItem{
+		plan.add(settlementItem{
 			Binding:   binding,
 			Volume:    volume,
 			Rate:      rate,
 			PZName:    trim(summary.PZName),
-			Deduction: deduction,
+			Deduction: roundSettlementAmount(volume*rate, s.rounding),
 			RawAmount: itemSummary.GrossAmount,
 			RawRate:   binding.Rate,
 		})
 	}
 
-	var balanceResult *UpstreamBalanceResult
-	below := false
-	if totalDeduction > 0 {
-		remark := fmt.Sprintf("日结 %s", target.Format("2006-01-02"))
-		balance, belowMin, adjustErr := s.Adjust(ctx, groupID, -totalDeduction, operatorID, remark, operationID)
-		if adjustErr != nil {
-			return nil, adjustErr
-		}
-		below = belowMin
-		balanceResult = balance
-	} else {
-		current, getErr := s.repo.Get(ctx, groupID)
-		if getErr != nil {
-			return nil, getErr
-		}
-		balanceResult = toBalanceResult(current)
-		below = balanceResult.Balance < balanceResult.MinBalance
-	}
-
-	report := s.buildSettlementReport(group, target, items, totalDeduction, balanceResult, errors)
-
-	return &SettlementResult{
-		GroupID:        groupID,
-		TargetDate:     target,
-		TotalDeduction: totalDeduction,
-		Balance:        balanceResult.Balance,
-		BelowMin:       below,
-		Report:         report,
-	}, nil
+	return plan, nil
 }
 
 // SubscribeEvents 获取调整事件通道
@@ -287,22 +636,35 @@ func (s *UpstreamBalanceServiceImpl) SubscribeEvents() <-chan *models.UpstreamBa
 }
 
 func (s *UpstreamBalanceServiceImpl) ensureUpstreamGroup(ctx context.Context, groupID int64) error {
+	_, err := s.loadUpstreamGroup(ctx, groupID)
+	return err
+}
+
+// loadUpstreamGroup 读取并校验上游群，区分群组不存在与数据库错误
+func (s *UpstreamBalanceServiceImpl) loadUpstreamGroup(ctx context.Context, groupID int64) (*models.Group, error) {
 	group, err := s.groupRepo.GetByTelegramID(ctx, groupID)
+	if errors.Is(err, repository.ErrGroupNotFound) {
+		return nil, ErrGroupNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("群组不存在")
+		logStorageError(err, "Failed to load upstream group: group_id=%d err=%v", groupID, err)
+		return nil, wrapStorageError("查询群组失败", err)
 	}
-	return s.validateUpstreamGroup(group)
+	if err := s.validateUpstreamGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
 }
 
 func (s *UpstreamBalanceServiceImpl) validateUpstreamGroup(group *models.Group) error {
 	if group == nil {
-		return fmt.Errorf("群组不存在")
+		return ErrGroupNotFound
 	}
 	if models.NormalizeGroupTier(group.Tier) != models.GroupTierUpstream {
-		return fmt.Errorf("仅上游群可使用余额功能")
+		return newValidationError("仅上游群可使用余额功能")
 	}
 	if len(group.Settings.InterfaceBindings) == 0 {
-		return fmt.Errorf("未绑定上游接口，无法使用余额功能")
+		return newValidationError("未绑定上游接口，无法使用余额功能")
 	}
 	return nil
 }
@@ -313,11 +675,24 @@ func (s *UpstreamBalanceServiceImpl) publishEvent(ev *models.UpstreamBalanceEven
 	}
 	select {
 	case s.events <- ev:
+		s.published.Add(1)
 	default:
+		s.dropped.Add(1)
+		upstreamBalanceEventsDropped.Inc()
 		logger.L().Warn("Upstream balance event channel full, dropping event")
 	}
 }
 
+// EventStats 返回事件通道的积压情况与累计发布、丢弃数量
+func (s *UpstreamBalanceServiceImpl) EventStats() UpstreamBalanceEventStats {
+	return UpstreamBalanceEventStats{
+		Queued:    len(s.events),
+		Capacity:  cap(s.events),
+		Published: s.published.Load(),
+		Dropped:   s.dropped.Load(),
+	}
+}
+
 func (s *UpstreamBalanceServiceImpl) buildSettlementReport(
 	group *models.Group,
 	target time.Time,
@@ -448,7 +823,7 @@ func trim(s string) string {
 }
 
 func formatMoney(v float64) string {
-	return fmt.Sprintf("%.2f", v)
+	return models.FormatMoney(v, models.CurrencyCNY)
 }
 
 func formatRatePercent(v float64) string {
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+type transferTestGroupRepository struct {
+	repository.GroupRepository
+	groups map[int64]*models.Group
+}
+
+func (r *transferTestGroupRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.Group, error) {
+	group, ok := r.groups[telegramID]
+	if !ok {
+		return nil, repository.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+type transferTestAdjust struct {
+	groupID     int64
+	delta       float64
+	opType      models.BalanceOperationType
+	operationID string
+}
+
+type transferTestBalanceRepository struct {
+	repository.UpstreamBalanceRepository
+	balances  map[int64]float64
+	applied   map[string]bool
+	adjusts   []transferTestAdjust
+	failGroup int64
+	remarks   []string
+	metadata  []map[string]string
+	gets      int
+}
+
+func (r *transferTestBalanceRepository) Get(ctx context.Context, groupID int64) (*models.UpstreamBalance, error) {
+	r.gets++
+	return &models.UpstreamBalance{GroupID: groupID, Balance: r.balances[groupID]}, nil
+}
+
+func (r *transferTestBalanceRepository) Find(ctx context.Context, groupID int64) (*models.UpstreamBalance, error) {
+	balance, ok := r.balances[groupID]
+	if !ok {
+		return nil, nil
+	}
+	return &models.UpstreamBalance{GroupID: groupID, Balance: balance}, nil
+}
+
+func (r *transferTestBalanceRepository) FindLogByOperation(ctx context.Context, groupID int64, operationID string) (*models.UpstreamBalanceLog, error) {
+	if !r.applied[operationID] {
+		return nil, nil
+	}
+	return &models.UpstreamBalanceLog{GroupID: groupID, OperationID: operationID}, nil
+}
+
+func (r *transferTestBalanceRepository) Adjust(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string, metadata map[string]string) (*models.UpstreamBalance, error) {
+	if groupID == r.failGroup {
+		return nil, errors.New("write failed")
+	}
+	if r.applied == nil {
+		r.applied = make(map[string]bool)
+	}
+	if !r.applied[operationID] {
+		r.applied[operationID] = true
+		r.balances[groupID] += delta
+		r.adjusts = append(r.adjusts, transferTestAdjust{groupID: groupID, delta: delta, opType: opType, operationID: operationID})
+		r.remarks = append(r.remarks, remark)
+		r.metadata = append(r.metadata, metadata)
+	}
+	return &models.UpstreamBalance{GroupID: groupID, Balance: r.balances[groupID]}, nil
+}
+
+func (r *transferTestBalanceRepository) AdjustNonNegative(ctx context.Context, groupID int64, delta float64, operatorID int64, remark string, opType models.BalanceOperationType, operationID string, metadata map[string]string) (*models.UpstreamBalance, error) {
+	if r.balances[groupID]+delta < 0 {
+		return nil, repository.ErrInsufficientBalance
+	}
+	return r.Adjust(ctx, groupID, delta, operatorID, remark, opType, operationID, metadata)
+}
+
+func newTransferTestService(balances map[int64]float64) (*UpstreamBalanceServiceImpl, *transferTestBalanceRepository) {
+	upstream := func(id int64) *models.Group {
+		return &models.Group{
+			TelegramID: id,
+			Tier:       models.GroupTierUpstream,
+			Settings: models.GroupSettings{
+				InterfaceBindings: []models.InterfaceBinding{{Name: "通道", ID: "1024"}},
+			},
+		}
+	}
+	groups := &transferTestGroupRepository{groups: map[int64]*models.Group{
+		-1001: upstream(-1001),
+		-1002: upstream(-1002),
+		-2001: {TelegramID: -2001, Tier: models.GroupTierMerchant},
+	}}
+	repo := &transferTestBalanceRepository{balances: balances}
+	svc := NewUpstreamBalanceService(repo, groups, nil, nil, "").(*UpstreamBalanceServiceImpl)
+	return svc, repo
+}
+
+func TestUpstreamBalanceTransferPairsAdjustments(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500, -1002: 20})
+
+	result, err := svc.Transfer(context.Background(), -1001, -1002, 120, 7, false, "transfer:-1001:42")
+	if err != nil {
+		t.Fatalf("Transfer returned error: %v", err)
+	}
+	if result.From.Balance != 380 || result.To.Balance != 140 {
+		t.Fatalf("unexpected balances: from=%.2f to=%.2f", result.From.Balance, result.To.Balance)
+	}
+
+	want := []transferTestAdjust{
+		{groupID: -1001, delta: -120, opType: models.BalanceOpDebit, operationID: "transfer:-1001:42:out"},
+		{groupID: -1002, delta: 120, opType: models.BalanceOpCredit, operationID: "transfer:-1001:42:in"},
+	}
+	if len(repo.adjusts) != len(want) {
+		t.Fatalf("expected %d adjusts, got %+v", len(want), repo.adjusts)
+	}
+	for i := range want {
+		if repo.adjusts[i] != want[i] {
+			t.Fatalf("adjust %d = %+v, want %+v", i, repo.adjusts[i], want[i])
+		}
+	}
+
+	// 相同 operationID 重放不会重复扣款/加款
+	if _, err := svc.Transfer(context.Background(), -1001, -1002, 120, 7, false, "transfer:-1001:42"); err != nil {
+		t.Fatalf("replayed Transfer returned error: %v", err)
+	}
+	if len(repo.adjusts) != len(want) || repo.balances[-1001] != 380 || repo.balances[-1002] != 140 {
+		t.Fatalf("replay should be idempotent, got adjusts=%+v balances=%v", repo.adjusts, repo.balances)
+	}
+}
+
+func TestUpstreamBalanceTransferRejectsInsufficientBalance(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 50, -1002: 0})
+
+	_, err := svc.Transfer(context.Background(), -1001, -1002, 80, 7, false, "transfer:-1001:43")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "余额不足") {
+		t.Fatalf("expected insufficient balance validation error, got %v", err)
+	}
+	if len(repo.adjusts) != 0 {
+		t.Fatalf("expected no adjustments, got %+v", repo.adjusts)
+	}
+
+	result, err := svc.Transfer(context.Background(), -1001, -1002, 80, 7, true, "transfer:-1001:44")
+	if err != nil {
+		t.Fatalf("Transfer with allowNegative returned error: %v", err)
+	}
+	if result.From.Balance != -30 || result.To.Balance != 80 {
+		t.Fatalf("unexpected balances: from=%.2f to=%.2f", result.From.Balance, result.To.Balance)
+	}
+}
+
+func TestUpstreamBalanceTransferHonorsGroupNegativeSetting(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 50, -1002: 0})
+	groups := svc.groupRepo.(*transferTestGroupRepository).groups
+	groups[-1001].Settings.BalanceAllowNegativeConfigured = true
+	groups[-1001].Settings.BalanceAllowNegative = false
+
+	_, err := svc.Transfer(context.Background(), -1001, -1002, 80, 7, true, "transfer:-1001:45")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "已禁止负余额") {
+		t.Fatalf("expected --allow-negative to be rejected, got %v", err)
+	}
+	if len(repo.adjusts) != 0 || repo.balances[-1001] != 50 {
+		t.Fatalf("expected no adjustments, got adjusts=%+v balances=%v", repo.adjusts, repo.balances)
+	}
+}
+
+func TestUpstreamBalanceTransferValidatesGroups(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500})
+
+	if _, err := svc.Transfer(context.Background(), -1001, -2001, 10, 7, false, "transfer:x"); err == nil || !strings.Contains(err.Error(), "转入群") {
+		t.Fatalf("expected target group validation error, got %v", err)
+	}
+	if _, err := svc.Transfer(context.Background(), -1001, -1001, 10, 7, false, "transfer:y"); err == nil {
+		t.Fatal("expected same-group transfer to be rejected")
+	}
+	if len(repo.adjusts) != 0 {
+		t.Fatalf("expected no adjustments, got %+v", repo.adjusts)
+	}
+}
+
+func TestUpstreamBalanceTransferRollsBackWhenCreditFails(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500, -1002: 0})
+	repo.failGroup = -1002
+
+	if _, err := svc.Transfer(context.Background(), -1001, -1002, 100, 7, false, "transfer:z"); err == nil || !strings.Contains(err.Error(), "已回滚") {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	if repo.balances[-1001] != 500 {
+		t.Fatalf("expected source balance restored, got %.2f", repo.balances[-1001])
+	}
+
+	// 已回滚的划转重放：转出为幂等空操作，也不能再给转入群加款
+	repo.failGroup = 0
+	if _, err := svc.Transfer(context.Background(), -1001, -1002, 100, 7, false, "transfer:z"); err == nil || !strings.Contains(err.Error(), "已失败并回滚") {
+		t.Fatalf("expected replay of rolled back transfer to be rejected, got %v", err)
+	}
+	if repo.balances[-1001] != 500 || repo.balances[-1002] != 0 {
+		t.Fatalf("replay must not move money, got %v", repo.balances)
+	}
+}
+
+func TestUpstreamBalanceCorrectSettlementReferencesSettlementDate(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 300})
+	settlementDate := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+
+	result, _, err := svc.CorrectSettlement(context.Background(), -1001, settlementDate, -45.5, 7, "漏算接口", "settle-fix:-1001:9")
+	if err != nil {
+		t.Fatalf("CorrectSettlement returned error: %v", err)
+	}
+	if result.Balance != 254.5 {
+		t.Fatalf("expected balance 254.5, got %.2f", result.Balance)
+	}
+	if len(repo.adjusts) != 1 || repo.adjusts[0].opType != models.BalanceOpSettlementCorrection || repo.adjusts[0].delta != -45.5 {
+		t.Fatalf("unexpected adjusts: %+v", repo.adjusts)
+	}
+	if got := repo.metadata[0][models.BalanceLogSettlementDateKey]; got != "2024-10-25" {
+		t.Fatalf("expected correction to reference 2024-10-25, got %q", got)
+	}
+	if repo.remarks[0] != "日结 2024-10-25 更正：漏算接口" {
+		t.Fatalf("unexpected remark: %s", repo.remarks[0])
+	}
+
+	result, _, err = svc.CorrectSettlement(context.Background(), -1001, settlementDate, 20, 7, "", "settle-fix:-1001:10")
+	if err != nil {
+		t.Fatalf("compensation returned error: %v", err)
+	}
+	if result.Balance != 274.5 {
+		t.Fatalf("expected balance 274.5 after compensation, got %.2f", result.Balance)
+	}
+
+	if _, _, err := svc.CorrectSettlement(context.Background(), -1001, time.Time{}, 20, 7, "", "settle-fix:-1001:11"); err == nil {
+		t.Fatal("expected missing settlement date to be rejected")
+	}
+}
+
+type settlementTestPaymentService struct {
+	paymentservice.Service
+	summaries map[string]*paymentservice.SummaryByPZID
+}
+
+func (s *settlementTestPaymentService) GetSummaryByDayByPZID(ctx context.Context, pzid string, start, end time.Time) (*paymentservice.SummaryByPZID, error) {
+	summary, ok := s.summaries[pzid]
+	if !ok {
+		return nil, errors.New("upstream timeout")
+	}
+	return summary, nil
+}
+
+func TestUpstreamBalanceSettleDailyReportsPartialFailures(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"2048": {PZName: "微信", Items: []*paymentservice.SummaryByPZIDItem{{Date: "2024-10-25", GrossAmount: "1000"}}},
+	}}
+	group := svc.groupRepo.(*transferTestGroupRepository).groups[-1001]
+	group.Settings.InterfaceBindings = []models.InterfaceBinding{
+		{Name: "支付宝", ID: "1024", Rate: "3%"},
+		{Name: "微信", ID: "2048", Rate: "2%"},
+	}
+
+	target := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+	result, err := svc.SettleDaily(context.Background(), -1001, target, 7, "settle:-1001:2024-10-25")
+	if err != nil {
+		t.Fatalf("SettleDaily returned error: %v", err)
+	}
+
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "接口 1024 查询失败") {
+		t.Fatalf("expected query failure in Errors, got %v", result.Errors)
+	}
+	if len(result.Bindings) != 2 {
+		t.Fatalf("expected 2 binding results, got %+v", result.Bindings)
+	}
+	failed, settled := result.Bindings[0], result.Bindings[1]
+	if failed.InterfaceID != "1024" || failed.Error == "" || failed.Deduction != 0 {
+		t.Fatalf("unexpected failed binding: %+v", failed)
+	}
+	if settled.InterfaceID != "2048" || settled.Error != "" || settled.Volume != 1000 || settled.Deduction != 20 {
+		t.Fatalf("unexpected settled binding: %+v", settled)
+	}
+
+	if result.TotalDeduction != 20 || repo.balances[-1001] != 480 {
+		t.Fatalf("expected successful binding settled, got total=%.2f balance=%.2f", result.TotalDeduction, repo.balances[-1001])
+	}
+	if !strings.Contains(result.Report, "接口 1024 查询失败") {
+		t.Fatalf("expected report to keep failure text, got %s", result.Report)
+	}
+}
+
+func TestUpstreamBalanceSettleRangeIsIdempotentAndSumsDays(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 1000})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"1024": {Items: []*paymentservice.SummaryByPZIDItem{
+			{Date: "2024-10-25", GrossAmount: "1000"},
+			{Date: "2024-10-26", GrossAmount: "2000"},
+			{Date: "2024-10-27", GrossAmount: "500"},
+		}},
+	}}
+	svc.groupRepo.(*transferTestGroupRepository).groups[-1001].Settings.InterfaceBindings[0].Rate = "2%"
+	svc.nowFunc = func() time.Time { return time.Date(2024, 11, 1, 12, 0, 0, 0, svc.location) }
+
+	start := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+	end := time.Date(2024, 10, 27, 0, 0, 0, 0, svc.location)
+	result, err := svc.SettleRange(context.Background(), -1001, start, end, 7, false)
+	if err != nil {
+		t.Fatalf("SettleRange returned error: %v", err)
+	}
+
+	if len(result.Days) != 3 {
+		t.Fatalf("expected 3 daily results, got %d", len(result.Days))
+	}
+	sum := 0.0
+	for _, daily := range result.Days {
+		sum += daily.TotalDeduction
+	}
+	if result.TotalDeduction != 70 || sum != result.TotalDeduction {
+		t.Fatalf("expected total 70 matching per-day sum, got total=%.2f sum=%.2f", result.TotalDeduction, sum)
+	}
+	if result.Balance != 930 || repo.balances[-1001] != 930 {
+		t.Fatalf("expected balance 930, got result=%.2f repo=%.2f", result.Balance, repo.balances[-1001])
+	}
+	wantIDs := []string{"settle:-1001:2024-10-25", "settle:-1001:2024-10-26", "settle:-1001:2024-10-27"}
+	for i, want := range wantIDs {
+		if repo.adjusts[i].operationID != want {
+			t.Fatalf("adjust %d operationID = %s, want %s", i, repo.adjusts[i].operationID, want)
+		}
+	}
+	for _, want := range []string{"2024-10-26：扣减 40.00 CNY", "合计扣减：70.00 CNY"} {
+		if !strings.Contains(result.Report, want) {
+			t.Fatalf("expected report to contain %q, got:\n%s", want, result.Report)
+		}
+	}
+
+	// 重新执行同一区间不会重复扣款
+	if _, err := svc.SettleRange(context.Background(), -1001, start, end, 7, false); err != nil {
+		t.Fatalf("replayed SettleRange returned error: %v", err)
+	}
+	if len(repo.adjusts) != 3 || repo.balances[-1001] != 930 {
+		t.Fatalf("replay should be idempotent, got adjusts=%+v balance=%.2f", repo.adjusts, repo.balances[-1001])
+	}
+}
+
+func TestUpstreamBalanceSettleRangeSkipsAutoSettledDays(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 1000})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"1024": {Items: []*paymentservice.SummaryByPZIDItem{
+			{Date: "2024-10-25", GrossAmount: "1000"},
+			{Date: "2024-10-26", GrossAmount: "2000"},
+		}},
+	}}
+	svc.groupRepo.(*transferTestGroupRepository).groups[-1001].Settings.InterfaceBindings[0].Rate = "2%"
+	svc.nowFunc = func() time.Time { return time.Date(2024, 11, 1, 12, 0, 0, 0, svc.location) }
+	repo.applied = map[string]bool{"auto-settle:-1001:2024-10-25": true}
+	day := func(d int) time.Time { return time.Date(2024, 10, d, 0, 0, 0, 0, svc.location) }
+
+	// 自动日结已扣过 25 日，区间日结只扣 26 日
+	if _, err := svc.SettleDaily(context.Background(), -1001, day(26), 0, SettlementOperationID(-1001, day(26))); err != nil {
+		t.Fatalf("SettleDaily returned error: %v", err)
+	}
+	result, err := svc.SettleRange(context.Background(), -1001, day(25), day(26), 7, false)
+	if err != nil {
+		t.Fatalf("SettleRange returned error: %v", err)
+	}
+	if result.TotalDeduction != 0 || len(repo.adjusts) != 1 || repo.balances[-1001] != 960 {
+		t.Fatalf("expected range over settled days to deduct nothing, got total=%.2f adjusts=%+v balance=%.2f", result.TotalDeduction, repo.adjusts, repo.balances[-1001])
+	}
+	if !strings.Contains(result.Report, "2024-10-25：已日结，跳过") || !strings.Contains(result.Report, "2024-10-26：已日结，跳过") {
+		t.Fatalf("expected settled days marked in report, got:\n%s", result.Report)
+	}
+}
+
+func TestUpstreamBalanceSettleRangeValidatesRange(t *testing.T) {
+	svc, _ := newTransferTestService(map[int64]float64{-1001: 1000})
+	svc.nowFunc = func() time.Time { return time.Date(2024, 11, 1, 12, 0, 0, 0, svc.location) }
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, svc.location) }
+
+	cases := []struct {
+		name       string
+		start, end time.Time
+		want       string
+	}{
+		{name: "reversed", start: day(10, 27), end: day(10, 25), want: "早于开始日期"},
+		{name: "too long", start: day(9, 1), end: day(10, 31), want: "最多 31 天"},
+		{name: "today", start: day(10, 30), end: day(11, 1), want: "只能日结今天之前的日期"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.SettleRange(context.Background(), -1001, tc.start, tc.end, 7, true)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestUpstreamBalancePreviewSettlementDefaultsToPreviousBillingDate(t *testing.T) {
+	svc, _ := newTransferTestService(map[int64]float64{-1001: 500})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"1024": {Items: []*paymentservice.SummaryByPZIDItem{{Date: "2024-10-31", GrossAmount: "100"}}},
+	}}
+	svc.groupRepo.(*transferTestGroupRepository).groups[-1001].Settings.InterfaceBindings[0].Rate = "1%"
+
+	// 北京时间 11 月 1 日凌晨，UTC 仍是 10 月 31 日：默认日结日期应为北京时间前一天 10 月 31 日
+	svc.nowFunc = func() time.Time { return time.Date(2024, 10, 31, 16, 30, 0, 0, time.UTC) }
+
+	result, err := svc.PreviewSettlement(context.Background(), -1001, time.Time{})
+	if err != nil {
+		t.Fatalf("PreviewSettlement returned error: %v", err)
+	}
+	if got := result.TargetDate.Format("2006-01-02"); got != "2024-10-31" {
+		t.Fatalf("expected target date 2024-10-31, got %s", got)
+	}
+	if result.TotalDeduction != 1 {
+		t.Fatalf("expected deduction from 2024-10-31 volume, got %.2f", result.TotalDeduction)
+	}
+}
+
+func TestUpstreamBalancePreviewSettlementIsReadOnly(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"1024": {Items: []*paymentservice.SummaryByPZIDItem{{Date: "2024-10-25", GrossAmount: "100"}}},
+	}}
+	svc.groupRepo.(*transferTestGroupRepository).groups[-1001].Settings.InterfaceBindings[0].Rate = "1%"
+	svc.nowFunc = func() time.Time { return time.Date(2024, 10, 26, 10, 0, 0, 0, time.UTC) }
+	target := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+
+	result, err := svc.PreviewSettlement(context.Background(), -1001, target)
+	if err != nil {
+		t.Fatalf("PreviewSettlement returned error: %v", err)
+	}
+	if result.AlreadySettled || result.TotalDeduction != 1 || result.Balance != 499 {
+		t.Fatalf("unexpected preview: settled=%v deduction=%.2f balance=%.2f", result.AlreadySettled, result.TotalDeduction, result.Balance)
+	}
+	if repo.gets != 0 || len(repo.adjusts) != 0 {
+		t.Fatalf("preview must not write: gets=%d adjusts=%d", repo.gets, len(repo.adjusts))
+	}
+
+	if _, err := svc.SettleDaily(context.Background(), -1001, target, 7, SettlementOperationID(-1001, target)); err != nil {
+		t.Fatalf("SettleDaily returned error: %v", err)
+	}
+	result, err = svc.PreviewSettlement(context.Background(), -1001, target)
+	if err != nil {
+		t.Fatalf("PreviewSettlement returned error: %v", err)
+	}
+	if !result.AlreadySettled || result.TotalDeduction != 0 || !strings.Contains(result.Report, "已日结") {
+		t.Fatalf("expected settled date to preview no deduction, got %+v", result)
+	}
+}
+
+func TestUpstreamBalanceSettleDailyChargesEachGroupDateOnce(t *testing.T) {
+	svc, repo := newTransferTestService(map[int64]float64{-1001: 500, -1002: 500})
+	svc.paymentService = &settlementTestPaymentService{summaries: map[string]*paymentservice.SummaryByPZID{
+		"1024": {Items: []*paymentservice.SummaryByPZIDItem{{Date: "2024-10-25", GrossAmount: "100"}}},
+	}}
+	groups := svc.groupRepo.(*transferTestGroupRepository).groups
+	groups[-1001].Settings.InterfaceBindings[0].Rate = "10%"
+	groups[-1002].Settings.InterfaceBindings[0].Rate = "10%"
+	target := time.Date(2024, 10, 25, 0, 0, 0, 0, svc.location)
+
+	// 两个群同一天各自日结，操作 ID 按群区分
+	for _, groupID := range []int64{-1001, -1002} {
+		if _, err := svc.SettleDaily(context.Background(), groupID, target, 7, SettlementOperationID(groupID, target)); err != nil {
+			t.Fatalf("SettleDaily(%d) returned error: %v", groupID, err)
+		}
+	}
+	if repo.balances[-1001] != 490 || repo.balances[-1002] != 490 {
+		t.Fatalf("expected both groups charged once, got %v", repo.balances)
+	}
+
+	// 手动日结后自动日结（或旧版本 ID 已扣过）不会重复扣款
+	repo.applied["auto-settle:-1002:2024-10-26"] = true
+	svc.paymentService.(*settlementTestPaymentService).summaries["1024"].Items = append(
+		svc.paymentService.(*settlementTestPaymentService).summaries["1024"].Items,
+		&paymentservice.SummaryByPZIDItem{Date: "2024-10-26", GrossAmount: "100"},
+	)
+	for _, tc := range []struct {
+		groupID int64
+		target  time.Time
+	}{
+		{groupID: -1001, target: target},
+		{groupID: -1002, target: target.AddDate(0, 0, 1)},
+	} {
+		result, err := svc.SettleDaily(context.Background(), tc.groupID, tc.target, 0, "other-op")
+		if err != nil {
+			t.Fatalf("SettleDaily(%d) returned error: %v", tc.groupID, err)
+		}
+		if !result.AlreadySettled || result.TotalDeduction != 0 || !strings.Contains(result.Report, "已日结") {
+			t.Fatalf("expected %d %s to be skipped, got %+v", tc.groupID, tc.target.Format("2006-01-02"), result)
+		}
+	}
+	if len(repo.adjusts) != 2 || repo.balances[-1001] != 490 || repo.balances[-1002] != 490 {
+		t.Fatalf("expected no further charges, got adjusts=%+v balances=%v", repo.adjusts, repo.balances)
+	}
+}
+
+func TestUpstreamBalanceAdjustNegativeProtection(t *testing.T) {
+	newService := func(settings models.GroupSettings) (*UpstreamBalanceServiceImpl, *transferTestBalanceRepository) {
+		settings.InterfaceBindings = []models.InterfaceBinding{{Name: "通道", ID: "1024"}}
+		groups := &transferTestGroupRepository{groups: map[int64]*models.Group{
+			-1001: {TelegramID: -1001, Tier: models.GroupTierUpstream, Settings: settings},
+		}}
+		repo := &transferTestBalanceRepository{balances: map[int64]float64{-1001: 100}}
+		return NewUpstreamBalanceService(repo, groups, nil, nil, "").(*UpstreamBalanceServiceImpl), repo
+	}
+	disallow := models.GroupSettings{BalanceAllowNegativeConfigured: true, BalanceAllowNegative: false}
+
+	t.Run("allowed by default", func(t *testing.T) {
+		svc, repo := newService(models.GroupSettings{})
+		result, _, err := svc.Adjust(context.Background(), -1001, -150, 7, "", "op-1")
+		if err != nil {
+			t.Fatalf("Adjust returned error: %v", err)
+		}
+		if result.Balance != -50 || repo.balances[-1001] != -50 {
+			t.Fatalf("expected balance -50, got result=%.2f repo=%.2f", result.Balance, repo.balances[-1001])
+		}
+	})
+
+	t.Run("disallowed rejects overdraft without writing", func(t *testing.T) {
+		svc, repo := newService(disallow)
+		_, _, err := svc.Adjust(context.Background(), -1001, -150, 7, "", "op-1")
+		if err == nil || !strings.Contains(err.Error(), "余额不足") || !strings.Contains(err.Error(), "100.00") {
+			t.Fatalf("expected insufficient balance error with current balance, got %v", err)
+		}
+		if repo.balances[-1001] != 100 || len(repo.adjusts) != 0 {
+			t.Fatalf("expected no write, got balance=%.2f adjusts=%+v", repo.balances[-1001], repo.adjusts)
+		}
+	})
+
+	t.Run("disallowed permits debit exactly to zero", func(t *testing.T) {
+		svc, repo := newService(disallow)
+		result, _, err := svc.Adjust(context.Background(), -1001, -100, 7, "", "op-1")
+		if err != nil {
+			t.Fatalf("Adjust returned error: %v", err)
+		}
+		if result.Balance != 0 || repo.balances[-1001] != 0 {
+			t.Fatalf("expected balance 0, got result=%.2f repo=%.2f", result.Balance, repo.balances[-1001])
+		}
+	})
+
+	t.Run("disallowed still permits credits", func(t *testing.T) {
+		svc, repo := newService(disallow)
+		if _, _, err := svc.Adjust(context.Background(), -1001, 20, 7, "", "op-1"); err != nil {
+			t.Fatalf("Adjust returned error: %v", err)
+		}
+		if repo.balances[-1001] != 120 {
+			t.Fatalf("expected balance 120, got %.2f", repo.balances[-1001])
+		}
+	})
+}
+
+func TestUpstreamBalancePublishEventDropsWhenChannelFull(t *testing.T) {
+	svc := NewUpstreamBalanceService(nil, nil, nil, nil, "").(*UpstreamBalanceServiceImpl)
+	capacity := cap(svc.events)
+	droppedBefore := upstreamBalanceEventsDropped.Value()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < capacity+3; i++ {
+			svc.publishEvent(&models.UpstreamBalanceEvent{GroupID: int64(i)})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishEvent blocked on a full channel")
+	}
+
+	stats := svc.EventStats()
+	if stats.Queued != capacity || stats.Capacity != capacity {
+		t.Fatalf("expected full channel %d/%d, got %d/%d", capacity, capacity, stats.Queued, stats.Capacity)
+	}
+	if stats.Published != uint64(capacity) || stats.Dropped != 3 {
+		t.Fatalf("expected published=%d dropped=3, got published=%d dropped=%d", capacity, stats.Published, stats.Dropped)
+	}
+	if got := upstreamBalanceEventsDropped.Value() - droppedBefore; got != 3 {
+		t.Fatalf("expected dropped metric to increase by 3, got %d", got)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/telegram/models"
+)
+
+// maxSettlementRangeDays 区间日结最多覆盖的天数
+const maxSettlementRangeDays = 31
+
+// SettleRange 按日期区间逐日日结（含首尾两天），dryRun 时仅预览不扣款；
+// 每天与单日日结共用同一群同一日期的操作 ID，已日结（含自动日结）的日期会跳过，重复或重叠执行不会重复扣款
+func (s *UpstreamBalanceServiceImpl) SettleRange(ctx context.Context, groupID int64, start, end time.Time, operatorID int64, dryRun bool) (*SettlementRangeResult, error) {
+	days, err := s.settlementRangeDays(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SettlementRangeResult{
+		GroupID: groupID,
+		Start:   days[0],
+		End:     days[len(days)-1],
+		Days:    make([]*SettlementResult, 0, len(days)),
+		DryRun:  dryRun,
+	}
+
+	for _, day := range days {
+		var daily *SettlementResult
+		var dayErr error
+		if dryRun {
+			daily, dayErr = s.PreviewSettlement(ctx, groupID, day)
+		} else {
+			daily, dayErr = s.SettleDaily(ctx, groupID, day, operatorID, SettlementOperationID(groupID, day))
+		}
+		if dayErr != nil {
+			return nil, fmt.Errorf("日结 %s 失败（之前的日期已处理，重新执行会跳过）: %w", day.Format("2006-01-02"), dayErr)
+		}
+		result.Days = append(result.Days, daily)
+		result.TotalDeduction += daily.TotalDeduction
+	}
+
+	var current *models.UpstreamBalance
+	if dryRun {
+		current, err = s.peekBalance(ctx, groupID)
+	} else {
+		current, err = s.repo.Get(ctx, groupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance := toBalanceResult(current)
+	if dryRun {
+		balance.Balance -= result.TotalDeduction
+	}
+	result.Balance = balance.Balance
+	result.BelowMin = balance.Balance < balance.MinBalance
+	result.Report = buildSettlementRangeReport(result, balance)
+	return result, nil
+}
+
+// settlementRangeDays 校验区间并返回区间内每天的零点（业务时区）；仅允许今天之前的日期
+func (s *UpstreamBalanceServiceImpl) settlementRangeDays(start, end time.Time) ([]time.Time, error) {
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("请指定日结的起止日期")
+	}
+
+	loc := s.location
+	if loc == nil {
+		loc = time.Local
+	}
+	startDay := time.Date(start.In(loc).Year(), start.In(loc).Month(), start.In(loc).Day(), 0, 0, 0, 0, loc)
+	endDay := time.Date(end.In(loc).Year(), end.In(loc).Month(), end.In(loc).Day(), 0, 0, 0, 0, loc)
+	if endDay.Before(startDay) {
+		return nil, fmt.Errorf("结束日期 %s 早于开始日期 %s", endDay.Format("2006-01-02"), startDay.Format("2006-01-02"))
+	}
+	if latest := previousBillingDate(s.currentTime(), loc); endDay.After(latest) {
+		return nil, fmt.Errorf("只能日结今天之前的日期（最近可日结 %s）", latest.Format("2006-01-02"))
+	}
+
+	days := make([]time.Time, 0)
+	for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		if len(days) == maxSettlementRangeDays {
+			return nil, fmt.Errorf("区间日结最多 %d 天", maxSettlementRangeDays)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func buildSettlementRangeReport(result *SettlementRangeResult, balance *UpstreamBalanceResult) string {
+	builder := &strings.Builder{}
+	if result.DryRun {
+		builder.WriteString("🧪 预览模式（未实际扣款）\n")
+	}
+	builder.WriteString(fmt.Sprintf("📊 区间日结 - %s 至 %s\n\n", result.Start.Format("2006-01-02"), result.End.Format("2006-01-02")))
+
+	failedDays := 0
+	for _, daily := range result.Days {
+		line := fmt.Sprintf("• %s：扣减 %s CNY", daily.TargetDate.Format("2006-01-02"), formatMoney(daily.TotalDeduction))
+		if daily.AlreadySettled {
+			line = fmt.Sprintf("• %s：已日结，跳过", daily.TargetDate.Format("2006-01-02"))
+		} else if len(daily.Errors) > 0 {
+			failedDays++
+			line += fmt.Sprintf("（⚠️ %d 个接口失败）", len(daily.Errors))
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString(fmt.Sprintf("\n合计扣减：%s CNY\n", formatMoney(result.TotalDeduction)))
+	if result.DryRun {
+		builder.WriteString(fmt.Sprintf("预计余额：%s CNY\n", formatMoney(balance.Balance)))
+	} else {
+		builder.WriteString(fmt.Sprintf("当前余额：%s CNY\n", formatMoney(balance.Balance)))
+	}
+	builder.WriteString(fmt.Sprintf("最低余额：%s CNY\n", formatMoney(balance.MinBalance)))
+	if balance.Balance < balance.MinBalance {
+		builder.WriteString("⚠️ 余额低于阈值，请尽快加款。\n")
+	}
+	if failedDays > 0 {
+		builder.WriteString(fmt.Sprintf("\n⚠️ 有 %d 天存在接口日结失败，请查看单日日结详情。\n", failedDays))
+	}
+
+	return strings.TrimSpace(builder.String())
+}
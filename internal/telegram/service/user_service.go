@@ -65,13 +65,19 @@ func (s *UserServiceImpl) GrantAdminPermission(ctx context.Context, targetID, gr
 		return fmt.Errorf("目标用户不存在")
 	}
 
-	// 3. 检查是否已经是管理员
+	// 3. Owner 已拥有全部权限（包括给自己授权）
+	if target.IsOwner() {
+		logger.L().Infof("Grant admin skipped: user %d is owner (granted_by=%d)", targetID, grantedBy)
+		return fmt.Errorf("用户 %d 是 Owner，已拥有全部权限，无需授权", targetID)
+	}
+
+	// 4. 检查是否已经是管理员
 	if target.IsAdmin() {
 		logger.L().Infof("User %d is already an admin", targetID)
-		return fmt.Errorf("用户已经是管理员")
+		return fmt.Errorf("用户 %d 已经是管理员，无需重复授权", targetID)
 	}
 
-	// 4. 执行授权
+	// 5. 执行授权
 	if err := s.userRepo.GrantAdmin(ctx, targetID, grantedBy); err != nil {
 		logger.L().Errorf("Failed to grant admin to %d: %v", targetID, err)
 		return fmt.Errorf("授权失败: %w", err)
@@ -102,16 +108,16 @@ func (s *UserServiceImpl) RevokeAdminPermission(ctx context.Context, targetID, r
 		return fmt.Errorf("目标用户不存在")
 	}
 
-	// 3. 不能撤销 Owner
+	// 3. 不能撤销 Owner（包括撤销自己）
 	if target.IsOwner() {
-		logger.L().Warnf("User %d attempted to revoke owner permission", revokedBy)
-		return fmt.Errorf("不能撤销 Owner 权限")
+		logger.L().Warnf("User %d attempted to revoke owner permission of %d", revokedBy, targetID)
+		return fmt.Errorf("用户 %d 是 Owner，不能撤销 Owner 权限", targetID)
 	}
 
 	// 4. 检查是否已经是普通用户
 	if target.Role == models.RoleUser {
 		logger.L().Infof("User %d is already a regular user", targetID)
-		return fmt.Errorf("用户已经是普通用户")
+		return fmt.Errorf("用户 %d 不是管理员，无需撤销", targetID)
 	}
 
 	// 5. 执行撤销
@@ -170,3 +176,33 @@ func (s *UserServiceImpl) UpdateUserActivity(ctx context.Context, telegramID int
 	}
 	return nil
 }
+
+// SetUserBlocked 设置或清除用户屏蔽 Bot 的标记
+func (s *UserServiceImpl) SetUserBlocked(ctx context.Context, telegramID int64, blocked bool) error {
+	if err := s.userRepo.SetBlocked(ctx, telegramID, blocked); err != nil {
+		logger.L().Errorf("Failed to set blocked=%v for user %d: %v", blocked, telegramID, err)
+		return fmt.Errorf("更新用户屏蔽状态失败: %w", err)
+	}
+	logger.L().Infof("User %d blocked flag set to %v", telegramID, blocked)
+	return nil
+}
+
+// GrantInviterAdmin 将邀请 Bot 入群的用户自动设为管理员（系统授权，granted_by 为 0），用户不存在时先注册
+func (s *UserServiceImpl) GrantInviterAdmin(ctx context.Context, info *TelegramUserInfo) (bool, error) {
+	user, err := s.userRepo.GetByTelegramID(ctx, info.TelegramID)
+	if err != nil {
+		if err := s.RegisterOrUpdateUser(ctx, info); err != nil {
+			return false, err
+		}
+	} else if user.IsAdmin() {
+		return false, nil
+	}
+
+	if err := s.userRepo.GrantAdmin(ctx, info.TelegramID, 0); err != nil {
+		logger.L().Errorf("Failed to auto grant admin to inviter %d: %v", info.TelegramID, err)
+		return false, fmt.Errorf("自动授权管理员失败: %w", err)
+	}
+
+	logger.L().Infof("Inviter %d auto granted admin", info.TelegramID)
+	return true, nil
+}
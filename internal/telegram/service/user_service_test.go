@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/repository"
+)
+
+type roleTestUserRepository struct {
+	repository.UserRepository
+	users   map[int64]*models.User
+	granted []int64
+	revoked []int64
+}
+
+func newRoleTestUserRepository() *roleTestUserRepository {
+	return &roleTestUserRepository{users: map[int64]*models.User{
+		1: {TelegramID: 1, Role: models.RoleOwner},
+		2: {TelegramID: 2, Role: models.RoleAdmin},
+		3: {TelegramID: 3, Role: models.RoleUser},
+		4: {TelegramID: 4, Role: models.RoleOwner},
+	}}
+}
+
+func (r *roleTestUserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.User, error) {
+	if user, ok := r.users[telegramID]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *roleTestUserRepository) GrantAdmin(ctx context.Context, telegramID int64, grantedBy int64) error {
+	r.granted = append(r.granted, telegramID)
+	return nil
+}
+
+func (r *roleTestUserRepository) RevokeAdmin(ctx context.Context, telegramID int64) error {
+	r.revoked = append(r.revoked, telegramID)
+	return nil
+}
+
+func TestGrantAdminPermissionEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		targetID  int64
+		grantedBy int64
+		wantErr   string
+	}{
+		{name: "owner grants regular user", targetID: 3, grantedBy: 1},
+		{name: "operator lacks permission", targetID: 3, grantedBy: 2, wantErr: "只有 Owner"},
+		{name: "target already admin", targetID: 2, grantedBy: 1, wantErr: "已经是管理员"},
+		{name: "target is another owner", targetID: 4, grantedBy: 1, wantErr: "是 Owner"},
+		{name: "owner grants self", targetID: 1, grantedBy: 1, wantErr: "是 Owner"},
+		{name: "target unknown", targetID: 99, grantedBy: 1, wantErr: "目标用户不存在"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRoleTestUserRepository()
+			svc := NewUserService(repo)
+
+			err := svc.GrantAdminPermission(context.Background(), tt.targetID, tt.grantedBy)
+			if tt.wantErr == "" {
+				if err != nil || len(repo.granted) != 1 {
+					t.Fatalf("expected grant to succeed, err=%v granted=%v", err, repo.granted)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(repo.granted) != 0 {
+				t.Fatalf("expected no grant, got %v", repo.granted)
+			}
+		})
+	}
+}
+
+func TestRevokeAdminPermissionEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		targetID  int64
+		revokedBy int64
+		wantErr   string
+	}{
+		{name: "owner revokes admin", targetID: 2, revokedBy: 1},
+		{name: "operator lacks permission", targetID: 3, revokedBy: 2, wantErr: "只有 Owner"},
+		{name: "target is owner", targetID: 4, revokedBy: 1, wantErr: "不能撤销 Owner"},
+		{name: "owner revokes self", targetID: 1, revokedBy: 1, wantErr: "不能撤销 Owner"},
+		{name: "target not admin", targetID: 3, revokedBy: 1, wantErr: "不是管理员"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRoleTestUserRepository()
+			svc := NewUserService(repo)
+
+			err := svc.RevokeAdminPermission(context.Background(), tt.targetID, tt.revokedBy)
+			if tt.wantErr == "" {
+				if err != nil || len(repo.revoked) != 1 {
+					t.Fatalf("expected revoke to succeed, err=%v revoked=%v", err, repo.revoked)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(repo.revoked) != 0 {
+				t.Fatalf("expected no revoke, got %v", repo.revoked)
+			}
+		})
+	}
+}
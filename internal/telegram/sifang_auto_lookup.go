@@ -25,7 +25,7 @@ const (
 )
 
 func (b *Bot) tryTriggerSifangAutoLookup(ctx context.Context, msg *botModels.Message, fileNames ...string) {
-	if b.paymentService == nil || b.groupService == nil || msg == nil {
+	if !paymentservice.Available(b.paymentService) || b.groupService == nil || msg == nil {
 		return
 	}
 
@@ -86,7 +86,7 @@ func (b *Bot) tryTriggerSifangAutoLookup(ctx context.Context, msg *botModels.Mes
 }
 
 func (b *Bot) performSifangOrderLookup(chatID int64, messageID int, merchantID int64, orderNos []string) {
-	if b.paymentService == nil {
+	if !paymentservice.Available(b.paymentService) {
 		return
 	}
 
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const cleanupCommand = "/cleanup"
+
+// stateSweeper 一类内存中带过期时间的状态及其清理函数
+type stateSweeper struct {
+	name  string
+	sweep func(now time.Time) int
+}
+
+// stateSweepResult 单类状态的清理结果
+type stateSweepResult struct {
+	name   string
+	purged int
+}
+
+// stateSweepers 返回所有需要过期清理的内存状态，未初始化的组件跳过
+func (b *Bot) stateSweepers() []stateSweeper {
+	sweepers := []stateSweeper{
+		{name: "订单联动状态", sweep: func(now time.Time) int {
+			b.orderCascadeMu.Lock()
+			defer b.orderCascadeMu.Unlock()
+			return b.purgeExpiredOrderCascadeStatesLocked(now)
+		}},
+		{name: "转单失败重试", sweep: b.purgeExpiredOrderCascadeFailures},
+	}
+	if b.configMenuService != nil {
+		sweepers = append(sweepers, stateSweeper{name: "配置菜单输入", sweep: b.configMenuService.PurgeExpiredUserStates})
+	}
+	if b.sifangFeature != nil {
+		sweepers = append(sweepers, stateSweeper{name: "下发确认", sweep: b.sifangFeature.PurgeExpiredPending})
+	}
+	return sweepers
+}
+
+// sweepExpiredStates 立即清理所有过期的内存状态
+func (b *Bot) sweepExpiredStates(now time.Time) []stateSweepResult {
+	sweepers := b.stateSweepers()
+	results := make([]stateSweepResult, 0, len(sweepers))
+	for _, sweeper := range sweepers {
+		results = append(results, stateSweepResult{name: sweeper.name, purged: sweeper.sweep(now)})
+	}
+	return results
+}
+
+// handleCleanup 处理 /cleanup 命令，立即清理过期的内存状态并汇报各类清理数量
+func (b *Bot) handleCleanup(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	results := b.sweepExpiredStates(time.Now())
+
+	total := 0
+	var text strings.Builder
+	for _, result := range results {
+		total += result.purged
+		text.WriteString(fmt.Sprintf("• %s：%d\n", result.name, result.purged))
+	}
+
+	logger.L().Infof("Expired states cleaned up: user_id=%d total=%d", msg.From.ID, total)
+	b.sendSuccessMessage(ctx, msg.Chat.ID, fmt.Sprintf("已清理过期状态 %d 条\n\n%s", total, text.String()), msg.ID)
+}
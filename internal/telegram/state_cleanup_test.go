@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+func TestSweepExpiredStatesPurgesAndCounts(t *testing.T) {
+	now := time.Now()
+	configMenu := service.NewConfigMenuService(nil)
+	b := &Bot{
+		configMenuService: configMenu,
+		orderCascadeStates: map[string]*orderCascadeState{
+			"live":    {Token: "live", ExpiresAt: now.Add(time.Hour)},
+			"expired": {Token: "expired", ExpiresAt: now.Add(-time.Minute)},
+		},
+		orderCascadeFailures: map[string]*orderCascadeDelivery{
+			"old": {State: &orderCascadeState{Token: "old", ExpiresAt: now.Add(-time.Minute)}},
+			"new": {State: &orderCascadeState{Token: "new", ExpiresAt: now.Add(time.Hour)}},
+		},
+	}
+	configMenu.SetUserState(-1001, 1, &models.UserState{ExpiresAt: now.Add(-time.Minute).Unix()})
+	configMenu.SetUserState(-1001, 2, &models.UserState{ExpiresAt: now.Add(time.Minute).Unix()})
+	configMenu.SetUserState(-1002, 3, &models.UserState{ExpiresAt: now.Add(-time.Hour).Unix()})
+
+	results := b.sweepExpiredStates(now)
+	purged := make(map[string]int, len(results))
+	for _, result := range results {
+		purged[result.name] = result.purged
+	}
+	want := map[string]int{"订单联动状态": 1, "转单失败重试": 1, "配置菜单输入": 2}
+	if len(purged) != len(want) {
+		t.Fatalf("unexpected sweepers: %v", purged)
+	}
+	for name, count := range want {
+		if purged[name] != count {
+			t.Fatalf("%s: expected %d purged, got %d", name, count, purged[name])
+		}
+	}
+
+	if _, ok := b.orderCascadeStates["live"]; !ok || len(b.orderCascadeStates) != 1 {
+		t.Fatalf("expected only live cascade state kept, got %v", b.orderCascadeStates)
+	}
+	if _, ok := b.orderCascadeFailures["new"]; !ok || len(b.orderCascadeFailures) != 1 {
+		t.Fatalf("expected only unexpired failure kept, got %v", b.orderCascadeFailures)
+	}
+	if configMenu.GetUserState(-1001, 2) == nil || configMenu.GetUserState(-1001, 1) != nil {
+		t.Fatal("expected only unexpired config state kept")
+	}
+
+	for _, result := range b.sweepExpiredStates(now) {
+		if result.purged != 0 {
+			t.Fatalf("expected second sweep to purge nothing, got %s=%d", result.name, result.purged)
+		}
+	}
+}
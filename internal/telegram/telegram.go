@@ -27,12 +27,20 @@ import (
 
 // Config Telegram Bot 配置
 type Config struct {
-	Token                string  // Bot Token
-	OwnerIDs             []int64 // Owner 用户 IDs
-	Debug                bool    // 是否开启调试模式
-	MessageRetentionDays int     // 消息保留天数（用于 TTL 索引）
-	ChannelID            int64   // 源频道 ID（用于转发功能）
-	DailyBillPushEnabled bool    // 是否启用每日账单自动推送
+	Token                       string        // Bot Token
+	OwnerIDs                    []int64       // Owner 用户 IDs
+	Debug                       bool          // 是否开启调试模式
+	MessageRetentionDays        int           // 消息保留天数（用于 TTL 索引）
+	ChannelID                   int64         // 源频道 ID（用于转发功能）
+	DailyBillPushEnabled        bool          // 是否启用每日账单自动推送
+	BalanceReminderInterval     time.Duration // 低余额定时提醒扫描间隔，<=0 时使用默认值
+	BalanceWriteConflictRetries int           // 余额事务写冲突最大重试次数，<0 时使用默认值
+	ConfigMenuCloseMode         string        // 配置菜单关闭方式（config.ConfigMenuCloseDelete / ConfigMenuCloseEdit），为空时直接删除
+	SettlementRounding          string        // 日结扣减金额取整方式（round / ceil / floor），为空时四舍五入
+	SettlementConcurrency       int           // 自动日结并发处理的上游群数量上限，<=0 时使用默认值
+	BalanceLogRetention         time.Duration // 上游余额日志保留时长，<=0 时不清理
+	BalanceLogKeepLatest        int           // 清理余额日志时每个群至少保留的最近条数
+	AutoGrantInviterAdmin       bool          // Bot 被拉入群组时是否自动将邀请人设为管理员
 }
 
 // Bot Telegram Bot 服务
@@ -40,29 +48,40 @@ type Bot struct {
 	bot                  *bot.Bot
 	db                   *mongo.Database
 	ownerIDs             []int64
-	messageRetentionDays int // 消息保留天数
+	commands             []commandSpec // 已注册的文本命令，用于生成命令菜单
+	messageRetentionDays int           // 消息保留天数
 	workerPool           *WorkerPool
 	startTime            time.Time
 	tempMessageCtx       context.Context
 	tempMessageCancel    context.CancelFunc
+	configMenuCloseMode  string // 配置菜单关闭方式
+	autoGrantInviter     bool   // Bot 入群时自动将邀请人设为管理员
+	settlementWorkers    int    // 自动日结并发处理的上游群数量上限
 
 	// Service 层（业务逻辑）
-	userService       service.UserService
-	groupService      service.GroupService
-	messageService    service.MessageService
-	configMenuService *service.ConfigMenuService
-	forwardService    service.ForwardService    // 转发服务
-	accountingService service.AccountingService // 收支记账服务
-	paymentService    paymentservice.Service
-	balanceService    service.UpstreamBalanceService
+	userService         service.UserService
+	groupService        service.GroupService
+	messageService      service.MessageService
+	configMenuService   *service.ConfigMenuService
+	forwardService      service.ForwardService    // 转发服务
+	accountingService   service.AccountingService // 收支记账服务
+	paymentService      paymentservice.Service
+	balanceService      service.UpstreamBalanceService
+	featureUsageService service.FeatureUsageService
+	settingService      service.SettingService
 
 	// 功能管理器
 	featureManager *features.Manager
 	sifangFeature  *sifangfeature.Feature
+	balanceFeature *upstream.BalanceFeature
 
 	dailySummaryScheduler *dailySummaryScheduler
 	upstreamScheduler     *upstreamSettlementScheduler
+	withdrawReconciler    *withdrawReconcileScheduler
 	balanceMonitor        *upstreamBalanceMonitor
+	orderCascadeRetrier   *orderCascadeRetryWorker
+	accountingResetter    *accountingResetScheduler
+	balanceLogPruner      *balanceLogRetentionScheduler
 
 	// Repository 层（仅用于初始化）
 	userRepo            repository.UserRepository
@@ -71,10 +90,31 @@ type Bot struct {
 	forwardRecordRepo   repository.ForwardRecordRepository
 	accountingRepo      repository.AccountingRepository
 	withdrawQuoteRepo   repository.WithdrawQuoteRepository
+	sendMoneyRepo       repository.SendMoneyRecordRepository
 	upstreamBalanceRepo repository.UpstreamBalanceRepository
+	featureUsageRepo    repository.FeatureUsageRepository
+	settingRepo         repository.SettingRepository
 
-	orderCascadeStates map[string]*orderCascadeState
-	orderCascadeMu     sync.RWMutex
+	orderCascadeStates   map[string]*orderCascadeState
+	orderCascadeFailures map[string]*orderCascadeDelivery
+	orderCascadeUnbound  map[string]*orderCascadeInterfaceMismatch
+	orderCascadeMu       sync.RWMutex
+
+	inlineMerchantMu      sync.Mutex
+	inlineMerchantIDs     []int64   // 内联查单缓存的已知商户号
+	inlineMerchantExpires time.Time // 商户号缓存过期时间
+
+	accountingDedupClaims sync.Map // 已处理的重复记账确认提示（key: "chatID:messageID"，value: 处理时间）
+
+	nowFunc func() time.Time // 测试中注入固定时钟，为空时使用 time.Now
+}
+
+// currentTime 返回当前时间，转单有效期与定时任务统一经此取时
+func (b *Bot) currentTime() time.Time {
+	if b != nil && b.nowFunc != nil {
+		return b.nowFunc()
+	}
+	return time.Now()
 }
 
 // New 创建 Telegram Bot 实例
@@ -91,7 +131,10 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 	forwardRecordRepo := repository.NewForwardRecordRepository(db)
 	accountingRepo := repository.NewMongoAccountingRepository(db)
 	withdrawQuoteRepo := repository.NewMongoWithdrawQuoteRepository(db)
-	upstreamBalanceRepo := repository.NewMongoUpstreamBalanceRepository(db)
+	sendMoneyRepo := repository.NewMongoSendMoneyRecordRepository(db)
+	upstreamBalanceRepo := repository.NewMongoUpstreamBalanceRepository(db, cfg.BalanceWriteConflictRetries)
+	featureUsageRepo := repository.NewMongoFeatureUsageRepository(db)
+	settingRepo := repository.NewMongoSettingRepository(db)
 
 	// 创建 services
 	userService := service.NewUserService(userRepo)
@@ -99,7 +142,9 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 	messageService := service.NewMessageService(messageRepo, groupRepo)
 	configMenuService := service.NewConfigMenuService(groupService)
 	accountingService := service.NewAccountingService(accountingRepo, groupRepo)
-	balanceService := service.NewUpstreamBalanceService(upstreamBalanceRepo, groupRepo, paymentSvc)
+	balanceService := service.NewUpstreamBalanceService(upstreamBalanceRepo, groupRepo, userRepo, paymentSvc, cfg.SettlementRounding)
+	featureUsageService := service.NewFeatureUsageService(featureUsageRepo)
+	settingService := service.NewSettingService(settingRepo)
 
 	// 创建转发服务（如果配置了频道 ID）
 	var forwardService service.ForwardService
@@ -117,6 +162,7 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 
 	// 创建功能管理器
 	featureManager := features.NewManager(groupService)
+	featureManager.SetUsageService(featureUsageService)
 
 	// 创建 worker pool (10 workers, 100 queue size)
 	workerPool := NewWorkerPool(10, 100)
@@ -137,6 +183,9 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 		db:                   db,
 		ownerIDs:             cfg.OwnerIDs,
 		messageRetentionDays: cfg.MessageRetentionDays,
+		configMenuCloseMode:  cfg.ConfigMenuCloseMode,
+		autoGrantInviter:     cfg.AutoGrantInviterAdmin,
+		settlementWorkers:    cfg.SettlementConcurrency,
 		workerPool:           workerPool,
 		startTime:            time.Now(),
 		userService:          userService,
@@ -146,6 +195,8 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 		forwardService:       forwardService,
 		accountingService:    accountingService,
 		balanceService:       balanceService,
+		featureUsageService:  featureUsageService,
+		settingService:       settingService,
 		paymentService:       paymentSvc,
 		featureManager:       featureManager,
 		userRepo:             userRepo,
@@ -154,7 +205,10 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 		forwardRecordRepo:    forwardRecordRepo,
 		accountingRepo:       accountingRepo,
 		withdrawQuoteRepo:    withdrawQuoteRepo,
+		sendMoneyRepo:        sendMoneyRepo,
 		upstreamBalanceRepo:  upstreamBalanceRepo,
+		featureUsageRepo:     featureUsageRepo,
+		settingRepo:          settingRepo,
 		orderCascadeStates:   make(map[string]*orderCascadeState),
 	}
 
@@ -178,9 +232,13 @@ func New(cfg Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bo
 		return nil, fmt.Errorf("failed to ensure indexes: %w", err)
 	}
 
-	telegramBot.initUpstreamBalanceMonitor()
+	telegramBot.initUpstreamBalanceMonitor(cfg.BalanceReminderInterval)
 	telegramBot.initDailySummaryScheduler(cfg.DailyBillPushEnabled)
 	telegramBot.initUpstreamSettlementScheduler(cfg.DailyBillPushEnabled)
+	telegramBot.initWithdrawReconcileScheduler(cfg.DailyBillPushEnabled)
+	telegramBot.initOrderCascadeRetryWorker()
+	telegramBot.initAccountingResetScheduler()
+	telegramBot.initBalanceLogRetentionScheduler(cfg.BalanceLogRetention, cfg.BalanceLogKeepLatest)
 
 	logger.L().Info("Telegram bot initialized successfully")
 	return telegramBot, nil
@@ -202,20 +260,45 @@ func (b *Bot) asyncHandler(handler bot.HandlerFunc) bot.HandlerFunc {
 
 // InitFromConfig 从应用配置初始化 Telegram Bot
 func InitFromConfig(cfg *config.Config, db *mongo.Database, paymentSvc paymentservice.Service) (*Bot, error) {
+	return InitFromBotConfig(cfg, config.BotConfig{
+		Name:          "default",
+		TelegramToken: cfg.TelegramToken,
+		OwnerIDs:      cfg.BotOwnerIDs,
+		MongoDBName:   cfg.MongoDBName,
+		ChannelID:     cfg.ChannelID,
+	}, db, paymentSvc)
+}
+
+// InitFromBotConfig 按单个 Bot 实例配置初始化 Telegram Bot，全局开关沿用应用配置
+func InitFromBotConfig(cfg *config.Config, botCfg config.BotConfig, db *mongo.Database, paymentSvc paymentservice.Service) (*Bot, error) {
 	telegramCfg := Config{
-		Token:                cfg.TelegramToken,
-		OwnerIDs:             cfg.BotOwnerIDs,
-		Debug:                false, // 可根据需要从环境变量读取
-		MessageRetentionDays: cfg.MessageRetentionDays,
-		ChannelID:            cfg.ChannelID,
-		DailyBillPushEnabled: cfg.DailyBillPushEnabled,
+		Token:                       botCfg.TelegramToken,
+		OwnerIDs:                    botCfg.OwnerIDs,
+		Debug:                       false, // 可根据需要从环境变量读取
+		MessageRetentionDays:        cfg.MessageRetentionDays,
+		ChannelID:                   botCfg.ChannelID,
+		DailyBillPushEnabled:        cfg.DailyBillPushEnabled,
+		BalanceReminderInterval:     cfg.BalanceReminderInterval,
+		BalanceWriteConflictRetries: cfg.BalanceWriteConflictRetries,
+		ConfigMenuCloseMode:         cfg.ConfigMenuCloseMode,
+		SettlementRounding:          cfg.SettlementRounding,
+		SettlementConcurrency:       cfg.SettlementConcurrency,
+		BalanceLogRetention:         cfg.BalanceLogRetention,
+		BalanceLogKeepLatest:        cfg.BalanceLogKeepLatest,
+		AutoGrantInviterAdmin:       cfg.AutoGrantInviterAdmin,
 	}
 	return New(telegramCfg, db, paymentSvc)
 }
 
+// BalanceService 返回上游余额服务（供管理 API 复用）
+func (b *Bot) BalanceService() service.UpstreamBalanceService {
+	return b.balanceService
+}
+
 // Start 启动 Bot（阻塞式，应在 goroutine 中运行）
 func (b *Bot) Start(ctx context.Context) error {
 	logger.L().Info("Starting Telegram bot...")
+	b.syncCommandMenus(ctx)
 	b.bot.Start(ctx)
 	logger.L().Info("Telegram bot stopped")
 	return nil
@@ -246,11 +329,31 @@ func (b *Bot) Stop(ctx context.Context) error {
 		b.upstreamScheduler = nil
 	}
 
+	if b.withdrawReconciler != nil {
+		b.withdrawReconciler.stop()
+		b.withdrawReconciler = nil
+	}
+
 	if b.balanceMonitor != nil {
 		b.balanceMonitor.stop()
 		b.balanceMonitor = nil
 	}
 
+	if b.orderCascadeRetrier != nil {
+		b.orderCascadeRetrier.stop()
+		b.orderCascadeRetrier = nil
+	}
+
+	if b.accountingResetter != nil {
+		b.accountingResetter.stop()
+		b.accountingResetter = nil
+	}
+
+	if b.balanceLogPruner != nil {
+		b.balanceLogPruner.stop()
+		b.balanceLogPruner = nil
+	}
+
 	// bot.Stop() 通过 context 取消实现
 	return nil
 }
@@ -328,6 +431,13 @@ func (b *Bot) ensureIndexes(ctx context.Context) error {
 		logger.L().Debug("Withdraw quote indexes ensured")
 	}
 
+	if b.sendMoneyRepo != nil {
+		if err := b.sendMoneyRepo.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("failed to ensure send money record indexes: %w", err)
+		}
+		logger.L().Debug("Send money record indexes ensured")
+	}
+
 	if b.upstreamBalanceRepo != nil {
 		if err := b.upstreamBalanceRepo.EnsureIndexes(ctx); err != nil {
 			return fmt.Errorf("failed to ensure upstream balance indexes: %w", err)
@@ -335,6 +445,20 @@ func (b *Bot) ensureIndexes(ctx context.Context) error {
 		logger.L().Debug("Upstream balance indexes ensured")
 	}
 
+	if b.featureUsageRepo != nil {
+		if err := b.featureUsageRepo.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("failed to ensure feature usage indexes: %w", err)
+		}
+		logger.L().Debug("Feature usage indexes ensured")
+	}
+
+	if b.settingRepo != nil {
+		if err := b.settingRepo.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("failed to ensure setting indexes: %w", err)
+		}
+		logger.L().Debug("Setting indexes ensured")
+	}
+
 	return nil
 }
 
@@ -344,7 +468,7 @@ func (b *Bot) initDailySummaryScheduler(enabled bool) {
 		return
 	}
 
-	if b.paymentService == nil {
+	if !paymentservice.Available(b.paymentService) {
 		logger.L().Warn("Daily bill push not started: payment service is not configured")
 		return
 	}
@@ -359,12 +483,12 @@ func (b *Bot) initDailySummaryScheduler(enabled bool) {
 	scheduler.start()
 }
 
-func (b *Bot) initUpstreamBalanceMonitor() {
+func (b *Bot) initUpstreamBalanceMonitor(reminderInterval time.Duration) {
 	if b.balanceService == nil || b.groupService == nil {
 		logger.L().Warn("Upstream balance monitor not started: service unavailable")
 		return
 	}
-	monitor := newUpstreamBalanceMonitor(b, b.balanceService, b.groupService)
+	monitor := newUpstreamBalanceMonitor(b, b.balanceService, b.groupService, reminderInterval)
 	b.balanceMonitor = monitor
 	monitor.start()
 }
@@ -380,7 +504,7 @@ func (b *Bot) initUpstreamSettlementScheduler(enabled bool) {
 		return
 	}
 
-	if b.paymentService == nil {
+	if !paymentservice.Available(b.paymentService) {
 		logger.L().Warn("Upstream settlement scheduler not started: payment service not configured")
 		return
 	}
@@ -390,6 +514,67 @@ func (b *Bot) initUpstreamSettlementScheduler(enabled bool) {
 	scheduler.start()
 }
 
+func (b *Bot) initWithdrawReconcileScheduler(enabled bool) {
+	if !enabled {
+		logger.L().Info("Withdraw reconcile scheduler disabled via config")
+		return
+	}
+
+	if b.sendMoneyRepo == nil {
+		logger.L().Warn("Withdraw reconcile scheduler not started: send money repository unavailable")
+		return
+	}
+
+	if !paymentservice.Available(b.paymentService) {
+		logger.L().Warn("Withdraw reconcile scheduler not started: payment service not configured")
+		return
+	}
+
+	scheduler := newWithdrawReconcileScheduler(b)
+	b.withdrawReconciler = scheduler
+	scheduler.start()
+}
+
+// initBalanceLogRetentionScheduler 启动上游余额日志定期清理，retention <= 0 时不清理
+func (b *Bot) initBalanceLogRetentionScheduler(retention time.Duration, keepLatest int) {
+	if retention <= 0 {
+		logger.L().Info("Balance log retention disabled via config")
+		return
+	}
+
+	if b.balanceService == nil {
+		logger.L().Warn("Balance log retention scheduler not started: balance service unavailable")
+		return
+	}
+
+	scheduler := newBalanceLogRetentionScheduler(b, retention, keepLatest)
+	b.balanceLogPruner = scheduler
+	scheduler.start()
+}
+
+// initAccountingResetScheduler 启动记账周期清零任务（群组默认不清零，未配置周期的群组不受影响）
+func (b *Bot) initAccountingResetScheduler() {
+	if b.accountingService == nil || b.groupService == nil {
+		logger.L().Warn("Accounting reset scheduler not started: service unavailable")
+		return
+	}
+
+	scheduler := newAccountingResetScheduler(b)
+	b.accountingResetter = scheduler
+	scheduler.start()
+}
+
+func (b *Bot) initOrderCascadeRetryWorker() {
+	if !paymentservice.Available(b.paymentService) {
+		logger.L().Warn("Order cascade retry worker not started: payment service not configured")
+		return
+	}
+
+	worker := newOrderCascadeRetryWorker(b)
+	b.orderCascadeRetrier = worker
+	worker.start()
+}
+
 // registerFeatures 注册所有功能插件
 func (b *Bot) registerFeatures() {
 	// 注册计算器功能
@@ -400,12 +585,14 @@ func (b *Bot) registerFeatures() {
 
 	// 注册接口绑定功能
 	b.featureManager.Register(upstream.New(b.groupService, b.userService))
-	b.featureManager.Register(upstream.NewBalanceFeature(b.balanceService, b.userService, b.groupService))
+	b.balanceFeature = upstream.NewBalanceFeature(b.balanceService, b.userService, b.groupService)
+	b.featureManager.Register(b.balanceFeature)
 	b.featureManager.Register(upstream.NewSummaryFeature(b.paymentService))
 
 	// 注册四方支付功能
 	b.sifangFeature = sifangfeature.New(b.paymentService, b.userService)
 	b.sifangFeature.SetWithdrawQuoteRepository(b.withdrawQuoteRepo)
+	b.sifangFeature.SetSendMoneyRecordRepository(b.sendMoneyRepo)
 	b.featureManager.Register(b.sifangFeature)
 
 	// 注册加密货币价格查询功能
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go_bot/internal/logger"
@@ -18,7 +19,16 @@ type balanceAlertState struct {
 	lastScan     time.Time
 }
 
-const monitorDefaultAlertLimit = 3
+const (
+	monitorDefaultAlertLimit = 3
+	// monitorDefaultInterval 定时扫描余额的默认间隔；未发生余额调整的群也会被提醒
+	monitorDefaultInterval = 10 * time.Minute
+	// monitorScanJitter 容忍定时器抖动，避免群间隔与扫描间隔相同时隔一轮才提醒
+	monitorScanJitter = 30 * time.Second
+)
+
+// quietHoursLocation 免打扰时段按北京时间计算
+var quietHoursLocation = mustLoadChinaLocation()
 
 type upstreamBalanceMonitor struct {
 	bot            *Bot
@@ -30,15 +40,19 @@ type upstreamBalanceMonitor struct {
 	statesMu       sync.Mutex
 	states         map[int64]*balanceAlertState
 	interval       time.Duration
+	processed      atomic.Uint64 // 已从事件通道取出并处理的余额事件数
 }
 
-func newUpstreamBalanceMonitor(bot *Bot, balanceSvc service.UpstreamBalanceService, groupSvc service.GroupService) *upstreamBalanceMonitor {
+func newUpstreamBalanceMonitor(bot *Bot, balanceSvc service.UpstreamBalanceService, groupSvc service.GroupService, interval time.Duration) *upstreamBalanceMonitor {
+	if interval <= 0 {
+		interval = monitorDefaultInterval
+	}
 	return &upstreamBalanceMonitor{
 		bot:            bot,
 		balanceService: balanceSvc,
 		groupService:   groupSvc,
 		states:         make(map[int64]*balanceAlertState),
-		interval:       10 * time.Minute, // base ticker; per-group间隔在评估时控制
+		interval:       interval, // base ticker; per-group间隔在评估时控制
 	}
 }
 
@@ -84,6 +98,7 @@ func (m *upstreamBalanceMonitor) consumeEvents(ctx context.Context) {
 			if ev == nil {
 				continue
 			}
+			m.processed.Add(1)
 			group, err := m.groupService.GetGroupInfo(ctx, ev.GroupID)
 			if err != nil {
 				logger.L().Warnf("Balance monitor failed to load group %d: %v", ev.GroupID, err)
@@ -165,7 +180,7 @@ func (m *upstreamBalanceMonitor) evaluateAndAlert(ctx context.Context, group *mo
 		state = &balanceAlertState{}
 		m.states[group.TelegramID] = state
 	}
-	now := time.Now()
+	now := m.bot.currentTime()
 
 	if state.windowStart.IsZero() || now.Sub(state.windowStart) >= time.Hour {
 		state.windowStart = now
@@ -177,7 +192,7 @@ func (m *upstreamBalanceMonitor) evaluateAndAlert(ctx context.Context, group *mo
 		if interval <= 0 {
 			interval = 5 * time.Minute
 		}
-		if !state.lastScan.IsZero() && now.Sub(state.lastScan) < interval {
+		if !state.lastScan.IsZero() && now.Sub(state.lastScan) < interval-monitorScanJitter {
 			m.statesMu.Unlock()
 			return
 		}
@@ -200,6 +215,14 @@ func (m *upstreamBalanceMonitor) evaluateAndAlert(ctx context.Context, group *mo
 		return
 	}
 
+	// 免打扰时段内不发送也不占用提醒次数，时段结束后的定时扫描会重新提醒
+	if resumeAt, quiet := models.QuietHoursResumeAt(group.Settings, now.In(quietHoursLocation)); quiet {
+		state.low = true
+		m.statesMu.Unlock()
+		logger.L().Debugf("Balance alert deferred by quiet hours: chat_id=%d resume_at=%s", group.TelegramID, resumeAt.Format(time.RFC3339))
+		return
+	}
+
 	state.low = true
 	state.sentInWindow++
 	m.statesMu.Unlock()
@@ -233,5 +256,5 @@ func (m *upstreamBalanceMonitor) sendAlert(ctx context.Context, group *models.Gr
 }
 
 func formatAmount(value float64) string {
-	return fmt.Sprintf("%.2f", value)
+	return models.FormatMoney(value, models.CurrencyCNY)
 }
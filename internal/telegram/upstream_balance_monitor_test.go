@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
 )
 
 func TestUpstreamBalanceMonitorEvaluateAndAlertLowBalanceNoPanic(t *testing.T) {
@@ -83,3 +85,91 @@ func TestUpstreamBalanceMonitorEvaluateAndAlertEnforceInterval(t *testing.T) {
 		t.Fatalf("expected 1 alert due to interval gate, got %d", alertCount)
 	}
 }
+
+type reminderTestGroupService struct {
+	service.GroupService
+	groups []*models.Group
+}
+
+func (s *reminderTestGroupService) ListActiveGroups(ctx context.Context) ([]*models.Group, error) {
+	return s.groups, nil
+}
+
+type reminderTestBalanceService struct {
+	service.UpstreamBalanceService
+	balances []*service.UpstreamBalanceResult
+}
+
+func (s *reminderTestBalanceService) ListAll(ctx context.Context) ([]*service.UpstreamBalanceResult, error) {
+	return s.balances, nil
+}
+
+func TestUpstreamBalanceMonitorScanRemindsLowBalanceGroups(t *testing.T) {
+	upstream := func(id int64) *models.Group {
+		return &models.Group{
+			TelegramID: id,
+			Tier:       models.GroupTierUpstream,
+			Settings: models.GroupSettings{
+				InterfaceBindings: []models.InterfaceBinding{{Name: "通道", ID: "1024"}},
+			},
+		}
+	}
+
+	var reminded []int64
+	monitor := newUpstreamBalanceMonitor(nil,
+		&reminderTestBalanceService{balances: []*service.UpstreamBalanceResult{
+			{GroupID: -1001, Balance: 50, MinBalance: 100, AlertLimitPerHour: 1},
+			{GroupID: -1002, Balance: 500, MinBalance: 100},
+		}},
+		&reminderTestGroupService{groups: []*models.Group{upstream(-1001), upstream(-1002)}},
+		time.Minute,
+	)
+	monitor.alertSender = func(ctx context.Context, group *models.Group, balance, minBalance float64) error {
+		reminded = append(reminded, group.TelegramID)
+		return nil
+	}
+
+	monitor.scanBalances(context.Background())
+	if len(reminded) != 1 || reminded[0] != -1001 {
+		t.Fatalf("expected only the low balance group to be reminded, got %v", reminded)
+	}
+
+	// 超过群间隔后再次扫描，但受每小时次数限制不再提醒
+	monitor.states[-1001].lastScan = time.Now().Add(-time.Hour / 2)
+	monitor.scanBalances(context.Background())
+	if len(reminded) != 1 {
+		t.Fatalf("expected hourly alert limit to throttle reminders, got %v", reminded)
+	}
+}
+
+func TestUpstreamBalanceMonitorDefersAlertDuringQuietHours(t *testing.T) {
+	now := time.Date(2024, 10, 26, 3, 0, 0, 0, quietHoursLocation)
+	alertCount := 0
+	monitor := &upstreamBalanceMonitor{
+		bot:    &Bot{nowFunc: func() time.Time { return now }},
+		states: make(map[int64]*balanceAlertState),
+		alertSender: func(ctx context.Context, group *models.Group, balance, minBalance float64) error {
+			alertCount++
+			return nil
+		},
+	}
+	group := &models.Group{
+		TelegramID: 1004,
+		Settings:   models.GroupSettings{QuietHours: models.QuietHoursNight},
+	}
+
+	monitor.evaluateAndAlert(context.Background(), group, 10, 100, 1, false)
+	if alertCount != 0 {
+		t.Fatalf("expected alert to be deferred during quiet hours, got %d", alertCount)
+	}
+	if state := monitor.states[group.TelegramID]; state.sentInWindow != 0 {
+		t.Fatalf("expected deferred alert not to count against limit, got %d", state.sentInWindow)
+	}
+
+	// 免打扰结束后立即提醒
+	now = time.Date(2024, 10, 26, 8, 0, 0, 0, quietHoursLocation)
+	monitor.evaluateAndAlert(context.Background(), group, 10, 100, 1, false)
+	if alertCount != 1 {
+		t.Fatalf("expected alert after quiet hours, got %d", alertCount)
+	}
+}
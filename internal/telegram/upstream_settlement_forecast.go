@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	sifangfeature "go_bot/internal/telegram/features/sifang"
+	"go_bot/internal/telegram/features/types"
+
+	"github.com/go-telegram/bot"
+	botModels "github.com/go-telegram/bot/models"
+)
+
+const settlementForecastCommand = "日结汇总"
+
+// handleSettlementForecast 处理"日结汇总 [日期]"命令：对所有上游群预览日结（不扣款），汇总扣减与低于阈值的群，用于预估补款需求
+func (b *Bot) handleSettlementForecast(ctx context.Context, botInstance *bot.Bot, update *botModels.Update) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	if !paymentservice.Available(b.paymentService) {
+		b.sendMessage(ctx, msg.Chat.ID, types.PaymentNotConfiguredText, msg.ID)
+		return
+	}
+	if b.upstreamScheduler == nil {
+		b.sendErrorMessage(ctx, msg.Chat.ID, "上游日结未启用（需开启每日账单推送）", msg.ID)
+		return
+	}
+
+	loc := b.upstreamScheduler.location
+	now := b.currentTime().In(loc)
+	target := previousBillingDate(now, loc)
+	if raw := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(msg.Text), settlementForecastCommand)); raw != "" {
+		parsed, err := sifangfeature.ParseSummaryDate(raw, now, settlementForecastCommand)
+		if err != nil {
+			b.sendErrorMessage(ctx, msg.Chat.ID, err.Error(), msg.ID)
+			return
+		}
+		if parsed.After(now) {
+			b.sendErrorMessage(ctx, msg.Chat.ID, "不能预览未来日期的日结", msg.ID)
+			return
+		}
+		target = parsed
+	}
+
+	report, err := b.upstreamScheduler.settleAll(ctx, target, true)
+	if err != nil {
+		logger.L().Errorf("Settlement forecast failed: target=%s err=%v", target.Format("2006-01-02"), err)
+		b.sendStorageError(ctx, msg.Chat.ID, err, "日结汇总失败，请稍后重试", msg.ID)
+		return
+	}
+
+	_, _ = b.sendLongMessage(ctx, msg.Chat.ID, formatSettlementForecast(report), botModels.ParseModeHTML, nil, msg.ID)
+}
+
+// formatSettlementForecast 生成全部上游群的日结预览汇总：总扣减、各群明细与日结后低于阈值的群
+func formatSettlementForecast(report *settlementRunReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📈 日结汇总预览（未实际扣款）- %s\n", report.TargetDate.Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("上游群：%d 个，预览成功 %d 个\n", report.Eligible, len(report.Previews)))
+
+	total := 0.0
+	var details, belowMin strings.Builder
+	lowCount := 0
+	for _, preview := range report.Previews {
+		total += preview.TotalDeduction
+		name := fmt.Sprintf("%s (%d)", html.EscapeString(report.Titles[preview.GroupID]), preview.GroupID)
+		details.WriteString(fmt.Sprintf("• %s：扣减 %s，余额 → %s\n", name, formatAmount(preview.TotalDeduction), formatAmount(preview.Balance)))
+		if len(preview.Errors) > 0 {
+			details.WriteString(fmt.Sprintf("   ⚠️ %d 个接口查询失败，扣减可能偏低\n", len(preview.Errors)))
+		}
+		if preview.BelowMin {
+			lowCount++
+			belowMin.WriteString(fmt.Sprintf("• %s：余额 → %s\n", name, formatAmount(preview.Balance)))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("预计总扣减：%s CNY\n", formatAmount(total)))
+
+	if details.Len() > 0 {
+		sb.WriteString("\n各群明细：\n")
+		sb.WriteString(details.String())
+	}
+	if lowCount > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠️ 日结后低于阈值（需补充余额）%d 个：\n", lowCount))
+		sb.WriteString(belowMin.String())
+	}
+	if len(report.Failures) > 0 {
+		sb.WriteString(fmt.Sprintf("\n预览失败 %d 个：\n", len(report.Failures)))
+		for _, failure := range report.Failures {
+			sb.WriteString("• " + html.EscapeString(failure) + "\n")
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
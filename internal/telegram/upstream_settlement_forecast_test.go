@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+type forecastTestBalanceService struct {
+	service.UpstreamBalanceService
+	results map[int64]*service.SettlementResult
+}
+
+func (s *forecastTestBalanceService) PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*service.SettlementResult, error) {
+	return s.results[groupID], nil
+}
+
+func TestFormatSettlementForecastConsolidatesGroups(t *testing.T) {
+	upstreamGroup := func(id int64, title string) *models.Group {
+		return &models.Group{
+			TelegramID: id,
+			Title:      title,
+			Tier:       models.GroupTierUpstream,
+			BotStatus:  models.BotStatusActive,
+			Settings:   models.GroupSettings{InterfaceBindings: []models.InterfaceBinding{{Name: "通道", ID: "1024"}}},
+		}
+	}
+
+	b := &Bot{
+		groupService: &autoLookupTestGroupService{groups: []*models.Group{upstreamGroup(-1001, "上游A"), upstreamGroup(-1002, "上游B")}},
+		balanceService: &forecastTestBalanceService{results: map[int64]*service.SettlementResult{
+			-1001: {GroupID: -1001, TotalDeduction: 120.5, Balance: 879.5, DryRun: true},
+			-1002: {GroupID: -1002, TotalDeduction: 300, Balance: -50, BelowMin: true, DryRun: true, Errors: []string{"接口 2048 查询失败"}},
+		}},
+	}
+	scheduler := newUpstreamSettlementScheduler(b)
+
+	report, err := scheduler.settleAll(context.Background(), time.Date(2024, 11, 20, 0, 0, 0, 0, scheduler.location), true)
+	if err != nil {
+		t.Fatalf("settleAll returned error: %v", err)
+	}
+
+	text := formatSettlementForecast(report)
+	for _, want := range []string{
+		"2024-11-20",
+		"上游群：2 个，预览成功 2 个",
+		"预计总扣减：420.50 CNY",
+		"上游A (-1001)：扣减 120.50，余额 → 879.50",
+		"上游B (-1002)：扣减 300.00，余额 → -50.00",
+		"1 个接口查询失败",
+		"日结后低于阈值（需补充余额）1 个",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in forecast:\n%s", want, text)
+		}
+	}
+	if strings.Count(text, "上游A") != 1 {
+		t.Fatalf("expected 上游A listed only in breakdown:\n%s", text)
+	}
+}
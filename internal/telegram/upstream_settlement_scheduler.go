@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,19 +12,29 @@ import (
 
 	"go_bot/internal/logger"
 	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
 )
 
+// defaultSettlementConcurrency 未配置时自动日结同时处理的上游群数量
+const defaultSettlementConcurrency = 8
+
 type upstreamSettlementScheduler struct {
-	bot      *Bot
-	cancel   context.CancelFunc
-	done     chan struct{}
-	location *time.Location
+	bot         *Bot
+	cancel      context.CancelFunc
+	done        chan struct{}
+	location    *time.Location
+	concurrency int // 同时处理的上游群数量上限
 }
 
 func newUpstreamSettlementScheduler(bot *Bot) *upstreamSettlementScheduler {
+	concurrency := bot.settlementWorkers
+	if concurrency <= 0 {
+		concurrency = defaultSettlementConcurrency
+	}
 	return &upstreamSettlementScheduler{
-		bot:      bot,
-		location: mustLoadChinaLocation(),
+		bot:         bot,
+		location:    mustLoadChinaLocation(),
+		concurrency: concurrency,
 	}
 }
 
@@ -55,9 +66,9 @@ func (s *upstreamSettlementScheduler) run(ctx context.Context) {
 	defer close(s.done)
 
 	for {
-		now := time.Now().In(s.location)
+		now := s.bot.currentTime().In(s.location)
 		next := nextDailyRun(now, s.location)
-		wait := time.Until(next)
+		wait := next.Sub(now)
 		if wait <= 0 {
 			wait = time.Second
 		}
@@ -79,31 +90,52 @@ func (s *upstreamSettlementScheduler) dispatch(parent context.Context) {
 		return
 	}
 
-	startTime := time.Now()
-	targetDate := previousBillingDate(time.Now().In(s.location), s.location)
+	targetDate := previousBillingDate(s.bot.currentTime().In(s.location), s.location)
 	runCtx, cancel := context.WithTimeout(parent, 3*time.Minute)
 	defer cancel()
 
-	groups, err := s.bot.groupService.ListActiveGroups(runCtx)
-	if err != nil {
+	if _, err := s.settleAll(runCtx, targetDate, false); err != nil {
 		logger.L().Errorf("Upstream settlement failed to list groups: %v", err)
-		return
+	}
+}
+
+// settlementRunReport 一次批量日结（或预览）的结果
+type settlementRunReport struct {
+	TargetDate time.Time
+	DryRun     bool
+	Eligible   int
+	Titles     map[int64]string // 参与日结的上游群名称
+	Previews   []*service.SettlementResult
+	Failures   []string
+}
+
+// settleAll 对所有符合条件的上游群执行日结；dryRun 时仅计算预览，不扣款也不发送消息
+func (s *upstreamSettlementScheduler) settleAll(ctx context.Context, targetDate time.Time, dryRun bool) (*settlementRunReport, error) {
+	startTime := time.Now()
+
+	groups, err := s.bot.groupService.ListActiveGroups(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	report := &settlementRunReport{TargetDate: targetDate, DryRun: dryRun}
 	eligible := filterEligibleUpstreamGroups(groups)
+	report.Eligible = len(eligible)
+	report.Titles = make(map[int64]string, len(eligible))
+	for _, group := range eligible {
+		report.Titles[group.TelegramID] = group.Title
+	}
 	if len(eligible) == 0 {
 		logger.L().Infof("Upstream settlement skipped: no eligible groups for %s", targetDate.Format("2006-01-02"))
-		return
+		return report, nil
 	}
 
-	logger.L().Infof("Upstream settlement started for %d groups, target_date=%s", len(eligible), targetDate.Format("2006-01-02"))
+	logger.L().Infof("Upstream settlement started for %d groups, target_date=%s dry_run=%v", len(eligible), targetDate.Format("2006-01-02"), dryRun)
 
-	const workerLimit = 8
 	var mu sync.Mutex
-	failures := make([]string, 0)
 
-	eg, egCtx := errgroup.WithContext(runCtx)
-	eg.SetLimit(workerLimit)
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(s.workerLimit())
 
 	for _, group := range eligible {
 		group := group
@@ -111,10 +143,22 @@ func (s *upstreamSettlementScheduler) dispatch(parent context.Context) {
 			settleCtx, cancelGroup := context.WithTimeout(egCtx, 20*time.Second)
 			defer cancelGroup()
 
-			operationID := fmt.Sprintf("auto-settle:%d:%s", group.TelegramID, targetDate.Format("2006-01-02"))
+			if dryRun {
+				preview, err := s.bot.balanceService.PreviewSettlement(settleCtx, group.TelegramID, targetDate)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					report.Failures = append(report.Failures, fmt.Sprintf("%d(%s): %v", group.TelegramID, group.Title, err))
+					return nil
+				}
+				report.Previews = append(report.Previews, preview)
+				return nil
+			}
+
+			operationID := service.SettlementOperationID(group.TelegramID, targetDate)
 			if err := s.settleWithRetry(settleCtx, group, targetDate, operationID); err != nil {
 				mu.Lock()
-				failures = append(failures, fmt.Sprintf("%d(%s): %v", group.TelegramID, group.Title, err))
+				report.Failures = append(report.Failures, fmt.Sprintf("%d(%s): %v", group.TelegramID, group.Title, err))
 				mu.Unlock()
 			}
 			return nil
@@ -123,12 +167,26 @@ func (s *upstreamSettlementScheduler) dispatch(parent context.Context) {
 
 	_ = eg.Wait()
 
+	sort.Slice(report.Previews, func(i, j int) bool {
+		return report.Previews[i].GroupID < report.Previews[j].GroupID
+	})
+
 	duration := time.Since(startTime)
-	logger.L().Infof("Upstream settlement completed for %d groups (failures=%d) duration=%s", len(eligible), len(failures), duration.Round(time.Millisecond))
+	logger.L().Infof("Upstream settlement completed for %d groups (failures=%d dry_run=%v) duration=%s", len(eligible), len(report.Failures), dryRun, duration.Round(time.Millisecond))
+
+	if len(report.Failures) > 0 {
+		logger.L().Warnf("Upstream settlement failures: %v", report.Failures)
+	}
+
+	return report, nil
+}
 
-	if len(failures) > 0 {
-		logger.L().Warnf("Upstream settlement failures: %v", failures)
+// workerLimit 返回同时处理的上游群数量上限
+func (s *upstreamSettlementScheduler) workerLimit() int {
+	if s.concurrency <= 0 {
+		return defaultSettlementConcurrency
 	}
+	return s.concurrency
 }
 
 func (s *upstreamSettlementScheduler) settleWithRetry(ctx context.Context, group *models.Group, targetDate time.Time, operationID string) error {
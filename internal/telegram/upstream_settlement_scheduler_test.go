@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_bot/internal/telegram/models"
+	"go_bot/internal/telegram/service"
+)
+
+type settlementTestBalanceService struct {
+	service.UpstreamBalanceService
+
+	mu           sync.Mutex
+	settleCalls  int
+	previewCalls int
+}
+
+func (s *settlementTestBalanceService) SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*service.SettlementResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settleCalls++
+	return &service.SettlementResult{GroupID: groupID}, nil
+}
+
+func (s *settlementTestBalanceService) PreviewSettlement(ctx context.Context, groupID int64, targetDate time.Time) (*service.SettlementResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.previewCalls++
+	return &service.SettlementResult{
+		GroupID:        groupID,
+		TargetDate:     targetDate,
+		TotalDeduction: 10,
+		Balance:        90,
+		DryRun:         true,
+	}, nil
+}
+
+// concurrencyTestBalanceService 记录同时执行的 SettleDaily 数量与各群使用的操作 ID
+type concurrencyTestBalanceService struct {
+	service.UpstreamBalanceService
+
+	mu           sync.Mutex
+	inFlight     int
+	maxInFlight  int
+	operationIDs map[int64][]string
+}
+
+func (s *concurrencyTestBalanceService) SettleDaily(ctx context.Context, groupID int64, targetDate time.Time, operatorID int64, operationID string) (*service.SettlementResult, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.operationIDs[groupID] = append(s.operationIDs[groupID], operationID)
+	s.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return &service.SettlementResult{GroupID: groupID, Report: "日结完成"}, nil
+}
+
+func upstreamGroup(id int64) *models.Group {
+	return &models.Group{
+		TelegramID: id,
+		Tier:       models.GroupTierUpstream,
+		BotStatus:  models.BotStatusActive,
+		Settings: models.GroupSettings{
+			InterfaceBindings: []models.InterfaceBinding{{Name: "通道", ID: "1024", Rate: "5%"}},
+		},
+	}
+}
+
+func TestUpstreamSettlementSchedulerCapsConcurrency(t *testing.T) {
+	botInstance, api := newTestTelegramBot(t)
+
+	groups := make([]*models.Group, 0, 6)
+	for i := int64(1); i <= 6; i++ {
+		groups = append(groups, upstreamGroup(-1000-i))
+	}
+	balanceSvc := &concurrencyTestBalanceService{operationIDs: make(map[int64][]string)}
+	b := &Bot{
+		bot:               botInstance,
+		groupService:      &autoLookupTestGroupService{groups: groups},
+		balanceService:    balanceSvc,
+		settlementWorkers: 2,
+	}
+	scheduler := newUpstreamSettlementScheduler(b)
+
+	target := time.Date(2024, 11, 20, 0, 0, 0, 0, scheduler.location)
+	report, err := scheduler.settleAll(context.Background(), target, false)
+	if err != nil {
+		t.Fatalf("settleAll returned error: %v", err)
+	}
+	if len(report.Failures) != 0 {
+		t.Fatalf("unexpected failures: %v", report.Failures)
+	}
+	if balanceSvc.maxInFlight > 2 {
+		t.Fatalf("expected at most 2 concurrent settlements, got %d", balanceSvc.maxInFlight)
+	}
+	if len(balanceSvc.operationIDs) != len(groups) {
+		t.Fatalf("expected %d groups settled, got %d", len(groups), len(balanceSvc.operationIDs))
+	}
+	for _, group := range groups {
+		want := fmt.Sprintf("settle:%d:2024-11-20", group.TelegramID)
+		ids := balanceSvc.operationIDs[group.TelegramID]
+		if len(ids) != 1 || ids[0] != want {
+			t.Fatalf("group %d: expected single settlement with operation id %q, got %v", group.TelegramID, want, ids)
+		}
+	}
+	if sent := len(api.Requests("sendMessage")); sent != len(groups) {
+		t.Fatalf("expected %d settlement reports sent, got %d", len(groups), sent)
+	}
+}
+
+func TestUpstreamSettlementSchedulerDefaultsConcurrency(t *testing.T) {
+	scheduler := newUpstreamSettlementScheduler(&Bot{})
+	if got := scheduler.workerLimit(); got != defaultSettlementConcurrency {
+		t.Fatalf("expected default concurrency %d, got %d", defaultSettlementConcurrency, got)
+	}
+}
+
+func TestUpstreamSettlementSchedulerDryRunPerformsNoWrites(t *testing.T) {
+	balanceSvc := &settlementTestBalanceService{}
+	b := &Bot{
+		groupService: &autoLookupTestGroupService{
+			groups: []*models.Group{
+				upstreamGroup(-1002),
+				upstreamGroup(-1001),
+				{TelegramID: -2001, Tier: models.GroupTierMerchant, BotStatus: models.BotStatusActive},
+			},
+		},
+		balanceService: balanceSvc,
+	}
+	scheduler := newUpstreamSettlementScheduler(b)
+
+	target := time.Date(2024, 11, 20, 0, 0, 0, 0, scheduler.location)
+	report, err := scheduler.settleAll(context.Background(), target, true)
+	if err != nil {
+		t.Fatalf("settleAll returned error: %v", err)
+	}
+
+	if balanceSvc.settleCalls != 0 {
+		t.Fatalf("expected no SettleDaily calls in dry-run, got %d", balanceSvc.settleCalls)
+	}
+	if report.Eligible != 2 || len(report.Previews) != 2 || balanceSvc.previewCalls != 2 {
+		t.Fatalf("unexpected preview count: eligible=%d previews=%d calls=%d", report.Eligible, len(report.Previews), balanceSvc.previewCalls)
+	}
+	if report.Previews[0].GroupID != -1002 || report.Previews[1].GroupID != -1001 {
+		t.Fatalf("expected previews sorted by group id, got %d, %d", report.Previews[0].GroupID, report.Previews[1].GroupID)
+	}
+
+	text := formatSettlementForecast(report)
+	if !strings.Contains(text, "上游群：2 个") || !strings.Contains(text, "预计总扣减：20.00 CNY") {
+		t.Fatalf("unexpected report text: %s", text)
+	}
+}
@@ -0,0 +1,347 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_bot/internal/logger"
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+)
+
+const (
+	withdrawReconcilePageSize = 100
+	withdrawReconcileMaxPages = 20
+	// withdrawReconcileMatchWindow 无提现单号时按金额匹配允许的时间差
+	withdrawReconcileMatchWindow = 10 * time.Minute
+)
+
+// withdrawReconcileResult 单个商户的下发对账结果
+type withdrawReconcileResult struct {
+	MerchantID         int64
+	Matched            int
+	MissingUpstream    []*models.SendMoneyRecord  // 本地有下发记录，但上游提现列表中不存在
+	UnexpectedUpstream []*paymentservice.Withdraw // 上游提现列表存在，但本地无对应下发记录
+}
+
+func (r *withdrawReconcileResult) hasMismatch() bool {
+	return r != nil && (len(r.MissingUpstream) > 0 || len(r.UnexpectedUpstream) > 0)
+}
+
+// reconcileWithdrawals 比对本地下发记录与上游提现列表
+// 优先按提现单号/订单号匹配；无单号的记录（如超时未返回）按金额与时间窗口匹配；上游明确拒绝的记录不参与比对
+func reconcileWithdrawals(merchantID int64, records []*models.SendMoneyRecord, withdrawals []*paymentservice.Withdraw, location *time.Location) *withdrawReconcileResult {
+	result := &withdrawReconcileResult{MerchantID: merchantID}
+	used := make([]bool, len(withdrawals))
+
+	var unnumbered []*models.SendMoneyRecord
+	for _, record := range records {
+		if record == nil || record.Status == models.SendMoneyStatusFailed {
+			continue
+		}
+		withdrawNo := strings.TrimSpace(record.WithdrawNo)
+		orderNo := strings.TrimSpace(record.OrderNo)
+		if withdrawNo == "" && orderNo == "" {
+			unnumbered = append(unnumbered, record)
+			continue
+		}
+
+		matched := false
+		for i, withdraw := range withdrawals {
+			if used[i] || withdraw == nil {
+				continue
+			}
+			if (withdrawNo != "" && strings.TrimSpace(withdraw.WithdrawNo) == withdrawNo) ||
+				(orderNo != "" && strings.TrimSpace(withdraw.OrderNo) == orderNo) {
+				used[i] = true
+				matched = true
+				break
+			}
+		}
+		if matched {
+			result.Matched++
+		} else {
+			result.MissingUpstream = append(result.MissingUpstream, record)
+		}
+	}
+
+	for _, record := range unnumbered {
+		matched := false
+		for i, withdraw := range withdrawals {
+			if used[i] || withdraw == nil {
+				continue
+			}
+			if !sameAmountInCents(record.Amount, withdraw.Amount) {
+				continue
+			}
+			if createdAt, ok := parseWithdrawTime(withdraw.CreatedAt, location); ok {
+				diff := createdAt.Sub(record.CreatedAt)
+				if diff < -withdrawReconcileMatchWindow || diff > withdrawReconcileMatchWindow {
+					continue
+				}
+			}
+			used[i] = true
+			matched = true
+			break
+		}
+		if matched {
+			result.Matched++
+		} else {
+			result.MissingUpstream = append(result.MissingUpstream, record)
+		}
+	}
+
+	for i, withdraw := range withdrawals {
+		if !used[i] && withdraw != nil {
+			result.UnexpectedUpstream = append(result.UnexpectedUpstream, withdraw)
+		}
+	}
+
+	return result
+}
+
+func sameAmountInCents(local float64, upstream string) bool {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(upstream), ",", ""), 64)
+	if err != nil {
+		return false
+	}
+	return math.Round(local*100) == math.Round(value*100)
+}
+
+func parseWithdrawTime(value string, location *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, location)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+type withdrawReconcileScheduler struct {
+	bot      *Bot
+	cancel   context.CancelFunc
+	done     chan struct{}
+	location *time.Location
+}
+
+func newWithdrawReconcileScheduler(bot *Bot) *withdrawReconcileScheduler {
+	return &withdrawReconcileScheduler{
+		bot:      bot,
+		location: mustLoadChinaLocation(),
+	}
+}
+
+func (s *withdrawReconcileScheduler) start() {
+	if s == nil || s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+	logger.L().Info("Withdraw reconcile scheduler started")
+}
+
+func (s *withdrawReconcileScheduler) stop() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+	s.done = nil
+	logger.L().Info("Withdraw reconcile scheduler stopped")
+}
+
+func (s *withdrawReconcileScheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		now := time.Now().In(s.location)
+		next := nextWithdrawReconcileRun(now, s.location)
+		wait := time.Until(next)
+		if wait <= 0 {
+			wait = time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.dispatch(ctx)
+		}
+	}
+}
+
+// nextWithdrawReconcileRun 每日 00:20 对账前一天，给上游提现状态留出落库时间
+func nextWithdrawReconcileRun(now time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), 0, 20, 0, 0, location)
+	if !next.After(local) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+func (s *withdrawReconcileScheduler) dispatch(parent context.Context) {
+	if parent.Err() != nil {
+		return
+	}
+
+	targetDate := previousBillingDate(time.Now(), s.location)
+	runCtx, cancel := context.WithTimeout(parent, 5*time.Minute)
+	defer cancel()
+
+	results, failures := s.reconcileDate(runCtx, targetDate)
+
+	mismatched := 0
+	for _, result := range results {
+		if result.hasMismatch() {
+			mismatched++
+		}
+	}
+	logger.L().Infof("Withdraw reconcile completed: date=%s merchants=%d mismatched=%d failures=%d",
+		targetDate.Format("2006-01-02"), len(results), mismatched, len(failures))
+
+	if mismatched == 0 && len(failures) == 0 {
+		return
+	}
+	s.notifyOwners(parent, buildWithdrawReconcileReport(targetDate, results, failures))
+}
+
+// reconcileDate 对指定日期执行对账，返回各商户结果及查询失败信息
+func (s *withdrawReconcileScheduler) reconcileDate(ctx context.Context, targetDate time.Time) ([]*withdrawReconcileResult, []string) {
+	start := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, s.location)
+	end := start.Add(24 * time.Hour)
+
+	records, err := s.bot.sendMoneyRepo.ListByDateRange(ctx, start, end)
+	if err != nil {
+		logger.L().Errorf("Withdraw reconcile failed to load local records: %v", err)
+		return nil, []string{fmt.Sprintf("读取本地下发记录失败: %v", err)}
+	}
+
+	recordsByMerchant := make(map[int64][]*models.SendMoneyRecord)
+	for _, record := range records {
+		recordsByMerchant[record.MerchantID] = append(recordsByMerchant[record.MerchantID], record)
+	}
+
+	// 对账范围：本地有下发记录的商户 + 活跃商户群绑定的商户
+	merchantSet := make(map[int64]bool, len(recordsByMerchant))
+	for merchantID := range recordsByMerchant {
+		merchantSet[merchantID] = true
+	}
+	var failures []string
+	if groups, err := s.bot.groupService.ListActiveGroups(ctx); err != nil {
+		logger.L().Warnf("Withdraw reconcile failed to list groups: %v", err)
+		failures = append(failures, fmt.Sprintf("获取群组失败: %v", err))
+	} else {
+		for _, group := range filterEligibleMerchantGroups(groups) {
+			for _, merchantID := range models.GroupMerchantIDs(group.Settings) {
+				merchantSet[merchantID] = true
+			}
+		}
+	}
+
+	merchants := make([]int64, 0, len(merchantSet))
+	for merchantID := range merchantSet {
+		merchants = append(merchants, merchantID)
+	}
+	sort.Slice(merchants, func(i, j int) bool { return merchants[i] < merchants[j] })
+
+	results := make([]*withdrawReconcileResult, 0, len(merchants))
+	for _, merchantID := range merchants {
+		withdrawals, err := s.fetchWithdrawals(ctx, merchantID, start, end.Add(-time.Second))
+		if err != nil {
+			logger.L().Errorf("Withdraw reconcile failed to fetch upstream list: merchant_id=%d err=%v", merchantID, err)
+			failures = append(failures, fmt.Sprintf("merchant_id=%d: 查询上游提现列表失败 (%v)", merchantID, err))
+			continue
+		}
+		results = append(results, reconcileWithdrawals(merchantID, recordsByMerchant[merchantID], withdrawals, s.location))
+	}
+	return results, failures
+}
+
+// fetchWithdrawals 分页拉取商户在时间范围内的全部上游提现记录
+func (s *withdrawReconcileScheduler) fetchWithdrawals(ctx context.Context, merchantID int64, start, end time.Time) ([]*paymentservice.Withdraw, error) {
+	var items []*paymentservice.Withdraw
+	for page := 1; page <= withdrawReconcileMaxPages; page++ {
+		list, err := s.bot.paymentService.GetWithdrawList(ctx, merchantID, start, end, page, withdrawReconcilePageSize)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			break
+		}
+		items = append(items, list.Items...)
+		if len(list.Items) < withdrawReconcilePageSize || (list.TotalPages > 0 && page >= list.TotalPages) {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (s *withdrawReconcileScheduler) notifyOwners(parent context.Context, report string) {
+	if len(s.bot.ownerIDs) == 0 || parent.Err() != nil {
+		return
+	}
+
+	notifyCtx, cancel := context.WithTimeout(parent, 30*time.Second)
+	defer cancel()
+
+	for _, ownerID := range s.bot.ownerIDs {
+		if err := s.bot.sendDirectMessage(notifyCtx, ownerID, report); err != nil {
+			logger.L().Errorf("Withdraw reconcile failed to notify owner %d: %v", ownerID, err)
+		}
+	}
+}
+
+func buildWithdrawReconcileReport(targetDate time.Time, results []*withdrawReconcileResult, failures []string) string {
+	builder := &strings.Builder{}
+	builder.WriteString("🧾 下发对账报告\n")
+	builder.WriteString(fmt.Sprintf("日期：%s\n", targetDate.Format("2006-01-02")))
+
+	for _, result := range results {
+		if !result.hasMismatch() {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("\n商户 <code>%d</code>（已匹配 %d 笔）\n", result.MerchantID, result.Matched))
+		for _, record := range result.MissingUpstream {
+			builder.WriteString(fmt.Sprintf("• 本地有记录、上游缺失：%s 元，%s，状态 %s",
+				strconv.FormatFloat(record.Amount, 'f', -1, 64),
+				record.CreatedAt.In(targetDate.Location()).Format("15:04:05"),
+				escapeHTML(record.Status)))
+			if record.WithdrawNo != "" {
+				builder.WriteString(fmt.Sprintf("，单号 <code>%s</code>", escapeHTML(record.WithdrawNo)))
+			}
+			builder.WriteString("\n")
+		}
+		for _, withdraw := range result.UnexpectedUpstream {
+			builder.WriteString(fmt.Sprintf("• 上游存在、本地无记录：%s 元，%s，单号 <code>%s</code>\n",
+				escapeHTML(strings.TrimSpace(withdraw.Amount)),
+				escapeHTML(strings.TrimSpace(withdraw.CreatedAt)),
+				escapeHTML(strings.TrimSpace(withdraw.WithdrawNo))))
+		}
+	}
+
+	if len(failures) > 0 {
+		builder.WriteString("\n查询失败：\n")
+		for _, failure := range failures {
+			builder.WriteString("• " + escapeHTML(failure) + "\n")
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
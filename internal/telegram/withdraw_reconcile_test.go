@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	paymentservice "go_bot/internal/payment/service"
+	"go_bot/internal/telegram/models"
+)
+
+func TestReconcileWithdrawalsOverlappingSets(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	sentAt := time.Date(2024, 10, 26, 10, 0, 0, 0, loc)
+
+	records := []*models.SendMoneyRecord{
+		{MerchantID: 1, Amount: 100, Status: models.SendMoneyStatusSuccess, WithdrawNo: "W-1", CreatedAt: sentAt},
+		// 超时未返回单号，但上游实际已处理
+		{MerchantID: 1, Amount: 200.5, Status: models.SendMoneyStatusPending, CreatedAt: sentAt.Add(time.Hour)},
+		// 上游明确拒绝，不参与比对
+		{MerchantID: 1, Amount: 999, Status: models.SendMoneyStatusFailed, CreatedAt: sentAt},
+		// 本地成功但上游列表缺失
+		{MerchantID: 1, Amount: 300, Status: models.SendMoneyStatusSuccess, WithdrawNo: "W-LOST", CreatedAt: sentAt},
+	}
+	withdrawals := []*paymentservice.Withdraw{
+		{WithdrawNo: "W-1", Amount: "100.00", CreatedAt: "2024-10-26 10:00:01"},
+		{WithdrawNo: "W-2", Amount: "200.50", CreatedAt: "2024-10-26 11:00:30"},
+		{WithdrawNo: "W-EXTRA", Amount: "50", CreatedAt: "2024-10-26 12:00:00"},
+	}
+
+	result := reconcileWithdrawals(1, records, withdrawals, loc)
+	if result.Matched != 2 {
+		t.Fatalf("expected 2 matched, got %d", result.Matched)
+	}
+	if len(result.MissingUpstream) != 1 || result.MissingUpstream[0].WithdrawNo != "W-LOST" {
+		t.Fatalf("expected W-LOST missing upstream, got %+v", result.MissingUpstream)
+	}
+	if len(result.UnexpectedUpstream) != 1 || result.UnexpectedUpstream[0].WithdrawNo != "W-EXTRA" {
+		t.Fatalf("expected W-EXTRA unexpected upstream, got %+v", result.UnexpectedUpstream)
+	}
+	if !result.hasMismatch() {
+		t.Fatalf("expected mismatch to be flagged")
+	}
+}
+
+func TestReconcileWithdrawalsDisjointSets(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	sentAt := time.Date(2024, 10, 26, 10, 0, 0, 0, loc)
+
+	records := []*models.SendMoneyRecord{
+		{MerchantID: 1, Amount: 100, Status: models.SendMoneyStatusPending, CreatedAt: sentAt},
+	}
+	withdrawals := []*paymentservice.Withdraw{
+		// 金额相同但超出时间窗口
+		{WithdrawNo: "W-LATE", Amount: "100", CreatedAt: "2024-10-26 15:00:00"},
+		{WithdrawNo: "W-OTHER", Amount: "80", CreatedAt: "2024-10-26 10:00:00"},
+	}
+
+	result := reconcileWithdrawals(1, records, withdrawals, loc)
+	if result.Matched != 0 || len(result.MissingUpstream) != 1 || len(result.UnexpectedUpstream) != 2 {
+		t.Fatalf("expected nothing matched, got matched=%d missing=%d unexpected=%d",
+			result.Matched, len(result.MissingUpstream), len(result.UnexpectedUpstream))
+	}
+
+	clean := reconcileWithdrawals(2, nil, nil, loc)
+	if clean.hasMismatch() {
+		t.Fatalf("expected empty sets to reconcile cleanly")
+	}
+
+	report := buildWithdrawReconcileReport(sentAt, []*withdrawReconcileResult{result, clean}, []string{"merchant_id=3: <timeout>"})
+	for _, want := range []string{"商户 <code>1</code>", "上游存在、本地无记录：100 元", "本地有记录、上游缺失：100 元，10:00:00，状态 pending", "&lt;timeout&gt;"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "商户 <code>2</code>") {
+		t.Fatalf("clean merchant should not be reported:\n%s", report)
+	}
+}
+
+func TestNextWithdrawReconcileRun(t *testing.T) {
+	loc := mustLoadChinaLocation()
+	before := time.Date(2024, 10, 26, 0, 10, 0, 0, loc)
+	if got := nextWithdrawReconcileRun(before, loc); !got.Equal(time.Date(2024, 10, 26, 0, 20, 0, 0, loc)) {
+		t.Fatalf("unexpected next run: %s", got)
+	}
+	after := time.Date(2024, 10, 26, 9, 0, 0, 0, loc)
+	if got := nextWithdrawReconcileRun(after, loc); !got.Equal(time.Date(2024, 10, 27, 0, 20, 0, 0, loc)) {
+		t.Fatalf("unexpected next run: %s", got)
+	}
+}